@@ -0,0 +1,111 @@
+package agent
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	"servicetelemetry/core"
+)
+
+// 小助手回复格式
+const (
+	FormatPlain    = "plain"    // 纯文本，不含 Markdown 标记（默认）
+	FormatMarkdown = "markdown" // Markdown：小标题与列表
+	FormatJSON     = "json"     // 结构化 JSON，分区展示正常、异常、证书与建议
+)
+
+// ValidFormat 判断回复格式是否合法，空字符串表示使用配置的默认格式
+func ValidFormat(format string) bool {
+	switch format {
+	case "", FormatPlain, FormatMarkdown, FormatJSON:
+		return true
+	}
+	return false
+}
+
+// formatInstruction 不同回复格式对模型的排版要求
+func formatInstruction(format string) string {
+	if format == FormatMarkdown {
+		return "使用 Markdown 排版：以小标题区分正常服务、异常服务与证书问题，条目使用列表。"
+	}
+	return "只输出纯文本，不使用任何 Markdown 标记（如 #、*、-、`）。"
+}
+
+// StructuredSummary 结构化监控总结（format=json）
+// 各分区由检查结果直接统计（每个目标取最新一次结果），模型只生成概述与建议，分区内容不依赖模型输出
+type StructuredSummary struct {
+	Overview       string          `json:"overview"`       // 概述
+	Healthy        []string        `json:"healthy"`        // 正常目标
+	Failing        []FailingTarget `json:"failing"`        // 异常目标
+	Certs          []CertIssue     `json:"certs"`          // 证书已过期或即将过期的目标
+	Recommendation string          `json:"recommendation"` // 建议的下一步动作
+}
+
+// FailingTarget 异常目标及最新一次失败原因
+type FailingTarget struct {
+	Target    string    `json:"target"`
+	ErrorType string    `json:"errorType"`
+	ErrorMsg  string    `json:"errorMsg"`
+	CheckedAt time.Time `json:"checkedAt"`
+}
+
+// CertIssue 证书问题
+type CertIssue struct {
+	Target   string `json:"target"`
+	DaysLeft int    `json:"daysLeft"` // 剩余天数，已过期为负数
+	Expiry   string `json:"expiry"`   // 证书状态描述，如「还有3天过期」
+}
+
+// certIssue 判断证书是否已过期或即将过期（剩余天数低于 core.CertWarnDays）
+func certIssue(r *core.MonitorResult) bool {
+	days, ok := r.CertDaysLeft()
+	return ok && days < core.CertWarnDays
+}
+
+// summarySections 按目标取最新一次结果，统计正常、异常与证书问题分区（按目标地址排序）
+func summarySections(results []*core.MonitorResult) *StructuredSummary {
+	latest := make(map[string]*core.MonitorResult)
+	for _, r := range results {
+		if cur, ok := latest[r.TargetURL]; !ok || r.CheckedAt.After(cur.CheckedAt) {
+			latest[r.TargetURL] = r
+		}
+	}
+	targets := make([]string, 0, len(latest))
+	for t := range latest {
+		targets = append(targets, t)
+	}
+	sort.Strings(targets)
+
+	s := &StructuredSummary{Healthy: []string{}, Failing: []FailingTarget{}, Certs: []CertIssue{}}
+	for _, t := range targets {
+		r := latest[t]
+		if r.Status == "failed" {
+			s.Failing = append(s.Failing, FailingTarget{Target: t, ErrorType: r.ErrorType, ErrorMsg: r.ErrorMsg, CheckedAt: r.CheckedAt})
+		} else {
+			s.Healthy = append(s.Healthy, t)
+		}
+		if certIssue(r) {
+			days, _ := r.CertDaysLeft()
+			s.Certs = append(s.Certs, CertIssue{Target: t, DaysLeft: days, Expiry: r.SSLCertExpiry})
+		}
+	}
+	return s
+}
+
+// parseSummaryJSON 解析模型返回的概述与建议；模型未按要求输出 JSON 时整段作为概述
+func parseSummaryJSON(text string, s *StructuredSummary) {
+	text = strings.TrimSpace(text)
+	// 部分模型会用代码块包裹 JSON
+	text = strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(text, "```json"), "```"), "```")
+	var out struct {
+		Overview       string `json:"overview"`
+		Recommendation string `json:"recommendation"`
+	}
+	if err := json.Unmarshal([]byte(text), &out); err != nil || out.Overview == "" {
+		s.Overview = sanitizeReply(text)
+		return
+	}
+	s.Overview, s.Recommendation = sanitizeReply(out.Overview), sanitizeReply(out.Recommendation)
+}
@@ -0,0 +1,142 @@
+package agent
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"servicetelemetry/config"
+)
+
+// 内置意图解析器名称
+const (
+	IntentParserKeyword = "keyword" // 关键词规则（默认），按提问语言选择中文或英文关键词
+	IntentParserEnglish = "english" // 只使用英文关键词规则
+	IntentParserAuto    = "auto"    // 与 keyword 相同，保留以兼容旧配置
+)
+
+// IntentParser 意图解析器，将用户的自由文本查询转换为查询意图
+// 实现需可并发调用；返回 nil 时按默认时间范围检索全部数据
+type IntentParser interface {
+	Parse(query string, defaultTimeRange int) *QueryIntent
+}
+
+// IntentParserFunc 函数形式的意图解析器
+type IntentParserFunc func(query string, defaultTimeRange int) *QueryIntent
+
+// Parse 调用函数本身
+func (f IntentParserFunc) Parse(query string, defaultTimeRange int) *QueryIntent {
+	return f(query, defaultTimeRange)
+}
+
+// IntentParserFactory 根据小助手配置创建意图解析器，配置不合法时返回错误
+// 自定义解析器的专有参数通过 agent.intentOptions 传入
+type IntentParserFactory func(cfg *config.AgentConfig) (IntentParser, error)
+
+var (
+	intentMu      sync.RWMutex
+	intentParsers = map[string]IntentParserFactory{}
+)
+
+func init() {
+	RegisterIntentParser(IntentParserKeyword, func(cfg *config.AgentConfig) (IntentParser, error) {
+		return withAliases(IntentParserFunc(ParseQueryIntent), cfg.ServiceAliases), nil
+	})
+	RegisterIntentParser(IntentParserEnglish, func(cfg *config.AgentConfig) (IntentParser, error) {
+		return withAliases(IntentParserFunc(ParseEnglishQueryIntent), cfg.ServiceAliases), nil
+	})
+	RegisterIntentParser(IntentParserAuto, func(cfg *config.AgentConfig) (IntentParser, error) {
+		return withAliases(IntentParserFunc(ParseQueryIntent), cfg.ServiceAliases), nil
+	})
+}
+
+// RegisterIntentParser 注册意图解析器，通过配置 agent.intentParser 按名称选用
+// 应在程序启动时（如 init 函数中）调用；名称为空、工厂为 nil 或名称重复时 panic
+func RegisterIntentParser(name string, factory IntentParserFactory) {
+	intentMu.Lock()
+	defer intentMu.Unlock()
+	if name == "" || factory == nil {
+		panic("agent: 意图解析器名称与工厂函数不能为空")
+	}
+	if _, ok := intentParsers[name]; ok {
+		panic("agent: 重复注册意图解析器 " + name)
+	}
+	intentParsers[name] = factory
+}
+
+// IntentParsers 返回已注册的意图解析器名称（按名称排序）
+func IntentParsers() []string {
+	intentMu.RLock()
+	defer intentMu.RUnlock()
+	names := make([]string, 0, len(intentParsers))
+	for name := range intentParsers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewIntentParser 按配置 agent.intentParser 创建意图解析器，未配置时使用 keyword
+func NewIntentParser(cfg *config.AgentConfig) (IntentParser, error) {
+	name := cfg.IntentParser
+	if name == "" {
+		name = IntentParserKeyword
+	}
+	intentMu.RLock()
+	factory, ok := intentParsers[name]
+	intentMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("未注册的意图解析器：%s，可选 %s", name, strings.Join(IntentParsers(), " / "))
+	}
+	parser, err := factory(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("创建意图解析器 %s 失败：%w", name, err)
+	}
+	return parser, nil
+}
+
+// withAliases 在解析结果上追加服务别名对应的目标关键词
+// aliases：别名 → 目标地址关键词，如 "支付" → "pay.example.com"，别名匹配不区分大小写
+func withAliases(parser IntentParser, aliases map[string]string) IntentParser {
+	if len(aliases) == 0 {
+		return parser
+	}
+	return IntentParserFunc(func(query string, defaultTimeRange int) *QueryIntent {
+		intent := parser.Parse(query, defaultTimeRange)
+		if intent == nil {
+			return nil
+		}
+		lowerQuery := strings.ToLower(query)
+		var matched []string
+		for alias, keyword := range aliases {
+			if alias == "" || keyword == "" || !strings.Contains(lowerQuery, strings.ToLower(alias)) {
+				continue
+			}
+			if !containsString(matched, keyword) {
+				matched = append(matched, keyword)
+			}
+		}
+		// 别名比内置规则更具体，排在前面优先用于检索；别名遍历顺序不固定，排序后检索条件稳定
+		sort.Strings(matched)
+		for _, kw := range intent.TargetKeywords {
+			if !containsString(matched, kw) {
+				matched = append(matched, kw)
+			}
+		}
+		if matched != nil {
+			intent.TargetKeywords = matched
+		}
+		return intent
+	})
+}
+
+// containsString 判断切片中是否包含指定字符串
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
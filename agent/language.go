@@ -0,0 +1,53 @@
+package agent
+
+import (
+	"unicode"
+)
+
+// 小助手识别的提问语言
+const (
+	LangChinese = "zh" // 中文（默认）
+	LangEnglish = "en" // 英文
+)
+
+// DetectLanguage 识别提问使用的语言：按汉字与英文单词的数量判断，汉字每两个计为一个词
+// 中文问题中夹带的英文术语（如「github的ssl证书」）与英文问题中夹带的中文服务名（如 "is 支付 down"）不影响判断；
+// 既没有汉字也没有英文单词（如只有数字）时返回中文
+func DetectLanguage(text string) string {
+	han, words := 0, 0
+	inWord := false
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			han++
+			inWord = false
+		case r < unicode.MaxASCII && unicode.IsLetter(r):
+			if !inWord {
+				words++
+			}
+			inWord = true
+		default:
+			inWord = false
+		}
+	}
+	if words > 0 && words*2 > han {
+		return LangEnglish
+	}
+	return LangChinese
+}
+
+// languageInstruction 要求模型使用与提问相同的语言回答
+func languageInstruction(lang string) string {
+	if lang == LangEnglish {
+		return "The user asked in English: answer in English."
+	}
+	return "使用中文回答。"
+}
+
+// localized 按提问语言选择提示语
+func localized(lang, zh, en string) string {
+	if lang == LangEnglish {
+		return en
+	}
+	return zh
+}
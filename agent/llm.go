@@ -0,0 +1,188 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// ErrAIUnavailable AI 服务暂不可用：重试与备用模型均失败，或熔断期间直接拒绝
+// 返回该错误时方法同时返回可直接展示给用户的降级回复
+var ErrAIUnavailable = errors.New("AI服务暂不可用")
+
+// 熔断器状态
+const (
+	BreakerClosed = "closed" // 正常调用
+	BreakerOpen   = "open"   // 连续失败达到阈值，冷却期内不调用模型
+)
+
+// AIStatus AI 服务状态，供状态接口展示
+type AIStatus struct {
+	Enabled       bool       `json:"enabled"`                 // 是否开启 AI 功能
+	Model         string     `json:"model,omitempty"`         // 主模型
+	FallbackModel string     `json:"fallbackModel,omitempty"` // 备用模型
+	Breaker       string     `json:"breaker"`                 // 熔断器状态：closed / open
+	Failures      int        `json:"failures"`                // 连续失败次数
+	OpenUntil     *time.Time `json:"openUntil,omitempty"`     // 熔断结束时间
+	LastError     string     `json:"lastError,omitempty"`     // 最近一次失败原因
+}
+
+// llmBreaker 模型调用熔断器：连续失败达到阈值后在冷却期内直接拒绝调用，冷却结束后放行一次试探调用
+type llmBreaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+	lastError string
+	probing   bool // 冷却结束后的试探调用进行中，其余调用继续拒绝
+}
+
+// allow 判断是否允许调用模型，拒绝时返回熔断剩余时间
+func (b *llmBreaker) allow() (bool, time.Duration) {
+	if b.threshold <= 0 {
+		return true, 0
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.failures < b.threshold {
+		return true, 0
+	}
+	if wait := time.Until(b.openUntil); wait > 0 {
+		return false, wait
+	}
+	if b.probing {
+		return false, 0
+	}
+	b.probing = true
+	return true, 0
+}
+
+// record 记录一次调用结果；失败次数达到阈值时打开熔断器
+func (b *llmBreaker) record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+	if err == nil {
+		if b.failures >= b.threshold && b.threshold > 0 {
+			log.Infof("模型调用恢复，关闭熔断器")
+		}
+		b.failures, b.lastError = 0, ""
+		return
+	}
+	b.failures++
+	b.lastError = err.Error()
+	if b.threshold > 0 && b.failures >= b.threshold {
+		b.openUntil = time.Now().Add(b.cooldown)
+		log.Warnf("模型连续调用失败 %d 次，暂停 AI 功能 %s：%v", b.failures, b.cooldown, err)
+	}
+}
+
+// status 返回熔断器状态
+func (b *llmBreaker) status(s *AIStatus) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	s.Breaker, s.Failures, s.LastError = BreakerClosed, b.failures, b.lastError
+	if b.threshold > 0 && b.failures >= b.threshold {
+		s.Breaker = BreakerOpen
+		if until := b.openUntil; time.Now().Before(until) {
+			s.OpenUntil = &until
+		}
+	}
+}
+
+// complete 调用模型：主模型对临时性错误（限流、5xx、超时、网络错误）按退避重试，仍失败时改用备用模型
+// 整体失败计入熔断器；熔断期间直接返回 ErrAIUnavailable
+func (ls *LightweightSummarizer) complete(ctx context.Context, req openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+	if ok, wait := ls.breaker.allow(); !ok {
+		if wait > 0 {
+			return openai.ChatCompletionResponse{}, fmt.Errorf("%w：模型连续调用失败，约 %s 后恢复", ErrAIUnavailable, (wait + time.Second - 1).Truncate(time.Second))
+		}
+		return openai.ChatCompletionResponse{}, fmt.Errorf("%w：模型连续调用失败，正在试探恢复", ErrAIUnavailable)
+	}
+
+	models := []string{req.Model}
+	if ls.cfg.FallbackModel != "" && ls.cfg.FallbackModel != req.Model {
+		models = append(models, ls.cfg.FallbackModel)
+	}
+	var err error
+	for i, model := range models {
+		req.Model = model
+		var resp openai.ChatCompletionResponse
+		resp, err = ls.completeWithRetry(ctx, req)
+		if err == nil {
+			if i > 0 {
+				log.Infof("主模型调用失败，已由备用模型[%s]完成", model)
+			}
+			ls.breaker.record(nil)
+			return resp, nil
+		}
+		// 调用方取消或超出延迟预算时不再尝试备用模型
+		if ctx.Err() != nil {
+			break
+		}
+		log.Warnf("模型[%s]调用失败：%v", model, err)
+	}
+	ls.breaker.record(err)
+	return openai.ChatCompletionResponse{}, fmt.Errorf("%w：%v", ErrAIUnavailable, err)
+}
+
+// completeWithRetry 调用指定模型，临时性错误按 retryBackoff 指数退避重试，最多 maxRetries 次
+func (ls *LightweightSummarizer) completeWithRetry(ctx context.Context, req openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+	backoff := ls.cfg.RetryBackoff
+	for attempt := 0; ; attempt++ {
+		resp, err := ls.client.CreateChatCompletion(ctx, req)
+		if err == nil && len(resp.Choices) == 0 {
+			err = errors.New("模型未返回内容")
+		}
+		if err == nil {
+			return resp, nil
+		}
+		if attempt >= ls.cfg.MaxRetries || !transientLLMError(err) {
+			return resp, err
+		}
+		log.Debugf("模型[%s]调用失败，%s 后第 %d 次重试：%v", req.Model, backoff, attempt+1, err)
+		select {
+		case <-ctx.Done():
+			return resp, err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// transientLLMError 判断是否为可重试的临时性错误：限流、服务端错误、超时与网络错误
+func transientLLMError(err error) bool {
+	status := 0
+	var apiErr *openai.APIError
+	var reqErr *openai.RequestError
+	switch {
+	case errors.As(err, &apiErr):
+		status = apiErr.HTTPStatusCode
+	case errors.As(err, &reqErr):
+		status = reqErr.HTTPStatusCode
+	}
+	if status != 0 {
+		return status == http.StatusTooManyRequests || status == http.StatusRequestTimeout || status >= http.StatusInternalServerError
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// Status 返回 AI 服务状态（是否开启、模型与熔断器状态）
+func (ls *LightweightSummarizer) Status() *AIStatus {
+	s := &AIStatus{Enabled: ls.enable, Breaker: BreakerClosed}
+	if !ls.enable {
+		return s
+	}
+	s.Model, s.FallbackModel = ls.cfg.ModelName, ls.cfg.FallbackModel
+	ls.breaker.status(s)
+	return s
+}
@@ -26,6 +26,7 @@ type AgentResponse struct {
 type QueryIntent struct {
 	IsFailed       bool     // 是否查询失败服务
 	IsSSL          bool     // 是否查询SSL证书相关信息
+	CertExpiring   bool     // 是否只查询即将过期（剩余天数低于 core.CertWarnDays）或已过期的证书
 	IsTCP          bool     // 是否查询TCP服务相关信息
 	TargetKeywords []string // 目标地址关键词，用于过滤结果
 	TimeRangeHours int      // 检索时间范围（小时）
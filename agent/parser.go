@@ -1,11 +1,17 @@
 package agent
 
 import (
+	"regexp"
+	"strconv"
 	"strings"
 	"unicode"
 )
 
+// targetPatterns 内置的目标地址关键词，查询中出现时用于过滤结果
+var targetPatterns = []string{"baidu", "github", "google", "127.0.0.1", "localhost"}
+
 // ParseQueryIntent 解析用户查询内容，提取查询意图和条件
+// 按 DetectLanguage 识别提问语言，英文问题使用 ParseEnglishQueryIntent 的英文关键词规则
 // userQuery：用户输入的查询内容
 // defaultTimeRange：默认检索时间范围（小时）
 func ParseQueryIntent(userQuery string, defaultTimeRange int) *QueryIntent {
@@ -18,6 +24,9 @@ func ParseQueryIntent(userQuery string, defaultTimeRange int) *QueryIntent {
 	if userQuery == "" {
 		return intent
 	}
+	if DetectLanguage(userQuery) == LangEnglish {
+		return ParseEnglishQueryIntent(userQuery, defaultTimeRange)
+	}
 
 	// 转换为小写，统一查询条件判断标准
 	lowerQuery := strings.ToLower(userQuery)
@@ -40,18 +49,21 @@ func ParseQueryIntent(userQuery string, defaultTimeRange int) *QueryIntent {
 		}
 	}
 
+	// 解析查询意图：是否只关注即将过期的证书
+	for _, kw := range []string{"过期", "到期"} {
+		if strings.Contains(lowerQuery, kw) {
+			intent.IsSSL, intent.CertExpiring = true, true
+			break
+		}
+	}
+
 	// 解析查询意图：是否查询TCP服务相关信息
 	if strings.Contains(lowerQuery, "tcp") {
 		intent.IsTCP = true
 	}
 
 	// 提取目标地址关键词，用于过滤结果
-	targetPatterns := []string{"baidu", "github", "google", "127.0.0.1", "localhost"}
-	for _, pat := range targetPatterns {
-		if strings.Contains(lowerQuery, pat) {
-			intent.TargetKeywords = append(intent.TargetKeywords, pat)
-		}
-	}
+	intent.TargetKeywords = matchTargetPatterns(lowerQuery)
 
 	// 提取用户指定的时间范围，覆盖默认值
 	intent.TimeRangeHours = extractTimeRange(lowerQuery, defaultTimeRange)
@@ -97,3 +109,69 @@ func extractTimeRange(query string, defaultRange int) int {
 	// 无明确单位，返回默认时间范围
 	return defaultRange
 }
+
+// matchTargetPatterns 返回查询中出现的内置目标地址关键词
+func matchTargetPatterns(query string) []string {
+	keywords := []string{}
+	for _, pat := range targetPatterns {
+		if strings.Contains(query, pat) {
+			keywords = append(keywords, pat)
+		}
+	}
+	return keywords
+}
+
+// englishRangePattern 匹配「last 6 hours」「past 3d」等英文时间范围
+var englishRangePattern = regexp.MustCompile(`\b(?:last|past|previous)\s+(\d+)\s*(h|hrs?|hours?|d|days?|w|weeks?)\b`)
+
+// ParseEnglishQueryIntent 解析英文查询内容，规则与 ParseQueryIntent 一致
+// 如 "which services failed in the last 6 hours"、"ssl certs expiring on github"
+func ParseEnglishQueryIntent(userQuery string, defaultTimeRange int) *QueryIntent {
+	intent := &QueryIntent{
+		TimeRangeHours: defaultTimeRange,
+		TargetKeywords: []string{},
+	}
+	if userQuery == "" {
+		return intent
+	}
+	lowerQuery := strings.ToLower(userQuery)
+
+	for _, kw := range []string{"down", "fail", "error", "timeout", "timed out", "unhealthy", "outage", "broken", "unreachable"} {
+		if strings.Contains(lowerQuery, kw) {
+			intent.IsFailed = true
+			break
+		}
+	}
+	for _, kw := range []string{"ssl", "tls", "cert", "expir"} {
+		if strings.Contains(lowerQuery, kw) {
+			intent.IsSSL = true
+			break
+		}
+	}
+	if strings.Contains(lowerQuery, "expir") {
+		intent.IsSSL, intent.CertExpiring = true, true
+	}
+	intent.IsTCP = strings.Contains(lowerQuery, "tcp")
+	intent.TargetKeywords = matchTargetPatterns(lowerQuery)
+
+	switch m := englishRangePattern.FindStringSubmatch(lowerQuery); {
+	case m != nil:
+		n, _ := strconv.Atoi(m[1])
+		switch m[2][0] {
+		case 'd':
+			n *= 24
+		case 'w':
+			n *= 24 * 7
+		}
+		if n > 0 {
+			intent.TimeRangeHours = n
+		}
+	case strings.Contains(lowerQuery, "last hour") || strings.Contains(lowerQuery, "past hour"):
+		intent.TimeRangeHours = 1
+	case strings.Contains(lowerQuery, "last week") || strings.Contains(lowerQuery, "past week") || strings.Contains(lowerQuery, "this week"):
+		intent.TimeRangeHours = 24 * 7
+	case strings.Contains(lowerQuery, "today") || strings.Contains(lowerQuery, "last day") || strings.Contains(lowerQuery, "past day"):
+		intent.TimeRangeHours = 24
+	}
+	return intent
+}
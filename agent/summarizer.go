@@ -8,15 +8,20 @@ import (
 
 	"servicetelemetry/config"
 	"servicetelemetry/core"
+	"servicetelemetry/logger"
 
 	"github.com/sashabaranov/go-openai"
 )
 
+var log = logger.New("agent")
+
 // 保留原有结构体，兼容历史功能
 type LightweightSummarizer struct {
-	client *openai.Client
-	cfg    *config.LLMConfig
-	enable bool
+	client  *openai.Client
+	cfg     *config.LLMConfig
+	enable  bool
+	breaker *llmBreaker // 模型调用熔断器
+	scope   *chatScope  // 通用问答的角色设定与话题范围
 }
 
 // 保留原有初始化方法
@@ -29,16 +34,35 @@ func NewLightweightSummarizer(agentCfg *config.AgentConfig) *LightweightSummariz
 	openaiCfg.BaseURL = agentCfg.LLM.APIBaseURL
 
 	return &LightweightSummarizer{
-		client: openai.NewClientWithConfig(openaiCfg),
-		cfg:    &agentCfg.LLM,
-		enable: true,
+		client:  openai.NewClientWithConfig(openaiCfg),
+		cfg:     &agentCfg.LLM,
+		enable:  true,
+		breaker: &llmBreaker{threshold: agentCfg.LLM.BreakerThreshold, cooldown: agentCfg.LLM.BreakerCooldown},
+		scope:   newChatScope(&agentCfg.Chat),
 	}
 }
 
-// 保留原有监控数据总结方法（兼容历史功能）
+// 保留原有监控数据总结方法（兼容历史功能），输出中文纯文本
 func (ls *LightweightSummarizer) Summarize(results []*core.MonitorResult) (string, error) {
+	text, _, err := ls.SummarizeAs(results, FormatPlain, LangChinese)
+	return text, err
+}
+
+// SummarizeAs 按指定格式与语言总结监控数据
+// format：plain / markdown 返回文本；json 同时返回结构化总结，文本为其中的概述
+// lang：回答语言，通常为 DetectLanguage 识别的提问语言
+// AI 不可用时返回规则统计文本（json 格式下分区内容不受影响）与 ErrAIUnavailable
+func (ls *LightweightSummarizer) SummarizeAs(results []*core.MonitorResult, format, lang string) (string, *StructuredSummary, error) {
+	var structured *StructuredSummary
+	if format == FormatJSON {
+		structured = summarySections(results)
+	}
 	if !ls.enable || len(results) == 0 {
-		return "暂无监控数据可总结。", nil
+		text := localized(lang, "暂无监控数据可总结。", "No monitoring data to summarize.")
+		if structured != nil {
+			structured.Overview = text
+		}
+		return text, structured, nil
 	}
 
 	// 统计监控数据
@@ -51,22 +75,32 @@ func (ls *LightweightSummarizer) Summarize(results []*core.MonitorResult) (strin
 			failedCount++
 			failedTargets = append(failedTargets, r.TargetURL)
 		}
-		if r.SSLCertExpiry == "已过期" || r.SSLCertExpiry == "即将过期" {
+		if certIssue(r) {
 			sslExpired = append(sslExpired, r.TargetURL)
 		}
 	}
 
-	// 构建总结Prompt
-	prompt := fmt.Sprintf(`
-请简洁总结以下监控数据，要求：
+	// 构建总结Prompt：目标地址等不可信内容只放在数据块中
+	prompt := `请简洁总结以下监控数据，要求：
 1.  正常服务和异常服务分开说明
 2.  突出SSL证书问题
 3.  3句话以内，语言精炼
-监控数据：
-- 总监控服务数：%d
-- 异常服务数：%d，异常地址：%s
-- SSL证书异常地址：%s
-`, len(results), failedCount, strings.Join(failedTargets, "、"), strings.Join(sslExpired, "、"))
+4.  ` + formatInstruction(format) + `
+5.  ` + languageInstruction(lang) + `
+`
+	if structured != nil {
+		prompt = `请根据以下监控数据输出 JSON 对象，只包含两个字符串字段：
+- overview：一到两句话的整体概述，突出异常服务与SSL证书问题
+- recommendation：建议的下一步排查或处理动作，无异常时给出空字符串
+只输出 JSON，不要输出其他内容。` + languageInstruction(lang) + `
+`
+	}
+	prompt += dataBlock(struct {
+		Total         int      `json:"total"`
+		FailedCount   int      `json:"failedCount"`
+		FailedTargets []string `json:"failedTargets"`
+		SSLIssues     []string `json:"sslIssueTargets"`
+	}{len(results), failedCount, untrustedList(failedTargets), untrustedList(sslExpired)})
 
 	// 调用LLM
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -77,24 +111,46 @@ func (ls *LightweightSummarizer) Summarize(results []*core.MonitorResult) (strin
 		Temperature: 0.3,
 		MaxTokens:   200,
 		Messages: []openai.ChatCompletionMessage{
-			{Role: openai.ChatMessageRoleSystem, Content: "你是运维监控总结助手，仅基于提供的数据进行总结，不编造额外信息。"},
+			{Role: openai.ChatMessageRoleSystem, Content: "你是运维监控总结助手，仅基于提供的数据进行总结，不编造额外信息。" + dataGuard},
 			{Role: openai.ChatMessageRoleUser, Content: prompt},
 		},
 	}
+	if structured != nil {
+		req.MaxTokens = 300
+		req.ResponseFormat = &openai.ChatCompletionResponseFormat{Type: openai.ChatCompletionResponseFormatTypeJSONObject}
+	}
 
-	resp, err := ls.client.CreateChatCompletion(ctx, req)
+	log.Debugf("调用模型[%s]总结 %d 条监控数据", ls.cfg.ModelName, len(results))
+	resp, err := ls.complete(ctx, req)
 	if err != nil {
-		return "", fmt.Errorf("总结失败：%w", err)
+		// 降级为规则统计，不向用户展示原始错误
+		text := fallbackSummary(len(results), failedTargets, sslExpired, lang)
+		if structured != nil {
+			structured.Overview = text
+		}
+		return text, structured, err
 	}
 
-	return strings.TrimSpace(resp.Choices[0].Message.Content), nil
+	if structured != nil {
+		parseSummaryJSON(resp.Choices[0].Message.Content, structured)
+		return structured.Overview, structured, nil
+	}
+	return sanitizeReply(resp.Choices[0].Message.Content), nil, nil
 }
 
-// 新增：通用问答方法（不依赖任何监控数据，支持任意问题）
+// 新增：通用问答方法（不依赖任何监控数据，支持任意问题），输出纯文本
 func (ls *LightweightSummarizer) Chat(userQuery string) (string, error) {
+	return ls.ChatAs(userQuery, FormatPlain)
+}
+
+// ChatAs 按指定格式回答通用问题，使用与提问相同的语言；通用问答没有结构化分区，json 格式按 markdown 处理
+// 配置了话题范围（agent.chat.scope）时，超出范围的问题返回拒绝提示与 ErrOutOfScope
+func (ls *LightweightSummarizer) ChatAs(userQuery, format string) (string, error) {
+	lang := DetectLanguage(userQuery)
 	// 未开启AI功能的提示
 	if !ls.enable {
-		return "小助手AI功能未开启，请在配置文件中启用EnableAI并配置正确的LLM参数后重试。", nil
+		return localized(lang, "小助手AI功能未开启，请在配置文件中启用EnableAI并配置正确的LLM参数后重试。",
+			"The assistant's AI features are disabled. Enable EnableAI and configure the LLM settings, then try again."), nil
 	}
 
 	// 空查询过滤
@@ -112,13 +168,8 @@ func (ls *LightweightSummarizer) Chat(userQuery string) (string, error) {
 		MaxTokens:   500, // 增大令牌数，支持更长回答
 		Messages: []openai.ChatCompletionMessage{
 			{
-				Role: openai.ChatMessageRoleSystem,
-				Content: `你是一个全能智能小助手，能够回答用户提出的任意问题，包括但不限于：
-1.  运维技术问题（HTTP状态码、TCP排查、SSL证书等）
-2.  编程语言知识（Golang、Python等）
-3.  通用生活常识、科普知识
-4.  工作效率技巧、工具使用
-回答要求：语言简洁易懂，逻辑清晰，避免冗余，针对技术问题可适当补充实操步骤。`,
+				Role:    openai.ChatMessageRoleSystem,
+				Content: ls.scope.systemPrompt() + formatInstruction(format) + languageInstruction(lang),
 			},
 			{
 				Role:    openai.ChatMessageRoleUser,
@@ -128,14 +179,114 @@ func (ls *LightweightSummarizer) Chat(userQuery string) (string, error) {
 	}
 
 	// 调用LLM获取通用回答
-	resp, err := ls.client.CreateChatCompletion(ctx, req)
+	log.Debugf("调用模型[%s]回答通用问题，问题长度 %d", ls.cfg.ModelName, len([]rune(userQuery)))
+	resp, err := ls.complete(ctx, req)
+	if err != nil {
+		return localized(lang, "小助手暂时无法回答，请稍后再试；监控数据查询不受影响，可使用「纯数据展示」查看原始数据。",
+			"The assistant cannot answer right now, please try again later. Monitoring data queries are unaffected: use data mode to view the raw results."), err
+	}
+
+	reply := strings.TrimSpace(resp.Choices[0].Message.Content)
+	// 系统提示之外再按话题过滤回答，避免模型被诱导越界
+	if !ls.scope.allows(userQuery, reply) {
+		log.Infof("通用问答超出话题范围（%s），已拒绝回答，问题长度 %d", ls.scope.scope, len([]rune(userQuery)))
+		return ls.scope.refusalFor(lang), ErrOutOfScope
+	}
+	return reply, nil
+}
+
+// EnrichAlert 为告警补充一到两句上下文（近期相关失败、错误类型解读、建议的下一步），实现 alert.Enricher 接口
+// ctx：调用方的延迟预算
+// result：触发告警的监控结果
+// recent：该目标近期历史结果
+// maxTokens：生成的最大 token 数
+func (ls *LightweightSummarizer) EnrichAlert(ctx context.Context, result *core.MonitorResult, recent []*core.MonitorResult, maxTokens int) (string, error) {
+	if !ls.enable {
+		return "", fmt.Errorf("AI功能未开启")
+	}
+
+	recentFailed := 0
+	for _, r := range recent {
+		if r.Status == "failed" {
+			recentFailed++
+		}
+	}
+
+	// 错误信息可能带出目标返回的内容，与目标地址一起只放在数据块中
+	prompt := "请用不超过两句话为以下告警补充上下文，包括：近期失败情况、错误类型含义、建议的下一步排查动作。\n" + dataBlock(struct {
+		Target       string `json:"target"`
+		ErrorType    string `json:"errorType"`
+		ErrorMsg     string `json:"errorMsg"`
+		StatusCode   int    `json:"statusCode"`
+		RecentChecks int    `json:"recentChecks24h"`
+		RecentFailed int    `json:"recentFailed24h"`
+	}{untrusted(result.TargetURL), untrusted(result.ErrorType), untrusted(result.ErrorMsg), result.StatusCode, len(recent), recentFailed})
+
+	req := openai.ChatCompletionRequest{
+		Model:       ls.cfg.ModelName,
+		Temperature: 0.2,
+		MaxTokens:   maxTokens,
+		Messages: []openai.ChatCompletionMessage{
+			{Role: openai.ChatMessageRoleSystem, Content: "你是值班运维助手，仅基于提供的数据补充告警上下文，不编造额外信息。" + dataGuard},
+			{Role: openai.ChatMessageRoleUser, Content: prompt},
+		},
+	}
+
+	resp, err := ls.complete(ctx, req)
 	if err != nil {
-		return "", fmt.Errorf("小助手回答失败：%w", err)
+		return "", fmt.Errorf("告警补充失败：%w", err)
+	}
+
+	return sanitizeReply(resp.Choices[0].Message.Content), nil
+}
+
+// fallbackSummary AI 不可用时的规则统计总结
+func fallbackSummary(total int, failedTargets, sslExpired []string, lang string) string {
+	if lang == LangEnglish {
+		return fallbackSummaryEnglish(total, failedTargets, sslExpired)
 	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "AI 总结暂不可用，以下为统计结果：共 %d 条监控数据，", total)
+	if len(failedTargets) == 0 {
+		b.WriteString("无异常记录")
+	} else {
+		fmt.Fprintf(&b, "异常 %d 条（%s）", len(failedTargets), strings.Join(limitDistinct(failedTargets, 5, "等 %d 个"), "、"))
+	}
+	if len(sslExpired) > 0 {
+		fmt.Fprintf(&b, "；SSL证书异常：%s", strings.Join(limitDistinct(sslExpired, 5, "等 %d 个"), "、"))
+	}
+	b.WriteString("。")
+	return b.String()
+}
 
-	if len(resp.Choices) == 0 {
-		return "未获取到有效回答，请稍后再试。", nil
+// fallbackSummaryEnglish 英文提问时的规则统计总结
+func fallbackSummaryEnglish(total int, failedTargets, sslExpired []string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "AI summary is unavailable, statistics instead: %d monitoring results, ", total)
+	if len(failedTargets) == 0 {
+		b.WriteString("no failures")
+	} else {
+		fmt.Fprintf(&b, "%d failed (%s)", len(failedTargets), strings.Join(limitDistinct(failedTargets, 5, "%d in total"), ", "))
 	}
+	if len(sslExpired) > 0 {
+		fmt.Fprintf(&b, "; SSL certificate issues: %s", strings.Join(limitDistinct(sslExpired, 5, "%d in total"), ", "))
+	}
+	b.WriteString(".")
+	return b.String()
+}
 
-	return strings.TrimSpace(resp.Choices[0].Message.Content), nil
+// limitDistinct 去重后最多保留 n 项，超出部分按 more 格式表示总数，如「等 N 个」
+func limitDistinct(list []string, n int, more string) []string {
+	var out []string
+	seen := make(map[string]bool)
+	for _, v := range list {
+		if !seen[v] {
+			seen[v] = true
+			out = append(out, v)
+		}
+	}
+	if len(out) > n {
+		out = append(out[:n], fmt.Sprintf(more, len(out)))
+	}
+	return out
 }
@@ -0,0 +1,210 @@
+package alert
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"servicetelemetry/eventbus"
+)
+
+// 事件操作，通过告警通知中的签名链接执行
+const (
+	ActionAcknowledge = "ack"     // 确认事件：表示已有人处理，不改变事件状态
+	ActionResolve     = "resolve" // 手动解决事件
+)
+
+// StatusAcknowledged 事件被确认时发送的通知状态
+const StatusAcknowledged = "acknowledged"
+
+// 事件操作错误
+var (
+	ErrIncidentNotFound  = errors.New("事件不存在")
+	ErrIncidentResolved  = errors.New("事件已恢复")
+	ErrInvalidActionLink = errors.New("操作链接无效")
+	ErrActionLinkExpired = errors.New("操作链接已过期")
+)
+
+// ActionLinks 告警通知中附带的一键操作链接
+type ActionLinks struct {
+	Acknowledge string    `json:"acknowledge"` // 确认事件
+	Resolve     string    `json:"resolve"`     // 解决事件
+	ExpiresAt   time.Time `json:"expiresAt"`   // 链接过期时间
+}
+
+// signAction 计算操作链接签名：HMAC-SHA256(事件ID|事件随机值|操作|过期时间戳)，指定了操作人时末尾再加 |操作人
+func signAction(secret string, id uint64, nonce, action string, expires int64, by string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d|%s|%s|%d", id, nonce, action, expires)
+	if by != "" {
+		fmt.Fprintf(mac, "|%s", by)
+	}
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// newActionNonce 生成事件的随机值，读取随机数失败时退化为纳秒时间戳
+func newActionNonce() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
+
+// incidentNonce 返回事件的随机值，事件不存在时返回 false
+func (m *Manager) incidentNonce(id uint64) (string, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	incident, ok := m.incidents[id]
+	if !ok {
+		return "", false
+	}
+	return incident.nonce, true
+}
+
+// actionsEnabled 判断是否生成操作链接，未配置签名密钥时视为关闭
+func (m *Manager) actionsEnabled() bool {
+	return m.cfg.Actions.Enable && m.cfg.Actions.Secret != ""
+}
+
+// actionLinks 为事件生成带签名的确认 / 解决链接，未开启或事件不存在时返回 nil，调用方不能持有锁
+// by：记录的操作人，随链接签名，为空时记为「通知链接」
+func (m *Manager) actionLinks(id uint64, by string) *ActionLinks {
+	if !m.actionsEnabled() || id == 0 {
+		return nil
+	}
+	nonce, ok := m.incidentNonce(id)
+	if !ok {
+		return nil
+	}
+	expiresAt := time.Now().Add(m.cfg.Actions.TTL).Truncate(time.Second)
+	return &ActionLinks{
+		Acknowledge: m.actionURL(id, nonce, ActionAcknowledge, expiresAt.Unix(), by),
+		Resolve:     m.actionURL(id, nonce, ActionResolve, expiresAt.Unix(), by),
+		ExpiresAt:   expiresAt,
+	}
+}
+
+// actionURL 拼接操作链接，事件随机值只参与签名，不出现在链接中
+func (m *Manager) actionURL(id uint64, nonce, action string, expires int64, by string) string {
+	query := url.Values{}
+	query.Set("expires", fmt.Sprintf("%d", expires))
+	if by != "" {
+		query.Set("by", by)
+	}
+	query.Set("sig", signAction(m.cfg.Actions.Secret, id, nonce, action, expires, by))
+	return fmt.Sprintf("%s/api/v1/incidents/%d/actions/%s?%s",
+		strings.TrimRight(m.cfg.Actions.PublicURL, "/"), id, action, query.Encode())
+}
+
+// VerifyAction 校验操作链接的签名与有效期，签名绑定事件的随机值：服务重启前生成的链接不会作用于重启后ID相同的新事件
+// 事件不存在时返回 ErrIncidentNotFound
+// id：事件ID
+// action：操作（ack / resolve）
+// expires：链接中的过期时间戳（秒）
+// by：链接中的操作人（随链接签名），没有时为空
+// sig：链接中的签名
+func (m *Manager) VerifyAction(id uint64, action string, expires int64, by, sig string) error {
+	if !m.actionsEnabled() {
+		return ErrInvalidActionLink
+	}
+	nonce, ok := m.incidentNonce(id)
+	if !ok {
+		return ErrIncidentNotFound
+	}
+	expected := signAction(m.cfg.Actions.Secret, id, nonce, action, expires, by)
+	if !hmac.Equal([]byte(expected), []byte(sig)) {
+		return ErrInvalidActionLink
+	}
+	if time.Now().Unix() > expires {
+		return ErrActionLinkExpired
+	}
+	return nil
+}
+
+// Acknowledge 确认事件，记录确认人与确认时间并通知各渠道；重复确认不重复通知
+// 确认只表示已有人处理，不影响事件的恢复判断，也不通知状态订阅人
+func (m *Manager) Acknowledge(id uint64, by string) (*Incident, error) {
+	m.mu.Lock()
+	incident, ok := m.incidents[id]
+	if !ok {
+		m.mu.Unlock()
+		return nil, ErrIncidentNotFound
+	}
+	if incident.Status == StatusResolved {
+		m.mu.Unlock()
+		return nil, ErrIncidentResolved
+	}
+	if incident.AckedAt != nil {
+		snapshot := incidentSnapshot(incident)
+		m.mu.Unlock()
+		return snapshot, nil
+	}
+
+	now := time.Now()
+	incident.AckedAt, incident.AckedBy = &now, by
+	m.stopEscalationLocked(incident)
+	m.appendLogLocked(incident, LogEntry{Time: now, Kind: LogAcknowledged, Actor: by, Message: fmt.Sprintf("事件已确认，开始后 %s", now.Sub(incident.OpenedAt).Round(time.Second))})
+	snapshot := incidentSnapshot(incident)
+	m.publish(eventbus.TypeIncident, fmt.Sprintf("%d", incident.ID), snapshot)
+	m.mu.Unlock()
+
+	go m.dispatch(&Alert{
+		IncidentID: id,
+		GroupKey:   snapshot.GroupKey,
+		Status:     StatusAcknowledged,
+		Severity:   snapshot.Severity,
+		Title:      fmt.Sprintf("【已确认】%s", snapshot.Title),
+		Body:       fmt.Sprintf("事件：#%d\n确认人：%s\n已持续：%s", id, by, now.Sub(snapshot.OpenedAt).Round(time.Second)),
+		FiredAt:    now,
+	})
+	return snapshot, nil
+}
+
+// ResolveIncident 手动解决事件（如故障已确认由外部原因导致并处理完毕），仍处于异常的目标不再归属该事件
+// 这些目标恢复后不再发送恢复通知，再次异常时产生新事件
+func (m *Manager) ResolveIncident(id uint64, by string) (*Incident, error) {
+	m.mu.Lock()
+	incident, ok := m.incidents[id]
+	if !ok {
+		m.mu.Unlock()
+		return nil, ErrIncidentNotFound
+	}
+	if incident.Status == StatusResolved {
+		m.mu.Unlock()
+		return nil, ErrIncidentResolved
+	}
+
+	now := time.Now()
+	for target := range incident.open {
+		if m.targetIncident[target] == id {
+			delete(m.targetIncident, target)
+		}
+	}
+	incident.open = make(map[string]bool)
+	incident.Remaining = 0
+	incident.Status = StatusResolved
+	incident.ResolvedAt, incident.ResolvedBy = &now, by
+	m.stopEscalationLocked(incident)
+	m.appendLogLocked(incident, LogEntry{Time: now, Kind: LogResolved, Actor: by, Message: fmt.Sprintf("手动解决，持续 %s", now.Sub(incident.OpenedAt).Round(time.Second))})
+	m.emitIncidentLocked(IncidentResolved, incident)
+	snapshot := incidentSnapshot(incident)
+	m.mu.Unlock()
+
+	go m.dispatch(&Alert{
+		IncidentID: id,
+		GroupKey:   snapshot.GroupKey,
+		Status:     StatusResolved,
+		Severity:   snapshot.Severity,
+		Title:      fmt.Sprintf("【已解决】%s", snapshot.Title),
+		Body:       fmt.Sprintf("事件：#%d\n解决人：%s\n持续时长：%s", id, by, now.Sub(snapshot.OpenedAt).Round(time.Second)),
+		FiredAt:    now,
+	})
+	return snapshot, nil
+}
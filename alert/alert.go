@@ -0,0 +1,105 @@
+package alert
+
+import (
+	"fmt"
+	"time"
+
+	"servicetelemetry/core"
+)
+
+// 告警状态
+const (
+	StatusFiring   = "firing"   // 告警触发
+	StatusResolved = "resolved" // 告警恢复
+)
+
+// 告警级别，由告警规则指定，未指定时为 critical
+const (
+	SeverityCritical = "critical"
+	SeverityWarning  = "warning"
+	SeverityInfo     = "info"
+)
+
+// severityRank 告警级别的严重程度，数值越大越严重
+var severityRank = map[string]int{SeverityInfo: 1, SeverityWarning: 2, SeverityCritical: 3}
+
+// Alert 告警通知，由监控结果的状态变化产生；聚合告警通过 Members 携带全部成员结果
+type Alert struct {
+	IncidentID uint64                `json:"incidentId"`         // 所属事件ID
+	GroupKey   string                `json:"groupKey,omitempty"` // 聚合特征（仅聚合告警）
+	TargetURL  string                `json:"targetUrl"`          // 告警目标地址（聚合告警为空）
+	Status     string                `json:"status"`             // 告警状态：firing / resolved
+	Rule       string                `json:"rule,omitempty"`     // 触发告警的规则名称（使用默认规则时为空）
+	Severity   string                `json:"severity"`           // 告警级别：critical / warning / info
+	Title      string                `json:"title"`              // 告警标题
+	Body       string                `json:"body"`               // 告警正文
+	Context    string                `json:"context"`            // AI 补充的上下文说明（可选）
+	Result     *core.MonitorResult   `json:"result"`             // 触发告警的监控结果（聚合告警为空）
+	Members    []*core.MonitorResult `json:"members,omitempty"`  // 聚合告警的成员结果
+	FiredAt    time.Time             `json:"firedAt"`            // 告警产生时间
+	Actions    *ActionLinks          `json:"actions,omitempty"`  // 一键确认 / 解决链接（仅触发告警，需开启 alert.actions）
+
+	templated bool // 正文由通知模板渲染，FullBody 不再追加 AI 上下文与操作链接
+}
+
+// Notifier 告警通知渠道接口
+type Notifier interface {
+	Name() string        // 渠道名称
+	Send(a *Alert) error // 发送告警
+}
+
+// newAlert 根据监控结果构建告警事件，正文使用固定模板
+// status：告警状态
+// result：触发告警的监控结果
+func newAlert(status string, result *core.MonitorResult) *Alert {
+	a := &Alert{
+		TargetURL: result.TargetURL,
+		Status:    status,
+		Severity:  SeverityCritical,
+		Result:    result,
+		FiredAt:   time.Now(),
+	}
+
+	if status == StatusResolved {
+		a.Title = fmt.Sprintf("【恢复】%s 已恢复正常", result.TargetURL)
+		a.Body = fmt.Sprintf("目标：%s\n响应耗时：%.0fms\n检查时间：%s",
+			result.TargetURL, result.ResponseTime, result.CheckedAt.Format("2006-01-02 15:04:05"))
+		return a
+	}
+
+	a.Title = fmt.Sprintf("【告警】%s 检查失败", result.TargetURL)
+	a.Body = fmt.Sprintf("目标：%s\n错误类型：%s\n错误信息：%s\n检查时间：%s",
+		result.TargetURL, result.ErrorType, result.ErrorMsg, result.CheckedAt.Format("2006-01-02 15:04:05"))
+	return a
+}
+
+// newRuleAlert 根据监控结果与其满足的告警规则构建告警事件，默认规则（或 rule 为 nil）时与 newAlert 一致
+func newRuleAlert(status string, result *core.MonitorResult, rule *Rule) *Alert {
+	a := newAlert(status, result)
+	if rule == nil || rule.implicit || status != StatusFiring {
+		return a
+	}
+	a.Severity = rule.Severity
+	a.Rule = rule.Name
+	a.Title = fmt.Sprintf("【告警】%s 触发规则「%s」", result.TargetURL, rule.Name)
+	a.Body = fmt.Sprintf("目标：%s\n规则：%s（%s）\n检查状态：%s\n错误类型：%s\n错误信息：%s\n响应耗时：%.0fms\n检查时间：%s",
+		result.TargetURL, rule.Name, rule.Expr(), result.Status, result.ErrorType, result.ErrorMsg, result.ResponseTime,
+		result.CheckedAt.Format("2006-01-02 15:04:05"))
+	return a
+}
+
+// FullBody 返回包含 AI 上下文与操作链接的完整正文，两者都没有时与模板正文一致
+// 正文由通知模板渲染时直接返回模板正文
+func (a *Alert) FullBody() string {
+	body := a.Body
+	if a.templated {
+		return body
+	}
+	if a.Context != "" {
+		body += "\n\n🤖 " + a.Context
+	}
+	if a.Actions != nil {
+		body += fmt.Sprintf("\n\n确认：%s\n解决：%s", a.Actions.Acknowledge, a.Actions.Resolve)
+	}
+	return body
+}
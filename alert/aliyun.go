@@ -0,0 +1,186 @@
+package alert
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"servicetelemetry/config"
+)
+
+// aliyunVarLimit 阿里云短信 / 语音模板单个变量的最大长度（字符），超出部分截断
+const aliyunVarLimit = 35
+
+// aliyunProvider 阿里云电话服务商：短信使用短信服务 SendSms，语音电话使用语音服务 SingleCallByTts（文本转语音模板）
+// 模板变量：${title} 为告警标题、${content} 为正文，均按变量长度上限截断
+type aliyunProvider struct {
+	cfg    config.AliyunVoiceConfig
+	secret string
+	client *http.Client
+}
+
+// newAliyunProvider 创建阿里云电话服务商，AccessKey Secret 引用在创建时解析
+func newAliyunProvider(cfg *config.TelephonyConfig, timeout time.Duration) (TelephonyProvider, error) {
+	ac := cfg.Aliyun
+	if ac.AccessKeyID == "" || ac.AccessKeySecret == "" {
+		return nil, fmt.Errorf("aliyun 需要配置 accessKeyId 与 accessKeySecret")
+	}
+	if ac.SMSTemplateCode != "" && ac.SignName == "" {
+		return nil, fmt.Errorf("aliyun 发送短信需要配置 signName")
+	}
+	if ac.TTSCode != "" && ac.CalledShowNumber == "" {
+		return nil, fmt.Errorf("aliyun 拨打语音电话需要配置 calledShowNumber")
+	}
+	secret, err := config.ResolveSecret(ac.AccessKeySecret)
+	if err != nil {
+		return nil, fmt.Errorf("解析 aliyun.accessKeySecret 失败：%w", err)
+	}
+	if ac.SMSEndpoint == "" {
+		ac.SMSEndpoint = "https://dysmsapi.aliyuncs.com"
+	}
+	if ac.VoiceEndpoint == "" {
+		ac.VoiceEndpoint = "https://dyvmsapi.aliyuncs.com"
+	}
+	return &aliyunProvider{cfg: ac, secret: secret, client: &http.Client{Timeout: timeout}}, nil
+}
+
+// Name 返回服务商名称
+func (ap *aliyunProvider) Name() string {
+	return "aliyun"
+}
+
+// SendSMS 按短信模板发送短信
+func (ap *aliyunProvider) SendSMS(ctx context.Context, phone, title, text string) error {
+	if ap.cfg.SMSTemplateCode == "" {
+		return fmt.Errorf("未配置 aliyun.smsTemplateCode，无法发送短信")
+	}
+	return ap.call(ctx, ap.cfg.SMSEndpoint, map[string]string{
+		"Action":        "SendSms",
+		"PhoneNumbers":  aliyunPhone(phone),
+		"SignName":      ap.cfg.SignName,
+		"TemplateCode":  ap.cfg.SMSTemplateCode,
+		"TemplateParam": templateParams(title, text),
+	})
+}
+
+// Call 按文本转语音模板拨打语音通知电话，播报两遍
+func (ap *aliyunProvider) Call(ctx context.Context, phone, title, text string) error {
+	if ap.cfg.TTSCode == "" {
+		return fmt.Errorf("未配置 aliyun.ttsCode，无法拨打语音电话")
+	}
+	return ap.call(ctx, ap.cfg.VoiceEndpoint, map[string]string{
+		"Action":           "SingleCallByTts",
+		"CalledNumber":     aliyunPhone(phone),
+		"CalledShowNumber": ap.cfg.CalledShowNumber,
+		"TtsCode":          ap.cfg.TTSCode,
+		"TtsParam":         templateParams(title, text),
+		"PlayTimes":        "2",
+	})
+}
+
+// call 以 RPC 风格（签名版本 1.0，HMAC-SHA1）调用阿里云接口，应答 Code 不为 OK 时返回错误
+func (ap *aliyunProvider) call(ctx context.Context, endpoint string, params map[string]string) error {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return fmt.Errorf("生成签名随机数失败：%w", err)
+	}
+	params["Format"] = "JSON"
+	params["Version"] = "2017-05-25"
+	params["RegionId"] = "cn-hangzhou"
+	params["AccessKeyId"] = ap.cfg.AccessKeyID
+	params["SignatureMethod"] = "HMAC-SHA1"
+	params["SignatureVersion"] = "1.0"
+	params["SignatureNonce"] = hex.EncodeToString(nonce)
+	params["Timestamp"] = time.Now().UTC().Format("2006-01-02T15:04:05Z")
+	params["Signature"] = aliyunSignature(http.MethodPost, params, ap.secret)
+
+	form := url.Values{}
+	for k, v := range params {
+		form.Set(k, v)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(endpoint, "/")+"/", strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("创建阿里云请求失败：%w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := ap.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("请求阿里云 %s 失败：%w", params["Action"], err)
+	}
+	defer resp.Body.Close()
+	var result struct {
+		Code    string `json:"Code"`
+		Message string `json:"Message"`
+	}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if err := json.Unmarshal(data, &result); err != nil {
+		return fmt.Errorf("阿里云 %s 返回无法解析的应答（状态码 %d）", params["Action"], resp.StatusCode)
+	}
+	if result.Code != "OK" {
+		return fmt.Errorf("阿里云 %s 返回错误 %s：%s", params["Action"], result.Code, result.Message)
+	}
+	return nil
+}
+
+// aliyunSignature 计算 RPC 风格接口签名：HMAC-SHA1(AccessKeySecret&, METHOD&%2F&规范化参数)
+func aliyunSignature(method string, params map[string]string, secret string) string {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		if k != "Signature" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, aliyunEncode(k)+"="+aliyunEncode(params[k]))
+	}
+	stringToSign := method + "&" + aliyunEncode("/") + "&" + aliyunEncode(strings.Join(pairs, "&"))
+	mac := hmac.New(sha1.New, []byte(secret+"&"))
+	mac.Write([]byte(stringToSign))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// aliyunEncode 阿里云签名使用的 URL 编码（RFC 3986：空格为 %20，保留 ~）
+func aliyunEncode(s string) string {
+	s = url.QueryEscape(s)
+	return strings.NewReplacer("+", "%20", "*", "%2A", "%7E", "~").Replace(s)
+}
+
+// aliyunPhone 将 E.164 手机号转换为阿里云格式：中国大陆号码去掉 +86，其余号码去掉 +（国家码 + 号码）
+func aliyunPhone(phone string) string {
+	if strings.HasPrefix(phone, "+86") {
+		return strings.TrimPrefix(phone, "+86")
+	}
+	return strings.TrimPrefix(phone, "+")
+}
+
+// templateParams 生成模板变量 JSON：title 与 content，均截断到变量长度上限
+func templateParams(title, text string) string {
+	data, _ := json.Marshal(map[string]string{
+		"title":   truncateRunes(title, aliyunVarLimit),
+		"content": truncateRunes(strings.ReplaceAll(text, "\n", " "), aliyunVarLimit),
+	})
+	return string(data)
+}
+
+// truncateRunes 截断到最多 n 个字符
+func truncateRunes(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
@@ -0,0 +1,257 @@
+package alert
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"servicetelemetry/storage"
+)
+
+// 沟通记录类型
+const (
+	LogOpened       = "opened"       // 事件开启
+	LogUpdated      = "updated"      // 聚合事件中部分目标已恢复
+	LogResolved     = "resolved"     // 事件恢复（自动恢复或手动解决）
+	LogNotification = "notification" // 告警通知渠道发送的通知
+	LogEscalation   = "escalation"   // 升级通知（短信 / 语音电话）
+	LogAcknowledged = "acknowledged" // 事件被确认
+	LogComment      = "comment"      // 人工评论
+	LogStatusPage   = "status_page"  // 状态页订阅人收到的事件更新
+)
+
+// maxLogEntries 每个事件最多保留的沟通记录条数，超出时丢弃最早的记录并计数
+const maxLogEntries = 1000
+
+// maxCommentLength 评论的最大字符数
+const maxCommentLength = 4000
+
+// 评论错误
+var (
+	ErrEmptyComment   = errors.New("评论内容不能为空")
+	ErrCommentTooLong = fmt.Errorf("评论内容不能超过 %d 个字符", maxCommentLength)
+)
+
+// LogEntry 事件沟通记录中的一条：发出的通知、确认、评论与状态页更新
+type LogEntry struct {
+	Time      time.Time `json:"time"`                // 记录时间
+	Kind      string    `json:"kind"`                // 记录类型
+	Actor     string    `json:"actor,omitempty"`     // 操作人（确认、手动解决、评论）
+	Channel   string    `json:"channel,omitempty"`   // 通知渠道，如 webhook / ntfy / sms / email
+	Recipient string    `json:"recipient,omitempty"` // 接收人（推送订阅人、升级联系人、状态订阅人数）
+	Message   string    `json:"message"`             // 记录内容（通知为发出的标题，评论为评论正文）
+	Error     string    `json:"error,omitempty"`     // 发送失败的原因
+}
+
+// IncidentLog 事件及其按时间排列的沟通记录，用于复盘
+type IncidentLog struct {
+	Incident *Incident  `json:"incident"`          // 事件快照
+	Entries  []LogEntry `json:"entries"`           // 沟通记录，按时间升序
+	Dropped  int        `json:"dropped,omitempty"` // 超出保留条数被丢弃的最早记录数
+}
+
+// recipientNamer 可选接口：通知渠道有具体接收人（如个人推送订阅）时实现，记录在沟通记录中
+type recipientNamer interface {
+	Recipient() string
+}
+
+// logQueueSize 等待写入数据库的沟通记录上限，数据库持续不可用时超出的记录只保留在内存中
+const logQueueSize = 1024
+
+// incidentLogJob 一条待写入数据库的沟通记录及记录时的事件快照
+type incidentLogJob struct {
+	record   storage.IncidentLogRecord
+	snapshot string
+}
+
+// appendLogLocked 追加一条沟通记录，并连同事件快照排队写入数据库，调用方需持有锁
+func (m *Manager) appendLogLocked(incident *Incident, entry LogEntry) {
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+	if len(incident.entries) >= maxLogEntries {
+		incident.entries = incident.entries[1:]
+		incident.dropped++
+	}
+	incident.entries = append(incident.entries, entry)
+
+	if m.logQueue == nil {
+		return
+	}
+	snapshot, err := json.Marshal(incidentSnapshot(incident))
+	if err != nil {
+		log.Errorf("序列化事件#%d失败：%v", incident.ID, err)
+		return
+	}
+	job := incidentLogJob{
+		record: storage.IncidentLogRecord{
+			IncidentID: incident.ID,
+			Time:       entry.Time,
+			Kind:       entry.Kind,
+			Actor:      entry.Actor,
+			Channel:    entry.Channel,
+			Recipient:  entry.Recipient,
+			Message:    entry.Message,
+			Error:      entry.Error,
+		},
+		snapshot: string(snapshot),
+	}
+	select {
+	case m.logQueue <- job:
+	default:
+		log.Warnf("沟通记录写入队列已满，事件#%d的记录只保留在内存中", incident.ID)
+	}
+}
+
+// persistLog 按顺序将沟通记录写入数据库，服务重启或事件超出保留时长被清除后仍可导出
+func (m *Manager) persistLog() {
+	for job := range m.logQueue {
+		if err := m.storage.AppendIncidentLog(&job.record, job.snapshot); err != nil {
+			log.Errorf("保存事件#%d的沟通记录失败：%v", job.record.IncidentID, err)
+		}
+	}
+}
+
+// RecordCommunication 为事件追加一条沟通记录（如状态订阅模块记录状态页更新），事件不存在时忽略
+func (m *Manager) RecordCommunication(id uint64, entry LogEntry) {
+	if id == 0 {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if incident, ok := m.incidents[id]; ok {
+		m.appendLogLocked(incident, entry)
+	}
+}
+
+// AddComment 为事件添加评论，已恢复的事件也可以评论（如补充复盘结论）
+func (m *Manager) AddComment(id uint64, author, text string) (*LogEntry, error) {
+	text = strings.TrimSpace(text)
+	switch {
+	case text == "":
+		return nil, ErrEmptyComment
+	case utf8.RuneCountInString(text) > maxCommentLength:
+		return nil, ErrCommentTooLong
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	incident, ok := m.incidents[id]
+	if !ok {
+		return nil, ErrIncidentNotFound
+	}
+	entry := LogEntry{Time: time.Now(), Kind: LogComment, Actor: author, Message: text}
+	m.appendLogLocked(incident, entry)
+	return &entry, nil
+}
+
+// CommunicationLog 返回事件的沟通记录
+func (m *Manager) CommunicationLog(id uint64) (*IncidentLog, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	incident, ok := m.incidents[id]
+	if !ok {
+		return m.storedLog(id)
+	}
+	// 通知在发送完成后才记录（记录时间为开始发送的时间），按时间重新排序
+	entries := append([]LogEntry{}, incident.entries...)
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Time.Before(entries[j].Time) })
+	return &IncidentLog{
+		Incident: incidentSnapshot(incident),
+		Entries:  entries,
+		Dropped:  incident.dropped,
+	}, nil
+}
+
+// storedLog 从数据库读取已不在内存中的事件（服务重启前的事件或超出保留时长被清除的事件）的沟通记录
+func (m *Manager) storedLog(id uint64) (*IncidentLog, error) {
+	if m.storage == nil {
+		return nil, ErrIncidentNotFound
+	}
+	snapshot, records, err := m.storage.IncidentLog(id)
+	if err != nil {
+		return nil, err
+	}
+	if snapshot == "" {
+		return nil, ErrIncidentNotFound
+	}
+	incident := &Incident{}
+	if err := json.Unmarshal([]byte(snapshot), incident); err != nil {
+		return nil, fmt.Errorf("解析事件#%d快照失败：%w", id, err)
+	}
+	entries := make([]LogEntry, 0, len(records))
+	for _, r := range records {
+		entries = append(entries, LogEntry{Time: r.Time, Kind: r.Kind, Actor: r.Actor, Channel: r.Channel,
+			Recipient: r.Recipient, Message: r.Message, Error: r.Error})
+	}
+	return &IncidentLog{Incident: incident, Entries: entries}, nil
+}
+
+// logKindNames 记录类型的中文名称，用于导出
+var logKindNames = map[string]string{
+	LogOpened:       "事件开启",
+	LogUpdated:      "事件更新",
+	LogResolved:     "事件恢复",
+	LogNotification: "告警通知",
+	LogEscalation:   "升级通知",
+	LogAcknowledged: "确认",
+	LogComment:      "评论",
+	LogStatusPage:   "状态页更新",
+}
+
+// KindName 返回记录类型的中文名称
+func (e LogEntry) KindName() string {
+	if name, ok := logKindNames[e.Kind]; ok {
+		return name
+	}
+	return e.Kind
+}
+
+// Markdown 将沟通记录导出为 Markdown，可直接粘贴到复盘文档
+func (l *IncidentLog) Markdown() string {
+	in := l.Incident
+	var b strings.Builder
+	fmt.Fprintf(&b, "# 事件 #%d：%s\n\n", in.ID, in.Title)
+	fmt.Fprintf(&b, "- 级别：%s\n", in.Severity)
+	fmt.Fprintf(&b, "- 状态：%s\n", in.Status)
+	fmt.Fprintf(&b, "- 开始时间：%s\n", in.OpenedAt.Format(time.RFC3339))
+	if in.AckedAt != nil {
+		fmt.Fprintf(&b, "- 确认：%s（%s，开始后 %s）\n", in.AckedAt.Format(time.RFC3339), in.AckedBy, in.AckedAt.Sub(in.OpenedAt).Round(time.Second))
+	}
+	if in.ResolvedAt != nil {
+		by := in.ResolvedBy
+		if by == "" {
+			by = "自动恢复"
+		}
+		fmt.Fprintf(&b, "- 恢复：%s（%s，持续 %s）\n", in.ResolvedAt.Format(time.RFC3339), by, in.ResolvedAt.Sub(in.OpenedAt).Round(time.Second))
+	}
+	fmt.Fprintf(&b, "- 涉及目标：%s\n", strings.Join(in.Targets, "、"))
+	if l.Dropped > 0 {
+		fmt.Fprintf(&b, "- 最早的 %d 条记录超出保留上限已丢弃\n", l.Dropped)
+	}
+
+	b.WriteString("\n## 沟通记录\n\n| 时间 | 类型 | 渠道 | 操作人 / 接收人 | 内容 |\n|------|------|------|------|------|\n")
+	for _, e := range l.Entries {
+		who := e.Actor
+		if who == "" {
+			who = e.Recipient
+		}
+		content := e.Message
+		if e.Error != "" {
+			content += "（失败：" + e.Error + "）"
+		}
+		fmt.Fprintf(&b, "| %s | %s | %s | %s | %s |\n", e.Time.Format("2006-01-02 15:04:05"), e.KindName(),
+			markdownCell(e.Channel), markdownCell(who), markdownCell(content))
+	}
+	return b.String()
+}
+
+// markdownCell 转义表格单元格中的竖线与换行
+func markdownCell(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.ReplaceAll(strings.ReplaceAll(s, "\r\n", "<br>"), "\n", "<br>")
+}
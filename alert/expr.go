@@ -0,0 +1,558 @@
+package alert
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Expr 编译后的告警条件表达式
+// 语法：字面量（数字、"字符串" / '字符串'、true / false / null）、变量、函数调用 name(参数...)、括号，
+// 运算符按优先级从低到高：||、&&、== != < <= > >= =~ !~（=~ / !~ 为正则匹配）、+ -、* / %、一元 ! -
+// 变量缺失值为 null：与 null 的大小比较与正则匹配均为 false，参与算术运算结果仍为 null，逻辑运算中视为 false
+type Expr struct {
+	source string
+	root   exprNode
+}
+
+// exprEnv 表达式求值时的变量与函数
+type exprEnv interface {
+	// lookup 返回变量值（float64 / string / bool / nil）
+	lookup(name string) interface{}
+	// call 调用函数
+	call(name string, args []interface{}) (interface{}, error)
+}
+
+// exprSymbols 编译期校验变量与函数名，未知名称在编译时报错，避免规则上线后才发现拼写错误
+type exprSymbols interface {
+	// knownVar 判断变量名是否有效
+	knownVar(name string) bool
+	// knownFunc 返回函数的参数个数，函数不存在时返回 -1
+	knownFunc(name string) int
+}
+
+// exprNode 语法树节点
+type exprNode interface {
+	eval(env exprEnv) (interface{}, error)
+}
+
+// 表达式的规模限制：规则可以通过接口提交测试，限制长度与嵌套层数，避免超长或深度嵌套的输入耗尽栈空间
+const (
+	maxExprLength = 4096 // 表达式最大字节数
+	maxExprDepth  = 100  // 括号、函数参数与一元运算的最大嵌套层数
+)
+
+// compileExpr 编译表达式，语法错误、未知变量或函数、无效正则、超出长度或嵌套层数限制时返回错误
+func compileExpr(source string, symbols exprSymbols) (*Expr, error) {
+	if len(source) > maxExprLength {
+		return nil, fmt.Errorf("表达式长度超过上限 %d", maxExprLength)
+	}
+	tokens, err := lexExpr(source)
+	if err != nil {
+		return nil, err
+	}
+	p := &exprParser{tokens: tokens, symbols: symbols}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, fmt.Errorf("位置 %d：多余的 %q", tok.pos, tok.text)
+	}
+	return &Expr{source: source, root: root}, nil
+}
+
+// String 返回表达式原文
+func (e *Expr) String() string {
+	return e.source
+}
+
+// eval 求值，结果必须为布尔值（null 视为 false）
+func (e *Expr) eval(env exprEnv) (bool, error) {
+	v, err := e.root.eval(env)
+	if err != nil {
+		return false, err
+	}
+	switch b := v.(type) {
+	case bool:
+		return b, nil
+	case nil:
+		return false, nil
+	}
+	return false, fmt.Errorf("表达式结果不是布尔值：%v", v)
+}
+
+// 词法单元类型
+const (
+	tokEOF = iota
+	tokNumber
+	tokString
+	tokIdent
+	tokOp
+)
+
+// exprToken 词法单元
+type exprToken struct {
+	kind int
+	text string
+	num  float64
+	pos  int
+}
+
+// exprOps 运算符与分隔符，双字符运算符在前以优先匹配
+var exprOps = []string{"||", "&&", "==", "!=", "<=", ">=", "=~", "!~", "<", ">", "+", "-", "*", "/", "%", "!", "(", ")", ","}
+
+// lexExpr 将表达式拆分为词法单元
+func lexExpr(s string) ([]exprToken, error) {
+	var tokens []exprToken
+	for i := 0; i < len(s); {
+		c := rune(s[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c >= '0' && c <= '9' || c == '.':
+			j := i
+			for j < len(s) && (s[j] >= '0' && s[j] <= '9' || s[j] == '.') {
+				j++
+			}
+			n, err := strconv.ParseFloat(s[i:j], 64)
+			if err != nil {
+				return nil, fmt.Errorf("位置 %d：无效的数字 %q", i, s[i:j])
+			}
+			tokens = append(tokens, exprToken{kind: tokNumber, text: s[i:j], num: n, pos: i})
+			i = j
+		case c == '"' || c == '\'':
+			var b strings.Builder
+			j := i + 1
+			for ; j < len(s) && rune(s[j]) != c; j++ {
+				if s[j] == '\\' && j+1 < len(s) {
+					j++
+				}
+				b.WriteByte(s[j])
+			}
+			if j >= len(s) {
+				return nil, fmt.Errorf("位置 %d：字符串未闭合", i)
+			}
+			tokens = append(tokens, exprToken{kind: tokString, text: b.String(), pos: i})
+			i = j + 1
+		case c == '_' || unicode.IsLetter(c):
+			j := i
+			for j < len(s) && (s[j] == '_' || unicode.IsLetter(rune(s[j])) || unicode.IsDigit(rune(s[j]))) {
+				j++
+			}
+			tokens = append(tokens, exprToken{kind: tokIdent, text: s[i:j], pos: i})
+			i = j
+		default:
+			matched := ""
+			for _, op := range exprOps {
+				if strings.HasPrefix(s[i:], op) {
+					matched = op
+					break
+				}
+			}
+			if matched == "" {
+				return nil, fmt.Errorf("位置 %d：无法识别的字符 %q", i, c)
+			}
+			tokens = append(tokens, exprToken{kind: tokOp, text: matched, pos: i})
+			i += len(matched)
+		}
+	}
+	return append(tokens, exprToken{kind: tokEOF, text: "结尾", pos: len(s)}), nil
+}
+
+// exprParser 递归下降语法分析器
+type exprParser struct {
+	tokens  []exprToken
+	pos     int
+	depth   int // 当前嵌套层数
+	symbols exprSymbols
+}
+
+// peek 返回当前词法单元
+func (p *exprParser) peek() exprToken {
+	return p.tokens[p.pos]
+}
+
+// accept 当前词法单元为指定运算符时前进并返回 true
+func (p *exprParser) accept(ops ...string) (string, bool) {
+	tok := p.peek()
+	if tok.kind != tokOp {
+		return "", false
+	}
+	for _, op := range ops {
+		if tok.text == op {
+			p.pos++
+			return op, true
+		}
+	}
+	return "", false
+}
+
+// parseBinary 解析左结合的二元运算
+func (p *exprParser) parseBinary(next func() (exprNode, error), ops ...string) (exprNode, error) {
+	left, err := next()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.accept(ops...)
+		if !ok {
+			return left, nil
+		}
+		right, err := next()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{op: op, left: left, right: right}
+	}
+}
+
+// enter 进入一层嵌套，超过 maxExprDepth 时返回错误；成功时调用方解析完该层后需调用 leave
+func (p *exprParser) enter() error {
+	p.depth++
+	if p.depth > maxExprDepth {
+		return fmt.Errorf("位置 %d：嵌套层数超过上限 %d", p.peek().pos, maxExprDepth)
+	}
+	return nil
+}
+
+// leave 退出一层嵌套
+func (p *exprParser) leave() {
+	p.depth--
+}
+
+// parseOr 解析完整的子表达式（顶层、括号内与函数参数），每次进入计为一层嵌套
+func (p *exprParser) parseOr() (exprNode, error) {
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer p.leave()
+	return p.parseBinary(p.parseAnd, "||")
+}
+
+func (p *exprParser) parseAnd() (exprNode, error) {
+	return p.parseBinary(p.parseCompare, "&&")
+}
+
+// parseCompare 比较运算不可连写（a < b < c）
+func (p *exprParser) parseCompare() (exprNode, error) {
+	left, err := p.parseAdd()
+	if err != nil {
+		return nil, err
+	}
+	op, ok := p.accept("==", "!=", "<=", ">=", "<", ">", "=~", "!~")
+	if !ok {
+		return left, nil
+	}
+	right, err := p.parseAdd()
+	if err != nil {
+		return nil, err
+	}
+	if op == "=~" || op == "!~" {
+		lit, ok := right.(*literalNode)
+		pattern, isString := lit.valueOrNil().(string)
+		if !ok || !isString {
+			return nil, fmt.Errorf("%s 右侧必须为字符串形式的正则表达式", op)
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("正则表达式无效：%w", err)
+		}
+		return &matchNode{negate: op == "!~", left: left, re: re}, nil
+	}
+	return &binaryNode{op: op, left: left, right: right}, nil
+}
+
+func (p *exprParser) parseAdd() (exprNode, error) {
+	return p.parseBinary(p.parseMul, "+", "-")
+}
+
+func (p *exprParser) parseMul() (exprNode, error) {
+	return p.parseBinary(p.parseUnary, "*", "/", "%")
+}
+
+func (p *exprParser) parseUnary() (exprNode, error) {
+	if op, ok := p.accept("!", "-"); ok {
+		if err := p.enter(); err != nil {
+			return nil, err
+		}
+		defer p.leave()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &unaryNode{op: op, operand: operand}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *exprParser) parsePrimary() (exprNode, error) {
+	tok := p.peek()
+	p.pos++
+	switch tok.kind {
+	case tokNumber:
+		return &literalNode{value: tok.num}, nil
+	case tokString:
+		return &literalNode{value: tok.text}, nil
+	case tokIdent:
+		switch tok.text {
+		case "true":
+			return &literalNode{value: true}, nil
+		case "false":
+			return &literalNode{value: false}, nil
+		case "null":
+			return &literalNode{}, nil
+		}
+		if _, ok := p.accept("("); ok {
+			return p.parseCall(tok)
+		}
+		if !p.symbols.knownVar(tok.text) {
+			return nil, fmt.Errorf("位置 %d：未知变量 %s", tok.pos, tok.text)
+		}
+		return &varNode{name: tok.text}, nil
+	case tokOp:
+		if tok.text == "(" {
+			inner, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := p.accept(")"); !ok {
+				return nil, fmt.Errorf("位置 %d：缺少右括号", p.peek().pos)
+			}
+			return inner, nil
+		}
+	}
+	return nil, fmt.Errorf("位置 %d：意外的 %q", tok.pos, tok.text)
+}
+
+// parseCall 解析函数调用的参数列表（左括号已读取）
+func (p *exprParser) parseCall(name exprToken) (exprNode, error) {
+	arity := p.symbols.knownFunc(name.text)
+	if arity < 0 {
+		return nil, fmt.Errorf("位置 %d：未知函数 %s", name.pos, name.text)
+	}
+	call := &callNode{name: name.text}
+	if _, ok := p.accept(")"); !ok {
+		for {
+			arg, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			call.args = append(call.args, arg)
+			if _, ok := p.accept(","); ok {
+				continue
+			}
+			if _, ok := p.accept(")"); !ok {
+				return nil, fmt.Errorf("位置 %d：函数 %s 缺少右括号", p.peek().pos, name.text)
+			}
+			break
+		}
+	}
+	if len(call.args) != arity {
+		return nil, fmt.Errorf("函数 %s 需要 %d 个参数，实际为 %d 个", name.text, arity, len(call.args))
+	}
+	return call, nil
+}
+
+// literalNode 字面量
+type literalNode struct {
+	value interface{}
+}
+
+func (n *literalNode) eval(exprEnv) (interface{}, error) { return n.value, nil }
+
+// valueOrNil 返回字面量的值，节点为 nil 时返回 nil
+func (n *literalNode) valueOrNil() interface{} {
+	if n == nil {
+		return nil
+	}
+	return n.value
+}
+
+// varNode 变量
+type varNode struct {
+	name string
+}
+
+func (n *varNode) eval(env exprEnv) (interface{}, error) { return env.lookup(n.name), nil }
+
+// callNode 函数调用
+type callNode struct {
+	name string
+	args []exprNode
+}
+
+func (n *callNode) eval(env exprEnv) (interface{}, error) {
+	args := make([]interface{}, len(n.args))
+	for i, a := range n.args {
+		v, err := a.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = v
+	}
+	return env.call(n.name, args)
+}
+
+// unaryNode 一元运算
+type unaryNode struct {
+	op      string
+	operand exprNode
+}
+
+func (n *unaryNode) eval(env exprEnv) (interface{}, error) {
+	v, err := n.operand.eval(env)
+	if err != nil || v == nil {
+		return nil, err
+	}
+	if n.op == "!" {
+		b, ok := v.(bool)
+		if !ok {
+			return nil, fmt.Errorf("! 的操作数不是布尔值：%v", v)
+		}
+		return !b, nil
+	}
+	f, ok := v.(float64)
+	if !ok {
+		return nil, fmt.Errorf("- 的操作数不是数字：%v", v)
+	}
+	return -f, nil
+}
+
+// matchNode 正则匹配（=~ / !~），左侧不是字符串（含 null）时为 false
+type matchNode struct {
+	negate bool
+	left   exprNode
+	re     *regexp.Regexp
+}
+
+func (n *matchNode) eval(env exprEnv) (interface{}, error) {
+	v, err := n.left.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	s, ok := v.(string)
+	if !ok {
+		return false, nil
+	}
+	return n.re.MatchString(s) != n.negate, nil
+}
+
+// binaryNode 二元运算
+type binaryNode struct {
+	op          string
+	left, right exprNode
+}
+
+func (n *binaryNode) eval(env exprEnv) (interface{}, error) {
+	left, err := n.left.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	// 逻辑运算短路求值，null 视为 false
+	switch n.op {
+	case "&&", "||":
+		l, err := truthy(left)
+		if err != nil {
+			return nil, err
+		}
+		if l == (n.op == "||") {
+			return l, nil
+		}
+		right, err := n.right.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		return truthy(right)
+	}
+
+	right, err := n.right.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	switch n.op {
+	case "==":
+		return equalValues(left, right), nil
+	case "!=":
+		return !equalValues(left, right), nil
+	}
+	if left == nil || right == nil {
+		switch n.op {
+		case "<", "<=", ">", ">=":
+			return false, nil
+		}
+		return nil, nil
+	}
+
+	if ls, ok := left.(string); ok {
+		rs, ok := right.(string)
+		if !ok {
+			return nil, fmt.Errorf("%s 两侧类型不一致：%v、%v", n.op, left, right)
+		}
+		switch n.op {
+		case "+":
+			return ls + rs, nil
+		case "<":
+			return ls < rs, nil
+		case "<=":
+			return ls <= rs, nil
+		case ">":
+			return ls > rs, nil
+		case ">=":
+			return ls >= rs, nil
+		}
+		return nil, fmt.Errorf("字符串不支持运算符 %s", n.op)
+	}
+
+	l, lok := left.(float64)
+	r, rok := right.(float64)
+	if !lok || !rok {
+		return nil, fmt.Errorf("%s 的操作数必须为数字：%v、%v", n.op, left, right)
+	}
+	switch n.op {
+	case "+":
+		return l + r, nil
+	case "-":
+		return l - r, nil
+	case "*":
+		return l * r, nil
+	case "/":
+		if r == 0 {
+			return nil, nil
+		}
+		return l / r, nil
+	case "%":
+		if r == 0 {
+			return nil, nil
+		}
+		return math.Mod(l, r), nil
+	case "<":
+		return l < r, nil
+	case "<=":
+		return l <= r, nil
+	case ">":
+		return l > r, nil
+	case ">=":
+		return l >= r, nil
+	}
+	return nil, fmt.Errorf("不支持的运算符 %s", n.op)
+}
+
+// truthy 逻辑运算的操作数：布尔值或 null（视为 false）
+func truthy(v interface{}) (bool, error) {
+	switch b := v.(type) {
+	case bool:
+		return b, nil
+	case nil:
+		return false, nil
+	}
+	return false, fmt.Errorf("逻辑运算的操作数不是布尔值：%v", v)
+}
+
+// equalValues 判断两个值是否相等，类型不同时不相等
+func equalValues(a, b interface{}) bool {
+	return a == b
+}
@@ -0,0 +1,159 @@
+package alert
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"servicetelemetry/core"
+)
+
+// Incident 告警事件，单个目标异常或多个目标因共同原因同时异常时产生
+type Incident struct {
+	ID         uint64     `json:"id"`                   // 事件唯一标识
+	GroupKey   string     `json:"groupKey,omitempty"`   // 聚合特征，如 host:api.example.com；单目标事件为空
+	Status     string     `json:"status"`               // 事件状态：firing / resolved
+	Severity   string     `json:"severity"`             // 告警级别，聚合事件取成员中最严重的级别
+	Title      string     `json:"title"`                // 事件标题
+	Targets    []string   `json:"targets"`              // 涉及的目标地址
+	Tags       []string   `json:"tags"`                 // 涉及目标的标签（组件），用于匹配状态订阅
+	Remaining  int        `json:"remaining"`            // 仍处于异常状态的目标数
+	OpenedAt   time.Time  `json:"openedAt"`             // 事件开始时间
+	ResolvedAt *time.Time `json:"resolvedAt"`           // 事件恢复时间，未恢复为空
+	ResolvedBy string     `json:"resolvedBy,omitempty"` // 手动解决人，自动恢复为空
+	AckedAt    *time.Time `json:"ackedAt"`              // 事件确认时间，未确认为空
+	AckedBy    string     `json:"ackedBy,omitempty"`    // 事件确认人
+	// 已发送的升级通知（短信 / 语音电话）次数与最近一次升级时间，未升级时为空
+	Escalations int             `json:"escalations,omitempty"`
+	EscalatedAt *time.Time      `json:"escalatedAt,omitempty"`
+	open        map[string]bool // 仍处于异常状态的目标
+	escalation  *time.Timer     // 尚未执行的升级通知
+	entries     []LogEntry      // 沟通记录，按时间升序
+	nonce       string          // 随操作链接签名的随机值，不持久化，重启前签发的链接不会作用于任何事件
+	dropped     int             // 超出保留条数被丢弃的沟通记录数
+}
+
+// 事件变化类型，通知事件监听器
+const (
+	IncidentOpened   = "opened"   // 事件开启
+	IncidentUpdated  = "updated"  // 聚合事件中部分目标已恢复
+	IncidentResolved = "resolved" // 事件全部目标恢复
+)
+
+// IncidentListener 事件监听器，事件开启 / 更新 / 恢复时调用，incident 为事件快照
+type IncidentListener func(change string, incident *Incident)
+
+// groupTraits 返回监控结果可用于聚合的共同特征键
+func groupTraits(r *core.MonitorResult) []string {
+	var keys []string
+	if host := core.TargetHost(r.TargetURL); host != "" {
+		keys = append(keys, "host:"+host)
+	}
+	for _, t := range r.Tags {
+		if t = strings.TrimSpace(t); t != "" {
+			keys = append(keys, "tag:"+t)
+		}
+	}
+	if r.ErrorType != "" {
+		keys = append(keys, "errorType:"+r.ErrorType)
+	}
+	return keys
+}
+
+// traitPriority 特征优先级，数量相同时优先按主机聚合，其次标签，最后错误类型
+func traitPriority(key string) int {
+	switch {
+	case strings.HasPrefix(key, "host:"):
+		return 0
+	case strings.HasPrefix(key, "tag:"):
+		return 1
+	default:
+		return 2
+	}
+}
+
+// describeTrait 将特征键转换为可读描述
+func describeTrait(key string) string {
+	kind, value, _ := strings.Cut(key, ":")
+	switch kind {
+	case "host":
+		return "同一主机 " + value
+	case "tag":
+		return "相同标签 " + value
+	case "errorType":
+		return "相同错误类型 " + value
+	}
+	return key
+}
+
+// groupResults 按共同特征贪心聚合失败结果：每轮选取覆盖目标最多的特征，达到最小聚合数量则成组
+// results：窗口期内的失败结果
+// minSize：成组所需的最少目标数
+// 返回聚合组（特征键 -> 成员结果）及未被聚合的单个结果
+func groupResults(results []*core.MonitorResult, minSize int) (map[string][]*core.MonitorResult, []*core.MonitorResult) {
+	groups := make(map[string][]*core.MonitorResult)
+	remaining := results
+
+	for minSize > 1 && len(remaining) >= minSize {
+		byTrait := make(map[string][]*core.MonitorResult)
+		for _, r := range remaining {
+			for _, key := range groupTraits(r) {
+				byTrait[key] = append(byTrait[key], r)
+			}
+		}
+
+		bestKey := ""
+		for key, members := range byTrait {
+			if bestKey == "" || len(members) > len(byTrait[bestKey]) ||
+				(len(members) == len(byTrait[bestKey]) &&
+					(traitPriority(key) < traitPriority(bestKey) ||
+						(traitPriority(key) == traitPriority(bestKey) && key < bestKey))) {
+				bestKey = key
+			}
+		}
+		if bestKey == "" || len(byTrait[bestKey]) < minSize {
+			break
+		}
+
+		groups[bestKey] = byTrait[bestKey]
+		inGroup := make(map[*core.MonitorResult]bool)
+		for _, r := range byTrait[bestKey] {
+			inGroup[r] = true
+		}
+		var rest []*core.MonitorResult
+		for _, r := range remaining {
+			if !inGroup[r] {
+				rest = append(rest, r)
+			}
+		}
+		remaining = rest
+	}
+
+	return groups, remaining
+}
+
+// newGroupAlert 构建聚合告警通知
+// incident：聚合事件
+// members：成员结果
+func newGroupAlert(incident *Incident, members []*core.MonitorResult) *Alert {
+	sort.Slice(members, func(i, j int) bool {
+		return members[i].TargetURL < members[j].TargetURL
+	})
+
+	lines := []string{fmt.Sprintf("共同特征：%s", describeTrait(incident.GroupKey)), "异常目标："}
+	for _, r := range members {
+		lines = append(lines, fmt.Sprintf("- %s（%s）%s", r.TargetURL, r.ErrorType, r.ErrorMsg))
+	}
+
+	return &Alert{
+		IncidentID: incident.ID,
+		GroupKey:   incident.GroupKey,
+		Status:     StatusFiring,
+		Severity:   incident.Severity,
+		Title:      incident.Title,
+		Body:       strings.Join(lines, "\n"),
+		Members:    members,
+		FiredAt:    time.Now(),
+	}
+}
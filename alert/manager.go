@@ -0,0 +1,518 @@
+package alert
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"servicetelemetry/config"
+	"servicetelemetry/core"
+	"servicetelemetry/eventbus"
+	"servicetelemetry/logger"
+	"servicetelemetry/metrics"
+	"servicetelemetry/storage"
+)
+
+var log = logger.New("alert")
+
+// Enricher 告警上下文补充接口，由 AI 总结器实现
+type Enricher interface {
+	// EnrichAlert 根据当前结果和近期历史生成一到两句补充说明
+	EnrichAlert(ctx context.Context, result *core.MonitorResult, recent []*core.MonitorResult, maxTokens int) (string, error)
+}
+
+// Manager 告警管理器，根据监控结果的状态变化产生事件，并将告警分发到各通知渠道
+type Manager struct {
+	cfg       *config.AlertConfig
+	storage   *storage.MySQLStorage
+	silences  *SilenceManager
+	enricher  Enricher
+	notifiers []Notifier
+	listeners []IncidentListener
+	rules     []*Rule                 // 告警规则，未配置时为默认规则 status == "failed"
+	templates []*notificationTemplate // 通知模板，按渠道 / 级别 / 状态覆盖默认消息
+	telephony TelephonyProvider       // 升级通知使用的电话服务商，未开启升级时为 nil
+	window    time.Duration           // 聚合变量需要保留的检查结果时长
+	bus       *eventbus.Bus
+	feed      *eventbus.Feed
+	logQueue  chan incidentLogJob // 待写入数据库的沟通记录，由 persistLog 按顺序写入
+
+	mu             sync.Mutex
+	states         map[string]string         // 目标地址 -> 上一次检查状态
+	history        map[string]*targetHistory // 目标地址 -> 近期检查结果（规则的连续状态与聚合变量）
+	alerting       map[string]*Rule          // 目标地址 -> 当前满足的规则，不满足任何规则时为 nil
+	pending        []*core.MonitorResult     // 聚合窗口内等待发送的失败结果
+	flushTimer     *time.Timer               // 聚合窗口定时器
+	incidents      map[uint64]*Incident      // 事件ID -> 事件
+	targetIncident map[string]uint64         // 目标地址 -> 所属未恢复事件ID
+	nextIncidentID uint64
+}
+
+// NewManager 创建一个新的告警管理器
+// cfg：告警配置
+// storage：数据库存储客户端，用于查询近期历史（AI 补充上下文）
+// silences：静默规则管理器
+// enricher：告警上下文补充器，可为 nil
+// 规则表达式或通知模板无效时回退为默认规则 / 默认消息，无效的推送订阅被跳过、无效的升级配置关闭升级通知，
+// 启动时应先调用 Validate 校验
+func NewManager(cfg *config.AlertConfig, storage *storage.MySQLStorage, silences *SilenceManager, enricher Enricher) *Manager {
+	rules, err := CompileRules(cfg.Rules)
+	if err != nil {
+		log.Errorf("告警规则无效，使用默认规则：%v", err)
+		rules, _ = CompileRules(nil)
+	}
+	templates, err := compileTemplates(cfg.Templates)
+	if err != nil {
+		log.Errorf("通知模板无效，使用默认消息：%v", err)
+		templates = nil
+	}
+	m := &Manager{
+		rules:          rules,
+		templates:      templates,
+		window:         ruleWindow(rules),
+		history:        make(map[string]*targetHistory),
+		alerting:       make(map[string]*Rule),
+		cfg:            cfg,
+		storage:        storage,
+		silences:       silences,
+		enricher:       enricher,
+		states:         make(map[string]string),
+		incidents:      make(map[uint64]*Incident),
+		targetIncident: make(map[string]uint64),
+	}
+	if storage != nil {
+		// 事件ID接着重启前已保存的最大ID分配，沟通记录不会混入重启前同ID事件的记录
+		if id, err := storage.MaxIncidentID(); err != nil {
+			log.Errorf("读取已保存的事件ID失败：%v", err)
+		} else {
+			m.nextIncidentID = id
+		}
+		m.logQueue = make(chan incidentLogJob, logQueueSize)
+		go m.persistLog()
+	}
+	for _, url := range cfg.WebhookURLs {
+		m.notifiers = append(m.notifiers, NewWebhookNotifier(url, cfg.SendTimeout))
+	}
+	for _, sub := range cfg.Push {
+		pn, err := NewPushNotifier(sub, cfg.SendTimeout)
+		if err != nil {
+			log.Errorf("推送订阅无效，已跳过：%v", err)
+			continue
+		}
+		m.notifiers = append(m.notifiers, pn)
+	}
+	if cfg.Escalation.Enable {
+		provider, err := newTelephonyProvider(&cfg.Escalation, cfg.SendTimeout)
+		if err != nil {
+			log.Errorf("升级通知配置无效，已关闭升级通知：%v", err)
+		} else {
+			m.telephony = provider
+		}
+	}
+	return m
+}
+
+// AddNotifier 注册额外的通知渠道
+func (m *Manager) AddNotifier(n Notifier) {
+	m.notifiers = append(m.notifiers, n)
+}
+
+// OnIncident 注册事件监听器（如状态订阅通知），监听器在独立协程中调用，不阻塞告警流程
+func (m *Manager) OnIncident(l IncidentListener) {
+	m.listeners = append(m.listeners, l)
+}
+
+// SetEventBus 设置事件总线，状态变化和事件开启 / 恢复时发布事件
+func (m *Manager) SetEventBus(bus *eventbus.Bus) {
+	m.bus = bus
+}
+
+// SetChangeFeed 设置进程内变更流，状态变化与事件变更同时写入，供长轮询接口读取
+func (m *Manager) SetChangeFeed(feed *eventbus.Feed) {
+	m.feed = feed
+}
+
+// publish 将状态变化或事件变更发布到事件总线与变更流
+func (m *Manager) publish(eventType, key string, data interface{}) {
+	m.bus.Emit(eventType, key, data)
+	m.feed.Publish(eventType, key, data)
+}
+
+// Process 处理一条监控结果：目标开始满足告警规则（默认为检查失败）时触发告警，不再满足任何规则时发送恢复通知
+// 开启聚合后，触发告警的结果先进入聚合窗口，窗口结束时按共同特征合并为一个事件
+func (m *Manager) Process(result *core.MonitorResult) {
+	if result == nil {
+		return
+	}
+
+	m.mu.Lock()
+	prev, seen := m.states[result.TargetURL]
+	m.states[result.TargetURL] = result.Status
+	rule, wasAlerting := m.evaluateLocked(result, true)
+
+	// 状态变化事件与告警开关无关，始终发布
+	if seen && prev != result.Status {
+		m.publish(eventbus.TypeTransition, result.TargetURL, &eventbus.TransitionData{
+			TargetURL: result.TargetURL,
+			From:      prev,
+			To:        result.Status,
+			Result:    result,
+		})
+	}
+	if !m.cfg.Enable {
+		m.mu.Unlock()
+		return
+	}
+
+	switch {
+	case rule != nil && !wasAlerting:
+		if m.silences != nil && m.silences.IsSilenced(result.TargetURL) {
+			m.mu.Unlock()
+			return
+		}
+		if m.cfg.Group.Enable && m.cfg.Group.Window > 0 {
+			m.pending = append(m.pending, result)
+			if m.flushTimer == nil {
+				m.flushTimer = time.AfterFunc(m.cfg.Group.Window, m.flush)
+			}
+			m.mu.Unlock()
+			return
+		}
+		a := m.openSingleLocked(result)
+		m.mu.Unlock()
+		go m.dispatch(a)
+
+	case rule == nil && wasAlerting:
+		// 仍在聚合窗口内即恢复的目标直接移出，不再告警
+		for i, r := range m.pending {
+			if r.TargetURL == result.TargetURL {
+				m.pending = append(m.pending[:i], m.pending[i+1:]...)
+				m.mu.Unlock()
+				return
+			}
+		}
+		a := m.resolveTargetLocked(result)
+		m.mu.Unlock()
+		if a != nil {
+			go m.dispatch(a)
+		}
+
+	default:
+		m.mu.Unlock()
+	}
+}
+
+// Seed 使用历史结果初始化各目标的上一次状态（启动预热时调用），不触发告警
+// 避免重启后首轮检查把仍在故障中的目标当作新故障重复告警；已有状态的目标不会被覆盖
+func (m *Manager) Seed(results []*core.MonitorResult) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, r := range results {
+		if _, ok := m.states[r.TargetURL]; !ok {
+			m.states[r.TargetURL] = r.Status
+			m.evaluateLocked(r, true)
+		}
+	}
+}
+
+// evaluateLocked 按告警规则判断结果，返回本次满足的规则（不满足任何规则时为 nil）与此前是否处于告警状态，调用方需持有锁
+// commit：是否记录本次结果与告警状态（演练预览时为 false）
+func (m *Manager) evaluateLocked(result *core.MonitorResult, commit bool) (*Rule, bool) {
+	hist := m.history[result.TargetURL]
+	prev := m.alerting[result.TargetURL]
+	rule := matchRule(m.rules, result, hist)
+	if commit {
+		if hist == nil {
+			hist = &targetHistory{}
+			m.history[result.TargetURL] = hist
+		}
+		hist.record(result, m.window)
+		m.alerting[result.TargetURL] = rule
+	}
+	return rule, prev != nil
+}
+
+// Notify 发送一条与检查结果无关的告警（如证书透明度发现），遵循告警开关与静默规则
+func (m *Manager) Notify(a *Alert) {
+	if !m.cfg.Enable || a == nil {
+		return
+	}
+	if a.TargetURL != "" && m.silences != nil && m.silences.IsSilenced(a.TargetURL) {
+		return
+	}
+	go m.dispatch(a)
+}
+
+// Preview 预演一条监控结果将产生的告警，不修改状态也不发送通知，供演练模式使用
+// 返回 nil 表示该结果不会触发告警（状态未变化、告警未开启或目标处于静默中）
+func (m *Manager) Preview(result *core.MonitorResult) *Alert {
+	if !m.cfg.Enable || result == nil {
+		return nil
+	}
+
+	m.mu.Lock()
+	rule, wasAlerting := m.evaluateLocked(result, false)
+	_, hasIncident := m.targetIncident[result.TargetURL]
+	m.mu.Unlock()
+
+	switch {
+	case rule != nil && !wasAlerting:
+		if m.silences != nil && m.silences.IsSilenced(result.TargetURL) {
+			return nil
+		}
+		return newRuleAlert(StatusFiring, result, rule)
+	case rule == nil && wasAlerting && hasIncident:
+		return newRuleAlert(StatusResolved, result, nil)
+	}
+	return nil
+}
+
+// flush 聚合窗口结束，按共同特征合并失败结果并发送告警
+func (m *Manager) flush() {
+	m.mu.Lock()
+	pending := m.pending
+	m.pending = nil
+	m.flushTimer = nil
+
+	groups, singles := groupResults(pending, m.cfg.Group.MinSize)
+
+	var alerts []*Alert
+	keys := make([]string, 0, len(groups))
+	for key := range groups {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		alerts = append(alerts, m.openGroupLocked(key, groups[key]))
+	}
+	for _, r := range singles {
+		alerts = append(alerts, m.openSingleLocked(r))
+	}
+	m.mu.Unlock()
+
+	for _, a := range alerts {
+		go m.dispatch(a)
+	}
+}
+
+// openSingleLocked 为单个目标创建事件并返回告警通知，调用方需持有锁
+func (m *Manager) openSingleLocked(result *core.MonitorResult) *Alert {
+	a := newRuleAlert(StatusFiring, result, m.alerting[result.TargetURL])
+	incident := m.newIncidentLocked("", a.Title, a.Severity, []*core.MonitorResult{result})
+	a.IncidentID = incident.ID
+	return a
+}
+
+// openGroupLocked 为一组共同原因的目标创建聚合事件并返回告警通知，调用方需持有锁
+func (m *Manager) openGroupLocked(key string, members []*core.MonitorResult) *Alert {
+	title := fmt.Sprintf("【聚合告警】%d 个目标同时异常（%s）", len(members), describeTrait(key))
+	severity := SeverityInfo
+	for _, r := range members {
+		if rule := m.alerting[r.TargetURL]; rule != nil && severityRank[rule.Severity] > severityRank[severity] {
+			severity = rule.Severity
+		}
+	}
+	incident := m.newIncidentLocked(key, title, severity, members)
+	return newGroupAlert(incident, members)
+}
+
+// newIncidentLocked 创建事件并登记目标归属，调用方需持有锁
+func (m *Manager) newIncidentLocked(key, title, severity string, members []*core.MonitorResult) *Incident {
+	m.nextIncidentID++
+	incident := &Incident{
+		ID:       m.nextIncidentID,
+		GroupKey: key,
+		Status:   StatusFiring,
+		Severity: severity,
+		Title:    title,
+		OpenedAt: time.Now(),
+		open:     make(map[string]bool),
+		nonce:    newActionNonce(),
+	}
+	seenTags := make(map[string]bool)
+	for _, r := range members {
+		incident.Targets = append(incident.Targets, r.TargetURL)
+		incident.open[r.TargetURL] = true
+		m.targetIncident[r.TargetURL] = incident.ID
+		for _, t := range r.Tags {
+			if t = strings.TrimSpace(t); t != "" && !seenTags[t] {
+				seenTags[t] = true
+				incident.Tags = append(incident.Tags, t)
+			}
+		}
+	}
+	incident.Remaining = len(incident.open)
+	m.pruneIncidentsLocked(incident.OpenedAt)
+	m.incidents[incident.ID] = incident
+	m.appendLogLocked(incident, LogEntry{Kind: LogOpened, Message: fmt.Sprintf("%s（涉及 %d 个目标）", title, len(incident.Targets))})
+	m.scheduleEscalationLocked(incident, m.cfg.Escalation.After)
+	m.emitIncidentLocked(IncidentOpened, incident)
+	return incident
+}
+
+// resolveTargetLocked 标记目标已恢复，所属事件全部目标恢复时返回恢复通知，调用方需持有锁
+func (m *Manager) resolveTargetLocked(result *core.MonitorResult) *Alert {
+	id, ok := m.targetIncident[result.TargetURL]
+	if !ok {
+		return nil
+	}
+	delete(m.targetIncident, result.TargetURL)
+
+	incident := m.incidents[id]
+	delete(incident.open, result.TargetURL)
+	incident.Remaining = len(incident.open)
+	if len(incident.open) > 0 {
+		m.appendLogLocked(incident, LogEntry{Kind: LogUpdated, Message: fmt.Sprintf("%s 已恢复，仍有 %d 个目标异常", result.TargetURL, incident.Remaining)})
+		m.emitIncidentLocked(IncidentUpdated, incident)
+		return nil
+	}
+
+	now := time.Now()
+	incident.Status = StatusResolved
+	incident.ResolvedAt = &now
+	m.stopEscalationLocked(incident)
+	m.appendLogLocked(incident, LogEntry{Time: now, Kind: LogResolved, Message: fmt.Sprintf("全部目标已恢复，持续 %s", now.Sub(incident.OpenedAt).Round(time.Second))})
+	m.emitIncidentLocked(IncidentResolved, incident)
+
+	if incident.GroupKey == "" {
+		a := newAlert(StatusResolved, result)
+		a.IncidentID = incident.ID
+		a.Severity = incident.Severity
+		return a
+	}
+	return &Alert{
+		IncidentID: incident.ID,
+		GroupKey:   incident.GroupKey,
+		Status:     StatusResolved,
+		Severity:   incident.Severity,
+		Title:      fmt.Sprintf("【恢复】聚合告警 #%d 的 %d 个目标已全部恢复", incident.ID, len(incident.Targets)),
+		Body:       fmt.Sprintf("共同特征：%s\n持续时长：%s", describeTrait(incident.GroupKey), now.Sub(incident.OpenedAt).Round(time.Second)),
+		FiredAt:    now,
+	}
+}
+
+// pruneIncidentsLocked 清除恢复时间早于保留时长的事件，调用方需持有锁
+func (m *Manager) pruneIncidentsLocked(now time.Time) {
+	if m.cfg.IncidentRetention <= 0 {
+		return
+	}
+	cutoff := now.Add(-m.cfg.IncidentRetention)
+	for id, incident := range m.incidents {
+		if incident.Status == StatusResolved && incident.ResolvedAt != nil && incident.ResolvedAt.Before(cutoff) {
+			delete(m.incidents, id)
+		}
+	}
+}
+
+// emitIncidentLocked 发布事件开启 / 更新 / 恢复消息（发布事件快照）并通知事件监听器，调用方需持有锁
+func (m *Manager) emitIncidentLocked(change string, incident *Incident) {
+	snapshot := incidentSnapshot(incident)
+	m.publish(eventbus.TypeIncident, fmt.Sprintf("%d", incident.ID), snapshot)
+	for _, l := range m.listeners {
+		go l(change, snapshot)
+	}
+}
+
+// incidentSnapshot 复制事件，供锁外读取
+func incidentSnapshot(incident *Incident) *Incident {
+	snapshot := *incident
+	snapshot.Targets = append([]string(nil), incident.Targets...)
+	snapshot.Tags = append([]string(nil), incident.Tags...)
+	snapshot.open = nil
+	snapshot.escalation = nil
+	snapshot.entries = nil
+	return &snapshot
+}
+
+// Incidents 返回全部事件，未恢复的排在前面，其余按开始时间倒序
+func (m *Manager) Incidents() []*Incident {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	list := make([]*Incident, 0, len(m.incidents))
+	for _, incident := range m.incidents {
+		list = append(list, incidentSnapshot(incident))
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Status != list[j].Status {
+			return list[i].Status == StatusFiring
+		}
+		return list[i].OpenedAt.After(list[j].OpenedAt)
+	})
+	return list
+}
+
+// dispatch 补充 AI 上下文与操作链接后发送告警到全部通知渠道
+func (m *Manager) dispatch(a *Alert) {
+	if a.Status == StatusFiring && a.Result != nil {
+		a.Context = m.enrich(a.Result)
+	}
+	if a.Status == StatusFiring {
+		a.Actions = m.actionLinks(a.IncidentID, "")
+	}
+
+	for _, n := range m.notifiers {
+		if f, ok := n.(severityFilter); ok && !f.Accepts(a) {
+			continue
+		}
+		start := time.Now()
+		rendered := m.renderFor(n.Name(), a)
+		err := n.Send(rendered)
+		metrics.ObserveDelivery(metrics.KindAlert, n.Name(), err, start)
+		if err != nil {
+			log.Errorf("发送告警[%s]到渠道[%s]失败：%v", a.Title, n.Name(), err)
+		}
+		entry := LogEntry{Time: start, Kind: LogNotification, Channel: n.Name(), Message: rendered.Title}
+		if r, ok := n.(recipientNamer); ok {
+			entry.Recipient = r.Recipient()
+		}
+		if err != nil {
+			entry.Error = err.Error()
+		}
+		m.RecordCommunication(a.IncidentID, entry)
+	}
+}
+
+// enrich 在延迟预算内调用 AI 生成补充说明，任何失败都回退为空（即纯模板正文）
+func (m *Manager) enrich(result *core.MonitorResult) string {
+	ec := m.cfg.Enrich
+	if !ec.Enable || m.enricher == nil {
+		return ""
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), ec.Timeout)
+	defer cancel()
+
+	var recent []*core.MonitorResult
+	if m.storage != nil && ec.HistoryLimit > 0 {
+		endTime := time.Now()
+		history, err := m.storage.QueryResults(result.TargetURL, endTime.Add(-24*time.Hour), endTime, ec.HistoryLimit)
+		if err == nil {
+			recent = history
+		}
+	}
+
+	type enrichResult struct {
+		text string
+		err  error
+	}
+	done := make(chan enrichResult, 1)
+	go func() {
+		text, err := m.enricher.EnrichAlert(ctx, result, recent, ec.MaxTokens)
+		done <- enrichResult{text: text, err: err}
+	}()
+
+	select {
+	case r := <-done:
+		if r.err != nil {
+			log.Warnf("告警[%s]AI补充失败，使用普通模板：%v", result.TargetURL, r.err)
+			return ""
+		}
+		return r.text
+	case <-ctx.Done():
+		log.Warnf("告警[%s]AI补充超时，使用普通模板", result.TargetURL)
+		return ""
+	}
+}
@@ -0,0 +1,241 @@
+package alert
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"servicetelemetry/config"
+)
+
+// 个人手机推送服务
+const (
+	PushNtfy   = "ntfy"
+	PushGotify = "gotify"
+	PushBark   = "bark"
+)
+
+// 推送服务的默认地址（gotify 为自建服务，没有默认地址）
+var defaultPushServers = map[string]string{
+	PushNtfy: "https://ntfy.sh",
+	PushBark: "https://api.day.app",
+}
+
+// severityFilter 可选接口：通知渠道只接收部分告警时实现，dispatch 跳过不接收的告警
+type severityFilter interface {
+	Accepts(a *Alert) bool
+}
+
+// PushNotifier 个人手机推送通知渠道（ntfy / Gotify / Bark）
+type PushNotifier struct {
+	sub    config.PushSubscription
+	server string
+	token  string
+	client *http.Client
+}
+
+// NewPushNotifier 创建一个新的手机推送通知渠道，令牌引用在创建时解析
+// sub：推送订阅配置
+// timeout：推送超时时间
+func NewPushNotifier(sub config.PushSubscription, timeout time.Duration) (*PushNotifier, error) {
+	if err := validatePush(sub); err != nil {
+		return nil, err
+	}
+	token, err := config.ResolveSecret(sub.Token)
+	if err != nil {
+		return nil, fmt.Errorf("解析 %s 的 %s 令牌失败：%w", sub.User, sub.Type, err)
+	}
+	server := sub.Server
+	if server == "" {
+		server = defaultPushServers[sub.Type]
+	}
+	return &PushNotifier{
+		sub:    sub,
+		server: strings.TrimRight(server, "/"),
+		token:  token,
+		client: &http.Client{Timeout: timeout},
+	}, nil
+}
+
+// validatePush 校验推送订阅配置（不解析令牌引用）
+func validatePush(sub config.PushSubscription) error {
+	if sub.User == "" {
+		return fmt.Errorf("推送订阅缺少 user")
+	}
+	if sub.MinSeverity != "" && severityRank[sub.MinSeverity] == 0 {
+		return fmt.Errorf("%s 的推送订阅：无效的最低告警级别 %s，可选 critical / warning / info", sub.User, sub.MinSeverity)
+	}
+	switch sub.Type {
+	case PushNtfy:
+		if sub.Topic == "" {
+			return fmt.Errorf("%s 的 ntfy 订阅缺少 topic", sub.User)
+		}
+	case PushGotify:
+		if sub.Server == "" || sub.Token == "" {
+			return fmt.Errorf("%s 的 gotify 订阅需要配置 server 与 token（应用令牌）", sub.User)
+		}
+	case PushBark:
+		if sub.Token == "" {
+			return fmt.Errorf("%s 的 bark 订阅需要配置 token（设备 key）", sub.User)
+		}
+	default:
+		return fmt.Errorf("%s 的推送订阅：不支持的推送服务 %q，可选 ntfy / gotify / bark", sub.User, sub.Type)
+	}
+	if sub.Server != "" && !strings.HasPrefix(sub.Server, "https://") && !strings.HasPrefix(sub.Server, "http://") {
+		return fmt.Errorf("%s 的 %s 订阅：server 必须是 http(s) 地址", sub.User, sub.Type)
+	}
+	return nil
+}
+
+// Name 返回渠道名称（推送服务类型，用于按渠道选择通知模板）
+func (pn *PushNotifier) Name() string {
+	return pn.sub.Type
+}
+
+// Recipient 返回订阅人，记录在事件沟通记录中
+func (pn *PushNotifier) Recipient() string {
+	return pn.sub.User
+}
+
+// Accepts 判断告警级别是否不低于订阅的最低级别，未设置级别的告警按 critical 处理
+func (pn *PushNotifier) Accepts(a *Alert) bool {
+	if pn.sub.MinSeverity == "" {
+		return true
+	}
+	severity := a.Severity
+	if severity == "" {
+		severity = SeverityCritical
+	}
+	return severityRank[severity] >= severityRank[pn.sub.MinSeverity]
+}
+
+// Send 推送告警到订阅人的手机
+func (pn *PushNotifier) Send(a *Alert) error {
+	var (
+		url     string
+		payload interface{}
+		header  = http.Header{}
+	)
+	switch pn.sub.Type {
+	case PushNtfy:
+		url, payload = pn.server, pn.ntfyMessage(a)
+		if pn.token != "" {
+			header.Set("Authorization", "Bearer "+pn.token)
+		}
+	case PushGotify:
+		url, payload = pn.server+"/message", pn.gotifyMessage(a)
+		header.Set("X-Gotify-Key", pn.token)
+	case PushBark:
+		url, payload = pn.server+"/push", pn.barkMessage(a)
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("序列化推送消息失败：%w", err)
+	}
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("创建推送请求失败：%w", err)
+	}
+	req.Header = header
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+
+	resp, err := pn.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("推送到 %s 的 %s 失败：%w", pn.sub.User, pn.sub.Type, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("推送到 %s 的 %s 返回异常状态码：%d %s", pn.sub.User, pn.sub.Type, resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// clickURL 点击通知时打开的链接：触发告警为一键确认链接（需开启 alert.actions）
+func clickURL(a *Alert) string {
+	if a.Actions != nil {
+		return a.Actions.Acknowledge
+	}
+	return ""
+}
+
+// ntfyMessage 构建 ntfy JSON 发布消息：级别映射为优先级，操作链接映射为通知按钮
+func (pn *PushNotifier) ntfyMessage(a *Alert) map[string]interface{} {
+	priority, tag := 3, "information_source"
+	switch {
+	case a.Status == StatusResolved:
+		tag = "white_check_mark"
+	case a.Status == StatusAcknowledged:
+		tag = "eyes"
+	case a.Severity == SeverityWarning:
+		priority, tag = 4, "warning"
+	case a.Severity != SeverityInfo:
+		priority, tag = 5, "rotating_light"
+	}
+	msg := map[string]interface{}{
+		"topic":    pn.sub.Topic,
+		"title":    a.Title,
+		"message":  a.FullBody(),
+		"priority": priority,
+		"tags":     []string{tag},
+	}
+	if a.Actions != nil {
+		msg["actions"] = []map[string]string{
+			{"action": "view", "label": "确认", "url": a.Actions.Acknowledge},
+			{"action": "view", "label": "解决", "url": a.Actions.Resolve},
+		}
+	}
+	return msg
+}
+
+// gotifyMessage 构建 Gotify 消息：级别映射为优先级（8 及以上在 Android 客户端中会弹出提醒）
+func (pn *PushNotifier) gotifyMessage(a *Alert) map[string]interface{} {
+	priority := 2
+	switch {
+	case a.Status != StatusFiring:
+		priority = 4
+	case a.Severity == SeverityWarning:
+		priority = 6
+	case a.Severity != SeverityInfo:
+		priority = 8
+	}
+	msg := map[string]interface{}{
+		"title":    a.Title,
+		"message":  a.FullBody(),
+		"priority": priority,
+	}
+	if u := clickURL(a); u != "" {
+		msg["extras"] = map[string]interface{}{
+			"client::notification": map[string]interface{}{"click": map[string]string{"url": u}},
+		}
+	}
+	return msg
+}
+
+// barkMessage 构建 Bark 推送：critical 告警使用重要警告（静音与勿扰模式下仍响铃），warning 使用时效性通知
+func (pn *PushNotifier) barkMessage(a *Alert) map[string]interface{} {
+	level := "active"
+	switch {
+	case a.Status != StatusFiring || a.Severity == SeverityInfo:
+	case a.Severity == SeverityWarning:
+		level = "timeSensitive"
+	default:
+		level = "critical"
+	}
+	msg := map[string]interface{}{
+		"device_key": pn.token,
+		"title":      a.Title,
+		"body":       a.FullBody(),
+		"level":      level,
+		"group":      "servicetelemetry",
+	}
+	if u := clickURL(a); u != "" {
+		msg["url"] = u
+	}
+	return msg
+}
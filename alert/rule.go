@@ -0,0 +1,455 @@
+package alert
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"servicetelemetry/config"
+	"servicetelemetry/core"
+)
+
+// DefaultRuleExpr 未配置告警规则时的默认条件：检查失败即告警
+const DefaultRuleExpr = `status == "failed"`
+
+// maxRuleWindow 聚合变量的最大窗口，窗口内的检查结果保存在内存中
+const maxRuleWindow = 24 * time.Hour
+
+// Rule 编译后的告警规则
+type Rule struct {
+	Name     string // 规则名称
+	Severity string // 告警级别
+	expr     *Expr
+	window   time.Duration // 表达式中聚合变量的最大窗口
+	implicit bool          // 未配置规则时使用的默认规则，告警标题与正文保持原有格式
+}
+
+// Expr 返回规则的条件表达式
+func (r *Rule) Expr() string {
+	return r.expr.String()
+}
+
+// ruleVars 取自检查结果的变量
+var ruleVars = map[string]func(r *core.MonitorResult) interface{}{
+	"status":          func(r *core.MonitorResult) interface{} { return r.Status },
+	"status_code":     func(r *core.MonitorResult) interface{} { return float64(r.StatusCode) },
+	"response_time":   func(r *core.MonitorResult) interface{} { return r.ResponseTime },
+	"error_type":      func(r *core.MonitorResult) interface{} { return r.ErrorType },
+	"error_msg":       func(r *core.MonitorResult) interface{} { return r.ErrorMsg },
+	"warning":         func(r *core.MonitorResult) interface{} { return r.Warning },
+	"target_url":      func(r *core.MonitorResult) interface{} { return r.TargetURL },
+	"host":            func(r *core.MonitorResult) interface{} { return core.TargetHost(r.TargetURL) },
+	"keyword_matched": func(r *core.MonitorResult) interface{} { return r.KeywordMatched },
+	"ssl_days_left": func(r *core.MonitorResult) interface{} {
+		if r.SSLDaysLeft == nil {
+			return nil
+		}
+		return float64(*r.SSLDaysLeft)
+	},
+	"domain_days_left": func(r *core.MonitorResult) interface{} {
+		if r.Details == nil || r.Details.Domain == nil || r.Details.Domain.DaysLeft == nil {
+			return nil
+		}
+		return float64(*r.Details.Domain.DaysLeft)
+	},
+	"dns_ms":      timingVar(func(t *core.PhaseTimings) float64 { return t.DNSMs }),
+	"connect_ms":  timingVar(func(t *core.PhaseTimings) float64 { return t.ConnectMs }),
+	"tls_ms":      timingVar(func(t *core.PhaseTimings) float64 { return t.TLSMs }),
+	"ttfb_ms":     timingVar(func(t *core.PhaseTimings) float64 { return t.TTFBMs }),
+	"download_ms": timingVar(func(t *core.PhaseTimings) float64 { return t.DownloadMs }),
+}
+
+// timingVar 取自 HTTP 阶段耗时的变量，非 HTTP 检查为 null
+func timingVar(field func(t *core.PhaseTimings) float64) func(r *core.MonitorResult) interface{} {
+	return func(r *core.MonitorResult) interface{} {
+		if r.Timings == nil {
+			return nil
+		}
+		return field(r.Timings)
+	}
+}
+
+// 目标连续状态变量（含本次结果）
+const (
+	varConsecutiveFailures  = "consecutive_failures"
+	varConsecutiveSuccesses = "consecutive_successes"
+)
+
+// ruleFuncs 表达式可用的函数及参数个数
+var ruleFuncs = map[string]int{
+	"has_tag":     1, // has_tag("payment")：目标带有该标签
+	"contains":    2, // contains(error_msg, "refused")：字符串包含子串
+	"starts_with": 2, // starts_with(target_url, "https://")：字符串以前缀开头
+}
+
+// aggregatePattern 滑动窗口聚合变量：<聚合>_<窗口>，窗口单位 s / m / h / d，如 p95_latency_5m、failure_rate_1h
+var aggregatePattern = regexp.MustCompile(`^(p(\d{1,2})_latency|avg_latency|max_latency|min_latency|failure_rate|failures|checks)_(\d+)([smhd])$`)
+
+// aggregate 解析后的聚合变量
+type aggregate struct {
+	kind       string  // 聚合方式：p<N>_latency / avg_latency / max_latency / min_latency / failure_rate / failures / checks
+	percentile float64 // 百分位（仅 p<N>_latency）
+	window     time.Duration
+}
+
+// parseAggregate 解析聚合变量名，不是聚合变量时返回 false
+func parseAggregate(name string) (aggregate, bool) {
+	m := aggregatePattern.FindStringSubmatch(name)
+	if m == nil {
+		return aggregate{}, false
+	}
+	n, _ := strconv.Atoi(m[3])
+	unit := map[string]time.Duration{"s": time.Second, "m": time.Minute, "h": time.Hour, "d": 24 * time.Hour}[m[4]]
+	agg := aggregate{kind: m[1], window: time.Duration(n) * unit}
+	if m[2] != "" {
+		agg.kind = "percentile"
+		agg.percentile, _ = strconv.ParseFloat(m[2], 64)
+	}
+	return agg, agg.window > 0 && agg.window <= maxRuleWindow
+}
+
+// ruleSymbols 编译期的变量与函数校验，同时记录聚合变量的最大窗口
+type ruleSymbols struct {
+	window time.Duration
+	vars   []string // 表达式引用的变量（去重，按出现顺序）
+}
+
+func (s *ruleSymbols) knownVar(name string) bool {
+	_, ok := ruleVars[name]
+	if !ok && name != varConsecutiveFailures && name != varConsecutiveSuccesses {
+		agg, isAggregate := parseAggregate(name)
+		if !isAggregate {
+			return false
+		}
+		if agg.window > s.window {
+			s.window = agg.window
+		}
+	}
+	for _, v := range s.vars {
+		if v == name {
+			return true
+		}
+	}
+	s.vars = append(s.vars, name)
+	return true
+}
+
+func (s *ruleSymbols) knownFunc(name string) int {
+	if n, ok := ruleFuncs[name]; ok {
+		return n
+	}
+	return -1
+}
+
+// CompileRules 编译告警规则，rules 为空时返回默认规则（status == "failed"）
+func CompileRules(rules []config.AlertRule) ([]*Rule, error) {
+	if len(rules) == 0 {
+		expr, _ := compileExpr(DefaultRuleExpr, &ruleSymbols{})
+		return []*Rule{{Name: "failed", Severity: SeverityCritical, expr: expr, implicit: true}}, nil
+	}
+	seen := make(map[string]bool)
+	compiled := make([]*Rule, 0, len(rules))
+	for i, r := range rules {
+		name := strings.TrimSpace(r.Name)
+		if name == "" {
+			return nil, fmt.Errorf("alert.rules[%d]：规则名称不能为空", i)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("alert.rules[%d]：规则名称重复：%s", i, name)
+		}
+		seen[name] = true
+		severity := r.Severity
+		if severity == "" {
+			severity = SeverityCritical
+		}
+		if severityRank[severity] == 0 {
+			return nil, fmt.Errorf("alert.rules[%d] %s：无效的告警级别 %s，可选 critical / warning / info", i, name, r.Severity)
+		}
+		symbols := &ruleSymbols{}
+		expr, err := compileExpr(r.Expr, symbols)
+		if err != nil {
+			return nil, fmt.Errorf("alert.rules[%d] %s：%w", i, name, err)
+		}
+		compiled = append(compiled, &Rule{Name: name, Severity: severity, expr: expr, window: symbols.window})
+	}
+	return compiled, nil
+}
+
+// Validate 校验告警配置中的规则表达式、通知模板、推送订阅与升级通知
+func Validate(cfg *config.AlertConfig) error {
+	if _, err := CompileRules(cfg.Rules); err != nil {
+		return err
+	}
+	if _, err := compileTemplates(cfg.Templates); err != nil {
+		return err
+	}
+	for i, sub := range cfg.Push {
+		if _, err := NewPushNotifier(sub, cfg.SendTimeout); err != nil {
+			return fmt.Errorf("alert.push[%d]：%w", i, err)
+		}
+	}
+	if cfg.Escalation.Enable {
+		if _, err := newTelephonyProvider(&cfg.Escalation, cfg.SendTimeout); err != nil {
+			return fmt.Errorf("alert.escalation：%w", err)
+		}
+	}
+	return nil
+}
+
+// ruleWindow 规则中聚合变量的最大窗口，即需要在内存中保留的检查结果时长
+func ruleWindow(rules []*Rule) time.Duration {
+	var window time.Duration
+	for _, r := range rules {
+		if r.window > window {
+			window = r.window
+		}
+	}
+	return window
+}
+
+// ruleSample 窗口内的一次检查结果
+type ruleSample struct {
+	at           time.Time
+	failed       bool
+	responseTime float64
+}
+
+// targetHistory 目标的近期检查结果，供连续状态与聚合变量使用
+type targetHistory struct {
+	samples              []ruleSample // 按检查时间排列，只保留聚合窗口内的结果
+	consecutiveFailures  int
+	consecutiveSuccesses int
+	last                 *core.MonitorResult // 最近一次检查结果，供规则试算使用
+}
+
+// record 记录一次检查结果，丢弃窗口外的旧结果
+func (h *targetHistory) record(result *core.MonitorResult, window time.Duration) {
+	if result.Status == core.StatusFailed {
+		h.consecutiveFailures++
+		h.consecutiveSuccesses = 0
+	} else {
+		h.consecutiveSuccesses++
+		h.consecutiveFailures = 0
+	}
+	h.last = result
+	if window <= 0 {
+		return
+	}
+	h.samples = append(h.samples, ruleSample{at: result.CheckedAt, failed: result.Status == core.StatusFailed, responseTime: result.ResponseTime})
+	cutoff := result.CheckedAt.Add(-window)
+	drop := 0
+	for drop < len(h.samples) && !h.samples[drop].at.After(cutoff) {
+		drop++
+	}
+	h.samples = h.samples[drop:]
+}
+
+// ruleEnv 一次规则求值的变量环境：历史结果加本次结果
+type ruleEnv struct {
+	result   *core.MonitorResult
+	hist     *targetHistory
+	recorded bool // 本次结果已记录在 hist 中（规则试算时使用目标的最近一次结果）
+}
+
+func (e *ruleEnv) lookup(name string) interface{} {
+	if get, ok := ruleVars[name]; ok {
+		return get(e.result)
+	}
+	failed := e.result.Status == core.StatusFailed
+	pending := 1
+	if e.recorded {
+		pending = 0
+	}
+	switch name {
+	case varConsecutiveFailures:
+		if !failed {
+			return float64(0)
+		}
+		return float64(e.hist.consecutiveFailures + pending)
+	case varConsecutiveSuccesses:
+		if failed {
+			return float64(0)
+		}
+		return float64(e.hist.consecutiveSuccesses + pending)
+	}
+	agg, ok := parseAggregate(name)
+	if !ok {
+		return nil
+	}
+	return e.aggregate(agg)
+}
+
+// aggregate 计算窗口（本次检查时间往前 window，含本次结果）内的聚合值，延迟类聚合包含失败结果的耗时
+func (e *ruleEnv) aggregate(agg aggregate) interface{} {
+	cutoff := e.result.CheckedAt.Add(-agg.window)
+	var samples []ruleSample
+	if !e.recorded || len(e.hist.samples) == 0 {
+		samples = append(samples, ruleSample{at: e.result.CheckedAt, failed: e.result.Status == core.StatusFailed, responseTime: e.result.ResponseTime})
+	}
+	for _, s := range e.hist.samples {
+		if s.at.After(cutoff) && !s.at.After(e.result.CheckedAt) {
+			samples = append(samples, s)
+		}
+	}
+
+	failures := 0
+	latencies := make([]float64, 0, len(samples))
+	for _, s := range samples {
+		if s.failed {
+			failures++
+		}
+		latencies = append(latencies, s.responseTime)
+	}
+	sort.Float64s(latencies)
+
+	switch agg.kind {
+	case "checks":
+		return float64(len(samples))
+	case "failures":
+		return float64(failures)
+	case "failure_rate":
+		return float64(failures) / float64(len(samples))
+	case "min_latency":
+		return latencies[0]
+	case "max_latency":
+		return latencies[len(latencies)-1]
+	case "avg_latency":
+		sum := 0.0
+		for _, l := range latencies {
+			sum += l
+		}
+		return sum / float64(len(latencies))
+	case "percentile":
+		// 最近秩法：第 ceil(p/100 × n) 个值
+		rank := int(math.Ceil(agg.percentile / 100 * float64(len(latencies))))
+		if rank < 1 {
+			rank = 1
+		}
+		return latencies[rank-1]
+	}
+	return nil
+}
+
+func (e *ruleEnv) call(name string, args []interface{}) (interface{}, error) {
+	strs := make([]string, len(args))
+	for i, a := range args {
+		s, ok := a.(string)
+		if !ok {
+			if a == nil {
+				return false, nil
+			}
+			return nil, fmt.Errorf("函数 %s 的第 %d 个参数不是字符串：%v", name, i+1, a)
+		}
+		strs[i] = s
+	}
+	switch name {
+	case "has_tag":
+		for _, t := range e.result.Tags {
+			if strings.EqualFold(strings.TrimSpace(t), strs[0]) {
+				return true, nil
+			}
+		}
+		return false, nil
+	case "contains":
+		return strings.Contains(strs[0], strs[1]), nil
+	case "starts_with":
+		return strings.HasPrefix(strs[0], strs[1]), nil
+	}
+	return nil, fmt.Errorf("未知函数 %s", name)
+}
+
+// matchRule 返回本次结果满足的第一条规则，均不满足时返回 nil；求值出错的规则记录日志并视为不满足
+// hist：目标的历史结果（不含本次），可为 nil
+func matchRule(rules []*Rule, result *core.MonitorResult, hist *targetHistory) *Rule {
+	if hist == nil {
+		hist = &targetHistory{}
+	}
+	env := &ruleEnv{result: result, hist: hist}
+	for _, r := range rules {
+		ok, err := r.expr.eval(env)
+		if err != nil {
+			log.Warnf("告警规则[%s]对目标[%s]求值失败：%v", r.Name, result.TargetURL, err)
+			continue
+		}
+		if ok {
+			return r
+		}
+	}
+	return nil
+}
+
+// RuleEvaluation 规则试算中单个目标的结果
+type RuleEvaluation struct {
+	TargetURL string                 `json:"targetUrl"`
+	Matched   bool                   `json:"matched"`         // 是否满足表达式
+	Error     string                 `json:"error,omitempty"` // 求值错误
+	Vars      map[string]interface{} `json:"vars"`            // 表达式引用的变量的取值
+	CheckedAt time.Time              `json:"checkedAt"`       // 参与试算的最近一次检查时间
+}
+
+// RuleStatus 告警规则及当前满足该规则的目标
+type RuleStatus struct {
+	Name     string   `json:"name"`
+	Expr     string   `json:"expr"`
+	Severity string   `json:"severity"`
+	Default  bool     `json:"default,omitempty"` // 未配置规则时使用的默认规则
+	Alerting []string `json:"alerting"`          // 当前满足该规则（处于告警状态）的目标
+}
+
+// Rules 返回生效的告警规则及各规则当前的告警目标
+func (m *Manager) Rules() []*RuleStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	byRule := make(map[*Rule]*RuleStatus, len(m.rules))
+	list := make([]*RuleStatus, 0, len(m.rules))
+	for _, r := range m.rules {
+		st := &RuleStatus{Name: r.Name, Expr: r.Expr(), Severity: r.Severity, Default: r.implicit, Alerting: []string{}}
+		byRule[r] = st
+		list = append(list, st)
+	}
+	for url, r := range m.alerting {
+		if st, ok := byRule[r]; ok {
+			st.Alerting = append(st.Alerting, url)
+		}
+	}
+	for _, st := range list {
+		sort.Strings(st.Alerting)
+	}
+	return list
+}
+
+// TestRule 以各目标最近一次检查结果试算表达式，不影响告警状态，用于编写规则时确认效果
+// 聚合变量只能使用已生效规则保留的窗口内的结果，窗口不足时按已保留的结果计算
+// match：目标地址过滤（子串匹配，不区分大小写），为空表示全部目标
+func (m *Manager) TestRule(source, match string) ([]*RuleEvaluation, error) {
+	symbols := &ruleSymbols{}
+	expr, err := compileExpr(source, symbols)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	match = strings.ToLower(match)
+	list := []*RuleEvaluation{}
+	for url, hist := range m.history {
+		if hist.last == nil || !strings.Contains(strings.ToLower(url), match) {
+			continue
+		}
+		env := &ruleEnv{result: hist.last, hist: hist, recorded: true}
+		eval := &RuleEvaluation{TargetURL: url, Vars: make(map[string]interface{}, len(symbols.vars)), CheckedAt: hist.last.CheckedAt}
+		for _, v := range symbols.vars {
+			eval.Vars[v] = env.lookup(v)
+		}
+		if eval.Matched, err = expr.eval(env); err != nil {
+			eval.Error = err.Error()
+		}
+		list = append(list, eval)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].TargetURL < list[j].TargetURL })
+	return list, nil
+}
@@ -0,0 +1,160 @@
+package alert
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Silence 告警静默规则，匹配的监控目标在有效期内不发送告警
+type Silence struct {
+	ID        uint64    `json:"id"`        // 静默规则唯一标识
+	Matcher   string    `json:"matcher"`   // 目标地址匹配关键词（子串匹配，不区分大小写）
+	CreatedBy string    `json:"createdBy"` // 创建人（如聊天工具中的用户名）
+	Reason    string    `json:"reason"`    // 静默原因
+	StartsAt  time.Time `json:"startsAt"`  // 生效时间
+	EndsAt    time.Time `json:"endsAt"`    // 失效时间
+}
+
+// Active 判断静默规则在指定时间点是否生效
+func (s *Silence) Active(now time.Time) bool {
+	return !now.Before(s.StartsAt) && now.Before(s.EndsAt)
+}
+
+// SilenceManager 静默规则管理器，内存存储，线程安全
+type SilenceManager struct {
+	mu       sync.RWMutex
+	silences map[uint64]*Silence
+	nextID   uint64
+}
+
+// NewSilenceManager 创建一个新的静默规则管理器
+func NewSilenceManager() *SilenceManager {
+	return &SilenceManager{
+		silences: make(map[uint64]*Silence),
+	}
+}
+
+// Add 新增一条静默规则
+// matcher：目标地址匹配关键词
+// duration：静默时长
+// createdBy：创建人
+// reason：静默原因（可选）
+func (sm *SilenceManager) Add(matcher string, duration time.Duration, createdBy, reason string) (*Silence, error) {
+	matcher = strings.TrimSpace(matcher)
+	if matcher == "" {
+		return nil, fmt.Errorf("静默目标不能为空")
+	}
+	if duration <= 0 {
+		return nil, fmt.Errorf("静默时长必须大于0")
+	}
+
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	sm.nextID++
+	now := time.Now()
+	s := &Silence{
+		ID:        sm.nextID,
+		Matcher:   matcher,
+		CreatedBy: createdBy,
+		Reason:    reason,
+		StartsAt:  now,
+		EndsAt:    now.Add(duration),
+	}
+	sm.silences[s.ID] = s
+	return s, nil
+}
+
+// Remove 删除指定静默规则，返回是否存在
+func (sm *SilenceManager) Remove(id uint64) bool {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	if _, ok := sm.silences[id]; !ok {
+		return false
+	}
+	delete(sm.silences, id)
+	return true
+}
+
+// IsSilenced 判断目标地址当前是否处于静默状态
+func (sm *SilenceManager) IsSilenced(targetURL string) bool {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	now := time.Now()
+	lowerURL := strings.ToLower(targetURL)
+	for _, s := range sm.silences {
+		if s.Active(now) && strings.Contains(lowerURL, strings.ToLower(s.Matcher)) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchAt 返回在指定时间点对目标地址生效的静默规则（多条时取最晚失效的一条），没有时返回 nil
+// 用于预览未来的检查是否落在静默期内
+func (sm *SilenceManager) MatchAt(targetURL string, at time.Time) *Silence {
+	if sm == nil {
+		return nil
+	}
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	lowerURL := strings.ToLower(targetURL)
+	var match *Silence
+	for _, s := range sm.silences {
+		if s.Active(at) && strings.Contains(lowerURL, strings.ToLower(s.Matcher)) && (match == nil || s.EndsAt.After(match.EndsAt)) {
+			match = s
+		}
+	}
+	return match
+}
+
+// List 返回当前生效的静默规则，按失效时间升序排列，同时清理已过期规则
+func (sm *SilenceManager) List() []*Silence {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	now := time.Now()
+	active := make([]*Silence, 0, len(sm.silences))
+	for id, s := range sm.silences {
+		if !now.Before(s.EndsAt) {
+			delete(sm.silences, id)
+			continue
+		}
+		active = append(active, s)
+	}
+	sort.Slice(active, func(i, j int) bool {
+		return active[i].EndsAt.Before(active[j].EndsAt)
+	})
+	return active
+}
+
+// ParseSilenceDuration 解析静默时长，在 time.ParseDuration 基础上支持「d」（天）单位
+// 例如：30m、2h、1d、1d12h
+func ParseSilenceDuration(s string) (time.Duration, error) {
+	s = strings.TrimSpace(strings.ToLower(s))
+	if s == "" {
+		return 0, fmt.Errorf("静默时长不能为空")
+	}
+
+	var days time.Duration
+	if idx := strings.Index(s, "d"); idx > 0 {
+		n, err := strconv.Atoi(s[:idx])
+		if err != nil {
+			return 0, fmt.Errorf("无效的静默时长：%s", s)
+		}
+		days = time.Duration(n) * 24 * time.Hour
+		s = s[idx+1:]
+		if s == "" {
+			return days, nil
+		}
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("无效的静默时长：%s", s)
+	}
+	return days + d, nil
+}
@@ -0,0 +1,220 @@
+package alert
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"servicetelemetry/config"
+	"servicetelemetry/eventbus"
+	"servicetelemetry/metrics"
+)
+
+// 升级通知的渠道名称，可用于按渠道选择通知模板（如短信只保留一行摘要）
+const (
+	ChannelSMS   = "sms"
+	ChannelVoice = "voice"
+)
+
+// TelephonyProvider 电话服务商，发送短信与拨打语音通知电话
+// 实现需可并发调用；title 为告警标题，text 为短信正文或语音播报内容
+type TelephonyProvider interface {
+	Name() string
+	SendSMS(ctx context.Context, phone, title, text string) error
+	Call(ctx context.Context, phone, title, text string) error
+}
+
+// TelephonyFactory 根据电话服务商配置创建服务商，配置不合法时返回错误
+// 自定义服务商的专有参数通过 alert.escalation.telephony.options 传入
+type TelephonyFactory func(cfg *config.TelephonyConfig, timeout time.Duration) (TelephonyProvider, error)
+
+var (
+	telephonyMu        sync.RWMutex
+	telephonyProviders = map[string]TelephonyFactory{}
+)
+
+func init() {
+	RegisterTelephonyProvider("twilio", newTwilioProvider)
+	RegisterTelephonyProvider("aliyun", newAliyunProvider)
+}
+
+// RegisterTelephonyProvider 注册电话服务商，通过配置 alert.escalation.telephony.provider 按名称选用
+// 应在程序启动时（如 init 函数中）调用；名称为空、工厂为 nil 或名称重复时 panic
+func RegisterTelephonyProvider(name string, factory TelephonyFactory) {
+	telephonyMu.Lock()
+	defer telephonyMu.Unlock()
+	if name == "" || factory == nil {
+		panic("alert: 电话服务商名称与工厂函数不能为空")
+	}
+	if _, ok := telephonyProviders[name]; ok {
+		panic("alert: 重复注册电话服务商 " + name)
+	}
+	telephonyProviders[name] = factory
+}
+
+// TelephonyProviders 返回已注册的电话服务商名称（按名称排序）
+func TelephonyProviders() []string {
+	telephonyMu.RLock()
+	defer telephonyMu.RUnlock()
+	names := make([]string, 0, len(telephonyProviders))
+	for name := range telephonyProviders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// phonePattern E.164 手机号
+var phonePattern = regexp.MustCompile(`^\+[1-9]\d{6,14}$`)
+
+// newTelephonyProvider 按配置创建升级通知使用的电话服务商，并校验联系人
+func newTelephonyProvider(cfg *config.AlertEscalationConfig, timeout time.Duration) (TelephonyProvider, error) {
+	switch {
+	case cfg.After <= 0:
+		return nil, fmt.Errorf("after 必须大于 0")
+	case cfg.Repeat < 0:
+		return nil, fmt.Errorf("repeat 不能为负数")
+	case cfg.MaxAttempts < 1:
+		return nil, fmt.Errorf("maxAttempts 至少为 1")
+	case len(cfg.Contacts) == 0:
+		return nil, fmt.Errorf("至少需要配置一个联系人")
+	}
+	for i, c := range cfg.Contacts {
+		if !phonePattern.MatchString(c.Phone) {
+			return nil, fmt.Errorf("contacts[%d] %s：手机号 %q 应为 E.164 格式，如 +8613800000000", i, c.Name, c.Phone)
+		}
+		if !c.SMS && !c.Voice {
+			return nil, fmt.Errorf("contacts[%d] %s：sms 与 voice 至少开启一项", i, c.Name)
+		}
+	}
+
+	telephonyMu.RLock()
+	factory, ok := telephonyProviders[cfg.Telephony.Provider]
+	telephonyMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("未注册的电话服务商：%q，可选 %s", cfg.Telephony.Provider, strings.Join(TelephonyProviders(), " / "))
+	}
+	provider, err := factory(&cfg.Telephony, timeout)
+	if err != nil {
+		return nil, fmt.Errorf("创建电话服务商 %s 失败：%w", cfg.Telephony.Provider, err)
+	}
+	return provider, nil
+}
+
+// scheduleEscalationLocked 为 critical 事件安排升级通知，调用方需持有锁
+func (m *Manager) scheduleEscalationLocked(incident *Incident, after time.Duration) {
+	if m.telephony == nil || incident.Severity != SeverityCritical {
+		return
+	}
+	id := incident.ID
+	incident.escalation = time.AfterFunc(after, func() { m.escalate(id) })
+}
+
+// stopEscalationLocked 事件已确认或已恢复时取消尚未执行的升级通知，调用方需持有锁
+func (m *Manager) stopEscalationLocked(incident *Incident) {
+	if incident.escalation != nil {
+		incident.escalation.Stop()
+		incident.escalation = nil
+	}
+}
+
+// escalate 事件仍未确认也未恢复时，向联系人发送短信并拨打语音电话，未达到次数上限时安排下一次升级
+func (m *Manager) escalate(id uint64) {
+	m.mu.Lock()
+	incident, ok := m.incidents[id]
+	if !ok || incident.Status == StatusResolved || incident.AckedAt != nil {
+		m.mu.Unlock()
+		return
+	}
+	now := time.Now()
+	incident.Escalations++
+	incident.EscalatedAt = &now
+	incident.escalation = nil
+	ec := m.cfg.Escalation
+	if ec.Repeat > 0 && incident.Escalations < ec.MaxAttempts {
+		m.scheduleEscalationLocked(incident, ec.Repeat)
+	}
+	snapshot := incidentSnapshot(incident)
+	// 升级只发布事件快照，不通知状态订阅等事件监听器
+	m.publish(eventbus.TypeIncident, fmt.Sprintf("%d", id), snapshot)
+	m.mu.Unlock()
+
+	log.Warnf("事件#%d 已持续 %s 未确认，第 %d 次升级通知", id, now.Sub(snapshot.OpenedAt).Round(time.Second), snapshot.Escalations)
+	a := &Alert{
+		IncidentID: id,
+		GroupKey:   snapshot.GroupKey,
+		Status:     StatusFiring,
+		Severity:   snapshot.Severity,
+		Title:      fmt.Sprintf("【升级】%s", snapshot.Title),
+		Body: fmt.Sprintf("事件 #%d 已持续 %s 未确认（第 %d 次升级通知），涉及 %d 个目标，请尽快处理",
+			id, now.Sub(snapshot.OpenedAt).Round(time.Minute), snapshot.Escalations, len(snapshot.Targets)),
+		FiredAt: now,
+		Actions: m.actionLinks(id, "升级通知"),
+	}
+	m.notifyContacts(a)
+}
+
+// notifyContacts 按联系人配置发送短信与拨打语音电话，各联系人并发通知
+func (m *Manager) notifyContacts(a *Alert) {
+	sms := m.renderFor(ChannelSMS, a)
+	// 语音播报不包含操作链接
+	voice := *m.renderFor(ChannelVoice, a)
+	voice.Actions = nil
+
+	var wg sync.WaitGroup
+	for _, contact := range m.cfg.Escalation.Contacts {
+		contact := contact
+		if contact.SMS {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				m.deliverEscalation(a.IncidentID, ChannelSMS, sms.Title, contact, func(ctx context.Context) error {
+					return m.telephony.SendSMS(ctx, contact.Phone, sms.Title, sms.FullBody())
+				})
+			}()
+		}
+		if contact.Voice {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				m.deliverEscalation(a.IncidentID, ChannelVoice, voice.Title, contact, func(ctx context.Context) error {
+					return m.telephony.Call(ctx, contact.Phone, voice.Title, voice.FullBody())
+				})
+			}()
+		}
+	}
+	wg.Wait()
+}
+
+// deliverEscalation 在发送超时内执行一次短信 / 电话通知，记录投递指标与事件沟通记录
+func (m *Manager) deliverEscalation(id uint64, channel, title string, contact config.EscalationContact, send func(ctx context.Context) error) {
+	ctx, cancel := context.WithTimeout(context.Background(), m.cfg.SendTimeout)
+	defer cancel()
+	start := time.Now()
+	err := send(ctx)
+	metrics.ObserveDelivery(metrics.KindAlert, m.telephony.Name()+"_"+channel, err, start)
+	entry := LogEntry{
+		Time:      start,
+		Kind:      LogEscalation,
+		Channel:   channel,
+		Recipient: fmt.Sprintf("%s（%s）", contact.Name, maskPhone(contact.Phone)),
+		Message:   fmt.Sprintf("%s（经由 %s）", title, m.telephony.Name()),
+	}
+	if err != nil {
+		entry.Error = err.Error()
+		log.Errorf("通过 %s 向 %s（%s）发送%s升级通知失败：%v", m.telephony.Name(), contact.Name, maskPhone(contact.Phone), channel, err)
+	}
+	m.RecordCommunication(id, entry)
+}
+
+// maskPhone 日志中的手机号只保留前 4 位与后 4 位
+func maskPhone(phone string) string {
+	if len(phone) <= 8 {
+		return phone
+	}
+	return phone[:4] + strings.Repeat("*", len(phone)-8) + phone[len(phone)-4:]
+}
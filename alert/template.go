@@ -0,0 +1,284 @@
+package alert
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"servicetelemetry/config"
+	"servicetelemetry/core"
+)
+
+// templateFuncs 通知模板可用的辅助函数
+var templateFuncs = template.FuncMap{
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	// date 格式化时间，layout 为空时使用 2006-01-02 15:04:05
+	"date": func(layout string, t time.Time) string {
+		if layout == "" {
+			layout = "2006-01-02 15:04:05"
+		}
+		return t.Format(layout)
+	},
+	// truncate 截断到最多 n 个字符，超出部分以 … 结尾，适合短信等长度受限的渠道
+	"truncate": func(n int, s string) string {
+		runes := []rune(s)
+		if n <= 0 || len(runes) <= n {
+			return s
+		}
+		if n == 1 {
+			return "…"
+		}
+		return string(runes[:n-1]) + "…"
+	},
+	// ms 将毫秒耗时格式化为整数毫秒
+	"ms": func(v float64) string {
+		return fmt.Sprintf("%.0fms", v)
+	},
+	// default 值为空字符串时使用默认值
+	"default": func(def, v string) string {
+		if v == "" {
+			return def
+		}
+		return v
+	},
+}
+
+// notificationTemplate 编译后的通知模板
+type notificationTemplate struct {
+	cfg   config.NotificationTemplate
+	index int                // 配置中的序号，特异性相同时取靠前的模板
+	title *template.Template // 为 nil 时使用默认标题
+	body  *template.Template // 为 nil 时使用默认正文
+}
+
+// compileTemplates 编译通知模板并以示例告警试渲染，任一模板无效时返回错误
+func compileTemplates(cfgs []config.NotificationTemplate) ([]*notificationTemplate, error) {
+	compiled := make([]*notificationTemplate, 0, len(cfgs))
+	for i, c := range cfgs {
+		if c.Severity != "" && severityRank[c.Severity] == 0 {
+			return nil, fmt.Errorf("alert.templates[%d]：无效的告警级别 %s，可选 critical / warning / info", i, c.Severity)
+		}
+		if c.Status != "" && c.Status != StatusFiring && c.Status != StatusResolved && c.Status != StatusAcknowledged {
+			return nil, fmt.Errorf("alert.templates[%d]：无效的告警状态 %s，可选 firing / resolved / acknowledged", i, c.Status)
+		}
+		if c.Title == "" && c.Body == "" {
+			return nil, fmt.Errorf("alert.templates[%d]：title 与 body 至少需要配置一项", i)
+		}
+		t, err := compileTemplate(i, c)
+		if err != nil {
+			return nil, fmt.Errorf("alert.templates[%d]：%w", i, err)
+		}
+		// 以示例数据试渲染，提前发现引用不存在的字段等错误
+		if _, err := t.render(newAlert(StatusFiring, sampleResult("", StatusFiring))); err != nil {
+			return nil, fmt.Errorf("alert.templates[%d]：%w", i, err)
+		}
+		compiled = append(compiled, t)
+	}
+	return compiled, nil
+}
+
+// compileTemplate 编译单个通知模板
+func compileTemplate(index int, c config.NotificationTemplate) (*notificationTemplate, error) {
+	t := &notificationTemplate{cfg: c, index: index}
+	var err error
+	if c.Title != "" {
+		if t.title, err = template.New("title").Funcs(templateFuncs).Option("missingkey=zero").Parse(c.Title); err != nil {
+			return nil, fmt.Errorf("标题模板无效：%w", err)
+		}
+	}
+	if c.Body != "" {
+		if t.body, err = template.New("body").Funcs(templateFuncs).Option("missingkey=zero").Parse(c.Body); err != nil {
+			return nil, fmt.Errorf("正文模板无效：%w", err)
+		}
+	}
+	return t, nil
+}
+
+// specificity 模板对告警的匹配程度，-1 表示不匹配，否则为限定条件的个数
+func (t *notificationTemplate) specificity(channel, severity, status string) int {
+	score := 0
+	for _, f := range [][2]string{{t.cfg.Channel, channel}, {t.cfg.Severity, severity}, {t.cfg.Status, status}} {
+		if f[0] == "" {
+			continue
+		}
+		if f[0] != f[1] {
+			return -1
+		}
+		score++
+	}
+	return score
+}
+
+// selectTemplate 选择对 (渠道, 级别, 状态) 最具体的模板，没有匹配的模板时返回 nil
+// 未设置级别的告警（如故障切换、证书透明度通知）按 critical 匹配
+func selectTemplate(templates []*notificationTemplate, channel, severity, status string) *notificationTemplate {
+	if severity == "" {
+		severity = SeverityCritical
+	}
+	var best *notificationTemplate
+	bestScore := -1
+	for _, t := range templates {
+		if score := t.specificity(channel, severity, status); score > bestScore {
+			best, bestScore = t, score
+		}
+	}
+	return best
+}
+
+// render 以告警为数据渲染模板，返回套用模板后的告警副本
+// 正文使用模板时，副本的 FullBody 不再追加 AI 上下文与操作链接，由模板通过 .Context / .Actions 自行决定
+func (t *notificationTemplate) render(a *Alert) (*Alert, error) {
+	rendered := *a
+	if t.title != nil {
+		var buf bytes.Buffer
+		if err := t.title.Execute(&buf, a); err != nil {
+			return nil, fmt.Errorf("渲染标题失败：%w", err)
+		}
+		rendered.Title = strings.TrimSpace(buf.String())
+	}
+	if t.body != nil {
+		var buf bytes.Buffer
+		if err := t.body.Execute(&buf, a); err != nil {
+			return nil, fmt.Errorf("渲染正文失败：%w", err)
+		}
+		rendered.Body = strings.TrimRight(buf.String(), "\n")
+		rendered.templated = true
+	}
+	return &rendered, nil
+}
+
+// renderFor 按渠道选择模板并渲染告警，没有匹配的模板或渲染失败时返回原告警
+func (m *Manager) renderFor(channel string, a *Alert) *Alert {
+	t := selectTemplate(m.templates, channel, a.Severity, a.Status)
+	if t == nil {
+		return a
+	}
+	rendered, err := t.render(a)
+	if err != nil {
+		log.Errorf("渠道[%s]的通知模板 alert.templates[%d] %v，使用默认消息", channel, t.index, err)
+		return a
+	}
+	return rendered
+}
+
+// TemplatePreview 通知模板预览请求
+type TemplatePreview struct {
+	Channel   string `json:"channel"`   // 通知渠道名称，如 webhook
+	Severity  string `json:"severity"`  // 告警级别，默认 critical
+	Status    string `json:"status"`    // 告警状态，默认 firing
+	Title     string `json:"title"`     // 待预览的标题模板，与 body 都为空时使用已配置的模板
+	Body      string `json:"body"`      // 待预览的正文模板
+	TargetURL string `json:"targetUrl"` // 使用该目标最近一次检查结果作为数据，为空或无结果时使用示例数据
+}
+
+// TemplateRender 通知模板预览结果
+type TemplateRender struct {
+	Template *config.NotificationTemplate `json:"template"` // 生效的模板，为 nil 表示使用默认消息
+	Sample   bool                         `json:"sample"`   // 是否使用示例数据
+	Title    string                       `json:"title"`    // 渲染后的标题
+	Body     string                       `json:"body"`     // 渲染后的完整正文（即通知渠道收到的正文）
+}
+
+// PreviewTemplate 渲染通知模板预览，不发送通知
+// 请求中携带 title / body 时预览这段模板，否则预览对应渠道、级别、状态下已配置模板的效果
+func (m *Manager) PreviewTemplate(req TemplatePreview) (*TemplateRender, error) {
+	if req.Severity == "" {
+		req.Severity = SeverityCritical
+	}
+	if req.Status == "" {
+		req.Status = StatusFiring
+	}
+	if severityRank[req.Severity] == 0 {
+		return nil, fmt.Errorf("无效的告警级别 %s，可选 critical / warning / info", req.Severity)
+	}
+	if req.Status != StatusFiring && req.Status != StatusResolved && req.Status != StatusAcknowledged {
+		return nil, fmt.Errorf("无效的告警状态 %s，可选 firing / resolved / acknowledged", req.Status)
+	}
+
+	var t *notificationTemplate
+	if req.Title != "" || req.Body != "" {
+		cfg := config.NotificationTemplate{Channel: req.Channel, Severity: req.Severity, Status: req.Status, Title: req.Title, Body: req.Body}
+		compiled, err := compileTemplate(0, cfg)
+		if err != nil {
+			return nil, err
+		}
+		t = compiled
+	} else {
+		t = selectTemplate(m.templates, req.Channel, req.Severity, req.Status)
+	}
+
+	var result *core.MonitorResult
+	if req.TargetURL != "" {
+		m.mu.Lock()
+		if hist := m.history[req.TargetURL]; hist != nil {
+			result = hist.last
+		}
+		m.mu.Unlock()
+	}
+	render := &TemplateRender{Sample: result == nil}
+	if result == nil {
+		result = sampleResult(req.TargetURL, req.Status)
+	}
+
+	a := newAlert(req.Status, result)
+	a.IncidentID = 1
+	a.Severity = req.Severity
+	if req.Status == StatusAcknowledged {
+		// 与 AcknowledgeIncident 发送的通知一致：按事件发送，不携带检查结果
+		a = &Alert{
+			IncidentID: 1,
+			Status:     StatusAcknowledged,
+			Severity:   req.Severity,
+			Title:      fmt.Sprintf("【已确认】%s", a.Title),
+			Body:       fmt.Sprintf("事件：#%d\n确认人：%s\n已持续：%s", 1, "oncall@example.com", 5*time.Minute),
+			FiredAt:    a.FiredAt,
+		}
+	}
+	if req.Status == StatusFiring {
+		a.Context = "（示例）AI 补充的上下文说明"
+		a.Actions = &ActionLinks{
+			Acknowledge: "https://status.example.com/api/v1/incidents/1/actions/ack?expires=0&sig=example",
+			Resolve:     "https://status.example.com/api/v1/incidents/1/actions/resolve?expires=0&sig=example",
+			ExpiresAt:   a.FiredAt.Add(24 * time.Hour),
+		}
+	}
+
+	if t != nil {
+		rendered, err := t.render(a)
+		if err != nil {
+			return nil, err
+		}
+		a = rendered
+		render.Template = &t.cfg
+	}
+	render.Title = a.Title
+	render.Body = a.FullBody()
+	return render, nil
+}
+
+// sampleResult 构建用于模板预览的示例检查结果
+func sampleResult(url, status string) *core.MonitorResult {
+	if url == "" {
+		url = "https://example.com/health"
+	}
+	result := &core.MonitorResult{
+		TargetURL:    url,
+		Status:       core.StatusFailed,
+		StatusCode:   503,
+		ResponseTime: 1234,
+		ErrorType:    string(core.ErrorTypeHTTP),
+		ErrorMsg:     "响应状态码 503 不在期望范围内",
+		CheckedAt:    time.Now(),
+	}
+	if status == StatusResolved {
+		result.Status = core.StatusSuccess
+		result.StatusCode = 200
+		result.ResponseTime = 86
+		result.ErrorType = ""
+		result.ErrorMsg = ""
+	}
+	return result
+}
@@ -0,0 +1,99 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"servicetelemetry/config"
+)
+
+// twilioProvider Twilio 电话服务商：短信使用 Messages 接口，语音电话使用 Calls 接口并以 TwiML <Say> 播报
+type twilioProvider struct {
+	cfg    config.TwilioConfig
+	token  string
+	base   string
+	client *http.Client
+}
+
+// newTwilioProvider 创建 Twilio 电话服务商，Auth Token 引用在创建时解析
+func newTwilioProvider(cfg *config.TelephonyConfig, timeout time.Duration) (TelephonyProvider, error) {
+	tc := cfg.Twilio
+	if tc.AccountSID == "" || tc.AuthToken == "" || tc.From == "" {
+		return nil, fmt.Errorf("twilio 需要配置 accountSid、authToken 与 from")
+	}
+	token, err := config.ResolveSecret(tc.AuthToken)
+	if err != nil {
+		return nil, fmt.Errorf("解析 twilio.authToken 失败：%w", err)
+	}
+	if tc.Language == "" {
+		tc.Language = "zh-CN"
+	}
+	base := tc.APIBaseURL
+	if base == "" {
+		base = "https://api.twilio.com"
+	}
+	return &twilioProvider{cfg: tc, token: token, base: strings.TrimRight(base, "/"), client: &http.Client{Timeout: timeout}}, nil
+}
+
+// Name 返回服务商名称
+func (tp *twilioProvider) Name() string {
+	return "twilio"
+}
+
+// SendSMS 发送短信，标题与正文之间空一行
+func (tp *twilioProvider) SendSMS(ctx context.Context, phone, title, text string) error {
+	form := url.Values{}
+	form.Set("To", phone)
+	form.Set("From", tp.cfg.From)
+	form.Set("Body", title+"\n\n"+text)
+	return tp.post(ctx, "Messages.json", form)
+}
+
+// Call 拨打语音电话，播报标题与正文两遍
+func (tp *twilioProvider) Call(ctx context.Context, phone, title, text string) error {
+	var say strings.Builder
+	xml.EscapeText(&say, []byte(title+"。"+text))
+	twiml := fmt.Sprintf(`<Response><Say language="%s">%s</Say><Pause length="1"/><Say language="%s">%s</Say></Response>`,
+		tp.cfg.Language, say.String(), tp.cfg.Language, say.String())
+	form := url.Values{}
+	form.Set("To", phone)
+	form.Set("From", tp.cfg.From)
+	form.Set("Twiml", twiml)
+	return tp.post(ctx, "Calls.json", form)
+}
+
+// post 调用 Twilio REST 接口（HTTP Basic 认证），非 2xx 时返回 Twilio 的错误信息
+func (tp *twilioProvider) post(ctx context.Context, resource string, form url.Values) error {
+	endpoint := fmt.Sprintf("%s/2010-04-01/Accounts/%s/%s", tp.base, url.PathEscape(tp.cfg.AccountSID), resource)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("创建 Twilio 请求失败：%w", err)
+	}
+	req.SetBasicAuth(tp.cfg.AccountSID, tp.token)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := tp.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("请求 Twilio 失败：%w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	var apiErr struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if json.Unmarshal(data, &apiErr) == nil && apiErr.Message != "" {
+		return fmt.Errorf("Twilio 返回错误 %d：%s", apiErr.Code, apiErr.Message)
+	}
+	return fmt.Errorf("Twilio 返回异常状态码：%d", resp.StatusCode)
+}
@@ -0,0 +1,60 @@
+package alert
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// WebhookNotifier 通用 Webhook 通知渠道，以 JSON 格式推送告警
+type WebhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookNotifier 创建一个新的 Webhook 通知渠道
+// url：接收告警的 Webhook 地址
+// timeout：推送超时时间
+func NewWebhookNotifier(url string, timeout time.Duration) *WebhookNotifier {
+	return &WebhookNotifier{
+		url:    url,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+// Name 返回渠道名称
+func (wn *WebhookNotifier) Name() string {
+	return "webhook"
+}
+
+// Send 推送告警到 Webhook 地址
+func (wn *WebhookNotifier) Send(a *Alert) error {
+	payload, err := json.Marshal(map[string]interface{}{
+		"incidentId": a.IncidentID,
+		"title":      a.Title,
+		"body":       a.FullBody(),
+		"status":     a.Status,
+		"severity":   a.Severity,
+		"rule":       a.Rule,
+		"targetUrl":  a.TargetURL,
+		"firedAt":    a.FiredAt,
+		"result":     a.Result,
+		"actions":    a.Actions,
+	})
+	if err != nil {
+		return fmt.Errorf("序列化告警失败：%w", err)
+	}
+
+	resp, err := wn.client.Post(wn.url, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("推送Webhook失败：%w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("Webhook返回异常状态码：%d", resp.StatusCode)
+	}
+	return nil
+}
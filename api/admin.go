@@ -0,0 +1,43 @@
+package api
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"strings"
+
+	"servicetelemetry/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// adminAuth 管理员认证：要求 Authorization: Bearer <token>，令牌来自 api.adminTokens（支持 env: / file: 引用）
+// 未配置令牌时受保护的接口一律拒绝（返回功能未开启），不会因漏配而对外开放
+func adminAuth(cfg *config.APIConfig) (gin.HandlerFunc, error) {
+	tokens := make([][]byte, 0, len(cfg.AdminTokens))
+	for i, ref := range cfg.AdminTokens {
+		token, err := config.ResolveSecret(ref)
+		if err != nil {
+			return nil, fmt.Errorf("解析 api.adminTokens[%d] 失败：%w", i, err)
+		}
+		if token == "" {
+			return nil, fmt.Errorf("api.adminTokens[%d] 为空", i)
+		}
+		tokens = append(tokens, []byte(token))
+	}
+
+	return func(c *gin.Context) {
+		if len(tokens) == 0 {
+			respondError(c, CodeFeatureDisabled, "管理接口未开启：未配置 api.adminTokens", nil)
+			c.Abort()
+			return
+		}
+		given := []byte(strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer "))
+		for _, token := range tokens {
+			if subtle.ConstantTimeCompare(given, token) == 1 {
+				return
+			}
+		}
+		respondError(c, CodeUnauthenticated, "缺少或无效的管理员令牌", nil)
+		c.Abort()
+	}, nil
+}
@@ -0,0 +1,48 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// testAlertRuleRequest 告警规则试算请求
+type testAlertRuleRequest struct {
+	Expr  string `json:"expr" binding:"required"` // 待试算的表达式
+	Match string `json:"match"`                   // 目标地址过滤（子串匹配），为空表示全部目标
+}
+
+// GetAlertRules 返回生效的告警规则及各规则当前处于告警状态的目标
+func (h *Handler) GetAlertRules(c *gin.Context) {
+	rules := h.alerts.Rules()
+	respond(c, http.StatusOK, gin.H{
+		"total": len(rules),
+		"list":  rules,
+	})
+}
+
+// TestAlertRule 以各目标最近一次检查结果试算告警表达式，返回是否满足与引用变量的取值，不影响告警状态
+// 请求体：{"expr": "consecutive_failures >= 3 && error_type == \"timeout\"", "match": "example.com"}
+func (h *Handler) TestAlertRule(c *gin.Context) {
+	var req testAlertRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, CodeInvalidArgument, "请求参数错误："+err.Error(), nil)
+		return
+	}
+	list, err := h.alerts.TestRule(req.Expr, req.Match)
+	if err != nil {
+		respondError(c, CodeInvalidArgument, "表达式无效："+err.Error(), gin.H{"field": "expr"})
+		return
+	}
+	matched := 0
+	for _, e := range list {
+		if e.Matched {
+			matched++
+		}
+	}
+	respond(c, http.StatusOK, gin.H{
+		"total":   len(list),
+		"matched": matched,
+		"list":    list,
+	})
+}
@@ -0,0 +1,26 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"servicetelemetry/alert"
+)
+
+// PreviewAlertTemplate 渲染通知模板预览，返回渠道将收到的标题与正文，不发送通知
+// 请求体：{"channel": "webhook", "severity": "warning", "status": "firing", "body": "{{.Title}} {{ms .Result.ResponseTime}}", "targetUrl": "https://example.com"}
+// 未携带 title / body 时预览已配置模板对该渠道、级别、状态的效果
+func (h *Handler) PreviewAlertTemplate(c *gin.Context) {
+	var req alert.TemplatePreview
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, CodeInvalidArgument, "请求参数错误："+err.Error(), nil)
+		return
+	}
+	render, err := h.alerts.PreviewTemplate(req)
+	if err != nil {
+		respondError(c, CodeInvalidArgument, "模板预览失败："+err.Error(), nil)
+		return
+	}
+	respond(c, http.StatusOK, render)
+}
@@ -0,0 +1,128 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+
+	"servicetelemetry/agent"
+	"servicetelemetry/alert"
+	"servicetelemetry/core"
+	"servicetelemetry/subscription"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Capabilities 当前部署的能力描述，前端与探测节点据此按实际配置调整功能入口，而不是假定全部功能可用
+type Capabilities struct {
+	APIVersion    string          `json:"apiVersion"`       // API 版本
+	LegacyAPI     bool            `json:"legacyApi"`        // 是否仍提供旧版（无版本前缀）接口
+	Region        string          `json:"region,omitempty"` // 本实例所在的探测区域
+	Subsystems    map[string]bool `json:"subsystems"`       // 各子系统是否开启
+	Schemes       []string        `json:"schemes"`          // 支持的检查协议
+	Statuses      []string        `json:"statuses"`         // 检查结果可能的状态
+	TLSProfiles   []string        `json:"tlsProfiles"`      // 可选的 TLS 配置档
+	Notifications Notifications   `json:"notifications"`    // 已配置的通知渠道类型
+	AI            *AICapabilities `json:"ai,omitempty"`     // AI 小助手能力，未开启时为空
+}
+
+// Notifications 已配置的通知渠道类型，未配置的渠道不列出
+type Notifications struct {
+	Alerts        []string `json:"alerts"`        // 告警通知渠道
+	Subscriptions []string `json:"subscriptions"` // 状态订阅可选的渠道
+	ChatOps       []string `json:"chatops"`       // 斜杠命令入口
+	Events        string   `json:"events,omitempty"`
+	Remediation   []string `json:"remediation"` // 已定义的自动处置动作类型
+}
+
+// AICapabilities AI 小助手能力
+type AICapabilities struct {
+	IntentParsers []string `json:"intentParsers"` // 已注册的意图解析器
+	OutputFormats []string `json:"outputFormats"` // 支持的回复格式
+	DefaultFormat string   `json:"defaultFormat"` // 默认回复格式
+}
+
+// GetCapabilities 查询当前部署开启的子系统、支持的检查协议、已配置的通知渠道类型与 API 版本
+func (h *Handler) GetCapabilities(c *gin.Context) {
+	cfg := h.cfg
+	caps := &Capabilities{
+		APIVersion: APIVersion,
+		LegacyAPI:  !cfg.API.DisableLegacy,
+		Region:     cfg.Monitor.Region,
+		Subsystems: map[string]bool{
+			"scheduler":     cfg.Monitor.Scheduler,
+			"dryRun":        cfg.Monitor.DryRun,
+			"alerts":        cfg.Alert.Enable,
+			"alertGrouping": cfg.Alert.Enable && cfg.Alert.Group.Enable,
+			"alertActions":  cfg.Alert.Enable && cfg.Alert.Actions.Enable && cfg.Alert.Actions.Secret != "",
+			"escalation":    cfg.Alert.Enable && cfg.Alert.Escalation.Enable,
+			"ai":            cfg.Agent.EnableAI,
+			"chatops":       cfg.ChatOps.Enable,
+			"events":        cfg.Events.Enable,
+			"registration":  cfg.Events.RegistrationTopic != "",
+			"ct":            cfg.CT.Enable,
+			"snapshots":     cfg.Snapshots.Enable,
+			"subscriptions": cfg.Subscriptions.Enable,
+			"failover":      cfg.Failover.Enable,
+			"remediation":   cfg.Remediation.Enable,
+			"probeAuth":     cfg.Probes.Listen != "",
+			"viewer":        cfg.API.Viewer.Listen != "",
+		},
+		Schemes:     core.SupportedSchemes,
+		Statuses:    []string{core.StatusSuccess, core.StatusFailed, core.StatusDegraded},
+		TLSProfiles: h.checker.TLSProfiles(),
+		Notifications: Notifications{
+			Alerts:        []string{},
+			Subscriptions: []string{},
+			ChatOps:       []string{},
+			Remediation:   []string{},
+		},
+	}
+
+	n := &caps.Notifications
+	if cfg.Alert.Enable && len(cfg.Alert.WebhookURLs) > 0 {
+		n.Alerts = append(n.Alerts, "webhook")
+	}
+	if cfg.Alert.Enable {
+		pushTypes := make(map[string]bool)
+		for _, sub := range cfg.Alert.Push {
+			if !pushTypes[sub.Type] {
+				pushTypes[sub.Type] = true
+				n.Alerts = append(n.Alerts, sub.Type)
+			}
+		}
+		if cfg.Alert.Escalation.Enable {
+			n.Alerts = append(n.Alerts, alert.ChannelSMS, alert.ChannelVoice)
+		}
+	}
+	if cfg.Subscriptions.Enable {
+		if cfg.Subscriptions.SMTP.Host != "" {
+			n.Subscriptions = append(n.Subscriptions, subscription.ChannelEmail)
+		}
+		n.Subscriptions = append(n.Subscriptions, subscription.ChannelWebhook)
+	}
+	if cfg.ChatOps.Enable {
+		n.ChatOps = append(n.ChatOps, "slack", "dingtalk")
+	}
+	if cfg.Events.Enable {
+		n.Events = cfg.Events.Driver
+	}
+	if cfg.Remediation.Enable {
+		types := make(map[string]bool)
+		for _, action := range cfg.Remediation.Actions {
+			if !types[action.Type] {
+				types[action.Type] = true
+				n.Remediation = append(n.Remediation, action.Type)
+			}
+		}
+		sort.Strings(n.Remediation)
+	}
+
+	if cfg.Agent.EnableAI {
+		caps.AI = &AICapabilities{
+			IntentParsers: agent.IntentParsers(),
+			OutputFormats: []string{agent.FormatPlain, agent.FormatMarkdown, agent.FormatJSON},
+			DefaultFormat: cfg.Agent.OutputFormat,
+		}
+	}
+	respond(c, http.StatusOK, caps)
+}
@@ -0,0 +1,125 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"servicetelemetry/core"
+)
+
+// CertificateReport 单个目标最近一次检查记录的证书链
+type CertificateReport struct {
+	TargetURL   string                 `json:"targetUrl"`
+	CheckedAt   time.Time              `json:"checkedAt"`
+	ChainValid  bool                   `json:"chainValid"`
+	ChainError  string                 `json:"chainError,omitempty"`
+	TrustedRoot string                 `json:"trustedRoot,omitempty"`
+	Chain       []core.CertificateInfo `json:"chain"`
+}
+
+// GetCertificates 查询各目标最近一次检查记录的证书链（签发者、主题、SAN、序列号、签名算法、有效期与证书链有效性）
+// 过滤参数：target（目标地址子串）、issuer（链中任一证书的签发者子串）、name（叶子证书主题或 SAN 子串）、
+// invalid=true（只返回证书链无效或有证书存在问题的目标）、maxDays（叶子证书剩余天数不超过该值）、hours（查询最近多少小时的结果，默认 24）
+func (h *Handler) GetCertificates(c *gin.Context) {
+	hours, err := strconv.Atoi(c.DefaultQuery("hours", "24"))
+	if err != nil || hours <= 0 {
+		respondError(c, CodeInvalidArgument, "hours参数错误，应为正整数", gin.H{"field": "hours"})
+		return
+	}
+	var maxDays *int
+	if v := c.Query("maxDays"); v != "" {
+		days, err := strconv.Atoi(v)
+		if err != nil {
+			respondError(c, CodeInvalidArgument, "maxDays 必须为整数", gin.H{"field": "maxDays"})
+			return
+		}
+		maxDays = &days
+	}
+	target := strings.ToLower(c.Query("target"))
+	issuer := strings.ToLower(c.Query("issuer"))
+	name := strings.ToLower(c.Query("name"))
+	invalidOnly := c.Query("invalid") == "true"
+
+	results, err := h.storage.LatestResultFields(time.Now().Add(-time.Duration(hours)*time.Hour), []string{"targetUrl", "details", "checkedAt"})
+	if err != nil {
+		respondError(c, CodeStorageError, "查询最近检查结果失败："+err.Error(), nil)
+		return
+	}
+
+	list := []*CertificateReport{}
+	for _, r := range results {
+		if r.Details == nil || r.Details.TLS == nil || len(r.Details.TLS.Chain) == 0 {
+			continue
+		}
+		tlsDetails := r.Details.TLS
+		leaf := tlsDetails.Chain[0]
+		switch {
+		case target != "" && !strings.Contains(strings.ToLower(r.TargetURL), target):
+			continue
+		case issuer != "" && !chainHasIssuer(tlsDetails.Chain, issuer):
+			continue
+		case name != "" && !certMatchesName(leaf, name):
+			continue
+		case invalidOnly && tlsDetails.ChainValid && !chainHasProblems(tlsDetails.Chain):
+			continue
+		case maxDays != nil && leaf.DaysLeft > *maxDays:
+			continue
+		}
+		list = append(list, &CertificateReport{
+			TargetURL:   r.TargetURL,
+			CheckedAt:   r.CheckedAt,
+			ChainValid:  tlsDetails.ChainValid,
+			ChainError:  tlsDetails.ChainError,
+			TrustedRoot: tlsDetails.TrustedRoot,
+			Chain:       tlsDetails.Chain,
+		})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Chain[0].DaysLeft != list[j].Chain[0].DaysLeft {
+			return list[i].Chain[0].DaysLeft < list[j].Chain[0].DaysLeft
+		}
+		return list[i].TargetURL < list[j].TargetURL
+	})
+	respond(c, http.StatusOK, gin.H{
+		"total": len(list),
+		"list":  list,
+	})
+}
+
+// chainHasIssuer 判断证书链中是否有证书的签发者包含指定子串（已转为小写）
+func chainHasIssuer(chain []core.CertificateInfo, issuer string) bool {
+	for _, cert := range chain {
+		if strings.Contains(strings.ToLower(cert.Issuer), issuer) {
+			return true
+		}
+	}
+	return false
+}
+
+// certMatchesName 判断证书主题或 SAN 是否包含指定子串（已转为小写）
+func certMatchesName(cert core.CertificateInfo, name string) bool {
+	if strings.Contains(strings.ToLower(cert.Subject), name) {
+		return true
+	}
+	for _, san := range cert.SANs {
+		if strings.Contains(strings.ToLower(san), name) {
+			return true
+		}
+	}
+	return false
+}
+
+// chainHasProblems 判断证书链中是否有证书存在问题
+func chainHasProblems(chain []core.CertificateInfo) bool {
+	for _, cert := range chain {
+		if len(cert.Problems) > 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,94 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"servicetelemetry/eventbus"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	changesDefaultLimit = 100  // 单次长轮询默认返回的最多变更条数
+	changesMaxLimit     = 1000 // 单次长轮询最多返回的变更条数
+)
+
+// changeTypes 长轮询支持的变更类型
+var changeTypes = map[string]bool{eventbus.TypeTransition: true, eventbus.TypeIncident: true}
+
+// GetChanges 长轮询读取状态变化与事件变更，适用于 SSE / WebSocket 被中间设备拦截、只能使用普通 HTTP 的环境
+// 参数：since 上次响应中的 cursor（不传时立即返回当前游标，不返回历史变更），wait 最长等待秒数（默认且不超过 api.longPollMaxWait），
+// limit 最多返回条数，types 只返回指定类型（transition / incident，逗号分隔）
+// 游标之后已有变更时立即返回；否则挂起请求直到有新变更或等待超时（超时返回空列表与原游标）
+func (h *Handler) GetChanges(c *gin.Context) {
+	if h.changes == nil {
+		respondError(c, CodeFeatureDisabled, "变更流未开启（api.changesBuffer 为 0）", nil)
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+
+	maxWait := h.cfg.API.LongPollMaxWait
+	wait := maxWait
+	if v := c.Query("wait"); v != "" {
+		seconds, err := strconv.Atoi(v)
+		if err != nil || seconds < 0 {
+			respondError(c, CodeInvalidArgument, "无效的等待时间："+v, gin.H{"field": "wait", "max": int(maxWait.Seconds())})
+			return
+		}
+		wait = min(time.Duration(seconds)*time.Second, maxWait)
+	}
+	limit := changesDefaultLimit
+	if v := c.Query("limit"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil || parsed <= 0 || parsed > changesMaxLimit {
+			respondError(c, CodeInvalidArgument, "无效的条数："+v, gin.H{"field": "limit", "max": changesMaxLimit})
+			return
+		}
+		limit = parsed
+	}
+	var types map[string]bool
+	if v := c.Query("types"); v != "" {
+		types = make(map[string]bool)
+		for _, t := range strings.Split(v, ",") {
+			t = strings.TrimSpace(t)
+			if !changeTypes[t] {
+				respondError(c, CodeInvalidArgument, "无效的变更类型："+t, gin.H{"field": "types", "allowed": []string{eventbus.TypeTransition, eventbus.TypeIncident}})
+				return
+			}
+			types[t] = true
+		}
+	}
+
+	since := c.Query("since")
+	if since == "" {
+		respond(c, http.StatusOK, gin.H{"changes": []eventbus.Change{}, "cursor": strconv.FormatUint(h.changes.Cursor(), 10), "reset": false})
+		return
+	}
+	cursor, err := strconv.ParseUint(since, 10, 64)
+	if err != nil {
+		respondError(c, CodeInvalidArgument, "无效的游标："+since, gin.H{"field": "since"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), wait)
+	defer cancel()
+	for {
+		changes, next, reset := h.changes.Since(cursor, limit)
+		matched := make([]eventbus.Change, 0, len(changes))
+		for _, change := range changes {
+			if types == nil || types[change.Type] {
+				matched = append(matched, change)
+			}
+		}
+		cursor = next
+		// 游标失效时立即返回，提醒调用方重新拉取全量状态
+		if len(matched) > 0 || reset || !h.changes.Wait(ctx, cursor) {
+			respond(c, http.StatusOK, gin.H{"changes": matched, "cursor": strconv.FormatUint(cursor, 10), "reset": reset})
+			return
+		}
+	}
+}
@@ -0,0 +1,437 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"servicetelemetry/agent"
+	"servicetelemetry/alert"
+	"servicetelemetry/config"
+	"servicetelemetry/core"
+
+	"github.com/gin-gonic/gin"
+)
+
+// chatCommand 解析后的斜杠命令
+type chatCommand struct {
+	Action string   // 子命令：status / why / silence / silences / unsilence / help
+	Args   []string // 子命令参数（按空白切分）
+	Text   string   // 子命令之后的原始文本，供 why 等自由文本命令使用
+	User   string   // 发起命令的用户
+}
+
+// chatReplyTimeout 延迟回复的发送超时
+const chatReplyTimeout = 10 * time.Second
+
+// chatPendingReply 耗时命令先返回的确认消息，结果稍后发送到聊天工具提供的回复地址
+const chatPendingReply = "正在分析，稍后回复……"
+
+// chatStopWords why 命令提取目标关键词时忽略的词
+var chatStopWords = map[string]bool{
+	"is": true, "are": true, "the": true, "a": true, "down": true, "up": true, "why": true,
+	"failing": true, "broken": true, "slow": true, "not": true, "working": true,
+	"挂了": true, "异常": true, "为什么": true, "怎么了": true,
+}
+
+// parseChatCommand 解析斜杠命令文本
+// text：用户输入的命令文本（可包含或不包含命令名）
+// commandName：配置的命令名称，如 /telemetry
+// user：发起命令的用户
+func parseChatCommand(text, commandName, user string) *chatCommand {
+	text = strings.TrimSpace(text)
+	if commandName != "" && strings.HasPrefix(text, commandName) {
+		text = strings.TrimSpace(strings.TrimPrefix(text, commandName))
+	}
+
+	cmd := &chatCommand{Action: "help", User: user}
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return cmd
+	}
+
+	cmd.Action = strings.ToLower(fields[0])
+	cmd.Args = fields[1:]
+	cmd.Text = strings.TrimSpace(strings.TrimPrefix(text, fields[0]))
+	return cmd
+}
+
+// executeChatCommand 执行斜杠命令，返回纯文本回复
+func (h *Handler) executeChatCommand(cmd *chatCommand) string {
+	switch cmd.Action {
+	case "status":
+		keyword := ""
+		if len(cmd.Args) > 0 {
+			keyword = cmd.Args[0]
+		}
+		return h.chatStatus(keyword)
+	case "why":
+		return h.chatWhy(cmd.Text)
+	case "silence":
+		return h.chatSilence(cmd)
+	case "silences":
+		return h.chatListSilences()
+	case "unsilence":
+		if len(cmd.Args) == 0 {
+			return "用法：" + h.cfg.ChatOps.CommandName + " unsilence <静默ID>"
+		}
+		id, err := strconv.ParseUint(cmd.Args[0], 10, 64)
+		if err != nil || !h.silences.Remove(id) {
+			return fmt.Sprintf("静默规则 %s 不存在", cmd.Args[0])
+		}
+		return fmt.Sprintf("已解除静默规则 #%d", id)
+	default:
+		return h.chatHelp()
+	}
+}
+
+// slow 判断命令是否需要较长时间（why 需要调用 AI），聊天工具要求在几秒内响应回调，这类命令改为延迟回复
+func (cmd *chatCommand) slow() bool {
+	return cmd.Action == "why"
+}
+
+// validChatReplyURL 校验聊天工具提供的延迟回复地址：只接受指定域名下的 HTTPS 地址
+// domain：聊天工具的域名，如 slack.com
+func validChatReplyURL(raw, domain string) bool {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme != "https" {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	return host == domain || strings.HasSuffix(host, "."+domain)
+}
+
+// deferChatReply 在后台执行命令，完成后将回复发送到聊天工具提供的回复地址（Slack response_url / 钉钉 sessionWebhook）
+// payload：将回复文本包装为对应聊天工具的消息格式
+func (h *Handler) deferChatReply(cmd *chatCommand, replyURL string, payload func(text string) gin.H) {
+	go func() {
+		body, err := json.Marshal(payload(h.executeChatCommand(cmd)))
+		if err != nil {
+			log.Errorf("序列化斜杠命令回复失败：%v", err)
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), chatReplyTimeout)
+		defer cancel()
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, replyURL, bytes.NewReader(body))
+		if err != nil {
+			log.Errorf("创建斜杠命令回复请求失败：%v", err)
+			return
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			log.Errorf("发送斜杠命令回复失败：%v", err)
+			return
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			log.Errorf("发送斜杠命令回复失败：HTTP %d", resp.StatusCode)
+		}
+	}()
+}
+
+// slackReply 构建 Slack 斜杠命令回复，回复对频道内所有人可见
+func slackReply(text string) gin.H {
+	return gin.H{"response_type": "in_channel", "text": text}
+}
+
+// dingTalkReply 构建钉钉机器人文本回复
+func dingTalkReply(text string) gin.H {
+	return gin.H{"msgtype": "text", "text": gin.H{"content": text}}
+}
+
+// chatHelp 返回斜杠命令帮助信息
+func (h *Handler) chatHelp() string {
+	name := h.cfg.ChatOps.CommandName
+	return strings.Join([]string{
+		"可用命令：",
+		name + " status [目标关键词]  —— 查看目标最新状态",
+		name + " why <问题描述>  —— AI 分析目标异常原因，例如：" + name + " why is checkout down",
+		name + " silence <目标关键词> <时长> [原因]  —— 静默告警，时长如 30m、2h、1d",
+		name + " silences  —— 查看生效中的静默规则",
+		name + " unsilence <静默ID>  —— 解除静默",
+	}, "\n")
+}
+
+// chatStatus 查询目标最新状态
+// keyword：目标地址关键词，为空时返回全部目标
+func (h *Handler) chatStatus(keyword string) string {
+	intent := &agent.QueryIntent{
+		TimeRangeHours: h.cfg.Agent.DefaultTimeRange,
+		TargetKeywords: []string{},
+	}
+	if keyword != "" {
+		intent.TargetKeywords = append(intent.TargetKeywords, keyword)
+	}
+
+	data, err := h.retriever.Retrieve(intent)
+	if err != nil {
+		return "数据检索失败：" + err.Error()
+	}
+	latest := latestResultPerTarget(data)
+	if len(latest) == 0 {
+		return fmt.Sprintf("近%d小时未查询到相关监控数据", intent.TimeRangeHours)
+	}
+	return formatChatResults(latest, h.cfg.ChatOps.MaxReplyItems)
+}
+
+// chatWhy 结合监控数据和 AI 总结回答异常原因
+// text：用户的自由文本问题
+func (h *Handler) chatWhy(text string) string {
+	if strings.TrimSpace(text) == "" {
+		return "用法：" + h.cfg.ChatOps.CommandName + " why <问题描述>"
+	}
+
+	intent := h.retriever.ParseIntent(text)
+	if len(intent.TargetKeywords) == 0 {
+		if kw := chatKeywordFromText(text); kw != "" {
+			intent.TargetKeywords = append(intent.TargetKeywords, kw)
+		}
+	}
+
+	data, err := h.retriever.Retrieve(intent)
+	if err != nil {
+		return "数据检索失败：" + err.Error()
+	}
+	latest := latestResultPerTarget(data)
+	if len(latest) == 0 {
+		return "未查询到相关监控数据，可先使用 status 命令确认目标名称"
+	}
+
+	listing := formatChatResults(latest, h.cfg.ChatOps.MaxReplyItems)
+	summary, _, err := h.summarizer.SummarizeAs(data, agent.FormatPlain, agent.DetectLanguage(text))
+	if err != nil && !errors.Is(err, agent.ErrAIUnavailable) {
+		return listing + "\n（AI 总结失败：" + err.Error() + "）"
+	}
+	return summary + "\n\n" + listing
+}
+
+// chatSilence 新增告警静默规则
+func (h *Handler) chatSilence(cmd *chatCommand) string {
+	if len(cmd.Args) < 2 {
+		return "用法：" + h.cfg.ChatOps.CommandName + " silence <目标关键词> <时长> [原因]"
+	}
+
+	duration, err := alert.ParseSilenceDuration(cmd.Args[1])
+	if err != nil {
+		return err.Error()
+	}
+	reason := strings.Join(cmd.Args[2:], " ")
+
+	s, err := h.silences.Add(cmd.Args[0], duration, cmd.User, reason)
+	if err != nil {
+		return "静默失败：" + err.Error()
+	}
+	return fmt.Sprintf("已静默 %s 至 %s（规则 #%d）", s.Matcher, s.EndsAt.Format("2006-01-02 15:04:05"), s.ID)
+}
+
+// chatListSilences 列出生效中的静默规则
+func (h *Handler) chatListSilences() string {
+	list := h.silences.List()
+	if len(list) == 0 {
+		return "当前没有生效中的静默规则"
+	}
+	lines := []string{"生效中的静默规则："}
+	for _, s := range list {
+		line := fmt.Sprintf("#%d %s 至 %s", s.ID, s.Matcher, s.EndsAt.Format("2006-01-02 15:04:05"))
+		if s.CreatedBy != "" {
+			line += "，创建人：" + s.CreatedBy
+		}
+		if s.Reason != "" {
+			line += "，原因：" + s.Reason
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// chatKeywordFromText 从自由文本中提取第一个非停用词作为目标关键词
+func chatKeywordFromText(text string) string {
+	for _, w := range strings.Fields(strings.ToLower(text)) {
+		w = strings.Trim(w, "?？!！,，.。")
+		if w != "" && !chatStopWords[w] {
+			return w
+		}
+	}
+	return ""
+}
+
+// latestResultPerTarget 每个目标仅保留最近一次检查结果，异常目标排在前面
+func latestResultPerTarget(results []*core.MonitorResult) []*core.MonitorResult {
+	latest := make(map[string]*core.MonitorResult)
+	for _, r := range results {
+		if cur, ok := latest[r.TargetURL]; !ok || r.CheckedAt.After(cur.CheckedAt) {
+			latest[r.TargetURL] = r
+		}
+	}
+
+	list := make([]*core.MonitorResult, 0, len(latest))
+	for _, r := range latest {
+		list = append(list, r)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Status != list[j].Status {
+			return list[i].Status == "failed"
+		}
+		return list[i].TargetURL < list[j].TargetURL
+	})
+	return list
+}
+
+// formatChatResults 将监控结果格式化为聊天消息文本
+// maxItems：最多列出的条数，<=0 时不限制
+func formatChatResults(results []*core.MonitorResult, maxItems int) string {
+	failed := 0
+	for _, r := range results {
+		if r.Status == "failed" {
+			failed++
+		}
+	}
+
+	lines := []string{fmt.Sprintf("共 %d 个目标，异常 %d 个：", len(results), failed)}
+	for i, r := range results {
+		if maxItems > 0 && i >= maxItems {
+			lines = append(lines, fmt.Sprintf("……其余 %d 个目标未列出", len(results)-maxItems))
+			break
+		}
+		if r.Status == "failed" {
+			lines = append(lines, fmt.Sprintf("❌ %s  %s（%s）", r.TargetURL, r.ErrorMsg, r.CheckedAt.Format("01-02 15:04")))
+		} else {
+			lines = append(lines, fmt.Sprintf("✅ %s  %.0fms（%s）", r.TargetURL, r.ResponseTime, r.CheckedAt.Format("01-02 15:04")))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ValidateChatOps 校验斜杠命令配置：开启时至少配置一个签名密钥，斜杠命令可以静默告警，不允许不校验签名的回调
+func ValidateChatOps(cfg *config.ChatOpsConfig) error {
+	if cfg.Enable && cfg.SlackSigningSecret == "" && cfg.DingTalkAppSecret == "" {
+		return fmt.Errorf("开启 chatops 时需要配置 slackSigningSecret 或 dingTalkAppSecret")
+	}
+	return nil
+}
+
+// registerChatOpsRoutes 注册斜杠命令回调，只注册配置了签名密钥的聊天工具
+func (h *Handler) registerChatOpsRoutes(apiGroup *gin.RouterGroup) {
+	if !h.cfg.ChatOps.Enable {
+		return
+	}
+	if h.cfg.ChatOps.SlackSigningSecret != "" {
+		apiGroup.POST("/chatops/slack", h.ChatOpsSlack)
+	}
+	if h.cfg.ChatOps.DingTalkAppSecret != "" {
+		apiGroup.POST("/chatops/dingtalk", h.ChatOpsDingTalk)
+	}
+}
+
+// ChatOpsSlack 处理 Slack 斜杠命令回调（application/x-www-form-urlencoded）
+func (h *Handler) ChatOpsSlack(c *gin.Context) {
+	secret := h.cfg.ChatOps.SlackSigningSecret
+	if !h.cfg.ChatOps.Enable || secret == "" {
+		respondError(c, CodeFeatureDisabled, "斜杠命令入口未开启", nil)
+		return
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		respondError(c, CodeInvalidArgument, "读取请求体失败："+err.Error(), nil)
+		return
+	}
+
+	if !verifySlackSignature(secret, c.GetHeader("X-Slack-Request-Timestamp"), c.GetHeader("X-Slack-Signature"), body) {
+		respondError(c, CodeUnauthenticated, "Slack 签名校验失败", nil)
+		return
+	}
+
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		respondError(c, CodeInvalidArgument, "参数错误："+err.Error(), nil)
+		return
+	}
+
+	cmd := parseChatCommand(form.Get("text"), h.cfg.ChatOps.CommandName, form.Get("user_name"))
+	// Slack 要求 3 秒内响应，耗时命令先回复确认（仅发起人可见），结果稍后发送到 response_url
+	if responseURL := form.Get("response_url"); cmd.slow() && validChatReplyURL(responseURL, "slack.com") {
+		h.deferChatReply(cmd, responseURL, slackReply)
+		c.JSON(http.StatusOK, gin.H{"response_type": "ephemeral", "text": chatPendingReply})
+		return
+	}
+	c.JSON(http.StatusOK, slackReply(h.executeChatCommand(cmd)))
+}
+
+// ChatOpsDingTalk 处理钉钉机器人消息回调
+func (h *Handler) ChatOpsDingTalk(c *gin.Context) {
+	secret := h.cfg.ChatOps.DingTalkAppSecret
+	if !h.cfg.ChatOps.Enable || secret == "" {
+		respondError(c, CodeFeatureDisabled, "斜杠命令入口未开启", nil)
+		return
+	}
+
+	if !verifyDingTalkSignature(secret, c.GetHeader("timestamp"), c.GetHeader("sign")) {
+		respondError(c, CodeUnauthenticated, "钉钉签名校验失败", nil)
+		return
+	}
+
+	type DingTalkMessage struct {
+		Text struct {
+			Content string `json:"content"`
+		} `json:"text"`
+		SenderNick     string `json:"senderNick"`
+		SessionWebhook string `json:"sessionWebhook"` // 当前会话的回复地址，用于延迟回复
+	}
+
+	var msg DingTalkMessage
+	if err := c.ShouldBindJSON(&msg); err != nil {
+		respondError(c, CodeInvalidArgument, "参数错误："+err.Error(), nil)
+		return
+	}
+
+	cmd := parseChatCommand(msg.Text.Content, h.cfg.ChatOps.CommandName, msg.SenderNick)
+	// 耗时命令先回复确认，结果稍后发送到会话的 sessionWebhook
+	if cmd.slow() && validChatReplyURL(msg.SessionWebhook, "dingtalk.com") {
+		h.deferChatReply(cmd, msg.SessionWebhook, dingTalkReply)
+		c.JSON(http.StatusOK, dingTalkReply(chatPendingReply))
+		return
+	}
+	c.JSON(http.StatusOK, dingTalkReply(h.executeChatCommand(cmd)))
+}
+
+// verifySlackSignature 校验 Slack 请求签名，拒绝5分钟之前的请求以防重放
+func verifySlackSignature(secret, timestamp, signature string, body []byte) bool {
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || time.Since(time.Unix(ts, 0)).Abs() > 5*time.Minute {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("v0:" + timestamp + ":"))
+	mac.Write(body)
+	expected := "v0=" + hex.EncodeToString(mac.Sum(nil))
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
+
+// verifyDingTalkSignature 校验钉钉机器人回调签名，时间戳需在1小时以内
+func verifyDingTalkSignature(secret, timestamp, signature string) bool {
+	ms, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || time.Since(time.UnixMilli(ms)).Abs() > time.Hour {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "\n" + secret))
+	expected := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
@@ -0,0 +1,71 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"servicetelemetry/core"
+	"servicetelemetry/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// mergeTargetsRequest 合并重复目标请求体
+type mergeTargetsRequest struct {
+	Into   string   `json:"into" binding:"required"` // 保留的目标地址
+	From   []string `json:"from" binding:"required"` // 合并到 into 后删除的目标地址
+	DryRun bool     `json:"dryRun"`                  // 只统计将要迁移的记录数，不修改数据
+}
+
+// GetDuplicateTargets 查找疑似重复的目标（规范化地址相同，或 HTTP(S) 主机名与路径相同），附带各目标的历史概况与建议保留的目标
+func (h *Handler) GetDuplicateTargets(c *gin.Context) {
+	groups, err := h.storage.FindDuplicateTargets()
+	if err != nil {
+		respondError(c, CodeStorageError, "查找重复目标失败："+err.Error(), nil)
+		return
+	}
+	respond(c, http.StatusOK, gin.H{
+		"total":  len(groups),
+		"groups": groups,
+	})
+}
+
+// MergeTargets 将重复目标合并到保留的目标：迁移检查结果、状态变化记录与处置执行记录，合并标签后删除被合并的目标
+// 请求体：{"into": "https://example.com", "from": ["https://EXAMPLE.com/"], "dryRun": true}
+func (h *Handler) MergeTargets(c *gin.Context) {
+	var req mergeTargetsRequest
+	if err := bindBody(c, &req); err != nil {
+		respondError(c, CodeInvalidArgument, "请求参数错误："+err.Error(), nil)
+		return
+	}
+	if len(req.From) == 0 {
+		respondError(c, CodeInvalidArgument, "from 不能为空", gin.H{"field": "from"})
+		return
+	}
+
+	report, err := h.storage.MergeTargets(req.Into, req.From, req.DryRun)
+	switch {
+	case errors.Is(err, storage.ErrMergeTargetNotFound):
+		respondError(c, CodeNotFound, err.Error(), nil)
+		return
+	case errors.Is(err, storage.ErrMergeIntoSelf):
+		respondError(c, CodeInvalidArgument, err.Error(), gin.H{"field": "from"})
+		return
+	case err != nil:
+		respondError(c, CodeStorageError, "合并目标失败："+err.Error(), nil)
+		return
+	}
+
+	if !req.DryRun {
+		h.heartbeats.invalidate()
+		// 被合并目标的缓存结果不再有效；与保留目标规范化地址相同的共用同一缓存，保留
+		into := core.CanonicalTargetURL(req.Into)
+		for _, u := range report.Merged {
+			if core.CanonicalTargetURL(u) != into {
+				h.checker.ForgetTarget(u)
+			}
+		}
+		log.Infof("已将 %d 个目标合并到 %s（迁移 %d 条结果）", len(report.Merged), report.Into, report.Results)
+	}
+	respond(c, http.StatusOK, report)
+}
@@ -0,0 +1,33 @@
+package api
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/gin-gonic/gin/render"
+)
+
+// respond 根据 Accept 请求头协商响应编码：声明接受 MessagePack 时返回二进制编码，否则返回 JSON
+// 适用于大屏高频轮询等对体积和解析耗时敏感的接口
+func respond(c *gin.Context, code int, obj interface{}) {
+	c.Writer.Header().Add("Vary", "Accept")
+	if c.GetString(ctxRoleKey) == RoleViewer {
+		obj = minimizeForViewer(obj)
+	}
+	switch c.NegotiateFormat(binding.MIMEJSON, binding.MIMEMSGPACK2, binding.MIMEMSGPACK) {
+	case binding.MIMEMSGPACK, binding.MIMEMSGPACK2:
+		c.Render(code, render.MsgPack{Data: obj})
+	default:
+		c.JSON(code, obj)
+	}
+}
+
+// bindBody 根据 Content-Type 解析请求体，支持 JSON 与 MessagePack
+func bindBody(c *gin.Context, obj interface{}) error {
+	contentType := strings.ToLower(c.ContentType())
+	if contentType == binding.MIMEMSGPACK || contentType == binding.MIMEMSGPACK2 {
+		return c.ShouldBindWith(obj, binding.MsgPack)
+	}
+	return c.ShouldBindJSON(obj)
+}
@@ -0,0 +1,59 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrorCode 机器可读的错误码，前端与自动化脚本据此分支处理，并可在客户端生成本地化提示
+type ErrorCode string
+
+const (
+	CodeInvalidArgument ErrorCode = "INVALID_ARGUMENT" // 请求参数或请求体不合法
+	CodeUnauthenticated ErrorCode = "UNAUTHENTICATED"  // 签名或身份校验失败
+	CodeNotFound        ErrorCode = "NOT_FOUND"        // 请求的资源不存在
+	CodeConflict        ErrorCode = "CONFLICT"         // 与正在处理的请求冲突（如相同幂等键的请求尚未完成）
+	CodeFeatureDisabled ErrorCode = "FEATURE_DISABLED" // 对应功能未开启
+	CodeStorageError    ErrorCode = "STORAGE_ERROR"    // 数据库读写失败
+	CodeAIError         ErrorCode = "AI_ERROR"         // AI 模型调用失败
+	CodeInternal        ErrorCode = "INTERNAL"         // 其他内部错误
+)
+
+// errorStatus 错误码对应的 HTTP 状态码
+var errorStatus = map[ErrorCode]int{
+	CodeInvalidArgument: http.StatusBadRequest,
+	CodeUnauthenticated: http.StatusUnauthorized,
+	CodeNotFound:        http.StatusNotFound,
+	CodeConflict:        http.StatusConflict,
+	CodeFeatureDisabled: http.StatusNotFound,
+	CodeStorageError:    http.StatusInternalServerError,
+	CodeAIError:         http.StatusInternalServerError,
+	CodeInternal:        http.StatusInternalServerError,
+}
+
+// APIError 统一错误响应体，所有接口的错误均以 {"error": APIError} 返回
+type APIError struct {
+	Code      ErrorCode   `json:"code"`              // 错误码
+	Message   string      `json:"message"`           // 错误描述（中文，仅供展示）
+	Details   interface{} `json:"details,omitempty"` // 错误详情，如出错的字段、可选值等
+	RequestID string      `json:"requestId"`         // 请求ID，与响应头 X-Request-ID 一致，便于排查日志
+}
+
+// newAPIError 创建错误响应体
+func newAPIError(c *gin.Context, code ErrorCode, message string, details interface{}) *APIError {
+	return &APIError{
+		Code:      code,
+		Message:   message,
+		Details:   details,
+		RequestID: c.GetString(requestIDKey),
+	}
+}
+
+// respondError 按错误码返回统一格式的错误响应
+// code：错误码，决定 HTTP 状态码
+// message：错误描述
+// details：错误详情（可选）
+func respondError(c *gin.Context, code ErrorCode, message string, details interface{}) {
+	respond(c, errorStatus[code], gin.H{"error": newAPIError(c, code, message, details)})
+}
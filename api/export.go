@@ -0,0 +1,86 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	exportBatchSize = 500     // 流式导出时每次从数据库读取的目标数
+	exportMaxLimit  = 1000000 // 单次导出的最大目标数
+)
+
+// ExportTargets 以 NDJSON（每行一个 JSON 对象）流式导出监控目标，按目标ID升序，分批读取数据库，内存占用与目标总数无关
+// 参数：cursor 从该ID之后继续导出（传上次收到的最后一行的 id，断点续传），limit 本次最多导出条数（默认全部），all=true 包含已停用目标
+// 导出结束后通过 HTTP Trailer 返回 X-Next-Cursor（还有剩余时为下一页游标，否则为空）与 X-Export-Count
+func (h *Handler) ExportTargets(c *gin.Context) {
+	var cursor int64
+	if v := c.Query("cursor"); v != "" {
+		parsed, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || parsed < 0 {
+			respondError(c, CodeInvalidArgument, "无效的游标："+v, gin.H{"field": "cursor"})
+			return
+		}
+		cursor = parsed
+	}
+	limit := exportMaxLimit
+	if v := c.Query("limit"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil || parsed <= 0 || parsed > exportMaxLimit {
+			respondError(c, CodeInvalidArgument, "无效的导出条数："+v, gin.H{"field": "limit", "max": exportMaxLimit})
+			return
+		}
+		limit = parsed
+	}
+	onlyCurrent := c.Query("all") != "true"
+
+	// 首批在写出响应头之前读取，数据库异常时仍可返回标准错误响应
+	batch, err := h.storage.ListTargetsAfter(cursor, min(exportBatchSize, limit+1), onlyCurrent)
+	if err != nil {
+		respondError(c, CodeStorageError, "导出监控目标失败："+err.Error(), nil)
+		return
+	}
+
+	header := c.Writer.Header()
+	header.Set("Content-Type", "application/x-ndjson; charset=utf-8")
+	header.Set("Trailer", "X-Next-Cursor, X-Export-Count, X-Export-Error")
+	c.Status(http.StatusOK)
+
+	encoder := json.NewEncoder(c.Writer)
+	count, last := 0, cursor
+	nextCursor := ""
+	for len(batch) > 0 {
+		for _, t := range batch {
+			if count == limit {
+				// 多读的一条用于判断是否还有剩余
+				nextCursor = strconv.FormatInt(last, 10)
+				break
+			}
+			// 明文凭据不随导出结果输出
+			t.TargetOptions = t.TargetOptions.Masked()
+			if err := encoder.Encode(t); err != nil {
+				// 客户端断开，无需继续
+				return
+			}
+			count++
+			last = t.ID
+		}
+		c.Writer.Flush()
+		if nextCursor != "" || len(batch) < exportBatchSize {
+			break
+		}
+		batch, err = h.storage.ListTargetsAfter(last, min(exportBatchSize, limit-count+1), onlyCurrent)
+		if err != nil {
+			log.Errorf("导出监控目标中断（已导出 %d 条）：%v", count, err)
+			header.Set("X-Export-Error", err.Error())
+			nextCursor = strconv.FormatInt(last, 10)
+			break
+		}
+	}
+
+	header.Set("X-Next-Cursor", nextCursor)
+	header.Set("X-Export-Count", strconv.Itoa(count))
+}
@@ -0,0 +1,59 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// failoverEnabled 判断故障切换路径演练是否开启，未开启时返回错误响应
+func (h *Handler) failoverEnabled(c *gin.Context) bool {
+	if h.failover == nil {
+		respondError(c, CodeFeatureDisabled, "故障切换路径演练未开启", nil)
+		return false
+	}
+	return true
+}
+
+// GetFailoverReports 查询各组主备路径的最新演练报告
+func (h *Handler) GetFailoverReports(c *gin.Context) {
+	if !h.failoverEnabled(c) {
+		return
+	}
+	lastRun, reports := h.failover.Reports()
+	respond(c, http.StatusOK, gin.H{
+		"lastRun": lastRun,
+		"total":   len(reports),
+		"list":    reports,
+	})
+}
+
+// GetFailoverHistory 查询指定主备路径最近的演练报告（最新的在前）
+func (h *Handler) GetFailoverHistory(c *gin.Context) {
+	if !h.failoverEnabled(c) {
+		return
+	}
+	name := c.Param("name")
+	history := h.failover.History(name)
+	if len(history) == 0 {
+		respondError(c, CodeNotFound, "主备路径不存在或尚未演练："+name, gin.H{"name": name})
+		return
+	}
+	respond(c, http.StatusOK, gin.H{
+		"name":  name,
+		"total": len(history),
+		"list":  history,
+	})
+}
+
+// RunFailoverProbes 立即执行一轮故障切换路径演练并返回报告
+func (h *Handler) RunFailoverProbes(c *gin.Context) {
+	if !h.failoverEnabled(c) {
+		return
+	}
+	reports := h.failover.Run()
+	respond(c, http.StatusOK, gin.H{
+		"total": len(reports),
+		"list":  reports,
+	})
+}
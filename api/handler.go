@@ -1,42 +1,98 @@
 package api
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"servicetelemetry/agent"
+	"servicetelemetry/alert"
 	"servicetelemetry/config"
 	"servicetelemetry/core"
+	"servicetelemetry/ctwatch"
+	"servicetelemetry/eventbus"
+	"servicetelemetry/failover"
+	"servicetelemetry/logger"
+	"servicetelemetry/metrics"
+	"servicetelemetry/probe"
+	"servicetelemetry/remediation"
+	"servicetelemetry/scheduler"
+	"servicetelemetry/snapshot"
 	"servicetelemetry/storage"
+	"servicetelemetry/subscription"
 
 	"github.com/gin-gonic/gin"
 )
 
+var log = logger.New("api")
+
 // 改造Handler结构体，新增summarizer字段
 type Handler struct {
-	checker    *core.ServiceChecker
-	storage    *storage.MySQLStorage
-	retriever  *agent.DataRetriever
-	cfg        *config.GlobalConfig
-	summarizer *agent.LightweightSummarizer // 新增：小助手AI实例
+	checker       *core.ServiceChecker
+	storage       *storage.MySQLStorage
+	retriever     *agent.DataRetriever
+	cfg           *config.GlobalConfig
+	summarizer    *agent.LightweightSummarizer // 新增：小助手AI实例
+	silences      *alert.SilenceManager        // 告警静默规则管理器
+	alerts        *alert.Manager               // 告警管理器
+	scheduler     *scheduler.Scheduler         // 定时调度器
+	bus           *eventbus.Bus                // 事件总线，未开启时为 nil
+	ctWatcher     *ctwatch.Watcher             // 证书透明度日志监控器，未开启时为 nil
+	snapshots     *snapshot.Manager            // 配置快照管理器，未开启时为 nil
+	subscriptions *subscription.Manager        // 状态订阅管理器，未开启时为 nil
+	failover      *failover.Prober             // 故障切换路径演练器，未开启时为 nil
+	remediation   *remediation.Manager         // 自动处置管理器，未开启时为 nil
+	idempotency   *idempotencyStore            // 写接口幂等键缓存，未开启时为 nil
+	heartbeats    *heartbeatIndex              // 心跳令牌索引
+	changes       *eventbus.Feed               // 长轮询变更流，未开启时为 nil
+	probes        *probe.Authenticator         // 探测节点 mTLS 认证器，未开启时为 nil
+	fleet         *probe.Fleet                 // 探测节点集合（心跳与版本），未开启时为 nil
 }
 
-// 改造NewHandler，初始化summarizer
+// NewHandler 创建HTTP接口处理器
 func NewHandler(
 	checker *core.ServiceChecker,
 	storage *storage.MySQLStorage,
 	retriever *agent.DataRetriever,
 	cfg *config.GlobalConfig,
+	summarizer *agent.LightweightSummarizer,
+	silences *alert.SilenceManager,
+	alerts *alert.Manager,
+	sched *scheduler.Scheduler,
+	bus *eventbus.Bus,
+	ctWatcher *ctwatch.Watcher,
+	snapshots *snapshot.Manager,
+	subscriptions *subscription.Manager,
+	failoverProber *failover.Prober,
+	remediator *remediation.Manager,
+	changes *eventbus.Feed,
+	probes *probe.Authenticator,
+	fleet *probe.Fleet,
 ) *Handler {
 	return &Handler{
-		checker:    checker,
-		storage:    storage,
-		retriever:  retriever,
-		cfg:        cfg,
-		summarizer: agent.NewLightweightSummarizer(&cfg.Agent), // 初始化AI实例
+		checker:       checker,
+		storage:       storage,
+		retriever:     retriever,
+		cfg:           cfg,
+		summarizer:    summarizer,
+		silences:      silences,
+		alerts:        alerts,
+		scheduler:     sched,
+		bus:           bus,
+		ctWatcher:     ctWatcher,
+		snapshots:     snapshots,
+		subscriptions: subscriptions,
+		failover:      failoverProber,
+		remediation:   remediator,
+		idempotency:   newIdempotencyStore(&cfg.API),
+		heartbeats:    &heartbeatIndex{},
+		changes:       changes,
+		probes:        probes,
+		fleet:         fleet,
 	}
 }
 
@@ -45,79 +101,298 @@ func (h *Handler) SubmitTargets(c *gin.Context) {
 	type TargetRequest struct {
 		Targets []string `json:"targets" binding:"required"`
 		Keyword string   `json:"keyword"`
+		Tags    []string `json:"tags"`
+		DryRun  bool     `json:"dryRun"` // 演练模式：只执行检查，不入库、不告警
+		config.TargetOptions
 	}
 
 	var req TargetRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "参数错误：" + err.Error()})
+	if err := bindBody(c, &req); err != nil {
+		respondError(c, CodeInvalidArgument, "参数错误："+err.Error(), nil)
+		return
+	}
+	if c.Query("dryRun") == "true" {
+		req.DryRun = true
+	}
+	// 凭据引用会读取服务端的环境变量或文件并发送给目标，只能在声明式目标定义文件中使用
+	if refs := req.TargetOptions.LocalSecretRefs(); len(refs) > 0 {
+		respondError(c, CodeInvalidArgument, "通过接口提交的目标不能使用 env: / file: 凭据引用，请在声明式目标定义文件中配置", gin.H{"fields": refs})
+		return
+	}
+	// 出口代理由运维统一配置，接口提交的目标不能改用其他代理或绕过代理直连（direct）
+	if req.Proxy != "" {
+		respondError(c, CodeInvalidArgument, "通过接口提交的目标不能配置 proxy，检查时统一使用全局代理 monitor.proxy", gin.H{"field": "proxy"})
+		return
+	}
+
+	// 目标地址安全校验（SSRF 防护）：被拒绝的目标记为 rejected，其余目标照常处理；全部被拒绝时整个请求失败
+	// 按提交顺序记录每个目标的处理结果，各 goroutine 只写自己的下标
+	outcomes := make([]TargetOutcome, len(req.Targets))
+	var rejected []gin.H
+	for i, u := range req.Targets {
+		normalized, err := h.checker.ValidateURL(u)
+		if err != nil {
+			reason := err.Error()
+			if re, ok := err.(*core.URLRejectedError); ok {
+				reason = re.Reason
+			}
+			rejected = append(rejected, gin.H{"url": u, "reason": reason})
+			outcomes[i] = TargetOutcome{URL: u, Outcome: OutcomeRejected, Reason: ReasonURLRejected, Error: reason}
+			continue
+		}
+		req.Targets[i] = normalized
+		outcomes[i].URL = normalized
+	}
+	if len(req.Targets) > 0 && len(rejected) == len(req.Targets) {
+		respondError(c, CodeInvalidArgument, fmt.Sprintf("%d 个目标地址未通过安全校验", len(rejected)), gin.H{"rejected": rejected})
+		return
+	}
+	// 比对地址为全部目标共用，被拒绝时整个请求失败
+	if req.Compare != nil {
+		normalized, err := h.checker.ValidateURL(req.Compare.URL)
+		if err != nil {
+			reason := err.Error()
+			if re, ok := err.(*core.URLRejectedError); ok {
+				reason = re.Reason
+			}
+			respondError(c, CodeInvalidArgument, "比对地址未通过安全校验", gin.H{"rejected": []gin.H{{"url": req.Compare.URL, "reason": "比对地址：" + reason}}})
+			return
+		}
+		req.Compare.URL = normalized
+	}
+	// 摘要文件地址同样由服务端请求，校验方式与比对地址一致
+	if req.Checksum != nil && req.Checksum.URL != "" {
+		normalized, err := h.checker.ValidateURL(req.Checksum.URL)
+		if err != nil {
+			reason := err.Error()
+			if re, ok := err.(*core.URLRejectedError); ok {
+				reason = re.Reason
+			}
+			respondError(c, CodeInvalidArgument, "摘要文件地址未通过安全校验", gin.H{"rejected": []gin.H{{"url": req.Checksum.URL, "reason": "摘要文件地址：" + reason}}})
+			return
+		}
+		req.Checksum.URL = normalized
+	}
+
+	// 处置动作会以目标地址与错误信息执行运维配置的脚本或调用内部接口，只能在声明式目标定义文件中引用
+	if len(req.Remediation) > 0 {
+		respondError(c, CodeInvalidArgument, "通过接口提交的目标不能配置 remediation，请在声明式目标定义文件中配置", gin.H{"field": "remediation"})
+		return
+	}
+
+	// 限定在其他区域检查的目标不在本实例检查，只保存目标，由对应区域的实例调度检查
+	if probe := (&core.MonitorTarget{TargetOptions: req.TargetOptions}); !probe.AllowedIn(h.cfg.Monitor.Region) {
+		regions := strings.Join(req.Regions, "、")
+		var deferred []string
+		for i, u := range req.Targets {
+			if outcomes[i].Outcome == OutcomeRejected {
+				continue
+			}
+			deferred = append(deferred, u)
+			outcomes[i].Outcome = OutcomeDeferred
+			if req.DryRun {
+				continue
+			}
+			target := &core.MonitorTarget{URL: u, Keyword: req.Keyword, IsCurrent: true, Tags: req.Tags, Source: core.TargetSourceAPI, TargetOptions: req.TargetOptions}
+			if err := h.storage.SaveTarget(target); err != nil {
+				log.Errorf("保存目标[%s]失败：%v", u, err)
+				outcomes[i].Outcome, outcomes[i].Reason, outcomes[i].Error = OutcomeSaveFailed, ReasonTargetSaveFailed, err.Error()
+			}
+		}
+		if req.DryRun {
+			respond(c, multiStatus(outcomes), gin.H{
+				"message":  fmt.Sprintf("目标限定在区域 %s 检查，本实例（区域：%s）不执行检查", regions, h.cfg.Monitor.Region),
+				"dryRun":   true,
+				"results":  []*core.MonitorResult{},
+				"deferred": deferred,
+				"outcomes": outcomes,
+				"summary":  summarizeOutcomes(outcomes),
+			})
+			return
+		}
+		respond(c, multiStatus(outcomes), gin.H{
+			"message":  fmt.Sprintf("目标限定在区域 %s 检查，已保存，由对应区域的探测实例检查", regions),
+			"results":  []*core.MonitorResult{},
+			"deferred": deferred,
+			"outcomes": outcomes,
+			"summary":  summarizeOutcomes(outcomes),
+		})
 		return
 	}
 
 	limiter := core.NewConcurrencyLimiter(h.cfg.Monitor.Concurrency)
 	var wg sync.WaitGroup
 	var mu sync.Mutex
-	var results []*core.MonitorResult
+	var previews []*alert.Alert
+	checked := make([]*core.MonitorResult, len(req.Targets))
 
-	wg.Add(len(req.Targets))
-	for _, url := range req.Targets {
+	for i, url := range req.Targets {
+		if outcomes[i].Outcome == OutcomeRejected {
+			continue
+		}
+		wg.Add(1)
 		limiter.Acquire()
-		go func(u string) {
+		go func(i int, u string) {
 			defer limiter.Release()
 			defer wg.Done()
 
 			target := &core.MonitorTarget{
-				URL:       u,
-				Keyword:   req.Keyword,
-				IsCurrent: true,
+				URL:           u,
+				Keyword:       req.Keyword,
+				IsCurrent:     true,
+				Tags:          req.Tags,
+				Source:        core.TargetSourceAPI,
+				TargetOptions: req.TargetOptions,
+			}
+			// 已存在规范化地址相同的历史目标时检查前就沿用其地址，结果、告警与自动修复都对应同一目标
+			if !req.DryRun {
+				existing, err := h.storage.ExistingTargetURL(u)
+				if err != nil {
+					log.Errorf("查询目标[%s]已保存的地址失败：%v", u, err)
+				}
+				target.URL = existing
+			}
+
+			if req.DryRun {
+				result := h.checker.Probe(target)
+				checked[i] = result
+				outcomes[i] = TargetOutcome{URL: u, Outcome: OutcomeChecked, Status: result.Status}
+				preview := h.alerts.Preview(result)
+				mu.Lock()
+				if preview != nil {
+					previews = append(previews, preview)
+				}
+				mu.Unlock()
+				return
 			}
 
-			result := h.checker.CheckTarget(target)
+			result, cached := h.checker.CheckTargetCached(target)
+			checked[i] = result
+			outcomes[i] = TargetOutcome{URL: u, Outcome: OutcomeChecked, Status: result.Status}
+			// 缓存命中的结果已在检查时处理并入库，不重复告警、不重复写入
+			if cached {
+				outcomes[i].Outcome = OutcomeCached
+				if err := h.storage.SaveTarget(target); err != nil {
+					log.Errorf("保存目标[%s]失败：%v", u, err)
+					outcomes[i].Outcome, outcomes[i].Reason, outcomes[i].Error = OutcomeSaveFailed, ReasonTargetSaveFailed, err.Error()
+				}
+				return
+			}
+			h.alerts.Process(result)
+			h.remediation.HandleResult(target, result)
 			if err := h.storage.SaveTarget(target); err != nil {
-				fmt.Printf("保存目标[%s]失败：%v\n", u, err)
+				log.Errorf("保存目标[%s]失败：%v", u, err)
+				outcomes[i].Outcome, outcomes[i].Reason, outcomes[i].Error = OutcomeSaveFailed, ReasonTargetSaveFailed, err.Error()
 			}
 			if err := h.storage.SaveResult(result); err != nil {
-				fmt.Printf("保存结果[%s]失败：%v\n", u, err)
-			} else {
-				mu.Lock()
-				results = append(results, result)
-				mu.Unlock()
+				log.Errorf("保存结果[%s]失败：%v", u, err)
+				outcomes[i].Outcome, outcomes[i].Reason, outcomes[i].Error = OutcomeSaveFailed, ReasonResultSaveFailed, err.Error()
+				return
 			}
-		}(url)
+			h.checker.TraceOnFailure(target, result, h.storage.UpdateResultDetails)
+			h.bus.Emit(eventbus.TypeResult, result.TargetURL, result)
+		}(i, url)
 	}
 
 	wg.Wait()
+	if !req.DryRun {
+		// 新提交的心跳目标立即可以上报
+		h.heartbeats.invalidate()
+	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"message": "检查完成",
-		"results": results,
+	if req.DryRun {
+		resp := gin.H{
+			"message":  "演练完成（结果未入库，告警未发送）",
+			"dryRun":   true,
+			"results":  checked,
+			"alerts":   previews,
+			"outcomes": outcomes,
+			"summary":  summarizeOutcomes(outcomes),
+		}
+		if len(rejected) > 0 {
+			resp["rejected"] = rejected
+		}
+		respond(c, multiStatus(outcomes), resp)
+		return
+	}
+
+	summary := summarizeOutcomes(outcomes)
+	message := "检查完成"
+	if failed := summary.Rejected + summary.SaveFailed; failed > 0 {
+		message = fmt.Sprintf("检查完成，%d 个目标处理失败（未通过安全校验 %d 个，保存失败 %d 个）", failed, summary.Rejected, summary.SaveFailed)
+	}
+	respond(c, multiStatus(outcomes), gin.H{
+		"message":    message,
+		"results":    checked,
+		"outcomes":   outcomes,
+		"summary":    summary,
+		"snapshotAt": time.Now(),
 	})
 }
 
+// RunSchedulerCycle 立即执行一次调度周期，dryRun=true 时只返回将会入库的结果和将会发送的告警
+func (h *Handler) RunSchedulerCycle(c *gin.Context) {
+	report := h.scheduler.RunCycle(c.Query("dryRun") == "true")
+	respond(c, http.StatusOK, report)
+}
+
+// GetSchedulerLastReport 查询最近一次调度周期的执行报告
+func (h *Handler) GetSchedulerLastReport(c *gin.Context) {
+	report := h.scheduler.LastReport()
+	if report == nil {
+		respondError(c, CodeNotFound, "调度器尚未执行过", nil)
+		return
+	}
+	respond(c, http.StatusOK, report)
+}
+
+// GetSchedulerPreview 预览各目标接下来的计划检查时间（考虑检查间隔、结果缓存、静默期与区域限定），用于核对调度配置
+// 查询参数：n 每个目标的计划次数（默认 5，最大 100），target 目标地址过滤（子串匹配）
+func (h *Handler) GetSchedulerPreview(c *gin.Context) {
+	n := 5
+	if v := c.Query("n"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil || parsed <= 0 || parsed > 100 {
+			respondError(c, CodeInvalidArgument, "n 应为 1~100 的整数", gin.H{"field": "n"})
+			return
+		}
+		n = parsed
+	}
+	preview, err := h.scheduler.Preview(n, c.Query("target"), h.silences)
+	if err != nil {
+		respondError(c, CodeStorageError, "加载监控目标失败："+err.Error(), nil)
+		return
+	}
+	respond(c, http.StatusOK, preview)
+}
+
 // 改造AgentQuery方法，支持通用问答
 func (h *Handler) AgentQuery(c *gin.Context) {
 	type AgentQueryRequest struct {
 		UserQuery string `json:"userQuery" binding:"required"`
 		Mode      string `json:"mode" binding:"required"`
+		Format    string `json:"format"` // AI 回复格式：plain / markdown / json，为空使用 agent.outputFormat
 	}
 
 	var req AgentQueryRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"isSuccess": false,
-			"errorMsg":  "参数错误：" + err.Error(),
-		})
+		respondAgentError(c, CodeInvalidArgument, "参数错误："+err.Error())
+		return
+	}
+	if !agent.ValidFormat(req.Format) {
+		respondAgentError(c, CodeInvalidArgument, "无效的回复格式："+req.Format+"，可选 plain / markdown / json")
 		return
 	}
+	if req.Format == "" {
+		req.Format = h.cfg.Agent.OutputFormat
+	}
 
 	// 模式1：data - 纯监控数据查询（原有功能，无修改）
 	if req.Mode == "data" {
-		intent := agent.ParseQueryIntent(req.UserQuery, h.cfg.Agent.DefaultTimeRange)
+		intent := h.retriever.ParseIntent(req.UserQuery)
 		data, err := h.retriever.Retrieve(intent)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"isSuccess": false,
-				"errorMsg":  "数据检索失败：" + err.Error(),
-			})
+			respondAgentError(c, CodeStorageError, "数据检索失败："+err.Error())
 			return
 		}
 
@@ -131,6 +406,9 @@ func (h *Handler) AgentQuery(c *gin.Context) {
 
 	// 模式2：ai - 分【监控总结】/【通用问答】，显式区分（核心改造）
 	if req.Mode == "ai" {
+		// 识别提问语言，模型使用相同的语言回答
+		lang := agent.DetectLanguage(req.UserQuery)
+
 		// 第一步：检查是否有/chat前缀（优先）
 		userQueryTrim := strings.TrimSpace(req.UserQuery)
 		isGeneralChat := strings.HasPrefix(userQueryTrim, "/chat")
@@ -141,17 +419,18 @@ func (h *Handler) AgentQuery(c *gin.Context) {
 			realQuery = strings.TrimPrefix(userQueryTrim, "/chat")
 			realQuery = strings.TrimSpace(realQuery)
 			if realQuery == "" {
-				c.JSON(http.StatusBadRequest, gin.H{
-					"isSuccess": false,
-					"errorMsg":  "通用问答请输入/chat 加具体问题，例如：/chat 什么是HTTP 502？",
-				})
+				respondAgentError(c, CodeInvalidArgument, "通用问答请输入/chat 加具体问题，例如：/chat 什么是HTTP 502？")
 				return
 			}
 		} else {
 			// 第二步：无/chat前缀，但通过关键词识别通用问答（双重保险）
 			generalKeywords := []string{"如何", "什么是", "区别", "为什么", "怎么", "教程", "含义", "原理", "步骤"}
+			if lang == agent.LangEnglish {
+				generalKeywords = englishGeneralKeywords
+			}
+			lowerQuery := strings.ToLower(userQueryTrim)
 			for _, kw := range generalKeywords {
-				if strings.Contains(userQueryTrim, kw) {
+				if strings.Contains(lowerQuery, kw) {
 					isGeneralChat = true
 					realQuery = userQueryTrim
 					break
@@ -161,68 +440,108 @@ func (h *Handler) AgentQuery(c *gin.Context) {
 
 		// 通用问答逻辑（带前缀或匹配关键词）
 		if isGeneralChat {
-			chatReply, err := h.summarizer.Chat(realQuery)
-			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{
-					"isSuccess": false,
-					"errorMsg":  "小助手回答失败：" + err.Error(),
+			chatReply, err := h.summarizer.ChatAs(realQuery, req.Format)
+			if errors.Is(err, agent.ErrOutOfScope) {
+				// 超出配置的话题范围：正常返回拒绝提示，不视为服务降级
+				c.JSON(http.StatusOK, gin.H{
+					"isSuccess":        true,
+					"reply":            chatReply,
+					"isMonitorSummary": false,
+					"outOfScope":       true,
+					"language":         lang,
+					"queryTime":        time.Now(),
 				})
 				return
 			}
-			c.JSON(http.StatusOK, gin.H{
+			if err != nil && !errors.Is(err, agent.ErrAIUnavailable) {
+				respondAgentError(c, CodeAIError, "小助手回答失败："+err.Error())
+				return
+			}
+			c.JSON(http.StatusOK, withDegraded(gin.H{
 				"isSuccess":        true,
 				"reply":            chatReply,
 				"isMonitorSummary": false,
+				"language":         lang,
 				"queryTime":        time.Now(),
-			})
+			}, err))
 			return
 		}
 
 		// 无前缀且不匹配通用关键词 → 监控总结逻辑
-		intent := agent.ParseQueryIntent(req.UserQuery, h.cfg.Agent.DefaultTimeRange)
+		intent := h.retriever.ParseIntent(req.UserQuery)
 		monitorData, err := h.retriever.Retrieve(intent)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"isSuccess": false,
-				"errorMsg":  "监控数据检索失败：" + err.Error(),
-			})
+			respondAgentError(c, CodeStorageError, "监控数据检索失败："+err.Error())
 			return
 		}
 		if len(monitorData) > 0 {
-			summary, err := h.summarizer.Summarize(monitorData)
-			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{
-					"isSuccess": false,
-					"errorMsg":  "监控数据总结失败：" + err.Error(),
-				})
+			summary, structured, err := h.summarizer.SummarizeAs(monitorData, req.Format, lang)
+			if err != nil && !errors.Is(err, agent.ErrAIUnavailable) {
+				respondAgentError(c, CodeAIError, "监控数据总结失败："+err.Error())
 				return
 			}
-			c.JSON(http.StatusOK, gin.H{
+			body := gin.H{
 				"isSuccess":        true,
 				"reply":            summary,
+				"format":           req.Format,
 				"isMonitorSummary": true,
+				"language":         lang,
 				"queryTime":        time.Now(),
-			})
+			}
+			if structured != nil {
+				body["summary"] = structured
+			}
+			c.JSON(http.StatusOK, withDegraded(body, err))
 			return
 		}
 		// 无监控数据提示
+		reply := "未查询到相关监控数据，若需通用问答，请在问题前加/chat 前缀（例：/chat 什么是Goroutine？）"
+		if lang == agent.LangEnglish {
+			reply = "No matching monitoring data. For general questions, prefix the question with /chat (e.g. /chat what is a goroutine?)"
+		}
 		c.JSON(http.StatusOK, gin.H{
 			"isSuccess":        true,
-			"reply":            "未查询到相关监控数据，若需通用问答，请在问题前加/chat 前缀（例：/chat 什么是Goroutine？）",
+			"reply":            reply,
 			"isMonitorSummary": false,
+			"language":         lang,
 			"queryTime":        time.Now(),
 		})
 		return
 	}
 
 	// 未知模式提示
-	c.JSON(http.StatusBadRequest, gin.H{
+	respondAgentError(c, CodeInvalidArgument, "不支持的查询模式，仅支持 data 和 ai")
+}
+
+// englishGeneralKeywords 英文提问中表示通用问答（而非查询监控数据）的关键词
+// 不包含 "why"：「why is github down」属于监控数据查询
+var englishGeneralKeywords = []string{"what is", "what's a", "what are", "how to", "how do i", "how does", "difference between", "explain", "tutorial", "meaning of"}
+
+// respondAgentError 返回小助手查询错误，保留页面使用的 isSuccess / errorMsg 字段
+func respondAgentError(c *gin.Context, code ErrorCode, message string) {
+	c.JSON(errorStatus[code], gin.H{
 		"isSuccess": false,
-		"errorMsg":  "不支持的查询模式，仅支持 data 和 ai",
+		"errorMsg":  message,
+		"error":     newAPIError(c, code, message, nil),
 	})
 }
 
-// 保留原有GetHistoryResults方法（不变）
+// withDegraded AI 服务不可用时在小助手响应中标记降级（回复为规则统计或提示语）及原因
+func withDegraded(body gin.H, err error) gin.H {
+	if err != nil {
+		body["degraded"] = true
+		body["degradedReason"] = err.Error()
+	}
+	return body
+}
+
+// GetAgentStatus 查询 AI 服务状态：是否开启、主 / 备用模型与熔断器状态
+func (h *Handler) GetAgentStatus(c *gin.Context) {
+	respond(c, http.StatusOK, h.summarizer.Status())
+}
+
+// GetHistoryResults 查询历史监控结果
+// 参数：targetUrl 地址关键词，startTime / endTime 时间范围，fields 返回字段，status / errorType / tag 过滤条件
 func (h *Handler) GetHistoryResults(c *gin.Context) {
 	targetURL := c.Query("targetUrl")
 	startTimeStr := c.Query("startTime")
@@ -235,7 +554,7 @@ func (h *Handler) GetHistoryResults(c *gin.Context) {
 	if startTimeStr != "" {
 		startTime, err = time.Parse("2006-01-02 15:04:05", startTimeStr)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "开始时间格式错误，应为：2006-01-02 15:04:05"})
+			respondError(c, CodeInvalidArgument, "开始时间格式错误，应为：2006-01-02 15:04:05", gin.H{"field": "startTime", "layout": "2006-01-02 15:04:05"})
 			return
 		}
 	} else {
@@ -245,29 +564,277 @@ func (h *Handler) GetHistoryResults(c *gin.Context) {
 	if endTimeStr != "" {
 		endTime, err = time.Parse("2006-01-02 15:04:05", endTimeStr)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "结束时间格式错误，应为：2006-01-02 15:04:05"})
+			respondError(c, CodeInvalidArgument, "结束时间格式错误，应为：2006-01-02 15:04:05", gin.H{"field": "endTime", "layout": "2006-01-02 15:04:05"})
+			return
+		}
+	}
+
+	// fields：仅查询并返回指定字段，如 fields=status,responseTime
+	fields, err := storage.ParseResultFields(c.Query("fields"))
+	if err != nil {
+		respondError(c, CodeInvalidArgument, err.Error(), gin.H{"field": "fields", "allowed": storage.AllResultFields})
+		return
+	}
+
+	// 与查询 DSL（POST /api/v1/query）使用同一查询路径，额外支持 status / errorType / tag 过滤
+	q := &storage.ResultQuery{
+		Target: targetURL,
+		Status: c.Query("status"),
+		Since:  &startTime,
+		Until:  &endTime,
+		Fields: fields,
+	}
+	if v := c.Query("errorType"); v != "" {
+		q.ErrorTypes = strings.Split(v, ",")
+	}
+	if v := c.Query("tag"); v != "" {
+		q.Tags = []string{v}
+	}
+	// maxCertDays：只返回证书剩余天数不超过该值的结果；sort=sslDaysLeft 按剩余天数升序
+	if v := c.Query("maxCertDays"); v != "" {
+		days, err := strconv.Atoi(v)
+		if err != nil {
+			respondError(c, CodeInvalidArgument, "maxCertDays 必须为整数", gin.H{"field": "maxCertDays"})
+			return
+		}
+		q.MaxCertDays = &days
+	}
+	q.Sort = c.Query("sort")
+	if err := q.Normalize(); err != nil {
+		respondError(c, CodeInvalidArgument, err.Error(), nil)
+		return
+	}
+
+	results, err := h.storage.SearchResults(q)
+	if err != nil {
+		respondError(c, CodeStorageError, "查询历史数据失败："+err.Error(), nil)
+		return
+	}
+
+	if c.Query("fields") == "" {
+		respond(c, http.StatusOK, gin.H{
+			"total": len(results),
+			"list":  results,
+		})
+		return
+	}
+	respond(c, http.StatusOK, gin.H{
+		"total":  len(results),
+		"fields": fields,
+		"list":   storage.ProjectResults(results, fields),
+	})
+}
+
+// GetTargetStates 返回内存中各目标的最新状态，不查询数据库，供大屏高频轮询
+// source=db 时改为读取目标当前状态表（每个目标一行，含连续失败次数与状态变化时间，重启后不丢失）
+func (h *Handler) GetTargetStates(c *gin.Context) {
+	if c.Query("source") == "db" {
+		statuses, err := h.storage.CurrentStatuses()
+		if err != nil {
+			respondError(c, CodeStorageError, "查询目标当前状态失败："+err.Error(), nil)
 			return
 		}
+		failed := 0
+		for _, s := range statuses {
+			if s.Status == "failed" {
+				failed++
+			}
+		}
+		respond(c, http.StatusOK, gin.H{
+			"total":  len(statuses),
+			"failed": failed,
+			"list":   statuses,
+		})
+		return
+	}
+
+	states := h.checker.LastKnownStates()
+	failed := 0
+	for _, r := range states {
+		if r.Status == "failed" {
+			failed++
+		}
+	}
+	respond(c, http.StatusOK, gin.H{
+		"total":  len(states),
+		"failed": failed,
+		"list":   states,
+	})
+}
+
+// GetCTFindings 查询证书透明度日志监控的最近发现（最新的排在前面）
+func (h *Handler) GetCTFindings(c *gin.Context) {
+	if h.ctWatcher == nil {
+		respondError(c, CodeFeatureDisabled, "证书透明度日志监控未开启", nil)
+		return
+	}
+	lastPoll, lastErr, findings := h.ctWatcher.Status()
+	unexpected := 0
+	for _, f := range findings {
+		if !f.Expected {
+			unexpected++
+		}
+	}
+	respond(c, http.StatusOK, gin.H{
+		"lastPoll":   lastPoll,
+		"lastError":  lastErr,
+		"total":      len(findings),
+		"unexpected": unexpected,
+		"list":       findings,
+	})
+}
+
+// GetStorageStats 查询存储操作耗时统计与最近的慢查询，reset=true 时返回后清空统计
+func (h *Handler) GetStorageStats(c *gin.Context) {
+	respond(c, http.StatusOK, h.storage.QueryStats(c.Query("reset") == "true"))
+}
+
+// GetPipelineMetrics 查询各通知渠道的投递成功率与各检查协议的耗时、错误分布，reset=true 时返回后清空统计
+func (h *Handler) GetPipelineMetrics(c *gin.Context) {
+	respond(c, http.StatusOK, metrics.Snapshot(c.Query("reset") == "true"))
+}
+
+// GetIncidents 查询告警事件列表（含聚合事件）
+func (h *Handler) GetIncidents(c *gin.Context) {
+	incidents := h.alerts.Incidents()
+	respond(c, http.StatusOK, gin.H{
+		"total": len(incidents),
+		"list":  incidents,
+	})
+}
+
+// GetHosts 按主机聚合各目标的最新状态，同一台主机上的多个服务合并展示
+func (h *Handler) GetHosts(c *gin.Context) {
+	hours, err := strconv.Atoi(c.DefaultQuery("hours", "24"))
+	if err != nil || hours <= 0 {
+		respondError(c, CodeInvalidArgument, "hours参数错误，应为正整数", gin.H{"field": "hours"})
+		return
+	}
+
+	// fields：各目标结果仅返回指定字段，聚合所需的 targetUrl/status/checkedAt 始终查询
+	fields, err := storage.ParseResultFields(c.Query("fields"))
+	if err != nil {
+		respondError(c, CodeInvalidArgument, err.Error(), gin.H{"field": "fields", "allowed": storage.AllResultFields})
+		return
 	}
+	queryFields := storage.WithResultFields(fields, "targetUrl", "status", "checkedAt")
 
-	results, err := h.storage.QueryResults(targetURL, startTime, endTime, 100)
+	results, err := h.storage.LatestResultFields(time.Now().Add(-time.Duration(hours)*time.Hour), queryFields)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询历史数据失败：" + err.Error()})
+		respondError(c, CodeStorageError, "查询主机状态失败："+err.Error(), nil)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"total": len(results),
-		"list":  results,
+	hosts := core.AggregateByHost(results)
+	if host := c.Query("host"); host != "" {
+		filtered := make([]*core.HostSummary, 0, 1)
+		for _, hs := range hosts {
+			if hs.Host == strings.ToLower(host) {
+				filtered = append(filtered, hs)
+			}
+		}
+		hosts = filtered
+	}
+
+	if c.Query("fields") == "" {
+		respond(c, http.StatusOK, gin.H{
+			"total": len(hosts),
+			"list":  hosts,
+		})
+		return
+	}
+	list := make([]gin.H, 0, len(hosts))
+	for _, hs := range hosts {
+		list = append(list, gin.H{
+			"host":        hs.Host,
+			"status":      hs.Status,
+			"total":       hs.Total,
+			"failed":      hs.Failed,
+			"lastChecked": hs.LastChecked,
+			"targets":     storage.ProjectResults(hs.Targets, fields),
+		})
+	}
+	respond(c, http.StatusOK, gin.H{
+		"total":  len(hosts),
+		"fields": fields,
+		"list":   list,
 	})
 }
 
-// 保留原有RegisterRoutes方法（不变）
-func (h *Handler) RegisterRoutes(router *gin.Engine) {
-	apiGroup := router.Group("/api")
-	{
-		apiGroup.POST("/targets", h.SubmitTargets)
-		apiGroup.POST("/agent/query", h.AgentQuery)
-		apiGroup.GET("/history/results", h.GetHistoryResults)
+// RegisterRoutes 注册API路由：正式接口位于 /api/v1，旧版 /api 路径作为兼容层保留并标记弃用；管理员令牌引用无效时返回错误
+func (h *Handler) RegisterRoutes(router *gin.Engine) error {
+	admin, err := adminAuth(&h.cfg.API)
+	if err != nil {
+		return err
+	}
+	common := []gin.HandlerFunc{requestID(), versionHeader()}
+	if h.cfg.API.Gzip {
+		common = append(common, gzipCompression())
 	}
+
+	v1Group := router.Group("/api/"+APIVersion, common...)
+	h.registerAPIRoutes(v1Group, admin)
+
+	if !h.cfg.API.DisableLegacy {
+		legacyGroup := router.Group("/api", append(common, legacyDeprecation(h.cfg.API.LegacySunset))...)
+		h.registerAPIRoutes(legacyGroup, admin)
+	}
+	return nil
+}
+
+// registerAPIRoutes 在指定路由组下注册全部接口，查询类接口支持 ETag 条件请求
+// admin：管理员认证中间件，用于返回个人信息或可以修改配置的接口
+func (h *Handler) registerAPIRoutes(apiGroup *gin.RouterGroup, admin gin.HandlerFunc) {
+	apiGroup.GET("/version", h.GetAPIVersion)
+	apiGroup.GET("/capabilities", h.GetCapabilities)
+	apiGroup.POST("/targets", h.idempotent(), h.SubmitTargets)
+	apiGroup.GET("/targets/state", conditionalGet(), h.GetTargetStates)
+	apiGroup.GET("/targets/export", h.ExportTargets)
+	apiGroup.GET("/targets/:id/transitions", conditionalGet(), h.GetTargetTransitions)
+	apiGroup.POST("/agent/query", h.AgentQuery)
+	apiGroup.GET("/agent/status", h.GetAgentStatus)
+	apiGroup.GET("/history/results", conditionalGet(), h.GetHistoryResults)
+	apiGroup.POST("/query", h.QueryResults)
+	apiGroup.POST("/scheduler/run", h.idempotent(), h.RunSchedulerCycle)
+	apiGroup.GET("/scheduler/last", conditionalGet(), h.GetSchedulerLastReport)
+	apiGroup.GET("/scheduler/preview", h.GetSchedulerPreview)
+	apiGroup.GET("/changes", h.GetChanges)
+	apiGroup.PUT("/heartbeats/:token", h.ReceiveHeartbeat)
+	apiGroup.PUT("/heartbeats/:token/start", h.ReceiveHeartbeatStart)
+	apiGroup.GET("/incidents", conditionalGet(), h.GetIncidents)
+	apiGroup.GET("/alerts/rules", h.GetAlertRules)
+	apiGroup.POST("/alerts/rules/test", h.TestAlertRule)
+	apiGroup.POST("/alerts/templates/preview", h.PreviewAlertTemplate)
+	apiGroup.GET("/incidents/:id/log", h.GetIncidentLog)
+	apiGroup.POST("/incidents/:id/comments", h.idempotent(), h.AddIncidentComment)
+	apiGroup.GET("/incidents/:id/actions/:action", h.IncidentActionPage)
+	apiGroup.POST("/incidents/:id/actions/:action", h.IncidentAction)
+	apiGroup.GET("/hosts", conditionalGet(), h.GetHosts)
+	apiGroup.GET("/status", conditionalGet(), h.GetPublicStatus)
+	apiGroup.GET("/status/subscriptions", admin, h.ListSubscriptions)
+	apiGroup.POST("/status/subscriptions", h.idempotent(), h.Subscribe)
+	apiGroup.GET("/status/subscriptions/confirm", h.ConfirmSubscription)
+	apiGroup.GET("/status/subscriptions/unsubscribe", h.Unsubscribe)
+	apiGroup.GET("/stats/health", conditionalGet(), h.GetHealthStats)
+	apiGroup.GET("/stats/availability", conditionalGet(), h.GetAvailabilityStats)
+	apiGroup.GET("/stats/reliability", conditionalGet(), h.GetReliabilityStats)
+	apiGroup.GET("/certificates", conditionalGet(), h.GetCertificates)
+	apiGroup.GET("/certificates/ct", conditionalGet(), h.GetCTFindings)
+	apiGroup.GET("/failover/reports", conditionalGet(), h.GetFailoverReports)
+	apiGroup.GET("/failover/reports/:name", conditionalGet(), h.GetFailoverHistory)
+	apiGroup.POST("/failover/run", h.idempotent(), h.RunFailoverProbes)
+	apiGroup.GET("/remediation/runs", h.ListRemediationRuns)
+	apiGroup.GET("/config/snapshots", admin, h.ListConfigSnapshots)
+	apiGroup.GET("/config/snapshots/diff", admin, h.DiffConfigSnapshots)
+	apiGroup.GET("/config/snapshots/:id", admin, h.GetConfigSnapshot)
+	apiGroup.POST("/config/snapshots/:id/rollback", admin, h.idempotent(), h.RollbackConfigSnapshot)
+	apiGroup.GET("/admin/log-levels", admin, h.GetLogLevels)
+	apiGroup.GET("/admin/storage/stats", admin, h.GetStorageStats)
+	apiGroup.GET("/admin/metrics", admin, h.GetPipelineMetrics)
+	apiGroup.GET("/probes", h.GetProbeFleet)
+	apiGroup.GET("/admin/targets/duplicates", admin, h.GetDuplicateTargets)
+	apiGroup.POST("/admin/targets/merge", admin, h.idempotent(), h.MergeTargets)
+	apiGroup.PUT("/admin/log-levels/:module", admin, h.SetLogLevel)
+	apiGroup.DELETE("/admin/log-levels/:module", admin, h.ResetLogLevel)
+	h.registerChatOpsRoutes(apiGroup)
 }
@@ -0,0 +1,109 @@
+package api
+
+import (
+	"crypto/sha256"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"servicetelemetry/core"
+)
+
+// HeartbeatReceipt 心跳接收结果
+type HeartbeatReceipt struct {
+	Targets    []string  `json:"targets"`    // 记录了本次心跳的目标
+	ReceivedAt time.Time `json:"receivedAt"` // 收到心跳的时间
+}
+
+// ReceiveHeartbeat 接收心跳目标（heartbeat://）的心跳：外部任务每完成一次调用，令牌对应的目标记录本次心跳
+// 令牌即为凭据，接口不需要其他认证，便于在定时任务末尾直接 curl -X PUT
+func (h *Handler) ReceiveHeartbeat(c *gin.Context) {
+	h.receiveHeartbeat(c, "心跳", func(t *core.MonitorTarget, at time.Time) error {
+		return h.storage.RecordHeartbeat(t.URL, c.ClientIP(), at)
+	})
+}
+
+// ReceiveHeartbeatStart 接收心跳目标的运行开始信号：任务开始时调用，完成时再调用 ReceiveHeartbeat，
+// 据此记录每次运行的耗时，并发现运行过长、过短或重叠
+func (h *Handler) ReceiveHeartbeatStart(c *gin.Context) {
+	h.receiveHeartbeat(c, "运行开始信号", func(t *core.MonitorTarget, at time.Time) error {
+		return h.storage.RecordHeartbeatStart(t.URL, c.ClientIP(), at, t.HeartbeatRunLimit())
+	})
+}
+
+// heartbeatIndexRefresh 心跳令牌索引的重建间隔：声明式目标定义、消息队列注册的目标与 file: 引用的令牌轮换在此间隔内生效
+const heartbeatIndexRefresh = 30 * time.Second
+
+// heartbeatIndex 心跳令牌索引（令牌 SHA-256 摘要 -> 心跳目标），避免每次上报都查询全部目标并解析令牌
+// 过期后在下一次上报时重建，通过接口提交或合并目标后立即失效
+type heartbeatIndex struct {
+	mu      sync.Mutex
+	targets map[[32]byte][]*core.MonitorTarget
+	builtAt time.Time
+}
+
+// invalidate 使索引失效，下一次上报时重建
+func (idx *heartbeatIndex) invalidate() {
+	idx.mu.Lock()
+	idx.builtAt = time.Time{}
+	idx.mu.Unlock()
+}
+
+// lookup 返回令牌对应的心跳目标，索引过期时先从数据库重建
+func (idx *heartbeatIndex) lookup(token string, list func() ([]*core.MonitorTarget, error)) ([]*core.MonitorTarget, error) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	if time.Since(idx.builtAt) > heartbeatIndexRefresh {
+		targets, err := list()
+		if err != nil {
+			return nil, err
+		}
+		index := make(map[[32]byte][]*core.MonitorTarget)
+		for _, t := range targets {
+			expected, err := t.HeartbeatToken()
+			if err != nil {
+				log.Warnf("解析心跳目标[%s]的令牌失败：%v", t.URL, err)
+				continue
+			}
+			if expected == "" {
+				continue
+			}
+			key := sha256.Sum256([]byte(expected))
+			index[key] = append(index[key], t)
+		}
+		idx.targets, idx.builtAt = index, time.Now()
+	}
+	return idx.targets[sha256.Sum256([]byte(token))], nil
+}
+
+// receiveHeartbeat 按令牌查找心跳目标并逐个记录
+// kind：信号名称，用于日志
+// record：记录一个目标的信号
+func (h *Handler) receiveHeartbeat(c *gin.Context, kind string, record func(t *core.MonitorTarget, at time.Time) error) {
+	targets, err := h.heartbeats.lookup(c.Param("token"), func() ([]*core.MonitorTarget, error) {
+		return h.storage.ListTargets(true)
+	})
+	if err != nil {
+		respondError(c, CodeStorageError, "查询监控目标失败："+err.Error(), nil)
+		return
+	}
+
+	receipt := &HeartbeatReceipt{Targets: []string{}, ReceivedAt: time.Now()}
+	for _, t := range targets {
+		if err := record(t, receipt.ReceivedAt); err != nil {
+			respondError(c, CodeStorageError, err.Error(), nil)
+			return
+		}
+		// 清除缓存的结果，下一次检查即可反映恢复
+		h.checker.ForgetTarget(t.URL)
+		receipt.Targets = append(receipt.Targets, t.URL)
+	}
+	if len(receipt.Targets) == 0 {
+		respondError(c, CodeNotFound, "心跳令牌不存在", nil)
+		return
+	}
+	log.Debugf("收到%s：%v（来源 %s）", kind, receipt.Targets, c.ClientIP())
+	respond(c, http.StatusOK, receipt)
+}
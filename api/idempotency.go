@@ -0,0 +1,219 @@
+package api
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"servicetelemetry/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxIdempotencyKeyLen 幂等键的最大长度
+const maxIdempotencyKeyLen = 255
+
+// idempotentEntry 一个幂等键对应的首次请求及其响应
+type idempotentEntry struct {
+	scope       string    // 接口与幂等键组成的缓存键
+	fingerprint [32]byte  // 请求体摘要，同一幂等键的重试必须携带相同的请求体
+	done        bool      // 首次请求是否已完成（未完成时重试返回 409）
+	status      int       // 首次响应的状态码
+	contentType string    // 首次响应的 Content-Type
+	body        []byte    // 首次响应体
+	expiresAt   time.Time // 过期时间，过期后同一幂等键视为新请求
+}
+
+// idempotencyStore 幂等键缓存（进程内），按接口与幂等键保存首次响应
+// 条目数超过 maxEntries 时按最近最少使用淘汰已完成的条目，请求体与响应体均不超过 maxBody 字节
+type idempotencyStore struct {
+	ttl        time.Duration
+	maxEntries int
+	maxBody    int64
+	mu         sync.Mutex
+	entries    map[string]*list.Element // 缓存键 -> lru 中的元素（值为 *idempotentEntry）
+	lru        *list.List               // 按最近使用排列，队首为最近使用
+	lastSweep  time.Time
+}
+
+// newIdempotencyStore 创建幂等键缓存，ttl 不大于 0 时返回 nil（不开启）
+func newIdempotencyStore(cfg *config.APIConfig) *idempotencyStore {
+	if cfg.IdempotencyTTL <= 0 {
+		return nil
+	}
+	return &idempotencyStore{
+		ttl:        cfg.IdempotencyTTL,
+		maxEntries: cfg.IdempotencyMaxEntries,
+		maxBody:    cfg.IdempotencyMaxBody,
+		entries:    make(map[string]*list.Element),
+		lru:        list.New(),
+	}
+}
+
+// sweepLocked 清理过期的幂等键，最多每分钟执行一次
+func (s *idempotencyStore) sweepLocked(now time.Time) {
+	if now.Sub(s.lastSweep) < time.Minute {
+		return
+	}
+	s.lastSweep = now
+	for _, elem := range s.entries {
+		if entry := elem.Value.(*idempotentEntry); entry.done && now.After(entry.expiresAt) {
+			s.removeLocked(elem)
+		}
+	}
+}
+
+// evictLocked 条目数达到上限时从最久未使用的一端淘汰已完成的条目，处理中的条目保留
+func (s *idempotencyStore) evictLocked() {
+	if s.maxEntries <= 0 {
+		return
+	}
+	for elem := s.lru.Back(); elem != nil && s.lru.Len() >= s.maxEntries; {
+		prev := elem.Prev()
+		if elem.Value.(*idempotentEntry).done {
+			s.removeLocked(elem)
+		}
+		elem = prev
+	}
+}
+
+// removeLocked 移除一个条目
+func (s *idempotencyStore) removeLocked(elem *list.Element) {
+	delete(s.entries, elem.Value.(*idempotentEntry).scope)
+	s.lru.Remove(elem)
+}
+
+// recordingResponseWriter 正常写出响应的同时保留一份响应体，供重试时原样返回
+// 响应体超过 limit 字节时停止保留，标记为 overflow
+type recordingResponseWriter struct {
+	gin.ResponseWriter
+	buf      bytes.Buffer
+	limit    int64
+	overflow bool
+}
+
+// record 保留一段响应体，超过上限时丢弃已保留的内容
+func (w *recordingResponseWriter) record(data []byte) {
+	if w.overflow {
+		return
+	}
+	if w.limit > 0 && int64(w.buf.Len()+len(data)) > w.limit {
+		w.overflow = true
+		w.buf = bytes.Buffer{}
+		return
+	}
+	w.buf.Write(data)
+}
+
+func (w *recordingResponseWriter) Write(data []byte) (int, error) {
+	w.record(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *recordingResponseWriter) WriteString(s string) (int, error) {
+	w.record([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+// idempotent 写接口的幂等键支持：请求头带 Idempotency-Key 时，有效期内携带相同幂等键的重试直接返回首次响应
+// （响应头 Idempotent-Replayed: true），不再重复执行；首次请求尚未完成时重试返回 409，
+// 同一幂等键携带不同请求体时返回 400，请求体超过 api.idempotencyMaxBody 时返回 400。
+// 首次响应为 5xx 或响应体超过 api.idempotencyMaxBody 时不缓存，允许调用方重试
+// 未带请求头或未开启（api.idempotencyTTL 为 0）时不做处理
+func (h *Handler) idempotent() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		s := h.idempotency
+		key := c.GetHeader("Idempotency-Key")
+		if s == nil || key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLen {
+			respondError(c, CodeInvalidArgument, "Idempotency-Key 过长", gin.H{"maxLength": maxIdempotencyKeyLen})
+			c.Abort()
+			return
+		}
+		reader := io.Reader(c.Request.Body)
+		if s.maxBody > 0 {
+			reader = io.LimitReader(reader, s.maxBody+1)
+		}
+		body, err := io.ReadAll(reader)
+		if err != nil {
+			respondError(c, CodeInvalidArgument, "读取请求体失败："+err.Error(), nil)
+			c.Abort()
+			return
+		}
+		if s.maxBody > 0 && int64(len(body)) > s.maxBody {
+			respondError(c, CodeInvalidArgument, "携带 Idempotency-Key 的请求体过大", gin.H{"maxBytes": s.maxBody})
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		fingerprint := sha256.Sum256(body)
+
+		// 新旧版路径共用同一幂等键空间
+		route := strings.TrimPrefix(strings.TrimPrefix(c.FullPath(), "/api/"+APIVersion), "/api")
+		scope := c.Request.Method + " " + route + " " + c.Request.URL.RawQuery + " " + key
+
+		now := time.Now()
+		s.mu.Lock()
+		s.sweepLocked(now)
+		var entry *idempotentEntry
+		elem, ok := s.entries[scope]
+		if ok {
+			entry = elem.Value.(*idempotentEntry)
+			if entry.done && now.After(entry.expiresAt) {
+				s.removeLocked(elem)
+				ok = false
+			}
+		}
+		if ok {
+			s.lru.MoveToFront(elem)
+			s.mu.Unlock()
+			switch {
+			case entry.fingerprint != fingerprint:
+				respondError(c, CodeInvalidArgument, "同一 Idempotency-Key 已用于不同的请求体", gin.H{"header": "Idempotency-Key"})
+			case !entry.done:
+				respondError(c, CodeConflict, "相同 Idempotency-Key 的请求正在处理中，请稍后重试", gin.H{"header": "Idempotency-Key"})
+			default:
+				c.Header("Idempotent-Replayed", "true")
+				c.Data(entry.status, entry.contentType, entry.body)
+			}
+			c.Abort()
+			return
+		}
+		s.evictLocked()
+		entry = &idempotentEntry{scope: scope, fingerprint: fingerprint}
+		elem = s.lru.PushFront(entry)
+		s.entries[scope] = elem
+		s.mu.Unlock()
+
+		w := &recordingResponseWriter{ResponseWriter: c.Writer, limit: s.maxBody}
+		c.Writer = w
+		defer func() {
+			c.Writer = w.ResponseWriter
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			// 处理器 panic 时响应尚未写出，同样不缓存
+			if r := recover(); r != nil {
+				s.removeLocked(elem)
+				panic(r)
+			}
+			if w.Status() >= http.StatusInternalServerError || w.overflow {
+				s.removeLocked(elem)
+				return
+			}
+			entry.done = true
+			entry.status = w.Status()
+			entry.contentType = w.Header().Get("Content-Type")
+			entry.body = w.buf.Bytes()
+			entry.expiresAt = time.Now().Add(s.ttl)
+		}()
+		c.Next()
+	}
+}
@@ -0,0 +1,223 @@
+package api
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strconv"
+	"time"
+
+	"servicetelemetry/alert"
+
+	"github.com/gin-gonic/gin"
+)
+
+// incidentActionNames 操作名称，用于响应消息
+var incidentActionNames = map[string]string{
+	alert.ActionAcknowledge: "确认",
+	alert.ActionResolve:     "解决",
+}
+
+// parseIncidentID 解析路径中的事件ID，无效时写入错误响应并返回 false
+func parseIncidentID(c *gin.Context) (uint64, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		respondError(c, CodeInvalidArgument, "事件ID应为正整数", gin.H{"field": "id"})
+		return 0, false
+	}
+	return id, true
+}
+
+// incidentActionPage 一键操作的确认页与结果页：聊天工具、邮件客户端与安全网关会预取链接，GET 只展示确认页，提交表单（POST）才执行操作
+var incidentActionPage = template.Must(template.New("incidentAction").Parse(`<!DOCTYPE html>
+<html lang="zh-CN">
+<head><meta charset="utf-8"><meta name="viewport" content="width=device-width, initial-scale=1"><title>{{.Title}}</title></head>
+<body style="font-family: sans-serif; max-width: 480px; margin: 40px auto; padding: 0 16px">
+<h2>{{.Title}}</h2>
+<p>{{.Message}}</p>
+{{if .Button}}<form method="post"><input type="hidden" name="confirm" value="1"><button type="submit" style="font-size: 18px; padding: 8px 24px">{{.Button}}</button></form>{{end}}
+</body>
+</html>
+`))
+
+// incidentActionView 确认页 / 结果页的内容
+type incidentActionView struct {
+	Title   string
+	Message string
+	Button  string // 确认按钮文字，为空时不显示表单（结果页）
+}
+
+// renderIncidentAction 输出确认页 / 结果页
+func renderIncidentAction(c *gin.Context, status int, view incidentActionView) {
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.Header("Cache-Control", "no-store")
+	c.Status(status)
+	incidentActionPage.Execute(c.Writer, view)
+}
+
+// verifyIncidentAction 解析并校验一键操作链接，返回事件ID、操作与链接中签名的操作人；无效时写入错误响应并返回 false
+func (h *Handler) verifyIncidentAction(c *gin.Context) (uint64, string, string, bool) {
+	id, ok := parseIncidentID(c)
+	if !ok {
+		return 0, "", "", false
+	}
+	action := c.Param("action")
+	if _, ok := incidentActionNames[action]; !ok {
+		respondError(c, CodeInvalidArgument, "不支持的操作："+action, gin.H{"field": "action", "allowed": []string{alert.ActionAcknowledge, alert.ActionResolve}})
+		return 0, "", "", false
+	}
+	expires, err := strconv.ParseInt(c.Query("expires"), 10, 64)
+	if err != nil {
+		respondError(c, CodeUnauthenticated, alert.ErrInvalidActionLink.Error(), nil)
+		return 0, "", "", false
+	}
+	by := c.Query("by")
+	if err := h.alerts.VerifyAction(id, action, expires, by, c.Query("sig")); err != nil {
+		if errors.Is(err, alert.ErrIncidentNotFound) {
+			respondError(c, CodeNotFound, "事件不存在或服务已重启", gin.H{"id": id})
+			return 0, "", "", false
+		}
+		respondError(c, CodeUnauthenticated, err.Error(), nil)
+		return 0, "", "", false
+	}
+	if by == "" {
+		by = "通知链接"
+	}
+	return id, action, by, true
+}
+
+// IncidentActionPage 打开告警通知中的一键操作链接：校验签名后展示确认页，不改变事件状态
+func (h *Handler) IncidentActionPage(c *gin.Context) {
+	id, action, _, ok := h.verifyIncidentAction(c)
+	if !ok {
+		return
+	}
+	name := incidentActionNames[action]
+	renderIncidentAction(c, http.StatusOK, incidentActionView{
+		Title:   fmt.Sprintf("%s事件 #%d", name, id),
+		Message: fmt.Sprintf("点击下方按钮%s事件 #%d。", name, id),
+		Button:  name + "事件",
+	})
+}
+
+// IncidentAction 执行告警通知中的一键操作链接（确认 / 解决事件），链接带签名与有效期，无需登录
+// 操作人取自链接中随签名的 by 参数；从确认页提交时返回结果页，否则返回 JSON
+func (h *Handler) IncidentAction(c *gin.Context) {
+	id, action, by, ok := h.verifyIncidentAction(c)
+	if !ok {
+		return
+	}
+	name := incidentActionNames[action]
+	fromPage := c.PostForm("confirm") == "1"
+
+	var incident *alert.Incident
+	var err error
+	if action == alert.ActionAcknowledge {
+		incident, err = h.alerts.Acknowledge(id, by)
+	} else {
+		incident, err = h.alerts.ResolveIncident(id, by)
+	}
+	switch {
+	case errors.Is(err, alert.ErrIncidentNotFound):
+		// 事件只保存在内存中，服务重启后旧链接对应的事件不再存在
+		if fromPage {
+			renderIncidentAction(c, http.StatusNotFound, incidentActionView{Title: "操作失败", Message: "事件不存在或服务已重启"})
+			return
+		}
+		respondError(c, CodeNotFound, "事件不存在或服务已重启", gin.H{"id": id})
+		return
+	case errors.Is(err, alert.ErrIncidentResolved):
+		if fromPage {
+			renderIncidentAction(c, http.StatusOK, incidentActionView{Title: name + "事件", Message: "事件已恢复，无需" + name})
+			return
+		}
+		respond(c, http.StatusOK, gin.H{"message": "事件已恢复，无需" + name})
+		return
+	case err != nil:
+		if fromPage {
+			renderIncidentAction(c, http.StatusInternalServerError, incidentActionView{Title: "操作失败", Message: err.Error()})
+			return
+		}
+		respondError(c, CodeInternal, err.Error(), nil)
+		return
+	}
+	if fromPage {
+		renderIncidentAction(c, http.StatusOK, incidentActionView{Title: name + "事件", Message: fmt.Sprintf("事件 #%d 已%s。", id, name)})
+		return
+	}
+	respond(c, http.StatusOK, gin.H{
+		"message":  "事件已" + name,
+		"incident": incident,
+	})
+}
+
+// GetIncidentLog 查询事件的沟通记录：发出的通知、升级通知、确认、评论与状态页更新，按时间升序
+// 参数：format 导出格式 json（默认）/ markdown / csv，markdown 与 csv 以附件形式下载，便于整理复盘文档
+func (h *Handler) GetIncidentLog(c *gin.Context) {
+	id, ok := parseIncidentID(c)
+	if !ok {
+		return
+	}
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "markdown" && format != "csv" {
+		respondError(c, CodeInvalidArgument, "不支持的导出格式："+format, gin.H{"field": "format", "allowed": []string{"json", "markdown", "csv"}})
+		return
+	}
+	incidentLog, err := h.alerts.CommunicationLog(id)
+	if errors.Is(err, alert.ErrIncidentNotFound) {
+		respondError(c, CodeNotFound, "事件不存在或服务已重启", gin.H{"id": id})
+		return
+	}
+
+	switch format {
+	case "markdown":
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="incident-%d.md"`, id))
+		c.Data(http.StatusOK, "text/markdown; charset=utf-8", []byte(incidentLog.Markdown()))
+	case "csv":
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="incident-%d.csv"`, id))
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		c.Status(http.StatusOK)
+		w := csv.NewWriter(c.Writer)
+		w.Write([]string{"time", "kind", "actor", "channel", "recipient", "message", "error"})
+		for _, e := range incidentLog.Entries {
+			w.Write([]string{e.Time.Format(time.RFC3339), e.Kind, e.Actor, e.Channel, e.Recipient, e.Message, e.Error})
+		}
+		w.Flush()
+	default:
+		respond(c, http.StatusOK, incidentLog)
+	}
+}
+
+// AddIncidentComment 为事件添加评论，记录在事件沟通记录中
+// 请求体：{"author": "alice", "text": "已联系 CDN 服务商，预计 30 分钟内恢复"}
+func (h *Handler) AddIncidentComment(c *gin.Context) {
+	id, ok := parseIncidentID(c)
+	if !ok {
+		return
+	}
+	var req struct {
+		Author string `json:"author"` // 评论人
+		Text   string `json:"text"`   // 评论内容
+	}
+	if err := bindBody(c, &req); err != nil {
+		respondError(c, CodeInvalidArgument, "请求参数错误："+err.Error(), nil)
+		return
+	}
+	if req.Author == "" {
+		respondError(c, CodeInvalidArgument, "评论人不能为空", gin.H{"field": "author"})
+		return
+	}
+
+	entry, err := h.alerts.AddComment(id, req.Author, req.Text)
+	switch {
+	case errors.Is(err, alert.ErrIncidentNotFound):
+		respondError(c, CodeNotFound, "事件不存在或服务已重启", gin.H{"id": id})
+		return
+	case err != nil:
+		respondError(c, CodeInvalidArgument, err.Error(), gin.H{"field": "text"})
+		return
+	}
+	respond(c, http.StatusCreated, entry)
+}
@@ -0,0 +1,61 @@
+package api
+
+import (
+	"net/http"
+
+	"servicetelemetry/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// globalLogModule 路径中表示全局日志级别的模块名
+const globalLogModule = "global"
+
+// logLevelsResponse 返回当前的全局级别与各模块级别
+func logLevelsResponse() gin.H {
+	global, modules := logger.Levels()
+	return gin.H{
+		"global":  global,
+		"modules": modules,
+	}
+}
+
+// GetLogLevels 查询全局日志级别与各模块当前生效的级别
+func (h *Handler) GetLogLevels(c *gin.Context) {
+	respond(c, http.StatusOK, logLevelsResponse())
+}
+
+// SetLogLevel 运行时调整日志级别，模块为 global 时调整全局级别，否则单独调整该模块
+// 请求体：{"level": "debug"}
+func (h *Handler) SetLogLevel(c *gin.Context) {
+	var req struct {
+		Level string `json:"level" binding:"required"`
+	}
+	if err := bindBody(c, &req); err != nil {
+		respondError(c, CodeInvalidArgument, "请求参数错误："+err.Error(), gin.H{"field": "level"})
+		return
+	}
+
+	module := c.Param("module")
+	var err error
+	if module == globalLogModule {
+		err = logger.SetGlobalLevel(req.Level)
+	} else {
+		err = logger.SetModuleLevel(module, req.Level)
+	}
+	if err != nil {
+		respondError(c, CodeInvalidArgument, err.Error(), gin.H{"module": module, "modules": logger.Modules})
+		return
+	}
+	respond(c, http.StatusOK, logLevelsResponse())
+}
+
+// ResetLogLevel 取消模块的单独日志级别，恢复使用全局级别
+func (h *Handler) ResetLogLevel(c *gin.Context) {
+	module := c.Param("module")
+	if err := logger.ResetModuleLevel(module); err != nil {
+		respondError(c, CodeInvalidArgument, err.Error(), gin.H{"module": module, "modules": logger.Modules})
+		return
+	}
+	respond(c, http.StatusOK, logLevelsResponse())
+}
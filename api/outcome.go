@@ -0,0 +1,73 @@
+package api
+
+import "net/http"
+
+// 批量提交中单个目标的处理结果
+const (
+	OutcomeChecked    = "checked"     // 已执行检查，结果已入库（演练模式下不入库）
+	OutcomeCached     = "cached"      // 缓存期内已检查过，返回缓存的结果，未重复检查与入库
+	OutcomeDeferred   = "deferred"    // 目标限定在其他区域检查，本实例只保存目标
+	OutcomeRejected   = "rejected"    // 目标地址未通过安全校验，未检查也未保存
+	OutcomeSaveFailed = "save_failed" // 已执行检查，但目标或结果保存失败（结果仍在响应中返回）
+)
+
+// 处理失败的原因，供调用方区分是否值得重试
+const (
+	ReasonURLRejected      = "URL_REJECTED"       // 地址未通过安全校验，原样重试仍会失败
+	ReasonTargetSaveFailed = "TARGET_SAVE_FAILED" // 保存目标失败，可重试
+	ReasonResultSaveFailed = "RESULT_SAVE_FAILED" // 保存检查结果失败，可重试
+)
+
+// TargetOutcome 批量提交中单个目标的处理结果，与请求中的目标一一对应、顺序一致
+type TargetOutcome struct {
+	URL     string `json:"url"`              // 目标地址（通过校验的为规范形式，被拒绝的为提交的原值）
+	Outcome string `json:"outcome"`          // 处理结果：checked / cached / deferred / rejected / save_failed
+	Status  string `json:"status,omitempty"` // 检查状态（success / failed / degraded），未执行检查时为空
+	Reason  string `json:"reason,omitempty"` // 处理失败的原因码
+	Error   string `json:"error,omitempty"`  // 处理失败的说明
+}
+
+// failed 判断目标是否处理失败（调用方需要重试或修正）
+func (o TargetOutcome) failed() bool {
+	return o.Outcome == OutcomeRejected || o.Outcome == OutcomeSaveFailed
+}
+
+// OutcomeSummary 批量提交的处理结果统计
+type OutcomeSummary struct {
+	Total      int `json:"total"`      // 提交的目标数
+	Checked    int `json:"checked"`    // 已检查并入库的目标数
+	Cached     int `json:"cached"`     // 返回缓存结果的目标数
+	Deferred   int `json:"deferred"`   // 交由其他区域检查的目标数
+	Rejected   int `json:"rejected"`   // 未通过安全校验的目标数
+	SaveFailed int `json:"saveFailed"` // 保存失败的目标数
+}
+
+// summarizeOutcomes 统计各类处理结果的数量
+func summarizeOutcomes(outcomes []TargetOutcome) OutcomeSummary {
+	summary := OutcomeSummary{Total: len(outcomes)}
+	for _, o := range outcomes {
+		switch o.Outcome {
+		case OutcomeChecked:
+			summary.Checked++
+		case OutcomeCached:
+			summary.Cached++
+		case OutcomeDeferred:
+			summary.Deferred++
+		case OutcomeRejected:
+			summary.Rejected++
+		case OutcomeSaveFailed:
+			summary.SaveFailed++
+		}
+	}
+	return summary
+}
+
+// multiStatus 批量请求的整体状态码：全部成功时为 200，部分目标处理失败时为 207（Multi-Status）
+func multiStatus(outcomes []TargetOutcome) int {
+	for _, o := range outcomes {
+		if o.failed() {
+			return http.StatusMultiStatus
+		}
+	}
+	return http.StatusOK
+}
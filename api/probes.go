@@ -0,0 +1,105 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"servicetelemetry/probe"
+
+	"github.com/gin-gonic/gin"
+)
+
+// probeIdentityKey 上下文中已认证的探测节点身份
+const probeIdentityKey = "probeIdentity"
+
+// RegisterProbeRoutes 在探测节点专用的 mTLS 端口上注册探测节点接口，全部接口要求有效的客户端证书
+func (h *Handler) RegisterProbeRoutes(router *gin.Engine) {
+	group := router.Group("/api/"+APIVersion, requestID(), versionHeader(), h.probeAuth())
+	group.GET("/probes/whoami", h.ProbeWhoAmI)
+	group.POST("/probes/heartbeat", h.ProbeHeartbeat)
+	group.GET("/probes/manifest", h.GetProbeManifest)
+}
+
+// probeAuth 探测节点认证：要求握手时提供的客户端证书，并在每个请求上重新校验吊销状态与允许列表，
+// 长连接建立后才吊销的证书同样立即失效
+func (h *Handler) probeAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if h.probes == nil {
+			respondError(c, CodeFeatureDisabled, "未开启探测节点认证", nil)
+			c.Abort()
+			return
+		}
+		if c.Request.TLS == nil {
+			respondError(c, CodeUnauthenticated, "探测节点接口只接受双向 TLS 连接", nil)
+			c.Abort()
+			return
+		}
+		id, err := h.probes.Verify(c.Request.TLS.PeerCertificates)
+		if err != nil {
+			respondError(c, CodeUnauthenticated, "探测节点认证失败："+err.Error(), nil)
+			c.Abort()
+			return
+		}
+		c.Set(probeIdentityKey, id)
+		c.Next()
+	}
+}
+
+// currentProbe 返回当前请求已认证的探测节点身份
+func currentProbe(c *gin.Context) *probe.Identity {
+	id, _ := c.Get(probeIdentityKey)
+	return id.(*probe.Identity)
+}
+
+// ProbeWhoAmI 返回中心服务识别到的探测节点身份（证书 CN、序列号、签发者与到期时间），供部署时确认证书配置
+func (h *Handler) ProbeWhoAmI(c *gin.Context) {
+	respond(c, http.StatusOK, currentProbe(c))
+}
+
+// fleetEnabled 判断探测节点集合是否开启，未开启时返回错误响应
+func (h *Handler) fleetEnabled(c *gin.Context) bool {
+	if h.fleet == nil {
+		respondError(c, CodeFeatureDisabled, "未开启探测节点接口（probes.listen）", nil)
+		return false
+	}
+	return true
+}
+
+// ProbeHeartbeat 接收探测节点心跳（版本、更新渠道、支持的检查协议），返回所在渠道的期望版本
+func (h *Handler) ProbeHeartbeat(c *gin.Context) {
+	if !h.fleetEnabled(c) {
+		return
+	}
+	var hb probe.Heartbeat
+	if err := c.ShouldBindJSON(&hb); err != nil {
+		respondError(c, CodeInvalidArgument, "心跳格式错误："+err.Error(), nil)
+		return
+	}
+	if strings.TrimSpace(hb.Version) == "" {
+		respondError(c, CodeInvalidArgument, "心跳缺少版本号", nil)
+		return
+	}
+	respond(c, http.StatusOK, h.fleet.Record(currentProbe(c), &hb, c.ClientIP()))
+}
+
+// GetProbeManifest 查询更新渠道的期望版本清单，?channel= 为空时使用 stable
+func (h *Handler) GetProbeManifest(c *gin.Context) {
+	if !h.fleetEnabled(c) {
+		return
+	}
+	channel := c.DefaultQuery("channel", probe.DefaultChannel)
+	m := h.fleet.Manifest(channel)
+	if m == nil {
+		respondError(c, CodeNotFound, "更新渠道未配置："+channel, nil)
+		return
+	}
+	respond(c, http.StatusOK, m)
+}
+
+// GetProbeFleet 探测节点总览：各节点的版本、渠道、支持的检查协议、最近心跳与是否为期望版本
+func (h *Handler) GetProbeFleet(c *gin.Context) {
+	if !h.fleetEnabled(c) {
+		return
+	}
+	respond(c, http.StatusOK, h.fleet.View())
+}
@@ -0,0 +1,54 @@
+package api
+
+import (
+	"net/http"
+
+	"servicetelemetry/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// QueryResults 按查询 DSL 检索监控结果，与历史数据查询、小助手检索共用同一查询路径
+// 请求体为 storage.ResultQuery；指定 aggregate 时返回分组统计，否则返回结果明细（fields 可裁剪返回字段）
+func (h *Handler) QueryResults(c *gin.Context) {
+	var q storage.ResultQuery
+	if err := bindBody(c, &q); err != nil {
+		respondError(c, CodeInvalidArgument, "参数错误："+err.Error(), nil)
+		return
+	}
+	projected := len(q.Fields) > 0
+	if err := q.Normalize(); err != nil {
+		respondError(c, CodeInvalidArgument, err.Error(), gin.H{"allowedFields": storage.AllResultFields})
+		return
+	}
+
+	if q.Aggregate != "" {
+		buckets, err := h.storage.AggregateResults(&q)
+		if err != nil {
+			respondError(c, CodeStorageError, "聚合查询失败："+err.Error(), nil)
+			return
+		}
+		respond(c, http.StatusOK, gin.H{
+			"query":     q,
+			"aggregate": q.Aggregate,
+			"total":     len(buckets),
+			"buckets":   buckets,
+		})
+		return
+	}
+
+	results, err := h.storage.SearchResults(&q)
+	if err != nil {
+		respondError(c, CodeStorageError, "查询失败："+err.Error(), nil)
+		return
+	}
+	var list interface{} = results
+	if projected {
+		list = storage.ProjectResults(results, q.Fields)
+	}
+	respond(c, http.StatusOK, gin.H{
+		"query": q,
+		"total": len(results),
+		"list":  list,
+	})
+}
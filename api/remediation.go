@@ -0,0 +1,35 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListRemediationRuns 查询自动处置的执行记录（审计日志），按时间倒序
+// 查询参数：target 目标地址（可选），limit 最多返回条数（默认 50，最大 500）
+func (h *Handler) ListRemediationRuns(c *gin.Context) {
+	if h.remediation == nil {
+		respondError(c, CodeFeatureDisabled, "自动处置未开启", nil)
+		return
+	}
+	limit := 50
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > 500 {
+			respondError(c, CodeInvalidArgument, "limit 应为 1~500 的整数", gin.H{"field": "limit"})
+			return
+		}
+		limit = n
+	}
+	runs, err := h.storage.ListRemediationRuns(c.Query("target"), limit)
+	if err != nil {
+		respondError(c, CodeStorageError, "查询处置执行记录失败："+err.Error(), nil)
+		return
+	}
+	respond(c, http.StatusOK, gin.H{
+		"total": len(runs),
+		"list":  runs,
+	})
+}
@@ -0,0 +1,144 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"servicetelemetry/snapshot"
+
+	"github.com/gin-gonic/gin"
+)
+
+// snapshotsEnabled 判断配置快照是否开启，未开启时返回错误响应
+func (h *Handler) snapshotsEnabled(c *gin.Context) bool {
+	if h.snapshots == nil {
+		respondError(c, CodeFeatureDisabled, "配置快照未开启", nil)
+		return false
+	}
+	return true
+}
+
+// snapshotID 解析路径或查询参数中的快照版本号，解析失败时返回错误响应
+func snapshotID(c *gin.Context, field, value string) (int64, bool) {
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || id <= 0 {
+		respondError(c, CodeInvalidArgument, "无效的快照版本号："+value, gin.H{"field": field})
+		return 0, false
+	}
+	return id, true
+}
+
+// respondSnapshotError 快照不存在返回 NOT_FOUND，其余返回存储错误
+func respondSnapshotError(c *gin.Context, err error) {
+	var notFound *snapshot.NotFoundError
+	if errors.As(err, &notFound) {
+		respondError(c, CodeNotFound, err.Error(), gin.H{"id": notFound.ID})
+		return
+	}
+	respondError(c, CodeStorageError, err.Error(), nil)
+}
+
+// ListConfigSnapshots 查询配置快照版本列表，kind 可选 config / targets
+func (h *Handler) ListConfigSnapshots(c *gin.Context) {
+	if !h.snapshotsEnabled(c) {
+		return
+	}
+	kind := c.Query("kind")
+	if kind != "" && kind != snapshot.KindConfig && kind != snapshot.KindTargets {
+		respondError(c, CodeInvalidArgument, "无效的快照类型："+kind, gin.H{"field": "kind", "allowed": []string{snapshot.KindConfig, snapshot.KindTargets}})
+		return
+	}
+	list, err := h.snapshots.List(kind)
+	if err != nil {
+		respondSnapshotError(c, err)
+		return
+	}
+	respond(c, http.StatusOK, gin.H{
+		"total": len(list),
+		"list":  list,
+	})
+}
+
+// GetConfigSnapshot 查询指定版本的快照内容（敏感字段已脱敏）
+func (h *Handler) GetConfigSnapshot(c *gin.Context) {
+	if !h.snapshotsEnabled(c) {
+		return
+	}
+	id, ok := snapshotID(c, "id", c.Param("id"))
+	if !ok {
+		return
+	}
+	s, err := h.snapshots.Get(id)
+	if err == nil && s == nil {
+		err = &snapshot.NotFoundError{ID: id}
+	}
+	if err != nil {
+		respondSnapshotError(c, err)
+		return
+	}
+	s.Content = snapshot.Redact(s.Content)
+	respond(c, http.StatusOK, s)
+}
+
+// DiffConfigSnapshots 对比两个快照版本，返回逐字段差异
+func (h *Handler) DiffConfigSnapshots(c *gin.Context) {
+	if !h.snapshotsEnabled(c) {
+		return
+	}
+	from, ok := snapshotID(c, "from", c.Query("from"))
+	if !ok {
+		return
+	}
+	to, ok := snapshotID(c, "to", c.Query("to"))
+	if !ok {
+		return
+	}
+	changes, err := h.snapshots.Diff(from, to)
+	if err != nil {
+		var notFound *snapshot.NotFoundError
+		if errors.As(err, &notFound) {
+			respondSnapshotError(c, err)
+			return
+		}
+		respondError(c, CodeInvalidArgument, err.Error(), nil)
+		return
+	}
+	respond(c, http.StatusOK, gin.H{
+		"from":    from,
+		"to":      to,
+		"total":   len(changes),
+		"changes": changes,
+	})
+}
+
+// RollbackConfigSnapshot 回滚到指定快照版本：写回文件并重新加载，声明式目标定义同时重新同步到数据库
+func (h *Handler) RollbackConfigSnapshot(c *gin.Context) {
+	if !h.snapshotsEnabled(c) {
+		return
+	}
+	id, ok := snapshotID(c, "id", c.Param("id"))
+	if !ok {
+		return
+	}
+	s, err := h.snapshots.Rollback(id)
+	if err != nil {
+		respondSnapshotError(c, err)
+		return
+	}
+
+	resp := gin.H{
+		"rolledBackTo": id,
+		"snapshot":     s,
+	}
+	if s.Kind == snapshot.KindTargets {
+		n, err := h.scheduler.SyncTargetSpec(h.cfg.Monitor.TargetsFile)
+		if err != nil {
+			respondError(c, CodeInternal, "目标定义已回滚，但同步到数据库失败："+err.Error(), gin.H{"synced": n})
+			return
+		}
+		resp["synced"] = n
+	}
+	s.Content = ""
+	respond(c, http.StatusOK, resp)
+}
@@ -0,0 +1,341 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"servicetelemetry/core"
+
+	"github.com/gin-gonic/gin"
+)
+
+// statsWindow 解析统计窗口参数 hours，未传时使用默认窗口，解析失败时返回错误响应
+func statsWindow(c *gin.Context, def time.Duration) (time.Duration, bool) {
+	v := c.Query("hours")
+	if v == "" {
+		return def, true
+	}
+	hours, err := strconv.Atoi(v)
+	if err != nil || hours <= 0 {
+		respondError(c, CodeInvalidArgument, "hours参数错误，应为正整数", gin.H{"field": "hours"})
+		return 0, false
+	}
+	return time.Duration(hours) * time.Hour, true
+}
+
+// targetTags 返回当前监控目标的地址 -> 标签
+func (h *Handler) targetTags() (map[string][]string, error) {
+	targets, err := h.storage.ListTargets(true)
+	if err != nil {
+		return nil, err
+	}
+	tags := make(map[string][]string, len(targets))
+	for _, t := range targets {
+		tags[t.URL] = t.Tags
+	}
+	return tags, nil
+}
+
+// GetHealthStats 计算各目标的综合健康分（可用率、延迟相对基线、近期故障、证书状态）并按标签汇总，最差的排在前面
+// 同时给出可用率与 P95 耗时相对对比周期的变化（如近24小时对比之前24小时，compareTo=week 为周同比）
+// 参数：hours 统计窗口（默认 stats.healthWindow），tag 仅返回指定标签下的目标
+func (h *Handler) GetHealthStats(c *gin.Context) {
+	window, ok := statsWindow(c, h.cfg.Stats.HealthWindow)
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	since := now.Add(-window)
+	current, err := h.storage.TargetStats(since, now)
+	if err != nil {
+		respondError(c, CodeStorageError, "查询统计数据失败："+err.Error(), nil)
+		return
+	}
+	var baseline map[string]*core.TargetStats
+	if h.cfg.Stats.BaselineWindow > 0 {
+		baseline, err = h.storage.TargetStats(since.Add(-h.cfg.Stats.BaselineWindow), since)
+		if err != nil {
+			respondError(c, CodeStorageError, "查询基线数据失败："+err.Error(), nil)
+			return
+		}
+	}
+	tags, err := h.targetTags()
+	if err != nil {
+		respondError(c, CodeStorageError, "查询监控目标失败："+err.Error(), nil)
+		return
+	}
+
+	// 对比周期：默认为紧邻的上一个等长周期，compareTo=week 时为一周前的同一时段（周同比）
+	offset := window
+	switch c.DefaultQuery("compareTo", "previous") {
+	case "previous":
+	case "week":
+		offset = 7 * 24 * time.Hour
+	default:
+		respondError(c, CodeInvalidArgument, "compareTo参数错误："+c.Query("compareTo"), gin.H{"field": "compareTo", "allowed": []string{"previous", "week"}})
+		return
+	}
+	previous, err := h.storage.TargetStats(since.Add(-offset), now.Add(-offset))
+	if err != nil {
+		respondError(c, CodeStorageError, "查询上一周期数据失败："+err.Error(), nil)
+		return
+	}
+
+	scores, tagScores := core.ComputeHealth(current, baseline, tags, h.cfg.Stats.HealthWeights)
+	core.ApplyTrends(scores, tagScores, previous)
+	if tag := c.Query("tag"); tag != "" {
+		filtered := make([]*core.HealthScore, 0, len(scores))
+		for _, s := range scores {
+			for _, t := range s.Tags {
+				if t == tag {
+					filtered = append(filtered, s)
+					break
+				}
+			}
+		}
+		scores = filtered
+		filteredTags := make([]*core.TagHealth, 0, 1)
+		for _, t := range tagScores {
+			if t.Tag == tag {
+				filteredTags = append(filteredTags, t)
+			}
+		}
+		tagScores = filteredTags
+	}
+
+	respond(c, http.StatusOK, gin.H{
+		"windowHours": window.Hours(),
+		"generatedAt": now,
+		"comparedTo": gin.H{
+			"since": since.Add(-offset),
+			"until": now.Add(-offset),
+		},
+		"total":   len(scores),
+		"targets": scores,
+		"tags":    tagScores,
+	})
+}
+
+// availabilityWindow 可用率统计的默认窗口
+const availabilityWindow = 7 * 24 * time.Hour
+
+// TargetAvailability 单个目标的可用率：全天与仅工作时间
+type TargetAvailability struct {
+	TargetURL      string   `json:"targetUrl"`      // 目标地址
+	Tags           []string `json:"tags"`           // 目标标签
+	Calendar       string   `json:"calendar"`       // 关联的工作时间日历，未关联时为空
+	Checks         int      `json:"checks"`         // 检查次数
+	Uptime         float64  `json:"uptime"`         // 全天可用率（百分比）
+	BusinessChecks int      `json:"businessChecks"` // 工作时间内的检查次数
+	BusinessUptime *float64 `json:"businessUptime"` // 工作时间内的可用率（百分比），未关联日历或无数据时为 null
+}
+
+// TagAvailability 按标签汇总的可用率（按检查次数加权）
+type TagAvailability struct {
+	Tag            string   `json:"tag"`
+	Targets        int      `json:"targets"`
+	Checks         int      `json:"checks"`
+	Uptime         float64  `json:"uptime"`
+	BusinessChecks int      `json:"businessChecks"`
+	BusinessUptime *float64 `json:"businessUptime"`
+}
+
+// GetAvailabilityStats 统计各目标全天与工作时间内的可用率，并按标签汇总
+// 目标按标签匹配 stats.calendars 中的第一个日历；calendar 参数可指定统一使用的日历
+// 参数：hours 统计窗口（默认7天），tag 仅返回指定标签下的目标，calendar 日历名称
+func (h *Handler) GetAvailabilityStats(c *gin.Context) {
+	window, ok := statsWindow(c, availabilityWindow)
+	if !ok {
+		return
+	}
+	calendars, err := core.NewCalendars(h.cfg.Stats.Calendars)
+	if err != nil {
+		respondError(c, CodeInternal, "工作时间日历配置无效："+err.Error(), nil)
+		return
+	}
+	var forced *core.Calendar
+	if name := c.Query("calendar"); name != "" {
+		for _, cal := range calendars {
+			if cal.Name == name {
+				forced = cal
+			}
+		}
+		if forced == nil {
+			respondError(c, CodeNotFound, "工作时间日历不存在："+name, gin.H{"field": "calendar"})
+			return
+		}
+	}
+
+	tags, err := h.targetTags()
+	if err != nil {
+		respondError(c, CodeStorageError, "查询监控目标失败："+err.Error(), nil)
+		return
+	}
+	calendarOf := make(map[string]*core.Calendar, len(tags))
+	for url, t := range tags {
+		if forced != nil {
+			calendarOf[url] = forced
+		} else if cal := core.CalendarFor(calendars, t); cal != nil {
+			calendarOf[url] = cal
+		}
+	}
+
+	now := time.Now()
+	stats, err := h.storage.TargetStatsWith(now.Add(-window), now, func(url string, at time.Time) bool {
+		cal := calendarOf[url]
+		return cal != nil && cal.InBusinessHours(at)
+	})
+	if err != nil {
+		respondError(c, CodeStorageError, "查询统计数据失败："+err.Error(), nil)
+		return
+	}
+
+	tagFilter := c.Query("tag")
+	targets := make([]*TargetAvailability, 0, len(stats))
+	byTag := make(map[string]*TagAvailability)
+	tagFailed, tagBusinessFailed := make(map[string]int), make(map[string]int)
+	for url, s := range stats {
+		t, ok := tags[url]
+		if !ok {
+			continue // 已删除或未生效的目标
+		}
+		if tagFilter != "" && !containsTag(t, tagFilter) {
+			continue
+		}
+		item := &TargetAvailability{TargetURL: url, Tags: t, Checks: s.Total, Uptime: s.Uptime, BusinessChecks: s.BusinessTotal}
+		if cal := calendarOf[url]; cal != nil {
+			item.Calendar = cal.Name
+			if s.BusinessTotal > 0 {
+				uptime := s.BusinessUptime
+				item.BusinessUptime = &uptime
+			}
+		}
+		targets = append(targets, item)
+
+		for _, tag := range t {
+			if tagFilter != "" && tag != tagFilter {
+				continue
+			}
+			agg, ok := byTag[tag]
+			if !ok {
+				agg = &TagAvailability{Tag: tag}
+				byTag[tag] = agg
+			}
+			agg.Targets++
+			agg.Checks += s.Total
+			agg.BusinessChecks += s.BusinessTotal
+			tagFailed[tag] += s.Failed
+			tagBusinessFailed[tag] += s.BusinessFailed
+		}
+	}
+
+	// 可用率最低的排在前面
+	sort.Slice(targets, func(i, j int) bool {
+		if targets[i].Uptime != targets[j].Uptime {
+			return targets[i].Uptime < targets[j].Uptime
+		}
+		return targets[i].TargetURL < targets[j].TargetURL
+	})
+	tagList := make([]*TagAvailability, 0, len(byTag))
+	for tag, agg := range byTag {
+		if agg.Checks > 0 {
+			agg.Uptime = float64(agg.Checks-tagFailed[tag]) / float64(agg.Checks) * 100
+		}
+		if agg.BusinessChecks > 0 {
+			uptime := float64(agg.BusinessChecks-tagBusinessFailed[tag]) / float64(agg.BusinessChecks) * 100
+			agg.BusinessUptime = &uptime
+		}
+		tagList = append(tagList, agg)
+	}
+	sort.Slice(tagList, func(i, j int) bool { return tagList[i].Tag < tagList[j].Tag })
+
+	names := make([]string, 0, len(calendars))
+	for _, cal := range calendars {
+		names = append(names, cal.Name)
+	}
+	respond(c, http.StatusOK, gin.H{
+		"windowHours": window.Hours(),
+		"generatedAt": now,
+		"calendars":   names,
+		"total":       len(targets),
+		"targets":     targets,
+		"tags":        tagList,
+	})
+}
+
+// reliabilityWindow 可靠性指标的默认窗口（周报）
+const reliabilityWindow = 7 * 24 * time.Hour
+
+// GetReliabilityStats 根据状态变化记录计算各目标的故障时长、MTTR 与 MTBF，并按标签汇总，故障时长最长的在前
+// 参数：hours 统计窗口（默认7天，即周报口径），tag 仅返回指定标签下的目标
+func (h *Handler) GetReliabilityStats(c *gin.Context) {
+	window, ok := statsWindow(c, reliabilityWindow)
+	if !ok {
+		return
+	}
+	tags, err := h.targetTags()
+	if err != nil {
+		respondError(c, CodeStorageError, "查询监控目标失败："+err.Error(), nil)
+		return
+	}
+
+	now := time.Now()
+	histories, err := h.storage.StateHistories(now.Add(-window), now)
+	if err != nil {
+		respondError(c, CodeStorageError, "查询状态变化记录失败："+err.Error(), nil)
+		return
+	}
+
+	tagFilter := c.Query("tag")
+	targets := make([]*core.TargetReliability, 0, len(histories))
+	for url, history := range histories {
+		t, ok := tags[url]
+		if !ok {
+			continue // 已删除或未生效的目标
+		}
+		if tagFilter != "" && !containsTag(t, tagFilter) {
+			continue
+		}
+		r := core.ComputeReliability(url, history, now)
+		r.Tags = t
+		targets = append(targets, r)
+	}
+	sort.Slice(targets, func(i, j int) bool {
+		if targets[i].DowntimeSeconds != targets[j].DowntimeSeconds {
+			return targets[i].DowntimeSeconds > targets[j].DowntimeSeconds
+		}
+		return targets[i].TargetURL < targets[j].TargetURL
+	})
+
+	tagList := core.AggregateReliability(targets, tags)
+	if tagFilter != "" {
+		filtered := make([]*core.TagReliability, 0, 1)
+		for _, t := range tagList {
+			if t.Tag == tagFilter {
+				filtered = append(filtered, t)
+			}
+		}
+		tagList = filtered
+	}
+
+	respond(c, http.StatusOK, gin.H{
+		"windowHours": window.Hours(),
+		"generatedAt": now,
+		"total":       len(targets),
+		"targets":     targets,
+		"tags":        tagList,
+	})
+}
+
+// containsTag 判断标签列表中是否包含指定标签
+func containsTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
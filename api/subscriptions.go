@@ -0,0 +1,186 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+
+	"servicetelemetry/alert"
+	"servicetelemetry/subscription"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SubscribeRequest 状态订阅请求
+type SubscribeRequest struct {
+	Channel string   `json:"channel" msgpack:"channel"` // 通知方式：email / webhook
+	Address string   `json:"address" msgpack:"address"` // 邮箱地址或 Webhook 地址
+	Tags    []string `json:"tags" msgpack:"tags"`       // 订阅的组件（目标标签），为空表示订阅全部
+}
+
+// ComponentStatus 对外公开的组件状态
+type ComponentStatus struct {
+	Name    string `json:"name"`    // 组件名称（目标标签）
+	Status  string `json:"status"`  // operational / outage
+	Targets int    `json:"targets"` // 组件下的目标数
+	Failing int    `json:"failing"` // 组件下当前检查失败的目标数
+}
+
+// subscriptionsEnabled 判断状态订阅是否开启，未开启时返回错误响应
+func (h *Handler) subscriptionsEnabled(c *gin.Context) bool {
+	if h.subscriptions == nil {
+		respondError(c, CodeFeatureDisabled, "状态订阅未开启", nil)
+		return false
+	}
+	return true
+}
+
+// GetPublicStatus 公开状态页数据：各组件（目标标签）的当前状态与未恢复的事件，不暴露内部目标地址
+func (h *Handler) GetPublicStatus(c *gin.Context) {
+	tags, err := h.targetTags()
+	if err != nil {
+		respondError(c, CodeStorageError, "查询监控目标失败："+err.Error(), nil)
+		return
+	}
+
+	outage := make(map[string]bool)
+	active := make([]*subscription.PublicIncident, 0)
+	for _, incident := range h.alerts.Incidents() {
+		if incident.Status != alert.StatusFiring {
+			continue
+		}
+		active = append(active, subscription.NewPublicIncident(incident))
+		for _, t := range incident.Tags {
+			outage[t] = true
+		}
+	}
+
+	// 各目标的当前状态取自当前状态表，无需对历史结果做窗口查询
+	statuses, err := h.storage.CurrentStatuses()
+	if err != nil {
+		respondError(c, CodeStorageError, "查询目标当前状态失败："+err.Error(), nil)
+		return
+	}
+	failing := make(map[string]bool, len(statuses))
+	for _, s := range statuses {
+		failing[s.TargetURL] = s.Status == "failed"
+	}
+
+	counts := make(map[string]int)
+	failed := make(map[string]int)
+	for url, list := range tags {
+		for _, t := range list {
+			counts[t]++
+			if failing[url] {
+				failed[t]++
+			}
+		}
+	}
+	components := make([]*ComponentStatus, 0, len(counts))
+	for name, n := range counts {
+		status := "operational"
+		if outage[name] {
+			status = "outage"
+		}
+		components = append(components, &ComponentStatus{Name: name, Status: status, Targets: n, Failing: failed[name]})
+	}
+	sort.Slice(components, func(i, j int) bool { return components[i].Name < components[j].Name })
+
+	respond(c, http.StatusOK, gin.H{
+		"components":    components,
+		"incidents":     active,
+		"subscriptions": h.subscriptions != nil,
+	})
+}
+
+// Subscribe 订阅组件的状态更新，订阅后需通过确认链接确认才会收到通知
+func (h *Handler) Subscribe(c *gin.Context) {
+	if !h.subscriptionsEnabled(c) {
+		return
+	}
+	var req SubscribeRequest
+	if err := bindBody(c, &req); err != nil {
+		respondError(c, CodeInvalidArgument, "参数错误："+err.Error(), nil)
+		return
+	}
+	if req.Channel == subscription.ChannelWebhook {
+		// 订阅人提交的 Webhook 地址与监控目标一样需要防止 SSRF
+		normalized, err := h.checker.ValidateURL(req.Address)
+		if err != nil {
+			respondError(c, CodeInvalidArgument, err.Error(), gin.H{"field": "address"})
+			return
+		}
+		req.Address = normalized
+	}
+
+	sub, err := h.subscriptions.Subscribe(req.Channel, req.Address, req.Tags)
+	var inputErr *subscription.InputError
+	if errors.As(err, &inputErr) {
+		respondError(c, CodeInvalidArgument, inputErr.Msg, gin.H{"field": inputErr.Field})
+		return
+	}
+	if err != nil {
+		respondError(c, CodeInternal, err.Error(), nil)
+		return
+	}
+	respond(c, http.StatusAccepted, gin.H{
+		"message":      "确认消息已发送，请通过其中的链接确认订阅",
+		"subscription": sub,
+	})
+}
+
+// ConfirmSubscription 通过确认链接确认订阅
+func (h *Handler) ConfirmSubscription(c *gin.Context) {
+	if !h.subscriptionsEnabled(c) {
+		return
+	}
+	sub, err := h.subscriptions.Confirm(c.Query("token"))
+	if err != nil {
+		respondError(c, CodeStorageError, err.Error(), nil)
+		return
+	}
+	if sub == nil {
+		respondError(c, CodeNotFound, "确认链接无效或订阅已退订", nil)
+		return
+	}
+	respond(c, http.StatusOK, gin.H{
+		"message":      "订阅已确认",
+		"subscription": sub,
+	})
+}
+
+// Unsubscribe 通过退订链接退订（支持 GET 以便直接点击邮件中的链接）
+func (h *Handler) Unsubscribe(c *gin.Context) {
+	if !h.subscriptionsEnabled(c) {
+		return
+	}
+	sub, err := h.subscriptions.Unsubscribe(c.Query("token"))
+	if err != nil {
+		respondError(c, CodeStorageError, err.Error(), nil)
+		return
+	}
+	if sub == nil {
+		respondError(c, CodeNotFound, "退订链接无效或已退订", nil)
+		return
+	}
+	respond(c, http.StatusOK, gin.H{
+		"message":      "已退订",
+		"subscription": sub,
+	})
+}
+
+// ListSubscriptions 查询全部订阅（含未确认），供管理员查看
+func (h *Handler) ListSubscriptions(c *gin.Context) {
+	if !h.subscriptionsEnabled(c) {
+		return
+	}
+	list, err := h.subscriptions.List()
+	if err != nil {
+		respondError(c, CodeStorageError, err.Error(), nil)
+		return
+	}
+	respond(c, http.StatusOK, gin.H{
+		"total": len(list),
+		"list":  list,
+	})
+}
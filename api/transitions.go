@@ -0,0 +1,59 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"servicetelemetry/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultTransitionWindow 状态变化记录的默认查询范围
+const defaultTransitionWindow = 7 * 24 * time.Hour
+
+// GetTargetTransitions 查询目标的状态变化记录（up / down / degraded），每条记录给出该状态的结束时间与持续时间
+// 参数：hours 查询最近 N 小时（默认 7 天），limit 返回条数（默认 100，最大 1000）
+func (h *Handler) GetTargetTransitions(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		respondError(c, CodeInvalidArgument, "目标ID应为正整数", gin.H{"field": "id"})
+		return
+	}
+	window, ok := statsWindow(c, defaultTransitionWindow)
+	if !ok {
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(storage.DefaultQueryLimit)))
+	if err != nil || limit <= 0 {
+		respondError(c, CodeInvalidArgument, "limit参数错误，应为正整数", gin.H{"field": "limit"})
+		return
+	}
+	if limit > storage.MaxQueryLimit {
+		limit = storage.MaxQueryLimit
+	}
+
+	target, err := h.storage.GetTarget(id)
+	if err != nil {
+		respondError(c, CodeStorageError, "查询监控目标失败："+err.Error(), nil)
+		return
+	}
+	if target == nil {
+		respondError(c, CodeNotFound, "监控目标不存在", gin.H{"id": id})
+		return
+	}
+
+	now := time.Now()
+	transitions, err := h.storage.ListTransitions(target.URL, now.Add(-window), now, limit)
+	if err != nil {
+		respondError(c, CodeStorageError, "查询状态变化记录失败："+err.Error(), nil)
+		return
+	}
+	respond(c, http.StatusOK, gin.H{
+		"id":        target.ID,
+		"targetUrl": target.URL,
+		"total":     len(transitions),
+		"list":      transitions,
+	})
+}
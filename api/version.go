@@ -0,0 +1,69 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APIVersion 当前 API 版本
+const APIVersion = "v1"
+
+// legacyUsage 旧版（无版本前缀）接口调用次数统计，便于评估下线影响
+var (
+	legacyUsage   = make(map[string]uint64)
+	legacyUsageMu sync.Mutex
+)
+
+// versionHeader 为所有 API 响应添加版本号响应头
+func versionHeader() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("X-API-Version", APIVersion)
+		c.Next()
+	}
+}
+
+// legacyDeprecation 旧版接口兼容层：请求照常处理，但在响应头中标记弃用并指向新版地址
+// 响应头遵循 RFC 8594：Deprecation、Sunset（计划下线日期）、Link（successor-version）
+// sunset：计划下线日期（HTTP-date 格式），为空时不返回 Sunset 头
+func legacyDeprecation(sunset string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		successor := "/api/" + APIVersion + strings.TrimPrefix(c.Request.URL.Path, "/api")
+
+		c.Header("Deprecation", "true")
+		if sunset != "" {
+			c.Header("Sunset", sunset)
+		}
+		c.Header("Link", fmt.Sprintf("<%s>; rel=\"successor-version\"", successor))
+
+		legacyUsageMu.Lock()
+		legacyUsage[c.FullPath()]++
+		legacyUsageMu.Unlock()
+
+		c.Next()
+	}
+}
+
+// LegacyUsage 返回旧版接口调用次数统计（路由 -> 次数）
+func LegacyUsage() map[string]uint64 {
+	legacyUsageMu.Lock()
+	defer legacyUsageMu.Unlock()
+	usage := make(map[string]uint64, len(legacyUsage))
+	for path, n := range legacyUsage {
+		usage[path] = n
+	}
+	return usage
+}
+
+// GetAPIVersion 查询 API 版本信息及旧版接口使用情况
+func (h *Handler) GetAPIVersion(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"version":       APIVersion,
+		"legacyEnabled": !h.cfg.API.DisableLegacy,
+		"legacySunset":  h.cfg.API.LegacySunset,
+		"legacyUsage":   LegacyUsage(),
+	})
+}
@@ -0,0 +1,235 @@
+package api
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"servicetelemetry/config"
+	"servicetelemetry/core"
+)
+
+// RoleViewer 查看者角色：只能访问聚合数据，响应经过数据最小化处理
+const RoleViewer = "viewer"
+
+// ctxRoleKey 请求上下文中记录调用方角色的键
+const ctxRoleKey = "role"
+
+// viewerHiddenFields 查看者响应中移除的字段：原始错误信息与警告、检查诊断信息（拨号地址、解析服务器、代理等内部拓扑），
+// 以及可能包含凭据或人员信息的元数据
+var viewerHiddenFields = map[string]bool{
+	"errorMsg":     true,
+	"warning":      true,
+	"details":      true,
+	"dialAttempts": true,
+	"traceroute":   true,
+	"families":     true,
+	"hedge":        true,
+	"snapshot":     true,
+	"caFile":       true,
+	"credentials":  true,
+	"headers":      true,
+	"proxy":        true,
+	"resolver":     true,
+	"sourceIP":     true,
+	"interface":    true,
+	"ackedBy":      true,
+	"resolvedBy":   true,
+}
+
+// embeddedURLPattern 字符串中出现的地址（如事件标题中的目标地址）
+var embeddedURLPattern = regexp.MustCompile(`[a-zA-Z][a-zA-Z0-9+.-]*://[^\s，。、（）()"']+`)
+
+// RegisterViewerRoutes 在查看者接口（单独的端口）上注册只读的聚合数据接口，令牌引用无效时返回错误
+// 只开放目标最新状态、主机聚合、事件、公开状态与统计类接口，不开放历史明细、配置与写接口
+func (h *Handler) RegisterViewerRoutes(router *gin.Engine) error {
+	auth, err := viewerAuth(&h.cfg.API.Viewer)
+	if err != nil {
+		return err
+	}
+	group := router.Group("/api/"+APIVersion, requestID(), versionHeader(), auth)
+	group.GET("/version", h.GetAPIVersion)
+	group.GET("/targets/state", conditionalGet(), h.GetViewerTargetStates)
+	group.GET("/hosts", conditionalGet(), h.GetHosts)
+	group.GET("/incidents", conditionalGet(), h.GetIncidents)
+	group.GET("/status", conditionalGet(), h.GetPublicStatus)
+	group.GET("/stats/health", conditionalGet(), h.GetHealthStats)
+	group.GET("/stats/availability", conditionalGet(), h.GetAvailabilityStats)
+	group.GET("/stats/reliability", conditionalGet(), h.GetReliabilityStats)
+	return nil
+}
+
+// ViewerTargetState 查看者接口返回的目标状态：只包含以下字段，检查结果新增的字段不会随之对外暴露
+type ViewerTargetState struct {
+	TargetURL    string    `json:"targetUrl"`             // 目标地址（去掉用户信息、查询参数与片段）
+	Status       string    `json:"status"`                // 最近一次检查状态：success / failed / degraded
+	ErrorType    string    `json:"errorType,omitempty"`   // 错误类型（不含原始错误信息）
+	ResponseTime float64   `json:"responseTime"`          // 响应耗时（毫秒）
+	SSLDaysLeft  *int      `json:"sslDaysLeft,omitempty"` // 证书剩余天数，非 TLS 检查为空
+	CheckedAt    time.Time `json:"checkedAt"`             // 检查时间
+	Tags         []string  `json:"tags,omitempty"`        // 目标标签
+}
+
+// GetViewerTargetStates 查看者接口的目标最新状态，按白名单字段返回，不返回诊断信息与原始错误
+// source=db 时从目标当前状态表读取（不含证书剩余天数与标签）
+func (h *Handler) GetViewerTargetStates(c *gin.Context) {
+	var list []ViewerTargetState
+	if c.Query("source") == "db" {
+		statuses, err := h.storage.CurrentStatuses()
+		if err != nil {
+			respondError(c, CodeStorageError, "查询目标当前状态失败", nil)
+			return
+		}
+		list = make([]ViewerTargetState, 0, len(statuses))
+		for _, s := range statuses {
+			list = append(list, ViewerTargetState{
+				TargetURL:    s.TargetURL,
+				Status:       s.Status,
+				ErrorType:    s.ErrorType,
+				ResponseTime: s.ResponseTime,
+				CheckedAt:    s.LastCheckedAt,
+			})
+		}
+	} else {
+		states := h.checker.LastKnownStates()
+		list = make([]ViewerTargetState, 0, len(states))
+		for _, r := range states {
+			list = append(list, viewerTargetState(r))
+		}
+	}
+	failed := 0
+	for _, s := range list {
+		if s.Status == "failed" {
+			failed++
+		}
+	}
+	respond(c, http.StatusOK, gin.H{
+		"total":  len(list),
+		"failed": failed,
+		"list":   list,
+	})
+}
+
+// viewerTargetState 从检查结果中复制查看者可见的字段
+func viewerTargetState(r *core.MonitorResult) ViewerTargetState {
+	return ViewerTargetState{
+		TargetURL:    r.TargetURL,
+		Status:       r.Status,
+		ErrorType:    r.ErrorType,
+		ResponseTime: r.ResponseTime,
+		SSLDaysLeft:  r.SSLDaysLeft,
+		CheckedAt:    r.CheckedAt,
+		Tags:         r.Tags,
+	}
+}
+
+// viewerAuth 查看者认证：配置了令牌时要求 Authorization: Bearer <token>，通过后将请求标记为查看者角色
+func viewerAuth(cfg *config.ViewerConfig) (gin.HandlerFunc, error) {
+	tokens := make([][]byte, 0, len(cfg.Tokens))
+	for i, ref := range cfg.Tokens {
+		token, err := config.ResolveSecret(ref)
+		if err != nil {
+			return nil, fmt.Errorf("解析 api.viewer.tokens[%d] 失败：%w", i, err)
+		}
+		if token == "" {
+			return nil, fmt.Errorf("api.viewer.tokens[%d] 为空", i)
+		}
+		tokens = append(tokens, []byte(token))
+	}
+
+	return func(c *gin.Context) {
+		c.Set(ctxRoleKey, RoleViewer)
+		if len(tokens) == 0 {
+			return
+		}
+		given := []byte(strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer "))
+		for _, token := range tokens {
+			if subtle.ConstantTimeCompare(given, token) == 1 {
+				return
+			}
+		}
+		respondError(c, CodeUnauthenticated, "缺少或无效的查看者令牌", nil)
+		c.Abort()
+	}, nil
+}
+
+// minimizeForViewer 对查看者的响应做数据最小化：移除敏感字段，并去掉所有地址中的用户信息、查询参数与片段
+// 通过 JSON 往返转换为通用结构后处理，新增的接口字段无需逐一适配；转换失败时返回空对象而不是原始数据
+func minimizeForViewer(obj interface{}) interface{} {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return gin.H{}
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var generic interface{}
+	if err := decoder.Decode(&generic); err != nil {
+		return gin.H{}
+	}
+	return minimizeValue(generic)
+}
+
+// minimizeValue 递归处理通用 JSON 值
+func minimizeValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, child := range val {
+			if viewerHiddenFields[k] {
+				delete(val, k)
+				continue
+			}
+			val[k] = minimizeValue(child)
+		}
+		return val
+	case []interface{}:
+		for i, child := range val {
+			val[i] = minimizeValue(child)
+		}
+		return val
+	case string:
+		return redactEmbeddedURLs(val)
+	case json.Number:
+		// 转回数值类型，MessagePack 编码时不会变成字符串
+		if i, err := val.Int64(); err == nil {
+			return i
+		}
+		f, _ := val.Float64()
+		return f
+	}
+	return v
+}
+
+// redactEmbeddedURLs 去掉字符串中各地址的用户信息、查询参数与片段，如 https://u:p@api.example.com/health?token=x → https://api.example.com/health
+func redactEmbeddedURLs(s string) string {
+	if !strings.Contains(s, "://") {
+		return s
+	}
+	return embeddedURLPattern.ReplaceAllStringFunc(s, func(raw string) string {
+		u, err := url.Parse(raw)
+		if err != nil || u.Host == "" {
+			// 无法解析的地址按文本去掉查询参数、片段与用户信息
+			if i := strings.IndexAny(raw, "?#"); i >= 0 {
+				raw = raw[:i]
+			}
+			scheme, rest, _ := strings.Cut(raw, "://")
+			host, path, _ := strings.Cut(rest, "/")
+			if i := strings.LastIndex(host, "@"); i >= 0 {
+				host = host[i+1:]
+			}
+			if path != "" {
+				return scheme + "://" + host + "/" + path
+			}
+			return scheme + "://" + host
+		}
+		u.User, u.RawQuery, u.Fragment, u.ForceQuery = nil, "", "", false
+		return u.String()
+	})
+}
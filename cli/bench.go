@@ -0,0 +1,285 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"math/rand"
+	"net"
+	"net/http"
+	"os"
+	"runtime"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"servicetelemetry/config"
+	"servicetelemetry/core"
+	"servicetelemetry/storage"
+)
+
+// benchURLPrefix 数据库写入压测使用的目标地址前缀，压测结束后按此前缀清理
+const benchURLPrefix = "bench://"
+
+// benchOptions bench 子命令参数
+type benchOptions struct {
+	targets     int
+	concurrency int
+	duration    time.Duration
+	latency     time.Duration
+	failRate    float64
+	db          bool
+	writes      int
+}
+
+// latencyStats 耗时分布统计
+type latencyStats struct {
+	mu      sync.Mutex
+	samples []float64
+}
+
+func (s *latencyStats) add(d time.Duration) {
+	s.mu.Lock()
+	s.samples = append(s.samples, float64(d.Microseconds())/1000)
+	s.mu.Unlock()
+}
+
+// percentile 返回指定分位的耗时（毫秒）
+func (s *latencyStats) percentile(p float64) float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.samples) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), s.samples...)
+	sort.Float64s(sorted)
+	return sorted[int(float64(len(sorted)-1)*p)]
+}
+
+// RunBench 执行 bench 子命令：针对本地模拟服务合成大量目标，测量可持续的检查吞吐、数据库写入吞吐与内存占用，输出容量评估报告
+// args：子命令参数，如 -n 5000 -c 50 -d 30s -db
+// 返回进程退出码
+func RunBench(args []string) int {
+	cfg := config.DefaultConfig()
+	opts := benchOptions{}
+
+	fs := flag.NewFlagSet("bench", flag.ContinueOnError)
+	fs.IntVar(&opts.targets, "n", 1000, "合成的模拟目标数")
+	fs.IntVar(&opts.concurrency, "c", cfg.Monitor.Concurrency, "检查并发数（默认与 monitor.concurrency 一致）")
+	fs.DurationVar(&opts.duration, "d", 10*time.Second, "检查压测时长")
+	fs.DurationVar(&opts.latency, "latency", 20*time.Millisecond, "模拟服务的平均响应延迟")
+	fs.Float64Var(&opts.failRate, "fail", 0.05, "模拟服务返回 500 的比例（0~1），失败的检查会按 monitor.maxRetry 重试")
+	fs.BoolVar(&opts.db, "db", false, "同时压测数据库写入（使用配置中的数据库，写入的数据在结束后清理）")
+	fs.IntVar(&opts.writes, "writes", 5000, "数据库写入压测的结果条数")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if opts.targets <= 0 || opts.concurrency <= 0 || opts.duration <= 0 {
+		fmt.Fprintln(os.Stderr, "目标数、并发数与压测时长必须大于0")
+		return 2
+	}
+
+	server, err := startMockServer(opts.latency, opts.failRate)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "启动模拟服务失败：", err)
+		return 1
+	}
+	defer server.Close()
+
+	runtime.GC()
+	var before runtime.MemStats
+	runtime.ReadMemStats(&before)
+
+	monitorCfg := cfg.Monitor
+	monitorCfg.Concurrency = opts.concurrency
+	checker := core.NewServiceChecker(&monitorCfg)
+	targets := make([]*core.MonitorTarget, opts.targets)
+	for i := range targets {
+		targets[i] = &core.MonitorTarget{
+			URL:       fmt.Sprintf("http://%s/targets/%d", server.Addr().String(), i),
+			IsCurrent: true,
+			Tags:      []string{"bench"},
+		}
+	}
+
+	fmt.Printf("压测开始：%d 个模拟目标，并发 %d，时长 %s，模拟延迟 %s，失败率 %.0f%%\n",
+		opts.targets, opts.concurrency, opts.duration, opts.latency, opts.failRate*100)
+	checks, failed, rounds, latency, peakHeap := benchChecks(checker, targets, opts)
+	checksPerSec := float64(checks) / opts.duration.Seconds()
+
+	runtime.GC()
+	var after runtime.MemStats
+	runtime.ReadMemStats(&after)
+	retained := int64(after.HeapAlloc) - int64(before.HeapAlloc)
+	if retained < 0 {
+		retained = 0
+	}
+
+	fmt.Println()
+	fmt.Println("========== 容量评估报告 ==========")
+	fmt.Printf("检查吞吐：%.1f 次/秒（共 %d 次，失败 %d 次，完成 %d 轮）\n", checksPerSec, checks, failed, rounds)
+	fmt.Printf("单次检查耗时：P50 %.1fms，P95 %.1fms，P99 %.1fms\n", latency.percentile(0.5), latency.percentile(0.95), latency.percentile(0.99))
+	capacity := int(checksPerSec * cfg.Monitor.CheckInterval.Seconds())
+	fmt.Printf("按检查间隔 %s 计算，单实例约可承载 %d 个目标（并发 %d）\n", cfg.Monitor.CheckInterval, capacity, opts.concurrency)
+	if capacity < opts.targets {
+		fmt.Printf("提示：%d 个目标无法在一个检查间隔内检查完，需提高并发或延长检查间隔\n", opts.targets)
+	}
+	fmt.Printf("内存：峰值堆占用 %.1fMB，常驻增量 %.1fMB，约 %.1fKB/目标\n",
+		mb(peakHeap), mb(uint64(retained)), float64(retained)/1024/float64(opts.targets))
+
+	if opts.db {
+		if err := benchWrites(&cfg.DB, opts); err != nil {
+			fmt.Fprintln(os.Stderr, "数据库写入压测失败：", err)
+			return 1
+		}
+	} else {
+		fmt.Println("数据库写入：未压测（使用 -db 开启）")
+	}
+	return 0
+}
+
+// benchChecks 在压测时长内循环检查全部目标，返回检查次数、失败次数、完成轮数、耗时分布与峰值堆占用
+// 每轮结果写入最新状态缓存，模拟常驻进程保留各目标最新结果的内存占用
+func benchChecks(checker *core.ServiceChecker, targets []*core.MonitorTarget, opts benchOptions) (int64, int64, int, *latencyStats, uint64) {
+	var checks, failed int64
+	var peakHeap uint64
+	latency := &latencyStats{}
+
+	stop := make(chan struct{})
+	sampled := make(chan struct{})
+	go func() {
+		defer close(sampled)
+		ticker := time.NewTicker(200 * time.Millisecond)
+		defer ticker.Stop()
+		var m runtime.MemStats
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				runtime.ReadMemStats(&m)
+				if m.HeapAlloc > peakHeap {
+					peakHeap = m.HeapAlloc
+				}
+			}
+		}
+	}()
+
+	deadline := time.Now().Add(opts.duration)
+	limiter := core.NewConcurrencyLimiter(opts.concurrency)
+	rounds := 0
+	for time.Now().Before(deadline) {
+		results := make([]*core.MonitorResult, len(targets))
+		var wg sync.WaitGroup
+		for i, t := range targets {
+			if time.Now().After(deadline) {
+				break
+			}
+			limiter.Acquire()
+			wg.Add(1)
+			go func(i int, target *core.MonitorTarget) {
+				defer limiter.Release()
+				defer wg.Done()
+				start := time.Now()
+				r := checker.Probe(target)
+				latency.add(time.Since(start))
+				atomic.AddInt64(&checks, 1)
+				if r.Status == "failed" {
+					atomic.AddInt64(&failed, 1)
+				}
+				results[i] = r
+			}(i, t)
+		}
+		wg.Wait()
+
+		completed := make([]*core.MonitorResult, 0, len(results))
+		for _, r := range results {
+			if r != nil {
+				completed = append(completed, r)
+			}
+		}
+		checker.Preload(completed)
+		if len(completed) == len(targets) {
+			rounds++
+		}
+	}
+
+	close(stop)
+	<-sampled
+	return checks, failed, rounds, latency, peakHeap
+}
+
+// benchWrites 并发写入合成的检查结果，测量数据库写入吞吐，结束后清理写入的数据
+func benchWrites(cfg *config.DBConfig, opts benchOptions) error {
+	st, err := storage.NewMySQLStorage(cfg)
+	if err != nil {
+		return err
+	}
+	defer st.Close()
+
+	latency := &latencyStats{}
+	var errCount int64
+	limiter := core.NewConcurrencyLimiter(opts.concurrency)
+	var wg sync.WaitGroup
+
+	start := time.Now()
+	for i := 0; i < opts.writes; i++ {
+		limiter.Acquire()
+		wg.Add(1)
+		go func(i int) {
+			defer limiter.Release()
+			defer wg.Done()
+			r := &core.MonitorResult{
+				TargetURL:    fmt.Sprintf("%starget-%d", benchURLPrefix, i%opts.targets),
+				Status:       "success",
+				StatusCode:   http.StatusOK,
+				ResponseTime: float64(opts.latency.Milliseconds()),
+				CheckedAt:    time.Now(),
+			}
+			begin := time.Now()
+			if err := st.SaveResult(r); err != nil {
+				atomic.AddInt64(&errCount, 1)
+				return
+			}
+			latency.add(time.Since(begin))
+		}(i)
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	purged, err := st.PurgeResults(benchURLPrefix)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "清理压测数据失败：", err)
+	}
+
+	fmt.Printf("数据库写入：%.1f 条/秒（共 %d 条，失败 %d 条，并发 %d）\n",
+		float64(int64(opts.writes)-errCount)/elapsed.Seconds(), opts.writes, errCount, opts.concurrency)
+	fmt.Printf("单条写入耗时：P50 %.1fms，P95 %.1fms，P99 %.1fms（已清理 %d 条压测数据）\n",
+		latency.percentile(0.5), latency.percentile(0.95), latency.percentile(0.99), purged)
+	return nil
+}
+
+// startMockServer 启动本地模拟服务：按平均延迟（±50% 抖动）返回响应，按失败率返回 500
+func startMockServer(latency time.Duration, failRate float64) (net.Listener, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if latency > 0 {
+			time.Sleep(latency/2 + time.Duration(rand.Int63n(int64(latency)+1)))
+		}
+		if rand.Float64() < failRate {
+			http.Error(w, "mock failure", http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte("ok"))
+	})
+	go http.Serve(ln, handler)
+	return ln, nil
+}
+
+// mb 字节数转换为 MB
+func mb(n uint64) float64 {
+	return float64(n) / 1024 / 1024
+}
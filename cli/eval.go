@@ -0,0 +1,428 @@
+package cli
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"servicetelemetry/agent"
+	"servicetelemetry/config"
+	"servicetelemetry/core"
+	"servicetelemetry/storage"
+)
+
+// evalCorpus 评估语料：一组带标注的用户查询
+type evalCorpus struct {
+	Aliases map[string]string `json:"aliases"` // 评估使用的服务别名，不为空时替换 agent.serviceAliases，使结果不受本地配置影响
+	Cases   []evalCase        `json:"cases"`   // 标注的查询
+}
+
+// evalCase 一条标注的查询
+type evalCase struct {
+	Query  string     `json:"query"`  // 用户查询
+	Expect evalIntent `json:"expect"` // 期望的查询意图
+}
+
+// evalIntent 标注的查询意图，字段含义与 agent.QueryIntent 一致
+type evalIntent struct {
+	Failed       bool     `json:"failed"`       // 只看失败
+	SSL          bool     `json:"ssl"`          // 关注证书
+	CertExpiring bool     `json:"certExpiring"` // 只看即将过期的证书
+	TCP          bool     `json:"tcp"`          // 只看 TCP 目标
+	Targets      []string `json:"targets"`      // 目标地址关键词
+	Hours        int      `json:"hours"`        // 时间范围（小时），0 表示 agent.defaultTimeRange
+}
+
+// defaultEvalCorpus 内置评估语料：别名指向 seed 生成的模拟目标，中英文查询各占一部分
+var defaultEvalCorpus = evalCorpus{
+	Aliases: map[string]string{
+		"支付": "svc-001", "payments": "svc-001",
+		"搜索": "svc-002", "search": "svc-002",
+		"登录": "svc-003", "auth": "svc-003",
+	},
+	Cases: []evalCase{
+		{"近3天哪些服务异常", evalIntent{Failed: true, Hours: 72}},
+		{"最近24小时有失败的服务吗", evalIntent{Failed: true, Hours: 24}},
+		{"最近一周哪些服务挂了", evalIntent{Failed: true, Hours: 168}},
+		{"哪些服务的SSL证书快过期了", evalIntent{SSL: true, CertExpiring: true}},
+		{"github的ssl证书还有多久到期", evalIntent{SSL: true, CertExpiring: true, Targets: []string{"github"}}},
+		{"证书有效期检查", evalIntent{SSL: true}},
+		{"支付服务挂了吗", evalIntent{Failed: true, Targets: []string{"svc-001"}}},
+		{"搜索服务近7天的超时情况", evalIntent{Failed: true, Targets: []string{"svc-002"}, Hours: 168}},
+		{"登录服务最近12小时的状态", evalIntent{Targets: []string{"svc-003"}, Hours: 12}},
+		{"支付和搜索服务的tcp连接失败", evalIntent{Failed: true, TCP: true, Targets: []string{"svc-001", "svc-002"}}},
+		{"tcp服务今天有没有异常", evalIntent{Failed: true, TCP: true}},
+		{"baidu近1天有没有失败", evalIntent{Failed: true, Targets: []string{"baidu"}, Hours: 24}},
+		{"localhost的检查结果", evalIntent{Targets: []string{"localhost"}}},
+		{"总结今天的监控情况", evalIntent{}},
+		{"which services failed in the last 6 hours", evalIntent{Failed: true, Hours: 6}},
+		{"ssl certs expiring this week", evalIntent{SSL: true, CertExpiring: true, Hours: 168}},
+		{"is payments down?", evalIntent{Failed: true, Targets: []string{"svc-001"}}},
+		{"any tcp errors in the past 2 days", evalIntent{Failed: true, TCP: true, Hours: 48}},
+		{"show github status for the last hour", evalIntent{Targets: []string{"github"}, Hours: 1}},
+		{"search latency today", evalIntent{Targets: []string{"svc-002"}}},
+		{"auth service timeouts over the past 3d", evalIntent{Failed: true, Targets: []string{"svc-003"}, Hours: 72}},
+		{"summarize monitoring for the last week", evalIntent{Hours: 168}},
+	},
+}
+
+// evalFacetNames 意图评估的维度，按报告中的顺序排列
+var evalFacetNames = []string{"failed", "ssl", "certExpiring", "tcp", "target", "hours"}
+
+// evalCounts 精确率 / 召回率计数
+type evalCounts struct {
+	tp, fp, fn int
+}
+
+func (c *evalCounts) add(o evalCounts) {
+	c.tp, c.fp, c.fn = c.tp+o.tp, c.fp+o.fp, c.fn+o.fn
+}
+
+// precision 精确率，没有预测项时为 1
+func (c evalCounts) precision() float64 {
+	if c.tp+c.fp == 0 {
+		return 1
+	}
+	return float64(c.tp) / float64(c.tp+c.fp)
+}
+
+// recall 召回率，没有期望项时为 1
+func (c evalCounts) recall() float64 {
+	if c.tp+c.fn == 0 {
+		return 1
+	}
+	return float64(c.tp) / float64(c.tp+c.fn)
+}
+
+// exact 预测与期望完全一致
+func (c evalCounts) exact() bool {
+	return c.fp == 0 && c.fn == 0
+}
+
+// RunEval 执行 eval 子命令：用带标注的查询语料评估意图解析器与数据检索，输出精确率 / 召回率报告，
+// 修改解析规则、别名或更换解析器后可据此量化比较；默认在内存中的模拟数据上检索，不需要数据库
+// args：子命令参数，如 -f corpus.json -parser english -min-recall 0.9；-db 改为检索配置的数据库（需先执行 seed）
+// 返回进程退出码，指标低于 -min-precision / -min-recall 时返回 1
+func RunEval(args []string) int {
+	cfg := config.DefaultConfig()
+	opts := seedOptions{}
+
+	fs := flag.NewFlagSet("eval", flag.ContinueOnError)
+	file := fs.String("f", "", "评估语料文件路径，为空时使用内置语料")
+	parserName := fs.String("parser", cfg.Agent.IntentParser, "评估的意图解析器（默认与 agent.intentParser 一致）")
+	useDB := fs.Bool("db", false, "检索配置的数据库（需先执行 seed），默认检索内存中的模拟数据")
+	fs.IntVar(&opts.targets, "n", 30, "内存模拟数据的目标数")
+	fs.IntVar(&opts.days, "days", 7, "内存模拟数据的历史天数")
+	fs.DurationVar(&opts.interval, "interval", 10*time.Minute, "内存模拟数据的检查间隔")
+	fs.Int64Var(&opts.seed, "seed", 1, "内存模拟数据的随机种子，与 seed 子命令相同的参数生成相同的目标")
+	minPrecision := fs.Float64("min-precision", 0, "意图与检索精确率的下限（0~1），低于时返回 1，用于 CI")
+	minRecall := fs.Float64("min-recall", 0, "意图与检索召回率的下限（0~1），低于时返回 1，用于 CI")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if !*useDB && (opts.targets <= 0 || opts.days <= 0 || opts.interval < time.Minute) {
+		fmt.Fprintln(os.Stderr, "目标数与天数必须大于0，检查间隔不能小于1分钟")
+		return 2
+	}
+
+	corpus := &defaultEvalCorpus
+	if *file != "" {
+		loaded, err := loadEvalCorpus(*file)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		corpus = loaded
+	}
+
+	agentCfg := cfg.Agent
+	agentCfg.IntentParser = *parserName
+	if len(corpus.Aliases) > 0 {
+		agentCfg.ServiceAliases = corpus.Aliases
+	}
+	parser, err := agent.NewIntentParser(&agentCfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	var retrieve func(intent *agent.QueryIntent) ([]*core.MonitorResult, error)
+	var source string
+	if *useDB {
+		st, err := storage.NewMySQLStorage(&cfg.DB)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "连接数据库失败：", err)
+			return 1
+		}
+		defer st.Close()
+		retrieve = agent.NewDataRetriever(st, &agentCfg, parser).Retrieve
+		source = fmt.Sprintf("数据库（最多返回 %d 条，与小助手一致）", agentCfg.MaxRetrieve)
+	} else {
+		results := evalSeedResults(opts)
+		retrieve = func(intent *agent.QueryIntent) ([]*core.MonitorResult, error) {
+			return searchInMemory(results, intent)
+		}
+		source = fmt.Sprintf("内存模拟数据（%d 个目标、%d 天，共 %d 条结果，不限制返回条数）", opts.targets, opts.days, len(results))
+	}
+	retriever := agent.NewDataRetriever(nil, &agentCfg, parser)
+
+	facets := map[string]*evalCounts{}
+	for _, name := range evalFacetNames {
+		facets[name] = &evalCounts{}
+	}
+	var intentTotal, retrievalTotal evalCounts
+	intentExact, retrievalExact := 0, 0
+	for i, c := range corpus.Cases {
+		got := retriever.ParseIntent(c.Query)
+		want := c.Expect.queryIntent(agentCfg.DefaultTimeRange)
+
+		var caseIntent evalCounts
+		for _, name := range evalFacetNames {
+			fc := compareFacet(name, got, want)
+			facets[name].add(fc)
+			caseIntent.add(fc)
+		}
+		intentTotal.add(caseIntent)
+		if caseIntent.exact() {
+			intentExact++
+		}
+
+		gotResults, err := retrieve(got)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "cases[%d] 检索失败：%v\n", i, err)
+			return 1
+		}
+		wantResults, err := retrieve(want)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "cases[%d] 按标注意图检索失败：%v\n", i, err)
+			return 1
+		}
+		caseRetrieval := compareResults(gotResults, wantResults)
+		retrievalTotal.add(caseRetrieval)
+		if caseRetrieval.exact() {
+			retrievalExact++
+		}
+
+		if !caseIntent.exact() || !caseRetrieval.exact() {
+			fmt.Printf("✗ cases[%d] %s\n", i, c.Query)
+			fmt.Printf("    期望：%s\n    解析：%s\n", describeIntent(want), describeIntent(got))
+			fmt.Printf("    检索：精确率 %.1f%%，召回率 %.1f%%（解析 %d 条，期望 %d 条）\n",
+				caseRetrieval.precision()*100, caseRetrieval.recall()*100, len(gotResults), len(wantResults))
+		}
+	}
+
+	total := len(corpus.Cases)
+	fmt.Println()
+	fmt.Println("========== 意图解析评估报告 ==========")
+	fmt.Printf("解析器：%s，用例 %d 个，意图完全正确 %d 个（%.1f%%）\n", agentCfg.IntentParser, total, intentExact, percent(intentExact, total))
+	// 中文表头按显示宽度（每个汉字占两列）手动对齐
+	fmt.Println("维度             精确率   召回率     TP     FP     FN")
+	for _, name := range evalFacetNames {
+		fc := facets[name]
+		fmt.Printf("%-14s %7.1f%% %7.1f%% %6d %6d %6d\n", name, fc.precision()*100, fc.recall()*100, fc.tp, fc.fp, fc.fn)
+	}
+	fmt.Printf("%-12s %7.1f%% %7.1f%% %6d %6d %6d\n", "总体", intentTotal.precision()*100, intentTotal.recall()*100, intentTotal.tp, intentTotal.fp, intentTotal.fn)
+	fmt.Printf("检索：%s\n", source)
+	fmt.Printf("检索精确率 %.1f%%，召回率 %.1f%%，结果完全一致 %d 个（%.1f%%）\n",
+		retrievalTotal.precision()*100, retrievalTotal.recall()*100, retrievalExact, percent(retrievalExact, total))
+
+	failed := false
+	for _, m := range []struct {
+		name  string
+		value float64
+		min   float64
+	}{
+		{"意图精确率", intentTotal.precision(), *minPrecision},
+		{"意图召回率", intentTotal.recall(), *minRecall},
+		{"检索精确率", retrievalTotal.precision(), *minPrecision},
+		{"检索召回率", retrievalTotal.recall(), *minRecall},
+	} {
+		if m.value < m.min {
+			fmt.Printf("未达标：%s %.1f%% 低于下限 %.1f%%\n", m.name, m.value*100, m.min*100)
+			failed = true
+		}
+	}
+	if failed {
+		return 1
+	}
+	return 0
+}
+
+// loadEvalCorpus 读取评估语料文件，查询为空或没有用例时返回错误
+func loadEvalCorpus(path string) (*evalCorpus, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取评估语料失败：%w", err)
+	}
+	corpus := &evalCorpus{}
+	if err := json.Unmarshal(data, corpus); err != nil {
+		return nil, fmt.Errorf("解析评估语料失败：%w", err)
+	}
+	if len(corpus.Cases) == 0 {
+		return nil, fmt.Errorf("评估语料 %s 没有用例", path)
+	}
+	for i, c := range corpus.Cases {
+		if strings.TrimSpace(c.Query) == "" {
+			return nil, fmt.Errorf("评估语料 cases[%d] 的 query 为空", i)
+		}
+		if c.Expect.Hours < 0 {
+			return nil, fmt.Errorf("评估语料 cases[%d] 的 hours 不能为负数", i)
+		}
+	}
+	return corpus, nil
+}
+
+// queryIntent 转换为查询意图，未标注时间范围时使用默认时间范围
+func (e evalIntent) queryIntent(defaultTimeRange int) *agent.QueryIntent {
+	intent := &agent.QueryIntent{
+		IsFailed:       e.Failed,
+		IsSSL:          e.SSL || e.CertExpiring,
+		CertExpiring:   e.CertExpiring,
+		IsTCP:          e.TCP,
+		TargetKeywords: append([]string{}, e.Targets...),
+		TimeRangeHours: e.Hours,
+	}
+	if intent.TimeRangeHours <= 0 {
+		intent.TimeRangeHours = defaultTimeRange
+	}
+	return intent
+}
+
+// compareFacet 比较一个维度：布尔维度按正例计数，目标关键词按集合计数，时间范围按是否相同计数
+func compareFacet(name string, got, want *agent.QueryIntent) evalCounts {
+	var c evalCounts
+	count := func(g, w bool) {
+		switch {
+		case g && w:
+			c.tp++
+		case g:
+			c.fp++
+		case w:
+			c.fn++
+		}
+	}
+	switch name {
+	case "failed":
+		count(got.IsFailed, want.IsFailed)
+	case "ssl":
+		count(got.IsSSL, want.IsSSL)
+	case "certExpiring":
+		count(got.CertExpiring, want.CertExpiring)
+	case "tcp":
+		count(got.IsTCP, want.IsTCP)
+	case "target":
+		wanted := map[string]bool{}
+		for _, kw := range want.TargetKeywords {
+			wanted[strings.ToLower(kw)] = true
+		}
+		seen := map[string]bool{}
+		for _, kw := range got.TargetKeywords {
+			kw = strings.ToLower(kw)
+			if seen[kw] {
+				continue
+			}
+			seen[kw] = true
+			count(true, wanted[kw])
+		}
+		for kw := range wanted {
+			if !seen[kw] {
+				c.fn++
+			}
+		}
+	case "hours":
+		if got.TimeRangeHours == want.TimeRangeHours {
+			c.tp++
+		} else {
+			c.fp++
+			c.fn++
+		}
+	}
+	return c
+}
+
+// compareResults 比较检索结果：按标注意图检索到的结果为相关结果
+func compareResults(got, want []*core.MonitorResult) evalCounts {
+	relevant := make(map[uint64]bool, len(want))
+	for _, r := range want {
+		relevant[r.ID] = true
+	}
+	var c evalCounts
+	for _, r := range got {
+		if relevant[r.ID] {
+			c.tp++
+			delete(relevant, r.ID)
+		} else {
+			c.fp++
+		}
+	}
+	c.fn = len(relevant)
+	return c
+}
+
+// describeIntent 查询意图的简要描述，用于输出不一致的用例
+func describeIntent(intent *agent.QueryIntent) string {
+	var parts []string
+	if intent.IsFailed {
+		parts = append(parts, "failed")
+	}
+	if intent.IsSSL {
+		parts = append(parts, "ssl")
+	}
+	if intent.CertExpiring {
+		parts = append(parts, "certExpiring")
+	}
+	if intent.IsTCP {
+		parts = append(parts, "tcp")
+	}
+	if len(intent.TargetKeywords) > 0 {
+		parts = append(parts, "target="+strings.Join(intent.TargetKeywords, ","))
+	}
+	parts = append(parts, fmt.Sprintf("hours=%d", intent.TimeRangeHours))
+	return strings.Join(parts, " ")
+}
+
+// evalSeedResults 按 seed 子命令的规则在内存中生成模拟结果，结果带有编号与目标标签
+func evalSeedResults(opts seedOptions) []*core.MonitorResult {
+	now := time.Now().Truncate(time.Minute)
+	rng := rand.New(rand.NewSource(opts.seed))
+	var results []*core.MonitorResult
+	for i, t := range planSeedTargets(rng, opts, now) {
+		for _, r := range seedResults(rand.New(rand.NewSource(opts.seed+int64(i)+1)), t, opts, now) {
+			r.Tags = t.target.Tags
+			results = append(results, r)
+		}
+	}
+	sort.Slice(results, func(a, b int) bool { return results[a].CheckedAt.Before(results[b].CheckedAt) })
+	for i, r := range results {
+		r.ID = uint64(i + 1)
+	}
+	return results
+}
+
+// searchInMemory 将查询意图转换为查询 DSL 后在内存结果中过滤，条件与数据库检索一致（不限制返回条数）
+func searchInMemory(results []*core.MonitorResult, intent *agent.QueryIntent) ([]*core.MonitorResult, error) {
+	q := intent.Query(0)
+	if err := q.Normalize(); err != nil {
+		return nil, err
+	}
+	var matched []*core.MonitorResult
+	for _, r := range results {
+		if q.Match(r) {
+			matched = append(matched, r)
+		}
+	}
+	return matched, nil
+}
+
+// percent 百分比，总数为 0 时为 0
+func percent(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(n) * 100 / float64(total)
+}
@@ -0,0 +1,302 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"math/rand"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"servicetelemetry/config"
+	"servicetelemetry/core"
+	"servicetelemetry/storage"
+)
+
+// seedDomain 生成的模拟目标使用的域名后缀（.example 为保留顶级域，不会解析到真实主机），清理时按此后缀识别
+const seedDomain = ".seed.example"
+
+// seedTags 模拟目标的业务标签
+var seedTags = []string{"payments", "search", "auth", "checkout", "internal-tools", "cdn"}
+
+// seedErrors 模拟故障的错误类型、状态码、错误信息与耗时（毫秒，0 表示使用正常耗时）
+var seedErrors = []struct {
+	errType string
+	code    int
+	msg     string
+	latency float64
+}{
+	{string(core.ErrorTypeTimeout), 0, "请求超时：context deadline exceeded", 10000},
+	{string(core.ErrorTypeNetwork), 0, "网络连接失败：connect: connection refused", 3},
+	{string(core.ErrorTypeHTTP), http.StatusServiceUnavailable, "HTTP状态码异常：503", 0},
+	{string(core.ErrorTypeHTTP), http.StatusBadGateway, "HTTP状态码异常：502", 0},
+	{string(core.ErrorTypeKeyword), http.StatusOK, "响应体未包含关键词", 0},
+}
+
+// seedOptions seed 子命令参数
+type seedOptions struct {
+	targets     int
+	days        int
+	interval    time.Duration
+	concurrency int
+	seed        int64
+	purge       bool
+}
+
+// seedTarget 模拟目标及其生成参数
+type seedTarget struct {
+	target    *core.MonitorTarget
+	baseMs    float64      // 正常响应耗时基线（毫秒）
+	certDays  int          // 当前证书剩余天数，仅 HTTPS 目标
+	incidents []seedOutage // 历史故障区间
+}
+
+// seedOutage 一次模拟故障
+type seedOutage struct {
+	start, end time.Time
+	errIndex   int
+}
+
+// RunSeed 执行 seed 子命令：向配置的数据库写入一批模拟目标与数周的历史结果（含故障与证书即将过期），
+// 供演示、前端开发与查询性能测试使用，无需真实的被监控服务
+// args：子命令参数，如 -n 50 -days 21 -interval 5m；-purge 只清理此前生成的数据
+// 返回进程退出码
+func RunSeed(args []string) int {
+	cfg := config.DefaultConfig()
+	opts := seedOptions{}
+
+	fs := flag.NewFlagSet("seed", flag.ContinueOnError)
+	fs.IntVar(&opts.targets, "n", 30, "生成的模拟目标数")
+	fs.IntVar(&opts.days, "days", 21, "生成的历史天数")
+	fs.DurationVar(&opts.interval, "interval", 5*time.Minute, "模拟检查间隔（决定结果条数：目标数 × 天数 × 每天检查次数）")
+	fs.IntVar(&opts.concurrency, "c", 8, "写入并发数（按目标并发，同一目标的结果按时间顺序写入）")
+	fs.Int64Var(&opts.seed, "seed", 1, "随机种子，相同种子生成相同的数据")
+	fs.BoolVar(&opts.purge, "purge", false, "只清理此前生成的模拟目标与结果，不生成新数据")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if !opts.purge && (opts.targets <= 0 || opts.days <= 0 || opts.interval < time.Minute || opts.concurrency <= 0) {
+		fmt.Fprintln(os.Stderr, "目标数、天数与写入并发数必须大于0，检查间隔不能小于1分钟")
+		return 2
+	}
+
+	st, err := storage.NewMySQLStorage(&cfg.DB)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "连接数据库失败：", err)
+		return 1
+	}
+	defer st.Close()
+
+	// 重复执行时先清理上次生成的数据，避免同一时间点出现重复结果
+	removed, purged, err := purgeSeed(st)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "清理模拟数据失败：", err)
+		return 1
+	}
+	if removed > 0 {
+		fmt.Printf("已清理此前生成的 %d 个模拟目标与 %d 条结果\n", removed, purged)
+	}
+	if opts.purge {
+		return 0
+	}
+
+	now := time.Now().Truncate(time.Minute)
+	rng := rand.New(rand.NewSource(opts.seed))
+	targets := planSeedTargets(rng, opts, now)
+	for _, t := range targets {
+		if err := st.SaveTarget(t.target); err != nil {
+			fmt.Fprintln(os.Stderr, "保存模拟目标失败：", err)
+			return 1
+		}
+	}
+
+	perTarget := int(time.Duration(opts.days) * 24 * time.Hour / opts.interval)
+	fmt.Printf("开始生成：%d 个模拟目标，%d 天，检查间隔 %s，共约 %d 条结果\n",
+		len(targets), opts.days, opts.interval, perTarget*len(targets))
+
+	var written, failed int64
+	start := time.Now()
+	limiter := core.NewConcurrencyLimiter(opts.concurrency)
+	var wg sync.WaitGroup
+	for i, t := range targets {
+		limiter.Acquire()
+		wg.Add(1)
+		// 每个目标使用独立的随机源，结果与写入并发无关
+		go func(t *seedTarget, rng *rand.Rand) {
+			defer limiter.Release()
+			defer wg.Done()
+			for _, r := range seedResults(rng, t, opts, now) {
+				if err := st.SaveResult(r); err != nil {
+					if atomic.AddInt64(&failed, 1) == 1 {
+						fmt.Fprintln(os.Stderr, "写入结果失败：", err)
+					}
+					continue
+				}
+				if n := atomic.AddInt64(&written, 1); n%10000 == 0 {
+					fmt.Printf("已写入 %d 条结果（%.0f 条/秒）\n", n, float64(n)/time.Since(start).Seconds())
+				}
+			}
+		}(t, rand.New(rand.NewSource(opts.seed+int64(i)+1)))
+	}
+	wg.Wait()
+
+	outages := 0
+	for _, t := range targets {
+		outages += len(t.incidents)
+	}
+	fmt.Printf("生成完成：写入 %d 条结果（失败 %d 条），包含 %d 次模拟故障，耗时 %s\n",
+		written, failed, outages, time.Since(start).Round(time.Second))
+	fmt.Printf("模拟目标的域名均以 %s 结尾；开启定时调度时这些目标会被实际检查并失败，演示时建议关闭 monitor.scheduler\n", seedDomain)
+	fmt.Println("清理：servicetelemetry seed -purge")
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+// planSeedTargets 生成模拟目标：以 HTTPS 为主，混合 HTTP、TCP 与 DNS，各自带有耗时基线、标签与历史故障
+func planSeedTargets(rng *rand.Rand, opts seedOptions, now time.Time) []*seedTarget {
+	from := now.Add(-time.Duration(opts.days) * 24 * time.Hour)
+	targets := make([]*seedTarget, opts.targets)
+	for i := range targets {
+		host := fmt.Sprintf("svc-%03d%s", i+1, seedDomain)
+		t := &seedTarget{baseMs: 20 + rng.ExpFloat64()*120}
+
+		var url string
+		switch p := rng.Float64(); {
+		case p < 0.7:
+			url = "https://" + host + "/health"
+			// 大部分证书剩余 30~90 天，少数即将过期，用于演示证书预警
+			t.certDays = 30 + rng.Intn(60)
+			if rng.Float64() < 0.15 {
+				t.certDays = rng.Intn(7)
+			}
+		case p < 0.8:
+			url = "http://" + host + "/status"
+		case p < 0.92:
+			url = "tcp://" + host + ":5432"
+			t.baseMs /= 4
+		default:
+			url = "dns://" + host + "?type=A"
+			t.baseMs /= 3
+		}
+
+		tags := []string{seedTags[rng.Intn(len(seedTags))]}
+		if rng.Float64() < 0.3 {
+			if extra := seedTags[rng.Intn(len(seedTags))]; extra != tags[0] {
+				tags = append(tags, extra)
+			}
+		}
+		t.target = &core.MonitorTarget{URL: url, IsCurrent: true, Priority: "normal", Tags: tags}
+
+		// 平均每周约一次故障，少数目标明显不稳定；故障持续 5 分钟到 2 小时，偏向较短
+		rate := float64(opts.days) / 7
+		if rng.Float64() < 0.1 {
+			rate *= 4
+		}
+		count := poisson(rng, rate)
+		for k := 0; k < count; k++ {
+			start := from.Add(time.Duration(rng.Int63n(int64(now.Sub(from)))))
+			duration := time.Duration(5+rng.ExpFloat64()*25) * time.Minute
+			if duration > 2*time.Hour {
+				duration = 2 * time.Hour
+			}
+			t.incidents = append(t.incidents, seedOutage{start: start, end: start.Add(duration), errIndex: rng.Intn(len(seedErrors))})
+		}
+		sort.Slice(t.incidents, func(a, b int) bool { return t.incidents[a].start.Before(t.incidents[b].start) })
+		targets[i] = t
+	}
+	return targets
+}
+
+// seedResults 按检查间隔生成目标的历史结果：工作时间耗时偏高，故障区间内返回对应错误，另有少量偶发的单次失败
+func seedResults(rng *rand.Rand, t *seedTarget, opts seedOptions, now time.Time) []*core.MonitorResult {
+	from := now.Add(-time.Duration(opts.days) * 24 * time.Hour)
+	// 各目标的检查时间错开，更接近调度器的实际情况
+	at := from.Add(time.Duration(rng.Int63n(int64(opts.interval))))
+	var results []*core.MonitorResult
+	outage := 0
+	for ; at.Before(now); at = at.Add(opts.interval) {
+		for outage < len(t.incidents) && !at.Before(t.incidents[outage].end) {
+			outage++
+		}
+
+		r := &core.MonitorResult{
+			TargetURL: t.target.URL,
+			Status:    "success",
+			CheckedAt: at,
+		}
+		// 耗时：基线 × 日内波动（14 点左右最高）× 对数正态噪声
+		hour := float64(at.Hour()) + float64(at.Minute())/60
+		diurnal := 1 + 0.35*math.Max(0, math.Cos((hour-14)/24*2*math.Pi))
+		r.ResponseTime = math.Round(t.baseMs*diurnal*math.Exp(rng.NormFloat64()*0.25)*10) / 10
+
+		if strings.HasPrefix(t.target.URL, "http") {
+			r.StatusCode = http.StatusOK
+			r.KeywordMatched = true
+		}
+		if strings.HasPrefix(t.target.URL, "https") {
+			days := t.certDays + int(now.Sub(at).Hours()/24)
+			r.SetCertExpiry(at.Add(time.Duration(days)*24*time.Hour+time.Hour), at)
+		}
+
+		failing := outage < len(t.incidents) && !at.Before(t.incidents[outage].start)
+		if failing || rng.Float64() < 0.002 {
+			e := seedErrors[rng.Intn(len(seedErrors))]
+			if failing {
+				e = seedErrors[t.incidents[outage].errIndex]
+			}
+			if !strings.HasPrefix(t.target.URL, "http") && e.code != 0 {
+				e = seedErrors[0]
+			}
+			r.Status, r.ErrorType, r.ErrorMsg = "failed", e.errType, e.msg
+			r.StatusCode, r.KeywordMatched = e.code, false
+			if e.latency > 0 {
+				r.ResponseTime = e.latency
+			}
+		}
+		results = append(results, r)
+	}
+	return results
+}
+
+// purgeSeed 删除此前生成的模拟目标及其结果、当前状态与状态变化记录，返回删除的目标数与结果条数
+func purgeSeed(st *storage.MySQLStorage) (int, int64, error) {
+	targets, err := st.ListTargets(false)
+	if err != nil {
+		return 0, 0, err
+	}
+	removed, purged := 0, int64(0)
+	for _, t := range targets {
+		if !strings.HasSuffix(core.TargetHost(t.URL), seedDomain) {
+			continue
+		}
+		n, err := st.PurgeResults(t.URL)
+		if err != nil {
+			return removed, purged, err
+		}
+		if _, err := st.DeleteTarget(t.URL); err != nil {
+			return removed, purged, err
+		}
+		removed++
+		purged += n
+	}
+	return removed, purged, nil
+}
+
+// poisson 按泊松分布抽样（Knuth 算法，适用于较小的均值）
+func poisson(rng *rand.Rand, mean float64) int {
+	limit, k, p := math.Exp(-mean), 0, 1.0
+	for {
+		p *= rng.Float64()
+		if p <= limit {
+			return k
+		}
+		k++
+	}
+}
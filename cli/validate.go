@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"servicetelemetry/config"
+	"servicetelemetry/core"
+)
+
+// RunValidate 执行 validate 子命令：加载声明式目标定义并静态校验，不发起实际检查，适用于 CI 合并前校验
+// args：子命令参数，如 -f targets.json
+// 返回进程退出码，存在问题时返回 1
+func RunValidate(args []string) int {
+	fs := flag.NewFlagSet("validate", flag.ContinueOnError)
+	file := fs.String("f", "targets.json", "声明式目标定义文件路径")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	spec, err := config.LoadTargetSpec(*file)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	problems := ValidateSpec(spec)
+	for _, p := range problems {
+		fmt.Println(p)
+	}
+
+	if len(problems) > 0 {
+		fmt.Printf("校验未通过：%d 个目标，发现 %d 个问题\n", len(spec.Targets), len(problems))
+		return 1
+	}
+	fmt.Printf("校验通过：%d 个目标\n", len(spec.Targets))
+	return 0
+}
+
+// ValidateSpec 校验声明式目标定义，返回格式化后的问题列表
+// 校验内容：目标定义本身（地址、协议、优先级、断言）、地址是否重复、凭据引用能否解析
+func ValidateSpec(spec *config.TargetSpec) []string {
+	var problems []string
+	seen := make(map[string]int)
+
+	for i, def := range spec.Targets {
+		prefix := fmt.Sprintf("targets[%d] %s：", i, def.URL)
+
+		for _, err := range core.ValidateTarget(core.TargetFromDefinition(def)) {
+			problems = append(problems, prefix+err.Error())
+		}
+
+		key := strings.ToLower(strings.TrimSpace(def.URL))
+		if first, ok := seen[key]; ok {
+			problems = append(problems, fmt.Sprintf("%s与 targets[%d] 地址重复", prefix, first))
+		} else {
+			seen[key] = i
+		}
+
+		refs := def.TargetOptions.SecretRefs()
+		names := make([]string, 0, len(refs))
+		for name := range refs {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if _, err := config.ResolveSecret(refs[name]); err != nil {
+				problems = append(problems, fmt.Sprintf("%s凭据 %s 无法解析：%v", prefix, name, err))
+			}
+		}
+	}
+
+	return problems
+}
@@ -9,40 +9,364 @@ import (
 
 // GlobalConfig 全局配置结构体，包含所有模块的配置信息
 type GlobalConfig struct {
-	Monitor MonitorConfig `json:"monitor"` // 服务监控配置
-	DB      DBConfig      `json:"db"`      // 数据库配置
-	Agent   AgentConfig   `json:"agent"`   // 小助手配置
+	Monitor       MonitorConfig      `json:"monitor"`       // 服务监控配置
+	DB            DBConfig           `json:"db"`            // 数据库配置
+	Agent         AgentConfig        `json:"agent"`         // 小助手配置
+	ChatOps       ChatOpsConfig      `json:"chatops"`       // 聊天工具斜杠命令配置
+	Alert         AlertConfig        `json:"alert"`         // 告警通知配置
+	API           APIConfig          `json:"api"`           // HTTP 接口配置
+	Events        EventBusConfig     `json:"events"`        // 事件总线配置
+	CT            CTConfig           `json:"ct"`            // 证书透明度日志监控配置
+	Snapshots     SnapshotConfig     `json:"snapshots"`     // 配置快照与回滚配置
+	Stats         StatsConfig        `json:"stats"`         // 统计接口配置
+	Subscriptions SubscriptionConfig `json:"subscriptions"` // 状态更新订阅配置
+	Failover      FailoverConfig     `json:"failover"`      // 故障切换路径演练配置
+	Remediation   RemediationConfig  `json:"remediation"`   // 自动处置配置
+	Probes        ProbeConfig        `json:"probes"`        // 探测节点与中心服务之间的双向 TLS 认证配置
+}
+
+// ProbeConfig 探测节点与中心服务之间的双向 TLS（mTLS）认证配置：
+// 中心服务在独立端口上提供探测节点接口，只接受由探测节点 CA 签发、未被吊销的客户端证书，证书 CN 即探测节点名称；
+// 探测节点以各自的证书访问中心服务。部署在不可信网络中的探测节点泄露证书时，吊销该证书即可
+type ProbeConfig struct {
+	Listen         string   `json:"listen"`         // 中心服务：探测节点接口的 TLS 监听地址（如 :8443），为空表示不开启
+	CertFile       string   `json:"certFile"`       // 中心服务：服务端证书
+	KeyFile        string   `json:"keyFile"`        // 中心服务：服务端私钥
+	ClientCAFile   string   `json:"clientCAFile"`   // 中心服务：签发探测节点证书的 CA（PEM，可包含多个）
+	CRLFile        string   `json:"crlFile"`        // 中心服务：CA 签发的证书吊销列表（PEM 或 DER），文件更新后自动重新加载
+	RevokedSerials []string `json:"revokedSerials"` // 中心服务：额外吊销的证书序列号（十六进制，可带冒号）
+	Allowed        []string `json:"allowed"`        // 中心服务：允许接入的探测节点名称（证书 CN），为空表示 CA 签发的证书均可接入
+	// 中心服务：超过该时长未收到心跳的探测节点视为离线
+	OfflineAfter time.Duration `json:"offlineAfter"`
+	// 中心服务：各更新渠道期望的探测节点版本（渠道名 -> 版本清单），探测节点按心跳中报告的渠道获取，默认渠道为 stable
+	Channels map[string]ReleaseManifest `json:"channels"`
+	Central  CentralConfig              `json:"central"` // 探测节点：访问中心服务的配置
+}
+
+// ReleaseManifest 探测节点版本清单：期望的版本及其安装包下载地址与摘要
+type ReleaseManifest struct {
+	Version string `json:"version"`          // 期望的版本号
+	URL     string `json:"url,omitempty"`    // 安装包下载地址（http:// 或 https://），为空时探测节点只报告版本落后，不自动下载
+	SHA256  string `json:"sha256,omitempty"` // 安装包的 SHA-256 摘要（十六进制），配置 url 时必填
+	Notes   string `json:"notes,omitempty"`  // 版本说明
+}
+
+// CentralConfig 探测节点访问中心服务的配置
+type CentralConfig struct {
+	URL      string        `json:"url"`      // 中心服务探测节点接口地址（如 https://central.example.com:8443），为空表示不接入中心服务
+	CertFile string        `json:"certFile"` // 本探测节点的客户端证书
+	KeyFile  string        `json:"keyFile"`  // 本探测节点的客户端私钥
+	CAFile   string        `json:"caFile"`   // 校验中心服务证书的 CA，为空时使用系统根证书
+	Timeout  time.Duration `json:"timeout"`  // 请求超时
+	// 心跳间隔，心跳中报告本节点的版本与支持的检查类型
+	HeartbeatInterval time.Duration `json:"heartbeatInterval"`
+	Channel           string        `json:"channel"`   // 更新渠道，默认 stable
+	UpdateDir         string        `json:"updateDir"` // 新版本安装包的下载目录，为空时只记录日志不下载；替换与重启由进程管理工具完成
+}
+
+// RemediationConfig 自动处置配置：目标进入失败状态时执行预先定义的处置动作（调用 Webhook、触发 Jenkins 任务、执行脚本），
+// 如重启已知不稳定的服务；目标只能按名称引用这里定义的动作，通过接口提交的目标无法执行任意命令
+type RemediationConfig struct {
+	Enable   bool                         `json:"enable"`   // 是否开启
+	Cooldown time.Duration                `json:"cooldown"` // 同一目标同一动作两次执行的最短间隔，冷却期内跳过并记录
+	Timeout  time.Duration                `json:"timeout"`  // 单次动作的执行超时
+	Actions  map[string]RemediationAction `json:"actions"`  // 处置动作（名称 -> 动作）
+}
+
+// RemediationAction 处置动作
+type RemediationAction struct {
+	Type          string            `json:"type"`          // 动作类型：webhook / jenkins / script
+	URL           string            `json:"url"`           // Webhook 地址，或 Jenkins 任务地址（如 https://jenkins.example.com/job/restart-api）
+	Headers       map[string]string `json:"headers"`       // Webhook 附加请求头，值支持 env: / file: 引用
+	User          string            `json:"user"`          // Jenkins 用户名
+	Token         string            `json:"token"`         // Jenkins API Token，支持 env: / file: 引用
+	Command       []string          `json:"command"`       // 脚本命令及参数（script），命令必须为绝对路径
+	AfterFailures int               `json:"afterFailures"` // 连续失败达到该次数时执行，0 或 1 表示进入失败状态即执行
+	Cooldown      time.Duration     `json:"cooldown"`      // 该动作的冷却时间，0 表示使用全局配置
+}
+
+// FailoverConfig 故障切换路径演练配置：定期检查备用 / 容灾入口，确认故障切换 DNS 或负载均衡备用池确实可以提供服务，
+// 并对比主备响应的差异，避免在真正切换时才发现备用路径不可用
+type FailoverConfig struct {
+	Enable       bool           `json:"enable"`       // 是否开启
+	Interval     time.Duration  `json:"interval"`     // 演练间隔
+	LatencyRatio float64        `json:"latencyRatio"` // 备用路径耗时超过主路径的倍数时记为差异，0 表示不比较耗时
+	Pairs        []FailoverPair `json:"pairs"`        // 主备路径列表
+}
+
+// FailoverPair 一组主备路径
+// 备用路径可以是容灾入口地址，也可以是主路径地址配合 hostHeader / sni 直接访问备用池中的节点
+type FailoverPair struct {
+	Name      string           `json:"name"`      // 名称
+	Primary   TargetDefinition `json:"primary"`   // 主路径
+	Secondary TargetDefinition `json:"secondary"` // 备用路径
+}
+
+// SubscriptionConfig 状态更新订阅配置，用户可通过邮件或 Webhook 订阅组件（目标标签）的事件开启 / 更新 / 恢复通知
+type SubscriptionConfig struct {
+	Enable      bool          `json:"enable"`      // 是否开启状态订阅
+	PublicURL   string        `json:"publicURL"`   // 服务对外访问地址，用于生成确认与退订链接，如 https://status.example.com
+	SendTimeout time.Duration `json:"sendTimeout"` // 单次通知发送超时时间
+	SMTP        SMTPConfig    `json:"smtp"`        // 邮件发送配置，未配置时不支持邮件订阅
+}
+
+// SMTPConfig 邮件发送配置
+type SMTPConfig struct {
+	Host     string `json:"host"`     // SMTP 服务器地址
+	Port     int    `json:"port"`     // SMTP 端口，默认 25（支持 STARTTLS）
+	Username string `json:"username"` // 认证用户名，为空时不认证
+	Password string `json:"password"` // 认证密码
+	From     string `json:"from"`     // 发件人地址
+}
+
+// StatsConfig 统计接口配置
+type StatsConfig struct {
+	HealthWindow   time.Duration      `json:"healthWindow"`   // 健康分的统计窗口
+	BaselineWindow time.Duration      `json:"baselineWindow"` // 延迟基线窗口（统计窗口之前的这段时间）
+	HealthWeights  HealthWeights      `json:"healthWeights"`  // 健康分各分量的权重
+	Calendars      []BusinessCalendar `json:"calendars"`      // 工作时间日历，用于按工作时间统计可用率
+}
+
+// BusinessCalendar 工作时间日历，按标签关联到目标，只统计工作时间内的可用率（部分内部工具的 SLA 只计工作时间）
+type BusinessCalendar struct {
+	Name     string   `json:"name"`     // 日历名称
+	Tags     []string `json:"tags"`     // 适用的目标标签
+	Timezone string   `json:"timezone"` // 时区，如 Asia/Shanghai，为空时使用本机时区
+	Days     []string `json:"days"`     // 工作星期，如 ["mon", "tue", "wed", "thu", "fri"]（默认周一至周五）
+	Start    string   `json:"start"`    // 工作开始时间 HH:MM（默认 09:00）
+	End      string   `json:"end"`      // 工作结束时间 HH:MM（默认 18:00），早于开始时间表示跨越午夜
+	Holidays []string `json:"holidays"` // 节假日 yyyy-mm-dd，当天不计工作时间
+	Workdays []string `json:"workdays"` // 调休工作日 yyyy-mm-dd，即使不在工作星期内也计工作时间
+}
+
+// HealthWeights 健康分各分量的权重，按权重加权平均
+type HealthWeights struct {
+	Availability float64 `json:"availability"` // 可用率
+	Latency      float64 `json:"latency"`      // 延迟相对基线的劣化程度
+	Incidents    float64 `json:"incidents"`    // 近期故障次数
+	Certificate  float64 `json:"certificate"`  // 证书状态
+}
+
+// SnapshotConfig 配置快照配置，定期检查生效配置与声明式目标定义，内容变化时保存新版本
+type SnapshotConfig struct {
+	Enable      bool          `json:"enable"`      // 是否开启
+	Interval    time.Duration `json:"interval"`    // 检查间隔
+	MaxVersions int           `json:"maxVersions"` // 每类快照最多保留的版本数，超出后删除最旧的版本
+}
+
+// CTConfig 证书透明度（Certificate Transparency）日志监控配置，发现非预期签发的证书时告警
+type CTConfig struct {
+	Enable          bool          `json:"enable"`          // 是否开启
+	Domains         []string      `json:"domains"`         // 需要监控的自有域名（包含全部子域名）
+	ExpectedIssuers []string      `json:"expectedIssuers"` // 预期的签发机构（匹配签发者名称中的关键词，如 Let's Encrypt），为空时所有新证书均告警
+	PollInterval    time.Duration `json:"pollInterval"`    // 轮询间隔
+	SourceURL       string        `json:"sourceURL"`       // CT 日志检索服务地址（crt.sh 兼容接口）
+	Timeout         time.Duration `json:"timeout"`         // 请求超时
+}
+
+// EventBusConfig 事件总线配置，将检查结果、状态变化与告警事件发布到消息队列
+type EventBusConfig struct {
+	Enable      bool          `json:"enable"`      // 是否开启事件发布
+	Driver      string        `json:"driver"`      // 消息队列类型：nats / kafka（REST Proxy）/ rabbitmq（管理接口）
+	URL         string        `json:"url"`         // 连接地址，如 nats://127.0.0.1:4222、http://kafka-rest:8082、http://rabbitmq:15672
+	Username    string        `json:"username"`    // 认证用户名（可选）
+	Password    string        `json:"password"`    // 认证密码（可选）
+	VHost       string        `json:"vhost"`       // RabbitMQ 虚拟主机，默认 /
+	Exchange    string        `json:"exchange"`    // RabbitMQ 交换机，默认 amq.topic
+	TopicPrefix string        `json:"topicPrefix"` // 主题前缀，实际主题为 <前缀>.results / .transitions / .incidents
+	BufferSize  int           `json:"bufferSize"`  // 发送缓冲区大小，缓冲区满时丢弃事件
+	Timeout     time.Duration `json:"timeout"`     // 连接与发送超时
+
+	RegistrationTopic string `json:"registrationTopic"` // 目标注册主题（RabbitMQ 为队列名），配置后消费注册 / 注销消息，与 enable 无关
+	ConsumerGroup     string `json:"consumerGroup"`     // 消费者组（Kafka 消费者组、NATS 队列组），多实例部署时每条消息只处理一次
+}
+
+// APIConfig HTTP 接口配置
+type APIConfig struct {
+	DisableLegacy         bool          `json:"disableLegacy"`         // 是否关闭旧版（无版本前缀的 /api/...）兼容路由
+	LegacySunset          string        `json:"legacySunset"`          // 旧版路由计划下线日期（HTTP-date 格式），通过 Sunset 响应头告知调用方
+	Gzip                  bool          `json:"gzip"`                  // 客户端支持时对响应进行 gzip 压缩
+	IdempotencyTTL        time.Duration `json:"idempotencyTTL"`        // 写接口 Idempotency-Key 的有效期，期内的重试直接返回首次响应，0 表示不开启
+	IdempotencyMaxEntries int           `json:"idempotencyMaxEntries"` // 进程内最多保存的幂等键数，超出时淘汰最久未使用的已完成条目，0 表示不限制
+	IdempotencyMaxBody    int64         `json:"idempotencyMaxBody"`    // 携带幂等键的请求体与缓存的响应体大小上限（字节），0 表示不限制
+	ChangesBuffer         int           `json:"changesBuffer"`         // 长轮询变更流保留的最近变更条数（状态变化与事件变更），0 表示关闭 /changes 接口
+	LongPollMaxWait       time.Duration `json:"longPollMaxWait"`       // 长轮询单次最长等待时间，应小于反向代理的读超时
+	Viewer                ViewerConfig  `json:"viewer"`                // 查看者角色接口（只读聚合数据，供合作方或信任度较低的内部大屏使用）
+	AdminTokens           []string      `json:"adminTokens"`           // 管理员令牌（支持 env: / file: 引用），访问订阅列表等敏感接口时需携带，为空时这些接口不可用
+}
+
+// ViewerConfig 查看者角色接口配置：在单独的端口上只提供聚合数据的只读接口，
+// 响应中移除原始错误信息、地址中的查询参数与凭据相关元数据
+type ViewerConfig struct {
+	Listen string   `json:"listen"` // 监听地址（如 :8090），为空表示不开启
+	Tokens []string `json:"tokens"` // 访问令牌（支持 env: / file: 引用），为空时无需认证（如公开大屏）
 }
 
 // MonitorConfig 服务监控配置，控制检查的并发、超时等参数
 type MonitorConfig struct {
-	Concurrency   int           `json:"concurrency"`   // 最大并发检查数，避免同时请求过多目标
-	CheckInterval time.Duration `json:"checkInterval"` // 监控检查间隔，定时刷新监控结果
-	HTTPTimeout   time.Duration `json:"httpTimeout"`   // HTTP请求超时时间
-	TCPTimeout    time.Duration `json:"tcpTimeout"`    // TCP连接超时时间
-	MaxRetry      int           `json:"maxRetry"`      // 目标检查失败后的最大重试次数
-	MaxBodySize   int64         `json:"maxBodySize"`   // HTTP响应体最大读取大小，防止内存溢出（1MB）
-	LogLevel      string        `json:"logLevel"`      // 新增：日志级别
-	CacheTTL      time.Duration `json:"cacheTTL"`      // 新增：监控结果缓存过期时间
+	Concurrency         int                         `json:"concurrency"`         // 最大并发检查数，避免同时请求过多目标
+	CheckInterval       time.Duration               `json:"checkInterval"`       // 监控检查间隔，定时刷新监控结果
+	HTTPTimeout         time.Duration               `json:"httpTimeout"`         // HTTP请求超时时间
+	TCPTimeout          time.Duration               `json:"tcpTimeout"`          // TCP连接超时时间
+	UDPTimeout          time.Duration               `json:"udpTimeout"`          // UDP检查等待响应的超时时间
+	GRPCTimeout         time.Duration               `json:"grpcTimeout"`         // gRPC健康检查超时时间
+	SSHTimeout          time.Duration               `json:"sshTimeout"`          // SSH检查超时时间（连接、版本交换与密钥交换）
+	RedisTimeout        time.Duration               `json:"redisTimeout"`        // Redis检查超时时间（连接、认证与 PING）
+	DatabaseTimeout     time.Duration               `json:"databaseTimeout"`     // 数据库检查超时时间（连接、认证与 SELECT 1）
+	KafkaTimeout        time.Duration               `json:"kafkaTimeout"`        // Kafka检查超时时间（连接、ApiVersions 与 Metadata 请求）
+	MQTTTimeout         time.Duration               `json:"mqttTimeout"`         // MQTT检查超时时间（连接、TLS握手与 CONNECT / CONNACK）
+	FileTransferTimeout time.Duration               `json:"fileTransferTimeout"` // FTP / SFTP检查超时时间（连接、登录与列目录）
+	TLSTimeout          time.Duration               `json:"tlsTimeout"`          // TLS握手检查（tls://）超时时间（连接与握手）
+	MaxRetry            int                         `json:"maxRetry"`            // 目标检查失败后的最大重试次数
+	MaxBodySize         int64                       `json:"maxBodySize"`         // HTTP响应体最大读取大小，防止内存溢出（1MB）
+	LogLevel            string                      `json:"logLevel"`            // 新增：日志级别
+	LogModules          map[string]string           `json:"logModules"`          // 各模块单独的日志级别（如 {"core": "debug"}），未设置的模块使用 logLevel
+	CacheTTL            time.Duration               `json:"cacheTTL"`            // 新增：监控结果缓存过期时间
+	Scheduler           bool                        `json:"scheduler"`           // 是否开启定时调度，按 CheckInterval 周期检查全部有效目标
+	DryRun              bool                        `json:"dryRun"`              // 演练模式：定时调度只执行检查，不入库、不告警
+	TargetsFile         string                      `json:"targetsFile"`         // 声明式目标定义文件，启动时同步到数据库（可选）
+	WarmUpWindow        time.Duration               `json:"warmUpWindow"`        // 启动预热：从数据库加载该时间范围内各目标的最新结果，0 表示不预热
+	URLPolicy           URLPolicyConfig             `json:"urlPolicy"`           // 外部提交目标地址的安全校验策略
+	SourceIP            string                      `json:"sourceIP"`            // 默认源地址（目标未单独配置时使用），为空由系统路由决定
+	Interface           string                      `json:"interface"`           // 默认源网卡，与 sourceIP 二选一
+	Proxy               string                      `json:"proxy"`               // 默认出站代理（目标未单独配置时使用）：http(s):// 代理用于 HTTP 检查，socks5:// 代理用于全部基于 TCP 的检查，为空表示直连
+	Region              string                      `json:"region"`              // 本实例所在的探测区域（如 eu、us-east），限定了区域的目标只由对应区域的实例检查
+	Egress              EgressPolicyConfig          `json:"egress"`              // 出站网络策略，由检查器拨号时强制执行
+	TLSProfiles         map[string]TLSProfileConfig `json:"tlsProfiles"`         // 自定义 TLS 配置档，目标通过 tlsProfile 引用
+	OCSP                OCSPConfig                  `json:"ocsp"`                // 证书吊销检查配置
+	ICMP                ICMPConfig                  `json:"icmp"`                // ICMP（icmp://）检查配置
+	DNS                 DNSCheckConfig              `json:"dns"`                 // DNS（dns://）检查配置
+	SMTP                SMTPCheckConfig             `json:"smtp"`                // SMTP（smtp:// / smtps://）检查配置
+	Checksum            ChecksumCheckConfig         `json:"checksum"`            // 文件摘要校验配置
+	NTP                 NTPCheckConfig              `json:"ntp"`                 // NTP（ntp://）检查配置
+	DomainExpiry        DomainExpiryConfig          `json:"domainExpiry"`        // 域名注册到期检查配置（目标开启 domainExpiry 时生效）
+	Traceroute          TracerouteConfig            `json:"traceroute"`          // 网络错误或超时失败后的自动路由跟踪
+	ResponseSnapshot    ResponseSnapshotConfig      `json:"responseSnapshot"`    // HTTP 检查失败时保存的响应快照
+}
+
+// ResponseSnapshotConfig 响应快照配置：HTTP/HTTPS 检查失败（状态码异常、关键词缺失、断言失败等）时，
+// 将服务器实际返回的状态码、响应头与截断的响应体随结果入库
+type ResponseSnapshotConfig struct {
+	Enable       bool          `json:"enable"`       // 是否开启，默认关闭；只对声明式目标定义文件与本机创建的目标生效
+	MaxBodyBytes int           `json:"maxBodyBytes"` // 响应体最多保存的字节数，超出部分截断
+	Retention    time.Duration `json:"retention"`    // 快照保留时长，超出后清除快照（结果本身保留），0 表示不清除
+}
+
+// TracerouteConfig 自动路由跟踪配置：检查因网络错误或超时失败时跟踪到目标的路由，逐跳记录在结果诊断信息中
+type TracerouteConfig struct {
+	Enable     bool          `json:"enable"`     // 是否开启（需要 root 或 CAP_NET_RAW）
+	MaxHops    int           `json:"maxHops"`    // 最大跳数
+	HopTimeout time.Duration `json:"hopTimeout"` // 每跳等待应答的超时
+	Timeout    time.Duration `json:"timeout"`    // 单次跟踪的总时长上限，超出时保留已采集的跳
+	// 同时进行的路由跟踪数上限，达到上限时新的失败结果不再跟踪
+	MaxConcurrent int `json:"maxConcurrent"`
+}
+
+// DomainExpiryConfig 域名注册到期检查配置，优先通过 RDAP 查询，顶级域没有 RDAP 服务时回退到 WHOIS
+type DomainExpiryConfig struct {
+	BootstrapURL string        `json:"bootstrapURL"` // RDAP 引导文件地址（IANA 发布的顶级域与 RDAP 服务对应表）
+	WhoisServer  string        `json:"whoisServer"`  // 查询顶级域 WHOIS 服务器的根 WHOIS 服务器 host[:port]
+	Timeout      time.Duration `json:"timeout"`      // 单次 RDAP / WHOIS 查询超时
+	CacheTTL     time.Duration `json:"cacheTTL"`     // 查询结果缓存时长，注册信息变化很少，无需每次检查都查询
+}
+
+// NTPCheckConfig NTP 检查配置
+type NTPCheckConfig struct {
+	MaxOffset time.Duration `json:"maxOffset"` // 允许的最大时钟偏差，目标地址中的 maxOffset 参数优先
+	Timeout   time.Duration `json:"timeout"`   // 等待响应的超时
+}
+
+// ChecksumCheckConfig 文件摘要校验配置
+type ChecksumCheckConfig struct {
+	MaxSize int64         `json:"maxSize"` // 默认下载大小上限（字节），超过时判定失败
+	Timeout time.Duration `json:"timeout"` // 配置了摘要校验的目标的请求超时（含下载整个文件）
+}
+
+// DNSCheckConfig DNS 检查配置
+type DNSCheckConfig struct {
+	Resolver string        `json:"resolver"` // 默认解析服务器 ip[:port]，为空时使用 /etc/resolv.conf 中的第一个 nameserver
+	Timeout  time.Duration `json:"timeout"`  // 单次查询超时
+}
+
+// SMTPCheckConfig SMTP 检查配置
+type SMTPCheckConfig struct {
+	EHLOName string        `json:"ehloName"` // EHLO 命令使用的主机名
+	Timeout  time.Duration `json:"timeout"`  // 整个会话（连接、问候、EHLO、STARTTLS）的超时
+}
+
+// ICMPConfig ICMP 检查配置，每次检查发送多个回显请求，统计丢包率与往返时延
+type ICMPConfig struct {
+	Count         int           `json:"count"`         // 每次检查发送的回显请求数
+	Interval      time.Duration `json:"interval"`      // 相邻请求的发送间隔
+	Timeout       time.Duration `json:"timeout"`       // 单个请求等待回复的超时
+	LossThreshold float64       `json:"lossThreshold"` // 丢包率达到该值（百分比）判定为失败，低于该值的丢包记为警告
+}
+
+// TLSProfileConfig TLS 配置档，控制检查时的 TLS 版本范围、加密套件及证书校验
+type TLSProfileConfig struct {
+	MinVersion         string   `json:"minVersion"`         // 最低版本：1.0 / 1.1 / 1.2 / 1.3，默认 1.2
+	MaxVersion         string   `json:"maxVersion"`         // 最高版本，为空不限制
+	CipherSuites       []string `json:"cipherSuites"`       // 加密套件（IANA 名称），all 表示全部（含不安全套件），为空使用默认套件
+	InsecureSkipVerify bool     `json:"insecureSkipVerify"` // 跳过证书校验（结果中会持久化警告标记）
+}
+
+// OCSPConfig 证书吊销检查配置，检查结果默认以警告形式记录
+type OCSPConfig struct {
+	CheckRevocation bool          `json:"checkRevocation"` // 服务端未装订 OCSP 响应时，是否向证书中的 OCSP 服务器在线查询吊销状态
+	WarnNoStapling  bool          `json:"warnNoStapling"`  // 服务端未启用 OCSP Stapling 时是否给出警告
+	FailOnRevoked   bool          `json:"failOnRevoked"`   // HTTPS 检查的证书吊销状态为 revoked 或 unknown 时判定检查失败（错误类型 ssl_revoked）
+	Timeout         time.Duration `json:"timeout"`         // 在线查询超时
+}
+
+// EgressPolicyConfig 出站网络策略，作用于所有检查（包括声明式定义的目标）
+// 黑名单优先；配置任一白名单后，目标的域名或实际连接的 IP 必须命中白名单
+type EgressPolicyConfig struct {
+	AllowCIDRs   []string `json:"allowCIDRs"`   // 允许连接的地址段
+	DenyCIDRs    []string `json:"denyCIDRs"`    // 禁止连接的地址段
+	AllowDomains []string `json:"allowDomains"` // 允许连接的域名，*.example.com 或 .example.com 表示子域名
+	DenyDomains  []string `json:"denyDomains"`  // 禁止连接的域名
+}
+
+// URLPolicyConfig 目标地址安全校验策略（SSRF 防护），作用于接口提交与消息队列注册的目标
+type URLPolicyConfig struct {
+	BlockPrivate bool     `json:"blockPrivate"` // 是否禁止检查环回、私有网络、链路本地及云元数据地址
+	AllowCIDRs   []string `json:"allowCIDRs"`   // 放行的地址段，如 10.1.0.0/16（需要监控的内网服务）
+	AllowHosts   []string `json:"allowHosts"`   // 放行的主机名，以 . 开头表示后缀匹配，如 .corp.example.com
+	MaxURLLength int      `json:"maxURLLength"` // 地址最大长度
 }
 
 // DBConfig 数据库配置，用于连接MySQL数据库
 type DBConfig struct {
-	Host     string `json:"host"`     // 数据库地址
-	Port     int    `json:"port"`     // 数据库端口
-	User     string `json:"user"`     // 数据库用户名
-	Password string `json:"password"` // 数据库密码
-	DBName   string `json:"dbName"`   // 数据库名称
-	MaxOpen  int    `json:"maxOpen"`  // 新增：最大打开连接数
-	MaxIdle  int    `json:"maxIdle"`  // 新增：最大空闲连接数
+	Host               string        `json:"host"`               // 数据库地址
+	Port               int           `json:"port"`               // 数据库端口
+	User               string        `json:"user"`               // 数据库用户名
+	Password           string        `json:"password"`           // 数据库密码
+	DBName             string        `json:"dbName"`             // 数据库名称
+	MaxOpen            int           `json:"maxOpen"`            // 新增：最大打开连接数
+	MaxIdle            int           `json:"maxIdle"`            // 新增：最大空闲连接数
+	SlowQueryThreshold time.Duration `json:"slowQueryThreshold"` // 慢查询阈值，超过时记录 SQL 与参数（敏感字段脱敏），0 表示不记录
 }
 
 // AgentConfig 小助手配置，控制数据检索和AI总结的相关参数
 type AgentConfig struct {
-	EnableAI         bool      `json:"enableAI"`         // 是否开启AI总结功能
-	MaxRetrieve      int       `json:"maxRetrieve"`      // 最大检索数据条数，避免返回过多数据
-	DefaultTimeRange int       `json:"defaultTimeRange"` // 默认检索时间范围（小时），默认查询近24小时数据
-	LLM              LLMConfig `json:"llm"`              // LLM 配置，用于AI总结功能
+	EnableAI         bool              `json:"enableAI"`         // 是否开启AI总结功能
+	MaxRetrieve      int               `json:"maxRetrieve"`      // 最大检索数据条数，避免返回过多数据
+	DefaultTimeRange int               `json:"defaultTimeRange"` // 默认检索时间范围（小时），默认查询近24小时数据
+	IntentParser     string            `json:"intentParser"`     // 意图解析器名称：keyword（默认，按提问语言选择中英文规则）/ english / auto 或自定义注册的解析器
+	ServiceAliases   map[string]string `json:"serviceAliases"`   // 服务别名 → 目标地址关键词，如 "支付" → "pay.example.com"，内置解析器均生效
+	IntentOptions    map[string]string `json:"intentOptions"`    // 自定义意图解析器的专有参数
+	OutputFormat     string            `json:"outputFormat"`     // AI 回复的默认格式：plain / markdown / json，请求中的 format 优先
+	Chat             ChatConfig        `json:"chat"`             // 通用问答的角色设定与话题范围
+	LLM              LLMConfig         `json:"llm"`              // LLM 配置，用于AI总结功能
+}
+
+// ChatConfig 通用问答（/chat）的角色设定与话题范围：范围通过系统提示约束，并在模型回答后按话题关键词过滤
+type ChatConfig struct {
+	Persona        string   `json:"persona"`        // 角色设定，替换默认的「全能智能小助手」描述，为空时使用默认
+	Scope          string   `json:"scope"`          // 话题范围：open（默认，不限制）/ ops（只回答运维与技术问题）/ custom（只回答 topics 中的话题）
+	Topics         []string `json:"topics"`         // 允许讨论的话题（写入系统提示），scope 为 custom 时必填，为 ops 时追加到内置话题
+	Keywords       []string `json:"keywords"`       // 话题过滤关键词，问题与回答均不包含任何关键词时拒绝回答；scope 为 ops 时追加到内置关键词
+	RefusalMessage string   `json:"refusalMessage"` // 超出范围时的回复，为空时使用内置提示（按提问语言）
 }
 
 // LLMConfig LLM 模型配置，适配 DeepSeek/OpenAI 等兼容 OpenAI API 格式的模型
@@ -52,6 +376,140 @@ type LLMConfig struct {
 	ModelName   string        `json:"modelName"`   // LLM 模型名称
 	Timeout     time.Duration `json:"timeout"`     // LLM 请求超时时间
 	Temperature float32       `json:"temperature"` // LLM 生成温度
+
+	FallbackModel    string        `json:"fallbackModel"`    // 备用模型（如更便宜的小模型），主模型重试后仍失败时改用，为空不启用
+	MaxRetries       int           `json:"maxRetries"`       // 限流、5xx、超时等临时性错误的最大重试次数
+	RetryBackoff     time.Duration `json:"retryBackoff"`     // 首次重试等待时间，之后逐次翻倍
+	BreakerThreshold int           `json:"breakerThreshold"` // 连续失败多少次后熔断，暂停 AI 功能，0 表示不熔断
+	BreakerCooldown  time.Duration `json:"breakerCooldown"`  // 熔断持续时间，结束后放行一次试探调用
+}
+
+// ChatOpsConfig 聊天工具斜杠命令配置，支持 Slack 斜杠命令与钉钉机器人回调
+type ChatOpsConfig struct {
+	Enable             bool   `json:"enable"`             // 是否开启斜杠命令入口，默认关闭
+	CommandName        string `json:"commandName"`        // 命令名称，如 /telemetry
+	SlackSigningSecret string `json:"slackSigningSecret"` // Slack 签名密钥，为空时不注册 Slack 回调
+	DingTalkAppSecret  string `json:"dingTalkAppSecret"`  // 钉钉机器人 AppSecret，为空时不注册钉钉回调
+	MaxReplyItems      int    `json:"maxReplyItems"`      // 回复中最多列出的目标条数
+}
+
+// AlertConfig 告警通知配置
+type AlertConfig struct {
+	Enable      bool              `json:"enable"`      // 是否开启告警
+	WebhookURLs []string          `json:"webhookUrls"` // Webhook 通知地址列表
+	SendTimeout time.Duration     `json:"sendTimeout"` // 单次通知发送超时时间
+	Enrich      AlertEnrichConfig `json:"enrich"`      // AI 补充告警上下文配置
+	Group       AlertGroupConfig  `json:"group"`       // 告警聚合配置
+	Actions     AlertActionConfig `json:"actions"`     // 通知中的一键确认 / 解决链接配置
+	// 告警规则：目标满足任一规则的表达式时告警，全部不满足时恢复；为空时沿用默认规则 status == "failed"
+	Rules []AlertRule `json:"rules"`
+	// 通知模板（Go text/template），可按渠道、级别与告警状态覆盖默认的标题与正文，匹配条件最具体的模板生效
+	Templates []NotificationTemplate `json:"templates"`
+	// 个人手机推送订阅（ntfy / Gotify / Bark），值班人员各自配置，夜间不依赖企业聊天工具
+	Push       []PushSubscription    `json:"push"`
+	Escalation AlertEscalationConfig `json:"escalation"` // 未确认事件的短信 / 语音电话升级通知
+	// 已恢复事件在内存中的保留时长，超出后连同沟通记录一起清除，0 表示不清除
+	IncidentRetention time.Duration `json:"incidentRetention"`
+}
+
+// AlertEscalationConfig 升级通知配置：critical 事件超过 after 仍未确认也未恢复时，
+// 通过电话服务商向联系人发送短信并拨打语音电话，作为升级链的最后一级
+type AlertEscalationConfig struct {
+	Enable      bool                `json:"enable"`      // 是否开启
+	After       time.Duration       `json:"after"`       // 事件开启后多久仍未确认时升级
+	Repeat      time.Duration       `json:"repeat"`      // 升级后仍未确认时再次通知的间隔，0 表示只通知一次
+	MaxAttempts int                 `json:"maxAttempts"` // 每个事件最多升级通知的次数（含首次）
+	Contacts    []EscalationContact `json:"contacts"`    // 升级联系人
+	Telephony   TelephonyConfig     `json:"telephony"`   // 电话服务商配置
+}
+
+// EscalationContact 升级联系人
+type EscalationContact struct {
+	Name  string `json:"name"`  // 联系人姓名
+	Phone string `json:"phone"` // 手机号，E.164 格式，如 +8613800000000
+	SMS   bool   `json:"sms"`   // 是否发送短信
+	Voice bool   `json:"voice"` // 是否拨打语音电话
+}
+
+// TelephonyConfig 电话服务商配置，内置 twilio / aliyun，也可在代码中注册自定义服务商
+type TelephonyConfig struct {
+	Provider string            `json:"provider"` // 服务商名称：twilio / aliyun
+	Twilio   TwilioConfig      `json:"twilio"`   // Twilio 配置
+	Aliyun   AliyunVoiceConfig `json:"aliyun"`   // 阿里云短信与语音服务配置
+	Options  map[string]string `json:"options"`  // 自定义服务商的专有参数
+}
+
+// TwilioConfig Twilio 短信（Messages）与语音（Calls）配置
+type TwilioConfig struct {
+	AccountSID string `json:"accountSid"`           // 账号 SID
+	AuthToken  string `json:"authToken"`            // Auth Token 引用，支持 env: / file:
+	From       string `json:"from"`                 // 主叫 / 发送号码（E.164）
+	Language   string `json:"language"`             // 语音播报语言，默认 zh-CN
+	APIBaseURL string `json:"apiBaseURL,omitempty"` // API 地址，默认 https://api.twilio.com
+}
+
+// AliyunVoiceConfig 阿里云短信服务（SendSms）与语音服务（SingleCallByTts）配置，
+// 短信模板与语音模板可使用变量 ${title}（告警标题）与 ${content}（正文），变量按 35 个字符截断
+type AliyunVoiceConfig struct {
+	AccessKeyID      string `json:"accessKeyId"`             // AccessKey ID
+	AccessKeySecret  string `json:"accessKeySecret"`         // AccessKey Secret 引用，支持 env: / file:
+	SignName         string `json:"signName"`                // 短信签名
+	SMSTemplateCode  string `json:"smsTemplateCode"`         // 短信模板 CODE
+	TTSCode          string `json:"ttsCode"`                 // 语音通知的文本转语音模板 ID
+	CalledShowNumber string `json:"calledShowNumber"`        // 语音通知的主叫显示号码
+	SMSEndpoint      string `json:"smsEndpoint,omitempty"`   // 短信服务地址，默认 https://dysmsapi.aliyuncs.com
+	VoiceEndpoint    string `json:"voiceEndpoint,omitempty"` // 语音服务地址，默认 https://dyvmsapi.aliyuncs.com
+}
+
+// PushSubscription 个人手机推送订阅，每个订阅是一个独立的通知渠道（渠道名称为 type）
+type PushSubscription struct {
+	User   string `json:"user"`             // 订阅人，用于日志与排查
+	Type   string `json:"type"`             // 推送服务：ntfy / gotify / bark
+	Server string `json:"server,omitempty"` // 服务地址，ntfy 默认 https://ntfy.sh，bark 默认 https://api.day.app，gotify 必填
+	Topic  string `json:"topic,omitempty"`  // ntfy 主题（ntfy 必填）
+	// 凭据引用，支持 env: / file:：gotify 为应用令牌（必填）、bark 为设备 key（必填）、ntfy 为访问令牌（可选）
+	Token       string `json:"token,omitempty"`
+	MinSeverity string `json:"minSeverity,omitempty"` // 只推送不低于该级别的告警：critical / warning / info（默认，全部推送）
+}
+
+// NotificationTemplate 通知模板，channel / severity / status 为空表示匹配任意值
+type NotificationTemplate struct {
+	Channel  string `json:"channel,omitempty"`  // 通知渠道名称，如 webhook
+	Severity string `json:"severity,omitempty"` // 告警级别：critical / warning / info
+	Status   string `json:"status,omitempty"`   // 告警状态：firing / resolved / acknowledged
+	Title    string `json:"title,omitempty"`    // 标题模板，为空时使用默认标题
+	Body     string `json:"body,omitempty"`     // 正文模板，为空时使用默认正文（含 AI 上下文与操作链接）
+}
+
+// AlertRule 告警规则，条件为基于检查结果字段与滑动窗口聚合值的表达式，
+// 如 consecutive_failures >= 3 && error_type == "timeout"、p95_latency_5m > 800
+type AlertRule struct {
+	Name     string `json:"name"`               // 规则名称，出现在告警标题中
+	Expr     string `json:"expr"`               // 告警条件表达式
+	Severity string `json:"severity,omitempty"` // 告警级别：critical（默认）/ warning / info，可用于按级别选择通知模板
+}
+
+// AlertActionConfig 告警通知中的一键确认 / 解决链接配置，链接带签名与有效期，值班人员无需登录控制台即可在手机上操作
+type AlertActionConfig struct {
+	Enable    bool          `json:"enable"`    // 是否在告警通知中附带操作链接
+	Secret    string        `json:"secret"`    // 链接签名密钥（HMAC-SHA256），为空时不生成链接
+	PublicURL string        `json:"publicURL"` // 服务对外访问地址，用于生成操作链接，如 https://status.example.com
+	TTL       time.Duration `json:"ttl"`       // 链接有效期
+}
+
+// AlertGroupConfig 告警聚合配置，短时间内大量目标失败时按共同特征（主机、错误类型、标签）合并为一个事件
+type AlertGroupConfig struct {
+	Enable  bool          `json:"enable"`  // 是否开启聚合
+	Window  time.Duration `json:"window"`  // 聚合窗口，窗口内的失败结果统一判断是否合并
+	MinSize int           `json:"minSize"` // 合并为一个事件所需的最少目标数
+}
+
+// AlertEnrichConfig AI 补充告警上下文配置，超出预算时回退为普通模板
+type AlertEnrichConfig struct {
+	Enable       bool          `json:"enable"`       // 是否由 AI 补充一到两句上下文
+	Timeout      time.Duration `json:"timeout"`      // 延迟预算，超时直接发送普通模板
+	MaxTokens    int           `json:"maxTokens"`    // 生成的最大 token 数
+	HistoryLimit int           `json:"historyLimit"` // 提供给 AI 的近期历史结果条数
 }
 
 // 新增：配置热加载相关
@@ -65,36 +523,196 @@ var (
 func DefaultConfig() *GlobalConfig {
 	return &GlobalConfig{
 		Monitor: MonitorConfig{
-			Concurrency:   5,
-			CheckInterval: 5 * time.Second,
-			HTTPTimeout:   10 * time.Second,
-			TCPTimeout:    5 * time.Second,
-			MaxRetry:      3,
-			MaxBodySize:   1024 * 1024,
-			LogLevel:      "info",           // 新增
-			CacheTTL:      30 * time.Second, // 新增
+			Concurrency:         5,
+			CheckInterval:       5 * time.Second,
+			HTTPTimeout:         10 * time.Second,
+			TCPTimeout:          5 * time.Second,
+			UDPTimeout:          3 * time.Second,
+			GRPCTimeout:         5 * time.Second,
+			SSHTimeout:          5 * time.Second,
+			RedisTimeout:        5 * time.Second,
+			DatabaseTimeout:     5 * time.Second,
+			MQTTTimeout:         5 * time.Second,
+			KafkaTimeout:        5 * time.Second,
+			FileTransferTimeout: 10 * time.Second,
+			TLSTimeout:          10 * time.Second,
+			MaxRetry:            3,
+			MaxBodySize:         1024 * 1024,
+			LogLevel:            "info",           // 新增
+			CacheTTL:            30 * time.Second, // 新增
+			WarmUpWindow:        24 * time.Hour,
+			OCSP: OCSPConfig{
+				Timeout: 5 * time.Second,
+			},
+			DNS: DNSCheckConfig{
+				Timeout: 5 * time.Second,
+			},
+			SMTP: SMTPCheckConfig{
+				EHLOName: "servicemonitor.local",
+				Timeout:  10 * time.Second,
+			},
+			NTP: NTPCheckConfig{
+				MaxOffset: 500 * time.Millisecond,
+				Timeout:   5 * time.Second,
+			},
+			DomainExpiry: DomainExpiryConfig{
+				BootstrapURL: "https://data.iana.org/rdap/dns.json",
+				WhoisServer:  "whois.iana.org:43",
+				Timeout:      10 * time.Second,
+				CacheTTL:     12 * time.Hour,
+			},
+			Traceroute: TracerouteConfig{
+				MaxHops:       30,
+				HopTimeout:    time.Second,
+				Timeout:       15 * time.Second,
+				MaxConcurrent: 4,
+			},
+			ResponseSnapshot: ResponseSnapshotConfig{
+				Enable:       false,
+				MaxBodyBytes: 4096,
+				Retention:    7 * 24 * time.Hour,
+			},
+			Checksum: ChecksumCheckConfig{
+				MaxSize: 256 << 20,
+				Timeout: 5 * time.Minute,
+			},
+			ICMP: ICMPConfig{
+				Count:         4,
+				Interval:      200 * time.Millisecond,
+				Timeout:       2 * time.Second,
+				LossThreshold: 100,
+			},
+			URLPolicy: URLPolicyConfig{
+				BlockPrivate: true,
+				MaxURLLength: 2048,
+			},
 		},
 		DB: DBConfig{
-			Host:     "127.0.0.1",
-			Port:     3306,
-			User:     "root",
-			Password: "123456",
-			DBName:   "servicemonitor",
-			MaxOpen:  10, // 新增
-			MaxIdle:  5,  // 新增
+			Host:               "127.0.0.1",
+			Port:               3306,
+			User:               "root",
+			Password:           "123456",
+			DBName:             "servicemonitor",
+			MaxOpen:            10, // 新增
+			MaxIdle:            5,  // 新增
+			SlowQueryThreshold: 200 * time.Millisecond,
 		},
 		Agent: AgentConfig{
 			EnableAI:         true,
 			MaxRetrieve:      50,
 			DefaultTimeRange: 24,
+			IntentParser:     "keyword",
+			OutputFormat:     "plain",
 			LLM: LLMConfig{
 				APIKey:      "sk-53438aee1ecf4910aefd9815f19dd2d3",
 				APIBaseURL:  "https://api.deepseek.com/v1",
 				ModelName:   "deepseek-chat",
 				Timeout:     30 * time.Second,
 				Temperature: 0.7,
+
+				MaxRetries:       2,
+				RetryBackoff:     500 * time.Millisecond,
+				BreakerThreshold: 5,
+				BreakerCooldown:  time.Minute,
+			},
+			Chat: ChatConfig{
+				Scope: "open",
+			},
+		},
+		ChatOps: ChatOpsConfig{
+			Enable:        false,
+			CommandName:   "/telemetry",
+			MaxReplyItems: 10,
+		},
+		API: APIConfig{
+			Gzip:                  true,
+			IdempotencyTTL:        24 * time.Hour,
+			IdempotencyMaxEntries: 10000,
+			IdempotencyMaxBody:    1 << 20,
+			ChangesBuffer:         1000,
+			LongPollMaxWait:       30 * time.Second,
+		},
+		Alert: AlertConfig{
+			Enable:            false,
+			SendTimeout:       5 * time.Second,
+			IncidentRetention: 7 * 24 * time.Hour,
+			Enrich: AlertEnrichConfig{
+				Enable:       false,
+				Timeout:      3 * time.Second,
+				MaxTokens:    80,
+				HistoryLimit: 10,
+			},
+			Group: AlertGroupConfig{
+				Enable:  true,
+				Window:  30 * time.Second,
+				MinSize: 3,
+			},
+			Actions: AlertActionConfig{
+				Enable:    false,
+				PublicURL: "http://localhost:8080",
+				TTL:       24 * time.Hour,
+			},
+			Escalation: AlertEscalationConfig{
+				After:       15 * time.Minute,
+				Repeat:      10 * time.Minute,
+				MaxAttempts: 3,
 			},
 		},
+		Stats: StatsConfig{
+			HealthWindow:   24 * time.Hour,
+			BaselineWindow: 7 * 24 * time.Hour,
+			HealthWeights: HealthWeights{
+				Availability: 50,
+				Latency:      20,
+				Incidents:    20,
+				Certificate:  10,
+			},
+		},
+		Subscriptions: SubscriptionConfig{
+			Enable:      false,
+			PublicURL:   "http://localhost:8080",
+			SendTimeout: 10 * time.Second,
+			SMTP: SMTPConfig{
+				Port: 25,
+			},
+		},
+		Remediation: RemediationConfig{
+			Enable:   false,
+			Cooldown: 30 * time.Minute,
+			Timeout:  time.Minute,
+		},
+		Probes: ProbeConfig{
+			OfflineAfter: 2 * time.Minute,
+			Central: CentralConfig{
+				Timeout:           10 * time.Second,
+				HeartbeatInterval: 30 * time.Second,
+				Channel:           "stable",
+			},
+		},
+		Failover: FailoverConfig{
+			Enable:       false,
+			Interval:     time.Hour,
+			LatencyRatio: 3,
+		},
+		Snapshots: SnapshotConfig{
+			Enable:      false,
+			Interval:    30 * time.Second,
+			MaxVersions: 50,
+		},
+		CT: CTConfig{
+			Enable:       false,
+			PollInterval: time.Hour,
+			SourceURL:    "https://crt.sh",
+			Timeout:      30 * time.Second,
+		},
+		Events: EventBusConfig{
+			Enable:        false,
+			Driver:        "nats",
+			TopicPrefix:   "servicetelemetry",
+			BufferSize:    1000,
+			Timeout:       5 * time.Second,
+			ConsumerGroup: "servicetelemetry",
+		},
 	}
 }
 
@@ -128,6 +746,13 @@ func GetCurrentConfig() *GlobalConfig {
 	return globalConfig
 }
 
+// CurrentConfigFile 返回热加载使用的配置文件路径
+func CurrentConfigFile() string {
+	configMu.RLock()
+	defer configMu.RUnlock()
+	return configFile
+}
+
 // 新增：配置热加载
 func StartConfigHotReload(interval time.Duration) {
 	ticker := time.NewTicker(interval)
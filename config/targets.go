@@ -0,0 +1,321 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+)
+
+// TargetSpec 声明式监控目标定义文件（monitoring-as-code），以 JSON 格式描述全部监控目标
+type TargetSpec struct {
+	Targets []TargetDefinition `json:"targets"` // 监控目标列表
+}
+
+// TargetOptions 监控目标的检查选项，嵌入目标定义与监控目标中（JSON 字段平铺），整体以 JSON 入库
+type TargetOptions struct {
+	SourceIP  string `json:"sourceIP,omitempty"`  // 发起检查使用的本机源地址，多网卡主机上用于选择防火墙路径
+	Interface string `json:"interface,omitempty"` // 发起检查使用的本机网卡（取该网卡地址作为源地址），与 sourceIP 二选一
+	Proxy     string `json:"proxy,omitempty"`     // 检查经由的代理：http:// / https:// / socks5:// / socks5h://，direct 表示不使用全局默认代理
+	Resolver  string `json:"resolver,omitempty"`  // 解析主机名使用的解析服务器 ip[:port]（如 8.8.8.8:53 或内网 DNS），为空时使用系统解析
+	// 检查使用的地址族：v4 / v6 只连接该地址族的地址；both 分别经由 IPv4 与 IPv6 检查并各自报告结果；为空时按 Happy Eyeballs 任一地址族连通即可
+	AddressFamily string `json:"addressFamily,omitempty"`
+	SNI           string `json:"sni,omitempty"`        // TLS 握手使用的 SNI 主机名，为空时使用 hostHeader 或地址中的主机名
+	HostHeader    string `json:"hostHeader,omitempty"` // 请求头 Host，用于探测共享 IP 后的虚拟主机或迁移中的源站
+	Method        string `json:"method,omitempty"`     // HTTP 请求方法：GET（默认）或 HEAD；HEAD 只校验状态码与响应头，适合大文件等只需确认可达的地址
+	// 是否跟随重定向（HTTP/HTTPS 目标），默认跟随；设为 false 时 3xx 响应即为检查结果，3xx 状态码视为正常
+	FollowRedirects *bool `json:"followRedirects,omitempty"`
+	MaxRedirects    int   `json:"maxRedirects,omitempty"` // 最多跟随的重定向次数，0 表示使用默认值 10
+	// 强制使用的 HTTP 版本（HTTP/HTTPS 目标）：1.1（默认）/ 2 / 3；3 经 QUIC 以 HTTP/3 发送请求，仅适用于 HTTPS 目标
+	HTTPVersion string            `json:"httpVersion,omitempty"`
+	Keywords    *KeywordOptions   `json:"keywords,omitempty"`   // 多关键词匹配（HTTP/HTTPS 目标），与 keyword 同时配置时 keyword 视为 all 中的一项
+	TLSProfile  string            `json:"tlsProfile,omitempty"` // TLS 配置档名称（内置 default / modern / legacy，或 monitor.tlsProfiles 中自定义）
+	TLS         *TLSOptions       `json:"tls,omitempty"`        // 目标级 TLS 校验选项（跳过校验、自定义 CA、最低版本），覆盖配置档中的对应设置
+	Regions     []string          `json:"regions,omitempty"`    // 允许检查该目标的探测区域（数据驻留 / 就近测量），为空表示任意区域均可检查
+	DNS         *DNSOptions       `json:"dns,omitempty"`        // DNS 检查选项（dns:// 目标）
+	UDP         *UDPOptions       `json:"udp,omitempty"`        // UDP 检查选项（udp:// 目标）
+	SSH         *SSHOptions       `json:"ssh,omitempty"`        // SSH 检查选项（ssh:// 目标）
+	Heartbeat   *HeartbeatOptions `json:"heartbeat,omitempty"`  // 心跳检查选项（heartbeat:// 目标）
+	Compare     *CompareOptions   `json:"compare,omitempty"`    // 响应一致性比对选项（HTTP/HTTPS 目标）
+	Checksum    *ChecksumOptions  `json:"checksum,omitempty"`   // 文件摘要校验选项（HTTP/HTTPS 目标，如发布镜像、固件下载地址）
+	Hedge       *HedgeOptions     `json:"hedge,omitempty"`      // 对冲请求选项（HTTP/HTTPS 目标），缓解跨区域检查偶发的长尾延迟
+	// 域名注册到期检查（地址为域名的目标），通过 RDAP / WHOIS 查询注册到期时间，临近到期时记为警告
+	DomainExpiry *DomainExpiryOptions `json:"domainExpiry,omitempty"`
+	Remediation  []string             `json:"remediation,omitempty"` // 目标进入失败状态时执行的处置动作名称（在 remediation.actions 中定义）
+	// 响应耗时阈值（毫秒），检查成功但耗时超过阈值时按 slowStatus 记为降级或失败，0 表示不限制
+	MaxResponseTimeMs float64 `json:"maxResponseTimeMs,omitempty"`
+	SlowStatus        string  `json:"slowStatus,omitempty"` // 耗时超过阈值时的检查状态：degraded（默认）或 failed
+	// 附加的 HTTP 请求头（HTTP/HTTPS 目标），如 X-API-Key；值与凭据一样支持 env: / file: 引用
+	Headers     map[string]string `json:"headers,omitempty"`
+	BasicAuth   *BasicAuthOptions `json:"basicAuth,omitempty"`   // HTTP Basic 认证（HTTP/HTTPS 目标），与 bearerToken 二选一
+	BearerToken string            `json:"bearerToken,omitempty"` // HTTP Bearer 令牌引用（HTTP/HTTPS 目标），支持 env: / file:
+	// 凭据引用（如 redis:// 目标的 password），值支持 env:变量名、file:文件路径 或明文，检查时才解析
+	// 随检查选项入库的是引用本身，建议使用 env: / file:，避免明文凭据出现在数据库与导出结果中
+	Credentials map[string]string `json:"credentials,omitempty"`
+}
+
+// BasicAuthOptions HTTP Basic 认证选项
+type BasicAuthOptions struct {
+	Username string `json:"username"` // 用户名
+	Password string `json:"password"` // 密码引用，支持 env: / file:
+}
+
+// maskedSecret 明文凭据在接口输出中的替代值
+const maskedSecret = "******"
+
+// sensitiveHeaderWords 请求头名称包含这些词时视为敏感请求头，明文值在输出时脱敏
+var sensitiveHeaderWords = []string{"auth", "token", "key", "secret", "password", "cookie", "session", "signature"}
+
+// SecretRefs 列出目标选项中的全部凭据引用（credentials、请求头、Basic 认证密码、Bearer 令牌与心跳令牌），
+// 键为字段路径（credentials 中的凭据直接使用凭据名），供校验引用能否解析
+func (o TargetOptions) SecretRefs() map[string]string {
+	refs := make(map[string]string, len(o.Credentials)+len(o.Headers)+3)
+	for name, ref := range o.Credentials {
+		refs[name] = ref
+	}
+	for name, ref := range o.Headers {
+		refs["headers."+name] = ref
+	}
+	if o.BasicAuth != nil {
+		refs["basicAuth.password"] = o.BasicAuth.Password
+	}
+	if o.BearerToken != "" {
+		refs["bearerToken"] = o.BearerToken
+	}
+	if o.Heartbeat != nil {
+		refs["heartbeat.token"] = o.Heartbeat.Token
+	}
+	return refs
+}
+
+// LocalSecretRefs 列出使用 env: / file: 引用的字段路径（credentials、请求头、Basic 认证密码、Bearer 令牌与心跳令牌，按路径排序）
+// 引用在检查时读取本机的环境变量或文件并发送给目标，只允许出现在声明式目标定义文件中；
+// 通过接口或注册消息提交的目标使用引用时应拒绝，否则调用方可以把服务端的密钥或任意文件发往自己控制的地址
+func (o TargetOptions) LocalSecretRefs() []string {
+	var fields []string
+	for path, ref := range o.SecretRefs() {
+		if !IsSecretRef(ref) {
+			continue
+		}
+		if _, ok := o.Credentials[path]; ok {
+			path = "credentials." + path
+		}
+		fields = append(fields, path)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// Masked 返回脱敏后的副本，用于接口输出：env: / file: 引用原样保留，
+// 明文的凭据、敏感请求头、Basic 认证密码、Bearer 令牌与心跳令牌替换为 ******
+func (o TargetOptions) Masked() TargetOptions {
+	if len(o.Credentials) > 0 {
+		credentials := make(map[string]string, len(o.Credentials))
+		for name, ref := range o.Credentials {
+			credentials[name] = maskSecret(ref)
+		}
+		o.Credentials = credentials
+	}
+	if len(o.Headers) > 0 {
+		headers := make(map[string]string, len(o.Headers))
+		for name, value := range o.Headers {
+			if isSensitiveHeader(name) {
+				value = maskSecret(value)
+			}
+			headers[name] = value
+		}
+		o.Headers = headers
+	}
+	if o.BasicAuth != nil {
+		o.BasicAuth = &BasicAuthOptions{Username: o.BasicAuth.Username, Password: maskSecret(o.BasicAuth.Password)}
+	}
+	o.BearerToken = maskSecret(o.BearerToken)
+	if o.Heartbeat != nil {
+		heartbeat := *o.Heartbeat
+		heartbeat.Token = maskSecret(heartbeat.Token)
+		o.Heartbeat = &heartbeat
+	}
+	if u, err := url.Parse(o.Proxy); err == nil && u.User != nil {
+		o.Proxy = u.Redacted()
+	}
+	return o
+}
+
+// maskSecret 脱敏单个凭据：引用原样返回，明文替换为 ******
+func maskSecret(ref string) string {
+	if ref == "" || IsSecretRef(ref) {
+		return ref
+	}
+	return maskedSecret
+}
+
+// IsSecretRef 判断凭据是否为 env: / file: 引用（而非明文）
+func IsSecretRef(ref string) bool {
+	return strings.HasPrefix(ref, "env:") || strings.HasPrefix(ref, "file:")
+}
+
+// isSensitiveHeader 判断请求头是否可能携带凭据
+func isSensitiveHeader(name string) bool {
+	name = strings.ToLower(name)
+	for _, word := range sensitiveHeaderWords {
+		if strings.Contains(name, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// KeywordOptions 多关键词匹配选项，三组条件同时满足才算匹配成功（均区分大小写）：
+// all 中的关键词必须全部出现，any 中至少出现一个，none 中的关键词均不得出现
+// 如「包含 X 且包含 Y 但不包含 Z」：{"all": ["X", "Y"], "none": ["Z"]}
+type KeywordOptions struct {
+	All  []string `json:"all,omitempty"`  // 必须全部出现
+	Any  []string `json:"any,omitempty"`  // 至少出现一个
+	None []string `json:"none,omitempty"` // 均不得出现
+}
+
+// Empty 判断是否未配置任何关键词
+func (o *KeywordOptions) Empty() bool {
+	return o == nil || len(o.All)+len(o.Any)+len(o.None) == 0
+}
+
+// AllKeywords 返回必须全部出现的关键词：keyword（非空时）在前，其后为 all 中的关键词，重复的只保留一个
+func (o *KeywordOptions) AllKeywords(keyword string) []string {
+	var list []string
+	if keyword != "" {
+		list = append(list, keyword)
+	}
+	if o == nil {
+		return list
+	}
+	for _, kw := range o.All {
+		duplicate := false
+		for _, v := range list {
+			duplicate = duplicate || v == kw
+		}
+		if !duplicate {
+			list = append(list, kw)
+		}
+	}
+	return list
+}
+
+// DNSOptions DNS 检查选项，与 dns:// 地址中的查询参数等效，地址中已有的参数优先
+type DNSOptions struct {
+	Resolver string   `json:"resolver,omitempty"` // 查询使用的解析服务器 ip[:port]，为空时使用 monitor.dns.resolver
+	Type     string   `json:"type,omitempty"`     // 记录类型：A / AAAA / CNAME / MX / NS / TXT / SRV，默认 A
+	Expect   []string `json:"expect,omitempty"`   // 期望出现在应答中的记录值（全部命中才算成功）
+	MinTTL   uint32   `json:"minTTL,omitempty"`   // 应答记录 TTL 下限（秒），0 表示不校验
+	MaxTTL   uint32   `json:"maxTTL,omitempty"`   // 应答记录 TTL 上限（秒），0 表示不校验
+}
+
+// DomainExpiryOptions 域名注册到期检查选项
+type DomainExpiryOptions struct {
+	Domain   string `json:"domain,omitempty"`   // 查询的注册域名，为空时取目标主机名的可注册域名（如 api.example.co.uk -> example.co.uk）
+	WarnDays int    `json:"warnDays,omitempty"` // 剩余天数低于该值时记为警告，0 表示使用默认值 30
+}
+
+// HeartbeatOptions 心跳检查选项：外部任务（定时任务、批处理流水线）每完成一次调用 PUT /api/v1/heartbeats/:token，
+// 超过 periodSeconds + graceSeconds 未收到心跳时检查失败；任务开始时另调用 PUT /api/v1/heartbeats/:token/start
+// 即可记录每次运行的耗时，运行过长、过短或与上一次运行重叠时检查失败
+type HeartbeatOptions struct {
+	Token         string `json:"token"`                   // 心跳令牌，支持 env: / file: 引用，明文至少 16 个字符
+	PeriodSeconds int    `json:"periodSeconds"`           // 任务的预期执行间隔（秒）
+	GraceSeconds  int    `json:"graceSeconds,omitempty"`  // 容许的延迟（秒），应覆盖任务自身的执行耗时
+	MinRunSeconds int    `json:"minRunSeconds,omitempty"` // 单次运行的最短耗时（秒），过短通常意味着任务提前退出，0 表示不限制
+	MaxRunSeconds int    `json:"maxRunSeconds,omitempty"` // 单次运行的最长耗时（秒），运行中超过即失败，0 表示不限制
+	AllowOverlap  bool   `json:"allowOverlap,omitempty"`  // 允许上一次运行尚未结束时开始新的运行，默认重叠时检查失败
+}
+
+// UDPOptions UDP 检查选项，与 udp:// 地址中的查询参数等效，地址中已有的参数优先
+type UDPOptions struct {
+	Payload        string `json:"payload,omitempty"`        // 发送的报文内容（文本）
+	PayloadHex     string `json:"payloadHex,omitempty"`     // 发送的报文内容（十六进制），与 payload 二选一，用于二进制协议
+	Expect         string `json:"expect,omitempty"`         // 期望响应中包含的内容，配置后未收到响应即判定失败
+	ExpectResponse bool   `json:"expectResponse,omitempty"` // 是否必须收到响应（不校验内容）
+}
+
+// SSHOptions SSH 检查选项，与 ssh:// 地址中的查询参数等效，地址中已有的参数优先
+type SSHOptions struct {
+	Fingerprints []string `json:"fingerprints,omitempty"` // 固定的主机公钥指纹（SHA256:... 格式，与 ssh-keygen -lf 输出一致），匹配任意一个即可
+}
+
+// CompareOptions 响应一致性比对选项：目标检查通过后再请求比对地址（如蓝绿环境、主从副本），比较两者的响应
+// 不一致时记为 divergence 类型的警告，不影响检查状态
+type CompareOptions struct {
+	URL       string   `json:"url"`                 // 比对地址（http / https）
+	Fields    []string `json:"fields,omitempty"`    // 比对的 JSON 字段路径，如 data.version、items.0.id；为空时比较整个响应体
+	Ignore    []string `json:"ignore,omitempty"`    // 比较整个 JSON 响应体时忽略的字段路径，如 meta.requestId
+	Tolerance float64  `json:"tolerance,omitempty"` // 数值字段允许的相对偏差（百分比），0 表示必须完全相等
+}
+
+// ChecksumOptions 文件摘要校验选项：下载整个文件（受大小上限约束）并校验 SHA-256
+// sha256 与 url 二选一；url 为发布方的摘要文件（如 SHA256SUMS、xxx.tar.gz.sha256）
+type ChecksumOptions struct {
+	SHA256  string `json:"sha256,omitempty"`  // 期望的 SHA-256（十六进制）
+	URL     string `json:"url,omitempty"`     // 摘要文件地址，按目标地址路径的文件名查找对应摘要
+	MaxSize int64  `json:"maxSize,omitempty"` // 下载大小上限（字节），0 表示使用 monitor.checksum.maxSize
+}
+
+// HedgeOptions 对冲请求选项：首个请求超过 delayMs 仍未收到响应时再发出一个请求（使用新的连接），采用最先成功的响应
+// 与失败后才重试的串行重试不同，对冲请求不必等待首个请求超时，适合偶发长尾延迟而非持续故障的链路
+type HedgeOptions struct {
+	DelayMs int `json:"delayMs"` // 发出对冲请求前等待的时间（毫秒），建议取该目标正常响应耗时的 P95 左右
+}
+
+// TLSOptions 目标级 TLS 校验选项，覆盖所选 TLS 配置档中的对应设置，用于使用私有 CA 或自签名证书的内网服务
+type TLSOptions struct {
+	InsecureSkipVerify bool   `json:"insecureSkipVerify,omitempty"` // 跳过证书校验（结果中会持久化警告标记），与 caFile 二选一
+	CAFile             string `json:"caFile,omitempty"`             // 校验服务端证书使用的 CA 证书文件（PEM，可包含多张），替代系统信任的根证书，配置档跳过校验时也按此校验
+	MinVersion         string `json:"minVersion,omitempty"`         // 最低 TLS 版本：1.0 / 1.1 / 1.2 / 1.3，为空时使用配置档的设置
+}
+
+// TargetDefinition 单个监控目标的声明式定义
+type TargetDefinition struct {
+	URL        string   `json:"url"`        // 目标服务地址
+	Keyword    string   `json:"keyword"`    // 响应体匹配关键词
+	Priority   string   `json:"priority"`   // 任务优先级（low/normal/high）
+	Tags       []string `json:"tags"`       // 目标标签
+	Assertions []string `json:"assertions"` // 断言表达式，如 status == 200、body contains ok
+	TargetOptions
+}
+
+// LoadTargetSpec 从文件加载声明式监控目标定义
+// filePath：定义文件路径
+func LoadTargetSpec(filePath string) (*TargetSpec, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("读取目标定义文件失败：%w", err)
+	}
+
+	var spec TargetSpec
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return nil, fmt.Errorf("解析目标定义文件失败：%w", err)
+	}
+	return &spec, nil
+}
+
+// ResolveSecret 解析凭据引用，返回实际的凭据内容
+// ref：凭据引用，env:NAME 读取环境变量，file:PATH 读取文件内容，其余视为明文
+func ResolveSecret(ref string) (string, error) {
+	switch {
+	case strings.HasPrefix(ref, "env:"):
+		name := strings.TrimPrefix(ref, "env:")
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("环境变量 %s 未设置", name)
+		}
+		return value, nil
+	case strings.HasPrefix(ref, "file:"):
+		path := strings.TrimPrefix(ref, "file:")
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("读取凭据文件 %s 失败：%w", path, err)
+		}
+		return strings.TrimSpace(string(data)), nil
+	default:
+		return ref, nil
+	}
+}
@@ -0,0 +1,131 @@
+package core
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Assertion 响应断言，格式为「字段 操作符 期望值」
+// 字段：status（HTTP状态码）、body（响应体）、header.名称（响应头）
+// 操作符：==、!=、<、<=、>、>=（仅 status），contains、!contains、matches（body / header）
+type Assertion struct {
+	Field string // 断言字段
+	Op    string // 操作符
+	Value string // 期望值
+}
+
+// 各字段支持的操作符
+var (
+	statusOps = map[string]bool{"==": true, "!=": true, "<": true, "<=": true, ">": true, ">=": true}
+	textOps   = map[string]bool{"==": true, "!=": true, "contains": true, "!contains": true, "matches": true}
+)
+
+// ParseAssertion 解析断言表达式，例如：status == 200、body contains "ok"、header.Content-Type contains json
+func ParseAssertion(expr string) (*Assertion, error) {
+	fields := strings.Fields(strings.TrimSpace(expr))
+	if len(fields) < 3 {
+		return nil, fmt.Errorf("断言格式错误，应为「字段 操作符 期望值」：%s", expr)
+	}
+
+	a := &Assertion{
+		Field: fields[0],
+		Op:    fields[1],
+		Value: strings.Trim(strings.Join(fields[2:], " "), `"'`),
+	}
+
+	switch {
+	case a.Field == "status":
+		if !statusOps[a.Op] {
+			return nil, fmt.Errorf("status 不支持操作符 %s：%s", a.Op, expr)
+		}
+		if _, err := strconv.Atoi(a.Value); err != nil {
+			return nil, fmt.Errorf("status 期望值必须为整数：%s", expr)
+		}
+	case a.Field == "body" || strings.HasPrefix(a.Field, "header."):
+		if !textOps[a.Op] {
+			return nil, fmt.Errorf("%s 不支持操作符 %s：%s", a.Field, a.Op, expr)
+		}
+		if a.Field == "header." {
+			return nil, fmt.Errorf("缺少响应头名称：%s", expr)
+		}
+		if a.Op == "matches" {
+			if _, err := regexp.Compile(a.Value); err != nil {
+				return nil, fmt.Errorf("正则表达式无效：%s", expr)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("不支持的断言字段 %s：%s", a.Field, expr)
+	}
+
+	return a, nil
+}
+
+// hasBodyAssertion 判断断言表达式中是否有需要读取响应体的 body 断言，无法解析的表达式忽略
+func hasBodyAssertion(exprs []string) bool {
+	for _, expr := range exprs {
+		if a, err := ParseAssertion(expr); err == nil && a.Field == "body" {
+			return true
+		}
+	}
+	return false
+}
+
+// String 返回断言的表达式形式
+func (a *Assertion) String() string {
+	return a.Field + " " + a.Op + " " + a.Value
+}
+
+// Evaluate 基于HTTP响应校验断言，失败时返回错误
+// statusCode：HTTP状态码
+// header：响应头
+// body：响应体
+func (a *Assertion) Evaluate(statusCode int, header http.Header, body []byte) error {
+	if a.Field == "status" {
+		expected, _ := strconv.Atoi(a.Value)
+		ok := false
+		switch a.Op {
+		case "==":
+			ok = statusCode == expected
+		case "!=":
+			ok = statusCode != expected
+		case "<":
+			ok = statusCode < expected
+		case "<=":
+			ok = statusCode <= expected
+		case ">":
+			ok = statusCode > expected
+		case ">=":
+			ok = statusCode >= expected
+		}
+		if !ok {
+			return fmt.Errorf("断言失败：%s（实际状态码 %d）", a, statusCode)
+		}
+		return nil
+	}
+
+	actual := string(body)
+	if strings.HasPrefix(a.Field, "header.") {
+		actual = header.Get(strings.TrimPrefix(a.Field, "header."))
+	}
+
+	ok := false
+	switch a.Op {
+	case "==":
+		ok = actual == a.Value
+	case "!=":
+		ok = actual != a.Value
+	case "contains":
+		ok = strings.Contains(actual, a.Value)
+	case "!contains":
+		ok = !strings.Contains(actual, a.Value)
+	case "matches":
+		ok = regexp.MustCompile(a.Value).MatchString(actual)
+	}
+	if !ok {
+		return fmt.Errorf("断言失败：%s", a)
+	}
+	return nil
+}
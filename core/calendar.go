@@ -0,0 +1,166 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"servicetelemetry/config"
+)
+
+// weekdays 星期名称（不区分大小写）
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// Calendar 工作时间日历，判断某一时刻是否处于工作时间
+type Calendar struct {
+	Name     string
+	Tags     []string
+	location *time.Location
+	days     map[time.Weekday]bool
+	start    int             // 工作开始时间（当天第几分钟）
+	end      int             // 工作结束时间（当天第几分钟），小于开始时间表示跨越午夜
+	holidays map[string]bool // 节假日（yyyy-mm-dd），当天不计工作时间
+	workdays map[string]bool // 调休工作日（yyyy-mm-dd），即使不在工作星期内也计工作时间
+}
+
+// NewCalendar 根据配置创建工作时间日历
+func NewCalendar(cfg config.BusinessCalendar) (*Calendar, error) {
+	if cfg.Name == "" {
+		return nil, fmt.Errorf("日历名称不能为空")
+	}
+	c := &Calendar{
+		Name:     cfg.Name,
+		Tags:     cfg.Tags,
+		location: time.Local,
+		days:     make(map[time.Weekday]bool),
+		holidays: make(map[string]bool),
+		workdays: make(map[string]bool),
+	}
+	if cfg.Timezone != "" {
+		loc, err := time.LoadLocation(cfg.Timezone)
+		if err != nil {
+			return nil, fmt.Errorf("日历[%s]时区无效：%s", cfg.Name, cfg.Timezone)
+		}
+		c.location = loc
+	}
+
+	days := cfg.Days
+	if len(days) == 0 {
+		days = []string{"mon", "tue", "wed", "thu", "fri"}
+	}
+	for _, d := range days {
+		name := strings.ToLower(strings.TrimSpace(d))
+		if len(name) > 3 {
+			name = name[:3] // 兼容 monday 等完整写法
+		}
+		wd, ok := weekdays[name]
+		if !ok {
+			return nil, fmt.Errorf("日历[%s]星期无效：%s", cfg.Name, d)
+		}
+		c.days[wd] = true
+	}
+
+	var err error
+	if c.start, err = parseClock(cfg.Start, "09:00"); err != nil {
+		return nil, fmt.Errorf("日历[%s]开始时间无效：%w", cfg.Name, err)
+	}
+	if c.end, err = parseClock(cfg.End, "18:00"); err != nil {
+		return nil, fmt.Errorf("日历[%s]结束时间无效：%w", cfg.Name, err)
+	}
+	if c.start == c.end {
+		return nil, fmt.Errorf("日历[%s]开始时间与结束时间不能相同", cfg.Name)
+	}
+
+	for _, d := range cfg.Holidays {
+		if _, err := time.Parse("2006-01-02", d); err != nil {
+			return nil, fmt.Errorf("日历[%s]节假日日期无效：%s", cfg.Name, d)
+		}
+		c.holidays[d] = true
+	}
+	for _, d := range cfg.Workdays {
+		if _, err := time.Parse("2006-01-02", d); err != nil {
+			return nil, fmt.Errorf("日历[%s]调休工作日日期无效：%s", cfg.Name, d)
+		}
+		c.workdays[d] = true
+	}
+	return c, nil
+}
+
+// NewCalendars 创建全部工作时间日历，名称重复或配置无效时返回错误
+func NewCalendars(cfgs []config.BusinessCalendar) ([]*Calendar, error) {
+	list := make([]*Calendar, 0, len(cfgs))
+	seen := make(map[string]bool)
+	for _, cfg := range cfgs {
+		c, err := NewCalendar(cfg)
+		if err != nil {
+			return nil, err
+		}
+		if seen[c.Name] {
+			return nil, fmt.Errorf("日历名称重复：%s", c.Name)
+		}
+		seen[c.Name] = true
+		list = append(list, c)
+	}
+	return list, nil
+}
+
+// InBusinessHours 判断时刻是否处于工作时间（按日历时区）
+// 跨越午夜的工作时间（如 22:00~06:00）中午夜之后的部分归属前一天
+func (c *Calendar) InBusinessHours(t time.Time) bool {
+	t = t.In(c.location)
+	minute := t.Hour()*60 + t.Minute()
+
+	day := t
+	if c.end < c.start {
+		if minute >= c.end && minute < c.start {
+			return false
+		}
+		if minute < c.end {
+			day = t.AddDate(0, 0, -1)
+		}
+	} else if minute < c.start || minute >= c.end {
+		return false
+	}
+	return c.workingDay(day)
+}
+
+// workingDay 判断日期是否为工作日：调休工作日 > 节假日 > 工作星期
+func (c *Calendar) workingDay(t time.Time) bool {
+	date := t.Format("2006-01-02")
+	if c.workdays[date] {
+		return true
+	}
+	if c.holidays[date] {
+		return false
+	}
+	return c.days[t.Weekday()]
+}
+
+// CalendarFor 返回目标适用的日历：第一个标签匹配的日历，无匹配时返回 nil
+func CalendarFor(calendars []*Calendar, tags []string) *Calendar {
+	for _, c := range calendars {
+		for _, ct := range c.Tags {
+			for _, t := range tags {
+				if ct == t {
+					return c
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// parseClock 解析 HH:MM 格式的时间，返回当天第几分钟，为空时使用默认值
+func parseClock(s, def string) (int, error) {
+	if s == "" {
+		s = def
+	}
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("应为 HH:MM 格式：%s", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
@@ -0,0 +1,128 @@
+package core
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// CertificateInfo 证书链中单张证书的信息
+type CertificateInfo struct {
+	Subject            string    `json:"subject"`            // 主题（RFC 2253 格式）
+	Issuer             string    `json:"issuer"`             // 签发者
+	SANs               []string  `json:"sans,omitempty"`     // 主题备用名称（DNS 名称、IP、邮箱、URI）
+	Serial             string    `json:"serial"`             // 序列号（十六进制）
+	SignatureAlgorithm string    `json:"signatureAlgorithm"` // 签名算法
+	PublicKey          string    `json:"publicKey"`          // 公钥算法与长度，如 RSA-2048、ECDSA-P256
+	NotBefore          time.Time `json:"notBefore"`
+	NotAfter           time.Time `json:"notAfter"`
+	DaysLeft           int       `json:"daysLeft"`           // 剩余天数，已过期为负数
+	IsCA               bool      `json:"isCa"`               // 是否为 CA 证书
+	SelfSigned         bool      `json:"selfSigned"`         // 是否自签名
+	SHA256             string    `json:"sha256"`             // 证书 SHA-256 指纹
+	Problems           []string  `json:"problems,omitempty"` // 该证书的问题：已过期、尚未生效、不是由链中下一张证书签发、签名算法不安全、与访问的主机名不匹配
+}
+
+// weakSignatureAlgorithms 不安全的签名算法
+var weakSignatureAlgorithms = map[x509.SignatureAlgorithm]bool{
+	x509.MD2WithRSA:    true,
+	x509.MD5WithRSA:    true,
+	x509.SHA1WithRSA:   true,
+	x509.DSAWithSHA1:   true,
+	x509.ECDSAWithSHA1: true,
+}
+
+// inspectChain 记录服务端发送的证书链，并判断证书链是否有效（可由系统信任的根证书验证，且与访问的主机名匹配）
+// 握手时已完成校验的直接视为有效；跳过校验（insecureSkipVerify）时另行验证，结果仅用于展示
+func inspectChain(state *tls.ConnectionState, details *TLSDetails, now time.Time) {
+	certs := state.PeerCertificates
+	if len(certs) == 0 {
+		return
+	}
+	for i, cert := range certs {
+		info := certificateInfo(cert, now)
+		if i+1 < len(certs) && cert.CheckSignatureFrom(certs[i+1]) != nil {
+			info.Problems = append(info.Problems, "不是由链中下一张证书签发")
+		}
+		if i == 0 && state.ServerName != "" && cert.VerifyHostname(state.ServerName) != nil {
+			info.Problems = append(info.Problems, "与访问的主机名 "+state.ServerName+" 不匹配")
+		}
+		details.Chain = append(details.Chain, info)
+	}
+
+	chains := state.VerifiedChains
+	if len(chains) == 0 {
+		intermediates := x509.NewCertPool()
+		for _, cert := range certs[1:] {
+			intermediates.AddCert(cert)
+		}
+		var err error
+		chains, err = certs[0].Verify(x509.VerifyOptions{DNSName: state.ServerName, Intermediates: intermediates, CurrentTime: now})
+		if err != nil {
+			details.ChainError = err.Error()
+			return
+		}
+	}
+	details.ChainValid = true
+	if chain := chains[0]; len(chain) > 0 {
+		details.TrustedRoot = chain[len(chain)-1].Subject.String()
+	}
+}
+
+// certificateInfo 提取证书信息，并检查有效期与签名算法
+func certificateInfo(cert *x509.Certificate, now time.Time) CertificateInfo {
+	fingerprint := sha256.Sum256(cert.Raw)
+	info := CertificateInfo{
+		Subject:            cert.Subject.String(),
+		Issuer:             cert.Issuer.String(),
+		Serial:             strings.ToUpper(cert.SerialNumber.Text(16)),
+		SignatureAlgorithm: cert.SignatureAlgorithm.String(),
+		PublicKey:          publicKeyDescription(cert),
+		NotBefore:          cert.NotBefore,
+		NotAfter:           cert.NotAfter,
+		DaysLeft:           int(cert.NotAfter.Sub(now).Hours() / 24),
+		IsCA:               cert.IsCA,
+		SelfSigned:         cert.Subject.String() == cert.Issuer.String() && cert.CheckSignatureFrom(cert) == nil,
+		SHA256:             hex.EncodeToString(fingerprint[:]),
+	}
+	info.SANs = append(info.SANs, cert.DNSNames...)
+	for _, ip := range cert.IPAddresses {
+		info.SANs = append(info.SANs, ip.String())
+	}
+	info.SANs = append(info.SANs, cert.EmailAddresses...)
+	for _, uri := range cert.URIs {
+		info.SANs = append(info.SANs, uri.String())
+	}
+
+	switch {
+	case now.After(cert.NotAfter):
+		info.Problems = append(info.Problems, "已过期")
+	case now.Before(cert.NotBefore):
+		info.Problems = append(info.Problems, "尚未生效")
+	}
+	// 自签名根证书的签名不参与信任判断，不提示算法问题
+	if weakSignatureAlgorithms[cert.SignatureAlgorithm] && !info.SelfSigned {
+		info.Problems = append(info.Problems, "签名算法不安全："+info.SignatureAlgorithm)
+	}
+	return info
+}
+
+// publicKeyDescription 返回公钥算法与长度
+func publicKeyDescription(cert *x509.Certificate) string {
+	switch key := cert.PublicKey.(type) {
+	case *rsa.PublicKey:
+		return fmt.Sprintf("RSA-%d", key.N.BitLen())
+	case *ecdsa.PublicKey:
+		return "ECDSA-" + strings.TrimPrefix(key.Curve.Params().Name, "P-")
+	case ed25519.PublicKey:
+		return "Ed25519"
+	}
+	return cert.PublicKeyAlgorithm.String()
+}
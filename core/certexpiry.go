@@ -0,0 +1,60 @@
+package core
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CertWarnDays 证书剩余天数低于该值时视为即将过期（检查结果记为警告）
+const CertWarnDays = 7
+
+// CertExpiryText 根据证书剩余天数生成展示文本：还有N天过期 / 今日过期 / 已过期N天
+func CertExpiryText(days int) string {
+	switch {
+	case days > 0:
+		return fmt.Sprintf("还有%d天过期", days)
+	case days == 0:
+		return "今日过期"
+	default:
+		return fmt.Sprintf("已过期%d天", -days)
+	}
+}
+
+// ParseCertExpiryText 从展示文本中解析证书剩余天数，用于没有 sslDaysLeft 的历史结果
+func ParseCertExpiryText(text string) (int, bool) {
+	var s string
+	sign := 1
+	switch {
+	case text == "今日过期":
+		return 0, true
+	case strings.HasPrefix(text, "还有") && strings.HasSuffix(text, "天过期"):
+		s = strings.TrimSuffix(strings.TrimPrefix(text, "还有"), "天过期")
+	case strings.HasPrefix(text, "已过期") && strings.HasSuffix(text, "天"):
+		s, sign = strings.TrimSuffix(strings.TrimPrefix(text, "已过期"), "天"), -1
+	default:
+		return 0, false
+	}
+	days, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, false
+	}
+	return sign * days, true
+}
+
+// SetCertExpiry 记录证书过期时间：notAfter、剩余天数（不足一天按 0 天计）与由剩余天数生成的展示文本
+func (r *MonitorResult) SetCertExpiry(notAfter, now time.Time) {
+	days := int(notAfter.Sub(now).Hours() / 24)
+	notAfter = notAfter.UTC()
+	r.SSLNotAfter, r.SSLDaysLeft = &notAfter, &days
+	r.SSLCertExpiry = CertExpiryText(days)
+}
+
+// CertDaysLeft 返回证书剩余天数：优先使用 sslDaysLeft，历史结果从展示文本解析；没有证书信息时返回 false
+func (r *MonitorResult) CertDaysLeft() (int, bool) {
+	if r.SSLDaysLeft != nil {
+		return *r.SSLDaysLeft, true
+	}
+	return ParseCertExpiryText(r.SSLCertExpiry)
+}
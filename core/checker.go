@@ -1,57 +1,95 @@
 package core
 
 import (
+	"context"
+	"crypto/sha256"
 	"crypto/tls"
 	"errors"
 	"fmt"
+	"hash"
 	"io"
+	"mime"
 	"net"
 	"net/http"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
 	"servicetelemetry/config"
+	"servicetelemetry/logger"
+	"servicetelemetry/metrics"
 )
 
+var log = logger.New("core")
+
 // 新增：错误分类枚举
 type ErrorType string
 
 const (
-	ErrorTypeNetwork ErrorType = "network" // 网络错误
-	ErrorTypeTimeout ErrorType = "timeout" // 超时错误
-	ErrorTypeSSL     ErrorType = "ssl"     // SSL证书错误
-	ErrorTypeHTTP    ErrorType = "http"    // HTTP状态码错误
-	ErrorTypeKeyword ErrorType = "keyword" // 关键词匹配错误
-	ErrorTypeInvalid ErrorType = "invalid" // 无效地址错误
-	ErrorTypeUnknown ErrorType = "unknown" // 未知错误
+	ErrorTypeNetwork   ErrorType = "network"     // 网络错误
+	ErrorTypeTimeout   ErrorType = "timeout"     // 超时错误
+	ErrorTypeSSL       ErrorType = "ssl"         // SSL证书错误
+	ErrorTypeRevoked   ErrorType = "ssl_revoked" // OCSP 吊销状态为 revoked 或 unknown（需开启 monitor.ocsp.failOnRevoked）
+	ErrorTypeHTTP      ErrorType = "http"        // HTTP状态码错误
+	ErrorTypeKeyword   ErrorType = "keyword"     // 关键词匹配错误
+	ErrorTypeAssert    ErrorType = "assertion"   // 响应断言失败
+	ErrorTypePolicy    ErrorType = "policy"      // 出站网络策略拒绝
+	ErrorTypeICMP      ErrorType = "icmp"        // ICMP 丢包或目标不可达
+	ErrorTypeDNS       ErrorType = "dns"         // DNS 应答错误或记录不符合预期
+	ErrorTypeGRPC      ErrorType = "grpc"        // gRPC 调用失败或服务状态不是 SERVING
+	ErrorTypeSMTP      ErrorType = "smtp"        // SMTP 服务返回错误应答或不满足 STARTTLS 要求
+	ErrorTypeSSH       ErrorType = "ssh"         // SSH 版本标识无效、密钥交换失败或主机公钥指纹不匹配
+	ErrorTypeRedis     ErrorType = "redis"       // Redis 认证失败或 PING 未返回 PONG
+	ErrorTypeDatabase  ErrorType = "database"    // 数据库认证失败、数据库不存在或 SELECT 1 执行失败
+	ErrorTypeChecksum  ErrorType = "checksum"    // 下载文件的 SHA-256 与期望值不一致、超过大小上限或摘要文件不可用
+	ErrorTypeNTP       ErrorType = "ntp"         // NTP 服务器未同步、拒绝服务，或时钟偏差、层级超过阈值
+	ErrorTypeKafka     ErrorType = "kafka"       // Kafka broker 返回错误、没有控制器或分区没有 leader
+	ErrorTypeMQTT      ErrorType = "mqtt"        // MQTT 服务拒绝连接（CONNACK 返回码非 0）或握手异常
+	ErrorTypeFTP       ErrorType = "ftp"         // FTP 服务返回错误响应（登录失败、目录不存在、不支持被动模式等）
+	ErrorTypeSFTP      ErrorType = "sftp"        // SSH 握手或认证失败、主机公钥指纹不匹配、sftp 子系统不可用或列目录失败
+	ErrorTypeSlow      ErrorType = "slow"        // 检查成功但响应耗时超过目标的 maxResponseTimeMs
+	ErrorTypeRedirect  ErrorType = "redirect"    // 重定向循环、超过最大重定向次数或 Location 无效
+	ErrorTypeProtocol  ErrorType = "protocol"    // 服务器未协商要求的 HTTP 版本（HTTP/2 / HTTP/3），或 HTTP/3 的 QUIC 连接失败
+	ErrorTypeHeartbeat ErrorType = "heartbeat"   // 超过预期间隔与容许延迟未收到心跳（heartbeat:// 目标）
+	ErrorTypeInvalid   ErrorType = "invalid"     // 无效地址错误
+	ErrorTypeUnknown   ErrorType = "unknown"     // 未知错误
 )
 
 // 新增：监控结果缓存
 var (
 	resultCache = make(map[string]*MonitorResult)
+	lastKnown   = make(map[string]*MonitorResult) // 各目标最近一次检查结果，不随缓存过期清理
 	cacheMu     sync.RWMutex
 )
 
 // ServiceChecker 服务检查器，负责执行具体的服务可用性检查
 type ServiceChecker struct {
-	cfg      *config.MonitorConfig
-	cacheTTL time.Duration
+	cfg        *config.MonitorConfig
+	cacheTTL   time.Duration
+	heartbeats HeartbeatStore // 心跳记录，未设置时 heartbeat:// 目标检查失败
+	traceSem   chan struct{}  // 限制同时进行的路由跟踪数
 }
 
 // NewServiceChecker 创建一个新的服务检查器
 func NewServiceChecker(cfg *config.MonitorConfig) *ServiceChecker {
+	maxTraces := cfg.Traceroute.MaxConcurrent
+	if maxTraces <= 0 {
+		maxTraces = 1
+	}
 	return &ServiceChecker{
 		cfg:      cfg,
 		cacheTTL: cfg.CacheTTL,
+		traceSem: make(chan struct{}, maxTraces),
 	}
 }
 
-// 新增：获取缓存的监控结果
+// 新增：获取缓存的监控结果，按规范化后的地址查找，同一目标的不同写法共用缓存
 func (sc *ServiceChecker) GetCachedResult(targetURL string) (*MonitorResult, bool) {
+	key := CanonicalTargetURL(targetURL)
 	cacheMu.RLock()
 	defer cacheMu.RUnlock()
-	result, ok := resultCache[targetURL]
+	result, ok := resultCache[key]
 	if !ok {
 		return nil, false
 	}
@@ -64,9 +102,82 @@ func (sc *ServiceChecker) GetCachedResult(targetURL string) (*MonitorResult, boo
 
 // 新增：更新监控结果缓存
 func (sc *ServiceChecker) updateCache(result *MonitorResult) {
+	key := CanonicalTargetURL(result.TargetURL)
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+	resultCache[key] = result
+	lastKnown[key] = result
+}
+
+// Preload 使用历史结果预热缓存（启动时调用），已有更新结果的目标不会被覆盖
+// 仍在缓存有效期内的结果同时写入结果缓存，避免重启后立即重复检查
+func (sc *ServiceChecker) Preload(results []*MonitorResult) int {
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+	loaded := 0
+	for _, result := range results {
+		key := CanonicalTargetURL(result.TargetURL)
+		if existing, ok := lastKnown[key]; ok && !result.CheckedAt.After(existing.CheckedAt) {
+			continue
+		}
+		lastKnown[key] = result
+		if time.Since(result.CheckedAt) <= sc.cacheTTL {
+			resultCache[key] = result
+		}
+		loaded++
+	}
+	return loaded
+}
+
+// LastKnown 返回内存中目标最近一次检查结果（不随缓存过期清理），按规范化后的地址查找
+func (sc *ServiceChecker) LastKnown(targetURL string) (*MonitorResult, bool) {
+	key := CanonicalTargetURL(targetURL)
+	cacheMu.RLock()
+	defer cacheMu.RUnlock()
+	result, ok := lastKnown[key]
+	return result, ok
+}
+
+// CacheTTL 返回结果缓存有效期，有效期内重复检查同一目标直接返回缓存结果
+func (sc *ServiceChecker) CacheTTL() time.Duration {
+	return sc.cacheTTL
+}
+
+// RetryBackoff 返回各次尝试失败后、下一次重试前的等待时间（指数退避），最后一次尝试失败后不再等待
+func (sc *ServiceChecker) RetryBackoff() []time.Duration {
+	backoff := make([]time.Duration, sc.cfg.MaxRetry)
+	base := 100 * time.Millisecond
+	for i := 0; i < sc.cfg.MaxRetry; i++ {
+		backoff[i] = base * (1 << i)
+	}
+	return backoff
+}
+
+// ForgetTarget 清除目标的结果缓存与最近一次检查结果（目标被合并或删除后调用），按规范化后的地址匹配
+func (sc *ServiceChecker) ForgetTarget(targetURL string) {
+	key := CanonicalTargetURL(targetURL)
 	cacheMu.Lock()
 	defer cacheMu.Unlock()
-	resultCache[result.TargetURL] = result
+	delete(resultCache, key)
+	delete(lastKnown, key)
+}
+
+// LastKnownStates 返回内存中各目标最近一次检查结果（不查询数据库），异常目标排在前面
+func (sc *ServiceChecker) LastKnownStates() []*MonitorResult {
+	cacheMu.RLock()
+	list := make([]*MonitorResult, 0, len(lastKnown))
+	for _, result := range lastKnown {
+		list = append(list, result)
+	}
+	cacheMu.RUnlock()
+
+	sort.Slice(list, func(i, j int) bool {
+		if (list[i].Status == "failed") != (list[j].Status == "failed") {
+			return list[i].Status == "failed"
+		}
+		return list[i].TargetURL < list[j].TargetURL
+	})
+	return list
 }
 
 // 新增：清理过期缓存
@@ -90,25 +201,64 @@ func (sc *ServiceChecker) CleanExpiredCache() {
 
 // CheckTarget 检查单个监控目标的可用性（增强版）
 func (sc *ServiceChecker) CheckTarget(target *MonitorTarget) *MonitorResult {
+	result, _ := sc.CheckTargetCached(target)
+	return result
+}
+
+// CheckTargetCached 与 CheckTarget 相同，同时返回结果是否取自缓存（缓存命中时未执行检查）
+func (sc *ServiceChecker) CheckTargetCached(target *MonitorTarget) (*MonitorResult, bool) {
 	// 先检查缓存
 	if cachedResult, ok := sc.GetCachedResult(target.URL); ok {
-		return cachedResult
+		return cachedResult, true
+	}
+
+	result := sc.Probe(target)
+
+	// 更新缓存
+	sc.updateCache(result)
+
+	return result, false
+}
+
+// Probe 直接执行一次检查（含重试），不读取也不更新结果缓存，供演练模式使用
+// 地址族为 both 的目标分别经由 IPv4 与 IPv6 检查，合并为一个结果
+func (sc *ServiceChecker) Probe(target *MonitorTarget) *MonitorResult {
+	var result *MonitorResult
+	if target.AddressFamily == FamilyBoth {
+		result = sc.probeDualStack(target)
+	} else {
+		result = sc.probeOnce(target)
 	}
 
+	scheme := targetScheme(target.URL)
+	if scheme == "" {
+		scheme = "http"
+	}
+	metrics.ObserveCheck(scheme, result.Status, result.ErrorType, result.ResponseTime)
+
+	if sc.cfg.Region != "" {
+		result.details().Region = sc.cfg.Region
+	}
+	if target.DomainExpiry != nil {
+		sc.inspectDomainExpiry(target, result)
+	}
+	log.Debugf("检查[%s]完成：status=%s statusCode=%d responseTime=%.0fms", target.URL, result.Status, result.StatusCode, result.ResponseTime)
+	return result
+}
+
+// probeOnce 执行一次检查（含重试）并应用耗时阈值
+func (sc *ServiceChecker) probeOnce(target *MonitorTarget) *MonitorResult {
 	// 初始化监控结果
 	result := &MonitorResult{
 		TargetURL:  target.URL,
 		CheckedAt:  time.Now(),
 		StatusCode: 0,
 		ErrorType:  "", // 新增字段
+		Tags:       target.Tags,
 	}
 
 	// 生成指数退避重试间隔
-	backoff := make([]time.Duration, sc.cfg.MaxRetry)
-	base := 100 * time.Millisecond
-	for i := 0; i < sc.cfg.MaxRetry; i++ {
-		backoff[i] = base * (1 << i)
-	}
+	backoff := sc.RetryBackoff()
 
 	var lastErr error
 	var errType ErrorType
@@ -117,26 +267,69 @@ func (sc *ServiceChecker) CheckTarget(target *MonitorTarget) *MonitorResult {
 	for retry := 0; retry < sc.cfg.MaxRetry; retry++ {
 		start := time.Now()
 
-		// 区分TCP和HTTP/HTTPS服务
-		if strings.HasPrefix(strings.ToLower(target.URL), "tcp://") {
-			lastErr, errType = sc.checkTCP(target.URL, result)
-		} else {
-			lastErr, errType = sc.checkHTTP(target.URL, target.Keyword, result)
+		// 按协议区分 TCP、UDP、ICMP、DNS、gRPC、SMTP、SSH、Redis、数据库、MQTT、Kafka、NTP、FTP/SFTP、心跳和 HTTP/HTTPS 服务
+		switch targetScheme(target.URL) {
+		case "tcp":
+			lastErr, errType = sc.checkTCP(target, result)
+		case "icmp":
+			lastErr, errType = sc.checkICMP(target, result)
+		case "dns":
+			lastErr, errType = sc.checkDNS(target, result)
+		case "udp":
+			lastErr, errType = sc.checkUDP(target, result)
+		case "grpc", "grpcs":
+			lastErr, errType = sc.checkGRPC(target, result)
+		case "smtp", "smtps":
+			lastErr, errType = sc.checkSMTP(target, result)
+		case "ssh":
+			lastErr, errType = sc.checkSSH(target, result)
+		case "redis":
+			lastErr, errType = sc.checkRedis(target, result)
+		case "mysql", "postgres":
+			lastErr, errType = sc.checkDatabase(target, result)
+		case "mqtt", "mqtts":
+			lastErr, errType = sc.checkMQTT(target, result)
+		case "kafka":
+			lastErr, errType = sc.checkKafka(target, result)
+		case "ntp":
+			lastErr, errType = sc.checkNTP(target, result)
+		case "ftp":
+			lastErr, errType = sc.checkFTP(target, result)
+		case "sftp":
+			lastErr, errType = sc.checkSFTP(target, result)
+		case "heartbeat":
+			lastErr, errType = sc.checkHeartbeat(target, result)
+		case "tls":
+			lastErr, errType = sc.checkTLS(target, result)
+		default:
+			lastErr, errType = sc.checkHTTP(target, result)
 		}
 
-		// 计算响应耗时
+		// 计算响应耗时（ICMP 检查使用平均往返时延，DNS 检查使用解析耗时，NTP 检查使用往返时延）
 		result.ResponseTime = float64(time.Since(start).Milliseconds())
+		if d := result.Details; d != nil && d.ICMP != nil && d.ICMP.Received > 0 {
+			result.ResponseTime = d.ICMP.AvgMs
+		}
+		if d := result.Details; d != nil && d.DNS != nil && d.DNS.RCode != "" {
+			result.ResponseTime = d.DNS.LatencyMs
+		}
+		if d := result.Details; d != nil && d.NTP != nil && d.NTP.Stratum > 0 {
+			result.ResponseTime = d.NTP.DelayMs
+		}
 
 		// 检查成功
 		if lastErr == nil {
 			result.Status = "success"
 			result.ErrorMsg = ""
 			result.ErrorType = ""
+			result.Snapshot = nil
 			break
 		}
 
-		// 最后一次重试失败
-		if retry == sc.cfg.MaxRetry-1 {
+		log.Debugf("检查[%s]第%d次失败（%s）：%v", target.URL, retry+1, errType, lastErr)
+
+		// 最后一次重试失败（被网络策略拒绝、心跳超时、证书已吊销时重试没有意义，直接失败）
+		if retry == sc.cfg.MaxRetry-1 || errType == ErrorTypePolicy || errType == ErrorTypeHeartbeat || errType == ErrorTypeRevoked {
 			result.Status = "failed"
 			result.ErrorMsg = lastErr.Error()
 			result.ErrorType = string(errType)
@@ -145,15 +338,18 @@ func (sc *ServiceChecker) CheckTarget(target *MonitorTarget) *MonitorResult {
 		}
 	}
 
-	// 更新缓存
-	sc.updateCache(result)
+	target.applyLatencyThreshold(result)
 
+	// 记录目标指定的解析服务器，区分同一主机名不同解析视图的结果（DNS 检查记录在 dns 详情中）
+	if resolver, _ := targetResolver(target); resolver != "" && targetScheme(target.URL) != "dns" {
+		result.details().Resolver = resolver
+	}
 	return result
 }
 
 // checkTCP 检查TCP服务（增强错误分类）
-func (sc *ServiceChecker) checkTCP(url string, result *MonitorResult) (error, ErrorType) {
-	address := strings.TrimPrefix(url, "tcp://")
+func (sc *ServiceChecker) checkTCP(target *MonitorTarget, result *MonitorResult) (error, ErrorType) {
+	address, _, _ := strings.Cut(strings.TrimPrefix(target.URL, "tcp://"), "#")
 	if address == "" {
 		return errors.New("无效的TCP地址，格式应为 tcp://ip:port"), ErrorTypeInvalid
 	}
@@ -175,8 +371,14 @@ func (sc *ServiceChecker) checkTCP(url string, result *MonitorResult) (error, Er
 	_ = port // 最简修复：使用空白标识符标记变量已使用
 
 	// 建立TCP连接
-	conn, err := net.DialTimeout("tcp", address, sc.cfg.TCPTimeout)
+	dialer := sc.newDialer(target, sc.cfg.TCPTimeout)
+	conn, err := dialer.DialContext(context.Background(), "tcp", address)
+	result.recordDialAttempts(dialer.Attempts())
 	if err != nil {
+		var denied *EgressDeniedError
+		if errors.As(err, &denied) {
+			return denied, ErrorTypePolicy
+		}
 		if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
 			return fmt.Errorf("TCP连接超时：%w", err), ErrorTypeTimeout
 		}
@@ -189,82 +391,287 @@ func (sc *ServiceChecker) checkTCP(url string, result *MonitorResult) (error, Er
 }
 
 // checkHTTP 检查HTTP/HTTPS服务（增强错误分类）
-func (sc *ServiceChecker) checkHTTP(url string, keyword string, result *MonitorResult) (error, ErrorType) {
-	// 构建HTTP客户端
+func (sc *ServiceChecker) checkHTTP(target *MonitorTarget, result *MonitorResult) (err error, errType ErrorType) {
+	url := target.URL
+
+	// 解析响应断言，含 status 断言时替代默认的 2xx 状态码校验
+	var assertions []*Assertion
+	hasStatusAssertion, hasBodyAssertion := false, false
+	for _, expr := range target.Assertions {
+		a, err := ParseAssertion(expr)
+		if err != nil {
+			return err, ErrorTypeInvalid
+		}
+		switch a.Field {
+		case "status":
+			hasStatusAssertion = true
+		case "body":
+			hasBodyAssertion = true
+		}
+		assertions = append(assertions, a)
+	}
+
+	// 构建HTTP客户端，检查结束后记录拨号尝试
+	dialer := sc.newDialer(target, sc.cfg.HTTPTimeout)
+	defer func() { result.recordDialAttempts(dialer.Attempts()) }()
+	tlsConfig, tlsProfile, err := sc.tlsConfig(target)
+	if err != nil {
+		return err, ErrorTypeInvalid
+	}
+	proxyURL, err := sc.proxyFor(target)
+	if err != nil {
+		return err, ErrorTypeInvalid
+	}
+	if proxyURL != nil {
+		result.details().Proxy = proxyURL.Redacted()
+	}
+	// HTTP/3 传输层持有 QUIC 连接，检查结束后关闭
+	var transports []http.RoundTripper
+	defer func() {
+		for _, t := range transports {
+			if closer, ok := t.(io.Closer); ok {
+				closer.Close()
+			}
+		}
+	}()
+	transport, err := sc.httpTransport(target, tlsConfig, dialer, proxyURL)
+	if err != nil {
+		return err, ErrorTypeInvalid
+	}
+	transports = append(transports, transport)
 	client := &http.Client{
-		Timeout: sc.cfg.HTTPTimeout,
-		Transport: &http.Transport{
-			TLSClientConfig: &tls.Config{
-				InsecureSkipVerify: false,
-				MinVersion:         tls.VersionTLS12, // 强制TLS 1.2+
-			},
-			DisableKeepAlives: true, // 关闭长连接
-		},
-	}
-
-	// 构建GET请求
-	req, err := http.NewRequest("GET", url, nil)
+		Timeout:   sc.cfg.HTTPTimeout,
+		Transport: transport,
+	}
+	// 校验文件摘要时需要下载整个文件，整体超时改用摘要校验的超时
+	if target.Checksum != nil {
+		client.Timeout = sc.cfg.Checksum.Timeout
+	}
+	// 对冲请求使用新建的传输层，不与首个请求共用连接
+	newClient := func() (*http.Client, error) {
+		transport, err := sc.httpTransport(target, tlsConfig, dialer, proxyURL)
+		if err != nil {
+			return nil, err
+		}
+		transports = append(transports, transport)
+		return &http.Client{Timeout: client.Timeout, Transport: transport}, nil
+	}
+
+	// 构建请求：默认 GET，HEAD 模式只取状态码与响应头
+	method := http.MethodGet
+	if strings.EqualFold(target.Method, http.MethodHead) {
+		method = http.MethodHead
+	}
+	req, err := http.NewRequest(method, url, nil)
 	if err != nil {
 		return fmt.Errorf("创建HTTP请求失败：%w", err), ErrorTypeInvalid
 	}
-
 	// 添加自定义User-Agent
 	req.Header.Set("User-Agent", "ServiceMonitor/1.0 (+https://github.com/example/servicemonitor)")
+	if target.HostHeader != "" {
+		req.Host = target.HostHeader
+	}
+	if err := target.applyRequestAuth(req); err != nil {
+		return err, ErrorTypeInvalid
+	}
 
-	// 发送HTTP请求
-	resp, err := client.Do(req)
+	// 发送HTTP请求（配置了 hedge 时按对冲方式发送），各阶段耗时与重定向链取自采用的请求
+	// 请求失败时同样保留已经历的阶段，便于判断慢在哪里
+	attempt := doHTTP(target, sc.urlPolicyFor(target), client, req, newClient, result)
+	defer attempt.cancel()
+	tracer, redirects := attempt.tracer, attempt.redirects
+	defer func() { result.Timings = tracer.result() }()
+	resp, err := attempt.resp, attempt.err
 	if err != nil {
+		// 请求失败时同样记录已经过的重定向链，便于定位在哪一跳出错
+		if len(redirects.hops) > 0 {
+			result.details().HTTP = &HTTPDetails{Method: method, ContentLength: -1, Redirects: redirects.hops}
+		}
+		if re, ok := redirectError(err); ok {
+			return re, ErrorTypeRedirect
+		}
+		var denied *EgressDeniedError
+		if errors.As(err, &denied) {
+			return denied, ErrorTypePolicy
+		}
 		if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
 			return fmt.Errorf("HTTP请求超时：%w", err), ErrorTypeTimeout
 		}
 		if strings.Contains(err.Error(), "certificate") {
 			return fmt.Errorf("SSL证书验证失败：%w", err), ErrorTypeSSL
 		}
+		if quicProtocolError(err) {
+			return fmt.Errorf("HTTP/3请求失败：%w", err), ErrorTypeProtocol
+		}
 		return fmt.Errorf("HTTP请求失败：%w", err), ErrorTypeNetwork
 	}
 	defer resp.Body.Close()
 
-	// 读取响应体
-	body, err := io.ReadAll(io.LimitReader(resp.Body, sc.cfg.MaxBodySize))
-	if err != nil {
-		return fmt.Errorf("读取响应体失败：%w", err), ErrorTypeUnknown
+	// 检查失败时保存响应快照（状态码、响应头与截断的响应体），便于查看服务器实际返回的内容
+	var body []byte
+	defer func() {
+		if err != nil {
+			sc.captureSnapshot(target, resp, body, result.Details.HTTP.BodyRead, result)
+		}
+	}()
+
+	details := &HTTPDetails{Method: method, Protocol: resp.Proto, ContentType: resp.Header.Get("Content-Type"), ContentLength: resp.ContentLength}
+	redirects.record(details)
+	result.details().HTTP = details
+
+	// 强制 HTTP/2 时服务器必须协商 h2；强制 HTTP/3 时还要求响应通过 Alt-Svc 声明 h3，否则浏览器不会切换到 HTTP/3
+	if target.HTTPVersion == HTTPVersion2 && resp.ProtoMajor != 2 {
+		result.StatusCode = resp.StatusCode
+		return fmt.Errorf("服务器未协商 HTTP/2（实际为 %s）", resp.Proto), ErrorTypeProtocol
+	}
+	if target.HTTPVersion == HTTPVersion3 && resp.ProtoMajor != 3 {
+		result.StatusCode = resp.StatusCode
+		return fmt.Errorf("请求未经 HTTP/3 发送（实际为 %s）", resp.Proto), ErrorTypeProtocol
+	}
+	if target.HTTPVersion == HTTPVersion3 {
+		details.AltSvc = resp.Header.Get("Alt-Svc")
+		if !advertisesH3(details.AltSvc) {
+			result.addWarning("响应头 Alt-Svc 未声明 h3，浏览器不会切换到 HTTP/3")
+		}
+	}
+
+	// 二进制内容（图片、压缩包、安装包等）不做关键词匹配
+	scanKeyword := target.hasKeywords()
+	if scanKeyword && isBinaryContentType(details.ContentType) {
+		scanKeyword = false
+		result.addWarning(fmt.Sprintf("响应为二进制内容（%s），已跳过关键词匹配", details.ContentType))
+	}
+
+	// 校验文件摘要时，读取的响应体同时计入摘要
+	bodyReader := io.Reader(resp.Body)
+	var hasher hash.Hash
+	if target.Checksum != nil {
+		hasher = sha256.New()
+		bodyReader = io.TeeReader(resp.Body, hasher)
+	}
+
+	// 只有关键词、body 断言或响应比对需要时才读取响应体，其余情况只确认可达与状态码，不下载响应体
+	if method != http.MethodHead && (scanKeyword || hasBodyAssertion || target.Compare != nil) {
+		body, err = io.ReadAll(io.LimitReader(bodyReader, sc.cfg.MaxBodySize))
+		if err != nil {
+			return fmt.Errorf("读取响应体失败：%w", err), ErrorTypeUnknown
+		}
+		details.BodyRead, details.BodyBytes = true, len(body)
+		tracer.bodyDone()
 	}
 
 	// 记录HTTP状态码
 	result.StatusCode = resp.StatusCode
 
 	// 关键词匹配
-	if keyword != "" {
-		result.KeywordMatched = strings.Contains(string(body), keyword)
-		if !result.KeywordMatched {
-			return fmt.Errorf("响应体未找到关键词：%s", keyword), ErrorTypeKeyword
+	if scanKeyword {
+		checks, err := target.matchKeywords(string(body))
+		if !target.Keywords.Empty() {
+			result.details().Keywords = checks
+		}
+		result.KeywordMatched = err == nil
+		if err != nil {
+			return err, ErrorTypeKeyword
 		}
 	}
 
-	// 提取SSL证书信息
-	if resp.TLS != nil && len(resp.TLS.PeerCertificates) > 0 {
-		cert := resp.TLS.PeerCertificates[0]
-		expiry := cert.NotAfter
-		days := int(expiry.Sub(time.Now()).Hours() / 24)
-
-		if days > 0 {
-			result.SSLCertExpiry = fmt.Sprintf("还有%d天过期", days)
-		} else if days == 0 {
-			result.SSLCertExpiry = "今日过期"
-		} else {
-			result.SSLCertExpiry = fmt.Sprintf("已过期%d天", -days)
+	// 记录TLS握手信息与证书有效期
+	if resp.TLS != nil {
+		sc.recordTLS(target, tlsProfile, resp.TLS, result)
+		if err := sc.revocationError(result); err != nil {
+			return err, ErrorTypeRevoked
 		}
+	}
 
-		// 检查证书有效期（提前预警）
-		if days < 7 {
-			result.Warning = fmt.Sprintf("SSL证书即将过期（剩余%d天）", days) // 新增字段
+	// 校验响应断言
+	for _, a := range assertions {
+		if err := a.Evaluate(resp.StatusCode, resp.Header, body); err != nil {
+			return err, ErrorTypeAssert
 		}
 	}
 
-	// 验证HTTP状态码
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+	// 验证HTTP状态码（不跟随重定向时 3xx 即为预期的响应）
+	if !hasStatusAssertion && (resp.StatusCode < 200 || resp.StatusCode >= 300) && !(redirects.stopped() && resp.StatusCode < 400) {
 		return fmt.Errorf("HTTP状态码异常：%d", resp.StatusCode), ErrorTypeHTTP
 	}
 
+	// 文件摘要校验（下载剩余部分，大小受上限约束）
+	if target.Checksum != nil {
+		err, errType := sc.verifyChecksum(attempt.client, target, bodyReader, hasher, int64(len(body)), result)
+		tracer.bodyDone()
+		if err != nil {
+			return err, errType
+		}
+	}
+
+	// 响应一致性比对（仅在目标检查通过后进行，差异记为警告）
+	if target.Compare != nil {
+		sc.compareResponse(target, tlsConfig, resp.StatusCode, body, result)
+	}
+
 	return nil, ""
 }
+
+// isBinaryContentType 判断响应类型是否为二进制内容（图片、音视频、字体、压缩包、安装包等）
+// 未声明类型或无法识别的类型按文本处理
+func isBinaryContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	switch major, minor, _ := strings.Cut(mediaType, "/"); major {
+	case "image", "audio", "video", "font":
+		// SVG 为 XML 文本
+		return minor != "svg+xml"
+	case "application":
+		switch minor {
+		case "octet-stream", "zip", "gzip", "x-gzip", "x-tar", "x-7z-compressed", "x-rar-compressed", "x-bzip2", "x-xz",
+			"zstd", "pdf", "wasm", "java-archive", "vnd.android.package-archive", "x-msdownload", "x-apple-diskimage",
+			"x-protobuf", "protobuf", "grpc", "msgpack", "x-msgpack", "cbor":
+			return true
+		}
+	}
+	return false
+}
+
+// recordTLS 记录TLS握手信息（跳过证书校验时给出明确警告）与证书有效期
+// profile：目标使用的 TLS 配置档
+// state：TLS 连接状态
+func (sc *ServiceChecker) recordTLS(target *MonitorTarget, profile config.TLSProfileConfig, state *tls.ConnectionState, result *MonitorResult) {
+	profileName := target.TLSProfile
+	if profileName == "" {
+		profileName = DefaultTLSProfile
+	}
+	result.details().TLS = &TLSDetails{
+		Profile:            profileName,
+		Version:            tlsVersionName(state.Version),
+		CipherSuite:        tls.CipherSuiteName(state.CipherSuite),
+		InsecureSkipVerify: profile.InsecureSkipVerify,
+	}
+	if opts := target.TLS; opts != nil {
+		result.Details.TLS.CAFile = opts.CAFile
+		if opts.InsecureSkipVerify {
+			// 目标级选项跳过的校验，警告中注明来源，避免误以为是配置档的设置
+			profileName += "，目标 tls.insecureSkipVerify"
+		}
+	}
+	if profile.InsecureSkipVerify {
+		result.addWarning("已跳过证书校验（TLS配置档：" + profileName + "），证书状态不可信")
+	}
+	result.Details.TLS.OCSP = sc.inspectOCSP(target, state, result)
+	inspectChain(state, result.Details.TLS, time.Now())
+	if profile.InsecureSkipVerify && result.Details.TLS.ChainError != "" {
+		result.addWarning("证书链无效：" + result.Details.TLS.ChainError)
+	}
+
+	// 提取SSL证书信息
+	if len(state.PeerCertificates) > 0 {
+		result.SetCertExpiry(state.PeerCertificates[0].NotAfter, time.Now())
+		days := *result.SSLDaysLeft
+
+		// 检查证书有效期（提前预警）
+		if days < CertWarnDays {
+			result.addWarning(fmt.Sprintf("SSL证书即将过期（剩余%d天）", days))
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// maxChecksumFileSize 摘要文件（如 SHA256SUMS）的最大读取大小
+const maxChecksumFileSize = 1 << 20
+
+// sha256Pattern SHA-256 摘要的十六进制形式
+var sha256Pattern = regexp.MustCompile(`^[0-9a-fA-F]{64}$`)
+
+// bsdChecksumLine BSD 风格的摘要行：SHA256 (文件名) = 摘要
+var bsdChecksumLine = regexp.MustCompile(`^SHA256 \((.+)\) = ([0-9a-fA-F]{64})$`)
+
+// verifyChecksum 下载目标文件的剩余部分并校验 SHA-256 摘要
+// body：尚未读取的响应体，读取的内容同时写入 hasher；read：此前已读取（用于关键词或断言）的字节数
+// 期望摘要来自目标配置的 sha256，或先请求发布方的摘要文件（在下载文件之前，摘要文件不可用时不下载）
+func (sc *ServiceChecker) verifyChecksum(client *http.Client, target *MonitorTarget, body io.Reader, hasher hash.Hash, read int64, result *MonitorResult) (error, ErrorType) {
+	opts := target.Checksum
+	details := &ChecksumDetails{Source: "config", Expected: strings.ToLower(opts.SHA256)}
+	result.details().Checksum = details
+	if opts.URL != "" {
+		details.Source = opts.URL
+		expected, err := sc.fetchChecksum(client, target, opts.URL)
+		if err != nil {
+			var denied *EgressDeniedError
+			if errors.As(err, &denied) {
+				return denied, ErrorTypePolicy
+			}
+			return fmt.Errorf("获取摘要文件失败：%w", err), ErrorTypeChecksum
+		}
+		details.Expected = expected
+	}
+
+	maxSize := opts.MaxSize
+	if maxSize <= 0 {
+		maxSize = sc.cfg.Checksum.MaxSize
+	}
+	start := time.Now()
+	n, err := io.Copy(io.Discard, io.LimitReader(body, maxSize-read+1))
+	details.Bytes = read + n
+	details.DownloadMs = float64(time.Since(start).Milliseconds())
+	if err != nil {
+		if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+			return fmt.Errorf("下载文件超时（已下载 %d 字节）", details.Bytes), ErrorTypeTimeout
+		}
+		return fmt.Errorf("下载文件失败（已下载 %d 字节）：%w", details.Bytes, err), ErrorTypeNetwork
+	}
+	if details.Bytes > maxSize {
+		return fmt.Errorf("文件超过摘要校验的大小上限（%d 字节）", maxSize), ErrorTypeChecksum
+	}
+
+	details.Actual = hex.EncodeToString(hasher.Sum(nil))
+	details.Matched = details.Actual == details.Expected
+	if !details.Matched {
+		return fmt.Errorf("文件 SHA-256 不匹配：期望 %s，实际 %s", details.Expected, details.Actual), ErrorTypeChecksum
+	}
+	return nil, ""
+}
+
+// fetchChecksum 请求摘要文件并取出目标文件对应的 SHA-256
+func (sc *ServiceChecker) fetchChecksum(client *http.Client, target *MonitorTarget, checksumURL string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), sc.cfg.HTTPTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, checksumURL, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("User-Agent", "ServiceMonitor/1.0 (+https://github.com/example/servicemonitor)")
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("HTTP状态码 %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxChecksumFileSize))
+	if err != nil {
+		return "", err
+	}
+	u, err := url.Parse(target.URL)
+	if err != nil {
+		return "", err
+	}
+	return parseChecksumFile(data, path.Base(u.Path))
+}
+
+// parseChecksumFile 从摘要文件中取出指定文件的 SHA-256
+// 支持 sha256sum 输出格式（摘要 + 空格 + [*]文件名，每行一个文件）、BSD 格式（SHA256 (文件名) = 摘要）
+// 以及只包含一个摘要、不带文件名的 .sha256 文件；文件名按路径的最后一段比较
+func parseChecksumFile(data []byte, name string) (string, error) {
+	var single []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if m := bsdChecksumLine.FindStringSubmatch(line); m != nil {
+			if path.Base(m[1]) == name {
+				return strings.ToLower(m[2]), nil
+			}
+			continue
+		}
+		fields := strings.Fields(line)
+		if !sha256Pattern.MatchString(fields[0]) {
+			continue
+		}
+		if len(fields) == 1 {
+			single = append(single, fields[0])
+			continue
+		}
+		if path.Base(strings.TrimPrefix(fields[1], "*")) == name {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+	if len(single) == 1 {
+		return strings.ToLower(single[0]), nil
+	}
+	return "", fmt.Errorf("摘要文件中没有 %s 对应的 SHA-256 摘要", name)
+}
@@ -0,0 +1,287 @@
+package core
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"servicetelemetry/config"
+)
+
+// maxDivergences 单次比对最多记录的差异数
+const maxDivergences = 10
+
+// maxDivergenceValue 差异中记录的值的最大长度
+const maxDivergenceValue = 120
+
+// compareResponse 请求比对地址并与目标的响应比较，差异记录在 details.comparison 中并给出 divergence 警告
+// 比对请求使用独立的拨号器（同样遵循出站网络策略与源地址配置），拨号记录不计入目标的 dialAttempts
+// tlsConfig：目标检查使用的 TLS 配置（沿用 TLS 配置档，SNI 由比对地址决定）
+// statusCode、body：目标的响应
+func (sc *ServiceChecker) compareResponse(target *MonitorTarget, tlsConfig *tls.Config, statusCode int, body []byte, result *MonitorResult) {
+	opts := target.Compare
+	cmp := &ComparisonDetails{URL: opts.URL}
+	result.details().Comparison = cmp
+	proxyURL, err := sc.proxyFor(target)
+	if err != nil {
+		cmp.Error = err.Error()
+		result.addTypedWarning(WarningTypeDivergence, "代理配置无效："+err.Error())
+		return
+	}
+
+	tlsConfig = tlsConfig.Clone()
+	tlsConfig.ServerName = ""
+	dialer := sc.newDialer(target, sc.cfg.HTTPTimeout)
+	client := &http.Client{
+		Timeout: sc.cfg.HTTPTimeout,
+		// 与目标请求一样按目标的重定向策略跟随，跳转到其他主机时去掉自定义请求头并校验跳转地址
+		CheckRedirect: newRedirectRecorder(target, sc.urlPolicyFor(target)).CheckRedirect,
+		Transport: &http.Transport{
+			TLSClientConfig:   tlsConfig,
+			DisableKeepAlives: true,
+			DialContext:       dialer.DialContext,
+			Proxy:             httpProxy(proxyURL, dialer),
+		},
+	}
+
+	req, err := http.NewRequest("GET", opts.URL, nil)
+	if err != nil {
+		cmp.Error = err.Error()
+		result.addTypedWarning(WarningTypeDivergence, "比对地址无效："+err.Error())
+		return
+	}
+	req.Header.Set("User-Agent", "ServiceMonitor/1.0 (+https://github.com/example/servicemonitor)")
+	// 比对地址通常是同一服务的另一环境，沿用目标的请求头与认证
+	if err := target.applyRequestAuth(req); err != nil {
+		cmp.Error = err.Error()
+		result.addTypedWarning(WarningTypeDivergence, "比对请求认证配置无效："+err.Error())
+		return
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		cmp.Error = err.Error()
+		result.addTypedWarning(WarningTypeDivergence, "比对地址请求失败："+err.Error())
+		return
+	}
+	defer resp.Body.Close()
+	other, err := io.ReadAll(io.LimitReader(resp.Body, sc.cfg.MaxBodySize))
+	if err != nil {
+		cmp.Error = err.Error()
+		result.addTypedWarning(WarningTypeDivergence, "读取比对地址响应失败："+err.Error())
+		return
+	}
+	cmp.StatusCode = resp.StatusCode
+
+	add := func(field string, a, b interface{}) {
+		if len(cmp.Divergences) < maxDivergences {
+			cmp.Divergences = append(cmp.Divergences, Divergence{Field: field, Primary: divergenceValue(a), Secondary: divergenceValue(b)})
+		}
+		cmp.Total++
+	}
+	if statusCode != resp.StatusCode {
+		add("status", statusCode, resp.StatusCode)
+	}
+	compareBodies(opts, body, other, add)
+
+	cmp.Matched = cmp.Total == 0
+	if !cmp.Matched {
+		fields := make([]string, 0, len(cmp.Divergences))
+		for _, d := range cmp.Divergences {
+			fields = append(fields, d.Field)
+		}
+		result.addTypedWarning(WarningTypeDivergence, fmt.Sprintf("与比对地址响应不一致（%d 处）：%s", cmp.Total, strings.Join(fields, "、")))
+	}
+}
+
+// compareBodies 比较两个响应体：指定字段时按 JSON 字段比较，否则两者均为 JSON 时逐字段比较，不是 JSON 时按字节比较
+func compareBodies(opts *config.CompareOptions, a, b []byte, add func(field string, a, b interface{})) {
+	var ja, jb interface{}
+	aErr := decodeJSON(a, &ja)
+	bErr := decodeJSON(b, &jb)
+
+	if len(opts.Fields) > 0 {
+		if aErr != nil || bErr != nil {
+			add("body", jsonState(aErr), jsonState(bErr))
+			return
+		}
+		for _, path := range opts.Fields {
+			va, okA := lookupJSONPath(ja, path)
+			vb, okB := lookupJSONPath(jb, path)
+			switch {
+			case !okA && !okB:
+			case !okA || !okB:
+				add(path, missingOr(va, okA), missingOr(vb, okB))
+			case !jsonEqual(va, vb, opts.Tolerance):
+				add(path, va, vb)
+			}
+		}
+		return
+	}
+
+	if aErr == nil && bErr == nil {
+		ignore := make(map[string]bool, len(opts.Ignore))
+		for _, path := range opts.Ignore {
+			ignore[path] = true
+		}
+		diffJSON("", ja, jb, opts.Tolerance, ignore, add)
+		return
+	}
+	if !bytes.Equal(a, b) {
+		add("body", fmt.Sprintf("%d 字节", len(a)), fmt.Sprintf("%d 字节", len(b)))
+	}
+}
+
+// diffJSON 递归比较两个 JSON 值，记录每一处差异（路径以 . 分隔，数组下标为数字）
+func diffJSON(path string, a, b interface{}, tolerance float64, ignore map[string]bool, add func(field string, a, b interface{})) {
+	if ignore[path] {
+		return
+	}
+	field := path
+	if field == "" {
+		field = "body"
+	}
+
+	switch va := a.(type) {
+	case map[string]interface{}:
+		vb, ok := b.(map[string]interface{})
+		if !ok {
+			add(field, a, b)
+			return
+		}
+		keys := make([]string, 0, len(va)+len(vb))
+		for k := range va {
+			keys = append(keys, k)
+		}
+		for k := range vb {
+			if _, ok := va[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			child := joinJSONPath(path, k)
+			ca, okA := va[k]
+			cb, okB := vb[k]
+			if !okA || !okB {
+				if !ignore[child] {
+					add(child, missingOr(ca, okA), missingOr(cb, okB))
+				}
+				continue
+			}
+			diffJSON(child, ca, cb, tolerance, ignore, add)
+		}
+	case []interface{}:
+		vb, ok := b.([]interface{})
+		if !ok || len(va) != len(vb) {
+			add(field, a, b)
+			return
+		}
+		for i := range va {
+			diffJSON(joinJSONPath(path, strconv.Itoa(i)), va[i], vb[i], tolerance, ignore, add)
+		}
+	default:
+		if !jsonEqual(a, b, tolerance) {
+			add(field, a, b)
+		}
+	}
+}
+
+// jsonEqual 比较两个 JSON 值，数值按相对偏差（百分比）比较
+func jsonEqual(a, b interface{}, tolerance float64) bool {
+	na, okA := a.(json.Number)
+	nb, okB := b.(json.Number)
+	if okA && okB {
+		fa, errA := na.Float64()
+		fb, errB := nb.Float64()
+		if errA != nil || errB != nil {
+			return na == nb
+		}
+		if fa == fb {
+			return true
+		}
+		return tolerance > 0 && math.Abs(fa-fb) <= math.Max(math.Abs(fa), math.Abs(fb))*tolerance/100
+	}
+	ea, _ := json.Marshal(a)
+	eb, _ := json.Marshal(b)
+	return bytes.Equal(ea, eb)
+}
+
+// lookupJSONPath 按路径取 JSON 字段，路径以 . 分隔，数组下标为数字
+func lookupJSONPath(v interface{}, path string) (interface{}, bool) {
+	for _, key := range strings.Split(path, ".") {
+		switch node := v.(type) {
+		case map[string]interface{}:
+			child, ok := node[key]
+			if !ok {
+				return nil, false
+			}
+			v = child
+		case []interface{}:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil, false
+			}
+			v = node[i]
+		default:
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+// decodeJSON 解析 JSON，数值保留为 json.Number 以便精确比较
+func decodeJSON(data []byte, v *interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	if dec.More() {
+		return fmt.Errorf("响应体包含多个 JSON 值")
+	}
+	return nil
+}
+
+// joinJSONPath 拼接字段路径
+func joinJSONPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// missingOr 字段不存在时返回「（缺失）」
+func missingOr(v interface{}, ok bool) interface{} {
+	if !ok {
+		return "（缺失）"
+	}
+	return v
+}
+
+// jsonState 描述响应体能否解析为 JSON
+func jsonState(err error) string {
+	if err != nil {
+		return "（非 JSON）"
+	}
+	return "（JSON）"
+}
+
+// divergenceValue 将差异值转换为文本，过长时截断
+func divergenceValue(v interface{}) string {
+	s, ok := v.(string)
+	if !ok {
+		b, _ := json.Marshal(v)
+		s = string(b)
+	}
+	if r := []rune(s); len(r) > maxDivergenceValue {
+		s = string(r[:maxDivergenceValue]) + "..."
+	}
+	return s
+}
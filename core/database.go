@@ -0,0 +1,199 @@
+package core
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// 数据库类型
+const (
+	DatabaseMySQL    = "mysql"
+	DatabasePostgres = "postgres"
+)
+
+// probeNetwork 注册到 MySQL 驱动的拨号网络名，拨号时从上下文取出本次检查的拨号器（源地址绑定与出站网络策略）
+const probeNetwork = "servicetelemetry-probe"
+
+// dialerContextKey 上下文中保存拨号器的键
+type dialerContextKey struct{}
+
+func init() {
+	mysql.RegisterDialContext(probeNetwork, func(ctx context.Context, addr string) (net.Conn, error) {
+		dialer, ok := ctx.Value(dialerContextKey{}).(*probeDialer)
+		if !ok {
+			return nil, errors.New("缺少检查拨号器")
+		}
+		return dialer.DialContext(ctx, "tcp", addr)
+	})
+}
+
+// databaseQuery 数据库检查参数
+type databaseQuery struct {
+	engine   string // mysql / postgres
+	address  string // host:port
+	database string // 数据库名，来自地址路径
+	tls      string // MySQL：tls=true|false；PostgreSQL：sslmode=disable|prefer|require
+}
+
+// parseDatabaseURL 解析 mysql://host[:port]/db?tls=true 或 postgres://host[:port]/db?sslmode=require 形式的地址
+// 默认端口分别为 3306 与 5432；用户名与密码不写在地址中，通过目标的 credentials 配置
+func parseDatabaseURL(u *url.URL) (*databaseQuery, error) {
+	engine := strings.ToLower(u.Scheme)
+	if u.Hostname() == "" {
+		return nil, fmt.Errorf("数据库地址格式应为 %s://host:port/database", engine)
+	}
+	if u.User != nil {
+		return nil, fmt.Errorf("数据库地址中不能包含用户名或密码，请通过目标的 credentials（username / password）配置")
+	}
+	q := &databaseQuery{engine: engine, database: strings.Trim(u.Path, "/")}
+	port := u.Port()
+	switch {
+	case port == "" && engine == DatabaseMySQL:
+		port = "3306"
+	case port == "":
+		port = "5432"
+	}
+	if _, err := strconv.ParseUint(port, 10, 16); err != nil {
+		return nil, fmt.Errorf("无效的数据库端口：%s", port)
+	}
+	q.address = net.JoinHostPort(u.Hostname(), port)
+
+	if engine == DatabaseMySQL {
+		q.tls = "false"
+		if v := u.Query().Get("tls"); v != "" {
+			if v != "true" && v != "false" {
+				return nil, fmt.Errorf("无效的 tls：%s，可选 true / false", v)
+			}
+			q.tls = v
+		}
+		return q, nil
+	}
+	q.tls = PostgresSSLPrefer
+	if v := u.Query().Get("sslmode"); v != "" {
+		switch v {
+		case PostgresSSLDisable, PostgresSSLPrefer, PostgresSSLRequire:
+		default:
+			return nil, fmt.Errorf("无效的 sslmode：%s，可选 disable / prefer / require", v)
+		}
+		q.tls = v
+	}
+	return q, nil
+}
+
+// checkDatabase 检查数据库服务：使用目标配置的凭据建立连接并执行 SELECT 1，分别记录连接与查询耗时
+// 凭据取自目标的 credentials：username（必填）与 password，与存储检查结果使用的 db 配置无关
+func (sc *ServiceChecker) checkDatabase(target *MonitorTarget, result *MonitorResult) (error, ErrorType) {
+	u, err := url.Parse(target.URL)
+	if err != nil {
+		return fmt.Errorf("解析数据库地址失败：%w", err), ErrorTypeInvalid
+	}
+	q, err := parseDatabaseURL(u)
+	if err != nil {
+		return err, ErrorTypeInvalid
+	}
+	username, err := target.credential("username")
+	if err != nil {
+		return err, ErrorTypeInvalid
+	}
+	if username == "" {
+		return errors.New("数据库检查需要为目标配置 username 凭据"), ErrorTypeInvalid
+	}
+	password, err := target.credential("password")
+	if err != nil {
+		return err, ErrorTypeInvalid
+	}
+
+	timeout := sc.cfg.DatabaseTimeout
+	dialer := sc.newDialer(target, timeout)
+	ctx, cancel := context.WithTimeout(context.WithValue(context.Background(), dialerContextKey{}, dialer), timeout)
+	defer cancel()
+	defer func() { result.recordDialAttempts(dialer.Attempts()) }()
+
+	details := &DatabaseDetails{Engine: q.engine, Address: q.address, Database: q.database, User: username}
+	result.details().Database = details
+
+	if q.engine == DatabasePostgres {
+		return sc.checkPostgres(ctx, target, q, username, password, dialer, result)
+	}
+	return sc.checkMySQL(ctx, target, q, username, password, result)
+}
+
+// checkMySQL 通过 MySQL 驱动连接并执行 SELECT 1
+func (sc *ServiceChecker) checkMySQL(ctx context.Context, target *MonitorTarget, q *databaseQuery, username, password string, result *MonitorResult) (error, ErrorType) {
+	details := result.details().Database
+	cfg := mysql.NewConfig()
+	cfg.Net, cfg.Addr, cfg.DBName = probeNetwork, q.address, q.database
+	cfg.User, cfg.Passwd = username, password
+	cfg.Timeout = sc.cfg.DatabaseTimeout
+	if q.tls == "true" {
+		tlsConfig, _, err := sc.tlsConfig(target)
+		if err != nil {
+			return err, ErrorTypeInvalid
+		}
+		if tlsConfig.ServerName == "" {
+			tlsConfig.ServerName, _, _ = net.SplitHostPort(q.address)
+		}
+		cfg.TLS, details.TLS = tlsConfig, true
+	}
+	connector, err := mysql.NewConnector(cfg)
+	if err != nil {
+		return fmt.Errorf("MySQL连接配置无效：%w", err), ErrorTypeInvalid
+	}
+	db := sql.OpenDB(connector)
+	defer db.Close()
+
+	start := time.Now()
+	conn, err := db.Conn(ctx)
+	details.ConnectMs = float64(time.Since(start).Microseconds()) / 1000
+	if err != nil {
+		return mysqlError("MySQL连接失败", err)
+	}
+	defer conn.Close()
+
+	start = time.Now()
+	var one int
+	err = conn.QueryRowContext(ctx, "SELECT 1").Scan(&one)
+	details.QueryMs = float64(time.Since(start).Microseconds()) / 1000
+	if err != nil {
+		return mysqlError("执行 SELECT 1 失败", err)
+	}
+	if one != 1 {
+		return fmt.Errorf("SELECT 1 返回了意外的结果：%d", one), ErrorTypeDatabase
+	}
+	return nil, ""
+}
+
+// mysqlError 区分策略拒绝、超时、网络错误与服务端错误（如认证失败、数据库不存在）
+func mysqlError(action string, err error) (error, ErrorType) {
+	var denied *EgressDeniedError
+	if errors.As(err, &denied) {
+		return denied, ErrorTypePolicy
+	}
+	var myErr *mysql.MySQLError
+	if errors.As(err, &myErr) {
+		switch myErr.Number {
+		case 1045:
+			return fmt.Errorf("%s：用户名或密码错误", action), ErrorTypeDatabase
+		case 1049:
+			return fmt.Errorf("%s：数据库不存在", action), ErrorTypeDatabase
+		}
+		return fmt.Errorf("%s：%d %s", action, myErr.Number, myErr.Message), ErrorTypeDatabase
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() || errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("%s：超时", action), ErrorTypeTimeout
+	}
+	if strings.Contains(err.Error(), "certificate") {
+		return fmt.Errorf("%s：%w", action, err), ErrorTypeSSL
+	}
+	return fmt.Errorf("%s：%w", action, err), ErrorTypeNetwork
+}
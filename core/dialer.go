@@ -0,0 +1,559 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"servicetelemetry/config"
+
+	"golang.org/x/net/proxy"
+)
+
+// EgressDeniedError 出站连接被网络策略拒绝
+type EgressDeniedError struct {
+	Address string // 目标地址（主机名或IP）
+	Reason  string // 拒绝原因
+}
+
+func (e *EgressDeniedError) Error() string {
+	return fmt.Sprintf("出站连接[%s]被网络策略拒绝：%s", e.Address, e.Reason)
+}
+
+// probeDialer 检查器使用的拨号器，在建立连接前执行出站网络策略
+// 域名规则在解析前按主机名判断，地址段规则在解析后按实际连接的 IP 判断，可防止 DNS 重绑定绕过
+type probeDialer struct {
+	policy    *config.EgressPolicyConfig
+	timeout   time.Duration
+	localAddr net.IP // 源地址，为空由系统路由决定
+	bindErr   error  // 源地址或代理配置错误，拨号时返回
+
+	socks   proxy.ContextDialer // 经由 SOCKS5 代理拨号（TCP），未使用 SOCKS5 代理时为 nil
+	forward *probeDialer        // 连接 SOCKS5 代理服务器的拨号器
+
+	urlPolicy     *config.URLPolicyConfig // 外部提交的目标在连接时执行的地址安全校验策略，为 nil 时不校验
+	httpProxyAddr string                  // 经由 HTTP 代理时代理服务器的 host:port，连接代理服务器时不执行地址安全校验（目标主机在 proxyFunc 中校验）
+
+	family       string        // 只连接该地址族（FamilyV4 / FamilyV6）的地址，为空时不限制
+	resolver     *net.Resolver // 目标指定的解析器，为 nil 时使用系统解析
+	resolverAddr string        // 目标指定的解析服务器 ip:port
+
+	mu       sync.Mutex
+	attempts []DialAttempt // 已记录的拨号尝试
+}
+
+// newDialer 创建拨号器，目标未配置源地址 / 网卡时使用全局默认值
+// target：监控目标
+// timeout：单次连接超时
+func (sc *ServiceChecker) newDialer(target *MonitorTarget, timeout time.Duration) *probeDialer {
+	d := &probeDialer{
+		policy:    &sc.cfg.Egress,
+		timeout:   timeout,
+		urlPolicy: sc.urlPolicyFor(target),
+	}
+
+	sourceIP, iface := target.SourceIP, target.Interface
+	if sourceIP == "" && iface == "" {
+		sourceIP, iface = sc.cfg.SourceIP, sc.cfg.Interface
+	}
+	d.localAddr, d.bindErr = resolveSourceAddr(sourceIP, iface)
+	if d.bindErr != nil {
+		return d
+	}
+
+	// SOCKS5 代理对全部基于 TCP 的检查生效；HTTP 代理只对 HTTP 检查生效（见 httpProxy）
+	proxyURL, err := sc.proxyFor(target)
+	if err != nil {
+		d.bindErr = err
+		return d
+	}
+
+	scheme := targetScheme(target.URL)
+	proxied := isSOCKSProxy(proxyURL) || proxyURL != nil && (scheme == "http" || scheme == "https")
+
+	resolver, err := targetResolver(target)
+	if err != nil {
+		d.bindErr = err
+		return d
+	}
+	if resolver != "" {
+		// 经由代理时主机名由代理解析，指定的解析服务器不会生效，直接报错而不是静默忽略
+		if proxied {
+			d.bindErr = fmt.Errorf("经由代理 %s 检查时由代理解析主机名，不能同时指定解析服务器 %s（可将 proxy 设为 direct）", proxyURL.Redacted(), resolver)
+			return d
+		}
+		d.useResolver(resolver)
+	}
+	if family := target.AddressFamily; family == FamilyV4 || family == FamilyV6 {
+		if proxied {
+			d.bindErr = fmt.Errorf("经由代理 %s 检查时由代理连接目标，不能限定地址族（可将 proxy 设为 direct）", proxyURL.Redacted())
+			return d
+		}
+		d.family = family
+	}
+	if isSOCKSProxy(proxyURL) {
+		d.forward = &probeDialer{policy: d.policy, timeout: timeout, localAddr: d.localAddr}
+		d.socks, d.bindErr = socksDialer(proxyURL, d.forward)
+	} else if proxied {
+		d.httpProxyAddr = proxyURL.Host
+	}
+	return d
+}
+
+// ExternalHTTPClient 返回向外部提交的地址（如订阅人的 Webhook）发送请求使用的 HTTP 客户端：
+// 每次连接都执行出站网络策略，开启 blockPrivate 时同时执行地址安全校验策略（按实际连接的 IP 判断），不跟随重定向（3xx 作为响应返回）
+func (sc *ServiceChecker) ExternalHTTPClient(timeout time.Duration) *http.Client {
+	var urlPolicy *config.URLPolicyConfig
+	if sc.cfg.URLPolicy.BlockPrivate {
+		urlPolicy = &sc.cfg.URLPolicy
+	}
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			// 拨号器会记录拨号尝试，每次连接使用新的拨号器，避免长期使用的客户端累积记录
+			DialContext: func(ctx context.Context, network, address string) (net.Conn, error) {
+				d := &probeDialer{policy: &sc.cfg.Egress, timeout: timeout, urlPolicy: urlPolicy}
+				return d.DialContext(ctx, network, address)
+			},
+			TLSHandshakeTimeout: timeout,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// resolveSourceAddr 解析源地址：优先使用 sourceIP，否则取网卡的第一个 IPv4 地址（无 IPv4 时取 IPv6）
+func resolveSourceAddr(sourceIP, iface string) (net.IP, error) {
+	if sourceIP != "" {
+		ip := net.ParseIP(sourceIP)
+		if ip == nil {
+			return nil, fmt.Errorf("无效的源地址：%s", sourceIP)
+		}
+		return ip, nil
+	}
+	if iface == "" {
+		return nil, nil
+	}
+
+	ifi, err := net.InterfaceByName(iface)
+	if err != nil {
+		return nil, fmt.Errorf("网卡[%s]不存在：%w", iface, err)
+	}
+	addrs, err := ifi.Addrs()
+	if err != nil {
+		return nil, fmt.Errorf("读取网卡[%s]地址失败：%w", iface, err)
+	}
+	var v6 net.IP
+	for _, a := range addrs {
+		ipNet, ok := a.(*net.IPNet)
+		if !ok || ipNet.IP.IsLinkLocalUnicast() {
+			continue
+		}
+		if ipNet.IP.To4() != nil {
+			return ipNet.IP, nil
+		}
+		if v6 == nil {
+			v6 = ipNet.IP
+		}
+	}
+	if v6 == nil {
+		return nil, fmt.Errorf("网卡[%s]没有可用地址", iface)
+	}
+	return v6, nil
+}
+
+// DialContext 建立连接，违反出站策略时返回 *EgressDeniedError
+// 配置了源地址时只连接与源地址同协议族（IPv4 / IPv6）的目标地址
+func (d *probeDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	if d.bindErr != nil {
+		return nil, d.bindErr
+	}
+
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	// 限定地址族时非 TCP 连接（如 UDP、QUIC）由系统按 udp4 / udp6 解析
+	if d.family != "" && (network == "udp" || network == "tcp") {
+		network += strings.TrimPrefix(d.family, "v")
+	}
+
+	// 经由 SOCKS5 代理时由代理解析域名，地址段规则只能判断地址中直接写出的 IP
+	if d.socks != nil && strings.HasPrefix(network, "tcp") {
+		if err := d.checkProxiedHost(host); err != nil {
+			return nil, err
+		}
+		ctx, cancel := context.WithTimeout(ctx, d.timeout)
+		defer cancel()
+		conn, err := d.socks.DialContext(ctx, network, address)
+		if err != nil {
+			return nil, fmt.Errorf("经由SOCKS5代理连接失败：%w", err)
+		}
+		return conn, nil
+	}
+
+	domainAllowed, err := d.checkDomain(host)
+	if err != nil {
+		return nil, err
+	}
+	// 连接 HTTP 代理服务器（运维配置）时不执行地址安全校验
+	toProxy := d.httpProxyAddr != "" && strings.EqualFold(address, d.httpProxyAddr)
+	if !toProxy {
+		if err := d.checkURLPolicy(host, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	dialer := &net.Dialer{
+		Timeout:  d.timeout,
+		Resolver: d.resolver,
+		Control: func(_, resolved string, _ syscall.RawConn) error {
+			ipStr, _, err := net.SplitHostPort(resolved)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(ipStr)
+			if err := d.checkIP(host, ip, domainAllowed); err != nil {
+				return err
+			}
+			if toProxy {
+				return nil
+			}
+			return d.checkURLPolicy(host, ip)
+		},
+	}
+	if d.localAddr != nil {
+		if strings.HasPrefix(network, "udp") {
+			dialer.LocalAddr = &net.UDPAddr{IP: d.localAddr}
+		} else {
+			dialer.LocalAddr = &net.TCPAddr{IP: d.localAddr}
+		}
+	}
+	if !strings.HasPrefix(network, "tcp") {
+		return dialer.DialContext(ctx, network, address)
+	}
+	return d.dialRace(ctx, dialer, address)
+}
+
+// resolve 解析主机地址并执行出站网络策略，返回第一个允许连接的地址，供不经过 DialContext 的检查（如 ICMP）使用
+func (d *probeDialer) resolve(ctx context.Context, host string) (net.IP, error) {
+	if d.bindErr != nil {
+		return nil, d.bindErr
+	}
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	domainAllowed, err := d.checkDomain(host)
+	if err != nil {
+		return nil, err
+	}
+	if err := d.checkURLPolicy(host, nil); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, d.timeout)
+	defer cancel()
+	ips, err := d.lookup(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	var firstErr error
+	for _, ip := range ips {
+		err := d.checkIP(host, ip, domainAllowed)
+		if err == nil {
+			err = d.checkURLPolicy(host, ip)
+		}
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		return ip, nil
+	}
+	return nil, firstErr
+}
+
+// checkProxiedHost 经由代理连接目标时校验目标主机：代理负责解析域名，只能按域名规则与地址中直接写出的 IP 判断
+func (d *probeDialer) checkProxiedHost(host string) error {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	domainAllowed, err := d.checkDomain(host)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		if err := d.checkIP(host, ip, domainAllowed); err != nil {
+			return err
+		}
+	}
+	return d.checkURLPolicy(host, nil)
+}
+
+// checkURLPolicy 外部提交的目标按地址安全校验策略判断主机名与实际连接的 IP（ip 为 nil 时只判断主机名）
+// 提交时的校验无法防止 DNS 重绑定，连接时需要再次校验
+func (d *probeDialer) checkURLPolicy(host string, ip net.IP) error {
+	if d.urlPolicy == nil {
+		return nil
+	}
+	if reason := checkPolicyHost(host, d.urlPolicy); reason != "" {
+		return &EgressDeniedError{Address: host, Reason: reason}
+	}
+	if ip != nil && IsBlockedIP(ip, d.urlPolicy) && !hostAllowed(host, d.urlPolicy) {
+		return &EgressDeniedError{Address: host, Reason: fmt.Sprintf("%s 是受保护的内网地址", ip)}
+	}
+	return nil
+}
+
+// checkDomain 按域名规则判断主机名（IP 地址不判断），返回是否命中域名白名单，命中黑名单时返回 *EgressDeniedError
+func (d *probeDialer) checkDomain(host string) (bool, error) {
+	if net.ParseIP(host) != nil {
+		return false, nil
+	}
+	for _, pattern := range d.policy.DenyDomains {
+		if matchDomain(host, pattern) {
+			return false, &EgressDeniedError{Address: host, Reason: "命中域名黑名单 " + pattern}
+		}
+	}
+	for _, pattern := range d.policy.AllowDomains {
+		if matchDomain(host, pattern) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// fallbackDelay Happy Eyeballs 中启动下一个地址前等待的时间（RFC 8305 建议 250ms）
+const fallbackDelay = 250 * time.Millisecond
+
+// dialRace 解析全部 A/AAAA 记录，按 Happy Eyeballs 交替排列地址族并错峰发起连接，首个成功的连接胜出
+// 每个已发起的尝试（地址、顺序、耗时、结果）都会记录下来，用于定位「间歇性故障」实为某个后端 IP 异常的问题
+func (d *probeDialer) dialRace(ctx context.Context, dialer *net.Dialer, address string) (net.Conn, error) {
+	host, port, _ := net.SplitHostPort(address)
+
+	ctx, cancel := context.WithTimeout(ctx, d.timeout)
+	defer cancel()
+
+	ips, err := d.lookup(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	type outcome struct {
+		idx  int
+		conn net.Conn
+		err  error
+	}
+	results := make(chan outcome, len(ips))
+	attempts := make([]DialAttempt, 0, len(ips))
+	start := time.Now()
+
+	launch := func() {
+		idx := len(attempts)
+		addr := net.JoinHostPort(ips[idx].String(), port)
+		attempts = append(attempts, DialAttempt{
+			Address: addr,
+			Family:  ipFamily(ips[idx]),
+			StartMs: elapsedMs(start),
+		})
+		go func() {
+			conn, err := dialer.DialContext(ctx, "tcp", addr)
+			results <- outcome{idx: idx, conn: conn, err: err}
+		}()
+	}
+
+	launch()
+	timer := time.NewTimer(fallbackDelay)
+	defer timer.Stop()
+
+	var winner net.Conn
+	var firstErr error
+	for pending := 1; pending > 0; {
+		select {
+		case r := <-results:
+			pending--
+			a := &attempts[r.idx]
+			a.DurationMs = elapsedMs(start) - a.StartMs
+			switch {
+			case r.err == nil && winner == nil:
+				a.Outcome = "connected"
+				winner = r.conn
+				cancel()
+			case r.err == nil:
+				a.Outcome = "cancelled"
+				r.conn.Close()
+			case winner != nil:
+				a.Outcome = "cancelled"
+			default:
+				a.Outcome = "failed"
+				var denied *EgressDeniedError
+				if errors.As(r.err, &denied) {
+					a.Outcome = "denied"
+				}
+				a.Error = r.err.Error()
+				if firstErr == nil {
+					firstErr = r.err
+				}
+				// 当前地址失败时立即尝试下一个，不必等待错峰间隔
+				if len(attempts) < len(ips) {
+					launch()
+					pending++
+					timer.Reset(fallbackDelay)
+				}
+			}
+		case <-timer.C:
+			if winner == nil && len(attempts) < len(ips) {
+				launch()
+				pending++
+				timer.Reset(fallbackDelay)
+			}
+		}
+	}
+
+	d.mu.Lock()
+	d.attempts = append(d.attempts, attempts...)
+	d.mu.Unlock()
+
+	if winner != nil {
+		return winner, nil
+	}
+	return nil, firstErr
+}
+
+// lookup 解析主机地址并按 RFC 8305 交替排列（IPv6 优先），配置了源地址时只保留同协议族的地址
+func (d *probeDialer) lookup(ctx context.Context, host string) ([]net.IP, error) {
+	var ips []net.IP
+	if ip := net.ParseIP(host); ip != nil {
+		ips = []net.IP{ip}
+	} else {
+		resolver := d.resolver
+		if resolver == nil {
+			resolver = net.DefaultResolver
+		}
+		addrs, err := resolver.LookupIPAddr(ctx, host)
+		if err != nil {
+			return nil, d.resolverError(err)
+		}
+		for _, a := range addrs {
+			ips = append(ips, a.IP)
+		}
+	}
+
+	var v4, v6 []net.IP
+	for _, ip := range ips {
+		if ip.To4() != nil {
+			v4 = append(v4, ip)
+		} else {
+			v6 = append(v6, ip)
+		}
+	}
+	if d.localAddr != nil {
+		if d.localAddr.To4() != nil {
+			v6 = nil
+		} else {
+			v4 = nil
+		}
+	}
+	switch d.family {
+	case FamilyV4:
+		if v6 = nil; len(v4) == 0 {
+			return nil, fmt.Errorf("主机 %s 没有可用的 IPv4 地址", host)
+		}
+	case FamilyV6:
+		if v4 = nil; len(v6) == 0 {
+			return nil, fmt.Errorf("主机 %s 没有可用的 IPv6 地址", host)
+		}
+	}
+
+	ordered := make([]net.IP, 0, len(v4)+len(v6))
+	for i := 0; i < len(v4) || i < len(v6); i++ {
+		if i < len(v6) {
+			ordered = append(ordered, v6[i])
+		}
+		if i < len(v4) {
+			ordered = append(ordered, v4[i])
+		}
+	}
+	if len(ordered) == 0 {
+		return nil, fmt.Errorf("主机 %s 没有与源地址 %s 同协议族的地址", host, d.localAddr)
+	}
+	return ordered, nil
+}
+
+// Attempts 返回该拨号器记录的全部拨号尝试
+func (d *probeDialer) Attempts() []DialAttempt {
+	d.mu.Lock()
+	attempts := append([]DialAttempt(nil), d.attempts...)
+	d.mu.Unlock()
+	// 经由 SOCKS5 代理时记录的是连接代理服务器的尝试
+	if d.forward != nil {
+		attempts = append(attempts, d.forward.Attempts()...)
+	}
+	return attempts
+}
+
+// Dial 建立连接（不带上下文），供只接受 proxy.Dialer 的调用方使用
+func (d *probeDialer) Dial(network, address string) (net.Conn, error) {
+	return d.DialContext(context.Background(), network, address)
+}
+
+// ipFamily 返回 IP 的地址族名称
+func ipFamily(ip net.IP) string {
+	if ip.To4() != nil {
+		return "ipv4"
+	}
+	return "ipv6"
+}
+
+// elapsedMs 返回自 start 起经过的毫秒数（保留两位小数）
+func elapsedMs(start time.Time) float64 {
+	return float64(time.Since(start).Microseconds()) / 1000
+}
+
+// checkIP 按地址段规则判断实际连接的 IP：黑名单优先；配置了白名单时，域名或 IP 至少命中一项
+func (d *probeDialer) checkIP(host string, ip net.IP, domainAllowed bool) error {
+	if ip == nil {
+		return nil
+	}
+	if cidr, ok := matchCIDRs(ip, d.policy.DenyCIDRs); ok {
+		return &EgressDeniedError{Address: host, Reason: fmt.Sprintf("%s 命中地址段黑名单 %s", ip, cidr)}
+	}
+	if len(d.policy.AllowCIDRs) == 0 && len(d.policy.AllowDomains) == 0 {
+		return nil
+	}
+	if domainAllowed {
+		return nil
+	}
+	if _, ok := matchCIDRs(ip, d.policy.AllowCIDRs); ok {
+		return nil
+	}
+	return &EgressDeniedError{Address: host, Reason: fmt.Sprintf("%s 不在出站白名单内", ip)}
+}
+
+// matchCIDRs 判断 IP 是否落在任一地址段内，返回命中的地址段
+func matchCIDRs(ip net.IP, cidrs []string) (string, bool) {
+	if v4 := ip.To4(); v4 != nil {
+		ip = v4
+	}
+	for _, cidr := range cidrs {
+		if _, n, err := net.ParseCIDR(strings.TrimSpace(cidr)); err == nil && n.Contains(ip) {
+			return cidr, true
+		}
+	}
+	return "", false
+}
+
+// matchDomain 判断主机名是否匹配域名规则：精确匹配，或 *.example.com / .example.com 形式的子域名匹配
+func matchDomain(host, pattern string) bool {
+	pattern = strings.ToLower(strings.TrimSpace(pattern))
+	pattern = strings.TrimPrefix(pattern, "*")
+	if strings.HasPrefix(pattern, ".") {
+		return strings.HasSuffix(host, pattern)
+	}
+	return host == pattern
+}
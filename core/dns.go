@@ -0,0 +1,449 @@
+package core
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// dnsTypes 支持检查的 DNS 记录类型
+var dnsTypes = map[string]dnsmessage.Type{
+	"A":     dnsmessage.TypeA,
+	"AAAA":  dnsmessage.TypeAAAA,
+	"CNAME": dnsmessage.TypeCNAME,
+	"MX":    dnsmessage.TypeMX,
+	"NS":    dnsmessage.TypeNS,
+	"TXT":   dnsmessage.TypeTXT,
+	"SRV":   dnsmessage.TypeSRV,
+}
+
+// dnsQuery DNS 检查参数，由 dns:// 地址的查询参数与目标的 dns 选项合并得到
+type dnsQuery struct {
+	name     string   // 查询的域名
+	typeName string   // 记录类型名称
+	resolver string   // 解析服务器 ip:port
+	expect   []string // 期望的记录值
+	minTTL   uint32   // TTL 下限，0 表示不校验
+	maxTTL   uint32   // TTL 上限，0 表示不校验
+}
+
+// parseDNSURL 解析 dns://example.com?type=A&expect=1.2.3.4&resolver=8.8.8.8&minTTL=60&maxTTL=3600
+// expect 可重复出现，也可用逗号分隔多个值
+func parseDNSURL(u *url.URL) (*dnsQuery, error) {
+	q := &dnsQuery{name: strings.TrimSuffix(u.Hostname(), ".")}
+	if q.name == "" {
+		return nil, fmt.Errorf("DNS地址缺少查询域名，格式应为 dns://example.com?type=A")
+	}
+	if u.Port() != "" || strings.Trim(u.Path, "/") != "" {
+		return nil, fmt.Errorf("DNS地址格式应为 dns://example.com?type=A，解析服务器请通过 resolver 参数指定")
+	}
+
+	params := u.Query()
+	q.typeName = strings.ToUpper(params.Get("type"))
+	if q.typeName != "" {
+		if _, ok := dnsTypes[q.typeName]; !ok {
+			return nil, fmt.Errorf("不支持的DNS记录类型：%s", params.Get("type"))
+		}
+	}
+	q.resolver = params.Get("resolver")
+	if q.resolver != "" && resolverIP(q.resolver) == nil {
+		return nil, fmt.Errorf("resolver 应为 IP 地址（可带端口）：%s", q.resolver)
+	}
+	for _, v := range params["expect"] {
+		for _, item := range strings.Split(v, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				q.expect = append(q.expect, item)
+			}
+		}
+	}
+	for _, p := range []struct {
+		key string
+		dst *uint32
+	}{{"minTTL", &q.minTTL}, {"maxTTL", &q.maxTTL}} {
+		if v := params.Get(p.key); v != "" {
+			n, err := strconv.ParseUint(v, 10, 32)
+			if err != nil {
+				return nil, fmt.Errorf("无效的 %s：%s", p.key, v)
+			}
+			*p.dst = uint32(n)
+		}
+	}
+	if q.minTTL > 0 && q.maxTTL > 0 && q.minTTL > q.maxTTL {
+		return nil, fmt.Errorf("minTTL 不能大于 maxTTL")
+	}
+	return q, nil
+}
+
+// dnsQueryFor 合并地址参数与目标的 dns 选项（地址中已有的参数优先），补全默认值
+func (sc *ServiceChecker) dnsQueryFor(target *MonitorTarget) (*dnsQuery, error) {
+	u, err := url.Parse(target.URL)
+	if err != nil {
+		return nil, fmt.Errorf("DNS地址格式错误：%w", err)
+	}
+	q, err := parseDNSURL(u)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts := target.DNS; opts != nil {
+		if q.typeName == "" {
+			q.typeName = strings.ToUpper(opts.Type)
+		}
+		if q.resolver == "" {
+			q.resolver = opts.Resolver
+		}
+		if len(q.expect) == 0 {
+			q.expect = opts.Expect
+		}
+		if q.minTTL == 0 {
+			q.minTTL = opts.MinTTL
+		}
+		if q.maxTTL == 0 {
+			q.maxTTL = opts.MaxTTL
+		}
+	}
+	if q.typeName == "" {
+		q.typeName = "A"
+	}
+	if _, ok := dnsTypes[q.typeName]; !ok {
+		return nil, fmt.Errorf("不支持的DNS记录类型：%s", q.typeName)
+	}
+
+	// 目标指定的解析服务器（地址片段或 resolver 选项）同样作为 DNS 检查的解析服务器
+	if q.resolver == "" {
+		q.resolver = fragmentResolver(target.URL)
+	}
+	if q.resolver == "" {
+		q.resolver = target.Resolver
+	}
+	if q.resolver == "" {
+		q.resolver = sc.cfg.DNS.Resolver
+	}
+	if q.resolver == "" {
+		q.resolver = systemResolver()
+	}
+	if q.resolver == "" {
+		return nil, fmt.Errorf("未配置DNS解析服务器（monitor.dns.resolver），且 /etc/resolv.conf 中没有 nameserver")
+	}
+	if _, _, err := net.SplitHostPort(q.resolver); err != nil {
+		q.resolver = net.JoinHostPort(strings.Trim(q.resolver, "[]"), "53")
+	}
+	return q, nil
+}
+
+// resolverIP 提取解析服务器地址（ip 或 ip:port）中的 IP，格式不正确时返回 nil
+func resolverIP(resolver string) net.IP {
+	if host, _, err := net.SplitHostPort(resolver); err == nil {
+		resolver = host
+	}
+	return net.ParseIP(strings.Trim(resolver, "[]"))
+}
+
+var (
+	systemResolverOnce sync.Once
+	systemResolverAddr string
+)
+
+// systemResolver 返回 /etc/resolv.conf 中的第一个 nameserver，读取失败时返回空字符串
+func systemResolver() string {
+	systemResolverOnce.Do(func() {
+		f, err := os.Open("/etc/resolv.conf")
+		if err != nil {
+			return
+		}
+		defer f.Close()
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			fields := strings.Fields(scanner.Text())
+			if len(fields) >= 2 && fields[0] == "nameserver" {
+				systemResolverAddr = fields[1]
+				return
+			}
+		}
+	})
+	return systemResolverAddr
+}
+
+// checkDNS 检查 DNS 解析：向解析服务器查询指定类型的记录，校验应答码、期望的记录值与 TTL，记录解析耗时
+func (sc *ServiceChecker) checkDNS(target *MonitorTarget, result *MonitorResult) (error, ErrorType) {
+	q, err := sc.dnsQueryFor(target)
+	if err != nil {
+		return err, ErrorTypeInvalid
+	}
+
+	dialer := sc.newDialer(target, sc.cfg.DNS.Timeout)
+	start := time.Now()
+	resp, transport, err := exchangeDNS(dialer, q, sc.cfg.DNS.Timeout)
+	details := &DNSDetails{
+		Resolver:  q.resolver,
+		Type:      q.typeName,
+		Transport: transport,
+		LatencyMs: elapsedMs(start),
+	}
+	result.details().DNS = details
+	result.recordDialAttempts(dialer.Attempts())
+	if err != nil {
+		var denied *EgressDeniedError
+		if errors.As(err, &denied) {
+			return denied, ErrorTypePolicy
+		}
+		if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+			return fmt.Errorf("DNS查询超时（%s）：%w", q.resolver, err), ErrorTypeTimeout
+		}
+		return fmt.Errorf("DNS查询失败（%s）：%w", q.resolver, err), ErrorTypeNetwork
+	}
+
+	details.RCode = rcodeName(resp.RCode)
+	details.Authoritative = resp.Authoritative
+	var matched []DNSAnswer
+	for _, ans := range resp.Answers {
+		a := DNSAnswer{
+			Name:  strings.TrimSuffix(ans.Header.Name.String(), "."),
+			Type:  strings.TrimPrefix(ans.Header.Type.String(), "Type"),
+			TTL:   ans.Header.TTL,
+			Value: dnsValue(ans.Body),
+		}
+		details.Answers = append(details.Answers, a)
+		if a.Type == q.typeName {
+			matched = append(matched, a)
+		}
+	}
+
+	if resp.RCode != dnsmessage.RCodeSuccess {
+		return fmt.Errorf("DNS应答错误：%s", details.RCode), ErrorTypeDNS
+	}
+	if len(matched) == 0 {
+		return fmt.Errorf("未返回 %s 记录", q.typeName), ErrorTypeDNS
+	}
+
+	if len(q.expect) > 0 {
+		actual := make(map[string]bool, len(matched))
+		values := make([]string, 0, len(matched))
+		for _, a := range matched {
+			actual[normalizeDNSValue(q.typeName, a.Value)] = true
+			values = append(values, a.Value)
+		}
+		sort.Strings(values)
+		for _, e := range q.expect {
+			if !actual[normalizeDNSValue(q.typeName, e)] {
+				return fmt.Errorf("期望的 %s 记录 %s 未出现在应答中（实际：%s）", q.typeName, e, strings.Join(values, ", ")), ErrorTypeDNS
+			}
+		}
+	}
+
+	for _, a := range matched {
+		if q.minTTL > 0 && a.TTL < q.minTTL {
+			return fmt.Errorf("%s 记录 %s 的 TTL %d 低于下限 %d", q.typeName, a.Value, a.TTL, q.minTTL), ErrorTypeDNS
+		}
+		if q.maxTTL > 0 && a.TTL > q.maxTTL {
+			return fmt.Errorf("%s 记录 %s 的 TTL %d 超过上限 %d", q.typeName, a.Value, a.TTL, q.maxTTL), ErrorTypeDNS
+		}
+	}
+	return nil, ""
+}
+
+// dnsResponse 解析后的 DNS 应答
+type dnsResponse struct {
+	RCode         dnsmessage.RCode
+	Authoritative bool
+	Answers       []dnsmessage.Resource
+}
+
+// exchangeDNS 通过 UDP 发送查询，应答被截断时改用 TCP 重新查询，返回应答及使用的传输协议
+func exchangeDNS(dialer *probeDialer, q *dnsQuery, timeout time.Duration) (*dnsResponse, string, error) {
+	var idBuf [2]byte
+	rand.Read(idBuf[:])
+	id := binary.BigEndian.Uint16(idBuf[:])
+
+	msg, err := buildDNSQuery(id, q)
+	if err != nil {
+		return nil, "udp", err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	raw, err := exchangeUDP(ctx, dialer, q.resolver, id, msg)
+	if err != nil {
+		return nil, "udp", err
+	}
+	resp, truncated, err := parseDNSResponse(raw)
+	if err != nil || !truncated {
+		return resp, "udp", err
+	}
+
+	raw, err = exchangeTCP(ctx, dialer, q.resolver, msg)
+	if err != nil {
+		return nil, "tcp", err
+	}
+	resp, _, err = parseDNSResponse(raw)
+	return resp, "tcp", err
+}
+
+// buildDNSQuery 构建递归查询报文（携带 EDNS0，声明可接收 4096 字节的 UDP 应答）
+func buildDNSQuery(id uint16, q *dnsQuery) ([]byte, error) {
+	name, err := dnsmessage.NewName(q.name + ".")
+	if err != nil {
+		return nil, fmt.Errorf("无效的查询域名：%w", err)
+	}
+	b := dnsmessage.NewBuilder(nil, dnsmessage.Header{ID: id, RecursionDesired: true})
+	b.EnableCompression()
+	if err := b.StartQuestions(); err != nil {
+		return nil, err
+	}
+	if err := b.Question(dnsmessage.Question{Name: name, Type: dnsTypes[q.typeName], Class: dnsmessage.ClassINET}); err != nil {
+		return nil, err
+	}
+	if err := b.StartAdditionals(); err != nil {
+		return nil, err
+	}
+	var opt dnsmessage.ResourceHeader
+	if err := opt.SetEDNS0(4096, dnsmessage.RCodeSuccess, false); err != nil {
+		return nil, err
+	}
+	if err := b.OPTResource(opt, dnsmessage.OPTResource{}); err != nil {
+		return nil, err
+	}
+	return b.Finish()
+}
+
+// exchangeUDP 通过 UDP 发送查询，忽略 ID 不匹配的报文
+func exchangeUDP(ctx context.Context, dialer *probeDialer, resolver string, id uint16, msg []byte) ([]byte, error) {
+	conn, err := dialer.DialContext(ctx, "udp", resolver)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	if _, err := conn.Write(msg); err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, 4096)
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			return nil, err
+		}
+		if n >= 2 && binary.BigEndian.Uint16(buf) == id {
+			return buf[:n], nil
+		}
+	}
+}
+
+// exchangeTCP 通过 TCP 发送查询（报文前带两字节长度）
+func exchangeTCP(ctx context.Context, dialer *probeDialer, resolver string, msg []byte) ([]byte, error) {
+	conn, err := dialer.DialContext(ctx, "tcp", resolver)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	framed := make([]byte, 2+len(msg))
+	binary.BigEndian.PutUint16(framed, uint16(len(msg)))
+	copy(framed[2:], msg)
+	if _, err := conn.Write(framed); err != nil {
+		return nil, err
+	}
+
+	var length [2]byte
+	if _, err := io.ReadFull(conn, length[:]); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, binary.BigEndian.Uint16(length[:]))
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// parseDNSResponse 解析应答报文，返回应答及是否被截断
+func parseDNSResponse(raw []byte) (*dnsResponse, bool, error) {
+	var p dnsmessage.Parser
+	header, err := p.Start(raw)
+	if err != nil {
+		return nil, false, fmt.Errorf("解析DNS应答失败：%w", err)
+	}
+	if header.Truncated {
+		return nil, true, nil
+	}
+	if err := p.SkipAllQuestions(); err != nil {
+		return nil, false, fmt.Errorf("解析DNS应答失败：%w", err)
+	}
+	answers, err := p.AllAnswers()
+	if err != nil {
+		return nil, false, fmt.Errorf("解析DNS应答失败：%w", err)
+	}
+	return &dnsResponse{RCode: header.RCode, Authoritative: header.Authoritative, Answers: answers}, false, nil
+}
+
+// dnsValue 将记录内容转换为文本
+func dnsValue(body dnsmessage.ResourceBody) string {
+	switch b := body.(type) {
+	case *dnsmessage.AResource:
+		return net.IP(b.A[:]).String()
+	case *dnsmessage.AAAAResource:
+		return net.IP(b.AAAA[:]).String()
+	case *dnsmessage.CNAMEResource:
+		return strings.TrimSuffix(b.CNAME.String(), ".")
+	case *dnsmessage.NSResource:
+		return strings.TrimSuffix(b.NS.String(), ".")
+	case *dnsmessage.MXResource:
+		return fmt.Sprintf("%d %s", b.Pref, strings.TrimSuffix(b.MX.String(), "."))
+	case *dnsmessage.TXTResource:
+		return strings.Join(b.TXT, "")
+	case *dnsmessage.SRVResource:
+		return fmt.Sprintf("%d %d %d %s", b.Priority, b.Weight, b.Port, strings.TrimSuffix(b.Target.String(), "."))
+	}
+	return ""
+}
+
+// normalizeDNSValue 规范化记录值用于比较：IP 统一格式，域名不区分大小写并去掉末尾的点，TXT 保持原样
+func normalizeDNSValue(typeName, v string) string {
+	v = strings.TrimSpace(v)
+	if ip := net.ParseIP(v); ip != nil {
+		return ip.String()
+	}
+	if typeName == "TXT" {
+		return v
+	}
+	return strings.TrimSuffix(strings.ToLower(v), ".")
+}
+
+// rcodeName 返回应答码的标准名称
+func rcodeName(rc dnsmessage.RCode) string {
+	switch rc {
+	case dnsmessage.RCodeSuccess:
+		return "NOERROR"
+	case dnsmessage.RCodeFormatError:
+		return "FORMERR"
+	case dnsmessage.RCodeServerFailure:
+		return "SERVFAIL"
+	case dnsmessage.RCodeNameError:
+		return "NXDOMAIN"
+	case dnsmessage.RCodeNotImplemented:
+		return "NOTIMP"
+	case dnsmessage.RCodeRefused:
+		return "REFUSED"
+	}
+	return fmt.Sprintf("RCODE%d", rc)
+}
@@ -0,0 +1,423 @@
+package core
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// DefaultDomainWarnDays 域名注册剩余天数低于该值时记为警告（目标未配置 warnDays 时使用）
+const DefaultDomainWarnDays = 30
+
+// 域名注册信息来源
+const (
+	DomainSourceRDAP  = "rdap"
+	DomainSourceWhois = "whois"
+)
+
+// rdapBootstrapTTL RDAP 引导文件的缓存时长
+const rdapBootstrapTTL = 24 * time.Hour
+
+// domainErrorTTL 查询失败的结果最多缓存的时长，避免注册局短暂故障后长时间没有到期信息
+const domainErrorTTL = time.Hour
+
+// DomainDetails 域名注册到期检查结果
+type DomainDetails struct {
+	Domain     string     `json:"domain"`              // 查询的注册域名
+	Source     string     `json:"source,omitempty"`    // 信息来源：rdap / whois
+	Registrar  string     `json:"registrar,omitempty"` // 注册商
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty"` // 注册到期时间（UTC）
+	DaysLeft   *int       `json:"daysLeft,omitempty"`  // 剩余天数，已过期为负数
+	LookedUpAt time.Time  `json:"lookedUpAt"`          // 查询时间（多次检查共用缓存的查询结果）
+	Error      string     `json:"error,omitempty"`     // 查询失败原因
+}
+
+// domainRecord 缓存的域名注册信息
+type domainRecord struct {
+	source     string
+	registrar  string
+	expiresAt  time.Time
+	err        error
+	lookedUpAt time.Time
+}
+
+// rdapBootstrap IANA RDAP 引导文件：顶级域 -> RDAP 服务地址
+type rdapBootstrap struct {
+	services  map[string]string
+	fetchedAt time.Time
+}
+
+var (
+	domainMu    sync.Mutex
+	domainCache = make(map[string]*domainRecord) // 注册域名 -> 注册信息
+	rdapIndex   *rdapBootstrap
+)
+
+// whoisExpiryPattern WHOIS 应答中的到期时间行（各注册局字段名不一）
+var whoisExpiryPattern = regexp.MustCompile(`(?im)^\s*(?:registry expiry date|registrar registration expiration date|expiration date|expiry date|expiration time|expire date|expires on|expires|paid-till|free-date|renewal date)\s*:\s*(.+?)\s*$`)
+
+// whoisRegistrarPattern WHOIS 应答中的注册商行
+var whoisRegistrarPattern = regexp.MustCompile(`(?im)^\s*(?:registrar|sponsoring registrar|registrar name)\s*:\s*(.+?)\s*$`)
+
+// whoisReferPattern 根 WHOIS 服务器应答中顶级域的 WHOIS 服务器
+var whoisReferPattern = regexp.MustCompile(`(?im)^\s*(?:refer|whois)\s*:\s*(\S+)\s*$`)
+
+// whoisDateLayouts WHOIS 到期时间的常见格式
+var whoisDateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05 MST",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+	"2006.01.02 15:04:05",
+	"2006.01.02",
+	"2006/01/02",
+	"02.01.2006",
+	"02-Jan-2006",
+	"2-Jan-2006",
+	"January 2 2006",
+	"Mon Jan 2 15:04:05 MST 2006",
+}
+
+// domainExpiryHost 返回目标用于查询注册信息的主机名：sni / hostHeader 优先，其次为地址中的主机名
+func domainExpiryHost(target *MonitorTarget) string {
+	if name := target.serverName(); name != "" {
+		return name
+	}
+	u, err := url.Parse(target.URL)
+	if err != nil {
+		return ""
+	}
+	return u.Hostname()
+}
+
+// registrableDomain 返回主机名的可注册域名（公共后缀 + 1 级），IP 地址与公共后缀本身返回错误
+func registrableDomain(host string) (string, error) {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if host == "" {
+		return "", fmt.Errorf("目标地址中没有主机名")
+	}
+	if net.ParseIP(host) != nil {
+		return "", fmt.Errorf("%s 是 IP 地址，没有域名注册信息", host)
+	}
+	domain, err := publicsuffix.EffectiveTLDPlusOne(host)
+	if err != nil {
+		return "", fmt.Errorf("无法确定 %s 的注册域名：%w", host, err)
+	}
+	return domain, nil
+}
+
+// inspectDomainExpiry 查询目标域名的注册到期时间（结果按 monitor.domainExpiry.cacheTTL 缓存），
+// 剩余天数低于 warnDays 或已过期时记为警告，不影响检查状态
+func (sc *ServiceChecker) inspectDomainExpiry(target *MonitorTarget, result *MonitorResult) {
+	opts := target.DomainExpiry
+	domain := opts.Domain
+	var err error
+	if domain == "" {
+		domain, err = registrableDomain(domainExpiryHost(target))
+	}
+	details := &DomainDetails{Domain: strings.TrimSuffix(strings.ToLower(domain), ".")}
+	result.details().Domain = details
+	if err != nil {
+		details.Error, details.LookedUpAt = err.Error(), time.Now()
+		return
+	}
+
+	record := sc.lookupDomain(details.Domain)
+	details.LookedUpAt = record.lookedUpAt
+	details.Source = record.source
+	if record.err != nil {
+		details.Error = record.err.Error()
+		return
+	}
+	expiresAt := record.expiresAt.UTC()
+	days := int(expiresAt.Sub(time.Now()).Hours() / 24)
+	details.Registrar, details.ExpiresAt, details.DaysLeft = record.registrar, &expiresAt, &days
+
+	warnDays := opts.WarnDays
+	if warnDays <= 0 {
+		warnDays = DefaultDomainWarnDays
+	}
+	switch {
+	case expiresAt.Before(time.Now()):
+		result.addTypedWarning(WarningTypeDomainExpiry, fmt.Sprintf("域名 %s 注册已过期（%s 到期）", details.Domain, expiresAt.Format("2006-01-02")))
+	case days < warnDays:
+		result.addTypedWarning(WarningTypeDomainExpiry, fmt.Sprintf("域名 %s 注册即将到期（剩余%d天，%s 到期）", details.Domain, days, expiresAt.Format("2006-01-02")))
+	}
+}
+
+// lookupDomain 返回域名的注册信息，缓存未过期时直接使用缓存（查询失败的结果最多缓存 1 小时）
+func (sc *ServiceChecker) lookupDomain(domain string) *domainRecord {
+	ttl := sc.cfg.DomainExpiry.CacheTTL
+	domainMu.Lock()
+	cached := domainCache[domain]
+	domainMu.Unlock()
+	if cached != nil {
+		maxAge := ttl
+		if cached.err != nil && maxAge > domainErrorTTL {
+			maxAge = domainErrorTTL
+		}
+		if time.Since(cached.lookedUpAt) < maxAge {
+			return cached
+		}
+	}
+
+	record := sc.queryDomain(domain)
+	domainMu.Lock()
+	domainCache[domain] = record
+	domainMu.Unlock()
+	log.Debugf("查询域名[%s]注册信息：source=%s expiresAt=%s err=%v", domain, record.source, record.expiresAt.Format(time.RFC3339), record.err)
+	return record
+}
+
+// queryDomain 查询域名注册信息：顶级域有 RDAP 服务时使用 RDAP，否则回退到 WHOIS
+func (sc *ServiceChecker) queryDomain(domain string) *domainRecord {
+	record := &domainRecord{lookedUpAt: time.Now()}
+	tld := domain[strings.LastIndex(domain, ".")+1:]
+
+	base, err := sc.rdapServer(tld)
+	if err != nil {
+		log.Warnf("获取RDAP引导文件失败，使用WHOIS查询：%v", err)
+	}
+	if base != "" {
+		record.source = DomainSourceRDAP
+		record.registrar, record.expiresAt, record.err = sc.queryRDAP(base, domain)
+		return record
+	}
+	record.source = DomainSourceWhois
+	record.registrar, record.expiresAt, record.err = sc.queryWhois(tld, domain)
+	return record
+}
+
+// rdapServer 从 IANA 引导文件中查找顶级域的 RDAP 服务地址，顶级域没有 RDAP 服务时返回空字符串
+func (sc *ServiceChecker) rdapServer(tld string) (string, error) {
+	domainMu.Lock()
+	index := rdapIndex
+	domainMu.Unlock()
+	if index == nil || time.Since(index.fetchedAt) > rdapBootstrapTTL {
+		fetched, err := sc.fetchRDAPBootstrap()
+		if err != nil {
+			if index == nil {
+				return "", err
+			}
+			// 引导文件刷新失败时继续使用旧的对应表
+			log.Warnf("刷新RDAP引导文件失败，继续使用 %s 获取的版本：%v", index.fetchedAt.Format(time.RFC3339), err)
+		} else {
+			index = fetched
+			domainMu.Lock()
+			rdapIndex = fetched
+			domainMu.Unlock()
+		}
+	}
+	return index.services[tld], nil
+}
+
+// fetchRDAPBootstrap 下载并解析 IANA RDAP 引导文件（RFC 9224）
+func (sc *ServiceChecker) fetchRDAPBootstrap() (*rdapBootstrap, error) {
+	client := &http.Client{Timeout: sc.cfg.DomainExpiry.Timeout}
+	resp, err := client.Get(sc.cfg.DomainExpiry.BootstrapURL)
+	if err != nil {
+		return nil, fmt.Errorf("请求RDAP引导文件失败：%w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("RDAP引导文件返回状态码%d", resp.StatusCode)
+	}
+
+	var file struct {
+		Services [][][]string `json:"services"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 4<<20)).Decode(&file); err != nil {
+		return nil, fmt.Errorf("解析RDAP引导文件失败：%w", err)
+	}
+	index := &rdapBootstrap{services: make(map[string]string), fetchedAt: time.Now()}
+	for _, service := range file.Services {
+		if len(service) < 2 || len(service[1]) == 0 {
+			continue
+		}
+		// 同一服务有多个地址时优先使用 HTTPS 地址
+		base := service[1][0]
+		for _, u := range service[1] {
+			if strings.HasPrefix(u, "https://") {
+				base = u
+				break
+			}
+		}
+		for _, tld := range service[0] {
+			index.services[strings.ToLower(tld)] = base
+		}
+	}
+	return index, nil
+}
+
+// rdapDomain RDAP 域名查询应答中用到的字段（RFC 9083）
+type rdapDomain struct {
+	Events []struct {
+		Action string `json:"eventAction"`
+		Date   string `json:"eventDate"`
+	} `json:"events"`
+	Entities []struct {
+		Roles []string          `json:"roles"`
+		VCard []json.RawMessage `json:"vcardArray"`
+	} `json:"entities"`
+}
+
+// queryRDAP 通过 RDAP 查询域名的注册商与到期时间
+func (sc *ServiceChecker) queryRDAP(base, domain string) (string, time.Time, error) {
+	client := &http.Client{Timeout: sc.cfg.DomainExpiry.Timeout}
+	req, err := http.NewRequest(http.MethodGet, strings.TrimRight(base, "/")+"/domain/"+url.PathEscape(domain), nil)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("创建RDAP请求失败：%w", err)
+	}
+	req.Header.Set("Accept", "application/rdap+json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("请求RDAP服务失败：%w", err)
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return "", time.Time{}, fmt.Errorf("RDAP服务未找到域名 %s（可能未注册）", domain)
+	case resp.StatusCode != http.StatusOK:
+		return "", time.Time{}, fmt.Errorf("RDAP服务返回状态码%d", resp.StatusCode)
+	}
+
+	var info rdapDomain
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&info); err != nil {
+		return "", time.Time{}, fmt.Errorf("解析RDAP应答失败：%w", err)
+	}
+
+	var registrar string
+	for _, e := range info.Entities {
+		for _, role := range e.Roles {
+			if role == "registrar" && len(e.VCard) > 1 {
+				registrar = vcardName(e.VCard[1])
+			}
+		}
+	}
+	for _, ev := range info.Events {
+		if ev.Action != "expiration" {
+			continue
+		}
+		expiresAt, err := time.Parse(time.RFC3339, ev.Date)
+		if err != nil {
+			return registrar, time.Time{}, fmt.Errorf("无效的RDAP到期时间：%s", ev.Date)
+		}
+		return registrar, expiresAt, nil
+	}
+	return registrar, time.Time{}, errors.New("RDAP应答中没有到期时间（expiration 事件）")
+}
+
+// vcardName 从 jCard 属性列表中取出 fn（名称）
+func vcardName(raw json.RawMessage) string {
+	var props [][]interface{}
+	if err := json.Unmarshal(raw, &props); err != nil {
+		return ""
+	}
+	for _, p := range props {
+		if len(p) >= 4 && p[0] == "fn" {
+			if name, ok := p[3].(string); ok {
+				return name
+			}
+		}
+	}
+	return ""
+}
+
+// queryWhois 通过 WHOIS 查询域名的注册商与到期时间：先向根 WHOIS 服务器查询顶级域的 WHOIS 服务器，再查询域名
+func (sc *ServiceChecker) queryWhois(tld, domain string) (string, time.Time, error) {
+	root, err := sc.whois(sc.cfg.DomainExpiry.WhoisServer, tld)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	m := whoisReferPattern.FindStringSubmatch(root)
+	if m == nil {
+		return "", time.Time{}, fmt.Errorf("顶级域 .%s 既没有 RDAP 服务也没有 WHOIS 服务器", tld)
+	}
+	text, err := sc.whois(m[1], domain)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	var registrar string
+	if r := whoisRegistrarPattern.FindStringSubmatch(text); r != nil {
+		registrar = r[1]
+	}
+	e := whoisExpiryPattern.FindStringSubmatch(text)
+	if e == nil {
+		return registrar, time.Time{}, fmt.Errorf("WHOIS应答中没有到期时间（%s）", m[1])
+	}
+	for _, layout := range whoisDateLayouts {
+		if expiresAt, err := time.Parse(layout, e[1]); err == nil {
+			return registrar, expiresAt, nil
+		}
+	}
+	return registrar, time.Time{}, fmt.Errorf("无法解析WHOIS到期时间：%s", e[1])
+}
+
+// whois 向 WHOIS 服务器（host[:port]，默认端口 43）发送一次查询并读取完整应答
+func (sc *ServiceChecker) whois(server, query string) (string, error) {
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(server, "43")
+	}
+	timeout := sc.cfg.DomainExpiry.Timeout
+	conn, err := net.DialTimeout("tcp", server, timeout)
+	if err != nil {
+		return "", fmt.Errorf("连接WHOIS服务器 %s 失败：%w", server, err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(timeout))
+	if _, err := fmt.Fprintf(conn, "%s\r\n", query); err != nil {
+		return "", fmt.Errorf("发送WHOIS查询失败：%w", err)
+	}
+
+	var b strings.Builder
+	scanner := bufio.NewScanner(io.LimitReader(conn, 256*1024))
+	for scanner.Scan() {
+		b.WriteString(scanner.Text())
+		b.WriteByte('\n')
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("读取WHOIS应答失败：%w", err)
+	}
+	return b.String(), nil
+}
+
+// validateDomainExpiry 校验域名注册到期检查选项
+func validateDomainExpiry(target *MonitorTarget) error {
+	opts := target.DomainExpiry
+	if opts == nil {
+		return nil
+	}
+	if targetScheme(target.URL) == "heartbeat" {
+		return fmt.Errorf("domainExpiry 不适用于 heartbeat:// 目标")
+	}
+	if opts.WarnDays < 0 {
+		return fmt.Errorf("domainExpiry.warnDays 不能为负数")
+	}
+	if opts.Domain != "" {
+		domain, err := registrableDomain(opts.Domain)
+		if err != nil {
+			return fmt.Errorf("domainExpiry.domain 无效：%w", err)
+		}
+		if domain != strings.TrimSuffix(strings.ToLower(opts.Domain), ".") {
+			return fmt.Errorf("domainExpiry.domain 应为可注册域名 %s，而不是子域名 %s", domain, opts.Domain)
+		}
+		return nil
+	}
+	if _, err := registrableDomain(domainExpiryHost(target)); err != nil {
+		return fmt.Errorf("domainExpiry：%w", err)
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package core
+
+import (
+	"fmt"
+	"net"
+	"sync"
+)
+
+// 目标的地址族选项
+const (
+	FamilyV4   = "v4"   // 只经由 IPv4 检查
+	FamilyV6   = "v6"   // 只经由 IPv6 检查
+	FamilyBoth = "both" // 分别经由 IPv4 与 IPv6 检查，任一地址族失败即失败
+)
+
+// FamilyResult 双栈检查中单个地址族的检查结果
+type FamilyResult struct {
+	Family       string        `json:"family"`                 // 地址族：v4 / v6
+	Status       string        `json:"status"`                 // 检查状态
+	StatusCode   int           `json:"statusCode,omitempty"`   // HTTP 状态码
+	ResponseTime float64       `json:"responseTime"`           // 响应耗时（毫秒）
+	ErrorType    string        `json:"errorType,omitempty"`    // 错误类型
+	ErrorMsg     string        `json:"errorMsg,omitempty"`     // 错误信息
+	Warning      string        `json:"warning,omitempty"`      // 警告信息
+	DialAttempts []DialAttempt `json:"dialAttempts,omitempty"` // 该地址族的拨号尝试
+}
+
+// familyLabel 地址族在错误信息中的名称
+var familyLabel = map[string]string{FamilyV4: "IPv4", FamilyV6: "IPv6"}
+
+// statusRank 检查状态的严重程度，用于合并各地址族的结果
+var statusRank = map[string]int{StatusSuccess: 0, StatusDegraded: 1, StatusFailed: 2}
+
+// probeDualStack 分别经由 IPv4 与 IPv6 检查目标（并行执行），各地址族的结果记录在 details.families 中；
+// 整体状态与错误类型取最严重的地址族，错误信息按地址族分别注明，避免一个地址族正常掩盖另一个地址族的故障
+func (sc *ServiceChecker) probeDualStack(target *MonitorTarget) *MonitorResult {
+	families := []string{FamilyV4, FamilyV6}
+	results := make([]*MonitorResult, len(families))
+	var wg sync.WaitGroup
+	for i, family := range families {
+		wg.Add(1)
+		go func(i int, family string) {
+			defer wg.Done()
+			t := *target
+			t.AddressFamily = family
+			results[i] = sc.probeOnce(&t)
+		}(i, family)
+	}
+	wg.Wait()
+
+	worst := 0
+	for i, r := range results {
+		if statusRank[r.Status] > statusRank[results[worst].Status] {
+			worst = i
+		}
+	}
+	result := *results[worst]
+	details := ResultDetails{}
+	if results[worst].Details != nil {
+		details = *results[worst].Details
+	}
+	result.Details = &details
+	result.Warning, result.ErrorMsg = "", ""
+
+	for i, r := range results {
+		family := families[i]
+		fr := FamilyResult{
+			Family:       family,
+			Status:       r.Status,
+			StatusCode:   r.StatusCode,
+			ResponseTime: r.ResponseTime,
+			ErrorType:    r.ErrorType,
+			ErrorMsg:     r.ErrorMsg,
+			Warning:      r.Warning,
+		}
+		if r.Details != nil {
+			fr.DialAttempts = r.Details.DialAttempts
+		}
+		details.Families = append(details.Families, fr)
+		if r.ResponseTime > result.ResponseTime {
+			result.ResponseTime = r.ResponseTime
+		}
+		if r.Warning != "" {
+			result.addWarning(familyLabel[family] + "：" + r.Warning)
+		}
+		if r.ErrorMsg != "" {
+			if result.ErrorMsg != "" {
+				result.ErrorMsg += "；"
+			}
+			result.ErrorMsg += familyLabel[family] + "：" + r.ErrorMsg
+		}
+	}
+	return &result
+}
+
+// validateAddressFamily 校验地址族选项：不适用于不发起连接的心跳检查与地址族由记录类型决定的 DNS 检查，
+// 且须与源地址的地址族一致（both 需要两个地址族，不能配置源地址）
+func validateAddressFamily(target *MonitorTarget) error {
+	switch target.AddressFamily {
+	case "":
+		return nil
+	case FamilyV4, FamilyV6, FamilyBoth:
+	default:
+		return fmt.Errorf("无效的 addressFamily：%s，仅支持 v4 / v6 / both", target.AddressFamily)
+	}
+	switch targetScheme(target.URL) {
+	case "heartbeat", "dns":
+		return fmt.Errorf("addressFamily 不适用于 %s:// 目标", targetScheme(target.URL))
+	}
+	if target.AddressFamily == FamilyBoth && (target.SourceIP != "" || target.Interface != "") {
+		return fmt.Errorf("addressFamily 为 both 时不能指定源地址或网卡")
+	}
+	if ip := net.ParseIP(target.SourceIP); ip != nil && (ip.To4() != nil) != (target.AddressFamily == FamilyV4) {
+		return fmt.Errorf("源地址 %s 与 addressFamily %s 的地址族不一致", target.SourceIP, target.AddressFamily)
+	}
+	return nil
+}
@@ -0,0 +1,240 @@
+package core
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/textproto"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ftpAnonymousPassword 未配置凭据时匿名登录使用的密码（按惯例为邮箱形式）
+const ftpAnonymousPassword = "servicemonitor@"
+
+// ftpPASVPattern PASV 响应中的地址：(h1,h2,h3,h4,p1,p2)
+var ftpPASVPattern = regexp.MustCompile(`(\d+),(\d+),(\d+),(\d+),(\d+),(\d+)`)
+
+// parseFileTransferURL 解析 ftp://host[:port]/path 与 sftp://host[:port]/path 形式的地址，返回地址与列目录的路径
+// 默认端口分别为 21 与 22；路径为空时列出登录后的默认目录；用户名与密码通过目标的 credentials 配置
+func parseFileTransferURL(u *url.URL) (string, string, error) {
+	scheme := strings.ToLower(u.Scheme)
+	if u.Hostname() == "" {
+		return "", "", fmt.Errorf("地址格式应为 %s://host:port/path", scheme)
+	}
+	if u.User != nil {
+		return "", "", fmt.Errorf("%s地址中不能包含用户名或密码，请通过目标的 credentials（username / password）配置", strings.ToUpper(scheme))
+	}
+	port := u.Port()
+	switch {
+	case port == "" && scheme == "sftp":
+		port = "22"
+	case port == "":
+		port = "21"
+	}
+	if _, err := strconv.ParseUint(port, 10, 16); err != nil {
+		return "", "", fmt.Errorf("无效的%s端口：%s", strings.ToUpper(scheme), port)
+	}
+	if strings.ContainsAny(u.Path, "\r\n") {
+		return "", "", fmt.Errorf("无效的目录路径：%q", u.Path)
+	}
+	path := u.Path
+	if path == "" {
+		path = "."
+	}
+	return net.JoinHostPort(u.Hostname(), port), path, nil
+}
+
+// checkFTP 检查FTP服务：读取问候、登录（未配置凭据时匿名登录）、以被动模式列出目录，分别记录各阶段耗时
+// 数据连接始终连接控制连接的主机（忽略 PASV 返回的地址），与 NAT 后的服务端兼容，也不会被引导连接其他主机
+func (sc *ServiceChecker) checkFTP(target *MonitorTarget, result *MonitorResult) (error, ErrorType) {
+	u, err := url.Parse(target.URL)
+	if err != nil {
+		return fmt.Errorf("解析FTP地址失败：%w", err), ErrorTypeInvalid
+	}
+	address, path, err := parseFileTransferURL(u)
+	if err != nil {
+		return err, ErrorTypeInvalid
+	}
+	username, password, err := fileTransferCredentials(target)
+	if err != nil {
+		return err, ErrorTypeInvalid
+	}
+	if username == "" {
+		username, password = "anonymous", ftpAnonymousPassword
+	}
+
+	timeout := sc.cfg.FileTransferTimeout
+	dialer := sc.newDialer(target, timeout)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	defer func() { result.recordDialAttempts(dialer.Attempts()) }()
+
+	start := time.Now()
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return fileTransferDialError("FTP", err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	details := &FileTransferDetails{Protocol: "ftp", Address: address, User: username, Path: path}
+	result.details().FileTransfer = details
+
+	tp := textproto.NewConn(conn)
+	_, greeting, err := tp.ReadResponse(220)
+	details.ConnectMs = durationMs(time.Since(start))
+	if err != nil {
+		return ftpError("读取FTP问候失败", err)
+	}
+	details.Banner = truncateBanner(strings.SplitN(greeting, "\n", 2)[0])
+
+	start = time.Now()
+	code, msg, err := ftpCmd(tp, "USER %s", username)
+	if err == nil && code == 331 {
+		code, msg, err = ftpCmd(tp, "PASS %s", password)
+	}
+	if err != nil {
+		return ftpError("FTP登录失败", err)
+	}
+	if code != 230 {
+		return fmt.Errorf("FTP登录失败：%d %s", code, msg), ErrorTypeFTP
+	}
+	details.LoginMs = durationMs(time.Since(start))
+
+	// 被动模式：优先 EPSV（RFC 2428，IPv6 兼容），不支持时回退 PASV
+	start = time.Now()
+	port, err := ftpPassivePort(tp)
+	if err != nil {
+		return ftpError("进入被动模式失败", err)
+	}
+	host, _, _ := net.SplitHostPort(conn.RemoteAddr().String())
+	data, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(host, strconv.Itoa(port)))
+	if err != nil {
+		return fileTransferDialError("FTP数据", err)
+	}
+	defer data.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		data.SetDeadline(deadline)
+	}
+
+	listCmd := "NLST"
+	if path != "." {
+		listCmd = "NLST " + path
+	}
+	if code, msg, err = ftpCmd(tp, "%s", listCmd); err != nil {
+		return ftpError("列出目录失败", err)
+	}
+	if code != 125 && code != 150 {
+		return fmt.Errorf("列出目录失败：%d %s", code, msg), ErrorTypeFTP
+	}
+	scanner := bufio.NewScanner(data)
+	for scanner.Scan() {
+		if strings.TrimSpace(scanner.Text()) != "" {
+			details.Entries++
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return ftpError("读取目录列表失败", err)
+	}
+	data.Close()
+	if _, _, err := tp.ReadResponse(226); err != nil {
+		return ftpError("列出目录失败", err)
+	}
+	details.ListMs = durationMs(time.Since(start))
+
+	// QUIT 失败不影响检查结论
+	ftpCmd(tp, "QUIT")
+	return nil, ""
+}
+
+// ftpCmd 发送一条 FTP 命令并读取响应（不校验响应码）
+func ftpCmd(tp *textproto.Conn, format string, args ...interface{}) (int, string, error) {
+	id, err := tp.Cmd(format, args...)
+	if err != nil {
+		return 0, "", err
+	}
+	tp.StartResponse(id)
+	defer tp.EndResponse(id)
+	code, msg, err := tp.ReadResponse(0)
+	var protoErr *textproto.Error
+	if errors.As(err, &protoErr) {
+		return protoErr.Code, protoErr.Msg, nil
+	}
+	return code, msg, err
+}
+
+// ftpPassivePort 进入被动模式，返回服务端数据端口
+func ftpPassivePort(tp *textproto.Conn) (int, error) {
+	code, msg, err := ftpCmd(tp, "EPSV")
+	if err != nil {
+		return 0, err
+	}
+	if code == 229 {
+		// 229 Entering Extended Passive Mode (|||6446|)
+		if i, j := strings.Index(msg, "(|||"), strings.LastIndex(msg, "|)"); i >= 0 && j > i+4 {
+			if port, err := strconv.Atoi(msg[i+4 : j]); err == nil && port > 0 && port < 65536 {
+				return port, nil
+			}
+		}
+		return 0, fmt.Errorf("无法解析EPSV响应：%s", msg)
+	}
+	code, msg, err = ftpCmd(tp, "PASV")
+	if err != nil {
+		return 0, err
+	}
+	m := ftpPASVPattern.FindStringSubmatch(msg)
+	if code != 227 || m == nil {
+		return 0, fmt.Errorf("服务端不支持被动模式：%d %s", code, msg)
+	}
+	p1, _ := strconv.Atoi(m[5])
+	p2, _ := strconv.Atoi(m[6])
+	return p1*256 + p2, nil
+}
+
+// ftpError 区分超时、连接被关闭与其他错误
+func ftpError(action string, err error) (error, ErrorType) {
+	if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+		return fmt.Errorf("%s：等待响应超时", action), ErrorTypeTimeout
+	}
+	var protoErr *textproto.Error
+	if errors.As(err, &protoErr) {
+		return fmt.Errorf("%s：%d %s", action, protoErr.Code, protoErr.Msg), ErrorTypeFTP
+	}
+	if errors.Is(err, io.EOF) {
+		return fmt.Errorf("%s：连接被服务端关闭", action), ErrorTypeFTP
+	}
+	return fmt.Errorf("%s：%w", action, err), ErrorTypeNetwork
+}
+
+// fileTransferCredentials 读取目标的 username / password 凭据
+func fileTransferCredentials(target *MonitorTarget) (string, string, error) {
+	username, err := target.credential("username")
+	if err != nil {
+		return "", "", err
+	}
+	password, err := target.credential("password")
+	if err != nil {
+		return "", "", err
+	}
+	return username, password, nil
+}
+
+// fileTransferDialError 区分策略拒绝、超时与网络错误
+func fileTransferDialError(protocol string, err error) (error, ErrorType) {
+	var denied *EgressDeniedError
+	if errors.As(err, &denied) {
+		return denied, ErrorTypePolicy
+	}
+	if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+		return fmt.Errorf("%s连接超时：%w", protocol, err), ErrorTypeTimeout
+	}
+	return fmt.Errorf("%s连接失败：%w", protocol, err), ErrorTypeNetwork
+}
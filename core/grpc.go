@@ -0,0 +1,231 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/http2"
+)
+
+// grpcHealthPath 标准健康检查协议（grpc.health.v1）的 Check 方法
+const grpcHealthPath = "/grpc.health.v1.Health/Check"
+
+// maxGRPCResponse 健康检查响应的最大读取长度
+const maxGRPCResponse = 64 * 1024
+
+// gRPC 状态码（仅列出需要单独说明的）
+const (
+	grpcCodeOK            = 0
+	grpcCodeNotFound      = 5
+	grpcCodeUnimplemented = 12
+)
+
+// grpcServingStatus grpc.health.v1.HealthCheckResponse.ServingStatus 枚举值对应的名称
+var grpcServingStatus = map[uint64]string{
+	0: "UNKNOWN",
+	1: "SERVING",
+	2: "NOT_SERVING",
+	3: "SERVICE_UNKNOWN",
+}
+
+// checkGRPC 调用 grpc.health.v1.Health/Check 检查 gRPC 服务，SERVING 为成功，其余状态为失败
+// grpc:// 使用明文 HTTP/2（h2c），grpcs:// 使用 TLS（遵循目标的 TLS 配置档与 SNI）；地址路径为被检查的服务名，为空时检查整个服务端
+func (sc *ServiceChecker) checkGRPC(target *MonitorTarget, result *MonitorResult) (error, ErrorType) {
+	u, err := url.Parse(target.URL)
+	if err != nil || u.Hostname() == "" || u.Port() == "" {
+		return errors.New("无效的gRPC地址，格式应为 grpc://host:port/service"), ErrorTypeInvalid
+	}
+	secure := strings.EqualFold(u.Scheme, "grpcs")
+	service := strings.Trim(u.Path, "/")
+
+	timeout := sc.cfg.GRPCTimeout
+	dialer := sc.newDialer(target, timeout)
+	defer func() { result.recordDialAttempts(dialer.Attempts()) }()
+
+	transport := &http2.Transport{AllowHTTP: !secure}
+	scheme := "http"
+	var tlsProfile = builtinTLSProfiles[DefaultTLSProfile]
+	if secure {
+		tlsConfig, profile, err := sc.tlsConfig(target)
+		if err != nil {
+			return err, ErrorTypeInvalid
+		}
+		scheme, tlsProfile, transport.TLSClientConfig = "https", profile, tlsConfig
+		transport.DialTLSContext = func(ctx context.Context, network, addr string, cfg *tls.Config) (net.Conn, error) {
+			conn, err := dialer.DialContext(ctx, network, addr)
+			if err != nil {
+				return nil, err
+			}
+			tc := tls.Client(conn, cfg)
+			if err := tc.HandshakeContext(ctx); err != nil {
+				conn.Close()
+				return nil, err
+			}
+			return tc, nil
+		}
+	} else {
+		transport.DialTLSContext = func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+			return dialer.DialContext(ctx, network, addr)
+		}
+	}
+	defer transport.CloseIdleConnections()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "POST", scheme+"://"+u.Host+grpcHealthPath, bytes.NewReader(grpcFrame(healthCheckRequest(service))))
+	if err != nil {
+		return fmt.Errorf("创建gRPC请求失败：%w", err), ErrorTypeInvalid
+	}
+	req.Header.Set("Content-Type", "application/grpc")
+	req.Header.Set("TE", "trailers")
+	req.Header.Set("User-Agent", "ServiceMonitor/1.0 grpc-health-probe")
+	if target.HostHeader != "" {
+		req.Host = target.HostHeader
+	}
+
+	details := &GRPCDetails{Service: service, TLS: secure}
+	result.details().GRPC = details
+
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		var denied *EgressDeniedError
+		if errors.As(err, &denied) {
+			return denied, ErrorTypePolicy
+		}
+		if netErr, ok := err.(net.Error); ok && netErr.Timeout() || errors.Is(err, context.DeadlineExceeded) {
+			return fmt.Errorf("gRPC请求超时：%w", err), ErrorTypeTimeout
+		}
+		if strings.Contains(err.Error(), "certificate") {
+			return fmt.Errorf("SSL证书验证失败：%w", err), ErrorTypeSSL
+		}
+		return fmt.Errorf("gRPC请求失败：%w", err), ErrorTypeNetwork
+	}
+	defer resp.Body.Close()
+	result.StatusCode = resp.StatusCode
+	if resp.TLS != nil {
+		sc.recordTLS(target, tlsProfile, resp.TLS, result)
+	}
+
+	// 读完响应体后 Trailer 才可用；没有响应消息时服务端可能只返回 Trailers-Only 响应（状态在响应头中）
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxGRPCResponse))
+	if err != nil {
+		return fmt.Errorf("读取gRPC响应失败：%w", err), ErrorTypeNetwork
+	}
+	statusValue := resp.Trailer.Get("Grpc-Status")
+	details.Message = resp.Trailer.Get("Grpc-Message")
+	if statusValue == "" {
+		statusValue = resp.Header.Get("Grpc-Status")
+		details.Message = resp.Header.Get("Grpc-Message")
+	}
+	if statusValue == "" {
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("gRPC响应异常：HTTP状态码 %d", resp.StatusCode), ErrorTypeHTTP
+		}
+		return errors.New("gRPC响应缺少 grpc-status"), ErrorTypeGRPC
+	}
+	code, err := strconv.Atoi(statusValue)
+	if err != nil {
+		return fmt.Errorf("无效的 grpc-status：%s", statusValue), ErrorTypeGRPC
+	}
+	details.Code = code
+	if details.Message, err = url.PathUnescape(details.Message); err != nil {
+		details.Message = resp.Trailer.Get("Grpc-Message")
+	}
+
+	switch code {
+	case grpcCodeOK:
+	case grpcCodeUnimplemented:
+		return errors.New("服务端未实现 gRPC 健康检查协议（grpc.health.v1.Health）"), ErrorTypeGRPC
+	case grpcCodeNotFound:
+		details.ServingStatus = "SERVICE_UNKNOWN"
+		return fmt.Errorf("服务端未注册服务的健康状态：%s", service), ErrorTypeGRPC
+	default:
+		return fmt.Errorf("gRPC调用失败（code=%d）：%s", code, details.Message), ErrorTypeGRPC
+	}
+
+	status, err := parseHealthCheckResponse(body)
+	if err != nil {
+		return err, ErrorTypeGRPC
+	}
+	details.ServingStatus = status
+	if status != "SERVING" {
+		return fmt.Errorf("gRPC服务状态为 %s", status), ErrorTypeGRPC
+	}
+	return nil, ""
+}
+
+// healthCheckRequest 编码 HealthCheckRequest{service}（protobuf：字段 1，string）
+func healthCheckRequest(service string) []byte {
+	if service == "" {
+		return nil
+	}
+	msg := []byte{0x0a}
+	msg = binary.AppendUvarint(msg, uint64(len(service)))
+	return append(msg, service...)
+}
+
+// grpcFrame 按 gRPC 长度前缀格式封装消息：压缩标志（1 字节）+ 消息长度（4 字节大端）+ 消息
+func grpcFrame(msg []byte) []byte {
+	frame := make([]byte, 5+len(msg))
+	binary.BigEndian.PutUint32(frame[1:5], uint32(len(msg)))
+	copy(frame[5:], msg)
+	return frame
+}
+
+// parseHealthCheckResponse 解析 HealthCheckResponse（protobuf：字段 1，枚举 ServingStatus），返回状态名称
+func parseHealthCheckResponse(body []byte) (string, error) {
+	if len(body) < 5 {
+		return "", errors.New("gRPC响应消息为空")
+	}
+	if body[0] != 0 {
+		return "", errors.New("不支持压缩的gRPC响应")
+	}
+	length := binary.BigEndian.Uint32(body[1:5])
+	if int(length) > len(body)-5 {
+		return "", errors.New("gRPC响应消息不完整")
+	}
+	msg := body[5 : 5+length]
+
+	// 未设置的枚举字段不会被编码，默认值为 UNKNOWN
+	var value uint64
+	for len(msg) > 0 {
+		key, n := binary.Uvarint(msg)
+		if n <= 0 {
+			return "", errors.New("无法解析gRPC响应消息")
+		}
+		msg = msg[n:]
+		switch key & 0x7 {
+		case 0: // varint
+			v, n := binary.Uvarint(msg)
+			if n <= 0 {
+				return "", errors.New("无法解析gRPC响应消息")
+			}
+			msg = msg[n:]
+			if key>>3 == 1 {
+				value = v
+			}
+		case 2: // length-delimited
+			l, n := binary.Uvarint(msg)
+			if n <= 0 || uint64(len(msg)-n) < l {
+				return "", errors.New("无法解析gRPC响应消息")
+			}
+			msg = msg[n+int(l):]
+		default:
+			return "", errors.New("无法解析gRPC响应消息")
+		}
+	}
+	if name, ok := grpcServingStatus[value]; ok {
+		return name, nil
+	}
+	return fmt.Sprintf("UNKNOWN(%d)", value), nil
+}
@@ -0,0 +1,99 @@
+package core
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"servicetelemetry/config"
+
+	"golang.org/x/net/http/httpguts"
+)
+
+// reservedHeaders 不允许通过 headers 设置的请求头：Host 使用 hostHeader 配置，其余由 HTTP 客户端管理
+var reservedHeaders = map[string]bool{
+	"Host":              true,
+	"Content-Length":    true,
+	"Transfer-Encoding": true,
+	"Connection":        true,
+}
+
+// applyRequestAuth 为请求附加目标配置的请求头与认证信息（Basic 认证或 Bearer 令牌），检查时才解析凭据引用
+// 跳转到其他主机时 HTTP 客户端会自动去掉 Authorization 请求头，自定义请求头由 redirectRecorder 去掉，凭据不会发送给跳转后的主机
+func (t *MonitorTarget) applyRequestAuth(req *http.Request) error {
+	for name, ref := range t.Headers {
+		value, err := resolveRef("请求头 "+name, ref)
+		if err != nil {
+			return err
+		}
+		if !httpguts.ValidHeaderFieldValue(value) {
+			return fmt.Errorf("请求头 %s 的值包含非法字符", name)
+		}
+		req.Header.Set(name, value)
+	}
+	if t.BasicAuth != nil {
+		password, err := resolveRef("Basic 认证密码", t.BasicAuth.Password)
+		if err != nil {
+			return err
+		}
+		req.SetBasicAuth(t.BasicAuth.Username, password)
+	}
+	if t.BearerToken != "" {
+		token, err := resolveRef("Bearer 令牌", t.BearerToken)
+		if err != nil {
+			return err
+		}
+		if !httpguts.ValidHeaderFieldValue(token) {
+			return fmt.Errorf("Bearer 令牌包含非法字符")
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return nil
+}
+
+// validateRequestAuth 校验请求头与认证配置
+func validateRequestAuth(target *MonitorTarget) []error {
+	if len(target.Headers) == 0 && target.BasicAuth == nil && target.BearerToken == "" {
+		return nil
+	}
+	var errs []error
+	if scheme := targetScheme(target.URL); scheme != "http" && scheme != "https" {
+		errs = append(errs, fmt.Errorf("请求头与认证配置仅适用于 HTTP/HTTPS 目标"))
+	}
+	hasAuthorization := false
+	for name := range target.Headers {
+		canonical := http.CanonicalHeaderKey(name)
+		switch {
+		case !httpguts.ValidHeaderFieldName(name):
+			errs = append(errs, fmt.Errorf("无效的请求头名称：%q", name))
+		case reservedHeaders[canonical]:
+			errs = append(errs, fmt.Errorf("不能通过 headers 设置请求头 %s（Host 请使用 hostHeader）", canonical))
+		case canonical == "Authorization":
+			hasAuthorization = true
+		}
+		if strings.ContainsAny(target.Headers[name], "\r\n") {
+			errs = append(errs, fmt.Errorf("请求头 %s 的值不能包含换行", name))
+		}
+	}
+	if target.BasicAuth != nil {
+		if target.BasicAuth.Username == "" || strings.Contains(target.BasicAuth.Username, ":") {
+			errs = append(errs, fmt.Errorf("Basic 认证的用户名不能为空，且不能包含冒号"))
+		}
+		if target.BearerToken != "" {
+			errs = append(errs, fmt.Errorf("basicAuth 与 bearerToken 只能配置其中一个"))
+		}
+	}
+	if hasAuthorization && (target.BasicAuth != nil || target.BearerToken != "") {
+		errs = append(errs, fmt.Errorf("headers 中已有 Authorization，不能同时配置 basicAuth 或 bearerToken"))
+	}
+	return errs
+}
+
+// resolveRef 解析凭据引用，错误信息中带上用途
+func resolveRef(name, ref string) (string, error) {
+	value, err := config.ResolveSecret(ref)
+	if err != nil {
+		return "", fmt.Errorf("解析%s失败：%w", name, err)
+	}
+	return value, nil
+}
@@ -0,0 +1,285 @@
+package core
+
+import (
+	"math"
+	"sort"
+
+	"servicetelemetry/config"
+)
+
+// TargetStats 单个目标在一段时间内的检查统计
+type TargetStats struct {
+	TargetURL     string  `json:"targetUrl"`             // 目标地址
+	Total         int     `json:"total"`                 // 检查次数
+	Failed        int     `json:"failed"`                // 失败次数
+	Degraded      int     `json:"degraded"`              // 降级次数（响应耗时超过阈值，计入可用）
+	Uptime        float64 `json:"uptime"`                // 可用率（百分比）
+	AvgMs         float64 `json:"avgMs"`                 // 成功检查的平均耗时（毫秒）
+	P95Ms         float64 `json:"p95Ms"`                 // 成功检查的 P95 耗时（毫秒）
+	Incidents     int     `json:"incidents"`             // 由正常变为失败的次数
+	LastStatus    string  `json:"lastStatus"`            // 最近一次检查状态
+	SSLCertExpiry string  `json:"sslCertExpiry"`         // 最近一次检查的证书过期信息
+	SSLDaysLeft   *int    `json:"sslDaysLeft,omitempty"` // 最近一次检查的证书剩余天数
+
+	BusinessTotal  int     `json:"businessTotal"`  // 工作时间内的检查次数（未关联日历时为 0）
+	BusinessFailed int     `json:"businessFailed"` // 工作时间内的失败次数
+	BusinessUptime float64 `json:"businessUptime"` // 工作时间内的可用率（百分比）
+}
+
+// Percentile 计算已升序排列的样本的分位数（最近秩法），样本为空时返回 0
+// p：分位，取值 0~1
+func Percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	idx := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if idx < 0 {
+		idx = 0
+	}
+	return sorted[idx]
+}
+
+// HealthComponents 健康分的各项分量，每项 0~100
+type HealthComponents struct {
+	Availability float64 `json:"availability"` // 可用率：100% 为满分，低于 90% 为 0 分
+	Latency      float64 `json:"latency"`      // 延迟：P95 不超过基线的 1.2 倍为满分，达到 3 倍为 0 分
+	Incidents    float64 `json:"incidents"`    // 近期故障：每次由正常变为失败扣 25 分
+	Certificate  float64 `json:"certificate"`  // 证书：已过期为 0 分，7 天内过期 25 分，30 天内过期 60 分
+}
+
+// HealthScore 单个目标的综合健康分
+type HealthScore struct {
+	TargetURL     string           `json:"targetUrl"`             // 目标地址
+	Tags          []string         `json:"tags"`                  // 目标标签
+	Score         float64          `json:"score"`                 // 综合健康分（0~100）
+	Components    HealthComponents `json:"components"`            // 各项分量
+	Uptime        float64          `json:"uptime"`                // 统计窗口内的可用率（百分比）
+	P95Ms         float64          `json:"p95Ms"`                 // 统计窗口内的 P95 耗时
+	BaselineP95Ms float64          `json:"baselineP95Ms"`         // 基线窗口内的 P95 耗时，无基线数据时为 0
+	Incidents     int              `json:"incidents"`             // 统计窗口内由正常变为失败的次数
+	Checks        int              `json:"checks"`                // 统计窗口内的检查次数
+	UptimeTrend   *Trend           `json:"uptimeTrend,omitempty"` // 可用率相对上一个等长周期的变化
+	P95Trend      *Trend           `json:"p95Trend,omitempty"`    // P95 耗时相对上一个等长周期的变化
+}
+
+// TagHealth 标签维度的健康分汇总
+type TagHealth struct {
+	Tag         string  `json:"tag"`                   // 标签
+	Score       float64 `json:"score"`                 // 标签下各目标健康分的平均值
+	MinScore    float64 `json:"minScore"`              // 标签下最低的目标健康分
+	Targets     int     `json:"targets"`               // 标签下的目标数
+	WorstTarget string  `json:"worstTarget"`           // 健康分最低的目标
+	UptimeTrend *Trend  `json:"uptimeTrend,omitempty"` // 标签下目标平均可用率相对上一个等长周期的变化
+	P95Trend    *Trend  `json:"p95Trend,omitempty"`    // 标签下目标平均 P95 耗时相对上一个等长周期的变化
+}
+
+// ComputeHealth 根据统计窗口与基线窗口的统计计算各目标的健康分，并按标签汇总，均按健康分升序（最差的在前）
+// current：统计窗口内各目标的统计
+// baseline：基线窗口内各目标的统计（用于判断延迟是否劣化），可为空
+// tags：目标地址 -> 标签
+// weights：各分量的权重
+func ComputeHealth(current, baseline map[string]*TargetStats, tags map[string][]string, weights config.HealthWeights) ([]*HealthScore, []*TagHealth) {
+	scores := make([]*HealthScore, 0, len(current))
+	for url, st := range current {
+		if st.Total == 0 {
+			continue
+		}
+		hs := &HealthScore{
+			TargetURL: url,
+			Tags:      tags[url],
+			Uptime:    round1(st.Uptime),
+			P95Ms:     round1(st.P95Ms),
+			Incidents: st.Incidents,
+			Checks:    st.Total,
+		}
+		if b, ok := baseline[url]; ok && b.P95Ms > 0 {
+			hs.BaselineP95Ms = round1(b.P95Ms)
+		}
+		hs.Components = HealthComponents{
+			Availability: availabilityScore(st.Uptime),
+			Latency:      latencyScore(st.P95Ms, hs.BaselineP95Ms),
+			Incidents:    math.Max(0, 100-25*float64(st.Incidents)),
+			Certificate:  certificateScore(st.certDaysLeft()),
+		}
+		hs.Score = weightedScore(hs.Components, weights)
+		scores = append(scores, hs)
+	}
+	sort.Slice(scores, func(i, j int) bool {
+		if scores[i].Score != scores[j].Score {
+			return scores[i].Score < scores[j].Score
+		}
+		return scores[i].TargetURL < scores[j].TargetURL
+	})
+
+	byTag := make(map[string]*TagHealth)
+	sums := make(map[string]float64)
+	for _, hs := range scores {
+		for _, tag := range hs.Tags {
+			th, ok := byTag[tag]
+			if !ok {
+				// 目标已按健康分升序，首个即为最差目标
+				th = &TagHealth{Tag: tag, MinScore: hs.Score, WorstTarget: hs.TargetURL}
+				byTag[tag] = th
+			}
+			th.Targets++
+			sums[tag] += hs.Score
+		}
+	}
+	tagList := make([]*TagHealth, 0, len(byTag))
+	for tag, th := range byTag {
+		th.Score = round1(sums[tag] / float64(th.Targets))
+		tagList = append(tagList, th)
+	}
+	sort.Slice(tagList, func(i, j int) bool {
+		if tagList[i].Score != tagList[j].Score {
+			return tagList[i].Score < tagList[j].Score
+		}
+		return tagList[i].Tag < tagList[j].Tag
+	})
+	return scores, tagList
+}
+
+// availabilityScore 可用率分量：100% 为满分，90% 及以下为 0 分，之间线性
+func availabilityScore(uptime float64) float64 {
+	return clampScore((uptime - 90) * 10)
+}
+
+// latencyScore 延迟分量：无基线时为满分；P95 不超过基线 1.2 倍为满分，达到 3 倍为 0 分，之间线性
+func latencyScore(p95, baseline float64) float64 {
+	if baseline <= 0 || p95 <= 0 {
+		return 100
+	}
+	ratio := p95 / baseline
+	return clampScore((3 - ratio) / (3 - 1.2) * 100)
+}
+
+// certificateScore 证书分量，根据最近一次检查的证书剩余天数计算，非 HTTPS 目标为满分
+func certificateScore(days int, ok bool) float64 {
+	switch {
+	case !ok:
+		return 100
+	case days <= 0:
+		return 0
+	case days <= 7:
+		return 25
+	case days <= 30:
+		return 60
+	}
+	return 100
+}
+
+// certDaysLeft 返回最近一次检查的证书剩余天数，历史结果从展示文本解析
+func (st *TargetStats) certDaysLeft() (int, bool) {
+	if st.SSLDaysLeft != nil {
+		return *st.SSLDaysLeft, true
+	}
+	return ParseCertExpiryText(st.SSLCertExpiry)
+}
+
+// weightedScore 按权重合成综合健康分，权重之和为 0 时使用可用率分量
+func weightedScore(c HealthComponents, w config.HealthWeights) float64 {
+	total := w.Availability + w.Latency + w.Incidents + w.Certificate
+	if total <= 0 {
+		return round1(c.Availability)
+	}
+	sum := c.Availability*w.Availability + c.Latency*w.Latency + c.Incidents*w.Incidents + c.Certificate*w.Certificate
+	return round1(sum / total)
+}
+
+// clampScore 将分数限制在 0~100 并保留一位小数
+func clampScore(v float64) float64 {
+	return round1(math.Max(0, math.Min(100, v)))
+}
+
+// round1 保留一位小数
+func round1(v float64) float64 {
+	return math.Round(v*10) / 10
+}
+
+// 趋势方向
+const (
+	TrendUp   = "up"   // 上升
+	TrendDown = "down" // 下降
+	TrendFlat = "flat" // 持平（变化在容差内）
+)
+
+// Trend 指标相对上一个等长周期的变化
+type Trend struct {
+	Previous  float64 `json:"previous"`  // 上一周期的值
+	Delta     float64 `json:"delta"`     // 变化量（当前 - 上一周期）
+	Direction string  `json:"direction"` // 变化方向：up / down / flat
+	Arrow     string  `json:"arrow"`     // 方向箭头：↑ / ↓ / →，供大屏与报表直接展示
+	Better    *bool   `json:"better"`    // 是否变好（持平时为 null）
+}
+
+// NewTrend 计算指标相对上一周期的变化
+// current、previous：当前与上一周期的值
+// tolerance：视为持平的最大变化量（绝对值）
+// higherIsBetter：指标越大越好（如可用率）为 true，越小越好（如耗时）为 false
+func NewTrend(current, previous, tolerance float64, higherIsBetter bool) *Trend {
+	t := &Trend{Previous: round1(previous), Delta: round1(current - previous), Direction: TrendFlat, Arrow: "→"}
+	if math.Abs(current-previous) <= tolerance {
+		return t
+	}
+	up := current > previous
+	better := up == higherIsBetter
+	t.Better = &better
+	if up {
+		t.Direction, t.Arrow = TrendUp, "↑"
+	} else {
+		t.Direction, t.Arrow = TrendDown, "↓"
+	}
+	return t
+}
+
+// 判断持平的容差：可用率 0.1 个百分点，P95 耗时变化 5% 以内
+const (
+	uptimeTolerance  = 0.1
+	latencyTolerance = 0.05
+)
+
+// ApplyTrends 为健康分结果补充相对上一个等长周期的可用率与 P95 耗时变化，上一周期无数据的目标不补充
+// scores、tags：ComputeHealth 的计算结果
+// previous：上一个等长周期内各目标的统计
+func ApplyTrends(scores []*HealthScore, tags []*TagHealth, previous map[string]*TargetStats) {
+	type tagAgg struct {
+		uptime, prevUptime, p95, prevP95 float64
+		n                                int
+	}
+	aggs := make(map[string]*tagAgg)
+
+	for _, hs := range scores {
+		prev, ok := previous[hs.TargetURL]
+		if !ok || prev.Total == 0 {
+			continue
+		}
+		hs.UptimeTrend = NewTrend(hs.Uptime, prev.Uptime, uptimeTolerance, true)
+		if hs.P95Ms > 0 && prev.P95Ms > 0 {
+			hs.P95Trend = NewTrend(hs.P95Ms, prev.P95Ms, prev.P95Ms*latencyTolerance, false)
+		}
+		for _, tag := range hs.Tags {
+			a, ok := aggs[tag]
+			if !ok {
+				a = &tagAgg{}
+				aggs[tag] = a
+			}
+			a.n++
+			a.uptime += hs.Uptime
+			a.prevUptime += prev.Uptime
+			a.p95 += hs.P95Ms
+			a.prevP95 += prev.P95Ms
+		}
+	}
+
+	for _, th := range tags {
+		a, ok := aggs[th.Tag]
+		if !ok {
+			continue
+		}
+		n := float64(a.n)
+		th.UptimeTrend = NewTrend(a.uptime/n, a.prevUptime/n, uptimeTolerance, true)
+		if a.p95 > 0 && a.prevP95 > 0 {
+			th.P95Trend = NewTrend(a.p95/n, a.prevP95/n, a.prevP95/n*latencyTolerance, false)
+		}
+	}
+}
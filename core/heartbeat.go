@@ -0,0 +1,170 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"servicetelemetry/config"
+)
+
+// minHeartbeatToken 明文心跳令牌的最短长度，令牌即为上报心跳的唯一凭据
+const minHeartbeatToken = 16
+
+// HeartbeatPing 心跳目标的心跳记录
+type HeartbeatPing struct {
+	Since      time.Time  // 开始等待心跳的时间（首次检查该目标的时间），从未收到心跳时据此判断是否超时
+	LastPingAt *time.Time // 最近一次收到心跳的时间，从未收到时为空
+	Pings      int64      // 累计收到的心跳次数
+	Source     string     // 最近一次心跳的来源地址
+
+	// 以下为开始信号（PUT /heartbeats/:token/start）记录的运行信息，任务只上报完成心跳时均为空
+	Running       int        // 进行中的运行数，大于 1 表示运行重叠
+	RunStartedAt  *time.Time // 进行中最早一次运行的开始时间
+	LastStartAt   *time.Time // 最近一次开始信号的时间
+	LastRunMs     *int64     // 最近一次完成的运行耗时（毫秒）
+	LastRunEndAt  *time.Time // 最近一次完成的运行结束时间
+	Overlaps      int64      // 累计重叠次数
+	LastOverlapAt *time.Time // 最近一次重叠（上一次运行未结束时收到开始信号）的时间
+	AbandonedRuns int64      // 累计放弃的运行数（超过运行时限仍未结束，又收到新的开始信号）
+}
+
+// HeartbeatStore 心跳记录的读取接口，由存储层实现；多实例部署时心跳可能由任一实例接收，因此记录在共享的数据库中
+type HeartbeatStore interface {
+	// HeartbeatFor 返回目标的心跳记录，首次调用时开始等待心跳
+	HeartbeatFor(targetURL string) (*HeartbeatPing, error)
+}
+
+// SetHeartbeatStore 设置心跳记录，heartbeat:// 目标据此判断是否按时收到心跳
+func (sc *ServiceChecker) SetHeartbeatStore(store HeartbeatStore) {
+	sc.heartbeats = store
+}
+
+// HeartbeatRunLimit 运行时限：配置了 maxRunSeconds 时为该值，否则为预期间隔加容许延迟；
+// 收到开始信号时，开始时间早于该时限仍未结束的运行视为已放弃（任务异常退出未上报完成），不计为重叠
+func (t *MonitorTarget) HeartbeatRunLimit() time.Duration {
+	opts := t.Heartbeat
+	if opts == nil {
+		return 0
+	}
+	if opts.MaxRunSeconds > 0 {
+		return time.Duration(opts.MaxRunSeconds) * time.Second
+	}
+	return time.Duration(opts.PeriodSeconds+opts.GraceSeconds) * time.Second
+}
+
+// HeartbeatToken 解析目标的心跳令牌，非心跳目标返回空字符串
+func (t *MonitorTarget) HeartbeatToken() (string, error) {
+	if t.Heartbeat == nil || targetScheme(t.URL) != "heartbeat" {
+		return "", nil
+	}
+	return config.ResolveSecret(t.Heartbeat.Token)
+}
+
+// checkHeartbeat 心跳检查：最近一次心跳（从未收到时为开始等待的时间）距今超过 periodSeconds + graceSeconds 时失败
+func (sc *ServiceChecker) checkHeartbeat(target *MonitorTarget, result *MonitorResult) (error, ErrorType) {
+	opts := target.Heartbeat
+	if opts == nil || opts.PeriodSeconds <= 0 {
+		return errors.New("心跳目标未配置 heartbeat.periodSeconds"), ErrorTypeInvalid
+	}
+	if sc.heartbeats == nil {
+		return errors.New("未配置心跳记录存储"), ErrorTypeInvalid
+	}
+	ping, err := sc.heartbeats.HeartbeatFor(target.URL)
+	if err != nil {
+		return fmt.Errorf("读取心跳记录失败：%w", err), ErrorTypeUnknown
+	}
+
+	window := time.Duration(opts.PeriodSeconds+opts.GraceSeconds) * time.Second
+	last := ping.Since
+	if ping.LastPingAt != nil {
+		last = *ping.LastPingAt
+	}
+	details := &HeartbeatDetails{
+		LastPingAt: ping.LastPingAt,
+		Pings:      ping.Pings,
+		Source:     ping.Source,
+		Deadline:   last.Add(window),
+	}
+	result.details().Heartbeat = details
+
+	overdue := time.Since(details.Deadline)
+	if overdue <= 0 {
+		if ping.LastPingAt == nil && ping.Running == 0 {
+			result.addWarning(fmt.Sprintf("尚未收到心跳，%s 前未收到时检查失败", details.Deadline.Format(time.RFC3339)))
+		}
+		return checkHeartbeatRuns(opts, ping, details)
+	}
+	details.OverdueSeconds = int64(overdue.Seconds())
+	if ping.LastPingAt == nil {
+		return fmt.Errorf("从未收到心跳（已等待 %s，预期间隔 %ds，容许延迟 %ds）", time.Since(ping.Since).Round(time.Second), opts.PeriodSeconds, opts.GraceSeconds), ErrorTypeHeartbeat
+	}
+	return fmt.Errorf("最近一次心跳在 %s 前（%s），超过预期间隔 %ds 与容许延迟 %ds",
+		time.Since(last).Round(time.Second), last.Format(time.RFC3339), opts.PeriodSeconds, opts.GraceSeconds), ErrorTypeHeartbeat
+}
+
+// checkHeartbeatRuns 按开始信号记录的运行信息判断：进行中的运行超过 maxRunSeconds、最近一次运行与上一次运行重叠、
+// 最近一次完成的运行耗时超出 minRunSeconds / maxRunSeconds 时失败；重叠与耗时异常持续到下一次正常的运行
+func checkHeartbeatRuns(opts *config.HeartbeatOptions, ping *HeartbeatPing, details *HeartbeatDetails) (error, ErrorType) {
+	details.Running = ping.Running
+	details.RunStartedAt = ping.RunStartedAt
+	details.LastRunEndAt = ping.LastRunEndAt
+	details.Overlaps = ping.Overlaps
+	details.LastOverlapAt = ping.LastOverlapAt
+	details.AbandonedRuns = ping.AbandonedRuns
+	if ping.LastRunMs != nil {
+		details.LastRunSeconds = float64(*ping.LastRunMs) / 1000
+	}
+
+	maxRun := time.Duration(opts.MaxRunSeconds) * time.Second
+	if ping.Running > 0 && ping.RunStartedAt != nil {
+		elapsed := time.Since(*ping.RunStartedAt)
+		details.RunningSeconds = int64(elapsed.Seconds())
+		if maxRun > 0 && elapsed > maxRun {
+			return fmt.Errorf("本次运行自 %s 开始已持续 %s，超过最长耗时 %ds",
+				ping.RunStartedAt.Format(time.RFC3339), elapsed.Round(time.Second), opts.MaxRunSeconds), ErrorTypeHeartbeat
+		}
+	}
+	if !opts.AllowOverlap && ping.LastOverlapAt != nil && ping.LastStartAt != nil && !ping.LastOverlapAt.Before(*ping.LastStartAt) {
+		return fmt.Errorf("运行重叠：%s 开始新的运行时上一次运行尚未结束（进行中 %d 个）",
+			ping.LastOverlapAt.Format(time.RFC3339), ping.Running), ErrorTypeHeartbeat
+	}
+	if ping.LastRunMs == nil {
+		return nil, ""
+	}
+	last := time.Duration(*ping.LastRunMs) * time.Millisecond
+	switch {
+	case maxRun > 0 && last > maxRun:
+		return fmt.Errorf("最近一次运行耗时 %s，超过最长耗时 %ds", last.Round(time.Second), opts.MaxRunSeconds), ErrorTypeHeartbeat
+	case opts.MinRunSeconds > 0 && last < time.Duration(opts.MinRunSeconds)*time.Second:
+		return fmt.Errorf("最近一次运行耗时 %s，短于最短耗时 %ds", last.Round(time.Millisecond), opts.MinRunSeconds), ErrorTypeHeartbeat
+	}
+	return nil, ""
+}
+
+// validateHeartbeat 校验心跳选项：heartbeat:// 目标必须配置令牌与预期间隔，其他目标不能配置心跳选项
+func validateHeartbeat(target *MonitorTarget) error {
+	opts := target.Heartbeat
+	if targetScheme(target.URL) != "heartbeat" {
+		if opts != nil {
+			return fmt.Errorf("heartbeat 选项仅适用于 heartbeat:// 目标")
+		}
+		return nil
+	}
+	switch {
+	case opts == nil || opts.Token == "":
+		return fmt.Errorf("心跳目标必须配置 heartbeat.token")
+	case opts.PeriodSeconds <= 0:
+		return fmt.Errorf("心跳目标必须配置 heartbeat.periodSeconds（任务的预期执行间隔）")
+	case opts.GraceSeconds < 0:
+		return fmt.Errorf("heartbeat.graceSeconds 不能为负数")
+	case opts.MinRunSeconds < 0 || opts.MaxRunSeconds < 0:
+		return fmt.Errorf("heartbeat.minRunSeconds / maxRunSeconds 不能为负数")
+	case opts.MaxRunSeconds > 0 && opts.MinRunSeconds >= opts.MaxRunSeconds:
+		return fmt.Errorf("heartbeat.minRunSeconds 必须小于 maxRunSeconds")
+	}
+	if !config.IsSecretRef(opts.Token) && len(opts.Token) < minHeartbeatToken {
+		return fmt.Errorf("心跳令牌至少 %d 个字符", minHeartbeatToken)
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptrace"
+	"time"
+
+	"servicetelemetry/config"
+)
+
+// HedgeDetails 对冲请求（hedge）结果：首个请求在延迟内未完成时发出第二个请求，采用最先成功的响应
+type HedgeDetails struct {
+	DelayMs  float64        `json:"delayMs"`  // 配置的对冲延迟（毫秒）
+	Fired    bool           `json:"fired"`    // 是否发出了对冲请求，首个请求在延迟内完成时不发出
+	Winner   int            `json:"winner"`   // 采用的响应来自第几个请求：1 首个请求，2 对冲请求；全部失败时为 0
+	Attempts []HedgeAttempt `json:"attempts"` // 各请求的结果
+}
+
+// HedgeAttempt 对冲请求中的一个请求
+type HedgeAttempt struct {
+	Attempt    int     `json:"attempt"`              // 第几个请求
+	StartMs    float64 `json:"startMs"`              // 相对首个请求的发出时间（毫秒）
+	DurationMs float64 `json:"durationMs,omitempty"` // 收到响应头或失败的耗时（毫秒），被取消的请求为空
+	Error      string  `json:"error,omitempty"`      // 请求失败原因
+	Canceled   bool    `json:"canceled,omitempty"`   // 另一个请求已先成功，该请求被取消
+}
+
+// httpAttempt 一次 HTTP 请求：使用独立的阶段耗时与重定向记录，对冲的两个请求互不干扰
+type httpAttempt struct {
+	n         int
+	client    *http.Client
+	req       *http.Request
+	tracer    *phaseTracer
+	redirects *redirectRecorder
+	cancel    context.CancelFunc
+	launched  time.Time // 发出时间（对冲请求由发起方记录，读取时不与请求协程竞争）
+
+	start time.Time
+	done  time.Time
+	resp  *http.Response
+	err   error
+}
+
+// newHTTPAttempt 基于请求模板创建一次请求，client 的重定向策略改为该请求自己的重定向记录器
+func newHTTPAttempt(n int, target *MonitorTarget, policy *config.URLPolicyConfig, client *http.Client, req *http.Request) *httpAttempt {
+	a := &httpAttempt{n: n, tracer: &phaseTracer{}, redirects: newRedirectRecorder(target, policy)}
+	c := *client
+	c.CheckRedirect = a.redirects.CheckRedirect
+	a.client = &c
+	ctx, cancel := context.WithCancel(httptrace.WithClientTrace(req.Context(), a.tracer.trace()))
+	a.req, a.cancel = req.Clone(ctx), cancel
+	return a
+}
+
+// do 发送请求，返回时已收到响应头或失败
+func (a *httpAttempt) do() {
+	a.start = time.Now()
+	a.resp, a.err = a.client.Do(a.req)
+	a.done = time.Now()
+}
+
+// discard 取消请求并关闭已收到的响应体
+func (a *httpAttempt) discard() {
+	a.cancel()
+	if a.resp != nil {
+		a.resp.Body.Close()
+	}
+}
+
+// doHTTP 发送检查请求：目标配置了 hedge 时按对冲方式发送，否则只发送一次
+// newClient 创建对冲请求使用的客户端（独立的连接池，不复用首个请求所在的连接）
+// policy：跳转地址的安全校验策略，为 nil 时不校验
+// 返回采用的请求，调用方读完响应后需调用其 cancel
+func doHTTP(target *MonitorTarget, policy *config.URLPolicyConfig, client *http.Client, req *http.Request, newClient func() (*http.Client, error), result *MonitorResult) *httpAttempt {
+	first := newHTTPAttempt(1, target, policy, client, req)
+	if target.Hedge == nil || target.Hedge.DelayMs <= 0 {
+		first.do()
+		return first
+	}
+
+	delay := time.Duration(target.Hedge.DelayMs) * time.Millisecond
+	details := &HedgeDetails{DelayMs: float64(target.Hedge.DelayMs)}
+	attempts := []*httpAttempt{first}
+	results := make(chan *httpAttempt, 2)
+	launch := func(a *httpAttempt) {
+		a.launched = time.Now()
+		go func() {
+			a.do()
+			results <- a
+		}()
+	}
+	launch(first)
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	var winner *httpAttempt
+	finished := map[int]bool{}
+	for winner == nil && len(finished) < len(attempts) {
+		select {
+		case <-timer.C:
+			c, err := newClient()
+			if err != nil {
+				log.Warnf("检查[%s]创建对冲请求失败：%v", target.URL, err)
+				continue
+			}
+			second := newHTTPAttempt(2, target, policy, c, req)
+			attempts = append(attempts, second)
+			details.Fired = true
+			launch(second)
+		case a := <-results:
+			finished[a.n] = true
+			if a.err == nil {
+				winner = a
+			}
+		}
+	}
+
+	// 采用最先成功的请求；全部失败时以首个请求的错误作为检查结果
+	chosen := winner
+	if chosen == nil {
+		chosen = first
+	} else {
+		details.Winner = winner.n
+	}
+	for _, a := range attempts {
+		ha := HedgeAttempt{Attempt: a.n, StartMs: durationMs(a.launched.Sub(first.launched))}
+		if !finished[a.n] {
+			// 仍在进行的请求取消后在后台回收，避免读取其结果产生数据竞争
+			ha.Canceled = true
+			a.cancel()
+			go func() { (<-results).discard() }()
+		} else {
+			ha.DurationMs = durationMs(a.done.Sub(a.start))
+			if a.err != nil {
+				ha.Error = a.err.Error()
+			}
+			if a != chosen {
+				a.discard()
+			}
+		}
+		details.Attempts = append(details.Attempts, ha)
+	}
+	result.details().Hedge = details
+	if details.Fired {
+		log.Debugf("检查[%s]发出对冲请求，采用第 %d 个请求的响应", target.URL, details.Winner)
+	}
+	return chosen
+}
+
+// validateHedge 校验对冲请求选项：只适用于 HTTP/HTTPS 目标，不能与需要下载整个文件的摘要校验同时使用
+func validateHedge(target *MonitorTarget) error {
+	h := target.Hedge
+	if h == nil {
+		return nil
+	}
+	if scheme := targetScheme(target.URL); scheme != "http" && scheme != "https" {
+		return fmt.Errorf("对冲请求仅支持 HTTP/HTTPS 目标")
+	}
+	if h.DelayMs <= 0 {
+		return fmt.Errorf("hedge.delayMs 必须大于 0")
+	}
+	if target.Checksum != nil {
+		return fmt.Errorf("对冲请求不能与摘要校验同时使用（会重复下载整个文件）")
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package core
+
+import (
+	"sort"
+	"time"
+)
+
+// 主机聚合状态
+const (
+	HostStatusUp      = "up"      // 主机上全部目标正常
+	HostStatusPartial = "partial" // 主机上部分目标异常
+	HostStatusDown    = "down"    // 主机上全部目标异常
+)
+
+// HostSummary 主机维度的聚合状态，同一主机（域名或IP）上的多个监控目标合并展示
+type HostSummary struct {
+	Host        string           `json:"host"`        // 主机名或IP
+	Status      string           `json:"status"`      // 聚合状态：up / partial / down
+	Total       int              `json:"total"`       // 目标总数
+	Failed      int              `json:"failed"`      // 异常目标数
+	Degraded    int              `json:"degraded"`    // 降级目标数（响应耗时超过阈值，不影响聚合状态）
+	LastChecked time.Time        `json:"lastChecked"` // 最近一次检查时间
+	Targets     []*MonitorResult `json:"targets"`     // 各目标最近一次检查结果
+}
+
+// AggregateByHost 将各目标的最新结果按主机聚合，异常主机排在前面
+// results：每个目标的最新检查结果
+func AggregateByHost(results []*MonitorResult) []*HostSummary {
+	byHost := make(map[string]*HostSummary)
+	for _, r := range results {
+		host := TargetHost(r.TargetURL)
+		if host == "" {
+			host = r.TargetURL
+		}
+
+		hs, ok := byHost[host]
+		if !ok {
+			hs = &HostSummary{Host: host}
+			byHost[host] = hs
+		}
+		hs.Total++
+		switch r.Status {
+		case StatusFailed:
+			hs.Failed++
+		case StatusDegraded:
+			hs.Degraded++
+		}
+		if r.CheckedAt.After(hs.LastChecked) {
+			hs.LastChecked = r.CheckedAt
+		}
+		hs.Targets = append(hs.Targets, r)
+	}
+
+	list := make([]*HostSummary, 0, len(byHost))
+	for _, hs := range byHost {
+		switch {
+		case hs.Failed == 0:
+			hs.Status = HostStatusUp
+		case hs.Failed == hs.Total:
+			hs.Status = HostStatusDown
+		default:
+			hs.Status = HostStatusPartial
+		}
+		sort.Slice(hs.Targets, func(i, j int) bool {
+			return hs.Targets[i].TargetURL < hs.Targets[j].TargetURL
+		})
+		list = append(list, hs)
+	}
+
+	rank := map[string]int{HostStatusDown: 0, HostStatusPartial: 1, HostStatusUp: 2}
+	sort.Slice(list, func(i, j int) bool {
+		if rank[list[i].Status] != rank[list[j].Status] {
+			return rank[list[i].Status] < rank[list[j].Status]
+		}
+		return list[i].Host < list[j].Host
+	})
+	return list
+}
@@ -0,0 +1,130 @@
+package core
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/http3"
+	"golang.org/x/net/http2"
+)
+
+// 目标可强制使用的 HTTP 版本
+const (
+	HTTPVersion11 = "1.1" // HTTP/1.1（默认）
+	HTTPVersion2  = "2"   // HTTP/2：HTTPS 经 ALPN 协商 h2，HTTP 使用明文 HTTP/2（h2c）
+	HTTPVersion3  = "3"   // HTTP/3：经 QUIC（UDP）发送请求，仅适用于 HTTPS 目标
+)
+
+// validateHTTPVersion 校验目标强制的 HTTP 版本：仅适用于 HTTP/HTTPS 目标，HTTP/3 仅适用于 HTTPS 目标
+func validateHTTPVersion(target *MonitorTarget) error {
+	switch target.HTTPVersion {
+	case "":
+		return nil
+	case HTTPVersion11, HTTPVersion2, HTTPVersion3:
+	default:
+		return fmt.Errorf("无效的 httpVersion：%s（可选 1.1 / 2 / 3）", target.HTTPVersion)
+	}
+	scheme := targetScheme(target.URL)
+	if scheme != "http" && scheme != "https" {
+		return fmt.Errorf("httpVersion 仅适用于 HTTP/HTTPS 目标")
+	}
+	if target.HTTPVersion == HTTPVersion3 && scheme != "https" {
+		return fmt.Errorf("HTTP/3 仅适用于 HTTPS 目标")
+	}
+	return nil
+}
+
+// httpTransport 按目标强制的 HTTP 版本构建传输层
+// HTTP/2 的 HTTPS 目标经 ALPN 协商（服务器未选择 h2 时回落到 HTTP/1.1，由调用方判定失败），
+// HTTP 目标直接以明文 HTTP/2（h2c prior knowledge）发送请求，不支持经代理访问；
+// HTTP/3 经 QUIC 发送请求，同样不支持经代理访问，使用完毕后需要关闭传输层（实现 io.Closer）
+func (sc *ServiceChecker) httpTransport(target *MonitorTarget, tlsConfig *tls.Config, dialer *probeDialer, proxyURL *url.URL) (http.RoundTripper, error) {
+	if target.HTTPVersion == HTTPVersion3 {
+		if proxyURL != nil {
+			return nil, errors.New("HTTP/3 不支持经代理访问，请为目标配置 proxy: direct")
+		}
+		return &http3.RoundTripper{
+			TLSClientConfig: tlsConfig,
+			Dial:            dialer.dialQUIC,
+		}, nil
+	}
+	if target.HTTPVersion == HTTPVersion2 && targetScheme(target.URL) == "http" {
+		if proxyURL != nil {
+			return nil, errors.New("明文 HTTP/2（h2c）不支持经代理访问，请为目标配置 proxy: direct")
+		}
+		return &http2.Transport{
+			AllowHTTP: true,
+			DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+				return dialer.DialContext(ctx, network, addr)
+			},
+		}, nil
+	}
+	return &http.Transport{
+		TLSClientConfig:   tlsConfig, // 默认配置档强制TLS 1.2+
+		DisableKeepAlives: true,      // 关闭长连接
+		DialContext:       dialer.DialContext,
+		Proxy:             httpProxy(proxyURL, dialer),
+		ForceAttemptHTTP2: target.HTTPVersion == HTTPVersion2,
+	}, nil
+}
+
+// quicProtocolError 判断请求错误是否来自 QUIC 传输层（握手失败、连接被对端以错误码关闭等）
+func quicProtocolError(err error) bool {
+	var transportErr *quic.TransportError
+	var appErr *quic.ApplicationError
+	return errors.As(err, &transportErr) || errors.As(err, &appErr)
+}
+
+// dialQUIC 建立 HTTP/3 使用的 QUIC 连接：按出站网络策略与地址安全校验解析地址，源地址与地址族限制同样生效
+func (d *probeDialer) dialQUIC(ctx context.Context, addr string, tlsConfig *tls.Config, cfg *quic.Config) (quic.EarlyConnection, error) {
+	host, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return nil, fmt.Errorf("无效的端口：%s", portStr)
+	}
+	ip, err := d.resolve(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	network := "udp4"
+	if ip.To4() == nil {
+		network = "udp6"
+	}
+	pconn, err := net.ListenUDP(network, &net.UDPAddr{IP: d.localAddr})
+	if err != nil {
+		return nil, err
+	}
+	conn, err := quic.DialEarly(ctx, pconn, &net.UDPAddr{IP: ip, Port: port}, tlsConfig, cfg)
+	if err != nil {
+		pconn.Close()
+		return nil, err
+	}
+	// 连接关闭后释放 UDP 套接字
+	go func() {
+		<-conn.Context().Done()
+		pconn.Close()
+	}()
+	return conn, nil
+}
+
+// advertisesH3 判断 Alt-Svc 响应头是否声明了 HTTP/3（h3）
+func advertisesH3(altSvc string) bool {
+	for _, entry := range strings.Split(altSvc, ",") {
+		proto, _, _ := strings.Cut(strings.TrimSpace(entry), "=")
+		if proto == "h3" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,231 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/url"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+)
+
+// ICMP 协议号，用于解析回复报文
+const (
+	protocolICMP     = 1
+	protocolIPv6ICMP = 58
+)
+
+// icmpSeq 回显请求序号，进程内递增，避免并发检查之间混淆回复
+var icmpSeq uint32
+
+// checkICMP 检查 ICMP 连通性：发送多个回显请求，统计丢包率与往返时延
+// 优先使用原始套接字（需要 root 或 CAP_NET_RAW），失败时回退为非特权 ICMP 套接字（Linux 需 net.ipv4.ping_group_range 放行）
+func (sc *ServiceChecker) checkICMP(target *MonitorTarget, result *MonitorResult) (error, ErrorType) {
+	u, err := url.Parse(target.URL)
+	if err != nil || u.Hostname() == "" {
+		return errors.New("无效的ICMP地址，格式应为 icmp://host"), ErrorTypeInvalid
+	}
+
+	cfg := sc.cfg.ICMP
+	dialer := sc.newDialer(target, cfg.Timeout)
+	ip, err := dialer.resolve(context.Background(), u.Hostname())
+	if err != nil {
+		var denied *EgressDeniedError
+		if errors.As(err, &denied) {
+			return denied, ErrorTypePolicy
+		}
+		return fmt.Errorf("解析ICMP地址失败：%w", err), ErrorTypeNetwork
+	}
+
+	conn, mode, err := listenICMP(ip, dialer.localAddr)
+	if err != nil {
+		return fmt.Errorf("无法创建ICMP套接字（需要 root / CAP_NET_RAW，或放行 net.ipv4.ping_group_range）：%w", err), ErrorTypeUnknown
+	}
+	defer conn.Close()
+
+	stats := &ICMPDetails{Address: ip.String(), Mode: mode}
+	result.details().ICMP = stats
+
+	count := cfg.Count
+	if count <= 0 {
+		count = 1
+	}
+	var rtts []float64
+	var unreachable error
+	for i := 0; i < count; i++ {
+		if i > 0 && cfg.Interval > 0 {
+			time.Sleep(cfg.Interval)
+		}
+		stats.Sent++
+		rtt, err := pingOnce(conn, ip, mode, cfg.Timeout)
+		if err != nil {
+			var unreach *icmpUnreachableError
+			if errors.As(err, &unreach) {
+				unreachable = err
+			}
+			continue
+		}
+		rtts = append(rtts, rtt)
+	}
+
+	stats.Received = len(rtts)
+	stats.LossPercent = math.Round(float64(stats.Sent-stats.Received)/float64(stats.Sent)*10000) / 100
+	if len(rtts) > 0 {
+		stats.MinMs, stats.MaxMs = rtts[0], rtts[0]
+		var sum float64
+		for _, r := range rtts {
+			sum += r
+			stats.MinMs = math.Min(stats.MinMs, r)
+			stats.MaxMs = math.Max(stats.MaxMs, r)
+		}
+		stats.AvgMs = math.Round(sum/float64(len(rtts))*100) / 100
+	}
+
+	switch {
+	case stats.Received == 0 && unreachable != nil:
+		return unreachable, ErrorTypeICMP
+	case stats.Received == 0:
+		return fmt.Errorf("ICMP请求全部丢失（发送 %d 个）", stats.Sent), ErrorTypeICMP
+	case cfg.LossThreshold > 0 && stats.LossPercent >= cfg.LossThreshold:
+		return fmt.Errorf("ICMP丢包率 %.1f%% 达到失败阈值 %.1f%%", stats.LossPercent, cfg.LossThreshold), ErrorTypeICMP
+	case stats.LossPercent > 0:
+		result.addWarning(fmt.Sprintf("ICMP丢包率 %.1f%%（%d/%d）", stats.LossPercent, stats.Sent-stats.Received, stats.Sent))
+	}
+	return nil, ""
+}
+
+// icmpUnreachableError 收到目标不可达报文
+type icmpUnreachableError struct {
+	From string // 发出不可达报文的地址
+}
+
+func (e *icmpUnreachableError) Error() string {
+	return fmt.Sprintf("ICMP目标不可达（来自 %s）", e.From)
+}
+
+// listenICMP 创建 ICMP 套接字，原始套接字不可用时回退为非特权套接字，返回使用的模式
+// localAddr：源地址，为空时监听全部地址
+func listenICMP(ip, localAddr net.IP) (*icmp.PacketConn, string, error) {
+	rawNetwork, udpNetwork, listenAddr := "ip4:icmp", "udp4", "0.0.0.0"
+	if ip.To4() == nil {
+		rawNetwork, udpNetwork, listenAddr = "ip6:ipv6-icmp", "udp6", "::"
+	}
+	if localAddr != nil {
+		listenAddr = localAddr.String()
+	}
+
+	conn, rawErr := icmp.ListenPacket(rawNetwork, listenAddr)
+	if rawErr == nil {
+		return conn, "raw", nil
+	}
+	conn, err := icmp.ListenPacket(udpNetwork, listenAddr)
+	if err != nil {
+		return nil, "", fmt.Errorf("原始套接字：%v；非特权套接字：%v", rawErr, err)
+	}
+	return conn, "udp", nil
+}
+
+// pingOnce 发送一个回显请求并等待对应的应答，返回往返时延（毫秒）
+func pingOnce(conn *icmp.PacketConn, ip net.IP, mode string, timeout time.Duration) (float64, error) {
+	v4 := ip.To4() != nil
+	var reqType icmp.Type = ipv4.ICMPTypeEcho
+	proto := protocolICMP
+	if !v4 {
+		reqType, proto = ipv6.ICMPTypeEchoRequest, protocolIPv6ICMP
+	}
+
+	// 非特权套接字的标识符由内核分配，只能按序号匹配应答
+	id := os.Getpid() & 0xffff
+	seq := int(atomic.AddUint32(&icmpSeq, 1) & 0xffff)
+	msg := icmp.Message{
+		Type: reqType,
+		Body: &icmp.Echo{ID: id, Seq: seq, Data: []byte("servicetelemetry")},
+	}
+	payload, err := msg.Marshal(nil)
+	if err != nil {
+		return 0, err
+	}
+
+	var dst net.Addr = &net.IPAddr{IP: ip}
+	if mode == "udp" {
+		dst = &net.UDPAddr{IP: ip}
+	}
+	start := time.Now()
+	if _, err := conn.WriteTo(payload, dst); err != nil {
+		return 0, fmt.Errorf("发送ICMP请求失败：%w", err)
+	}
+
+	deadline := start.Add(timeout)
+	conn.SetReadDeadline(deadline)
+	buf := make([]byte, 1500)
+	for {
+		n, peer, err := conn.ReadFrom(buf)
+		if err != nil {
+			return 0, fmt.Errorf("等待ICMP应答超时：%w", err)
+		}
+		reply, err := icmp.ParseMessage(proto, buf[:n])
+		if err != nil {
+			continue
+		}
+
+		switch reply.Type {
+		case ipv4.ICMPTypeEchoReply, ipv6.ICMPTypeEchoReply:
+			echo, ok := reply.Body.(*icmp.Echo)
+			if !ok || echo.Seq != seq || (mode == "raw" && echo.ID != id) || !peerIP(peer).Equal(ip) {
+				continue // 其他检查或其他进程的应答
+			}
+			return elapsedMs(start), nil
+		case ipv4.ICMPTypeDestinationUnreachable, ipv6.ICMPTypeDestinationUnreachable:
+			if unreachableFor(reply, proto, seq) {
+				return 0, &icmpUnreachableError{From: peerIP(peer).String()}
+			}
+		}
+	}
+}
+
+// unreachableFor 判断不可达报文是否针对本次请求（报文中携带了原始请求的 IP 头与 ICMP 头）
+func unreachableFor(reply *icmp.Message, proto, seq int) bool {
+	body, ok := reply.Body.(*icmp.DstUnreach)
+	if !ok {
+		return false
+	}
+	return quotedSeq(body.Data, proto) == seq
+}
+
+// quotedSeq 从差错报文携带的原始请求（IP 头与 ICMP 头）中取出回显序号，无法解析时返回 -1
+func quotedSeq(data []byte, proto int) int {
+	if proto == protocolICMP {
+		if len(data) < ipv4.HeaderLen || len(data) < int(data[0]&0x0f)*4 {
+			return -1
+		}
+		data = data[int(data[0]&0x0f)*4:]
+	} else {
+		if len(data) < ipv6.HeaderLen {
+			return -1
+		}
+		data = data[ipv6.HeaderLen:]
+	}
+	// 原始 ICMP 头：type(1) code(1) checksum(2) id(2) seq(2)
+	if len(data) < 8 {
+		return -1
+	}
+	return int(data[6])<<8 | int(data[7])
+}
+
+// peerIP 提取应答来源的 IP
+func peerIP(addr net.Addr) net.IP {
+	switch a := addr.(type) {
+	case *net.IPAddr:
+		return a.IP
+	case *net.UDPAddr:
+		return a.IP
+	}
+	return nil
+}
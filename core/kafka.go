@@ -0,0 +1,353 @@
+package core
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Kafka 协议请求类型与检查使用的版本
+const (
+	kafkaAPIMetadata    = 3
+	kafkaAPIApiVersions = 18
+	kafkaClientID       = "servicemonitor"
+	// kafkaMaxResponse 响应大小上限，只请求单个 topic 的元数据，正常响应远小于该值
+	kafkaMaxResponse = 4 << 20
+)
+
+// kafkaErrorCodes 常见的 Kafka 错误码说明
+var kafkaErrorCodes = map[int16]string{
+	3:  "topic 或分区不存在",
+	5:  "分区没有可用的 leader",
+	9:  "副本不可用",
+	29: "topic 未授权",
+	31: "集群未授权",
+	35: "不支持的请求版本",
+	58: "SASL 认证失败",
+}
+
+// kafkaQuery Kafka 检查参数
+type kafkaQuery struct {
+	address string // host:port
+	tls     bool   // 是否使用 TLS
+	topic   string // 需要校验分区 leader 的 topic，为空时只校验集群元数据
+}
+
+// parseKafkaURL 解析 kafka://host[:port]?topic=orders&tls=true 形式的地址，默认端口 9092
+func parseKafkaURL(u *url.URL) (*kafkaQuery, error) {
+	if u.Hostname() == "" {
+		return nil, fmt.Errorf("Kafka地址格式应为 kafka://host:port")
+	}
+	if u.User != nil {
+		return nil, fmt.Errorf("Kafka地址中不能包含用户名或密码")
+	}
+	port := u.Port()
+	if port == "" {
+		port = "9092"
+	}
+	if _, err := strconv.ParseUint(port, 10, 16); err != nil {
+		return nil, fmt.Errorf("无效的Kafka端口：%s", port)
+	}
+	q := &kafkaQuery{address: net.JoinHostPort(u.Hostname(), port), topic: u.Query().Get("topic")}
+	switch v := u.Query().Get("tls"); v {
+	case "", "false":
+	case "true":
+		q.tls = true
+	default:
+		return nil, fmt.Errorf("无效的 tls：%s，可选 true / false", v)
+	}
+	if len(q.topic) > 249 || strings.ContainsAny(q.topic, " /,") {
+		return nil, fmt.Errorf("无效的 topic 名称：%s", q.topic)
+	}
+	return q, nil
+}
+
+// checkKafka 检查Kafka broker：先发送 ApiVersions 确认 broker 能处理请求，再发送 Metadata 读取集群的 broker 与控制器
+// 指定了 topic 时同时校验该 topic 各分区都有 leader；TCP 能连通但 broker 卡死、脱离集群或没有控制器时都会失败
+func (sc *ServiceChecker) checkKafka(target *MonitorTarget, result *MonitorResult) (error, ErrorType) {
+	u, err := url.Parse(target.URL)
+	if err != nil {
+		return fmt.Errorf("解析Kafka地址失败：%w", err), ErrorTypeInvalid
+	}
+	q, err := parseKafkaURL(u)
+	if err != nil {
+		return err, ErrorTypeInvalid
+	}
+
+	timeout := sc.cfg.KafkaTimeout
+	dialer := sc.newDialer(target, timeout)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	conn, err := dialer.DialContext(ctx, "tcp", q.address)
+	result.recordDialAttempts(dialer.Attempts())
+	if err != nil {
+		var denied *EgressDeniedError
+		if errors.As(err, &denied) {
+			return denied, ErrorTypePolicy
+		}
+		if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+			return fmt.Errorf("Kafka连接超时：%w", err), ErrorTypeTimeout
+		}
+		return fmt.Errorf("Kafka连接失败：%w", err), ErrorTypeNetwork
+	}
+	defer func() { conn.Close() }()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	details := &KafkaDetails{Address: q.address, TLS: q.tls, Topic: q.topic, BrokerID: -1, ControllerID: -1}
+	result.details().Kafka = details
+
+	if q.tls {
+		tlsConfig, profile, err := sc.tlsConfig(target)
+		if err != nil {
+			return err, ErrorTypeInvalid
+		}
+		if tlsConfig.ServerName == "" {
+			tlsConfig.ServerName = u.Hostname()
+		}
+		tc := tls.Client(conn, tlsConfig)
+		if err := tc.HandshakeContext(ctx); err != nil {
+			if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+				return fmt.Errorf("TLS握手超时"), ErrorTypeTimeout
+			}
+			return fmt.Errorf("TLS握手失败：%w", err), ErrorTypeSSL
+		}
+		state := tc.ConnectionState()
+		sc.recordTLS(target, profile, &state, result)
+		conn = tc
+	}
+
+	// ApiVersions v0：无请求体，所有版本的 broker 都支持，同时用于选择 Metadata 请求版本
+	start := time.Now()
+	resp, err := kafkaRoundTrip(conn, kafkaAPIApiVersions, 0, 1, nil)
+	if err != nil {
+		return kafkaError("ApiVersions请求失败", err)
+	}
+	details.APIVersionsMs = float64(time.Since(start).Microseconds()) / 1000
+	if code := resp.int16(); code != 0 {
+		return fmt.Errorf("ApiVersions返回错误：%s", kafkaErrorText(code)), ErrorTypeKafka
+	}
+	metadataVersion := int16(-1)
+	for n := resp.arrayLen(); n > 0 && resp.err == nil; n-- {
+		key, min, max := resp.int16(), resp.int16(), resp.int16()
+		details.APIs++
+		if key != kafkaAPIMetadata {
+			continue
+		}
+		// 优先使用 v4（可关闭自动创建 topic），老版本 broker 使用 v1
+		if min <= 4 && max >= 4 {
+			metadataVersion = 4
+		} else if min <= 1 && max >= 1 {
+			metadataVersion = 1
+		}
+	}
+	if resp.err != nil {
+		return fmt.Errorf("ApiVersions响应格式错误：%w", resp.err), ErrorTypeKafka
+	}
+	if metadataVersion < 0 {
+		return errors.New("broker 不支持本检查使用的 Metadata 请求版本（v1 / v4）"), ErrorTypeKafka
+	}
+	details.MetadataVersion = int(metadataVersion)
+
+	// Metadata：只请求指定的 topic（未指定时为空列表，只返回 broker 与控制器），v4 关闭自动创建 topic
+	var body []byte
+	if q.topic == "" {
+		body = binary.BigEndian.AppendUint32(body, 0)
+	} else {
+		body = binary.BigEndian.AppendUint32(body, 1)
+		body = kafkaString(body, q.topic)
+	}
+	if metadataVersion >= 4 {
+		body = append(body, 0)
+	}
+	start = time.Now()
+	resp, err = kafkaRoundTrip(conn, kafkaAPIMetadata, metadataVersion, 2, body)
+	if err != nil {
+		return kafkaError("Metadata请求失败", err)
+	}
+	details.MetadataMs = float64(time.Since(start).Microseconds()) / 1000
+	if metadataVersion >= 3 {
+		resp.int32() // throttle_time_ms
+	}
+	host, port, _ := net.SplitHostPort(q.address)
+	for n := resp.arrayLen(); n > 0 && resp.err == nil; n-- {
+		id, brokerHost, brokerPort := resp.int32(), resp.string(), resp.int32()
+		resp.string() // rack
+		details.Brokers++
+		if strings.EqualFold(brokerHost, host) && strconv.Itoa(int(brokerPort)) == port {
+			details.BrokerID = int(id)
+		}
+	}
+	if metadataVersion >= 2 {
+		details.ClusterID = resp.string()
+	}
+	details.ControllerID = int(resp.int32())
+	var topicErr int16
+	for n := resp.arrayLen(); n > 0 && resp.err == nil; n-- {
+		topicErr = resp.int16()
+		resp.string() // name
+		resp.bool()   // is_internal
+		for p := resp.arrayLen(); p > 0 && resp.err == nil; p-- {
+			resp.int16() // partition error_code
+			resp.int32() // partition_index
+			details.Partitions++
+			if resp.int32() >= 0 {
+				details.Leaders++
+			}
+			resp.int32Array() // replicas
+			resp.int32Array() // isr
+		}
+	}
+	if resp.err != nil {
+		return fmt.Errorf("Metadata响应格式错误：%w", resp.err), ErrorTypeKafka
+	}
+
+	switch {
+	case details.Brokers == 0:
+		return errors.New("Metadata未返回任何 broker"), ErrorTypeKafka
+	case details.ControllerID < 0:
+		return errors.New("集群当前没有可用的控制器"), ErrorTypeKafka
+	case topicErr != 0:
+		return fmt.Errorf("topic %s 元数据异常：%s", q.topic, kafkaErrorText(topicErr)), ErrorTypeKafka
+	case details.Leaders < details.Partitions:
+		return fmt.Errorf("topic %s 有 %d 个分区没有 leader（共 %d 个分区）", q.topic, details.Partitions-details.Leaders, details.Partitions), ErrorTypeKafka
+	}
+	if details.BrokerID < 0 {
+		result.addWarning(fmt.Sprintf("集群元数据中没有与 %s 对应的 broker，请确认 advertised.listeners 配置", q.address))
+	}
+	return nil, ""
+}
+
+// kafkaRoundTrip 发送一个请求并读取对应的响应，校验关联ID，返回指向响应体的读取器
+// 请求头：api_key、api_version、correlation_id、client_id（非 flexible 版本的请求头 v1）
+func kafkaRoundTrip(conn net.Conn, apiKey, version int16, correlationID int32, body []byte) (*kafkaReader, error) {
+	req := make([]byte, 4, 64+len(body))
+	req = binary.BigEndian.AppendUint16(req, uint16(apiKey))
+	req = binary.BigEndian.AppendUint16(req, uint16(version))
+	req = binary.BigEndian.AppendUint32(req, uint32(correlationID))
+	req = kafkaString(req, kafkaClientID)
+	req = append(req, body...)
+	binary.BigEndian.PutUint32(req, uint32(len(req)-4))
+	if _, err := conn.Write(req); err != nil {
+		return nil, err
+	}
+
+	var size [4]byte
+	if _, err := io.ReadFull(conn, size[:]); err != nil {
+		return nil, err
+	}
+	n := binary.BigEndian.Uint32(size[:])
+	if n < 4 || n > kafkaMaxResponse {
+		return nil, errKafkaMalformed
+	}
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		return nil, err
+	}
+	r := &kafkaReader{b: buf}
+	if r.int32() != correlationID {
+		return nil, errKafkaMalformed
+	}
+	return r, nil
+}
+
+// errKafkaMalformed 响应不是合法的 Kafka 协议响应（如端口上运行的是其他服务）
+var errKafkaMalformed = errors.New("响应不是合法的Kafka协议响应")
+
+// kafkaString 追加 Kafka 字符串：2 字节长度 + 内容
+func kafkaString(b []byte, s string) []byte {
+	b = binary.BigEndian.AppendUint16(b, uint16(len(s)))
+	return append(b, s...)
+}
+
+// kafkaReader 按 Kafka 协议的基本类型顺序读取响应，越界后记录错误并返回零值
+type kafkaReader struct {
+	b   []byte
+	err error
+}
+
+func (r *kafkaReader) take(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n < 0 || n > len(r.b) {
+		r.err = errKafkaMalformed
+		return nil
+	}
+	v := r.b[:n]
+	r.b = r.b[n:]
+	return v
+}
+
+func (r *kafkaReader) int16() int16 {
+	if v := r.take(2); v != nil {
+		return int16(binary.BigEndian.Uint16(v))
+	}
+	return 0
+}
+
+func (r *kafkaReader) int32() int32 {
+	if v := r.take(4); v != nil {
+		return int32(binary.BigEndian.Uint32(v))
+	}
+	return 0
+}
+
+func (r *kafkaReader) bool() bool {
+	v := r.take(1)
+	return v != nil && v[0] != 0
+}
+
+// string 读取字符串，长度为 -1（null）时返回空字符串
+func (r *kafkaReader) string() string {
+	n := r.int16()
+	if n < 0 {
+		return ""
+	}
+	return string(r.take(int(n)))
+}
+
+// arrayLen 读取数组长度，null 数组按空数组处理
+func (r *kafkaReader) arrayLen() int {
+	n := int(r.int32())
+	if n > len(r.b) {
+		r.err = errKafkaMalformed
+		return 0
+	}
+	return n
+}
+
+func (r *kafkaReader) int32Array() {
+	r.take(r.arrayLen() * 4)
+}
+
+// kafkaErrorText 返回错误码说明
+func kafkaErrorText(code int16) string {
+	if text, ok := kafkaErrorCodes[code]; ok {
+		return fmt.Sprintf("%d %s", code, text)
+	}
+	return fmt.Sprintf("错误码 %d", code)
+}
+
+// kafkaError 区分超时、连接被关闭、响应格式错误与其他网络错误
+func kafkaError(action string, err error) (error, ErrorType) {
+	if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+		return fmt.Errorf("%s：等待响应超时", action), ErrorTypeTimeout
+	}
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return fmt.Errorf("%s：连接被broker关闭（监听器可能要求 SASL 认证或 TLS）", action), ErrorTypeKafka
+	}
+	if errors.Is(err, errKafkaMalformed) {
+		return fmt.Errorf("%s：%w", action, err), ErrorTypeKafka
+	}
+	return fmt.Errorf("%s：%w", action, err), ErrorTypeNetwork
+}
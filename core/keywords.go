@@ -0,0 +1,84 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+)
+
+// 关键词匹配方式
+const (
+	KeywordAll  = "all"  // 必须出现
+	KeywordAny  = "any"  // 同组至少出现一个
+	KeywordNone = "none" // 不得出现
+)
+
+// KeywordCheck 单个关键词的匹配结果
+type KeywordCheck struct {
+	Keyword string `json:"keyword"` // 关键词
+	Mode    string `json:"mode"`    // 匹配方式：all / any / none
+	Found   bool   `json:"found"`   // 响应体中是否出现
+	Passed  bool   `json:"passed"`  // 是否满足条件（any 组中任意一个出现则整组通过）
+}
+
+// hasKeywords 判断目标是否配置了关键词匹配（keyword 或 keywords）
+func (t *MonitorTarget) hasKeywords() bool {
+	return t.Keyword != "" || !t.Keywords.Empty()
+}
+
+// matchKeywords 按 keyword 与 keywords 选项匹配响应体，返回各关键词的匹配结果；未通过时返回错误，列出未满足的条件
+// 只配置了单个 keyword 时沿用原有的错误信息
+func (t *MonitorTarget) matchKeywords(body string) ([]KeywordCheck, error) {
+	var checks []KeywordCheck
+	var missing, forbidden []string
+
+	all := t.Keywords.AllKeywords(t.Keyword)
+	for _, kw := range all {
+		found := strings.Contains(body, kw)
+		checks = append(checks, KeywordCheck{Keyword: kw, Mode: KeywordAll, Found: found, Passed: found})
+		if !found {
+			missing = append(missing, kw)
+		}
+	}
+
+	anyPassed := true
+	if t.Keywords != nil && len(t.Keywords.Any) > 0 {
+		start := len(checks)
+		anyPassed = false
+		for _, kw := range t.Keywords.Any {
+			found := strings.Contains(body, kw)
+			anyPassed = anyPassed || found
+			checks = append(checks, KeywordCheck{Keyword: kw, Mode: KeywordAny, Found: found})
+		}
+		for i := start; i < len(checks); i++ {
+			checks[i].Passed = anyPassed
+		}
+	}
+
+	if t.Keywords != nil {
+		for _, kw := range t.Keywords.None {
+			found := strings.Contains(body, kw)
+			checks = append(checks, KeywordCheck{Keyword: kw, Mode: KeywordNone, Found: found, Passed: !found})
+			if found {
+				forbidden = append(forbidden, kw)
+			}
+		}
+	}
+
+	if len(missing) == 0 && anyPassed && len(forbidden) == 0 {
+		return checks, nil
+	}
+	if t.Keywords.Empty() {
+		return checks, fmt.Errorf("响应体未找到关键词：%s", t.Keyword)
+	}
+	var reasons []string
+	if len(missing) > 0 {
+		reasons = append(reasons, "缺少 "+strings.Join(missing, "、"))
+	}
+	if !anyPassed {
+		reasons = append(reasons, "未包含任一 "+strings.Join(t.Keywords.Any, " / "))
+	}
+	if len(forbidden) > 0 {
+		reasons = append(reasons, "包含禁止的关键词 "+strings.Join(forbidden, "、"))
+	}
+	return checks, fmt.Errorf("关键词匹配未通过：%s", strings.Join(reasons, "；"))
+}
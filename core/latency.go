@@ -0,0 +1,40 @@
+package core
+
+import "fmt"
+
+// 检查状态
+const (
+	StatusSuccess  = "success"  // 检查成功
+	StatusFailed   = "failed"   // 检查失败
+	StatusDegraded = "degraded" // 检查成功但响应耗时超过目标的 maxResponseTimeMs（降级）
+)
+
+// applyLatencyThreshold 检查成功但响应耗时超过目标阈值时，按 slowStatus 将结果记为降级（默认）或失败，错误类型为 slow
+// 降级不计入失败：不触发告警与处置，可用率照常计为可用，但会记录 up → degraded 的状态变化
+func (t *MonitorTarget) applyLatencyThreshold(result *MonitorResult) {
+	if t.MaxResponseTimeMs <= 0 || result.Status != StatusSuccess || result.ResponseTime <= t.MaxResponseTimeMs {
+		return
+	}
+	result.Status = StatusDegraded
+	if t.SlowStatus == StatusFailed {
+		result.Status = StatusFailed
+	}
+	result.ErrorType = string(ErrorTypeSlow)
+	result.ErrorMsg = fmt.Sprintf("响应耗时 %.0fms 超过阈值 %.0fms", result.ResponseTime, t.MaxResponseTimeMs)
+}
+
+// validateLatencyThreshold 校验响应耗时阈值与超过阈值时的检查状态
+func validateLatencyThreshold(target *MonitorTarget) error {
+	if target.MaxResponseTimeMs < 0 {
+		return fmt.Errorf("maxResponseTimeMs 不能为负数")
+	}
+	switch target.SlowStatus {
+	case "", StatusDegraded, StatusFailed:
+	default:
+		return fmt.Errorf("无效的 slowStatus：%s，仅支持 degraded / failed", target.SlowStatus)
+	}
+	if target.SlowStatus != "" && target.MaxResponseTimeMs == 0 {
+		return fmt.Errorf("slowStatus 需要同时配置 maxResponseTimeMs")
+	}
+	return nil
+}
@@ -1,29 +1,449 @@
 package core
 
 import (
+	"fmt"
+	"net"
+	"strings"
 	"time"
+
+	"servicetelemetry/config"
 )
 
 // MonitorTarget 监控目标结构体
 type MonitorTarget struct {
-	URL       string `json:"url"`       // 目标服务地址
-	Keyword   string `json:"keyword"`   // 响应体匹配关键词
-	IsCurrent bool   `json:"isCurrent"` // 是否为当前有效监控目标
-	Priority  string `json:"priority"`  // 新增：任务优先级（low/normal/high）
+	ID         int64    `json:"id,omitempty"`     // 目标ID（数据库自增主键，仅从数据库读取的目标有值）
+	URL        string   `json:"url"`              // 目标服务地址
+	Keyword    string   `json:"keyword"`          // 响应体匹配关键词
+	IsCurrent  bool     `json:"isCurrent"`        // 是否为当前有效监控目标
+	Priority   string   `json:"priority"`         // 新增：任务优先级（low/normal/high）
+	Tags       []string `json:"tags"`             // 目标标签，用于分组聚合与统计
+	Assertions []string `json:"assertions"`       // 响应断言表达式，如 status == 200
+	Source     string   `json:"source,omitempty"` // 目标来源：spec / api / mq，为空表示命令行等本机创建的目标
+	config.TargetOptions
+}
+
+// 目标来源
+const (
+	TargetSourceSpec = "spec" // 声明式目标定义文件
+	TargetSourceAPI  = "api"  // 接口提交
+	TargetSourceMQ   = "mq"   // 消息队列注册
+)
+
+// External 是否为外部提交（接口或消息队列）的目标，这类目标在连接时同样执行地址安全校验
+func (t *MonitorTarget) External() bool {
+	return t.Source == TargetSourceAPI || t.Source == TargetSourceMQ
+}
+
+// serverName 返回 TLS 握手使用的 SNI：优先使用 sni，其次使用 hostHeader 中的主机名，均为空时由地址决定
+func (t *MonitorTarget) serverName() string {
+	if t.SNI != "" {
+		return t.SNI
+	}
+	if t.HostHeader == "" {
+		return ""
+	}
+	if host, _, err := net.SplitHostPort(t.HostHeader); err == nil {
+		return host
+	}
+	return t.HostHeader
+}
+
+// credential 解析目标配置的凭据引用，未配置时返回空字符串
+func (t *MonitorTarget) credential(name string) (string, error) {
+	ref, ok := t.Credentials[name]
+	if !ok {
+		return "", nil
+	}
+	value, err := config.ResolveSecret(ref)
+	if err != nil {
+		return "", fmt.Errorf("解析凭据 %s 失败：%w", name, err)
+	}
+	return value, nil
+}
+
+// AllowedIn 判断目标能否由指定区域的探测实例检查：未限定区域的目标任意实例均可检查，
+// 限定了区域的目标只能由对应区域的实例检查（未配置区域的实例不检查限定区域的目标）
+func (t *MonitorTarget) AllowedIn(region string) bool {
+	if len(t.Regions) == 0 {
+		return true
+	}
+	for _, r := range t.Regions {
+		if strings.EqualFold(r, region) {
+			return true
+		}
+	}
+	return false
+}
+
+// TargetFromDefinition 将声明式目标定义转换为监控目标，地址按 NormalizeTargetURL 规范化
+func TargetFromDefinition(def config.TargetDefinition) *MonitorTarget {
+	return &MonitorTarget{
+		URL:           CanonicalTargetURL(def.URL),
+		Keyword:       def.Keyword,
+		IsCurrent:     true,
+		Priority:      def.Priority,
+		Tags:          def.Tags,
+		Assertions:    def.Assertions,
+		TargetOptions: def.TargetOptions,
+	}
+}
+
+// 目标状态（状态变化记录使用），由检查状态映射而来
+const (
+	StateUp       = "up"       // 正常
+	StateDown     = "down"     // 故障
+	StateDegraded = "degraded" // 降级
+)
+
+// ResultState 将检查状态（success / failed / degraded）映射为目标状态
+func ResultState(status string) string {
+	switch status {
+	case "failed":
+		return StateDown
+	case "degraded":
+		return StateDegraded
+	default:
+		return StateUp
+	}
 }
 
 // MonitorResult 监控结果结构体（增强版）
 type MonitorResult struct {
-	ID             uint64    `json:"id"`             // 结果唯一标识
-	TargetURL      string    `json:"targetUrl"`      // 对应监控目标的地址
-	Status         string    `json:"status"`         // 检查状态
-	StatusCode     int       `json:"statusCode"`     // HTTP状态码
-	ResponseTime   float64   `json:"responseTime"`   // 响应耗时（毫秒）
-	SSLCertExpiry  string    `json:"sslCertExpiry"`  // SSL证书过期信息
-	KeywordMatched bool      `json:"keywordMatched"` // 关键词匹配结果
-	ErrorMsg       string    `json:"errorMsg"`       // 错误信息
-	ErrorType      string    `json:"errorType"`      // 新增：错误类型
-	Warning        string    `json:"warning"`        // 新增：警告信息
-	CheckedAt      time.Time `json:"checkedAt"`      // 检查完成时间
-	CreatedAt      time.Time `json:"createdAt"`      // 结果入库时间
+	ID             uint64            `json:"id"`                    // 结果唯一标识
+	TargetURL      string            `json:"targetUrl"`             // 对应监控目标的地址
+	Status         string            `json:"status"`                // 检查状态
+	StatusCode     int               `json:"statusCode"`            // HTTP状态码
+	ResponseTime   float64           `json:"responseTime"`          // 响应耗时（毫秒）
+	SSLCertExpiry  string            `json:"sslCertExpiry"`         // SSL证书过期信息（展示文本，由 sslDaysLeft 生成）
+	SSLDaysLeft    *int              `json:"sslDaysLeft,omitempty"` // 证书剩余天数，已过期为负数，非 TLS 检查为空
+	SSLNotAfter    *time.Time        `json:"sslNotAfter,omitempty"` // 证书 notAfter（UTC）
+	KeywordMatched bool              `json:"keywordMatched"`        // 关键词匹配结果
+	ErrorMsg       string            `json:"errorMsg"`              // 错误信息
+	ErrorType      string            `json:"errorType"`             // 新增：错误类型
+	Warning        string            `json:"warning"`               // 新增：警告信息
+	Tags           []string          `json:"tags,omitempty"`        // 目标标签（来自监控目标，不入库）
+	Timings        *PhaseTimings     `json:"timings,omitempty"`     // HTTP 请求各阶段耗时（以 JSON 入库），非 HTTP 检查为空
+	Details        *ResultDetails    `json:"details,omitempty"`     // 检查过程诊断信息（以 JSON 入库）
+	Snapshot       *ResponseSnapshot `json:"snapshot,omitempty"`    // HTTP 检查失败时的响应快照（以 JSON 单独入库，超过保留时长后清除）
+	CheckedAt      time.Time         `json:"checkedAt"`             // 检查完成时间
+	CreatedAt      time.Time         `json:"createdAt"`             // 结果入库时间
+}
+
+// ResultDetails 检查过程诊断信息，用于排查间歇性故障
+type ResultDetails struct {
+	DialAttempts []DialAttempt        `json:"dialAttempts,omitempty"` // 各次拨号尝试（多个 A/AAAA 记录时按尝试顺序排列）
+	Heartbeat    *HeartbeatDetails    `json:"heartbeat,omitempty"`    // 心跳检查结果
+	Families     []FamilyResult       `json:"families,omitempty"`     // 双栈检查（addressFamily 为 both）中各地址族的结果
+	Resolver     string               `json:"resolver,omitempty"`     // 目标指定的解析服务器（ip:port），使用系统解析时为空
+	TLS          *TLSDetails          `json:"tls,omitempty"`          // TLS 握手信息
+	Checksum     *ChecksumDetails     `json:"checksum,omitempty"`     // 文件 SHA-256 摘要校验结果
+	HTTP         *HTTPDetails         `json:"http,omitempty"`         // HTTP 请求方法、响应类型与响应体读取情况
+	Hedge        *HedgeDetails        `json:"hedge,omitempty"`        // 对冲请求结果（配置了 hedge 的 HTTP/HTTPS 目标）
+	ICMP         *ICMPDetails         `json:"icmp,omitempty"`         // ICMP 回显统计（丢包率与往返时延）
+	DNS          *DNSDetails          `json:"dns,omitempty"`          // DNS 查询结果
+	UDP          *UDPDetails          `json:"udp,omitempty"`          // UDP 收发结果
+	GRPC         *GRPCDetails         `json:"grpc,omitempty"`         // gRPC 健康检查结果
+	SMTP         *SMTPDetails         `json:"smtp,omitempty"`         // SMTP 会话结果
+	SSH          *SSHDetails          `json:"ssh,omitempty"`          // SSH 版本标识与主机公钥
+	Redis        *RedisDetails        `json:"redis,omitempty"`        // Redis PING 结果
+	Database     *DatabaseDetails     `json:"database,omitempty"`     // 数据库连接与 SELECT 1 结果
+	NTP          *NTPDetails          `json:"ntp,omitempty"`          // NTP 层级与时钟偏差
+	Kafka        *KafkaDetails        `json:"kafka,omitempty"`        // Kafka 集群元数据
+	Keywords     []KeywordCheck       `json:"keywords,omitempty"`     // 各关键词的匹配结果
+	MQTT         *MQTTDetails         `json:"mqtt,omitempty"`         // MQTT CONNECT / CONNACK 握手结果
+	FileTransfer *FileTransferDetails `json:"fileTransfer,omitempty"` // FTP / SFTP 登录与列目录结果
+	Comparison   *ComparisonDetails   `json:"comparison,omitempty"`   // 与比对地址的响应一致性比对结果
+	Domain       *DomainDetails       `json:"domain,omitempty"`       // 域名注册到期信息（开启 domainExpiry 的目标）
+	Traceroute   *TracerouteDetails   `json:"traceroute,omitempty"`   // 网络错误或超时失败后自动采集的路由跟踪
+	WarningTypes []WarningType        `json:"warningTypes,omitempty"` // 带类型的警告（警告文本仍记录在 warning 中）
+	Region       string               `json:"region,omitempty"`       // 执行检查的探测区域（monitor.region）
+	Proxy        string               `json:"proxy,omitempty"`        // HTTP 检查经由的代理（密码已脱敏）
+}
+
+// WarningType 警告类型，用于区分需要单独关注的警告
+type WarningType string
+
+const (
+	WarningTypeDivergence   WarningType = "divergence"    // 与比对地址的响应不一致
+	WarningTypeDomainExpiry WarningType = "domain_expiry" // 域名注册即将到期或已过期
+)
+
+// ComparisonDetails 响应一致性比对结果
+type ComparisonDetails struct {
+	URL         string       `json:"url"`                   // 比对地址
+	StatusCode  int          `json:"statusCode"`            // 比对地址的 HTTP 状态码
+	Matched     bool         `json:"matched"`               // 是否一致
+	Total       int          `json:"total"`                 // 差异总数
+	Divergences []Divergence `json:"divergences,omitempty"` // 差异明细（最多记录 10 处）
+	Error       string       `json:"error,omitempty"`       // 比对地址请求失败原因
+}
+
+// Divergence 一处响应差异
+type Divergence struct {
+	Field     string `json:"field"`     // 差异字段：status / body / JSON 字段路径
+	Primary   string `json:"primary"`   // 目标响应中的值
+	Secondary string `json:"secondary"` // 比对地址响应中的值
+}
+
+// DNSDetails DNS 查询结果
+type DNSDetails struct {
+	Resolver      string      `json:"resolver"`      // 使用的解析服务器
+	Type          string      `json:"type"`          // 查询的记录类型
+	Transport     string      `json:"transport"`     // 传输协议：udp / tcp（UDP 应答被截断时改用 TCP）
+	RCode         string      `json:"rcode"`         // 应答码，如 NOERROR / NXDOMAIN / SERVFAIL
+	Authoritative bool        `json:"authoritative"` // 是否为权威应答
+	LatencyMs     float64     `json:"latencyMs"`     // 解析耗时（毫秒）
+	Answers       []DNSAnswer `json:"answers"`       // 应答记录（含 CNAME 链）
+}
+
+// DNSAnswer 一条 DNS 应答记录
+type DNSAnswer struct {
+	Name  string `json:"name"`  // 记录名称
+	Type  string `json:"type"`  // 记录类型
+	TTL   uint32 `json:"ttl"`   // 剩余 TTL（秒）
+	Value string `json:"value"` // 记录值
+}
+
+// UDPDetails UDP 收发结果
+type UDPDetails struct {
+	Address       string `json:"address"`            // 实际发送的目标地址（ip:port）
+	BytesSent     int    `json:"bytesSent"`          // 发送的字节数
+	BytesReceived int    `json:"bytesReceived"`      // 收到的字节数
+	Outcome       string `json:"outcome"`            // 结论：response（收到响应）/ refused（端口不可达）/ no_response（未响应）
+	Response      string `json:"response,omitempty"` // 响应内容预览（二进制内容以 hex: 开头的十六进制表示）
+}
+
+// GRPCDetails gRPC 健康检查结果
+type GRPCDetails struct {
+	Service       string `json:"service"`                 // 被检查的服务名（为空表示整个服务端）
+	TLS           bool   `json:"tls"`                     // 是否使用 TLS（grpcs://）
+	Code          int    `json:"code"`                    // gRPC 状态码（grpc-status），0 为 OK
+	Message       string `json:"message,omitempty"`       // gRPC 错误信息（grpc-message）
+	ServingStatus string `json:"servingStatus,omitempty"` // 服务状态：SERVING / NOT_SERVING / UNKNOWN / SERVICE_UNKNOWN
+}
+
+// SMTPDetails SMTP 会话结果
+type SMTPDetails struct {
+	Address    string   `json:"address"`              // 连接的地址（host:port）
+	Banner     string   `json:"banner"`               // 服务端问候（220 应答的第一行）
+	GreetingMs float64  `json:"greetingMs"`           // 连接建立（smtps:// 为 TLS 握手完成）到收到问候的耗时（毫秒）
+	Extensions []string `json:"extensions,omitempty"` // EHLO 通告的扩展（STARTTLS 后为升级后重新 EHLO 的结果）
+	StartTLS   string   `json:"startTLS"`             // STARTTLS 策略：auto / require / off
+	TLS        bool     `json:"tls"`                  // 会话是否已加密（STARTTLS 或 smtps://）
+}
+
+// SSHDetails SSH 检查结果
+type SSHDetails struct {
+	Address            string  `json:"address"`                      // 连接的地址（host:port）
+	Banner             string  `json:"banner"`                       // 服务端版本标识，如 SSH-2.0-OpenSSH_9.6
+	BannerMs           float64 `json:"bannerMs"`                     // 连接建立到收到版本标识的耗时（毫秒）
+	HostKeyType        string  `json:"hostKeyType,omitempty"`        // 密钥交换协商的主机公钥类型（仅固定指纹时）
+	Fingerprint        string  `json:"fingerprint,omitempty"`        // 主机公钥指纹（SHA256:...，仅固定指纹时）
+	FingerprintMatched bool    `json:"fingerprintMatched,omitempty"` // 指纹是否与固定值匹配
+}
+
+// RedisDetails Redis 检查结果
+type RedisDetails struct {
+	Address string  `json:"address"` // 连接的地址（host:port）
+	Auth    bool    `json:"auth"`    // 是否发送了 AUTH
+	PingMs  float64 `json:"pingMs"`  // 发送 PING 到收到应答的耗时（毫秒）
+	Reply   string  `json:"reply"`   // PING 的应答（正常为 PONG）
+}
+
+// DatabaseDetails 数据库检查结果
+type DatabaseDetails struct {
+	Engine        string  `json:"engine"`                  // 数据库类型：mysql / postgres
+	Address       string  `json:"address"`                 // 连接的地址（host:port）
+	Database      string  `json:"database,omitempty"`      // 数据库名
+	User          string  `json:"user"`                    // 连接使用的用户名
+	TLS           bool    `json:"tls"`                     // 连接是否加密
+	ServerVersion string  `json:"serverVersion,omitempty"` // 服务端版本（仅 PostgreSQL）
+	ConnectMs     float64 `json:"connectMs"`               // 建立连接与认证的耗时（毫秒）
+	QueryMs       float64 `json:"queryMs"`                 // SELECT 1 的耗时（毫秒）
+}
+
+// HTTPDetails HTTP 检查的请求与响应概况
+type HTTPDetails struct {
+	Method        string `json:"method"`                // 请求方法（GET / HEAD）
+	Protocol      string `json:"protocol,omitempty"`    // 协商的 HTTP 协议（HTTP/1.1 / HTTP/2.0 / HTTP/3.0）
+	ContentType   string `json:"contentType,omitempty"` // 响应头 Content-Type
+	ContentLength int64  `json:"contentLength"`         // 响应头 Content-Length，未声明时为 -1
+	BodyRead      bool   `json:"bodyRead"`              // 是否读取了响应体（关键词、body 断言或响应比对需要时才读取）
+	BodyBytes     int    `json:"bodyBytes,omitempty"`   // 读取的响应体字节数（不超过 monitor.maxBodySize）
+	// 重定向链：每一跳的请求地址、状态码与 Location，没有重定向时为空
+	Redirects []RedirectHop `json:"redirects,omitempty"`
+	FinalURL  string        `json:"finalUrl,omitempty"` // 跟随重定向后最终请求的地址，没有重定向时为空
+	AltSvc    string        `json:"altSvc,omitempty"`   // 响应头 Alt-Svc，仅强制 HTTP/3（httpVersion: 3）时记录
+}
+
+// HeartbeatDetails 心跳检查结果
+type HeartbeatDetails struct {
+	LastPingAt     *time.Time `json:"lastPingAt,omitempty"`     // 最近一次收到心跳的时间，从未收到时为空
+	Pings          int64      `json:"pings"`                    // 累计收到的心跳次数
+	Source         string     `json:"source,omitempty"`         // 最近一次心跳的来源地址
+	Deadline       time.Time  `json:"deadline"`                 // 下一次心跳的截止时间
+	OverdueSeconds int64      `json:"overdueSeconds,omitempty"` // 已超过截止时间的秒数
+	// 以下为任务上报开始信号时记录的运行信息
+	Running        int        `json:"running,omitempty"`        // 进行中的运行数，大于 1 表示运行重叠
+	RunStartedAt   *time.Time `json:"runStartedAt,omitempty"`   // 进行中最早一次运行的开始时间
+	RunningSeconds int64      `json:"runningSeconds,omitempty"` // 进行中的运行已持续的秒数
+	LastRunSeconds float64    `json:"lastRunSeconds,omitempty"` // 最近一次完成的运行耗时（秒）
+	LastRunEndAt   *time.Time `json:"lastRunEndAt,omitempty"`   // 最近一次完成的运行结束时间
+	Overlaps       int64      `json:"overlaps,omitempty"`       // 累计重叠次数
+	LastOverlapAt  *time.Time `json:"lastOverlapAt,omitempty"`  // 最近一次重叠的时间
+	AbandonedRuns  int64      `json:"abandonedRuns,omitempty"`  // 累计放弃的运行数（超过运行时限未上报完成）
+}
+
+// NTPDetails NTP 检查结果
+type NTPDetails struct {
+	Address          string  `json:"address"`               // 查询的服务器地址
+	Stratum          int     `json:"stratum"`               // 服务器层级（1 为直连参考时钟，16 表示未同步）
+	ReferenceID      string  `json:"referenceId,omitempty"` // 参考标识：层级 1 为参考时钟类型（如 GPS），其余为上游服务器地址
+	OffsetMs         float64 `json:"offsetMs"`              // 服务器时钟相对本机的偏差（毫秒），正数表示服务器时钟超前
+	DelayMs          float64 `json:"delayMs"`               // 往返时延（毫秒）
+	RootDelayMs      float64 `json:"rootDelayMs"`           // 服务器到参考时钟的往返时延（毫秒）
+	RootDispersionMs float64 `json:"rootDispersionMs"`      // 服务器相对参考时钟的误差上限（毫秒）
+	MaxOffsetMs      float64 `json:"maxOffsetMs"`           // 判定使用的偏差阈值（毫秒）
+}
+
+// KafkaDetails Kafka 检查结果
+type KafkaDetails struct {
+	Address         string  `json:"address"`             // 连接的地址（host:port）
+	TLS             bool    `json:"tls"`                 // 是否使用 TLS
+	APIs            int     `json:"apis"`                // broker 支持的请求类型数（ApiVersions）
+	MetadataVersion int     `json:"metadataVersion"`     // 使用的 Metadata 请求版本
+	ClusterID       string  `json:"clusterId,omitempty"` // 集群ID
+	ControllerID    int     `json:"controllerId"`        // 控制器的 broker ID，-1 表示没有控制器
+	BrokerID        int     `json:"brokerId"`            // 所连接 broker 的 ID（按 advertised 地址匹配），未匹配时为 -1
+	Brokers         int     `json:"brokers"`             // 集群中的 broker 数
+	Topic           string  `json:"topic,omitempty"`     // 校验的 topic
+	Partitions      int     `json:"partitions"`          // topic 的分区数
+	Leaders         int     `json:"leaders"`             // 有 leader 的分区数
+	APIVersionsMs   float64 `json:"apiVersionsMs"`       // ApiVersions 请求耗时（毫秒）
+	MetadataMs      float64 `json:"metadataMs"`          // Metadata 请求耗时（毫秒）
+}
+
+// ChecksumDetails 文件摘要校验结果
+type ChecksumDetails struct {
+	Source     string  `json:"source"`           // 期望摘要的来源：config（目标配置）或摘要文件地址
+	Expected   string  `json:"expected"`         // 期望的 SHA-256
+	Actual     string  `json:"actual,omitempty"` // 实际下载内容的 SHA-256，下载未完成时为空
+	Matched    bool    `json:"matched"`          // 是否一致
+	Bytes      int64   `json:"bytes"`            // 下载的字节数
+	DownloadMs float64 `json:"downloadMs"`       // 下载剩余部分的耗时（毫秒）
+}
+
+// MQTTDetails MQTT 检查结果
+type MQTTDetails struct {
+	Address        string  `json:"address"`                 // 连接的地址（host:port）
+	TLS            bool    `json:"tls"`                     // 是否使用 TLS（mqtts://）
+	ClientID       string  `json:"clientId"`                // 使用的客户端标识
+	ReturnCode     *int    `json:"returnCode,omitempty"`    // CONNACK 返回码，0 表示接受；未收到 CONNACK 时为空
+	ReturnMessage  string  `json:"returnMessage,omitempty"` // 返回码说明
+	SessionPresent bool    `json:"sessionPresent"`          // 服务端是否保留了会话（clean session 下应为 false）
+	ConnackMs      float64 `json:"connackMs"`               // 发送 CONNECT 到收到 CONNACK 的耗时（毫秒）
+}
+
+// FileTransferDetails FTP / SFTP 检查结果
+type FileTransferDetails struct {
+	Protocol    string  `json:"protocol"`              // ftp / sftp
+	Address     string  `json:"address"`               // 连接的地址（host:port）
+	User        string  `json:"user"`                  // 登录用户名（FTP 未配置凭据时为 anonymous）
+	Path        string  `json:"path"`                  // 列出的目录，"." 表示登录后的默认目录
+	Banner      string  `json:"banner,omitempty"`      // FTP 问候语或 SSH 版本标识
+	Fingerprint string  `json:"fingerprint,omitempty"` // SFTP 服务端主机公钥指纹（SHA256:...）
+	Entries     int     `json:"entries"`               // 目录条目数（不含 . 与 ..）
+	ConnectMs   float64 `json:"connectMs"`             // 建立连接的耗时（FTP 含读取问候语，毫秒）
+	LoginMs     float64 `json:"loginMs"`               // 登录耗时（SFTP 含 SSH 握手，毫秒）
+	ListMs      float64 `json:"listMs"`                // 列目录耗时（FTP 含建立数据连接，SFTP 含打开子系统，毫秒）
+}
+
+// ICMPDetails ICMP 回显统计
+type ICMPDetails struct {
+	Address     string  `json:"address"`     // 实际发送的目标 IP
+	Mode        string  `json:"mode"`        // 套接字类型：raw（原始套接字）/ udp（非特权 ICMP 套接字）
+	Sent        int     `json:"sent"`        // 发送的回显请求数
+	Received    int     `json:"received"`    // 收到的回显应答数
+	LossPercent float64 `json:"lossPercent"` // 丢包率（百分比）
+	MinMs       float64 `json:"minMs"`       // 最小往返时延（毫秒）
+	AvgMs       float64 `json:"avgMs"`       // 平均往返时延（毫秒）
+	MaxMs       float64 `json:"maxMs"`       // 最大往返时延（毫秒）
+}
+
+// TLSDetails TLS 握手信息
+type TLSDetails struct {
+	Profile            string       `json:"profile"`            // 使用的 TLS 配置档
+	Version            string       `json:"version"`            // 协商的 TLS 版本
+	CipherSuite        string       `json:"cipherSuite"`        // 协商的加密套件
+	InsecureSkipVerify bool         `json:"insecureSkipVerify"` // 是否跳过了证书校验（为 true 时证书状态不可信）
+	CAFile             string       `json:"caFile,omitempty"`   // 校验证书使用的目标级 CA 证书文件（tls.caFile），使用系统根证书时为空
+	OCSP               *OCSPDetails `json:"ocsp,omitempty"`     // 证书吊销状态
+	// 服务端发送的证书链（叶子证书在前）及证书链是否有效（可由系统信任的根证书验证，且与访问的主机名匹配）
+	Chain       []CertificateInfo `json:"chain,omitempty"`
+	ChainValid  bool              `json:"chainValid"`
+	ChainError  string            `json:"chainError,omitempty"`  // 证书链无效的原因
+	TrustedRoot string            `json:"trustedRoot,omitempty"` // 验证证书链所用的根证书
+	// tls:// 检查的握手耗时（毫秒，不含 TCP 连接）与协商的应用层协议（ALPN）
+	HandshakeMs float64 `json:"handshakeMs,omitempty"`
+	ALPN        string  `json:"alpn,omitempty"`
+}
+
+// OCSPDetails 证书吊销（OCSP）检查结果
+type OCSPDetails struct {
+	Stapled   bool       `json:"stapled"`             // 服务端是否装订了 OCSP 响应
+	Source    string     `json:"source"`              // 状态来源：stapled（装订响应）/ responder（在线查询）/ none（未检查）
+	Status    string     `json:"status"`              // 吊销状态：good / revoked / unknown / unchecked
+	RevokedAt *time.Time `json:"revokedAt,omitempty"` // 吊销时间
+	Reason    string     `json:"reason,omitempty"`    // 吊销原因
+	Error     string     `json:"error,omitempty"`     // 检查失败原因
+}
+
+// DialAttempt 单次拨号尝试的诊断信息
+type DialAttempt struct {
+	Address    string  `json:"address"`         // 实际连接的地址（ip:port）
+	Family     string  `json:"family"`          // 地址族：ipv4 / ipv6
+	StartMs    float64 `json:"startMs"`         // 相对于本次连接开始的启动时间（毫秒）
+	DurationMs float64 `json:"durationMs"`      // 尝试耗时（毫秒）
+	Outcome    string  `json:"outcome"`         // 结果：connected / failed / cancelled / denied
+	Error      string  `json:"error,omitempty"` // 失败原因
+}
+
+// recordDialAttempts 记录本次检查的拨号尝试（重试时覆盖上一次的记录）
+func (r *MonitorResult) recordDialAttempts(attempts []DialAttempt) {
+	if len(attempts) == 0 {
+		if r.Details != nil {
+			r.Details.DialAttempts = nil
+		}
+		return
+	}
+	r.details().DialAttempts = attempts
+}
+
+// addWarning 追加一条警告信息，多条警告以「；」分隔
+func (r *MonitorResult) addWarning(msg string) {
+	if r.Warning == "" {
+		r.Warning = msg
+		return
+	}
+	r.Warning += "；" + msg
+}
+
+// addTypedWarning 追加一条带类型的警告，类型记录在 details.warningTypes 中（同一类型只记录一次）
+func (r *MonitorResult) addTypedWarning(kind WarningType, msg string) {
+	r.addWarning(msg)
+	d := r.details()
+	for _, k := range d.WarningTypes {
+		if k == kind {
+			return
+		}
+	}
+	d.WarningTypes = append(d.WarningTypes, kind)
+}
+
+// details 返回结果的诊断信息，不存在时创建
+func (r *MonitorResult) details() *ResultDetails {
+	if r.Details == nil {
+		r.Details = &ResultDetails{}
+	}
+	return r.Details
 }
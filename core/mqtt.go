@@ -0,0 +1,216 @@
+package core
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// mqttKeepAlive CONNECT 中声明的保活时间（秒），检查完成后立即断开，取值只需合法
+const mqttKeepAlive = 30
+
+// mqttReturnCodes MQTT 3.1.1 CONNACK 返回码对应的说明
+var mqttReturnCodes = map[byte]string{
+	0: "连接已接受",
+	1: "不支持的协议版本",
+	2: "客户端标识被拒绝",
+	3: "服务不可用",
+	4: "用户名或密码错误",
+	5: "未授权",
+}
+
+// mqttQuery MQTT 检查参数
+type mqttQuery struct {
+	address  string // host:port
+	secure   bool   // mqtts://，连接建立后直接 TLS 握手
+	clientID string // 客户端标识，为空时自动生成
+}
+
+// parseMQTTURL 解析 mqtt://host[:port]?clientId=... 形式的地址
+// mqtt:// 默认端口 1883，mqtts://（TLS）默认端口 8883；用户名与密码通过目标的 credentials 配置
+func parseMQTTURL(u *url.URL) (*mqttQuery, error) {
+	if u.Hostname() == "" {
+		return nil, fmt.Errorf("MQTT地址格式应为 %s://host:port", u.Scheme)
+	}
+	if u.User != nil {
+		return nil, fmt.Errorf("MQTT地址中不能包含用户名或密码，请通过目标的 credentials（username / password）配置")
+	}
+	q := &mqttQuery{secure: strings.EqualFold(u.Scheme, "mqtts"), clientID: u.Query().Get("clientId")}
+	port := u.Port()
+	switch {
+	case port == "" && q.secure:
+		port = "8883"
+	case port == "":
+		port = "1883"
+	}
+	if _, err := strconv.ParseUint(port, 10, 16); err != nil {
+		return nil, fmt.Errorf("无效的MQTT端口：%s", port)
+	}
+	if len(q.clientID) > 23 {
+		return nil, fmt.Errorf("clientId 不能超过 23 个字符（MQTT 3.1.1 服务端必须接受的最大长度）")
+	}
+	q.address = net.JoinHostPort(u.Hostname(), port)
+	return q, nil
+}
+
+// checkMQTT 检查MQTT服务：完成 CONNECT / CONNACK 握手（MQTT 3.1.1，clean session），记录服务端返回码后发送 DISCONNECT
+// 凭据取自目标的 credentials：username 与 password（可选）；mqtts:// 的证书校验遵循目标的 TLS 配置档与 SNI
+func (sc *ServiceChecker) checkMQTT(target *MonitorTarget, result *MonitorResult) (error, ErrorType) {
+	u, err := url.Parse(target.URL)
+	if err != nil {
+		return fmt.Errorf("解析MQTT地址失败：%w", err), ErrorTypeInvalid
+	}
+	q, err := parseMQTTURL(u)
+	if err != nil {
+		return err, ErrorTypeInvalid
+	}
+	username, err := target.credential("username")
+	if err != nil {
+		return err, ErrorTypeInvalid
+	}
+	password, err := target.credential("password")
+	if err != nil {
+		return err, ErrorTypeInvalid
+	}
+	if password != "" && username == "" {
+		return errors.New("MQTT 3.1.1 不支持只有密码没有用户名，请同时配置 username 凭据"), ErrorTypeInvalid
+	}
+	if q.clientID == "" {
+		raw := make([]byte, 6)
+		rand.Read(raw)
+		q.clientID = "servicemonitor-" + hex.EncodeToString(raw)[:8]
+	}
+
+	timeout := sc.cfg.MQTTTimeout
+	dialer := sc.newDialer(target, timeout)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	conn, err := dialer.DialContext(ctx, "tcp", q.address)
+	result.recordDialAttempts(dialer.Attempts())
+	if err != nil {
+		var denied *EgressDeniedError
+		if errors.As(err, &denied) {
+			return denied, ErrorTypePolicy
+		}
+		if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+			return fmt.Errorf("MQTT连接超时：%w", err), ErrorTypeTimeout
+		}
+		return fmt.Errorf("MQTT连接失败：%w", err), ErrorTypeNetwork
+	}
+	defer func() { conn.Close() }()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	details := &MQTTDetails{Address: q.address, TLS: q.secure, ClientID: q.clientID}
+	result.details().MQTT = details
+
+	if q.secure {
+		tlsConfig, profile, err := sc.tlsConfig(target)
+		if err != nil {
+			return err, ErrorTypeInvalid
+		}
+		if tlsConfig.ServerName == "" {
+			tlsConfig.ServerName = u.Hostname()
+		}
+		tc := tls.Client(conn, tlsConfig)
+		if err := tc.HandshakeContext(ctx); err != nil {
+			if strings.Contains(err.Error(), "certificate") {
+				return fmt.Errorf("SSL证书验证失败：%w", err), ErrorTypeSSL
+			}
+			return mqttError("TLS握手失败", err)
+		}
+		state := tc.ConnectionState()
+		sc.recordTLS(target, profile, &state, result)
+		conn = tc
+	}
+
+	start := time.Now()
+	if _, err := conn.Write(mqttConnectPacket(q.clientID, username, password)); err != nil {
+		return mqttError("发送CONNECT失败", err)
+	}
+	var connack [4]byte
+	if _, err := io.ReadFull(conn, connack[:]); err != nil {
+		return mqttError("读取CONNACK失败", err)
+	}
+	details.ConnackMs = float64(time.Since(start).Microseconds()) / 1000
+	if connack[0] != 0x20 || connack[1] != 0x02 {
+		return fmt.Errorf("服务端返回的不是MQTT CONNACK（% x）", connack[:2]), ErrorTypeMQTT
+	}
+	details.SessionPresent = connack[2]&0x01 == 1
+	code := connack[3]
+	returnCode := int(code)
+	details.ReturnCode = &returnCode
+	details.ReturnMessage = mqttReturnCodes[code]
+	if details.ReturnMessage == "" {
+		details.ReturnMessage = fmt.Sprintf("未知返回码（%d）", code)
+	}
+	if code != 0 {
+		return fmt.Errorf("MQTT服务拒绝连接：%d %s", code, details.ReturnMessage), ErrorTypeMQTT
+	}
+
+	// DISCONNECT 失败不影响检查结论
+	conn.Write([]byte{0xe0, 0x00})
+	return nil, ""
+}
+
+// mqttConnectPacket 编码 MQTT 3.1.1 CONNECT 报文：固定头 + 协议名 MQTT + 协议级别 4 + 连接标志 + 保活时间 + 客户端标识 [+ 用户名 [+ 密码]]
+func mqttConnectPacket(clientID, username, password string) []byte {
+	flags := byte(0x02) // clean session
+	payload := mqttString(nil, clientID)
+	if username != "" {
+		flags |= 0x80
+		payload = mqttString(payload, username)
+		if password != "" {
+			flags |= 0x40
+			payload = mqttString(payload, password)
+		}
+	}
+	body := mqttString(nil, "MQTT")
+	body = append(body, 4, flags)
+	body = binary.BigEndian.AppendUint16(body, mqttKeepAlive)
+	body = append(body, payload...)
+
+	packet := []byte{0x10}
+	// 剩余长度：变长编码，每字节 7 位，最高位表示后面还有字节
+	for n := len(body); ; {
+		b := byte(n % 128)
+		n /= 128
+		if n > 0 {
+			b |= 0x80
+		}
+		packet = append(packet, b)
+		if n == 0 {
+			break
+		}
+	}
+	return append(packet, body...)
+}
+
+// mqttString 追加 MQTT UTF-8 字符串：2 字节长度 + 内容
+func mqttString(b []byte, s string) []byte {
+	b = binary.BigEndian.AppendUint16(b, uint16(len(s)))
+	return append(b, s...)
+}
+
+// mqttError 区分超时、连接被关闭与其他网络错误
+// 部分服务端对认证失败等情况直接断开连接而不返回 CONNACK
+func mqttError(action string, err error) (error, ErrorType) {
+	if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+		return fmt.Errorf("%s：等待响应超时", action), ErrorTypeTimeout
+	}
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return fmt.Errorf("%s：连接被服务端关闭", action), ErrorTypeMQTT
+	}
+	return fmt.Errorf("%s：%w", action, err), ErrorTypeNetwork
+}
@@ -0,0 +1,183 @@
+package core
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/url"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// ntpEpochOffset NTP 时间起点（1900-01-01）与 Unix 时间起点之间的秒数
+const ntpEpochOffset = 2208988800
+
+// ntpQuery NTP 检查参数
+type ntpQuery struct {
+	address    string        // host:port
+	maxOffset  time.Duration // 允许的最大时钟偏差，0 表示使用 monitor.ntp.maxOffset
+	maxStratum int           // 允许的最大层级，0 表示不校验（层级 16 表示未同步，始终判定失败）
+}
+
+// parseNTPURL 解析 ntp://host[:port]?maxOffset=100ms&maxStratum=3 形式的地址，默认端口 123
+func parseNTPURL(u *url.URL) (*ntpQuery, error) {
+	if u.Hostname() == "" {
+		return nil, fmt.Errorf("NTP地址格式应为 ntp://host")
+	}
+	port := u.Port()
+	if port == "" {
+		port = "123"
+	}
+	if _, err := strconv.ParseUint(port, 10, 16); err != nil {
+		return nil, fmt.Errorf("无效的NTP端口：%s", port)
+	}
+	q := &ntpQuery{address: net.JoinHostPort(u.Hostname(), port)}
+	params := u.Query()
+	if v := params.Get("maxOffset"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("无效的 maxOffset：%s，格式如 100ms、1s", v)
+		}
+		q.maxOffset = d
+	}
+	if v := params.Get("maxStratum"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 15 {
+			return nil, fmt.Errorf("无效的 maxStratum：%s，取值 1~15", v)
+		}
+		q.maxStratum = n
+	}
+	return q, nil
+}
+
+// checkNTP 检查NTP服务：发送一个 SNTP 客户端请求（RFC 4330），按四个时间戳计算本机与服务器的时钟偏差与往返时延
+// 服务器未同步（闰秒指示为 3 或层级 16）、返回 Kiss-o'-Death、偏差或层级超过阈值时判定失败
+// 偏差以本机时钟为参照，检查节点自身时钟不准时所有 NTP 目标都会出现相同的偏差
+func (sc *ServiceChecker) checkNTP(target *MonitorTarget, result *MonitorResult) (error, ErrorType) {
+	u, err := url.Parse(target.URL)
+	if err != nil {
+		return fmt.Errorf("解析NTP地址失败：%w", err), ErrorTypeInvalid
+	}
+	q, err := parseNTPURL(u)
+	if err != nil {
+		return err, ErrorTypeInvalid
+	}
+	maxOffset := q.maxOffset
+	if maxOffset == 0 {
+		maxOffset = sc.cfg.NTP.MaxOffset
+	}
+
+	timeout := sc.cfg.NTP.Timeout
+	dialer := sc.newDialer(target, timeout)
+	conn, err := dialer.DialContext(context.Background(), "udp", q.address)
+	if err != nil {
+		var denied *EgressDeniedError
+		if errors.As(err, &denied) {
+			return denied, ErrorTypePolicy
+		}
+		return fmt.Errorf("NTP连接失败：%w", err), ErrorTypeNetwork
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	details := &NTPDetails{Address: conn.RemoteAddr().String(), MaxOffsetMs: durationMs(maxOffset)}
+	result.details().NTP = details
+
+	// 请求：LI=0，版本 4，模式 3（客户端）；发送时间戳 T1 由服务器原样放入响应的 originate 字段，用于匹配响应
+	req := make([]byte, 48)
+	req[0] = 0<<6 | 4<<3 | 3
+	t1 := time.Now()
+	binary.BigEndian.PutUint64(req[40:], ntpTimestamp(t1))
+	if _, err := conn.Write(req); err != nil {
+		return fmt.Errorf("发送NTP请求失败：%w", err), ErrorTypeNetwork
+	}
+	resp := make([]byte, 128)
+	n, err := conn.Read(resp)
+	t4 := time.Now()
+	if err != nil {
+		if errors.Is(err, syscall.ECONNREFUSED) {
+			return fmt.Errorf("NTP端口不可达（收到 ICMP 端口不可达）：%s", details.Address), ErrorTypeNetwork
+		}
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() {
+			return fmt.Errorf("NTP请求超时，%s 内未收到响应", timeout), ErrorTypeTimeout
+		}
+		return fmt.Errorf("接收NTP响应失败：%w", err), ErrorTypeNetwork
+	}
+	if n < 48 || resp[0]&0x07 != 4 || binary.BigEndian.Uint64(resp[24:]) != binary.BigEndian.Uint64(req[40:]) {
+		return errors.New("收到的不是本次请求的NTP服务器响应"), ErrorTypeNTP
+	}
+
+	leap, stratum := resp[0]>>6, int(resp[1])
+	details.Stratum = stratum
+	details.ReferenceID = ntpReferenceID(stratum, resp[12:16])
+	if stratum == 0 {
+		return fmt.Errorf("NTP服务器拒绝服务（Kiss-o'-Death：%s）", details.ReferenceID), ErrorTypeNTP
+	}
+
+	// 偏差 θ = ((T2 - T1) + (T3 - T4)) / 2，往返时延 δ = (T4 - T1) - (T3 - T2)
+	t2 := ntpTime(binary.BigEndian.Uint64(resp[32:]))
+	t3 := ntpTime(binary.BigEndian.Uint64(resp[40:]))
+	offset := (t2.Sub(t1) + t3.Sub(t4)) / 2
+	delay := t4.Sub(t1) - t3.Sub(t2)
+	details.OffsetMs, details.DelayMs = durationMs(offset), durationMs(delay)
+	details.RootDelayMs = ntpShortMs(binary.BigEndian.Uint32(resp[4:]))
+	details.RootDispersionMs = ntpShortMs(binary.BigEndian.Uint32(resp[8:]))
+
+	switch {
+	case leap == 3 || stratum >= 16:
+		return errors.New("NTP服务器自身时钟未同步"), ErrorTypeNTP
+	case math.Abs(details.OffsetMs) > details.MaxOffsetMs:
+		return fmt.Errorf("时钟偏差 %.1fms 超过阈值 %s", details.OffsetMs, maxOffset), ErrorTypeNTP
+	case q.maxStratum > 0 && stratum > q.maxStratum:
+		return fmt.Errorf("NTP服务器层级 %d 超过阈值 %d", stratum, q.maxStratum), ErrorTypeNTP
+	}
+	if leap == 1 || leap == 2 {
+		result.addWarning("NTP服务器通告了即将到来的闰秒")
+	}
+	return nil, ""
+}
+
+// ntpTimestamp 将时间转换为 NTP 64 位时间戳（高 32 位为秒，低 32 位为秒的小数部分）
+func ntpTimestamp(t time.Time) uint64 {
+	sec := uint64(t.Unix() + ntpEpochOffset)
+	frac := uint64(t.Nanosecond()) << 32 / 1e9
+	return sec<<32 | frac
+}
+
+// ntpTime 将 NTP 64 位时间戳转换为时间
+func ntpTime(ts uint64) time.Time {
+	sec := int64(ts>>32) - ntpEpochOffset
+	nsec := int64((ts & 0xffffffff) * 1e9 >> 32)
+	return time.Unix(sec, nsec)
+}
+
+// ntpShortMs 将 NTP 32 位短格式（16 位秒 + 16 位小数）转换为毫秒
+func ntpShortMs(v uint32) float64 {
+	return float64(v) / 65536 * 1000
+}
+
+// ntpReferenceID 格式化参考标识：层级 0 与 1 为 4 字符 ASCII 码（Kiss 码或参考时钟类型，如 GPS），其余为上游服务器的 IPv4 地址
+func ntpReferenceID(stratum int, id []byte) string {
+	if stratum <= 1 {
+		return string(trimNUL(id))
+	}
+	return net.IP(id).String()
+}
+
+// trimNUL 去掉末尾的 NUL 字节
+func trimNUL(b []byte) []byte {
+	for len(b) > 0 && b[len(b)-1] == 0 {
+		b = b[:len(b)-1]
+	}
+	return b
+}
+
+// durationMs 将时长转换为毫秒（保留小数）
+func durationMs(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
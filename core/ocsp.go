@@ -0,0 +1,163 @@
+package core
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net/http"
+
+	"golang.org/x/crypto/ocsp"
+)
+
+// OCSP 吊销状态
+const (
+	OCSPStatusGood      = "good"
+	OCSPStatusRevoked   = "revoked"
+	OCSPStatusUnknown   = "unknown"
+	OCSPStatusUnchecked = "unchecked"
+)
+
+// revocationReasons RFC 5280 定义的吊销原因
+var revocationReasons = map[int]string{
+	ocsp.Unspecified:          "unspecified",
+	ocsp.KeyCompromise:        "keyCompromise",
+	ocsp.CACompromise:         "caCompromise",
+	ocsp.AffiliationChanged:   "affiliationChanged",
+	ocsp.Superseded:           "superseded",
+	ocsp.CessationOfOperation: "cessationOfOperation",
+	ocsp.CertificateHold:      "certificateHold",
+	ocsp.RemoveFromCRL:        "removeFromCRL",
+	ocsp.PrivilegeWithdrawn:   "privilegeWithdrawn",
+	ocsp.AACompromise:         "aaCompromise",
+}
+
+// inspectOCSP 检查服务端证书的吊销状态：优先使用装订的 OCSP 响应，未装订且开启在线查询时请求证书中的 OCSP 服务器
+// 发现的问题（已吊销、未装订等）以警告形式记录到结果中，不影响检查状态
+func (sc *ServiceChecker) inspectOCSP(target *MonitorTarget, state *tls.ConnectionState, result *MonitorResult) *OCSPDetails {
+	details := &OCSPDetails{
+		Stapled: len(state.OCSPResponse) > 0,
+		Source:  "none",
+		Status:  OCSPStatusUnchecked,
+	}
+	if len(state.PeerCertificates) == 0 {
+		return details
+	}
+	leaf := state.PeerCertificates[0]
+	issuer := certIssuer(state)
+
+	var resp *ocsp.Response
+	var err error
+	switch {
+	case details.Stapled:
+		details.Source = "stapled"
+		resp, err = ocsp.ParseResponseForCert(state.OCSPResponse, leaf, issuer)
+	case sc.cfg.OCSP.CheckRevocation:
+		if sc.cfg.OCSP.WarnNoStapling {
+			result.addWarning("服务端未启用 OCSP Stapling")
+		}
+		details.Source = "responder"
+		resp, err = sc.queryOCSP(target, leaf, issuer)
+	default:
+		if sc.cfg.OCSP.WarnNoStapling {
+			result.addWarning("服务端未启用 OCSP Stapling")
+		}
+		return details
+	}
+
+	if err != nil {
+		details.Error = err.Error()
+		return details
+	}
+
+	switch resp.Status {
+	case ocsp.Good:
+		details.Status = OCSPStatusGood
+	case ocsp.Revoked:
+		details.Status = OCSPStatusRevoked
+		revokedAt := resp.RevokedAt
+		details.RevokedAt = &revokedAt
+		details.Reason = revocationReasons[resp.RevocationReason]
+		result.addWarning(fmt.Sprintf("SSL证书已被吊销（%s，原因：%s）", revokedAt.Format("2006-01-02"), details.Reason))
+	default:
+		details.Status = OCSPStatusUnknown
+	}
+	return details
+}
+
+// revocationError 开启 failOnRevoked 时，证书已吊销或 OCSP 服务器返回未知状态则返回错误
+// 未能获取吊销状态（未装订且未开启在线查询、查询失败）时不判定失败
+func (sc *ServiceChecker) revocationError(result *MonitorResult) error {
+	if !sc.cfg.OCSP.FailOnRevoked || result.Details == nil || result.Details.TLS == nil || result.Details.TLS.OCSP == nil {
+		return nil
+	}
+	details := result.Details.TLS.OCSP
+	switch details.Status {
+	case OCSPStatusRevoked:
+		return fmt.Errorf("SSL证书已被吊销（%s，原因：%s，来源：%s）", details.RevokedAt.Format("2006-01-02"), details.Reason, details.Source)
+	case OCSPStatusUnknown:
+		return fmt.Errorf("OCSP服务器未识别该证书，吊销状态未知（来源：%s）", details.Source)
+	}
+	return nil
+}
+
+// certIssuer 返回叶子证书的签发者证书：优先使用校验通过的证书链，其次使用服务端发送的证书链
+func certIssuer(state *tls.ConnectionState) *x509.Certificate {
+	if len(state.VerifiedChains) > 0 && len(state.VerifiedChains[0]) > 1 {
+		return state.VerifiedChains[0][1]
+	}
+	if len(state.PeerCertificates) > 1 {
+		return state.PeerCertificates[1]
+	}
+	return nil
+}
+
+// queryOCSP 向证书中声明的 OCSP 服务器在线查询吊销状态
+// OCSP 服务器地址来自目标返回的证书，与检查目标一样经由目标的拨号器与代理连接，执行出站网络策略与地址安全校验
+func (sc *ServiceChecker) queryOCSP(target *MonitorTarget, leaf, issuer *x509.Certificate) (*ocsp.Response, error) {
+	if issuer == nil {
+		return nil, fmt.Errorf("缺少签发者证书，无法查询OCSP")
+	}
+	if len(leaf.OCSPServer) == 0 {
+		return nil, fmt.Errorf("证书未声明OCSP服务器")
+	}
+
+	reqBody, err := ocsp.CreateRequest(leaf, issuer, nil)
+	if err != nil {
+		return nil, fmt.Errorf("创建OCSP请求失败：%w", err)
+	}
+
+	proxyURL, err := sc.proxyFor(target)
+	if err != nil {
+		return nil, err
+	}
+	dialer := sc.newDialer(target, sc.cfg.OCSP.Timeout)
+	client := &http.Client{
+		Timeout:       sc.cfg.OCSP.Timeout,
+		CheckRedirect: newRedirectRecorder(target, sc.urlPolicyFor(target)).CheckRedirect,
+		Transport: &http.Transport{
+			DisableKeepAlives: true,
+			DialContext:       dialer.DialContext,
+			Proxy:             httpProxy(proxyURL, dialer),
+		},
+	}
+	httpResp, err := client.Post(leaf.OCSPServer[0], "application/ocsp-request", bytes.NewReader(reqBody))
+	if err != nil {
+		return nil, fmt.Errorf("请求OCSP服务器失败：%w", err)
+	}
+	defer httpResp.Body.Close()
+	if httpResp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("OCSP服务器返回状态码%d", httpResp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(httpResp.Body, 64*1024))
+	if err != nil {
+		return nil, fmt.Errorf("读取OCSP响应失败：%w", err)
+	}
+	resp, err := ocsp.ParseResponseForCert(data, leaf, issuer)
+	if err != nil {
+		return nil, fmt.Errorf("解析OCSP响应失败：%w", err)
+	}
+	return resp, nil
+}
@@ -0,0 +1,91 @@
+package core
+
+import (
+	"crypto/tls"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// PhaseTimings HTTP 请求各阶段耗时（毫秒），经过重定向时为各次请求之和；未经历的阶段为 0（如 IP 地址无需解析、明文 HTTP 没有 TLS 握手）
+type PhaseTimings struct {
+	DNSMs      float64 `json:"dnsMs"`      // DNS 解析
+	ConnectMs  float64 `json:"connectMs"`  // 建立 TCP 连接（多个地址竞速时为首次发起连接到成功建立）
+	TLSMs      float64 `json:"tlsMs"`      // TLS 握手
+	TTFBMs     float64 `json:"ttfbMs"`     // 等待首字节：请求发送完毕到收到响应的第一个字节，主要为服务器处理耗时
+	DownloadMs float64 `json:"downloadMs"` // 下载响应体：收到首字节到读完响应体，未读取响应体时为 0
+}
+
+// phaseTracer 通过 httptrace 记录请求各阶段的耗时，回调可能并发触发（多地址竞速连接）
+type phaseTracer struct {
+	mu           sync.Mutex
+	timings      PhaseTimings
+	dnsStart     time.Time
+	connectStart time.Time
+	tlsStart     time.Time
+	wroteAt      time.Time
+	firstByteAt  time.Time
+	bodyDoneAt   time.Time
+}
+
+// trace 返回写入请求上下文的 httptrace 回调
+func (p *phaseTracer) trace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) { p.mark(&p.dnsStart) },
+		DNSDone:  func(httptrace.DNSDoneInfo) { p.add(&p.timings.DNSMs, &p.dnsStart) },
+		ConnectStart: func(string, string) {
+			p.mu.Lock()
+			defer p.mu.Unlock()
+			// 多地址竞速时只记录首次发起连接的时间
+			if p.connectStart.IsZero() {
+				p.connectStart = time.Now()
+			}
+		},
+		ConnectDone: func(_, _ string, err error) {
+			if err == nil {
+				p.add(&p.timings.ConnectMs, &p.connectStart)
+			}
+		},
+		TLSHandshakeStart:    func() { p.mark(&p.tlsStart) },
+		TLSHandshakeDone:     func(tls.ConnectionState, error) { p.add(&p.timings.TLSMs, &p.tlsStart) },
+		WroteRequest:         func(httptrace.WroteRequestInfo) { p.mark(&p.wroteAt) },
+		GotFirstResponseByte: func() { p.mark(&p.firstByteAt); p.add(&p.timings.TTFBMs, &p.wroteAt) },
+	}
+}
+
+// mark 记录阶段开始时间
+func (p *phaseTracer) mark(at *time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	*at = time.Now()
+}
+
+// add 阶段结束：累加自开始时间以来的耗时并清除开始时间，开始时间为空（重复的结束回调）时忽略
+func (p *phaseTracer) add(total *float64, start *time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if start.IsZero() {
+		return
+	}
+	*total += durationMs(time.Since(*start))
+	*start = time.Time{}
+}
+
+// bodyDone 记录读完响应体的时间，读取多次（如先读取用于关键词匹配、再读取剩余部分计算摘要）时以最后一次为准
+func (p *phaseTracer) bodyDone() {
+	p.mark(&p.bodyDoneAt)
+}
+
+// result 返回各阶段耗时，未经历任何阶段（如请求未发出即失败）时为 nil
+func (p *phaseTracer) result() *PhaseTimings {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	timings := p.timings
+	if timings == (PhaseTimings{}) {
+		return nil
+	}
+	if !p.bodyDoneAt.IsZero() && !p.firstByteAt.IsZero() {
+		timings.DownloadMs = durationMs(p.bodyDoneAt.Sub(p.firstByteAt))
+	}
+	return &timings
+}
@@ -0,0 +1,392 @@
+package core
+
+import (
+	"bufio"
+	"context"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// PostgreSQL SSL 策略（与 libpq 的 sslmode 同名，仅支持其中三种）
+const (
+	PostgresSSLDisable = "disable" // 不使用 SSL
+	PostgresSSLPrefer  = "prefer"  // 服务端支持时使用 SSL（默认）
+	PostgresSSLRequire = "require" // 必须使用 SSL，否则判定失败
+)
+
+// PostgreSQL 协议常量
+const (
+	pgProtocolVersion = 196608   // 3.0
+	pgSSLRequestCode  = 80877103 // SSLRequest
+	pgMaxMessage      = 1 << 20  // 单条消息的最大长度，防止异常服务端导致大量内存分配
+)
+
+// pgConn PostgreSQL 前后端协议（v3）的最小实现，只支持启动、认证、简单查询与终止，用于 SELECT 1 检查
+type pgConn struct {
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// pgServerError PostgreSQL 的 ErrorResponse
+type pgServerError struct {
+	Code    string // SQLSTATE，如 28P01
+	Message string
+}
+
+func (e *pgServerError) Error() string {
+	return fmt.Sprintf("%s（SQLSTATE %s）", e.Message, e.Code)
+}
+
+// checkPostgres 按 PostgreSQL 协议连接（可选 SSL）、认证并执行 SELECT 1
+// 支持 trust、明文密码、MD5 与 SCRAM-SHA-256 认证
+func (sc *ServiceChecker) checkPostgres(ctx context.Context, target *MonitorTarget, q *databaseQuery, username, password string, dialer *probeDialer, result *MonitorResult) (error, ErrorType) {
+	details := result.details().Database
+	start := time.Now()
+	conn, err := dialer.DialContext(ctx, "tcp", q.address)
+	if err != nil {
+		var denied *EgressDeniedError
+		if errors.As(err, &denied) {
+			return denied, ErrorTypePolicy
+		}
+		if netErr, ok := err.(net.Error); ok && netErr.Timeout() || errors.Is(err, context.DeadlineExceeded) {
+			return fmt.Errorf("PostgreSQL连接超时：%w", err), ErrorTypeTimeout
+		}
+		return fmt.Errorf("PostgreSQL连接失败：%w", err), ErrorTypeNetwork
+	}
+	defer func() { conn.Close() }()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	if q.tls != PostgresSSLDisable {
+		var req [8]byte
+		binary.BigEndian.PutUint32(req[0:4], 8)
+		binary.BigEndian.PutUint32(req[4:8], pgSSLRequestCode)
+		if _, err := conn.Write(req[:]); err != nil {
+			return postgresError("发送SSL请求失败", err)
+		}
+		var reply [1]byte
+		if _, err := io.ReadFull(conn, reply[:]); err != nil {
+			return postgresError("读取SSL应答失败", err)
+		}
+		switch {
+		case reply[0] == 'S':
+			tlsConfig, profile, err := sc.tlsConfig(target)
+			if err != nil {
+				return err, ErrorTypeInvalid
+			}
+			if tlsConfig.ServerName == "" {
+				tlsConfig.ServerName, _, _ = net.SplitHostPort(q.address)
+			}
+			tc := tls.Client(conn, tlsConfig)
+			if err := tc.HandshakeContext(ctx); err != nil {
+				if strings.Contains(err.Error(), "certificate") {
+					return fmt.Errorf("SSL证书验证失败：%w", err), ErrorTypeSSL
+				}
+				return postgresError("TLS握手失败", err)
+			}
+			state := tc.ConnectionState()
+			sc.recordTLS(target, profile, &state, result)
+			conn, details.TLS = tc, true
+		case q.tls == PostgresSSLRequire:
+			return errors.New("PostgreSQL服务不支持 SSL"), ErrorTypeDatabase
+		default:
+			result.addWarning("PostgreSQL服务不支持 SSL，连接未加密")
+		}
+	}
+
+	pc := &pgConn{conn: conn, reader: bufio.NewReader(conn)}
+	params := []string{"user", username, "application_name", "servicetelemetry"}
+	if q.database != "" {
+		params = append(params, "database", q.database)
+	}
+	if err := pc.startup(params); err != nil {
+		return postgresError("发送启动消息失败", err)
+	}
+	version, err := pc.authenticate(username, password)
+	if err != nil {
+		return postgresError("PostgreSQL连接失败", err)
+	}
+	details.ServerVersion = version
+	details.ConnectMs = float64(time.Since(start).Microseconds()) / 1000
+
+	start = time.Now()
+	value, err := pc.simpleQuery("SELECT 1")
+	details.QueryMs = float64(time.Since(start).Microseconds()) / 1000
+	if err != nil {
+		return postgresError("执行 SELECT 1 失败", err)
+	}
+	if value != "1" {
+		return fmt.Errorf("SELECT 1 返回了意外的结果：%s", truncateBanner(value)), ErrorTypeDatabase
+	}
+	pc.send('X', nil)
+	return nil, ""
+}
+
+// startup 发送启动消息：长度 + 协议版本 + 以 \0 分隔的参数键值 + \0
+func (pc *pgConn) startup(params []string) error {
+	body := binary.BigEndian.AppendUint32(nil, pgProtocolVersion)
+	for _, p := range params {
+		body = append(append(body, p...), 0)
+	}
+	body = append(body, 0)
+	msg := binary.BigEndian.AppendUint32(nil, uint32(len(body)+4))
+	_, err := pc.conn.Write(append(msg, body...))
+	return err
+}
+
+// send 发送一条带类型的消息
+func (pc *pgConn) send(typ byte, body []byte) error {
+	msg := append([]byte{typ}, binary.BigEndian.AppendUint32(nil, uint32(len(body)+4))...)
+	_, err := pc.conn.Write(append(msg, body...))
+	return err
+}
+
+// receive 读取一条消息，ErrorResponse 转换为 *pgServerError
+func (pc *pgConn) receive() (byte, []byte, error) {
+	var header [5]byte
+	if _, err := io.ReadFull(pc.reader, header[:]); err != nil {
+		return 0, nil, err
+	}
+	length := binary.BigEndian.Uint32(header[1:5])
+	if length < 4 || length > pgMaxMessage {
+		return 0, nil, fmt.Errorf("不是PostgreSQL协议应答（消息类型 %q）", header[0])
+	}
+	body := make([]byte, length-4)
+	if _, err := io.ReadFull(pc.reader, body); err != nil {
+		return 0, nil, err
+	}
+	if header[0] == 'E' {
+		return 0, nil, parsePGError(body)
+	}
+	return header[0], body, nil
+}
+
+// authenticate 完成认证流程并等待 ReadyForQuery，返回服务端版本（ParameterStatus server_version）
+func (pc *pgConn) authenticate(username, password string) (string, error) {
+	var version string
+	var scram *scramClient
+	for {
+		typ, body, err := pc.receive()
+		if err != nil {
+			return version, err
+		}
+		switch typ {
+		case 'R':
+			if len(body) < 4 {
+				return version, errors.New("认证消息格式错误")
+			}
+			code, data := binary.BigEndian.Uint32(body[:4]), body[4:]
+			switch code {
+			case 0: // AuthenticationOk
+			case 3: // 明文密码
+				if password == "" {
+					return version, errors.New("服务端要求密码，请为目标配置 password 凭据")
+				}
+				err = pc.send('p', append([]byte(password), 0))
+			case 5: // MD5：md5(md5(password + user) + salt)
+				if password == "" || len(data) < 4 {
+					return version, errors.New("服务端要求密码，请为目标配置 password 凭据")
+				}
+				inner := md5.Sum([]byte(password + username))
+				outer := md5.Sum(append([]byte(hex.EncodeToString(inner[:])), data[:4]...))
+				err = pc.send('p', append([]byte("md5"+hex.EncodeToString(outer[:])), 0))
+			case 10: // SASL
+				if !strings.Contains(string(data), "SCRAM-SHA-256\x00") {
+					return version, fmt.Errorf("不支持的SASL认证机制：%s", strings.ReplaceAll(strings.TrimRight(string(data), "\x00"), "\x00", ","))
+				}
+				if password == "" {
+					return version, errors.New("服务端要求密码，请为目标配置 password 凭据")
+				}
+				if scram, err = newSCRAMClient(password); err != nil {
+					return version, err
+				}
+				first := scram.clientFirst()
+				msg := append([]byte("SCRAM-SHA-256\x00"), binary.BigEndian.AppendUint32(nil, uint32(len(first)))...)
+				err = pc.send('p', append(msg, first...))
+			case 11: // SASLContinue
+				if scram == nil {
+					return version, errors.New("认证流程异常")
+				}
+				var final string
+				if final, err = scram.clientFinal(string(data)); err == nil {
+					err = pc.send('p', []byte(final))
+				}
+			case 12: // SASLFinal
+				if scram == nil {
+					return version, errors.New("认证流程异常")
+				}
+				err = scram.verifyServer(string(data))
+			default:
+				return version, fmt.Errorf("不支持的认证方式（%d）", code)
+			}
+			if err != nil {
+				return version, err
+			}
+		case 'S': // ParameterStatus
+			if parts := strings.Split(string(body), "\x00"); len(parts) >= 2 && parts[0] == "server_version" {
+				version = parts[1]
+			}
+		case 'Z': // ReadyForQuery
+			return version, nil
+		}
+	}
+}
+
+// simpleQuery 执行简单查询，返回第一行第一列的文本值
+func (pc *pgConn) simpleQuery(query string) (string, error) {
+	if err := pc.send('Q', append([]byte(query), 0)); err != nil {
+		return "", err
+	}
+	var value string
+	for {
+		typ, body, err := pc.receive()
+		if err != nil {
+			return "", err
+		}
+		switch typ {
+		case 'D': // DataRow：列数（2 字节）+ 各列（长度 4 字节 + 内容）
+			if value == "" && len(body) >= 6 && binary.BigEndian.Uint16(body[:2]) > 0 {
+				if n := int32(binary.BigEndian.Uint32(body[2:6])); n > 0 && int(n) <= len(body)-6 {
+					value = string(body[6 : 6+n])
+				}
+			}
+		case 'Z':
+			return value, nil
+		}
+	}
+}
+
+// parsePGError 解析 ErrorResponse：若干 (字段类型 1 字节 + 以 \0 结尾的值)，以 \0 结束
+func parsePGError(body []byte) error {
+	e := &pgServerError{}
+	for len(body) > 1 {
+		field := body[0]
+		end := strings.IndexByte(string(body[1:]), 0)
+		if end < 0 {
+			break
+		}
+		value := string(body[1 : 1+end])
+		body = body[2+end:]
+		switch field {
+		case 'C':
+			e.Code = value
+		case 'M':
+			e.Message = value
+		}
+	}
+	return e
+}
+
+// postgresError 区分服务端错误（认证失败、数据库不存在、正在启动等）、超时与网络错误
+func postgresError(action string, err error) (error, ErrorType) {
+	var pgErr *pgServerError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case "28P01", "28000":
+			return fmt.Errorf("%s：用户名或密码错误（%s）", action, pgErr.Message), ErrorTypeDatabase
+		case "3D000":
+			return fmt.Errorf("%s：数据库不存在", action), ErrorTypeDatabase
+		case "57P03":
+			return fmt.Errorf("%s：数据库正在启动或恢复中", action), ErrorTypeDatabase
+		}
+		return fmt.Errorf("%s：%s", action, pgErr), ErrorTypeDatabase
+	}
+	if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+		return fmt.Errorf("%s：等待应答超时", action), ErrorTypeTimeout
+	}
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return fmt.Errorf("%s：连接被服务端关闭", action), ErrorTypeNetwork
+	}
+	var netOpErr *net.OpError
+	if errors.As(err, &netOpErr) {
+		return fmt.Errorf("%s：%w", action, err), ErrorTypeNetwork
+	}
+	return fmt.Errorf("%s：%w", action, err), ErrorTypeDatabase
+}
+
+// scramClient SCRAM-SHA-256 客户端（RFC 5802 / RFC 7677），不使用通道绑定
+type scramClient struct {
+	password       string
+	nonce          string
+	clientFirstMsg string // client-first-message-bare
+	authMessage    string
+	saltedPassword []byte
+}
+
+func newSCRAMClient(password string) (*scramClient, error) {
+	raw := make([]byte, 18)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, fmt.Errorf("生成随机数失败：%w", err)
+	}
+	return &scramClient{password: password, nonce: base64.RawStdEncoding.EncodeToString(raw)}, nil
+}
+
+// clientFirst 返回 client-first-message；用户名由启动消息给出，此处留空
+func (s *scramClient) clientFirst() string {
+	s.clientFirstMsg = "n=,r=" + s.nonce
+	return "n,," + s.clientFirstMsg
+}
+
+// clientFinal 根据 server-first-message（r=...,s=...,i=...）计算 client-final-message
+func (s *scramClient) clientFinal(serverFirst string) (string, error) {
+	var nonce, salt string
+	var iterations int
+	for _, attr := range strings.Split(serverFirst, ",") {
+		key, value, _ := strings.Cut(attr, "=")
+		switch key {
+		case "r":
+			nonce = value
+		case "s":
+			salt = value
+		case "i":
+			fmt.Sscanf(value, "%d", &iterations)
+		}
+	}
+	saltBytes, err := base64.StdEncoding.DecodeString(salt)
+	if err != nil || !strings.HasPrefix(nonce, s.nonce) || iterations <= 0 {
+		return "", errors.New("SCRAM 服务端消息无效")
+	}
+
+	s.saltedPassword = pbkdf2.Key([]byte(s.password), saltBytes, iterations, sha256.Size, sha256.New)
+	clientKey := hmacSHA256(s.saltedPassword, "Client Key")
+	storedKey := sha256.Sum256(clientKey)
+	withoutProof := "c=biws,r=" + nonce
+	s.authMessage = s.clientFirstMsg + "," + serverFirst + "," + withoutProof
+	signature := hmacSHA256(storedKey[:], s.authMessage)
+	proof := make([]byte, len(clientKey))
+	for i := range clientKey {
+		proof[i] = clientKey[i] ^ signature[i]
+	}
+	return withoutProof + ",p=" + base64.StdEncoding.EncodeToString(proof), nil
+}
+
+// verifyServer 校验 server-final-message 中的服务端签名，防止连接到冒充的服务端
+func (s *scramClient) verifyServer(serverFinal string) error {
+	expected := hmacSHA256(hmacSHA256(s.saltedPassword, "Server Key"), s.authMessage)
+	got, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(serverFinal, "v="))
+	if err != nil || !hmac.Equal(got, expected) {
+		return errors.New("SCRAM 服务端签名校验失败")
+	}
+	return nil
+}
+
+func hmacSHA256(key []byte, msg string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(msg))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,137 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"servicetelemetry/config"
+)
+
+// defaultMaxRedirects 未配置 maxRedirects 时最多跟随的重定向次数，与 net/http 默认值一致
+const defaultMaxRedirects = 10
+
+// maxRedirectsLimit maxRedirects 允许配置的上限
+const maxRedirectsLimit = 30
+
+// RedirectHop 重定向链中的一跳
+type RedirectHop struct {
+	URL        string `json:"url"`        // 本跳请求的地址
+	StatusCode int    `json:"statusCode"` // 本跳响应的状态码（3xx）
+	Location   string `json:"location"`   // 重定向到的地址（已按本跳地址解析为绝对地址）
+}
+
+// RedirectError 重定向链异常：出现循环或超过最大重定向次数
+type RedirectError struct {
+	Reason string
+	Hops   []RedirectHop
+}
+
+func (e *RedirectError) Error() string {
+	return fmt.Sprintf("%s（共 %d 跳）", e.Reason, len(e.Hops))
+}
+
+// followRedirects 目标是否跟随重定向，默认跟随
+func (t *MonitorTarget) followRedirects() bool {
+	return t.FollowRedirects == nil || *t.FollowRedirects
+}
+
+// redirectRecorder 记录 HTTP 检查的重定向链，并按目标的重定向策略决定是否继续跟随
+type redirectRecorder struct {
+	follow  bool
+	max     int
+	hops    []RedirectHop
+	headers []string                // 目标配置的自定义请求头，跳转到其他主机时去掉
+	policy  *config.URLPolicyConfig // 外部提交的目标每一跳都执行的地址安全校验策略，为 nil 时不校验
+}
+
+// newRedirectRecorder 按目标配置创建重定向记录器
+// policy：地址安全校验策略，为 nil 时不校验跳转地址
+func newRedirectRecorder(target *MonitorTarget, policy *config.URLPolicyConfig) *redirectRecorder {
+	max := target.MaxRedirects
+	if max <= 0 {
+		max = defaultMaxRedirects
+	}
+	headers := make([]string, 0, len(target.Headers))
+	for name := range target.Headers {
+		headers = append(headers, name)
+	}
+	return &redirectRecorder{follow: target.followRedirects(), max: max, headers: headers, policy: policy}
+}
+
+// CheckRedirect 作为 http.Client.CheckRedirect：记录触发重定向的响应，
+// 不跟随时返回最后一个响应；重定向到已访问过的地址或超过次数上限时中止并返回 RedirectError
+func (r *redirectRecorder) CheckRedirect(req *http.Request, via []*http.Request) error {
+	prev := via[len(via)-1]
+	hop := RedirectHop{URL: prev.URL.String(), Location: req.URL.String()}
+	if req.Response != nil {
+		hop.StatusCode = req.Response.StatusCode
+	}
+	r.hops = append(r.hops, hop)
+
+	if !r.follow {
+		return http.ErrUseLastResponse
+	}
+	for _, v := range via {
+		if v.URL.String() == hop.Location {
+			return &RedirectError{Reason: "重定向循环：再次跳转到 " + hop.Location, Hops: r.hops}
+		}
+	}
+	if len(via) > r.max {
+		return &RedirectError{Reason: fmt.Sprintf("重定向次数超过上限 %d", r.max), Hops: r.hops}
+	}
+	// 跳转地址按主机名校验，解析后的 IP 由拨号器在连接时校验
+	if r.policy != nil {
+		if reason := checkPolicyHost(strings.ToLower(req.URL.Hostname()), r.policy); reason != "" {
+			return &RedirectError{Reason: "重定向地址被拒绝：" + reason, Hops: r.hops}
+		}
+	}
+	// HTTP 客户端跳转时会原样复制自定义请求头，跳转到其他主机时去掉，避免把 X-Api-Key 等凭据发给跳转后的主机
+	if !strings.EqualFold(req.URL.Hostname(), via[0].URL.Hostname()) {
+		for _, name := range r.headers {
+			req.Header.Del(name)
+		}
+	}
+	return nil
+}
+
+// stopped 是否因不跟随重定向而以 3xx 响应作为检查结果
+func (r *redirectRecorder) stopped() bool {
+	return !r.follow && len(r.hops) > 0
+}
+
+// record 将重定向链写入 HTTP 检查详情，跟随了重定向时同时记录最终地址
+func (r *redirectRecorder) record(details *HTTPDetails) {
+	if len(r.hops) == 0 {
+		return
+	}
+	details.Redirects = r.hops
+	if r.follow {
+		details.FinalURL = r.hops[len(r.hops)-1].Location
+	}
+}
+
+// redirectError 从请求错误中取出重定向链异常
+func redirectError(err error) (*RedirectError, bool) {
+	var re *RedirectError
+	ok := errors.As(err, &re)
+	return re, ok
+}
+
+// validateRedirects 校验重定向策略：仅适用于 HTTP/HTTPS 目标，maxRedirects 不能与不跟随同时配置
+func validateRedirects(target *MonitorTarget) error {
+	if target.FollowRedirects == nil && target.MaxRedirects == 0 {
+		return nil
+	}
+	if scheme := targetScheme(target.URL); scheme != "http" && scheme != "https" {
+		return fmt.Errorf("重定向策略仅适用于 HTTP/HTTPS 目标")
+	}
+	if target.MaxRedirects < 0 || target.MaxRedirects > maxRedirectsLimit {
+		return fmt.Errorf("maxRedirects 应为 0~%d", maxRedirectsLimit)
+	}
+	if target.MaxRedirects > 0 && !target.followRedirects() {
+		return fmt.Errorf("followRedirects 为 false 时不能配置 maxRedirects")
+	}
+	return nil
+}
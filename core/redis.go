@@ -0,0 +1,169 @@
+package core
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// parseRedisURL 解析 redis://host[:port] 形式的地址，默认端口 6379
+// 密码不写在地址中（地址会出现在日志、告警与导出结果中），通过目标的 credentials 配置
+func parseRedisURL(u *url.URL) (string, error) {
+	if u.Hostname() == "" {
+		return "", fmt.Errorf("Redis地址格式应为 redis://host:port")
+	}
+	if u.User != nil {
+		return "", fmt.Errorf("Redis地址中不能包含用户名或密码，请通过目标的 credentials（username / password）配置")
+	}
+	port := u.Port()
+	if port == "" {
+		port = "6379"
+	}
+	if _, err := strconv.ParseUint(port, 10, 16); err != nil {
+		return "", fmt.Errorf("无效的Redis端口：%s", port)
+	}
+	return net.JoinHostPort(u.Hostname(), port), nil
+}
+
+// checkRedis 检查Redis服务：配置了凭据时先发送 AUTH，再发送 PING，超时内未收到 PONG 即判定失败
+// 凭据取自目标的 credentials：password（必填）与 username（可选，Redis 6 ACL 用户）
+func (sc *ServiceChecker) checkRedis(target *MonitorTarget, result *MonitorResult) (error, ErrorType) {
+	u, err := url.Parse(target.URL)
+	if err != nil {
+		return fmt.Errorf("解析Redis地址失败：%w", err), ErrorTypeInvalid
+	}
+	address, err := parseRedisURL(u)
+	if err != nil {
+		return err, ErrorTypeInvalid
+	}
+	username, err := target.credential("username")
+	if err != nil {
+		return err, ErrorTypeInvalid
+	}
+	password, err := target.credential("password")
+	if err != nil {
+		return err, ErrorTypeInvalid
+	}
+	if username != "" && password == "" {
+		return errors.New("配置了 Redis 用户名但缺少 password 凭据"), ErrorTypeInvalid
+	}
+
+	timeout := sc.cfg.RedisTimeout
+	dialer := sc.newDialer(target, timeout)
+	conn, err := dialer.DialContext(context.Background(), "tcp", address)
+	result.recordDialAttempts(dialer.Attempts())
+	if err != nil {
+		var denied *EgressDeniedError
+		if errors.As(err, &denied) {
+			return denied, ErrorTypePolicy
+		}
+		if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+			return fmt.Errorf("Redis连接超时：%w", err), ErrorTypeTimeout
+		}
+		return fmt.Errorf("Redis连接失败：%w", err), ErrorTypeNetwork
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	details := &RedisDetails{Address: address}
+	result.details().Redis = details
+	reader := bufio.NewReader(conn)
+
+	if password != "" {
+		args := []string{"AUTH", password}
+		if username != "" {
+			args = []string{"AUTH", username, password}
+		}
+		details.Auth = true
+		reply, err := redisCommand(conn, reader, args...)
+		if err != nil {
+			return redisError("Redis认证", err)
+		}
+		if reply != "OK" {
+			return fmt.Errorf("Redis认证失败：%s", reply), ErrorTypeRedis
+		}
+	}
+
+	start := time.Now()
+	reply, err := redisCommand(conn, reader, "PING")
+	details.PingMs = float64(time.Since(start).Microseconds()) / 1000
+	if err != nil {
+		return redisError("Redis PING", err)
+	}
+	details.Reply = reply
+	if reply != "PONG" {
+		return fmt.Errorf("Redis PING 应答异常：%s", truncateBanner(reply)), ErrorTypeRedis
+	}
+	return nil, ""
+}
+
+// redisReplyError Redis 返回的错误应答（-ERR ...）
+type redisReplyError string
+
+func (e redisReplyError) Error() string { return string(e) }
+
+// redisCommand 以 RESP 数组格式发送命令并读取应答，支持简单字符串、错误、整数与单行批量字符串应答
+func redisCommand(conn net.Conn, reader *bufio.Reader, args ...string) (string, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if _, err := conn.Write([]byte(b.String())); err != nil {
+		return "", err
+	}
+
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	line = strings.TrimRight(line, "\r\n")
+	if line == "" {
+		return "", errors.New("应答为空")
+	}
+	switch line[0] {
+	case '+', ':':
+		return line[1:], nil
+	case '-':
+		return "", redisReplyError(line[1:])
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < 0 {
+			return "", fmt.Errorf("无法解析应答：%s", truncateBanner(line))
+		}
+		value, err := reader.ReadString('\n')
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimRight(value, "\r\n"), nil
+	}
+	return "", fmt.Errorf("不是Redis协议应答：%s", truncateBanner(line))
+}
+
+// redisError 区分超时、网络错误与服务端的错误应答（如 NOAUTH、WRONGPASS、LOADING）
+func redisError(action string, err error) (error, ErrorType) {
+	var reply redisReplyError
+	if errors.As(err, &reply) {
+		switch {
+		case strings.HasPrefix(string(reply), "NOAUTH"):
+			return fmt.Errorf("%s失败：服务端要求认证，请为目标配置 password 凭据", action), ErrorTypeRedis
+		case strings.HasPrefix(string(reply), "WRONGPASS"), strings.Contains(string(reply), "invalid password"):
+			return fmt.Errorf("%s失败：用户名或密码错误", action), ErrorTypeRedis
+		}
+		return fmt.Errorf("%s失败：%s", action, reply), ErrorTypeRedis
+	}
+	if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+		return fmt.Errorf("%s失败：等待应答超时", action), ErrorTypeTimeout
+	}
+	if errors.Is(err, io.EOF) {
+		return fmt.Errorf("%s失败：连接被服务端关闭", action), ErrorTypeNetwork
+	}
+	return fmt.Errorf("%s失败：%w", action, err), ErrorTypeNetwork
+}
@@ -0,0 +1,147 @@
+package core
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// StateChange 目标的一次状态变化
+type StateChange struct {
+	At time.Time // 变化时间
+	To string    // 变化后状态：up / down / degraded
+}
+
+// StateHistory 单个目标在统计窗口内的状态历史
+type StateHistory struct {
+	Initial string        // 窗口开始时（Start）的状态
+	Start   time.Time     // 开始统计的时间：窗口开始时间，目标在窗口内才开始检查时为首次检查时间
+	Changes []StateChange // 窗口内的状态变化，按时间升序
+}
+
+// TargetReliability 单个目标在统计窗口内的可靠性指标
+type TargetReliability struct {
+	TargetURL       string   `json:"targetUrl"`       // 目标地址
+	Tags            []string `json:"tags"`            // 目标标签
+	CurrentState    string   `json:"currentState"`    // 窗口结束时的状态
+	DowntimeSeconds float64  `json:"downtimeSeconds"` // 窗口内处于 down 状态的总时长（秒）
+	Availability    float64  `json:"availability"`    // 按时长计算的可用率（百分比）
+	Failures        int      `json:"failures"`        // 窗口内进入 down 状态的次数
+	Recoveries      int      `json:"recoveries"`      // 窗口内由 down 恢复的次数
+	MTTRSeconds     *float64 `json:"mttrSeconds"`     // 平均恢复时间（秒）：窗口内已恢复故障的平均持续时间，无恢复时为 null
+	MTBFSeconds     *float64 `json:"mtbfSeconds"`     // 平均故障间隔（秒）：窗口内非故障时长 / 故障次数，无故障时为 null
+
+	repairSeconds float64 // 已恢复故障的总时长，用于按标签汇总
+	upSeconds     float64 // 非故障总时长，用于按标签汇总
+}
+
+// TagReliability 标签维度的可靠性指标汇总（按时长与次数合并计算，而不是平均各目标的指标）
+type TagReliability struct {
+	Tag             string   `json:"tag"`             // 标签
+	Targets         int      `json:"targets"`         // 标签下的目标数
+	DowntimeSeconds float64  `json:"downtimeSeconds"` // 标签下各目标故障时长之和（秒）
+	Failures        int      `json:"failures"`        // 标签下各目标故障次数之和
+	MTTRSeconds     *float64 `json:"mttrSeconds"`     // 平均恢复时间（秒）
+	MTBFSeconds     *float64 `json:"mtbfSeconds"`     // 平均故障间隔（秒）
+	WorstTarget     string   `json:"worstTarget"`     // 故障时长最长的目标
+}
+
+// ComputeReliability 根据状态历史计算目标在 [history.Start, until) 内的故障时长、MTTR 与 MTBF
+// 窗口开始时已处于故障的区间按窗口开始时间截断；窗口结束时仍未恢复的故障计入故障时长，但不计入 MTTR
+func ComputeReliability(targetURL string, history *StateHistory, until time.Time) *TargetReliability {
+	r := &TargetReliability{TargetURL: targetURL, CurrentState: history.Initial}
+	state, since := history.Initial, history.Start
+	var downSince time.Time
+	if state == StateDown {
+		downSince = since
+	}
+
+	advance := func(at time.Time) {
+		d := at.Sub(since).Seconds()
+		if d < 0 {
+			d = 0
+		}
+		if state == StateDown {
+			r.DowntimeSeconds += d
+		} else {
+			r.upSeconds += d
+		}
+		since = at
+	}
+	for _, ch := range history.Changes {
+		if ch.At.Before(history.Start) || !ch.At.Before(until) || ch.To == state {
+			continue
+		}
+		advance(ch.At)
+		switch {
+		case ch.To == StateDown:
+			r.Failures++
+			downSince = ch.At
+		case state == StateDown:
+			r.Recoveries++
+			r.repairSeconds += ch.At.Sub(downSince).Seconds()
+		}
+		state = ch.To
+	}
+	advance(until)
+	r.CurrentState = state
+
+	if total := r.DowntimeSeconds + r.upSeconds; total > 0 {
+		r.Availability = math.Round(r.upSeconds/total*10000) / 100
+	}
+	if r.Recoveries > 0 {
+		mttr := r.repairSeconds / float64(r.Recoveries)
+		r.MTTRSeconds = &mttr
+	}
+	if r.Failures > 0 {
+		mtbf := r.upSeconds / float64(r.Failures)
+		r.MTBFSeconds = &mtbf
+	}
+	return r
+}
+
+// AggregateReliability 按标签汇总各目标的可靠性指标，故障时长最长的标签在前
+// tags：目标地址 -> 标签
+func AggregateReliability(targets []*TargetReliability, tags map[string][]string) []*TagReliability {
+	byTag := make(map[string]*TagReliability)
+	repair, up, recoveries := make(map[string]float64), make(map[string]float64), make(map[string]int)
+	worst := make(map[string]float64)
+	for _, t := range targets {
+		for _, tag := range tags[t.TargetURL] {
+			agg, ok := byTag[tag]
+			if !ok {
+				agg = &TagReliability{Tag: tag}
+				byTag[tag] = agg
+			}
+			agg.Targets++
+			agg.DowntimeSeconds += t.DowntimeSeconds
+			agg.Failures += t.Failures
+			repair[tag] += t.repairSeconds
+			up[tag] += t.upSeconds
+			recoveries[tag] += t.Recoveries
+			if agg.WorstTarget == "" || t.DowntimeSeconds > worst[tag] {
+				agg.WorstTarget, worst[tag] = t.TargetURL, t.DowntimeSeconds
+			}
+		}
+	}
+
+	list := make([]*TagReliability, 0, len(byTag))
+	for tag, agg := range byTag {
+		if recoveries[tag] > 0 {
+			mttr := repair[tag] / float64(recoveries[tag])
+			agg.MTTRSeconds = &mttr
+		}
+		if agg.Failures > 0 {
+			mtbf := up[tag] / float64(agg.Failures)
+			agg.MTBFSeconds = &mtbf
+		}
+		list = append(list, agg)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].DowntimeSeconds != list[j].DowntimeSeconds {
+			return list[i].DowntimeSeconds > list[j].DowntimeSeconds
+		}
+		return list[i].Tag < list[j].Tag
+	})
+	return list
+}
@@ -0,0 +1,108 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// resolverFragment 地址片段中指定解析服务器的前缀，如 https://app.example.com/health#resolver=10.0.0.53
+// 片段不会随请求发送，同一主机名的不同解析视图（split-horizon）因此可以作为不同的目标分别检查
+const resolverFragment = "resolver="
+
+// fragmentResolver 返回地址片段中指定的解析服务器（未规范化），未指定时返回空字符串
+func fragmentResolver(targetURL string) string {
+	u, err := url.Parse(strings.TrimSpace(targetURL))
+	if err != nil || !strings.HasPrefix(u.Fragment, resolverFragment) {
+		return ""
+	}
+	return strings.TrimPrefix(u.Fragment, resolverFragment)
+}
+
+// targetResolver 返回目标解析主机名使用的解析服务器 ip:port：地址片段优先，其次为 resolver 选项，均未指定时返回空字符串（使用系统解析）
+func targetResolver(target *MonitorTarget) (string, error) {
+	resolver := fragmentResolver(target.URL)
+	if resolver == "" {
+		resolver = target.Resolver
+	}
+	if resolver == "" {
+		return "", nil
+	}
+	return normalizeResolver(resolver)
+}
+
+// normalizeResolver 校验并规范化解析服务器地址 ip[:port]，缺少端口时使用 53
+func normalizeResolver(resolver string) (string, error) {
+	host, port, err := net.SplitHostPort(resolver)
+	if err != nil {
+		host, port = strings.Trim(resolver, "[]"), "53"
+	}
+	if net.ParseIP(host) == nil {
+		return "", fmt.Errorf("无效的解析服务器：%s，应为 ip[:port]", resolver)
+	}
+	if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+		return "", fmt.Errorf("无效的解析服务器端口：%s", resolver)
+	}
+	return net.JoinHostPort(host, port), nil
+}
+
+// resolverVia 创建只向指定解析服务器查询的解析器（/etc/hosts 仍然优先）
+// dial：连接解析服务器使用的拨号函数
+func resolverVia(addr string, dial func(ctx context.Context, network, address string) (net.Conn, error)) *net.Resolver {
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			return dial(ctx, network, addr)
+		},
+	}
+}
+
+// useResolver 使拨号器经由指定的解析服务器解析主机名；连接解析服务器时同样使用源地址并执行出站网络策略
+func (d *probeDialer) useResolver(addr string) {
+	d.resolverAddr = addr
+	d.resolver = resolverVia(addr, func(ctx context.Context, network, address string) (net.Conn, error) {
+		ip := resolverIP(address)
+		if err := d.checkIP(address, ip, false); err != nil {
+			return nil, err
+		}
+		if ip != nil && d.urlPolicy != nil && IsBlockedIP(ip, d.urlPolicy) && !hostAllowed(ip.String(), d.urlPolicy) {
+			return nil, &EgressDeniedError{Address: address, Reason: fmt.Sprintf("禁止使用受保护的内网解析服务器 %s", ip)}
+		}
+		dialer := &net.Dialer{Timeout: d.timeout}
+		if d.localAddr != nil {
+			if strings.HasPrefix(network, "udp") {
+				dialer.LocalAddr = &net.UDPAddr{IP: d.localAddr}
+			} else {
+				dialer.LocalAddr = &net.TCPAddr{IP: d.localAddr}
+			}
+		}
+		return dialer.DialContext(ctx, network, address)
+	})
+}
+
+// resolverError 将解析错误中的解析服务器改为目标指定的解析服务器（Go 解析器报告的是 /etc/resolv.conf 中的地址）
+func (d *probeDialer) resolverError(err error) error {
+	var dnsErr *net.DNSError
+	if d.resolverAddr != "" && errors.As(err, &dnsErr) {
+		dnsErr.Server = d.resolverAddr
+	}
+	return err
+}
+
+// validateResolver 校验目标指定的解析服务器：格式正确，且经由代理检查时由代理解析主机名，不能同时指定
+func validateResolver(target *MonitorTarget) error {
+	if _, err := targetResolver(target); err != nil {
+		return err
+	}
+	if fragmentResolver(target.URL) == "" && target.Resolver == "" {
+		return nil
+	}
+	if target.Proxy != "" && target.Proxy != ProxyDirect {
+		return fmt.Errorf("经由代理检查时由代理解析主机名，不能同时指定解析服务器")
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package core
+
+import (
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// 响应快照的大小限制
+const (
+	defaultSnapshotBodyBytes = 4096     // 未配置 maxBodyBytes 时保存的响应体字节数
+	maxSnapshotBodyBytes     = 64 << 10 // maxBodyBytes 的上限
+	maxSnapshotHeaders       = 50       // 最多保存的响应头数量
+	maxSnapshotHeaderLength  = 512      // 单个响应头值保存的最大字节数
+)
+
+// redactedHeaderValue 敏感响应头在快照中的替代值
+const redactedHeaderValue = "[redacted]"
+
+// snapshotSensitiveHeaders 快照中不保存值的响应头（可能带有会话或凭据）
+var snapshotSensitiveHeaders = map[string]bool{
+	"Set-Cookie":          true,
+	"Authorization":       true,
+	"Proxy-Authenticate":  true,
+	"Www-Authenticate":    true,
+	"Proxy-Authorization": true,
+}
+
+// ResponseSnapshot HTTP 检查失败时服务器实际返回的内容（截断），随结果单独入库
+type ResponseSnapshot struct {
+	StatusCode int               `json:"statusCode"`          // 响应状态码
+	Protocol   string            `json:"protocol,omitempty"`  // 响应协议，如 HTTP/1.1
+	Headers    map[string]string `json:"headers,omitempty"`   // 响应头（同名多值以逗号连接，敏感响应头的值不保存）
+	Body       string            `json:"body,omitempty"`      // 响应体（截断到 monitor.responseSnapshot.maxBodyBytes），二进制内容不保存
+	BodyBytes  int               `json:"bodyBytes"`           // 保存的响应体字节数
+	Truncated  bool              `json:"truncated,omitempty"` // 响应体是否被截断
+	Binary     bool              `json:"binary,omitempty"`    // 响应为二进制内容，未保存响应体
+}
+
+// captureSnapshot 检查失败时保存响应快照：响应体已读取时直接截取，未读取时从响应中再读取至多 maxBodyBytes 字节
+// 外部提交的目标不保存快照，避免调用方借助快照读取检查器所在网络中的响应内容
+func (sc *ServiceChecker) captureSnapshot(target *MonitorTarget, resp *http.Response, body []byte, bodyRead bool, result *MonitorResult) {
+	cfg := sc.cfg.ResponseSnapshot
+	if !cfg.Enable || resp == nil || target.External() {
+		return
+	}
+	limit := cfg.MaxBodyBytes
+	if limit <= 0 {
+		limit = defaultSnapshotBodyBytes
+	}
+	if limit > maxSnapshotBodyBytes {
+		limit = maxSnapshotBodyBytes
+	}
+
+	snap := &ResponseSnapshot{StatusCode: resp.StatusCode, Protocol: resp.Proto, Headers: snapshotHeaders(resp.Header)}
+	switch {
+	case isBinaryContentType(resp.Header.Get("Content-Type")):
+		snap.Binary = true
+	case bodyRead:
+		snap.Truncated = len(body) > limit
+		if snap.Truncated {
+			body = body[:limit]
+		}
+	default:
+		// 多读一个字节用于判断是否截断；读取失败时保留已读到的部分
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, int64(limit)+1))
+		snap.Truncated = len(data) > limit
+		if snap.Truncated {
+			data = data[:limit]
+		}
+		body = data
+	}
+	if !snap.Binary {
+		snap.Body = strings.ToValidUTF8(string(trimPartialRune(body)), "�")
+		snap.BodyBytes = len(snap.Body)
+	}
+	result.Snapshot = snap
+}
+
+// snapshotHeaders 整理响应头：同名多值以逗号连接，敏感响应头只保留名称，值超长时截断，最多保存 maxSnapshotHeaders 个
+func snapshotHeaders(h http.Header) map[string]string {
+	if len(h) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(h))
+	for name := range h {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if len(names) > maxSnapshotHeaders {
+		names = names[:maxSnapshotHeaders]
+	}
+	headers := make(map[string]string, len(names))
+	for _, name := range names {
+		if snapshotSensitiveHeaders[http.CanonicalHeaderKey(name)] {
+			headers[name] = redactedHeaderValue
+			continue
+		}
+		value := strings.Join(h[name], ", ")
+		if len(value) > maxSnapshotHeaderLength {
+			value = string(trimPartialRune([]byte(value[:maxSnapshotHeaderLength]))) + "…"
+		}
+		headers[name] = value
+	}
+	return headers
+}
+
+// trimPartialRune 去掉截断在末尾留下的不完整 UTF-8 字符
+func trimPartialRune(b []byte) []byte {
+	for i := 0; i < utf8.UTFMax && len(b) > 0; i++ {
+		r, size := utf8.DecodeLastRune(b)
+		if r != utf8.RuneError || size != 1 {
+			break
+		}
+		b = b[:len(b)-1]
+	}
+	return b
+}
@@ -0,0 +1,381 @@
+package core
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// SFTP 协议（draft-ietf-secsh-filexfer-02，版本 3）的报文类型与状态码
+const (
+	sftpInit     = 1
+	sftpVersion  = 2
+	sftpClose    = 4
+	sftpOpenDir  = 11
+	sftpReadDir  = 12
+	sftpStatus   = 101
+	sftpHandle   = 102
+	sftpName     = 104
+	sftpStatusOK = 0
+	sftpEOF      = 1
+)
+
+// maxSFTPPacket 允许的最大响应报文长度，防止异常服务端导致内存占用过大
+const maxSFTPPacket = 1 << 20
+
+// sftpStatusText SFTP 状态码说明
+var sftpStatusText = map[uint32]string{
+	2: "路径不存在",
+	3: "没有权限",
+	4: "操作失败",
+	5: "报文格式错误",
+	8: "不支持的操作",
+}
+
+// checkSFTP 检查SFTP服务：完成SSH握手与认证、打开 sftp 子系统并列出目录，分别记录各阶段耗时
+// 凭据取自目标的 credentials：username（必填），password 或 privateKey（PEM 私钥）；
+// 固定了主机公钥指纹（地址参数 fingerprint 或目标的 ssh.fingerprints）时校验指纹，否则只记录
+func (sc *ServiceChecker) checkSFTP(target *MonitorTarget, result *MonitorResult) (error, ErrorType) {
+	u, err := url.Parse(target.URL)
+	if err != nil {
+		return fmt.Errorf("解析SFTP地址失败：%w", err), ErrorTypeInvalid
+	}
+	address, path, err := parseFileTransferURL(u)
+	if err != nil {
+		return err, ErrorTypeInvalid
+	}
+	_, fingerprints, err := parseSSHURL(u)
+	if err != nil {
+		return err, ErrorTypeInvalid
+	}
+	if len(fingerprints) == 0 && target.SSH != nil {
+		fingerprints = target.SSH.Fingerprints
+	}
+	username, password, err := fileTransferCredentials(target)
+	if err != nil {
+		return err, ErrorTypeInvalid
+	}
+	if username == "" {
+		return errors.New("SFTP检查需要为目标配置 username 凭据"), ErrorTypeInvalid
+	}
+	auth := []ssh.AuthMethod{}
+	privateKey, err := target.credential("privateKey")
+	if err != nil {
+		return err, ErrorTypeInvalid
+	}
+	if privateKey != "" {
+		signer, err := ssh.ParsePrivateKey([]byte(privateKey))
+		if err != nil {
+			return fmt.Errorf("解析 privateKey 凭据失败：%w", err), ErrorTypeInvalid
+		}
+		auth = append(auth, ssh.PublicKeys(signer))
+	}
+	if password != "" {
+		auth = append(auth, ssh.Password(password))
+	}
+	if len(auth) == 0 {
+		return errors.New("SFTP检查需要为目标配置 password 或 privateKey 凭据"), ErrorTypeInvalid
+	}
+
+	timeout := sc.cfg.FileTransferTimeout
+	dialer := sc.newDialer(target, timeout)
+	start := time.Now()
+	conn, err := dialer.DialContext(context.Background(), "tcp", address)
+	result.recordDialAttempts(dialer.Attempts())
+	if err != nil {
+		return fileTransferDialError("SFTP", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(timeout))
+	details := &FileTransferDetails{Protocol: "sftp", Address: address, User: username, Path: path}
+	result.details().FileTransfer = details
+	details.ConnectMs = durationMs(time.Since(start))
+
+	mismatch := false
+	cfg := &ssh.ClientConfig{
+		User: username,
+		Auth: auth,
+		HostKeyCallback: func(_ string, _ net.Addr, key ssh.PublicKey) error {
+			details.Fingerprint = ssh.FingerprintSHA256(key)
+			if len(fingerprints) == 0 {
+				return nil
+			}
+			for _, fp := range fingerprints {
+				if fp == details.Fingerprint {
+					return nil
+				}
+			}
+			mismatch = true
+			return errors.New("host key mismatch")
+		},
+		Timeout: timeout,
+	}
+	start = time.Now()
+	sshConn, chans, reqs, err := ssh.NewClientConn(conn, address, cfg)
+	if err != nil {
+		switch {
+		case mismatch:
+			return fmt.Errorf("SSH主机公钥指纹不匹配：%s", details.Fingerprint), ErrorTypeSFTP
+		case isTimeout(err):
+			return errors.New("SSH握手或认证超时"), ErrorTypeTimeout
+		}
+		return fmt.Errorf("SSH握手或认证失败：%w", err), ErrorTypeSFTP
+	}
+	client := ssh.NewClient(sshConn, chans, reqs)
+	defer client.Close()
+	details.Banner = truncateBanner(string(sshConn.ServerVersion()))
+	details.LoginMs = durationMs(time.Since(start))
+
+	start = time.Now()
+	session, err := client.NewSession()
+	if err != nil {
+		return sftpError("打开SSH会话失败", err)
+	}
+	defer session.Close()
+	stdin, err := session.StdinPipe()
+	if err != nil {
+		return sftpError("打开SSH会话失败", err)
+	}
+	stdout, err := session.StdoutPipe()
+	if err != nil {
+		return sftpError("打开SSH会话失败", err)
+	}
+	if err := session.RequestSubsystem("sftp"); err != nil {
+		return sftpError("服务端未启用 sftp 子系统", err)
+	}
+	s := &sftpSession{w: stdin, r: bufio.NewReader(stdout)}
+	entries, err := s.list(path)
+	if err != nil {
+		return sftpError("列出目录失败", err)
+	}
+	details.Entries = entries
+	details.ListMs = durationMs(time.Since(start))
+	return nil, ""
+}
+
+// sftpSession 在 sftp 子系统上收发报文的最小实现，只支持列目录所需的请求
+type sftpSession struct {
+	w  io.Writer
+	r  io.Reader
+	id uint32
+}
+
+// sftpStatusError 服务端返回的非成功状态
+type sftpStatusError struct {
+	code uint32
+	msg  string
+}
+
+func (e *sftpStatusError) Error() string {
+	text := sftpStatusText[e.code]
+	if text == "" {
+		text = fmt.Sprintf("状态码 %d", e.code)
+	}
+	if e.msg != "" {
+		return text + "：" + e.msg
+	}
+	return text
+}
+
+// list 协商版本后打开目录并读取全部条目，返回条目数（不含 . 与 ..）
+func (s *sftpSession) list(path string) (int, error) {
+	if err := s.send(sftpInit, binary.BigEndian.AppendUint32(nil, 3)); err != nil {
+		return 0, err
+	}
+	typ, _, err := s.recv()
+	if err != nil {
+		return 0, err
+	}
+	if typ != sftpVersion {
+		return 0, fmt.Errorf("意外的响应类型 %d（期望 VERSION）", typ)
+	}
+
+	handle, err := s.request(sftpOpenDir, sftpString(nil, path), sftpHandle)
+	if err != nil {
+		return 0, err
+	}
+	defer s.request(sftpClose, handle, sftpStatus)
+
+	entries := 0
+	for {
+		payload, err := s.request(sftpReadDir, handle, sftpName)
+		var statusErr *sftpStatusError
+		if errors.As(err, &statusErr) && statusErr.code == sftpEOF {
+			return entries, nil
+		}
+		if err != nil {
+			return entries, err
+		}
+		names, err := sftpNames(payload)
+		if err != nil {
+			return entries, err
+		}
+		entries += names
+	}
+}
+
+// request 发送带请求ID的报文并读取响应；响应为 STATUS 时返回对应错误（期望 STATUS 且状态为 OK 时除外）
+// 返回去掉请求ID后的响应内容；handle 等参数已按 SFTP 字符串编码
+func (s *sftpSession) request(typ byte, body []byte, expect byte) ([]byte, error) {
+	s.id++
+	if err := s.send(typ, append(binary.BigEndian.AppendUint32(nil, s.id), body...)); err != nil {
+		return nil, err
+	}
+	rtyp, payload, err := s.recv()
+	if err != nil {
+		return nil, err
+	}
+	if len(payload) < 4 || binary.BigEndian.Uint32(payload) != s.id {
+		return nil, errors.New("响应的请求ID不匹配")
+	}
+	payload = payload[4:]
+	if rtyp == sftpStatus {
+		if len(payload) < 4 {
+			return nil, errors.New("STATUS 报文格式错误")
+		}
+		code := binary.BigEndian.Uint32(payload)
+		if code == sftpStatusOK && expect == sftpStatus {
+			return nil, nil
+		}
+		msg, _, _ := sftpReadString(payload[4:])
+		return nil, &sftpStatusError{code: code, msg: msg}
+	}
+	if rtyp != expect {
+		return nil, fmt.Errorf("意外的响应类型 %d", rtyp)
+	}
+	if expect == sftpHandle {
+		// 直接返回编码后的 handle，供后续请求原样使用
+		if _, _, err := sftpReadString(payload); err != nil {
+			return nil, err
+		}
+	}
+	return payload, nil
+}
+
+// send 发送一个报文：uint32 长度 + 类型 + 内容
+func (s *sftpSession) send(typ byte, body []byte) error {
+	packet := binary.BigEndian.AppendUint32(nil, uint32(len(body)+1))
+	packet = append(packet, typ)
+	_, err := s.w.Write(append(packet, body...))
+	return err
+}
+
+// recv 读取一个报文，返回类型与内容
+func (s *sftpSession) recv() (byte, []byte, error) {
+	var header [5]byte
+	if _, err := io.ReadFull(s.r, header[:]); err != nil {
+		return 0, nil, err
+	}
+	length := binary.BigEndian.Uint32(header[:4])
+	if length == 0 || length > maxSFTPPacket {
+		return 0, nil, fmt.Errorf("无效的报文长度：%d", length)
+	}
+	payload := make([]byte, length-1)
+	if _, err := io.ReadFull(s.r, payload); err != nil {
+		return 0, nil, err
+	}
+	return header[4], payload, nil
+}
+
+// sftpNames 解析 NAME 响应，返回除 . 与 .. 外的条目数
+func sftpNames(payload []byte) (int, error) {
+	if len(payload) < 4 {
+		return 0, errors.New("NAME 报文格式错误")
+	}
+	count, rest := binary.BigEndian.Uint32(payload), payload[4:]
+	names := 0
+	for i := uint32(0); i < count; i++ {
+		name, next, err := sftpReadString(rest)
+		if err != nil {
+			return 0, err
+		}
+		if _, next, err = sftpReadString(next); err != nil { // longname
+			return 0, err
+		}
+		if rest, err = sftpSkipAttrs(next); err != nil {
+			return 0, err
+		}
+		if name != "." && name != ".." {
+			names++
+		}
+	}
+	return names, nil
+}
+
+// sftpSkipAttrs 跳过文件属性（ATTRS），各字段是否存在由 flags 决定
+func sftpSkipAttrs(b []byte) ([]byte, error) {
+	if len(b) < 4 {
+		return nil, errors.New("ATTRS 格式错误")
+	}
+	flags, b := binary.BigEndian.Uint32(b), b[4:]
+	size := 0
+	if flags&0x1 != 0 { // size
+		size += 8
+	}
+	if flags&0x2 != 0 { // uid、gid
+		size += 8
+	}
+	if flags&0x4 != 0 { // permissions
+		size += 4
+	}
+	if flags&0x8 != 0 { // atime、mtime
+		size += 8
+	}
+	if len(b) < size {
+		return nil, errors.New("ATTRS 格式错误")
+	}
+	b = b[size:]
+	if flags&0x80000000 != 0 { // 扩展属性：count 对字符串
+		if len(b) < 4 {
+			return nil, errors.New("ATTRS 格式错误")
+		}
+		count := binary.BigEndian.Uint32(b)
+		b = b[4:]
+		for i := uint32(0); i < count*2; i++ {
+			var err error
+			if _, b, err = sftpReadString(b); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return b, nil
+}
+
+// sftpString 追加 SFTP 字符串（uint32 长度 + 内容）
+func sftpString(b []byte, s string) []byte {
+	return append(binary.BigEndian.AppendUint32(b, uint32(len(s))), s...)
+}
+
+// sftpReadString 读取 SFTP 字符串，返回内容与剩余部分
+func sftpReadString(b []byte) (string, []byte, error) {
+	if len(b) < 4 {
+		return "", nil, errors.New("字符串格式错误")
+	}
+	n := binary.BigEndian.Uint32(b)
+	if uint64(len(b)-4) < uint64(n) {
+		return "", nil, errors.New("字符串长度超出报文")
+	}
+	return string(b[4 : 4+n]), b[4+n:], nil
+}
+
+// sftpError 区分超时、服务端状态与其他错误
+func sftpError(action string, err error) (error, ErrorType) {
+	if isTimeout(err) {
+		return fmt.Errorf("%s：等待响应超时", action), ErrorTypeTimeout
+	}
+	return fmt.Errorf("%s：%w", action, err), ErrorTypeSFTP
+}
+
+// isTimeout 判断错误是否为网络超时
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
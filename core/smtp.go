@@ -0,0 +1,224 @@
+package core
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/textproto"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// STARTTLS 策略
+const (
+	SMTPStartTLSAuto    = "auto"    // 服务端支持时升级（默认）
+	SMTPStartTLSRequire = "require" // 必须支持 STARTTLS，否则判定失败
+	SMTPStartTLSOff     = "off"     // 不升级，仅检查问候与 EHLO
+)
+
+// smtpQuery SMTP 检查参数
+type smtpQuery struct {
+	address  string // host:port
+	implicit bool   // smtps://，连接建立后直接 TLS 握手
+	startTLS string // STARTTLS 策略
+}
+
+// parseSMTPURL 解析 smtp://host[:port]?starttls=require 形式的地址
+// smtp:// 默认端口 25，smtps://（隐式 TLS）默认端口 465；smtps:// 不支持 starttls 参数
+func parseSMTPURL(u *url.URL) (*smtpQuery, error) {
+	if u.Hostname() == "" {
+		return nil, fmt.Errorf("SMTP地址格式应为 %s://host:port", u.Scheme)
+	}
+	q := &smtpQuery{implicit: strings.EqualFold(u.Scheme, "smtps"), startTLS: SMTPStartTLSAuto}
+	port := u.Port()
+	switch {
+	case port == "" && q.implicit:
+		port = "465"
+	case port == "":
+		port = "25"
+	}
+	if _, err := strconv.ParseUint(port, 10, 16); err != nil {
+		return nil, fmt.Errorf("无效的SMTP端口：%s", port)
+	}
+	q.address = net.JoinHostPort(u.Hostname(), port)
+
+	if v := u.Query().Get("starttls"); v != "" {
+		switch v {
+		case SMTPStartTLSAuto, SMTPStartTLSRequire, SMTPStartTLSOff:
+		default:
+			return nil, fmt.Errorf("无效的 starttls：%s，可选 auto / require / off", v)
+		}
+		if q.implicit {
+			return nil, fmt.Errorf("smtps:// 已使用隐式 TLS，不支持 starttls 参数")
+		}
+		q.startTLS = v
+	}
+	return q, nil
+}
+
+// checkSMTP 检查SMTP服务：连接后读取问候（220），发送 EHLO，服务端支持时执行 STARTTLS 并校验证书，最后 QUIT
+// 问候耗时（连接建立或 smtps:// 的 TLS 握手完成到收到问候）记录在 details.smtp.greetingMs；证书信息与 HTTPS 检查一样记录
+func (sc *ServiceChecker) checkSMTP(target *MonitorTarget, result *MonitorResult) (error, ErrorType) {
+	u, err := url.Parse(target.URL)
+	if err != nil {
+		return fmt.Errorf("解析SMTP地址失败：%w", err), ErrorTypeInvalid
+	}
+	q, err := parseSMTPURL(u)
+	if err != nil {
+		return err, ErrorTypeInvalid
+	}
+
+	timeout := sc.cfg.SMTP.Timeout
+	dialer := sc.newDialer(target, timeout)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	conn, err := dialer.DialContext(ctx, "tcp", q.address)
+	result.recordDialAttempts(dialer.Attempts())
+	if err != nil {
+		var denied *EgressDeniedError
+		if errors.As(err, &denied) {
+			return denied, ErrorTypePolicy
+		}
+		if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+			return fmt.Errorf("SMTP连接超时：%w", err), ErrorTypeTimeout
+		}
+		return fmt.Errorf("SMTP连接失败：%w", err), ErrorTypeNetwork
+	}
+	defer func() { conn.Close() }()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	details := &SMTPDetails{Address: q.address, StartTLS: q.startTLS}
+	result.details().SMTP = details
+
+	handshake := func() (error, ErrorType) {
+		tlsConfig, profile, err := sc.tlsConfig(target)
+		if err != nil {
+			return err, ErrorTypeInvalid
+		}
+		if tlsConfig.ServerName == "" {
+			tlsConfig.ServerName = u.Hostname()
+		}
+		tc := tls.Client(conn, tlsConfig)
+		if err := tc.HandshakeContext(ctx); err != nil {
+			return smtpError("TLS握手失败", err, ErrorTypeSSL)
+		}
+		state := tc.ConnectionState()
+		sc.recordTLS(target, profile, &state, result)
+		conn, details.TLS = tc, true
+		return nil, ""
+	}
+	if q.implicit {
+		if err, errType := handshake(); err != nil {
+			return err, errType
+		}
+	}
+
+	start := time.Now()
+	tp := textproto.NewConn(conn)
+	code, banner, err := tp.ReadResponse(220)
+	details.GreetingMs = float64(time.Since(start).Microseconds()) / 1000
+	details.Banner = firstLine(banner)
+	result.StatusCode = code
+	if err != nil {
+		return smtpError("SMTP问候异常", err, ErrorTypeSMTP)
+	}
+
+	extensions, err, errType := smtpHello(tp, sc.cfg.SMTP.EHLOName)
+	if err != nil {
+		return err, errType
+	}
+	details.Extensions = extensions
+
+	if !q.implicit && q.startTLS != SMTPStartTLSOff {
+		supported := false
+		for _, ext := range extensions {
+			if strings.EqualFold(ext, "STARTTLS") {
+				supported = true
+				break
+			}
+		}
+		switch {
+		case supported:
+			id, err := tp.Cmd("STARTTLS")
+			if err != nil {
+				return smtpError("发送STARTTLS失败", err, ErrorTypeNetwork)
+			}
+			tp.StartResponse(id)
+			_, _, err = tp.ReadResponse(220)
+			tp.EndResponse(id)
+			if err != nil {
+				return smtpError("STARTTLS被拒绝", err, ErrorTypeSMTP)
+			}
+			if err, errType := handshake(); err != nil {
+				return err, errType
+			}
+			// 升级后需重新 EHLO，服务端可能通告不同的扩展
+			tp = textproto.NewConn(conn)
+			extensions, err, errType := smtpHello(tp, sc.cfg.SMTP.EHLOName)
+			if err != nil {
+				return err, errType
+			}
+			details.Extensions = extensions
+		case q.startTLS == SMTPStartTLSRequire:
+			return errors.New("SMTP服务未通告 STARTTLS"), ErrorTypeSMTP
+		default:
+			result.addWarning("SMTP服务不支持 STARTTLS，邮件将以明文传输")
+		}
+	}
+
+	// QUIT 失败不影响检查结论
+	if id, err := tp.Cmd("QUIT"); err == nil {
+		tp.StartResponse(id)
+		tp.ReadResponse(221)
+		tp.EndResponse(id)
+	}
+	return nil, ""
+}
+
+// smtpHello 发送 EHLO 并返回服务端通告的扩展（如 STARTTLS、SIZE 35882577）
+func smtpHello(tp *textproto.Conn, name string) ([]string, error, ErrorType) {
+	id, err := tp.Cmd("EHLO %s", name)
+	if err != nil {
+		err, errType := smtpError("发送EHLO失败", err, ErrorTypeNetwork)
+		return nil, err, errType
+	}
+	tp.StartResponse(id)
+	defer tp.EndResponse(id)
+	_, msg, err := tp.ReadResponse(250)
+	if err != nil {
+		err, errType := smtpError("EHLO被拒绝", err, ErrorTypeSMTP)
+		return nil, err, errType
+	}
+	// 第一行为服务端的主机名问候，其余每行一个扩展
+	lines := strings.Split(msg, "\n")
+	return lines[1:], nil, ""
+}
+
+// smtpError 区分超时、网络错误与服务端的错误应答
+func smtpError(action string, err error, errType ErrorType) (error, ErrorType) {
+	var protoErr *textproto.Error
+	if errors.As(err, &protoErr) {
+		return fmt.Errorf("%s：%d %s", action, protoErr.Code, protoErr.Msg), ErrorTypeSMTP
+	}
+	if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+		return fmt.Errorf("%s：等待响应超时", action), ErrorTypeTimeout
+	}
+	if errType == ErrorTypeSSL && !strings.Contains(err.Error(), "certificate") {
+		errType = ErrorTypeNetwork
+	}
+	return fmt.Errorf("%s：%w", action, err), errType
+}
+
+// firstLine 多行应答只取第一行
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
@@ -0,0 +1,171 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// maxSSHPreambleLines 版本标识之前允许的最多文本行数（RFC 4253 允许服务端在版本标识前发送其他行）
+const maxSSHPreambleLines = 20
+
+// parseSSHURL 解析 ssh://host[:port]?fingerprint=SHA256:... 形式的地址，默认端口 22，返回地址与固定的指纹
+// fingerprint 参数可重复或用逗号分隔
+func parseSSHURL(u *url.URL) (string, []string, error) {
+	if u.Hostname() == "" {
+		return "", nil, fmt.Errorf("SSH地址格式应为 ssh://host:port")
+	}
+	port := u.Port()
+	if port == "" {
+		port = "22"
+	}
+	if _, err := strconv.ParseUint(port, 10, 16); err != nil {
+		return "", nil, fmt.Errorf("无效的SSH端口：%s", port)
+	}
+
+	var fingerprints []string
+	for _, v := range u.Query()["fingerprint"] {
+		for _, fp := range strings.Split(v, ",") {
+			if fp = strings.TrimSpace(fp); fp != "" {
+				fingerprints = append(fingerprints, fp)
+			}
+		}
+	}
+	if err := validateSSHFingerprints(fingerprints); err != nil {
+		return "", nil, err
+	}
+	return net.JoinHostPort(u.Hostname(), port), fingerprints, nil
+}
+
+// validateSSHFingerprints 校验指纹格式（SHA256:base64，与 ssh-keygen -lf 输出一致）
+func validateSSHFingerprints(fingerprints []string) error {
+	for _, fp := range fingerprints {
+		if !strings.HasPrefix(fp, "SHA256:") || len(fp) <= len("SHA256:") {
+			return fmt.Errorf("无效的主机公钥指纹：%s，格式应为 SHA256:...", fp)
+		}
+	}
+	return nil
+}
+
+// checkSSH 检查SSH服务：建立TCP连接并读取服务端版本标识（SSH-2.0-...）
+// 固定了主机公钥指纹时继续完成密钥交换并比对指纹（不进行身份认证，服务端拒绝认证属于正常情况）
+func (sc *ServiceChecker) checkSSH(target *MonitorTarget, result *MonitorResult) (error, ErrorType) {
+	u, err := url.Parse(target.URL)
+	if err != nil {
+		return fmt.Errorf("解析SSH地址失败：%w", err), ErrorTypeInvalid
+	}
+	address, fingerprints, err := parseSSHURL(u)
+	if err != nil {
+		return err, ErrorTypeInvalid
+	}
+	if len(fingerprints) == 0 && target.SSH != nil {
+		fingerprints = target.SSH.Fingerprints
+	}
+
+	timeout := sc.cfg.SSHTimeout
+	dialer := sc.newDialer(target, timeout)
+	conn, err := dialer.DialContext(context.Background(), "tcp", address)
+	result.recordDialAttempts(dialer.Attempts())
+	if err != nil {
+		var denied *EgressDeniedError
+		if errors.As(err, &denied) {
+			return denied, ErrorTypePolicy
+		}
+		if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+			return fmt.Errorf("SSH连接超时：%w", err), ErrorTypeTimeout
+		}
+		return fmt.Errorf("SSH连接失败：%w", err), ErrorTypeNetwork
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	details := &SSHDetails{Address: address}
+	result.details().SSH = details
+
+	// 读取版本标识，读到的内容在密钥交换时原样回放给 ssh 客户端
+	start := time.Now()
+	var consumed bytes.Buffer
+	reader := bufio.NewReader(io.TeeReader(conn, &consumed))
+	var first string
+	for i := 0; ; i++ {
+		line, err := reader.ReadString('\n')
+		line = strings.TrimRight(line, "\r\n")
+		if strings.HasPrefix(line, "SSH-") {
+			details.Banner = line
+			break
+		}
+		if i == 0 {
+			first = line
+		}
+		if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+			return fmt.Errorf("等待SSH版本标识超时"), ErrorTypeTimeout
+		}
+		if err != nil || i >= maxSSHPreambleLines {
+			if first != "" {
+				return fmt.Errorf("服务端未发送SSH版本标识（首行：%s）", truncateBanner(first)), ErrorTypeSSH
+			}
+			return fmt.Errorf("读取SSH版本标识失败：%w", err), ErrorTypeSSH
+		}
+	}
+	details.BannerMs = float64(time.Since(start).Microseconds()) / 1000
+	if !strings.HasPrefix(details.Banner, "SSH-2.0-") && !strings.HasPrefix(details.Banner, "SSH-1.99-") {
+		return fmt.Errorf("不支持的SSH协议版本：%s", truncateBanner(details.Banner)), ErrorTypeSSH
+	}
+	if len(fingerprints) == 0 {
+		return nil, ""
+	}
+
+	// 密钥交换：在回调中记录主机公钥后中止，不发送任何认证信息
+	errHostKey := errors.New("host key captured")
+	cfg := &ssh.ClientConfig{
+		User: "servicemonitor",
+		HostKeyCallback: func(_ string, _ net.Addr, key ssh.PublicKey) error {
+			details.HostKeyType = key.Type()
+			details.Fingerprint = ssh.FingerprintSHA256(key)
+			return errHostKey
+		},
+		Timeout: timeout,
+	}
+	replay := &replayConn{Conn: conn, r: io.MultiReader(bytes.NewReader(consumed.Bytes()), conn)}
+	if _, _, _, err := ssh.NewClientConn(replay, address, cfg); err != nil && details.Fingerprint == "" {
+		if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+			return fmt.Errorf("SSH密钥交换超时"), ErrorTypeTimeout
+		}
+		return fmt.Errorf("SSH密钥交换失败：%w", err), ErrorTypeSSH
+	}
+	for _, fp := range fingerprints {
+		if fp == details.Fingerprint {
+			details.FingerprintMatched = true
+			return nil, ""
+		}
+	}
+	return fmt.Errorf("SSH主机公钥指纹不匹配：%s（%s）", details.Fingerprint, details.HostKeyType), ErrorTypeSSH
+}
+
+// replayConn 先回放已读取的数据再继续读取连接
+type replayConn struct {
+	net.Conn
+	r io.Reader
+}
+
+func (c *replayConn) Read(p []byte) (int, error) {
+	return c.r.Read(p)
+}
+
+// truncateBanner 截断过长的版本标识，避免错误信息过长
+func truncateBanner(s string) string {
+	if r := []rune(s); len(r) > 64 {
+		return string(r[:64]) + "..."
+	}
+	return s
+}
@@ -0,0 +1,101 @@
+package core
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// parseTLSURL 解析 tls://host:port 形式的地址，端口必填（TLS 服务没有统一的默认端口）
+// 可选参数 alpn 为握手时提供的应用层协议列表，逗号分隔，如 ?alpn=h2,http/1.1
+func parseTLSURL(u *url.URL) (string, []string, error) {
+	if u.Hostname() == "" || u.Port() == "" {
+		return "", nil, fmt.Errorf("TLS地址格式应为 tls://host:port，如 tls://ldap.example.com:636")
+	}
+	if _, err := strconv.ParseUint(u.Port(), 10, 16); err != nil {
+		return "", nil, fmt.Errorf("无效的TLS端口：%s", u.Port())
+	}
+	if u.User != nil || (u.Path != "" && u.Path != "/") {
+		return "", nil, fmt.Errorf("TLS地址不能包含用户信息或路径：%s", u.String())
+	}
+	var alpn []string
+	if v := u.Query().Get("alpn"); v != "" {
+		for _, p := range strings.Split(v, ",") {
+			if p = strings.TrimSpace(p); p != "" {
+				alpn = append(alpn, p)
+			}
+		}
+	}
+	return net.JoinHostPort(u.Hostname(), u.Port()), alpn, nil
+}
+
+// checkTLS 检查任意基于 TCP 的 TLS 服务（LDAPS、SMTPS、自定义 TLS 服务、负载均衡器监听等）：只完成 TLS 握手，不发送应用层数据
+// 记录协商的版本、加密套件、证书链、吊销状态与证书有效期；证书校验遵循目标的 TLS 配置档与 SNI
+func (sc *ServiceChecker) checkTLS(target *MonitorTarget, result *MonitorResult) (error, ErrorType) {
+	u, err := url.Parse(target.URL)
+	if err != nil {
+		return fmt.Errorf("解析TLS地址失败：%w", err), ErrorTypeInvalid
+	}
+	address, alpn, err := parseTLSURL(u)
+	if err != nil {
+		return err, ErrorTypeInvalid
+	}
+	tlsConfig, profile, err := sc.tlsConfig(target)
+	if err != nil {
+		return err, ErrorTypeInvalid
+	}
+	if tlsConfig.ServerName == "" {
+		tlsConfig.ServerName = u.Hostname()
+	}
+	tlsConfig.NextProtos = alpn
+
+	timeout := sc.cfg.TLSTimeout
+	dialer := sc.newDialer(target, timeout)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	result.recordDialAttempts(dialer.Attempts())
+	if err != nil {
+		var denied *EgressDeniedError
+		if errors.As(err, &denied) {
+			return denied, ErrorTypePolicy
+		}
+		if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+			return fmt.Errorf("TLS服务连接超时：%w", err), ErrorTypeTimeout
+		}
+		return fmt.Errorf("TLS服务连接失败：%w", err), ErrorTypeNetwork
+	}
+	defer conn.Close()
+
+	tc := tls.Client(conn, tlsConfig)
+	start := time.Now()
+	if err := tc.HandshakeContext(ctx); err != nil {
+		if netErr, ok := err.(net.Error); (ok && netErr.Timeout()) || errors.Is(err, context.DeadlineExceeded) {
+			return fmt.Errorf("TLS握手超时：%w", err), ErrorTypeTimeout
+		}
+		if strings.Contains(err.Error(), "certificate") {
+			return fmt.Errorf("SSL证书验证失败：%w", err), ErrorTypeSSL
+		}
+		return fmt.Errorf("TLS握手失败：%w", err), ErrorTypeSSL
+	}
+	handshakeMs := elapsedMs(start)
+	defer tc.Close()
+
+	state := tc.ConnectionState()
+	sc.recordTLS(target, profile, &state, result)
+	result.Details.TLS.HandshakeMs = handshakeMs
+	result.Details.TLS.ALPN = state.NegotiatedProtocol
+	if len(alpn) > 0 && state.NegotiatedProtocol == "" {
+		result.addWarning(fmt.Sprintf("服务端未协商 ALPN 协议（提供的协议：%s）", strings.Join(alpn, ", ")))
+	}
+	if err := sc.revocationError(result); err != nil {
+		return err, ErrorTypeRevoked
+	}
+	return nil, ""
+}
@@ -0,0 +1,189 @@
+package core
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"servicetelemetry/config"
+)
+
+// DefaultTLSProfile 未指定 TLS 配置档时使用的配置档名称
+const DefaultTLSProfile = "default"
+
+// builtinTLSProfiles 内置 TLS 配置档，可在配置文件 monitor.tlsProfiles 中覆盖或新增
+var builtinTLSProfiles = map[string]config.TLSProfileConfig{
+	// default：TLS 1.2 及以上，Go 默认安全套件
+	"default": {MinVersion: "1.2"},
+	// modern：仅 TLS 1.3
+	"modern": {MinVersion: "1.3"},
+	// legacy：兼容只支持 TLS 1.0 / 1.1 的老旧内网设备，允许不安全的加密套件
+	"legacy": {MinVersion: "1.0", CipherSuites: []string{"all"}},
+}
+
+// tlsVersions TLS 版本名称与常量的对应关系
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// TLSProfiles 返回可选的 TLS 配置档名称（内置与 monitor.tlsProfiles 中自定义的），按名称排序
+func (sc *ServiceChecker) TLSProfiles() []string {
+	names := make([]string, 0, len(builtinTLSProfiles)+len(sc.cfg.TLSProfiles))
+	for name := range builtinTLSProfiles {
+		names = append(names, name)
+	}
+	for name := range sc.cfg.TLSProfiles {
+		if _, ok := builtinTLSProfiles[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// tlsConfig 根据目标选择的 TLS 配置档与目标级 TLS 选项构建 TLS 客户端配置，返回合并目标选项后的配置档用于记录结果
+func (sc *ServiceChecker) tlsConfig(target *MonitorTarget) (*tls.Config, config.TLSProfileConfig, error) {
+	name := target.TLSProfile
+	if name == "" {
+		name = DefaultTLSProfile
+	}
+	profile, ok := sc.cfg.TLSProfiles[name]
+	if !ok {
+		profile, ok = builtinTLSProfiles[name]
+	}
+	if !ok {
+		return nil, profile, fmt.Errorf("TLS配置档不存在：%s", name)
+	}
+
+	opts := target.TLS
+	if opts != nil {
+		if err := validateTLSOptions(opts); err != nil {
+			return nil, profile, err
+		}
+		if opts.MinVersion != "" {
+			profile.MinVersion = opts.MinVersion
+		}
+		// 配置了 CA 文件时按其校验证书，即使配置档跳过了校验
+		profile.InsecureSkipVerify = (profile.InsecureSkipVerify || opts.InsecureSkipVerify) && opts.CAFile == ""
+	}
+
+	tc, err := BuildTLSConfig(profile)
+	if err != nil {
+		return nil, profile, fmt.Errorf("TLS配置档[%s]无效：%w", name, err)
+	}
+	tc.ServerName = target.serverName()
+	if opts != nil && opts.CAFile != "" {
+		if tc.RootCAs, err = loadCAFile(opts.CAFile); err != nil {
+			return nil, profile, err
+		}
+	}
+	return tc, profile, nil
+}
+
+// validateTLSOptions 校验目标级 TLS 选项（不读取 CA 文件，文件在检查时读取）
+func validateTLSOptions(opts *config.TLSOptions) error {
+	if opts == nil {
+		return nil
+	}
+	if opts.MinVersion != "" {
+		if _, ok := tlsVersions[opts.MinVersion]; !ok {
+			return fmt.Errorf("无效的 tls.minVersion：%s（可选 1.0/1.1/1.2/1.3）", opts.MinVersion)
+		}
+	}
+	if opts.InsecureSkipVerify && opts.CAFile != "" {
+		return fmt.Errorf("tls.insecureSkipVerify 与 tls.caFile 只能配置其中一个")
+	}
+	return nil
+}
+
+// loadCAFile 读取 PEM 格式的 CA 证书文件，每次检查时读取，更换文件后无需重启
+func loadCAFile(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取 tls.caFile 失败：%w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("tls.caFile %s 中没有有效的 PEM 证书", path)
+	}
+	return pool, nil
+}
+
+// BuildTLSConfig 将 TLS 配置档转换为 tls.Config
+func BuildTLSConfig(profile config.TLSProfileConfig) (*tls.Config, error) {
+	tc := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: profile.InsecureSkipVerify,
+	}
+
+	if profile.MinVersion != "" {
+		v, ok := tlsVersions[profile.MinVersion]
+		if !ok {
+			return nil, fmt.Errorf("无效的最低版本：%s（可选 1.0/1.1/1.2/1.3）", profile.MinVersion)
+		}
+		tc.MinVersion = v
+	}
+	if profile.MaxVersion != "" {
+		v, ok := tlsVersions[profile.MaxVersion]
+		if !ok {
+			return nil, fmt.Errorf("无效的最高版本：%s（可选 1.0/1.1/1.2/1.3）", profile.MaxVersion)
+		}
+		tc.MaxVersion = v
+	}
+	if tc.MaxVersion != 0 && tc.MaxVersion < tc.MinVersion {
+		return nil, fmt.Errorf("最高版本 %s 低于最低版本 %s", profile.MaxVersion, profile.MinVersion)
+	}
+
+	if len(profile.CipherSuites) > 0 {
+		suites, err := parseCipherSuites(profile.CipherSuites)
+		if err != nil {
+			return nil, err
+		}
+		tc.CipherSuites = suites
+	}
+	return tc, nil
+}
+
+// parseCipherSuites 解析加密套件名称（IANA 名称，如 TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256），all 表示包含不安全套件在内的全部套件
+// 注：TLS 1.3 的套件不可配置，仅作用于 TLS 1.2 及以下版本
+func parseCipherSuites(names []string) ([]uint16, error) {
+	all := append(tls.CipherSuites(), tls.InsecureCipherSuites()...)
+	var ids []uint16
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if strings.EqualFold(name, "all") {
+			for _, s := range all {
+				ids = append(ids, s.ID)
+			}
+			continue
+		}
+		found := false
+		for _, s := range all {
+			if s.Name == name {
+				ids = append(ids, s.ID)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("不支持的加密套件：%s", name)
+		}
+	}
+	return ids, nil
+}
+
+// tlsVersionName 返回 TLS 版本的可读名称
+func tlsVersionName(v uint16) string {
+	for name, id := range tlsVersions {
+		if id == v {
+			return "TLS " + name
+		}
+	}
+	return fmt.Sprintf("0x%04x", v)
+}
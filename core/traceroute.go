@@ -0,0 +1,199 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+)
+
+// TracerouteDetails 网络类失败后自动采集的路由跟踪结果
+type TracerouteDetails struct {
+	Address string          `json:"address,omitempty"` // 跟踪的目标 IP
+	Hops    []TracerouteHop `json:"hops,omitempty"`    // 各跳结果，按 TTL 递增排列
+	Reached bool            `json:"reached"`           // 是否到达目标
+	Error   string          `json:"error,omitempty"`   // 无法跟踪的原因（如没有原始套接字权限）
+}
+
+// TracerouteHop 路由跟踪的一跳
+type TracerouteHop struct {
+	TTL     int     `json:"ttl"`               // 跳数
+	Address string  `json:"address,omitempty"` // 应答的路由器地址，超时为空
+	RTTMs   float64 `json:"rttMs,omitempty"`   // 往返时延（毫秒）
+	Timeout bool    `json:"timeout,omitempty"` // 该跳在超时内没有应答（路由器不回复 ICMP 或被过滤）
+}
+
+// tracerouteErrorTypes 触发路由跟踪的错误类型
+var tracerouteErrorTypes = map[string]bool{
+	string(ErrorTypeNetwork): true,
+	string(ErrorTypeTimeout): true,
+}
+
+// TraceOnFailure 检查因网络错误或超时失败时，在后台跟踪到目标的路由，不阻塞检查与结果入库
+// 便于区分是目标本身故障还是中间链路问题；需要原始套接字权限（root 或 CAP_NET_RAW）
+// 应在结果入库后调用：跟踪结束后以补充了 traceroute 的诊断信息副本调用 save 更新已保存的结果，
+// 不修改传入的结果；同时进行的跟踪数达到 monitor.traceroute.maxConcurrent 时跳过本次跟踪
+func (sc *ServiceChecker) TraceOnFailure(target *MonitorTarget, result *MonitorResult, save func(resultID uint64, details *ResultDetails) error) {
+	cfg := sc.cfg.Traceroute
+	if !cfg.Enable || result.ID == 0 || result.Status != StatusFailed || !tracerouteErrorTypes[result.ErrorType] {
+		return
+	}
+	u, err := url.Parse(target.URL)
+	if err != nil || u.Hostname() == "" {
+		return
+	}
+	select {
+	case sc.traceSem <- struct{}{}:
+	default:
+		log.Warnf("路由跟踪[%s]已跳过：同时进行的跟踪数已达上限 %d", target.URL, cap(sc.traceSem))
+		return
+	}
+
+	var details ResultDetails
+	if result.Details != nil {
+		details = *result.Details
+	}
+	go func() {
+		defer func() { <-sc.traceSem }()
+		details.Traceroute = sc.traceTarget(target, u.Hostname())
+		if err := save(result.ID, &details); err != nil {
+			log.Errorf("保存路由跟踪[%s]失败：%v", target.URL, err)
+		}
+	}()
+}
+
+// traceTarget 解析目标主机名并跟踪到该地址的路由
+func (sc *ServiceChecker) traceTarget(target *MonitorTarget, host string) *TracerouteDetails {
+	cfg := sc.cfg.Traceroute
+	details := &TracerouteDetails{}
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout)
+	defer cancel()
+
+	dialer := sc.newDialer(target, cfg.HopTimeout)
+	ip, err := dialer.resolve(ctx, host)
+	if err != nil {
+		details.Error = fmt.Sprintf("解析地址失败：%v", err)
+		return details
+	}
+	details.Address = ip.String()
+
+	start := time.Now()
+	if err := traceroute(ctx, ip, dialer.localAddr, cfg.MaxHops, cfg.HopTimeout, details); err != nil {
+		details.Error = err.Error()
+	}
+	log.Debugf("路由跟踪[%s]完成：%d 跳，reached=%v，耗时 %.0fms", target.URL, len(details.Hops), details.Reached, elapsedMs(start))
+	return details
+}
+
+// traceroute 以递增的 TTL 发送 ICMP 回显请求，记录各跳返回超时报文的路由器，收到目标应答或不可达报文时结束
+// 非特权 ICMP 套接字收不到中间路由器的超时报文，因此只使用原始套接字
+func traceroute(ctx context.Context, ip, localAddr net.IP, maxHops int, hopTimeout time.Duration, details *TracerouteDetails) error {
+	conn, mode, err := listenICMP(ip, localAddr)
+	if err != nil {
+		return fmt.Errorf("无法创建ICMP套接字：%w", err)
+	}
+	defer conn.Close()
+	if mode != "raw" {
+		return errors.New("路由跟踪需要原始套接字权限（root 或 CAP_NET_RAW）")
+	}
+
+	for ttl := 1; ttl <= maxHops; ttl++ {
+		if ctx.Err() != nil {
+			return fmt.Errorf("路由跟踪在第 %d 跳超出总时长限制", ttl)
+		}
+		hop, done, err := traceHop(ctx, conn, ip, ttl, hopTimeout)
+		if err != nil {
+			return err
+		}
+		details.Hops = append(details.Hops, hop)
+		if done {
+			details.Reached = hop.Address == ip.String()
+			return nil
+		}
+	}
+	return nil
+}
+
+// traceHop 以指定 TTL 发送一个回显请求，等待超时报文、目标应答或不可达报文
+// done 为 true 表示不再继续跟踪（到达目标或收到不可达报文）
+func traceHop(ctx context.Context, conn *icmp.PacketConn, ip net.IP, ttl int, timeout time.Duration) (TracerouteHop, bool, error) {
+	hop := TracerouteHop{TTL: ttl}
+	v4 := ip.To4() != nil
+	var reqType icmp.Type = ipv4.ICMPTypeEcho
+	proto := protocolICMP
+	if v4 {
+		if err := conn.IPv4PacketConn().SetTTL(ttl); err != nil {
+			return hop, false, fmt.Errorf("设置 TTL 失败：%w", err)
+		}
+	} else {
+		reqType, proto = ipv6.ICMPTypeEchoRequest, protocolIPv6ICMP
+		if err := conn.IPv6PacketConn().SetHopLimit(ttl); err != nil {
+			return hop, false, fmt.Errorf("设置跳数限制失败：%w", err)
+		}
+	}
+
+	id := os.Getpid() & 0xffff
+	seq := int(atomic.AddUint32(&icmpSeq, 1) & 0xffff)
+	msg := icmp.Message{
+		Type: reqType,
+		Body: &icmp.Echo{ID: id, Seq: seq, Data: []byte("servicetelemetry")},
+	}
+	payload, err := msg.Marshal(nil)
+	if err != nil {
+		return hop, false, err
+	}
+
+	start := time.Now()
+	if _, err := conn.WriteTo(payload, &net.IPAddr{IP: ip}); err != nil {
+		return hop, false, fmt.Errorf("发送ICMP请求失败：%w", err)
+	}
+	deadline := start.Add(timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	conn.SetReadDeadline(deadline)
+
+	buf := make([]byte, 1500)
+	for {
+		n, peer, err := conn.ReadFrom(buf)
+		if err != nil {
+			hop.Timeout = true
+			return hop, false, nil
+		}
+		reply, err := icmp.ParseMessage(proto, buf[:n])
+		if err != nil {
+			continue
+		}
+
+		switch reply.Type {
+		case ipv4.ICMPTypeEchoReply, ipv6.ICMPTypeEchoReply:
+			echo, ok := reply.Body.(*icmp.Echo)
+			if !ok || echo.Seq != seq || echo.ID != id || !peerIP(peer).Equal(ip) {
+				continue
+			}
+			hop.Address, hop.RTTMs = ip.String(), elapsedMs(start)
+			return hop, true, nil
+		case ipv4.ICMPTypeTimeExceeded, ipv6.ICMPTypeTimeExceeded:
+			body, ok := reply.Body.(*icmp.TimeExceeded)
+			if !ok || quotedSeq(body.Data, proto) != seq {
+				continue
+			}
+			hop.Address, hop.RTTMs = peerIP(peer).String(), elapsedMs(start)
+			return hop, false, nil
+		case ipv4.ICMPTypeDestinationUnreachable, ipv6.ICMPTypeDestinationUnreachable:
+			if !unreachableFor(reply, proto, seq) {
+				continue
+			}
+			hop.Address, hop.RTTMs = peerIP(peer).String(), elapsedMs(start)
+			return hop, true, nil
+		}
+	}
+}
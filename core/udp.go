@@ -0,0 +1,156 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"syscall"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"servicetelemetry/config"
+)
+
+// maxUDPResponsePreview 结果中记录的响应内容最大长度
+const maxUDPResponsePreview = 256
+
+// UDP 检查结论
+const (
+	UDPOutcomeResponse   = "response"    // 收到响应
+	UDPOutcomeRefused    = "refused"     // 收到 ICMP 端口不可达，端口未开放
+	UDPOutcomeNoResponse = "no_response" // 超时未收到响应，端口开放或被过滤
+)
+
+// parseUDPURL 解析 udp://host:port?payload=ping&expect=pong 形式的地址，返回地址与其中的检查选项
+// payloadHex 参数以十六进制指定二进制报文；expectResponse=true 表示必须收到响应
+func parseUDPURL(u *url.URL) (string, *config.UDPOptions, error) {
+	if u.Hostname() == "" || u.Port() == "" {
+		return "", nil, fmt.Errorf("UDP地址格式应为 udp://host:port")
+	}
+	if _, err := strconv.ParseUint(u.Port(), 10, 16); err != nil {
+		return "", nil, fmt.Errorf("无效的UDP端口：%s", u.Port())
+	}
+
+	params := u.Query()
+	opts := &config.UDPOptions{
+		Payload:    params.Get("payload"),
+		PayloadHex: params.Get("payloadHex"),
+		Expect:     params.Get("expect"),
+	}
+	if v := params.Get("expectResponse"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return "", nil, fmt.Errorf("无效的 expectResponse：%s", v)
+		}
+		opts.ExpectResponse = b
+	}
+	if err := validateUDPOptions(opts); err != nil {
+		return "", nil, err
+	}
+	return u.Host, opts, nil
+}
+
+// validateUDPOptions 校验 UDP 检查选项
+func validateUDPOptions(opts *config.UDPOptions) error {
+	if opts.Payload != "" && opts.PayloadHex != "" {
+		return fmt.Errorf("payload 与 payloadHex 只能配置其中一个")
+	}
+	if _, err := hex.DecodeString(opts.PayloadHex); err != nil {
+		return fmt.Errorf("无效的 payloadHex：%w", err)
+	}
+	return nil
+}
+
+// checkUDP 检查UDP服务：发送报文（未配置时发送空报文）并等待响应
+// 收到 ICMP 端口不可达判定失败；配置了 expect / expectResponse 时必须收到（包含期望内容的）响应，否则超时未响应记为警告
+func (sc *ServiceChecker) checkUDP(target *MonitorTarget, result *MonitorResult) (error, ErrorType) {
+	u, err := url.Parse(target.URL)
+	if err != nil {
+		return fmt.Errorf("解析UDP地址失败：%w", err), ErrorTypeInvalid
+	}
+	address, opts, err := parseUDPURL(u)
+	if err != nil {
+		return err, ErrorTypeInvalid
+	}
+	if def := target.UDP; def != nil {
+		if opts.Payload == "" && opts.PayloadHex == "" {
+			opts.Payload, opts.PayloadHex = def.Payload, def.PayloadHex
+		}
+		if opts.Expect == "" {
+			opts.Expect = def.Expect
+		}
+		opts.ExpectResponse = opts.ExpectResponse || def.ExpectResponse
+	}
+	payload := []byte(opts.Payload)
+	if opts.PayloadHex != "" {
+		payload, _ = hex.DecodeString(opts.PayloadHex)
+	}
+
+	timeout := sc.cfg.UDPTimeout
+	dialer := sc.newDialer(target, timeout)
+	conn, err := dialer.DialContext(context.Background(), "udp", address)
+	if err != nil {
+		var denied *EgressDeniedError
+		if errors.As(err, &denied) {
+			return denied, ErrorTypePolicy
+		}
+		return fmt.Errorf("UDP连接失败：%w", err), ErrorTypeNetwork
+	}
+	defer conn.Close()
+
+	details := &UDPDetails{Address: conn.RemoteAddr().String()}
+	result.details().UDP = details
+	conn.SetDeadline(time.Now().Add(timeout))
+	n, err := conn.Write(payload)
+	details.BytesSent = n
+	if err != nil {
+		return fmt.Errorf("发送UDP报文失败：%w", err), ErrorTypeNetwork
+	}
+
+	buf := make([]byte, 64*1024)
+	n, err = conn.Read(buf)
+	switch {
+	case errors.Is(err, syscall.ECONNREFUSED):
+		details.Outcome = UDPOutcomeRefused
+		return fmt.Errorf("UDP端口不可达（收到 ICMP 端口不可达）：%s", details.Address), ErrorTypeNetwork
+	case err != nil:
+		var netErr net.Error
+		if !errors.As(err, &netErr) || !netErr.Timeout() {
+			return fmt.Errorf("接收UDP响应失败：%w", err), ErrorTypeNetwork
+		}
+		details.Outcome = UDPOutcomeNoResponse
+		if opts.Expect != "" || opts.ExpectResponse {
+			return fmt.Errorf("UDP请求超时，%s 内未收到响应", timeout), ErrorTypeTimeout
+		}
+		result.addWarning("未收到UDP响应（端口开放或被过滤）")
+		return nil, ""
+	}
+
+	details.Outcome = UDPOutcomeResponse
+	details.BytesReceived = n
+	details.Response = udpPreview(buf[:n])
+	if opts.Expect != "" {
+		result.KeywordMatched = bytes.Contains(buf[:n], []byte(opts.Expect))
+		if !result.KeywordMatched {
+			return fmt.Errorf("UDP响应未包含期望内容：%s", opts.Expect), ErrorTypeKeyword
+		}
+	}
+	return nil, ""
+}
+
+// udpPreview 响应内容预览：可打印文本原样保留，二进制内容以十六进制表示
+func udpPreview(data []byte) string {
+	if len(data) > maxUDPResponsePreview {
+		data = data[:maxUDPResponsePreview]
+	}
+	if utf8.Valid(data) && bytes.IndexFunc(data, func(r rune) bool { return !unicode.IsPrint(r) && !unicode.IsSpace(r) }) < 0 {
+		return string(data)
+	}
+	return "hex:" + hex.EncodeToString(data)
+}
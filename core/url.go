@@ -0,0 +1,25 @@
+package core
+
+import (
+	"net/url"
+	"strings"
+)
+
+// targetScheme 返回目标地址的协议（小写），缺少协议时返回空字符串
+func targetScheme(targetURL string) string {
+	scheme, _, ok := strings.Cut(strings.TrimSpace(targetURL), "://")
+	if !ok {
+		return ""
+	}
+	return strings.ToLower(scheme)
+}
+
+// TargetHost 提取监控目标地址中的主机名（域名或IP），解析失败时返回空字符串
+// 支持 http(s)://host/path、tcp://host:port、udp://host:port、grpc://host:port/service、smtp://host:port、ssh://host:port、icmp://host、dns://example.com 等带 scheme 的地址
+func TargetHost(targetURL string) string {
+	u, err := url.Parse(strings.TrimSpace(targetURL))
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Hostname())
+}
@@ -0,0 +1,252 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"servicetelemetry/config"
+)
+
+// blockedNetworks 默认禁止检查的地址段：环回、私有网络、链路本地（含云厂商元数据地址）、运营商级 NAT 等
+var blockedNetworks = mustParseCIDRs(
+	"0.0.0.0/8",
+	"10.0.0.0/8",
+	"100.64.0.0/10",
+	"127.0.0.0/8",
+	"169.254.0.0/16",
+	"172.16.0.0/12",
+	"192.0.0.0/24",
+	"192.168.0.0/16",
+	"198.18.0.0/15",
+	"224.0.0.0/4",
+	"240.0.0.0/4",
+	"::/128",
+	"::1/128",
+	"fc00::/7",
+	"fe80::/10",
+	"ff00::/8",
+)
+
+// blockedHosts 默认禁止检查的主机名（云厂商元数据服务）
+var blockedHosts = []string{
+	"localhost",
+	"metadata.google.internal",
+	"metadata.goog",
+}
+
+// URLRejectedError 目标地址未通过安全校验
+type URLRejectedError struct {
+	URL    string // 原始地址
+	Reason string // 拒绝原因
+}
+
+func (e *URLRejectedError) Error() string {
+	return fmt.Sprintf("目标地址[%s]被拒绝：%s", e.URL, e.Reason)
+}
+
+// defaultPorts 各协议的默认端口，规范化时省略，与各检查解析地址时使用的默认端口一致
+var defaultPorts = map[string]string{
+	"http":     "80",
+	"https":    "443",
+	"ssh":      "22",
+	"ftp":      "21",
+	"sftp":     "22",
+	"smtp":     "25",
+	"smtps":    "465",
+	"redis":    "6379",
+	"mysql":    "3306",
+	"postgres": "5432",
+	"mqtt":     "1883",
+	"mqtts":    "8883",
+	"kafka":    "9092",
+	"ntp":      "123",
+}
+
+// NormalizeTargetURL 规范化目标地址，使同一服务的不同写法得到相同的地址（如 http://EXAMPLE.com:80/ 与 http://example.com），
+// 避免产生两个历史分离的目标：去除首尾空白，缺少协议时默认 https://，协议与主机名转为小写，
+// 省略协议默认端口，仅有根路径 / 时去除，丢弃片段（#...，指定解析服务器的 #resolver=ip:port 除外）；其余路径（含末尾的 /）与查询参数保持不变
+func NormalizeTargetURL(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", fmt.Errorf("目标地址不能为空")
+	}
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + raw
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", fmt.Errorf("目标地址格式错误：%w", err)
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	if port := u.Port(); port == "" || port == defaultPorts[u.Scheme] {
+		u.Host = strings.TrimSuffix(u.Host, ":"+port)
+	}
+	if u.Path == "/" && u.RawPath == "" {
+		u.Path = ""
+	}
+	resolver := ""
+	if strings.HasPrefix(u.Fragment, resolverFragment) {
+		if resolver, err = normalizeResolver(strings.TrimPrefix(u.Fragment, resolverFragment)); err != nil {
+			return "", err
+		}
+		resolver = resolverFragment + resolver
+	}
+	u.Fragment, u.RawFragment = resolver, ""
+	if u.RawQuery == "" {
+		u.ForceQuery = false
+	}
+	return u.String(), nil
+}
+
+// CanonicalTargetURL 返回地址的规范形式，无法解析时原样返回，用于结果缓存与目标去重的比较键
+func CanonicalTargetURL(raw string) string {
+	if normalized, err := NormalizeTargetURL(raw); err == nil {
+		return normalized
+	}
+	return raw
+}
+
+// ValidateURL 对外部提交的目标地址做安全校验并返回规范化后的地址
+// 校验内容：长度上限、协议是否支持、主机名及其解析结果是否落在禁止的内网 / 元数据地址段（可通过白名单放行）
+func (sc *ServiceChecker) ValidateURL(raw string) (string, error) {
+	policy := sc.cfg.URLPolicy
+	if policy.MaxURLLength > 0 && len(raw) > policy.MaxURLLength {
+		return "", &URLRejectedError{URL: truncateURL(raw), Reason: fmt.Sprintf("地址长度超过上限 %d", policy.MaxURLLength)}
+	}
+
+	normalized, err := NormalizeTargetURL(raw)
+	if err != nil {
+		return "", &URLRejectedError{URL: raw, Reason: err.Error()}
+	}
+	if err := validateTargetURL(normalized); err != nil {
+		return "", &URLRejectedError{URL: raw, Reason: err.Error()}
+	}
+	// 心跳目标不发起连接，地址中的主机名只是名称
+	if targetScheme(normalized) == "heartbeat" {
+		return normalized, nil
+	}
+
+	if !policy.BlockPrivate {
+		return normalized, nil
+	}
+
+	// DNS 检查实际连接的是解析服务器，地址中指定的解析服务器同样需要校验
+	if targetScheme(normalized) == "dns" {
+		u, _ := url.Parse(normalized)
+		if ip := resolverIP(u.Query().Get("resolver")); ip != nil && IsBlockedIP(ip, &policy) && !hostAllowed(ip.String(), &policy) {
+			return "", &URLRejectedError{URL: raw, Reason: fmt.Sprintf("禁止使用受保护的内网解析服务器 %s", ip)}
+		}
+	}
+
+	// 地址片段中指定的解析服务器同样需要校验，主机名按该解析服务器的解析结果判断
+	resolver := net.DefaultResolver
+	if addr := fragmentResolver(normalized); addr != "" {
+		if ip := resolverIP(addr); IsBlockedIP(ip, &policy) && !hostAllowed(ip.String(), &policy) {
+			return "", &URLRejectedError{URL: raw, Reason: fmt.Sprintf("禁止使用受保护的内网解析服务器 %s", ip)}
+		}
+		resolver = resolverVia(addr, (&net.Dialer{}).DialContext)
+	}
+
+	host := TargetHost(normalized)
+	if hostAllowed(host, &policy) {
+		return normalized, nil
+	}
+	if reason := checkPolicyHost(host, &policy); reason != "" {
+		return "", &URLRejectedError{URL: raw, Reason: reason}
+	}
+	if net.ParseIP(host) != nil {
+		return normalized, nil
+	}
+
+	// 域名解析后逐个判断，解析失败时拒绝（无法确认地址是否安全）；检查时拨号器仍会按实际连接的 IP 再次校验
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	addrs, err := resolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return "", &URLRejectedError{URL: raw, Reason: fmt.Sprintf("无法解析主机 %s：%v", host, err)}
+	}
+	for _, a := range addrs {
+		if IsBlockedIP(a.IP, &policy) {
+			return "", &URLRejectedError{URL: raw, Reason: fmt.Sprintf("主机 %s 解析到受保护的内网地址 %s", host, a.IP)}
+		}
+	}
+	return normalized, nil
+}
+
+// urlPolicyFor 返回检查目标时在连接阶段执行的地址安全校验策略：只作用于接口提交与消息队列注册的目标，未开启 blockPrivate 时返回 nil
+func (sc *ServiceChecker) urlPolicyFor(target *MonitorTarget) *config.URLPolicyConfig {
+	if !target.External() || !sc.cfg.URLPolicy.BlockPrivate {
+		return nil
+	}
+	return &sc.cfg.URLPolicy
+}
+
+// checkPolicyHost 不解析域名，按主机名判断：白名单中的主机放行，内部主机名或受保护的内网 IP 返回拒绝原因
+func checkPolicyHost(host string, policy *config.URLPolicyConfig) string {
+	if hostAllowed(host, policy) {
+		return ""
+	}
+	for _, h := range blockedHosts {
+		if host == h || strings.HasSuffix(host, "."+h) {
+			return "禁止检查内部主机 " + host
+		}
+	}
+	if ip := net.ParseIP(host); ip != nil && IsBlockedIP(ip, policy) {
+		return fmt.Sprintf("禁止检查受保护的内网地址 %s", ip)
+	}
+	return ""
+}
+
+// IsBlockedIP 判断 IP 是否落在禁止的地址段且不在白名单内
+func IsBlockedIP(ip net.IP, policy *config.URLPolicyConfig) bool {
+	if v4 := ip.To4(); v4 != nil {
+		ip = v4
+	}
+	for _, cidr := range policy.AllowCIDRs {
+		if _, n, err := net.ParseCIDR(cidr); err == nil && n.Contains(ip) {
+			return false
+		}
+	}
+	for _, n := range blockedNetworks {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// hostAllowed 判断主机名是否在白名单中（精确匹配，或以 . 开头的后缀匹配）
+func hostAllowed(host string, policy *config.URLPolicyConfig) bool {
+	for _, h := range policy.AllowHosts {
+		if matchDomain(host, h) {
+			return true
+		}
+	}
+	return false
+}
+
+// truncateURL 截断超长地址，避免错误信息过长
+func truncateURL(raw string) string {
+	if len(raw) > 64 {
+		return raw[:64] + "..."
+	}
+	return raw
+}
+
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	nets := make([]*net.IPNet, 0, len(cidrs))
+	for _, c := range cidrs {
+		_, n, err := net.ParseCIDR(c)
+		if err != nil {
+			panic(err)
+		}
+		nets = append(nets, n)
+	}
+	return nets
+}
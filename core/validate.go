@@ -0,0 +1,287 @@
+package core
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// SupportedSchemes 检查器支持的目标地址协议
+var SupportedSchemes = []string{"http", "https", "tcp", "udp", "icmp", "dns", "grpc", "grpcs", "smtp", "smtps", "ssh", "redis", "mysql", "postgres", "mqtt", "mqtts", "kafka", "ntp", "ftp", "sftp", "heartbeat", "tls"}
+
+// ValidateTarget 静态校验监控目标定义（不发起实际检查），返回发现的全部问题
+// 校验内容：地址格式、协议是否支持、优先级取值、断言表达式能否解析、响应比对选项
+func ValidateTarget(target *MonitorTarget) []error {
+	var errs []error
+
+	if err := validateTargetURL(target.URL); err != nil {
+		errs = append(errs, err)
+	}
+
+	switch target.Priority {
+	case "", "low", "normal", "high":
+	default:
+		errs = append(errs, fmt.Errorf("无效的优先级：%s，仅支持 low/normal/high", target.Priority))
+	}
+
+	if target.SourceIP != "" && net.ParseIP(target.SourceIP) == nil {
+		errs = append(errs, fmt.Errorf("无效的源地址：%s", target.SourceIP))
+	}
+	if target.SourceIP != "" && target.Interface != "" {
+		errs = append(errs, fmt.Errorf("sourceIP 与 interface 只能配置其中一个"))
+	}
+
+	if target.SNI != "" && strings.ContainsAny(target.SNI, ":/ ") {
+		errs = append(errs, fmt.Errorf("无效的 SNI：%s，应为不含协议和端口的主机名", target.SNI))
+	}
+	if target.HostHeader != "" && strings.ContainsAny(target.HostHeader, "/ ") {
+		errs = append(errs, fmt.Errorf("无效的 Host 请求头：%s", target.HostHeader))
+	}
+
+	switch strings.ToUpper(target.Method) {
+	case "", http.MethodGet:
+	case http.MethodHead:
+		if scheme := targetScheme(target.URL); scheme != "http" && scheme != "https" {
+			errs = append(errs, fmt.Errorf("请求方法仅适用于 HTTP/HTTPS 目标"))
+		}
+		if target.hasKeywords() || target.Compare != nil || hasBodyAssertion(target.Assertions) {
+			errs = append(errs, fmt.Errorf("HEAD 请求没有响应体，不能配置关键词、body 断言或响应比对"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("无效的请求方法：%s，仅支持 GET / HEAD", target.Method))
+	}
+
+	if err := validateRedirects(target); err != nil {
+		errs = append(errs, err)
+	}
+	if err := validateLatencyThreshold(target); err != nil {
+		errs = append(errs, err)
+	}
+	if err := validateHTTPVersion(target); err != nil {
+		errs = append(errs, err)
+	}
+	errs = append(errs, validateRequestAuth(target)...)
+	if err := validateResolver(target); err != nil {
+		errs = append(errs, err)
+	}
+	if err := validateHeartbeat(target); err != nil {
+		errs = append(errs, err)
+	}
+	if err := validateDomainExpiry(target); err != nil {
+		errs = append(errs, err)
+	}
+	if err := validateAddressFamily(target); err != nil {
+		errs = append(errs, err)
+	}
+	if err := validateProxy(target); err != nil {
+		errs = append(errs, err)
+	}
+	if err := validateHedge(target); err != nil {
+		errs = append(errs, err)
+	}
+	if err := validateTLSOptions(target.TLS); err != nil {
+		errs = append(errs, err)
+	}
+
+	if cs := target.Checksum; cs != nil {
+		if scheme := targetScheme(target.URL); scheme != "http" && scheme != "https" {
+			errs = append(errs, fmt.Errorf("摘要校验仅支持 HTTP/HTTPS 目标"))
+		}
+		if strings.EqualFold(target.Method, http.MethodHead) {
+			errs = append(errs, fmt.Errorf("摘要校验需要下载文件，不能使用 HEAD 请求"))
+		}
+		switch {
+		case (cs.SHA256 == "") == (cs.URL == ""):
+			errs = append(errs, fmt.Errorf("摘要校验的 sha256 与 url 必须且只能配置一个"))
+		case cs.SHA256 != "" && !sha256Pattern.MatchString(cs.SHA256):
+			errs = append(errs, fmt.Errorf("无效的 SHA-256：%s，应为 64 位十六进制", cs.SHA256))
+		case cs.URL != "":
+			if scheme := targetScheme(cs.URL); scheme != "http" && scheme != "https" {
+				errs = append(errs, fmt.Errorf("摘要文件地址必须为 HTTP/HTTPS 地址：%s", cs.URL))
+			} else if err := validateTargetURL(cs.URL); err != nil {
+				errs = append(errs, fmt.Errorf("摘要文件地址无效：%w", err))
+			}
+		}
+		if cs.MaxSize < 0 {
+			errs = append(errs, fmt.Errorf("摘要校验的大小上限不能为负数"))
+		}
+	}
+
+	if kw := target.Keywords; kw != nil {
+		if scheme := targetScheme(target.URL); scheme != "http" && scheme != "https" {
+			errs = append(errs, fmt.Errorf("多关键词匹配仅支持 HTTP/HTTPS 目标"))
+		}
+		for _, k := range append(append(append([]string(nil), kw.All...), kw.Any...), kw.None...) {
+			if k == "" {
+				errs = append(errs, fmt.Errorf("关键词不能为空"))
+				break
+			}
+		}
+		for _, k := range kw.None {
+			if k == target.Keyword || containsKeyword(kw.All, k) || containsKeyword(kw.Any, k) {
+				errs = append(errs, fmt.Errorf("关键词 %q 同时出现在必须出现与不得出现的条件中", k))
+			}
+		}
+	}
+
+	if target.UDP != nil {
+		if err := validateUDPOptions(target.UDP); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if target.SSH != nil {
+		if err := validateSSHFingerprints(target.SSH.Fingerprints); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	for _, r := range target.Regions {
+		if r == "" || strings.ContainsAny(r, " ,/") {
+			errs = append(errs, fmt.Errorf("无效的探测区域：%q", r))
+		}
+	}
+
+	for _, expr := range target.Assertions {
+		if _, err := ParseAssertion(expr); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if cmp := target.Compare; cmp != nil {
+		if scheme := targetScheme(target.URL); scheme != "http" && scheme != "https" {
+			errs = append(errs, fmt.Errorf("响应比对仅支持 HTTP/HTTPS 目标"))
+		}
+		if scheme := targetScheme(cmp.URL); scheme != "http" && scheme != "https" {
+			errs = append(errs, fmt.Errorf("比对地址必须为 HTTP/HTTPS 地址：%s", cmp.URL))
+		} else if err := validateTargetURL(cmp.URL); err != nil {
+			errs = append(errs, fmt.Errorf("比对地址无效：%w", err))
+		}
+		for _, path := range append(append([]string(nil), cmp.Fields...), cmp.Ignore...) {
+			if path == "" || strings.HasPrefix(path, ".") || strings.HasSuffix(path, ".") || strings.Contains(path, "..") {
+				errs = append(errs, fmt.Errorf("无效的比对字段路径：%q", path))
+			}
+		}
+		if cmp.Tolerance < 0 {
+			errs = append(errs, fmt.Errorf("比对数值容差不能为负数"))
+		}
+	}
+
+	return errs
+}
+
+// validateTargetURL 校验目标地址格式及协议
+func validateTargetURL(targetURL string) error {
+	if strings.TrimSpace(targetURL) == "" {
+		return fmt.Errorf("目标地址不能为空")
+	}
+
+	u, err := url.Parse(targetURL)
+	if err != nil {
+		return fmt.Errorf("目标地址格式错误：%w", err)
+	}
+
+	scheme := strings.ToLower(u.Scheme)
+	supported := false
+	for _, s := range SupportedSchemes {
+		if scheme == s {
+			supported = true
+			break
+		}
+	}
+	if !supported {
+		return fmt.Errorf("不支持的协议：%q，仅支持 %s", u.Scheme, strings.Join(SupportedSchemes, "/"))
+	}
+
+	if u.Hostname() == "" {
+		return fmt.Errorf("目标地址缺少主机名：%s", targetURL)
+	}
+
+	if scheme == "tcp" {
+		if _, _, err := net.SplitHostPort(u.Host); err != nil {
+			return fmt.Errorf("TCP地址格式应为 tcp://ip:port：%s", targetURL)
+		}
+	}
+	if scheme == "grpc" || scheme == "grpcs" {
+		if _, _, err := net.SplitHostPort(u.Host); err != nil {
+			return fmt.Errorf("gRPC地址格式应为 %s://host:port/service：%s", scheme, targetURL)
+		}
+	}
+	if scheme == "smtp" || scheme == "smtps" {
+		if _, err := parseSMTPURL(u); err != nil {
+			return err
+		}
+	}
+	if scheme == "ssh" {
+		if _, _, err := parseSSHURL(u); err != nil {
+			return err
+		}
+	}
+	if scheme == "redis" {
+		if _, err := parseRedisURL(u); err != nil {
+			return err
+		}
+	}
+	if scheme == "mysql" || scheme == "postgres" {
+		if _, err := parseDatabaseURL(u); err != nil {
+			return err
+		}
+	}
+	if scheme == "ntp" {
+		if _, err := parseNTPURL(u); err != nil {
+			return err
+		}
+	}
+	if scheme == "kafka" {
+		if _, err := parseKafkaURL(u); err != nil {
+			return err
+		}
+	}
+	if scheme == "ftp" || scheme == "sftp" {
+		if _, _, err := parseFileTransferURL(u); err != nil {
+			return err
+		}
+	}
+	if scheme == "tls" {
+		if _, _, err := parseTLSURL(u); err != nil {
+			return err
+		}
+	}
+	if scheme == "sftp" {
+		if _, _, err := parseSSHURL(u); err != nil {
+			return err
+		}
+	}
+	if scheme == "mqtt" || scheme == "mqtts" {
+		if _, err := parseMQTTURL(u); err != nil {
+			return err
+		}
+	}
+	if scheme == "dns" {
+		if _, err := parseDNSURL(u); err != nil {
+			return err
+		}
+	}
+	if scheme == "udp" {
+		if _, _, err := parseUDPURL(u); err != nil {
+			return err
+		}
+	}
+	if scheme == "icmp" && (u.Port() != "" || strings.Trim(u.Path, "/") != "") {
+		return fmt.Errorf("ICMP地址格式应为 icmp://host，不能包含端口或路径：%s", targetURL)
+	}
+
+	return nil
+}
+
+// containsKeyword 判断关键词列表中是否包含指定关键词
+func containsKeyword(list []string, keyword string) bool {
+	for _, k := range list {
+		if k == keyword {
+			return true
+		}
+	}
+	return false
+}
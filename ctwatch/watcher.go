@@ -0,0 +1,199 @@
+package ctwatch
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"servicetelemetry/alert"
+	"servicetelemetry/config"
+	"servicetelemetry/logger"
+)
+
+var log = logger.New("ctwatch")
+
+// maxFindings 内存中保留的最近发现条数
+const maxFindings = 200
+
+// Certificate CT 日志中的一条证书记录（crt.sh JSON 格式）
+type Certificate struct {
+	ID           int64  `json:"id"`              // 日志条目ID
+	IssuerName   string `json:"issuer_name"`     // 签发者
+	CommonName   string `json:"common_name"`     // 证书 CN
+	NameValue    string `json:"name_value"`      // 证书包含的域名（换行分隔）
+	SerialNumber string `json:"serial_number"`   // 序列号
+	NotBefore    string `json:"not_before"`      // 生效时间
+	NotAfter     string `json:"not_after"`       // 过期时间
+	EntryTime    string `json:"entry_timestamp"` // 写入 CT 日志的时间
+}
+
+// Finding 新发现的证书
+type Finding struct {
+	Domain      string      `json:"domain"`      // 所属监控域名
+	Certificate Certificate `json:"certificate"` // 证书记录
+	Expected    bool        `json:"expected"`    // 是否由预期的签发机构签发
+	FoundAt     time.Time   `json:"foundAt"`     // 发现时间
+}
+
+// Watcher 证书透明度日志监控器，定期检索自有域名的新证书，非预期签发时通过告警渠道通知
+// 首次检索只建立基线（记录已有证书），不产生告警
+type Watcher struct {
+	cfg    *config.CTConfig
+	alerts *alert.Manager
+	client *http.Client
+
+	mu       sync.Mutex
+	seen     map[string]map[int64]bool // 域名 -> 已见过的日志条目ID
+	findings []*Finding
+	lastPoll time.Time
+	lastErr  string
+}
+
+// NewWatcher 创建证书透明度日志监控器
+// cfg：证书透明度监控配置
+// alerts：告警管理器，用于发送非预期证书告警
+func NewWatcher(cfg *config.CTConfig, alerts *alert.Manager) *Watcher {
+	return &Watcher{
+		cfg:    cfg,
+		alerts: alerts,
+		client: &http.Client{Timeout: cfg.Timeout},
+		seen:   make(map[string]map[int64]bool),
+	}
+}
+
+// Start 启动后台轮询
+func (w *Watcher) Start() {
+	go func() {
+		w.Poll()
+		ticker := time.NewTicker(w.cfg.PollInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			w.Poll()
+		}
+	}()
+}
+
+// Poll 检索全部监控域名一次，返回本次新发现的证书
+func (w *Watcher) Poll() []*Finding {
+	var found []*Finding
+	var errs []string
+	for _, domain := range w.cfg.Domains {
+		domain = strings.ToLower(strings.TrimSpace(domain))
+		if domain == "" {
+			continue
+		}
+		certs, err := w.fetch(domain)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s：%v", domain, err))
+			continue
+		}
+		found = append(found, w.diff(domain, certs)...)
+	}
+
+	w.mu.Lock()
+	w.lastPoll = time.Now()
+	w.lastErr = strings.Join(errs, "；")
+	w.findings = append(found, w.findings...)
+	if len(w.findings) > maxFindings {
+		w.findings = w.findings[:maxFindings]
+	}
+	w.mu.Unlock()
+
+	for _, e := range errs {
+		log.Warnf("证书透明度日志检索失败：%s", e)
+	}
+	for _, f := range found {
+		if !f.Expected {
+			w.alerts.Notify(newCTAlert(f, w.cfg.SourceURL))
+		}
+	}
+	return found
+}
+
+// diff 对比已见过的证书，返回新证书；该域名首次检索时只记录基线
+func (w *Watcher) diff(domain string, certs []Certificate) []*Finding {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	seen, baseline := w.seen[domain], false
+	if seen == nil {
+		seen = make(map[int64]bool)
+		w.seen[domain] = seen
+		baseline = true
+	}
+
+	var found []*Finding
+	for _, c := range certs {
+		if seen[c.ID] {
+			continue
+		}
+		seen[c.ID] = true
+		if baseline {
+			continue
+		}
+		found = append(found, &Finding{
+			Domain:      domain,
+			Certificate: c,
+			Expected:    w.expectedIssuer(c.IssuerName),
+			FoundAt:     time.Now(),
+		})
+	}
+	sort.Slice(found, func(i, j int) bool { return found[i].Certificate.ID < found[j].Certificate.ID })
+	return found
+}
+
+// expectedIssuer 判断签发者是否在预期列表中，未配置预期列表时所有证书均视为非预期
+func (w *Watcher) expectedIssuer(issuer string) bool {
+	lower := strings.ToLower(issuer)
+	for _, e := range w.cfg.ExpectedIssuers {
+		if e = strings.ToLower(strings.TrimSpace(e)); e != "" && strings.Contains(lower, e) {
+			return true
+		}
+	}
+	return false
+}
+
+// fetch 检索域名（含全部子域名）在 CT 日志中的证书记录
+func (w *Watcher) fetch(domain string) ([]Certificate, error) {
+	u := strings.TrimRight(w.cfg.SourceURL, "/") + "/?q=" + url.QueryEscape("%."+domain) + "&output=json&exclude=expired"
+	resp, err := w.client.Get(u)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("检索服务返回状态码%d", resp.StatusCode)
+	}
+
+	var certs []Certificate
+	if err := json.NewDecoder(resp.Body).Decode(&certs); err != nil {
+		return nil, fmt.Errorf("解析检索结果失败：%w", err)
+	}
+	return certs, nil
+}
+
+// Status 返回监控状态与最近的发现，最新的排在前面
+func (w *Watcher) Status() (lastPoll time.Time, lastErr string, findings []*Finding) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.lastPoll, w.lastErr, append([]*Finding(nil), w.findings...)
+}
+
+// newCTAlert 构建非预期证书告警
+func newCTAlert(f *Finding, sourceURL string) *alert.Alert {
+	c := f.Certificate
+	names := strings.ReplaceAll(c.NameValue, "\n", ", ")
+	return &alert.Alert{
+		TargetURL: f.Domain,
+		Status:    alert.StatusFiring,
+		Title:     fmt.Sprintf("【证书透明度】发现 %s 的非预期证书", f.Domain),
+		Body: fmt.Sprintf("签发者：%s\n包含域名：%s\n有效期：%s ~ %s\n序列号：%s\n详情：%s/?id=%d",
+			c.IssuerName, names, c.NotBefore, c.NotAfter, c.SerialNumber, strings.TrimRight(sourceURL, "/"), c.ID),
+		FiredAt: f.FoundAt,
+	}
+}
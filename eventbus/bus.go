@@ -0,0 +1,133 @@
+package eventbus
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"servicetelemetry/config"
+	"servicetelemetry/logger"
+	"servicetelemetry/metrics"
+)
+
+var log = logger.New("eventbus")
+
+// Publisher 消息队列发布接口，不同的消息队列实现该接口即可接入事件总线
+type Publisher interface {
+	// Name 返回驱动名称，用于日志
+	Name() string
+	// Publish 将消息发布到指定主题，key 为分区 / 路由键
+	Publish(topic, key string, payload []byte) error
+	// Close 释放连接
+	Close() error
+}
+
+// Bus 事件总线，异步将检查结果、状态变化和告警事件发布到消息队列
+// 发布在后台协程中完成，不阻塞检查流程；缓冲区满时丢弃事件并计数
+type Bus struct {
+	cfg       *config.EventBusConfig
+	publisher Publisher
+	queue     chan *Event
+	dropped   uint64
+	wg        sync.WaitGroup
+}
+
+// NewBus 根据配置创建事件总线，未开启时返回 nil（nil 总线的所有方法均为空操作）
+func NewBus(cfg *config.EventBusConfig) (*Bus, error) {
+	if !cfg.Enable {
+		return nil, nil
+	}
+
+	publisher, err := NewPublisher(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	b := &Bus{
+		cfg:       cfg,
+		publisher: publisher,
+		queue:     make(chan *Event, cfg.BufferSize),
+	}
+	b.wg.Add(1)
+	go b.run()
+	return b, nil
+}
+
+// NewPublisher 根据驱动名称创建消息队列发布器
+func NewPublisher(cfg *config.EventBusConfig) (Publisher, error) {
+	switch cfg.Driver {
+	case "nats":
+		return NewNATSPublisher(cfg.URL, cfg.Username, cfg.Password, cfg.Timeout), nil
+	case "kafka":
+		return NewKafkaRESTPublisher(cfg.URL, cfg.Timeout), nil
+	case "rabbitmq":
+		return NewRabbitMQPublisher(cfg.URL, cfg.Username, cfg.Password, cfg.VHost, cfg.Exchange, cfg.Timeout), nil
+	default:
+		return nil, fmt.Errorf("不支持的事件总线驱动：%s（可选 nats / kafka / rabbitmq）", cfg.Driver)
+	}
+}
+
+// Emit 投递一个事件，立即返回
+// eventType：事件类型
+// key：分区键
+// data：事件内容
+func (b *Bus) Emit(eventType, key string, data interface{}) {
+	if b == nil {
+		return
+	}
+	select {
+	case b.queue <- newEvent(eventType, key, data):
+	default:
+		if n := atomic.AddUint64(&b.dropped, 1); n%100 == 1 {
+			log.Warnf("事件总线缓冲区已满，已丢弃 %d 个事件", n)
+		}
+	}
+}
+
+// Topic 返回事件类型对应的主题名，如 servicetelemetry.results
+func (b *Bus) Topic(eventType string) string {
+	return b.cfg.TopicPrefix + "." + eventType + "s"
+}
+
+// Dropped 返回因缓冲区满被丢弃的事件数
+func (b *Bus) Dropped() uint64 {
+	if b == nil {
+		return 0
+	}
+	return atomic.LoadUint64(&b.dropped)
+}
+
+// Close 发送完缓冲区中的事件后关闭连接
+func (b *Bus) Close() error {
+	if b == nil {
+		return nil
+	}
+	close(b.queue)
+	b.wg.Wait()
+	return b.publisher.Close()
+}
+
+// run 后台发布循环，失败时重试一次
+func (b *Bus) run() {
+	defer b.wg.Done()
+	for event := range b.queue {
+		payload, err := json.Marshal(event)
+		if err != nil {
+			log.Errorf("事件序列化失败[%s]：%v", event.Type, err)
+			continue
+		}
+
+		topic := b.Topic(event.Type)
+		start := time.Now()
+		err = b.publisher.Publish(topic, event.Key, payload)
+		if err != nil {
+			err = b.publisher.Publish(topic, event.Key, payload)
+		}
+		metrics.ObserveDelivery(metrics.KindEventBus, b.publisher.Name(), err, start)
+		if err != nil {
+			log.Warnf("发布事件到[%s:%s]失败：%v", b.publisher.Name(), topic, err)
+		}
+	}
+}
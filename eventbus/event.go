@@ -0,0 +1,56 @@
+package eventbus
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"os"
+	"time"
+
+	"servicetelemetry/core"
+)
+
+// SchemaVersion 事件格式版本，格式发生不兼容变更时递增
+const SchemaVersion = "servicetelemetry.event/v1"
+
+// 事件类型
+const (
+	TypeResult     = "result"     // 每一条检查结果
+	TypeTransition = "transition" // 目标状态变化（如 success -> failed）
+	TypeIncident   = "incident"   // 告警事件开启 / 更新 / 恢复
+)
+
+// Event 事件总线消息信封，data 的结构由 type 决定
+type Event struct {
+	Schema string      `json:"schema"` // 事件格式版本
+	ID     string      `json:"id"`     // 事件唯一标识
+	Type   string      `json:"type"`   // 事件类型：result / transition / incident
+	Time   time.Time   `json:"time"`   // 事件产生时间
+	Source string      `json:"source"` // 产生事件的实例（主机名）
+	Key    string      `json:"key"`    // 分区键，通常为目标地址或事件ID
+	Data   interface{} `json:"data"`   // 事件内容
+}
+
+// TransitionData 状态变化事件内容
+type TransitionData struct {
+	TargetURL string              `json:"targetUrl"` // 目标地址
+	From      string              `json:"from"`      // 变化前状态
+	To        string              `json:"to"`        // 变化后状态
+	Result    *core.MonitorResult `json:"result"`    // 触发变化的检查结果
+}
+
+var hostname, _ = os.Hostname()
+
+// newEvent 创建事件信封
+func newEvent(eventType, key string, data interface{}) *Event {
+	buf := make([]byte, 8)
+	rand.Read(buf)
+	return &Event{
+		Schema: SchemaVersion,
+		ID:     hex.EncodeToString(buf),
+		Type:   eventType,
+		Time:   time.Now(),
+		Source: hostname,
+		Key:    key,
+		Data:   data,
+	}
+}
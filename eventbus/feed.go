@@ -0,0 +1,110 @@
+package eventbus
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Change 变更流中的一条记录，cursor 单调递增
+type Change struct {
+	Cursor uint64      `json:"cursor,string"` // 变更序号（字符串形式），长轮询时作为 since 传回
+	Type   string      `json:"type"`          // 变更类型：transition / incident
+	Time   time.Time   `json:"time"`          // 变更时间
+	Key    string      `json:"key"`           // 目标地址或事件ID
+	Data   interface{} `json:"data"`          // 变更内容，与事件总线消息的 data 一致
+}
+
+// Feed 进程内变更流：在固定大小的环形缓冲区中保留最近的状态变化与事件变更，供长轮询接口按游标读取
+// 与事件总线相互独立，未配置消息队列时同样可用；nil 变更流的所有方法均为空操作
+type Feed struct {
+	mu      sync.Mutex
+	buf     []Change      // 环形缓冲区
+	base    uint64        // 本进程的起始序号（启动时间），重启前的游标必然小于该值
+	last    uint64        // 最近一条变更的序号，没有变更时为 base
+	changed chan struct{} // 有新变更时关闭并替换，唤醒等待中的长轮询
+}
+
+// NewFeed 创建变更流，size 为保留的最近变更条数，不大于 0 时返回 nil（不开启）
+func NewFeed(size int) *Feed {
+	if size <= 0 {
+		return nil
+	}
+	base := uint64(time.Now().UnixMilli()) * 1000
+	return &Feed{buf: make([]Change, size), base: base, last: base, changed: make(chan struct{})}
+}
+
+// Publish 追加一条变更并唤醒等待中的长轮询
+// eventType：变更类型
+// key：目标地址或事件ID
+// data：变更内容
+func (f *Feed) Publish(eventType, key string, data interface{}) {
+	if f == nil {
+		return
+	}
+	f.mu.Lock()
+	f.last++
+	f.buf[f.last%uint64(len(f.buf))] = Change{Cursor: f.last, Type: eventType, Time: time.Now(), Key: key, Data: data}
+	close(f.changed)
+	f.changed = make(chan struct{})
+	f.mu.Unlock()
+}
+
+// Cursor 返回当前最新的游标，从该游标开始长轮询只会收到之后产生的变更
+func (f *Feed) Cursor() uint64 {
+	if f == nil {
+		return 0
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.last
+}
+
+// Since 读取游标之后的变更（最多 limit 条），返回变更、下一次轮询使用的游标，以及游标是否已失效
+// 游标早于缓冲区中最旧的变更（变更已被覆盖或服务已重启）或晚于最新变更时视为失效，
+// 此时从缓冲区中最旧的变更开始返回，调用方应先重新拉取全量状态
+func (f *Feed) Since(cursor uint64, limit int) ([]Change, uint64, bool) {
+	if f == nil {
+		return nil, cursor, false
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	oldest := f.base + 1
+	if f.last >= uint64(len(f.buf)) && f.last-uint64(len(f.buf))+1 > oldest {
+		oldest = f.last - uint64(len(f.buf)) + 1
+	}
+	reset := false
+	if cursor+1 < oldest || cursor > f.last {
+		cursor, reset = oldest-1, true
+	}
+	var changes []Change
+	for seq := cursor + 1; seq <= f.last && len(changes) < limit; seq++ {
+		changes = append(changes, f.buf[seq%uint64(len(f.buf))])
+	}
+	if len(changes) > 0 {
+		cursor = changes[len(changes)-1].Cursor
+	}
+	return changes, cursor, reset
+}
+
+// Wait 阻塞直到游标之后有新变更或 ctx 结束，有新变更时返回 true
+func (f *Feed) Wait(ctx context.Context, cursor uint64) bool {
+	if f == nil {
+		<-ctx.Done()
+		return false
+	}
+	for {
+		f.mu.Lock()
+		if f.last > cursor {
+			f.mu.Unlock()
+			return true
+		}
+		changed := f.changed
+		f.mu.Unlock()
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return false
+		}
+	}
+}
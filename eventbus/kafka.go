@@ -0,0 +1,184 @@
+package eventbus
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// KafkaRESTPublisher Kafka 发布器，通过 Kafka REST Proxy（v2 接口）写入主题
+type KafkaRESTPublisher struct {
+	baseURL string
+	client  *http.Client
+}
+
+// NewKafkaRESTPublisher 创建 Kafka 发布器
+// baseURL：REST Proxy 地址，如 http://kafka-rest:8082
+// timeout：请求超时
+func NewKafkaRESTPublisher(baseURL string, timeout time.Duration) *KafkaRESTPublisher {
+	return &KafkaRESTPublisher{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		client:  &http.Client{Timeout: timeout},
+	}
+}
+
+// Name 返回驱动名称
+func (p *KafkaRESTPublisher) Name() string { return "kafka" }
+
+// Publish 发布消息到主题，key 决定分区
+func (p *KafkaRESTPublisher) Publish(topic, key string, payload []byte) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"records": []map[string]interface{}{
+			{"key": key, "value": json.RawMessage(payload)},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, p.baseURL+"/topics/"+topic, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/vnd.kafka.json.v2+json")
+	req.Header.Set("Accept", "application/vnd.kafka.v2+json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("请求Kafka REST Proxy失败：%w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("Kafka REST Proxy返回状态码%d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Close 无需释放资源
+func (p *KafkaRESTPublisher) Close() error { return nil }
+
+// KafkaRESTSubscriber Kafka 订阅器，通过 Kafka REST Proxy（v2 接口）的消费者实例拉取消息，偏移量自动提交
+type KafkaRESTSubscriber struct {
+	baseURL string
+	group   string
+	client  *http.Client
+	loop    *pollLoop
+
+	mu      sync.Mutex
+	baseURI string // 消费者实例地址，由 REST Proxy 创建时返回
+}
+
+// NewKafkaRESTSubscriber 创建 Kafka 订阅器
+// baseURL：REST Proxy 地址，如 http://kafka-rest:8082
+// group：消费者组名称
+// timeout：请求超时
+func NewKafkaRESTSubscriber(baseURL, group string, timeout time.Duration) *KafkaRESTSubscriber {
+	return &KafkaRESTSubscriber{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		group:   group,
+		client:  &http.Client{Timeout: timeout},
+		loop:    newPollLoop("kafka"),
+	}
+}
+
+// Name 返回驱动名称
+func (s *KafkaRESTSubscriber) Name() string { return "kafka" }
+
+// Start 在后台订阅主题，消费者实例失效时自动重建
+func (s *KafkaRESTSubscriber) Start(topic string, handle func(payload []byte)) {
+	go s.loop.run(func() ([][]byte, error) {
+		return s.poll(topic)
+	}, handle)
+}
+
+// poll 拉取一批消息，首次调用或实例失效时先创建消费者实例并订阅主题
+func (s *KafkaRESTSubscriber) poll(topic string) ([][]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.baseURI == "" {
+		if err := s.createConsumer(topic); err != nil {
+			return nil, err
+		}
+	}
+
+	var records []struct {
+		Value json.RawMessage `json:"value"`
+	}
+	if err := s.call(http.MethodGet, s.baseURI+"/records", nil, &records); err != nil {
+		s.baseURI = ""
+		return nil, err
+	}
+
+	messages := make([][]byte, 0, len(records))
+	for _, r := range records {
+		messages = append(messages, r.Value)
+	}
+	return messages, nil
+}
+
+// createConsumer 在消费者组中创建实例并订阅主题
+func (s *KafkaRESTSubscriber) createConsumer(topic string) error {
+	var created struct {
+		BaseURI string `json:"base_uri"`
+	}
+	body := map[string]interface{}{"format": "json", "auto.offset.reset": "latest"}
+	if err := s.call(http.MethodPost, s.baseURL+"/consumers/"+s.group, body, &created); err != nil {
+		return fmt.Errorf("创建消费者实例失败：%w", err)
+	}
+	if err := s.call(http.MethodPost, created.BaseURI+"/subscription", map[string]interface{}{"topics": []string{topic}}, nil); err != nil {
+		return fmt.Errorf("订阅主题失败：%w", err)
+	}
+	s.baseURI = created.BaseURI
+	return nil
+}
+
+// call 调用 REST Proxy 接口，out 不为 nil 时解析响应
+func (s *KafkaRESTSubscriber) call(method, url string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/vnd.kafka.v2+json")
+	req.Header.Set("Accept", "application/vnd.kafka.json.v2+json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("请求Kafka REST Proxy失败：%w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("Kafka REST Proxy返回状态码%d", resp.StatusCode)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// Close 停止拉取并删除消费者实例
+func (s *KafkaRESTSubscriber) Close() error {
+	s.loop.close()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.baseURI == "" {
+		return nil
+	}
+	err := s.call(http.MethodDelete, s.baseURI, nil, nil)
+	s.baseURI = ""
+	return err
+}
@@ -0,0 +1,238 @@
+package eventbus
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// NATSPublisher NATS 发布器，直接使用 NATS 文本协议，断线后在下一次发布时自动重连
+type NATSPublisher struct {
+	addr     string
+	user     string
+	password string
+	timeout  time.Duration
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+// NewNATSPublisher 创建 NATS 发布器
+// rawURL：服务地址，如 nats://127.0.0.1:4222
+// user、password：认证信息（可选）
+// timeout：连接与写入超时
+func NewNATSPublisher(rawURL, user, password string, timeout time.Duration) *NATSPublisher {
+	addr := rawURL
+	if u, err := url.Parse(rawURL); err == nil && u.Host != "" {
+		addr = u.Host
+		if u.User != nil && user == "" {
+			user = u.User.Username()
+			password, _ = u.User.Password()
+		}
+	}
+	return &NATSPublisher{addr: addr, user: user, password: password, timeout: timeout}
+}
+
+// Name 返回驱动名称
+func (p *NATSPublisher) Name() string { return "nats" }
+
+// Publish 发布消息到主题（NATS 无分区概念，忽略 key）
+func (p *NATSPublisher) Publish(topic, key string, payload []byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.conn == nil {
+		conn, err := dialNATS(p.addr, p.user, p.password, p.timeout)
+		if err != nil {
+			return err
+		}
+		p.conn = conn
+		go discardNATS(conn)
+	}
+
+	p.conn.SetWriteDeadline(time.Now().Add(p.timeout))
+	msg := fmt.Sprintf("PUB %s %d\r\n", topic, len(payload))
+	if _, err := p.conn.Write(append(append([]byte(msg), payload...), '\r', '\n')); err != nil {
+		p.conn.Close()
+		p.conn = nil
+		return fmt.Errorf("写入NATS失败：%w", err)
+	}
+	return nil
+}
+
+// Close 关闭连接
+func (p *NATSPublisher) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.conn == nil {
+		return nil
+	}
+	err := p.conn.Close()
+	p.conn = nil
+	return err
+}
+
+// dialNATS 建立 NATS 连接：读取服务端 INFO，发送 CONNECT 并通过 PING/PONG 确认握手成功
+func dialNATS(addr, user, password string, timeout time.Duration) (net.Conn, error) {
+	conn, err := net.DialTimeout("tcp", addr, timeout)
+	if err != nil {
+		return nil, fmt.Errorf("连接NATS失败：%w", err)
+	}
+	conn.SetDeadline(time.Now().Add(timeout))
+	reader := bufio.NewReader(conn)
+
+	line, err := reader.ReadString('\n')
+	if err != nil || !strings.HasPrefix(line, "INFO") {
+		conn.Close()
+		return nil, fmt.Errorf("NATS握手失败：未收到INFO")
+	}
+
+	opts := map[string]interface{}{"verbose": false, "pedantic": false, "name": "servicetelemetry", "lang": "go"}
+	if user != "" {
+		opts["user"] = user
+		opts["pass"] = password
+	}
+	connect, _ := json.Marshal(opts)
+	if _, err := conn.Write([]byte("CONNECT " + string(connect) + "\r\nPING\r\n")); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("NATS握手失败：%w", err)
+	}
+
+	line, err = reader.ReadString('\n')
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("NATS握手失败：%w", err)
+	}
+	if strings.HasPrefix(line, "-ERR") {
+		conn.Close()
+		return nil, fmt.Errorf("NATS握手失败：%s", strings.TrimSpace(line))
+	}
+	conn.SetDeadline(time.Time{})
+	return conn, nil
+}
+
+// discardNATS 读取发布连接上的服务端消息，响应 PING 保持连接
+func discardNATS(conn net.Conn) {
+	reader := bufio.NewReader(conn)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		if strings.HasPrefix(line, "PING") {
+			conn.Write([]byte("PONG\r\n"))
+		}
+	}
+}
+
+// NATSSubscriber NATS 订阅器，配置队列组时多个实例之间负载均衡消费
+type NATSSubscriber struct {
+	addr     string
+	user     string
+	password string
+	group    string
+	timeout  time.Duration
+
+	mu     sync.Mutex
+	conn   net.Conn
+	closed bool
+}
+
+// NewNATSSubscriber 创建 NATS 订阅器
+// rawURL：服务地址，如 nats://127.0.0.1:4222
+// user、password：认证信息（可选）
+// group：队列组名称，为空时每个实例都收到全部消息
+// timeout：连接超时
+func NewNATSSubscriber(rawURL, user, password, group string, timeout time.Duration) *NATSSubscriber {
+	p := NewNATSPublisher(rawURL, user, password, timeout)
+	return &NATSSubscriber{addr: p.addr, user: p.user, password: p.password, group: group, timeout: timeout}
+}
+
+// Name 返回驱动名称
+func (s *NATSSubscriber) Name() string { return "nats" }
+
+// Start 在后台订阅主题，断线后自动重连
+func (s *NATSSubscriber) Start(topic string, handle func(payload []byte)) {
+	go func() {
+		for {
+			err := s.consume(topic, handle)
+			s.mu.Lock()
+			closed := s.closed
+			s.mu.Unlock()
+			if closed {
+				return
+			}
+			log.Warnf("[nats]订阅[%s]中断，%s后重连：%v", topic, retryDelay, err)
+			time.Sleep(retryDelay)
+		}
+	}()
+}
+
+// consume 建立连接并持续读取消息，直到连接断开
+func (s *NATSSubscriber) consume(topic string, handle func(payload []byte)) error {
+	conn, err := dialNATS(s.addr, s.user, s.password, s.timeout)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		conn.Close()
+		return nil
+	}
+	s.conn = conn
+	s.mu.Unlock()
+	defer conn.Close()
+
+	sub := "SUB " + topic + " 1\r\n"
+	if s.group != "" {
+		sub = "SUB " + topic + " " + s.group + " 1\r\n"
+	}
+	if _, err := conn.Write([]byte(sub)); err != nil {
+		return err
+	}
+
+	reader := bufio.NewReader(conn)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return err
+		}
+		switch {
+		case strings.HasPrefix(line, "PING"):
+			conn.Write([]byte("PONG\r\n"))
+		case strings.HasPrefix(line, "-ERR"):
+			return fmt.Errorf("服务端错误：%s", strings.TrimSpace(line))
+		case strings.HasPrefix(line, "MSG "):
+			// MSG <subject> <sid> [reply-to] <#bytes>
+			fields := strings.Fields(line)
+			size, err := strconv.Atoi(fields[len(fields)-1])
+			if err != nil {
+				return fmt.Errorf("消息头格式错误：%s", strings.TrimSpace(line))
+			}
+			payload := make([]byte, size+2)
+			if _, err := io.ReadFull(reader, payload); err != nil {
+				return err
+			}
+			handle(payload[:size])
+		}
+	}
+}
+
+// Close 停止订阅
+func (s *NATSSubscriber) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	if s.conn != nil {
+		return s.conn.Close()
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+package eventbus
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// RabbitMQPublisher RabbitMQ 发布器，通过管理插件的 HTTP 接口发布到交换机，主题作为路由键
+type RabbitMQPublisher struct {
+	baseURL  string
+	user     string
+	password string
+	vhost    string
+	exchange string
+	client   *http.Client
+}
+
+// NewRabbitMQPublisher 创建 RabbitMQ 发布器
+// baseURL：管理接口地址，如 http://rabbitmq:15672
+// user、password：管理接口认证信息
+// vhost：虚拟主机，为空时使用 /
+// exchange：交换机名称，为空时使用 amq.topic
+// timeout：请求超时
+func NewRabbitMQPublisher(baseURL, user, password, vhost, exchange string, timeout time.Duration) *RabbitMQPublisher {
+	if vhost == "" {
+		vhost = "/"
+	}
+	if exchange == "" {
+		exchange = "amq.topic"
+	}
+	return &RabbitMQPublisher{
+		baseURL:  strings.TrimRight(baseURL, "/"),
+		user:     user,
+		password: password,
+		vhost:    vhost,
+		exchange: exchange,
+		client:   &http.Client{Timeout: timeout},
+	}
+}
+
+// Name 返回驱动名称
+func (p *RabbitMQPublisher) Name() string { return "rabbitmq" }
+
+// Publish 以主题为路由键发布消息，key 写入消息头 x-key
+func (p *RabbitMQPublisher) Publish(topic, key string, payload []byte) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"properties": map[string]interface{}{
+			"content_type":  "application/json",
+			"delivery_mode": 2,
+			"headers":       map[string]string{"x-key": key},
+		},
+		"routing_key":      topic,
+		"payload":          string(payload),
+		"payload_encoding": "string",
+	})
+	if err != nil {
+		return err
+	}
+
+	resp, err := p.do(http.MethodPost, "/api/exchanges/"+url.PathEscape(p.vhost)+"/"+url.PathEscape(p.exchange)+"/publish", body)
+	if err != nil {
+		return err
+	}
+	// 未绑定队列时消息不会被路由（响应 routed=false），属于订阅方配置问题，不视为发布失败
+	resp.Body.Close()
+	return nil
+}
+
+// do 调用管理接口，非 2xx 状态码返回错误
+func (p *RabbitMQPublisher) do(method, path string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequest(method, p.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.SetBasicAuth(p.user, p.password)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("请求RabbitMQ管理接口失败：%w", err)
+	}
+	if resp.StatusCode >= 300 {
+		resp.Body.Close()
+		return nil, fmt.Errorf("RabbitMQ管理接口返回状态码%d", resp.StatusCode)
+	}
+	return resp, nil
+}
+
+// Close 无需释放资源
+func (p *RabbitMQPublisher) Close() error { return nil }
+
+// RabbitMQSubscriber RabbitMQ 订阅器，通过管理插件的 HTTP 接口从队列拉取消息（取出即确认），主题即队列名
+type RabbitMQSubscriber struct {
+	api  *RabbitMQPublisher
+	loop *pollLoop
+}
+
+// NewRabbitMQSubscriber 创建 RabbitMQ 订阅器
+// baseURL：管理接口地址，如 http://rabbitmq:15672
+// user、password：管理接口认证信息
+// vhost：虚拟主机，为空时使用 /
+// timeout：请求超时
+func NewRabbitMQSubscriber(baseURL, user, password, vhost string, timeout time.Duration) *RabbitMQSubscriber {
+	return &RabbitMQSubscriber{
+		api:  NewRabbitMQPublisher(baseURL, user, password, vhost, "", timeout),
+		loop: newPollLoop("rabbitmq"),
+	}
+}
+
+// Name 返回驱动名称
+func (s *RabbitMQSubscriber) Name() string { return "rabbitmq" }
+
+// Start 在后台从队列拉取消息
+func (s *RabbitMQSubscriber) Start(queue string, handle func(payload []byte)) {
+	go s.loop.run(func() ([][]byte, error) {
+		return s.poll(queue)
+	}, handle)
+}
+
+// poll 从队列取出一批消息
+func (s *RabbitMQSubscriber) poll(queue string) ([][]byte, error) {
+	body, _ := json.Marshal(map[string]interface{}{
+		"count":    10,
+		"ackmode":  "ack_requeue_false",
+		"encoding": "auto",
+	})
+	resp, err := s.api.do(http.MethodPost, "/api/queues/"+url.PathEscape(s.api.vhost)+"/"+url.PathEscape(queue)+"/get", body)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var items []struct {
+		Payload         string `json:"payload"`
+		PayloadEncoding string `json:"payload_encoding"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&items); err != nil {
+		return nil, fmt.Errorf("解析队列消息失败：%w", err)
+	}
+
+	messages := make([][]byte, 0, len(items))
+	for _, item := range items {
+		if item.PayloadEncoding == "base64" {
+			data, err := base64.StdEncoding.DecodeString(item.Payload)
+			if err != nil {
+				continue
+			}
+			messages = append(messages, data)
+			continue
+		}
+		messages = append(messages, []byte(item.Payload))
+	}
+	return messages, nil
+}
+
+// Close 停止拉取
+func (s *RabbitMQSubscriber) Close() error {
+	s.loop.close()
+	return nil
+}
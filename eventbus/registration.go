@@ -0,0 +1,52 @@
+package eventbus
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"servicetelemetry/config"
+)
+
+// 目标注册消息动作
+const (
+	ActionRegister   = "register"   // 注册（或更新）监控目标
+	ActionDeregister = "deregister" // 注销监控目标，停止检查
+)
+
+// RegistrationMessage 目标注册 / 注销消息，由外部发布流水线（如服务上下线事件）发布到注册主题
+type RegistrationMessage struct {
+	Action     string   `json:"action"`     // 动作：register / deregister
+	URL        string   `json:"url"`        // 目标地址
+	Keyword    string   `json:"keyword"`    // 响应体匹配关键词（可选）
+	Priority   string   `json:"priority"`   // 优先级（可选）
+	Tags       []string `json:"tags"`       // 目标标签（可选）
+	Assertions []string `json:"assertions"` // 响应断言（可选）
+	config.TargetOptions
+}
+
+// ParseRegistration 解析目标注册消息
+func ParseRegistration(payload []byte) (*RegistrationMessage, error) {
+	var msg RegistrationMessage
+	if err := json.Unmarshal(payload, &msg); err != nil {
+		return nil, fmt.Errorf("注册消息格式错误：%w", err)
+	}
+	if msg.Action != ActionRegister && msg.Action != ActionDeregister {
+		return nil, fmt.Errorf("注册消息动作无效：%q（可选 register / deregister）", msg.Action)
+	}
+	if msg.URL == "" {
+		return nil, fmt.Errorf("注册消息缺少目标地址")
+	}
+	return &msg, nil
+}
+
+// Definition 转换为声明式目标定义
+func (m *RegistrationMessage) Definition() config.TargetDefinition {
+	return config.TargetDefinition{
+		URL:           m.URL,
+		Keyword:       m.Keyword,
+		Priority:      m.Priority,
+		Tags:          m.Tags,
+		Assertions:    m.Assertions,
+		TargetOptions: m.TargetOptions,
+	}
+}
@@ -0,0 +1,80 @@
+package eventbus
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"servicetelemetry/config"
+)
+
+const (
+	pollInterval = time.Second     // 拉取模式下队列为空时的等待间隔
+	retryDelay   = 5 * time.Second // 连接或拉取失败后的重试间隔
+)
+
+// Subscriber 消息队列订阅接口，用于消费外部系统发布的消息
+type Subscriber interface {
+	// Name 返回驱动名称，用于日志
+	Name() string
+	// Start 在后台订阅主题，每条消息调用一次 handle，连接异常时自动重连
+	Start(topic string, handle func(payload []byte))
+	// Close 停止订阅并释放连接
+	Close() error
+}
+
+// NewSubscriber 根据驱动名称创建消息队列订阅器
+func NewSubscriber(cfg *config.EventBusConfig) (Subscriber, error) {
+	switch cfg.Driver {
+	case "nats":
+		return NewNATSSubscriber(cfg.URL, cfg.Username, cfg.Password, cfg.ConsumerGroup, cfg.Timeout), nil
+	case "kafka":
+		return NewKafkaRESTSubscriber(cfg.URL, cfg.ConsumerGroup, cfg.Timeout), nil
+	case "rabbitmq":
+		return NewRabbitMQSubscriber(cfg.URL, cfg.Username, cfg.Password, cfg.VHost, cfg.Timeout), nil
+	default:
+		return nil, fmt.Errorf("不支持的事件总线驱动：%s（可选 nats / kafka / rabbitmq）", cfg.Driver)
+	}
+}
+
+// pollLoop 拉取模式的通用订阅循环（Kafka REST Proxy、RabbitMQ 管理接口）
+type pollLoop struct {
+	name string
+	stop chan struct{}
+	once sync.Once
+}
+
+func newPollLoop(name string) *pollLoop {
+	return &pollLoop{name: name, stop: make(chan struct{})}
+}
+
+// run 循环调用 poll 拉取消息，队列为空时等待 pollInterval，失败时等待 retryDelay 后重试
+func (l *pollLoop) run(poll func() ([][]byte, error), handle func(payload []byte)) {
+	for {
+		messages, err := poll()
+		if err != nil {
+			log.Warnf("[%s]拉取消息失败，%s后重试：%v", l.name, retryDelay, err)
+		}
+		for _, payload := range messages {
+			handle(payload)
+		}
+
+		wait := time.Duration(0)
+		switch {
+		case err != nil:
+			wait = retryDelay
+		case len(messages) == 0:
+			wait = pollInterval
+		}
+		select {
+		case <-l.stop:
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// close 停止循环，可重复调用
+func (l *pollLoop) close() {
+	l.once.Do(func() { close(l.stop) })
+}
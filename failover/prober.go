@@ -0,0 +1,291 @@
+package failover
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"servicetelemetry/alert"
+	"servicetelemetry/config"
+	"servicetelemetry/core"
+	"servicetelemetry/logger"
+)
+
+var log = logger.New("failover")
+
+// 演练结论
+const (
+	VerdictOK            = "ok"             // 主备一致，备用路径可用
+	VerdictDrift         = "drift"          // 备用路径可用，但响应与主路径不一致
+	VerdictSecondaryDown = "secondary_down" // 主路径正常，备用路径不可用
+	VerdictPrimaryDown   = "primary_down"   // 主路径异常，备用路径可用（与故障切换无关，仅记录）
+	VerdictBothDown      = "both_down"      // 主备均不可用
+)
+
+// maxHistory 每组主备路径保留的最近报告数
+const maxHistory = 20
+
+// latencyFloor 主路径耗时过小时按该值比较，避免毫秒级抖动被放大为差异
+const latencyFloor = 50 * time.Millisecond
+
+// Drift 主备响应的一处差异
+type Drift struct {
+	Field     string `json:"field"`     // 差异字段：status / statusCode / keyword / certificate / latency / errorType
+	Primary   string `json:"primary"`   // 主路径的值
+	Secondary string `json:"secondary"` // 备用路径的值
+}
+
+// Report 一组主备路径的演练报告
+type Report struct {
+	Name      string              `json:"name"`      // 主备路径名称
+	Verdict   string              `json:"verdict"`   // 演练结论
+	Drifts    []Drift             `json:"drifts"`    // 主备响应差异
+	Primary   *core.MonitorResult `json:"primary"`   // 主路径检查结果
+	Secondary *core.MonitorResult `json:"secondary"` // 备用路径检查结果
+	CheckedAt time.Time           `json:"checkedAt"` // 演练时间
+}
+
+// Prober 故障切换路径演练器，定期同时检查主备路径并对比响应，备用路径不可用或出现差异时通过告警渠道通知
+// 同一结论只在首次出现时告警，恢复一致时发送恢复通知
+type Prober struct {
+	cfg     *config.FailoverConfig
+	checker *core.ServiceChecker
+	alerts  *alert.Manager
+
+	runMu   sync.Mutex // 保证同一时间只有一轮演练
+	mu      sync.Mutex
+	history map[string][]*Report // 名称 -> 最近的报告（最新的在前）
+	lastRun time.Time
+}
+
+// NewProber 创建故障切换路径演练器
+// cfg：故障切换路径演练配置
+// checker：服务检查器，主备路径使用与普通目标相同的检查逻辑（含 hostHeader / sni / 断言）
+// alerts：告警管理器
+func NewProber(cfg *config.FailoverConfig, checker *core.ServiceChecker, alerts *alert.Manager) *Prober {
+	return &Prober{
+		cfg:     cfg,
+		checker: checker,
+		alerts:  alerts,
+		history: make(map[string][]*Report),
+	}
+}
+
+// Start 启动后台定期演练
+func (p *Prober) Start() {
+	go func() {
+		p.Run()
+		ticker := time.NewTicker(p.cfg.Interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			p.Run()
+		}
+	}()
+}
+
+// Validate 校验主备路径定义，返回发现的第一个问题
+func Validate(cfg *config.FailoverConfig) error {
+	seen := make(map[string]bool)
+	for i, pair := range cfg.Pairs {
+		if pair.Name == "" {
+			return fmt.Errorf("第%d组主备路径缺少名称", i+1)
+		}
+		if seen[pair.Name] {
+			return fmt.Errorf("主备路径名称重复：%s", pair.Name)
+		}
+		seen[pair.Name] = true
+		for role, def := range map[string]config.TargetDefinition{"primary": pair.Primary, "secondary": pair.Secondary} {
+			if errs := core.ValidateTarget(core.TargetFromDefinition(def)); len(errs) > 0 {
+				return fmt.Errorf("主备路径[%s]的 %s 定义无效：%v", pair.Name, role, errs[0])
+			}
+		}
+	}
+	return nil
+}
+
+// Run 执行一轮演练，主备路径并发检查，返回本轮全部报告
+func (p *Prober) Run() []*Report {
+	p.runMu.Lock()
+	defer p.runMu.Unlock()
+
+	reports := make([]*Report, len(p.cfg.Pairs))
+	var wg sync.WaitGroup
+	for i, pair := range p.cfg.Pairs {
+		wg.Add(1)
+		go func(i int, pair config.FailoverPair) {
+			defer wg.Done()
+			reports[i] = p.probe(pair)
+		}(i, pair)
+	}
+	wg.Wait()
+
+	p.mu.Lock()
+	p.lastRun = time.Now()
+	var changed []*Report
+	var recovered []*Report
+	for _, r := range reports {
+		prev := p.history[r.Name]
+		list := append([]*Report{r}, prev...)
+		if len(list) > maxHistory {
+			list = list[:maxHistory]
+		}
+		p.history[r.Name] = list
+
+		switch {
+		case len(prev) > 0 && prev[0].Verdict == r.Verdict:
+		case needsAlert(r.Verdict):
+			changed = append(changed, r)
+		case r.Verdict == VerdictOK && len(prev) > 0 && needsAlert(prev[0].Verdict):
+			recovered = append(recovered, r)
+		}
+	}
+	p.mu.Unlock()
+
+	for _, r := range changed {
+		log.Warnf("故障切换演练[%s]：%s", r.Name, describe(r))
+		p.alerts.Notify(newFailoverAlert(r, alert.StatusFiring))
+	}
+	for _, r := range recovered {
+		log.Infof("故障切换演练[%s]已恢复一致", r.Name)
+		p.alerts.Notify(newFailoverAlert(r, alert.StatusResolved))
+	}
+	return reports
+}
+
+// probe 同时检查一组主备路径并对比
+func (p *Prober) probe(pair config.FailoverPair) *Report {
+	var primary, secondary *core.MonitorResult
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		primary = p.checker.Probe(core.TargetFromDefinition(pair.Primary))
+	}()
+	go func() {
+		defer wg.Done()
+		secondary = p.checker.Probe(core.TargetFromDefinition(pair.Secondary))
+	}()
+	wg.Wait()
+
+	r := &Report{
+		Name:      pair.Name,
+		Primary:   primary,
+		Secondary: secondary,
+		CheckedAt: time.Now(),
+	}
+	r.Drifts = compare(primary, secondary, p.cfg.LatencyRatio)
+
+	primaryOK, secondaryOK := primary.Status == "success", secondary.Status == "success"
+	switch {
+	case !primaryOK && !secondaryOK:
+		r.Verdict = VerdictBothDown
+	case !secondaryOK:
+		r.Verdict = VerdictSecondaryDown
+	case !primaryOK:
+		r.Verdict = VerdictPrimaryDown
+	case len(r.Drifts) > 0:
+		r.Verdict = VerdictDrift
+	default:
+		r.Verdict = VerdictOK
+	}
+	return r
+}
+
+// compare 对比主备检查结果，返回差异列表
+// latencyRatio：备用路径耗时超过主路径的倍数时记为差异，0 表示不比较
+func compare(primary, secondary *core.MonitorResult, latencyRatio float64) []Drift {
+	var drifts []Drift
+	add := func(field, a, b string) {
+		if a != b {
+			drifts = append(drifts, Drift{Field: field, Primary: a, Secondary: b})
+		}
+	}
+
+	add("status", primary.Status, secondary.Status)
+	add("errorType", primary.ErrorType, secondary.ErrorType)
+	if primary.Status != "success" || secondary.Status != "success" {
+		return drifts
+	}
+	add("statusCode", fmt.Sprint(primary.StatusCode), fmt.Sprint(secondary.StatusCode))
+	add("keyword", fmt.Sprint(primary.KeywordMatched), fmt.Sprint(secondary.KeywordMatched))
+	// 证书剩余天数不同说明备用路径使用了另一张证书，切换后可能提前过期
+	add("certificate", primary.SSLCertExpiry, secondary.SSLCertExpiry)
+
+	if latencyRatio > 0 {
+		base := primary.ResponseTime
+		if floor := float64(latencyFloor.Milliseconds()); base < floor {
+			base = floor
+		}
+		if secondary.ResponseTime > base*latencyRatio {
+			drifts = append(drifts, Drift{
+				Field:     "latency",
+				Primary:   fmt.Sprintf("%.0fms", primary.ResponseTime),
+				Secondary: fmt.Sprintf("%.0fms", secondary.ResponseTime),
+			})
+		}
+	}
+	return drifts
+}
+
+// needsAlert 判断结论是否需要告警（主路径异常由普通监控负责，这里不重复告警）
+func needsAlert(verdict string) bool {
+	return verdict == VerdictDrift || verdict == VerdictSecondaryDown || verdict == VerdictBothDown
+}
+
+// Reports 返回各组主备路径的最新报告及上一轮演练时间
+func (p *Prober) Reports() (time.Time, []*Report) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	latest := make([]*Report, 0, len(p.cfg.Pairs))
+	for _, pair := range p.cfg.Pairs {
+		if list := p.history[pair.Name]; len(list) > 0 {
+			latest = append(latest, list[0])
+		}
+	}
+	return p.lastRun, latest
+}
+
+// History 返回指定主备路径最近的报告（最新的在前），名称不存在时返回 nil
+func (p *Prober) History(name string) []*Report {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]*Report(nil), p.history[name]...)
+}
+
+// describe 演练结论的可读描述
+func describe(r *Report) string {
+	switch r.Verdict {
+	case VerdictSecondaryDown:
+		return fmt.Sprintf("备用路径不可用：%s", r.Secondary.ErrorMsg)
+	case VerdictBothDown:
+		return "主备路径均不可用"
+	case VerdictPrimaryDown:
+		return "主路径异常，备用路径可用"
+	case VerdictDrift:
+		parts := make([]string, 0, len(r.Drifts))
+		for _, d := range r.Drifts {
+			parts = append(parts, fmt.Sprintf("%s 主=%s 备=%s", d.Field, d.Primary, d.Secondary))
+		}
+		return "主备响应不一致：" + strings.Join(parts, "；")
+	}
+	return "主备一致"
+}
+
+// newFailoverAlert 构建故障切换演练告警
+func newFailoverAlert(r *Report, status string) *alert.Alert {
+	title := fmt.Sprintf("【故障切换演练】%s：%s", r.Name, describe(r))
+	if status == alert.StatusResolved {
+		title = fmt.Sprintf("【故障切换演练】%s 主备已恢复一致", r.Name)
+	}
+	return &alert.Alert{
+		TargetURL: r.Secondary.TargetURL,
+		Status:    status,
+		Title:     title,
+		Body: fmt.Sprintf("主路径：%s（%s，%.0fms）\n备用路径：%s（%s，%.0fms）\n演练时间：%s",
+			r.Primary.TargetURL, r.Primary.Status, r.Primary.ResponseTime,
+			r.Secondary.TargetURL, r.Secondary.Status, r.Secondary.ResponseTime,
+			r.CheckedAt.Format("2006-01-02 15:04:05")),
+		FiredAt: r.CheckedAt,
+	}
+}
@@ -0,0 +1,193 @@
+package logger
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Level 日志级别
+type Level int
+
+const (
+	LevelDebug Level = iota // 调试信息
+	LevelInfo               // 一般信息
+	LevelWarn               // 警告（可自动恢复的失败）
+	LevelError              // 错误
+)
+
+// levelNames 日志级别名称
+var levelNames = map[Level]string{
+	LevelDebug: "debug",
+	LevelInfo:  "info",
+	LevelWarn:  "warn",
+	LevelError: "error",
+}
+
+func (l Level) String() string {
+	return levelNames[l]
+}
+
+// ParseLevel 解析日志级别名称（不区分大小写），warning 视为 warn
+func ParseLevel(s string) (Level, error) {
+	name := strings.ToLower(strings.TrimSpace(s))
+	if name == "warning" {
+		name = "warn"
+	}
+	for l, n := range levelNames {
+		if n == name {
+			return l, nil
+		}
+	}
+	return LevelInfo, fmt.Errorf("无效的日志级别：%s（可选 debug / info / warn / error）", s)
+}
+
+// Modules 支持单独调整日志级别的模块
+var Modules = []string{"core", "storage", "agent", "api", "alert", "scheduler", "eventbus", "ctwatch", "snapshot", "subscription", "failover", "probe"}
+
+var (
+	mu        sync.RWMutex
+	global              = LevelInfo
+	overrides           = make(map[string]Level) // 模块 -> 单独设置的级别
+	output    io.Writer = os.Stdout
+)
+
+// Logger 模块日志记录器，按「模块单独级别 > 全局级别」判断是否输出
+type Logger struct {
+	module string
+}
+
+// New 创建模块日志记录器，各包以包级变量 log 持有，级别可通过管理接口按模块单独调整
+// module：模块名，应为 Modules 之一
+func New(module string) *Logger {
+	return &Logger{module: module}
+}
+
+// Enabled 判断指定级别的日志当前是否会输出，可用于跳过代价较高的日志参数计算
+func (l *Logger) Enabled(level Level) bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	min, ok := overrides[l.module]
+	if !ok {
+		min = global
+	}
+	return level >= min
+}
+
+// Debugf 输出调试日志
+func (l *Logger) Debugf(format string, args ...interface{}) {
+	l.logf(LevelDebug, format, args...)
+}
+
+// Infof 输出一般日志
+func (l *Logger) Infof(format string, args ...interface{}) {
+	l.logf(LevelInfo, format, args...)
+}
+
+// Warnf 输出警告日志
+func (l *Logger) Warnf(format string, args ...interface{}) {
+	l.logf(LevelWarn, format, args...)
+}
+
+// Errorf 输出错误日志
+func (l *Logger) Errorf(format string, args ...interface{}) {
+	l.logf(LevelError, format, args...)
+}
+
+func (l *Logger) logf(level Level, format string, args ...interface{}) {
+	if !l.Enabled(level) {
+		return
+	}
+	msg := fmt.Sprintf(format, args...)
+	fmt.Fprintf(output, "%s [%s] [%s] %s\n", time.Now().Format("2006-01-02 15:04:05"), strings.ToUpper(level.String()), l.module, strings.TrimRight(msg, "\n"))
+}
+
+// Configure 按配置初始化全局级别与各模块级别（启动时调用）
+// level：全局日志级别
+// modules：模块 -> 日志级别，可为空
+func Configure(level string, modules map[string]string) error {
+	if err := SetGlobalLevel(level); err != nil {
+		return err
+	}
+	for module, l := range modules {
+		if err := SetModuleLevel(module, l); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetGlobalLevel 设置全局日志级别，未单独设置级别的模块使用该级别
+func SetGlobalLevel(level string) error {
+	l, err := ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	mu.Lock()
+	global = l
+	mu.Unlock()
+	return nil
+}
+
+// SetModuleLevel 单独设置模块的日志级别
+func SetModuleLevel(module, level string) error {
+	if !knownModule(module) {
+		return fmt.Errorf("未知的日志模块：%s（可选 %s）", module, strings.Join(Modules, " / "))
+	}
+	l, err := ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	mu.Lock()
+	overrides[module] = l
+	mu.Unlock()
+	return nil
+}
+
+// ResetModuleLevel 取消模块的单独级别，恢复使用全局级别
+func ResetModuleLevel(module string) error {
+	if !knownModule(module) {
+		return fmt.Errorf("未知的日志模块：%s（可选 %s）", module, strings.Join(Modules, " / "))
+	}
+	mu.Lock()
+	delete(overrides, module)
+	mu.Unlock()
+	return nil
+}
+
+// ModuleLevel 模块当前的日志级别
+type ModuleLevel struct {
+	Module   string `json:"module"`   // 模块名
+	Level    string `json:"level"`    // 生效级别
+	Override bool   `json:"override"` // 是否为单独设置（否则继承全局级别）
+}
+
+// Levels 返回全局级别与各模块当前生效的级别
+func Levels() (string, []ModuleLevel) {
+	mu.RLock()
+	defer mu.RUnlock()
+	list := make([]ModuleLevel, 0, len(Modules))
+	for _, m := range Modules {
+		l, ok := overrides[m]
+		if !ok {
+			l = global
+		}
+		list = append(list, ModuleLevel{Module: m, Level: l.String(), Override: ok})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Module < list[j].Module })
+	return global.String(), list
+}
+
+// knownModule 判断模块名是否有效
+func knownModule(module string) bool {
+	for _, m := range Modules {
+		if m == module {
+			return true
+		}
+	}
+	return false
+}
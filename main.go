@@ -1,20 +1,54 @@
 package main
 
 import (
+	"net/http"
+	"os"
+	"time"
+
 	"servicetelemetry/agent"
+	"servicetelemetry/alert"
 	"servicetelemetry/api"
+	"servicetelemetry/cli"
 	"servicetelemetry/config"
 	"servicetelemetry/core"
+	"servicetelemetry/ctwatch"
+	"servicetelemetry/eventbus"
+	"servicetelemetry/failover"
+	"servicetelemetry/logger"
+	"servicetelemetry/probe"
+	"servicetelemetry/remediation"
+	"servicetelemetry/scheduler"
+	"servicetelemetry/snapshot"
 	"servicetelemetry/storage"
-	"time"
+	"servicetelemetry/subscription"
 
 	"github.com/gin-gonic/gin"
 )
 
 func main() {
+	// 子命令：validate 校验声明式目标定义，bench 容量压测，seed 生成演示数据，eval 评估意图解析与检索
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "validate":
+			os.Exit(cli.RunValidate(os.Args[2:]))
+		case "bench":
+			os.Exit(cli.RunBench(os.Args[2:]))
+		case "seed":
+			os.Exit(cli.RunSeed(os.Args[2:]))
+		case "eval":
+			os.Exit(cli.RunEval(os.Args[2:]))
+		}
+	}
+
 	// 1. 加载配置（支持热加载）
 	cfg := config.DefaultConfig()
 	config.StartConfigHotReload(30 * time.Second) // 每30秒检查一次配置更新
+	if err := logger.Configure(cfg.Monitor.LogLevel, cfg.Monitor.LogModules); err != nil {
+		panic("日志级别配置错误：" + err.Error())
+	}
+	if _, err := core.NewCalendars(cfg.Stats.Calendars); err != nil {
+		panic("工作时间日历配置错误：" + err.Error())
+	}
 
 	// 2. 初始化数据库存储客户端
 	mysqlStorage, err := storage.NewMySQLStorage(&cfg.DB)
@@ -25,6 +59,8 @@ func main() {
 
 	// 3. 初始化核心服务检查器
 	checker := core.NewServiceChecker(&cfg.Monitor)
+	// 心跳目标（heartbeat://）的心跳记录在共享数据库中，任一实例接收的心跳都对全部实例可见
+	checker.SetHeartbeatStore(mysqlStorage)
 
 	// 4. 定期清理过期缓存
 	go func() {
@@ -34,22 +70,198 @@ func main() {
 		}
 	}()
 
+	// 定期清除超过保留时长的响应快照
+	if snap := cfg.Monitor.ResponseSnapshot; snap.Enable && snap.Retention > 0 {
+		go func() {
+			ticker := time.NewTicker(time.Hour)
+			for range ticker.C {
+				if n, err := mysqlStorage.ExpireResponseSnapshots(time.Now().Add(-snap.Retention)); err != nil {
+					println("清除过期响应快照失败：", err.Error())
+				} else if n > 0 {
+					println("已清除过期响应快照：", n, "条")
+				}
+			}
+		}()
+	}
+
 	// 5. 初始化小助手数据检索器
-	retriever := agent.NewDataRetriever(mysqlStorage, &cfg.Agent)
+	intentParser, err := agent.NewIntentParser(&cfg.Agent)
+	if err != nil {
+		panic("意图解析器配置错误：" + err.Error())
+	}
+	retriever := agent.NewDataRetriever(mysqlStorage, &cfg.Agent, intentParser)
+	if !agent.ValidFormat(cfg.Agent.OutputFormat) {
+		panic("小助手回复格式配置错误：" + cfg.Agent.OutputFormat + "，可选 plain / markdown / json")
+	}
+	if err := agent.ValidateChatScope(&cfg.Agent.Chat); err != nil {
+		panic("小助手通用问答配置错误：" + err.Error())
+	}
+	if err := api.ValidateChatOps(&cfg.ChatOps); err != nil {
+		panic("斜杠命令配置错误：" + err.Error())
+	}
+
+	// 6. 初始化小助手AI实例与告警管理器
+	summarizer := agent.NewLightweightSummarizer(&cfg.Agent)
+	silences := alert.NewSilenceManager()
+	if err := alert.Validate(&cfg.Alert); err != nil {
+		panic("告警规则配置错误：" + err.Error())
+	}
+	alerts := alert.NewManager(&cfg.Alert, mysqlStorage, silences, summarizer)
+
+	// 7. 初始化事件总线（可选），发布检查结果、状态变化与告警事件
+	bus, err := eventbus.NewBus(&cfg.Events)
+	if err != nil {
+		panic("初始化事件总线失败：" + err.Error())
+	}
+	defer bus.Close()
+	alerts.SetEventBus(bus)
+	changes := eventbus.NewFeed(cfg.API.ChangesBuffer)
+	alerts.SetChangeFeed(changes)
+
+	// 8. 初始化定时调度器，同步声明式目标定义
+	sched := scheduler.NewScheduler(&cfg.Monitor, checker, mysqlStorage, alerts, bus)
+	if cfg.Monitor.TargetsFile != "" {
+		n, err := sched.SyncTargetSpec(cfg.Monitor.TargetsFile)
+		if err != nil {
+			panic("同步目标定义失败：" + err.Error())
+		}
+		println("已同步声明式目标定义：", n, "个目标")
+	}
+	if cfg.Monitor.WarmUpWindow > 0 {
+		n, err := sched.WarmUp(cfg.Monitor.WarmUpWindow)
+		if err != nil {
+			println("启动预热失败：", err.Error())
+		} else {
+			println("启动预热完成：", n, "个目标")
+		}
+	}
+	if cfg.Monitor.Scheduler {
+		sched.Start()
+	}
+	if cfg.Events.RegistrationTopic != "" {
+		sub, err := eventbus.NewSubscriber(&cfg.Events)
+		if err != nil {
+			panic("初始化目标注册订阅失败：" + err.Error())
+		}
+		defer sub.Close()
+		sub.Start(cfg.Events.RegistrationTopic, sched.HandleRegistration)
+	}
+
+	// 证书透明度日志监控（可选），发现非预期签发的证书时通过告警渠道通知
+	var ctWatcher *ctwatch.Watcher
+	if cfg.CT.Enable && len(cfg.CT.Domains) > 0 {
+		ctWatcher = ctwatch.NewWatcher(&cfg.CT, alerts)
+		ctWatcher.Start()
+	}
+
+	// 配置快照（可选），配置或声明式目标定义变化时保存新版本，支持对比与回滚
+	var snapshots *snapshot.Manager
+	if cfg.Snapshots.Enable {
+		snapshots = snapshot.NewManager(&cfg.Snapshots, &cfg.Monitor, mysqlStorage)
+		snapshots.Start()
+	}
+
+	// 故障切换路径演练（可选），定期检查备用 / 容灾入口并对比主备响应
+	var failoverProber *failover.Prober
+	if cfg.Failover.Enable && len(cfg.Failover.Pairs) > 0 {
+		if err := failover.Validate(&cfg.Failover); err != nil {
+			panic("故障切换路径配置错误：" + err.Error())
+		}
+		failoverProber = failover.NewProber(&cfg.Failover, checker, alerts)
+		failoverProber.Start()
+	}
+
+	// 状态订阅（可选），事件开启 / 更新 / 恢复时通过邮件或 Webhook 通知已确认的订阅人
+	var subscriptions *subscription.Manager
+	if cfg.Subscriptions.Enable {
+		subscriptions = subscription.NewManager(&cfg.Subscriptions, mysqlStorage, checker.ExternalHTTPClient(cfg.Subscriptions.SendTimeout))
+		subscriptions.SetCommunicationLog(alerts.RecordCommunication)
+		alerts.OnIncident(subscriptions.HandleIncident)
+	}
+
+	// 自动处置（可选），目标进入失败状态时执行其引用的处置动作（Webhook / Jenkins / 脚本），执行记录入库审计
+	if err := remediation.Validate(&cfg.Remediation); err != nil {
+		panic("自动处置配置错误：" + err.Error())
+	}
+	remediator := remediation.NewManager(&cfg.Remediation, mysqlStorage, silences)
+	sched.SetRemediation(remediator)
 
-	// 6. 初始化HTTP接口处理器
-	handler := api.NewHandler(checker, mysqlStorage, retriever, cfg)
+	// 探测节点 mTLS 认证（可选），探测节点接口在单独的端口上只接受由探测节点 CA 签发且未吊销的客户端证书
+	var probeAuth *probe.Authenticator
+	var fleet *probe.Fleet
+	if cfg.Probes.Listen != "" {
+		var err error
+		probeAuth, err = probe.NewAuthenticator(&cfg.Probes)
+		if err != nil {
+			panic("初始化探测节点认证失败：" + err.Error())
+		}
+		if fleet, err = probe.NewFleet(&cfg.Probes); err != nil {
+			panic("探测节点更新渠道配置错误：" + err.Error())
+		}
+	}
+
+	// 9. 初始化HTTP接口处理器
+	handler := api.NewHandler(checker, mysqlStorage, retriever, cfg, summarizer, silences, alerts, sched, bus, ctWatcher, snapshots, subscriptions, failoverProber, remediator, changes, probeAuth, fleet)
 
-	// 7. 初始化Gin引擎
+	// 10. 初始化Gin引擎
 	router := gin.Default()
 
 	// 配置静态文件路由
 	router.Static("/static", "./static")
 
-	// 8. 注册API路由
-	handler.RegisterRoutes(router)
+	// 11. 注册API路由
+	if err := handler.RegisterRoutes(router); err != nil {
+		panic("接口配置错误：" + err.Error())
+	}
+
+	if probeAuth != nil {
+		probeRouter := gin.New()
+		probeRouter.Use(gin.Logger(), gin.Recovery())
+		handler.RegisterProbeRoutes(probeRouter)
+		probeServer := &http.Server{Addr: cfg.Probes.Listen, Handler: probeRouter, TLSConfig: probeAuth.TLSConfig()}
+		go func() {
+			println("探测节点接口已启用（mTLS）：", cfg.Probes.Listen)
+			if err := probeServer.ListenAndServeTLS(cfg.Probes.CertFile, cfg.Probes.KeyFile); err != nil {
+				panic("探测节点接口启动失败：" + err.Error())
+			}
+		}()
+	}
+
+	// 查看者接口（可选），在单独的端口上只提供经过数据最小化处理的只读聚合数据
+	if cfg.API.Viewer.Listen != "" {
+		viewerRouter := gin.New()
+		viewerRouter.Use(gin.Logger(), gin.Recovery())
+		if err := handler.RegisterViewerRoutes(viewerRouter); err != nil {
+			panic("查看者接口配置错误：" + err.Error())
+		}
+		go func() {
+			println("查看者接口已启用：", cfg.API.Viewer.Listen)
+			if err := viewerRouter.Run(cfg.API.Viewer.Listen); err != nil {
+				panic("查看者接口启动失败：" + err.Error())
+			}
+		}()
+	}
+
+	// 本实例作为探测节点接入中心服务时，启动时确认证书配置，之后定期发送心跳（版本与支持的检查协议）
+	if cfg.Probes.Central.URL != "" {
+		central, err := probe.NewClient(&cfg.Probes.Central)
+		if err != nil {
+			panic("初始化中心服务客户端失败：" + err.Error())
+		}
+		go func() {
+			id, err := central.WhoAmI()
+			if err != nil {
+				println("中心服务认证失败：", err.Error())
+				return
+			}
+			println("已通过中心服务认证，探测节点：", id.Name, "版本：", probe.Version)
+		}()
+		reporter := probe.NewReporter(central, &cfg.Probes.Central, cfg.Monitor.Region, core.SupportedSchemes)
+		reporter.Start()
+		defer reporter.Stop()
+	}
 
-	// 9. 启动HTTP服务
+	// 12. 启动HTTP服务
 	println("服务启动成功，访问 http://localhost:8080/static 查看监控大屏")
 	println("配置热加载已启用（30秒间隔）")
 	if err := router.Run(":8080"); err != nil {
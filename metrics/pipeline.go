@@ -0,0 +1,187 @@
+package metrics
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// 通知渠道分类，渠道名为「分类:名称」，如 alert:webhook、subscription:email
+const (
+	KindAlert        = "alert"        // 告警通知渠道
+	KindSubscription = "subscription" // 状态订阅通知
+	KindEventBus     = "eventbus"     // 事件总线发布
+	KindRemediation  = "remediation"  // 自动处置动作
+)
+
+// latencyWindow 计算耗时分位数时保留的最近样本数
+const latencyWindow = 512
+
+// ChannelStats 单个通知渠道的投递统计
+type ChannelStats struct {
+	Channel     string     `json:"channel"`               // 渠道名（分类:名称）
+	Sent        int64      `json:"sent"`                  // 投递次数
+	Failed      int64      `json:"failed"`                // 失败次数
+	SuccessRate float64    `json:"successRate"`           // 投递成功率（0~1）
+	AvgMs       float64    `json:"avgMs"`                 // 平均投递耗时（毫秒）
+	MaxMs       float64    `json:"maxMs"`                 // 最大投递耗时（毫秒）
+	LastError   string     `json:"lastError,omitempty"`   // 最近一次失败的错误信息
+	LastErrorAt *time.Time `json:"lastErrorAt,omitempty"` // 最近一次失败时间
+	totalMs     float64
+}
+
+// SchemeStats 单个检查协议的检查统计
+type SchemeStats struct {
+	Scheme   string           `json:"scheme"`   // 检查协议
+	Checks   int64            `json:"checks"`   // 检查次数（不含命中缓存的结果）
+	Success  int64            `json:"success"`  // 成功次数
+	Degraded int64            `json:"degraded"` // 降级次数（耗时超过阈值）
+	Failed   int64            `json:"failed"`   // 失败次数
+	Errors   map[string]int64 `json:"errors"`   // 按错误类型统计的失败与降级次数
+	AvgMs    float64          `json:"avgMs"`    // 平均耗时（毫秒）
+	P50Ms    float64          `json:"p50Ms"`    // 最近样本的耗时中位数（毫秒）
+	P95Ms    float64          `json:"p95Ms"`    // 最近样本的 P95 耗时（毫秒）
+	MaxMs    float64          `json:"maxMs"`    // 最大耗时（毫秒）
+	totalMs  float64
+	samples  []float64
+	next     int
+}
+
+// PipelineStats 监控系统自身集成的运行指标
+type PipelineStats struct {
+	Since    time.Time       `json:"since"`    // 统计开始时间
+	Channels []*ChannelStats `json:"channels"` // 各通知渠道投递统计，按渠道名排序
+	Schemes  []*SchemeStats  `json:"schemes"`  // 各检查协议统计，按检查次数倒序
+}
+
+var (
+	mu       sync.Mutex
+	since    = time.Now()
+	channels = make(map[string]*ChannelStats)
+	schemes  = make(map[string]*SchemeStats)
+)
+
+// ObserveDelivery 记录一次通知投递
+// kind：渠道分类，如 KindAlert
+// name：渠道名称，如 webhook、email
+// err：投递结果，nil 表示成功
+// start：开始投递的时间
+func ObserveDelivery(kind, name string, err error, start time.Time) {
+	ms := elapsedMs(start)
+	channel := kind + ":" + name
+
+	mu.Lock()
+	defer mu.Unlock()
+	stats, ok := channels[channel]
+	if !ok {
+		stats = &ChannelStats{Channel: channel}
+		channels[channel] = stats
+	}
+	stats.Sent++
+	stats.totalMs += ms
+	stats.MaxMs = math.Max(stats.MaxMs, ms)
+	if err != nil {
+		now := time.Now()
+		stats.Failed++
+		stats.LastError, stats.LastErrorAt = err.Error(), &now
+	}
+}
+
+// ObserveCheck 记录一次实际执行的检查
+// scheme：检查协议
+// status：检查状态（success / degraded / failed）
+// errorType：错误类型，成功时为空
+// responseMs：检查耗时（毫秒）
+func ObserveCheck(scheme, status, errorType string, responseMs float64) {
+	mu.Lock()
+	defer mu.Unlock()
+	stats, ok := schemes[scheme]
+	if !ok {
+		stats = &SchemeStats{Scheme: scheme, Errors: make(map[string]int64)}
+		schemes[scheme] = stats
+	}
+	stats.Checks++
+	switch status {
+	case "success":
+		stats.Success++
+	case "degraded":
+		stats.Degraded++
+	default:
+		stats.Failed++
+	}
+	if errorType != "" {
+		stats.Errors[errorType]++
+	}
+	stats.totalMs += responseMs
+	stats.MaxMs = math.Max(stats.MaxMs, responseMs)
+	if len(stats.samples) < latencyWindow {
+		stats.samples = append(stats.samples, responseMs)
+	} else {
+		stats.samples[stats.next] = responseMs
+		stats.next = (stats.next + 1) % latencyWindow
+	}
+}
+
+// Snapshot 返回当前统计，reset 为 true 时同时清空统计
+func Snapshot(reset bool) *PipelineStats {
+	mu.Lock()
+	defer mu.Unlock()
+
+	out := &PipelineStats{
+		Since:    since,
+		Channels: make([]*ChannelStats, 0, len(channels)),
+		Schemes:  make([]*SchemeStats, 0, len(schemes)),
+	}
+	for _, c := range channels {
+		s := *c
+		s.SuccessRate = float64(s.Sent-s.Failed) / float64(s.Sent)
+		s.AvgMs = s.totalMs / float64(s.Sent)
+		out.Channels = append(out.Channels, &s)
+	}
+	sort.Slice(out.Channels, func(i, j int) bool { return out.Channels[i].Channel < out.Channels[j].Channel })
+
+	for _, sc := range schemes {
+		s := *sc
+		s.Errors = make(map[string]int64, len(sc.Errors))
+		for k, v := range sc.Errors {
+			s.Errors[k] = v
+		}
+		s.AvgMs = s.totalMs / float64(s.Checks)
+		sorted := append([]float64(nil), sc.samples...)
+		sort.Float64s(sorted)
+		s.P50Ms, s.P95Ms = percentile(sorted, 0.5), percentile(sorted, 0.95)
+		s.samples = nil
+		out.Schemes = append(out.Schemes, &s)
+	}
+	sort.Slice(out.Schemes, func(i, j int) bool {
+		if out.Schemes[i].Checks != out.Schemes[j].Checks {
+			return out.Schemes[i].Checks > out.Schemes[j].Checks
+		}
+		return out.Schemes[i].Scheme < out.Schemes[j].Scheme
+	})
+
+	if reset {
+		since = time.Now()
+		channels = make(map[string]*ChannelStats)
+		schemes = make(map[string]*SchemeStats)
+	}
+	return out
+}
+
+// percentile 从已排序的样本中取分位数（最近秩法）
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	i := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if i < 0 {
+		i = 0
+	}
+	return sorted[i]
+}
+
+// elapsedMs 返回自 start 起的耗时（毫秒）
+func elapsedMs(start time.Time) float64 {
+	return float64(time.Since(start).Microseconds()) / 1000
+}
@@ -0,0 +1,225 @@
+package probe
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"servicetelemetry/config"
+	"servicetelemetry/logger"
+)
+
+var log = logger.New("probe")
+
+// 探测节点证书校验失败的原因
+var (
+	ErrNoCertificate = errors.New("未提供客户端证书")
+	ErrRevoked       = errors.New("客户端证书已被吊销")
+	ErrNotAllowed    = errors.New("探测节点不在允许接入的列表中")
+)
+
+// Identity 通过 mTLS 认证的探测节点身份
+type Identity struct {
+	Name     string    `json:"name"`     // 探测节点名称（证书 CN）
+	Serial   string    `json:"serial"`   // 证书序列号（十六进制）
+	Issuer   string    `json:"issuer"`   // 签发者
+	NotAfter time.Time `json:"notAfter"` // 证书到期时间
+}
+
+// Authenticator 中心服务的探测节点认证器：校验客户端证书由探测节点 CA 签发、未被吊销且名称在允许列表中
+// 吊销列表文件更新后在下一次校验时自动重新加载，已建立的长连接在每个请求上重新校验吊销状态
+type Authenticator struct {
+	cfg     *config.ProbeConfig
+	roots   *x509.CertPool
+	cas     []*x509.Certificate
+	allowed map[string]bool
+
+	mu       sync.RWMutex
+	revoked  map[string]bool // 吊销的证书序列号（十六进制小写）
+	crlMod   time.Time       // 已加载的吊销列表文件修改时间
+	crlCheck time.Time       // 上次检查吊销列表文件的时间
+}
+
+// crlCheckInterval 检查吊销列表文件是否更新的最短间隔
+const crlCheckInterval = 5 * time.Second
+
+// NewAuthenticator 创建探测节点认证器
+// cfg：探测节点认证配置，clientCAFile 必填
+func NewAuthenticator(cfg *config.ProbeConfig) (*Authenticator, error) {
+	if cfg.ClientCAFile == "" {
+		return nil, errors.New("未配置探测节点 CA（probes.clientCAFile）")
+	}
+	data, err := os.ReadFile(cfg.ClientCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("读取探测节点 CA 失败：%w", err)
+	}
+	a := &Authenticator{cfg: cfg, roots: x509.NewCertPool(), allowed: make(map[string]bool)}
+	for block, rest := pem.Decode(data); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		ca, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("解析探测节点 CA 失败：%w", err)
+		}
+		a.roots.AddCert(ca)
+		a.cas = append(a.cas, ca)
+	}
+	if len(a.cas) == 0 {
+		return nil, fmt.Errorf("探测节点 CA 文件中没有证书：%s", cfg.ClientCAFile)
+	}
+	for _, name := range cfg.Allowed {
+		a.allowed[name] = true
+	}
+	if err := a.reloadCRL(true); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// TLSConfig 返回探测节点接口使用的服务端 TLS 配置：要求客户端证书，握手时即拒绝吊销或未允许的证书
+func (a *Authenticator) TLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ClientAuth: tls.RequireAndVerifyClientCert,
+		ClientCAs:  a.roots,
+		VerifyConnection: func(state tls.ConnectionState) error {
+			_, err := a.Verify(state.PeerCertificates)
+			return err
+		},
+	}
+}
+
+// Verify 校验已通过证书链验证的客户端证书，返回探测节点身份
+// certs：对端证书链，第一张为客户端证书
+func (a *Authenticator) Verify(certs []*x509.Certificate) (*Identity, error) {
+	if len(certs) == 0 {
+		return nil, ErrNoCertificate
+	}
+	cert := certs[0]
+	if err := a.reloadCRL(false); err != nil {
+		// 吊销列表暂时不可读时沿用已加载的列表，不影响已有探测节点接入
+		log.Warnf("重新加载吊销列表失败：%v", err)
+	}
+
+	id := &Identity{
+		Name:     cert.Subject.CommonName,
+		Serial:   serialHex(cert.SerialNumber),
+		Issuer:   cert.Issuer.CommonName,
+		NotAfter: cert.NotAfter,
+	}
+	a.mu.RLock()
+	revoked := a.revoked[id.Serial]
+	a.mu.RUnlock()
+	if revoked {
+		log.Warnf("拒绝已吊销的探测节点证书：%s（序列号 %s）", id.Name, id.Serial)
+		return id, ErrRevoked
+	}
+	if len(a.allowed) > 0 && !a.allowed[id.Name] {
+		log.Warnf("拒绝未允许接入的探测节点：%s", id.Name)
+		return id, ErrNotAllowed
+	}
+	return id, nil
+}
+
+// reloadCRL 加载配置中的吊销序列号与吊销列表文件；force 为 false 时只在文件修改后重新加载
+func (a *Authenticator) reloadCRL(force bool) error {
+	now := time.Now()
+	a.mu.RLock()
+	recent := !force && now.Sub(a.crlCheck) < crlCheckInterval
+	a.mu.RUnlock()
+	if recent {
+		return nil
+	}
+
+	var mod time.Time
+	if a.cfg.CRLFile != "" {
+		info, err := os.Stat(a.cfg.CRLFile)
+		if err != nil {
+			return fmt.Errorf("读取吊销列表失败：%w", err)
+		}
+		mod = info.ModTime()
+	}
+	a.mu.Lock()
+	a.crlCheck = now
+	unchanged := !force && mod.Equal(a.crlMod)
+	a.mu.Unlock()
+	if unchanged {
+		return nil
+	}
+
+	revoked := make(map[string]bool)
+	for _, s := range a.cfg.RevokedSerials {
+		revoked[normalizeSerial(s)] = true
+	}
+	if a.cfg.CRLFile != "" {
+		serials, err := a.loadCRL(a.cfg.CRLFile)
+		if err != nil {
+			return err
+		}
+		for _, s := range serials {
+			revoked[s] = true
+		}
+	}
+
+	a.mu.Lock()
+	a.revoked, a.crlMod = revoked, mod
+	a.mu.Unlock()
+	log.Infof("已加载探测节点证书吊销列表：%d 个序列号", len(revoked))
+	return nil
+}
+
+// loadCRL 解析吊销列表文件（PEM 或 DER），校验其由探测节点 CA 签发，返回吊销的序列号
+func (a *Authenticator) loadCRL(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取吊销列表失败：%w", err)
+	}
+	if block, _ := pem.Decode(data); block != nil {
+		data = block.Bytes
+	}
+	crl, err := x509.ParseRevocationList(data)
+	if err != nil {
+		return nil, fmt.Errorf("解析吊销列表失败：%w", err)
+	}
+	signed := false
+	for _, ca := range a.cas {
+		if crl.CheckSignatureFrom(ca) == nil {
+			signed = true
+			break
+		}
+	}
+	if !signed {
+		return nil, errors.New("吊销列表不是由探测节点 CA 签发的")
+	}
+	if !crl.NextUpdate.IsZero() && time.Now().After(crl.NextUpdate) {
+		log.Warnf("吊销列表已过期（nextUpdate %s），请及时更新", crl.NextUpdate.Format(time.RFC3339))
+	}
+	serials := make([]string, 0, len(crl.RevokedCertificateEntries))
+	for _, entry := range crl.RevokedCertificateEntries {
+		serials = append(serials, serialHex(entry.SerialNumber))
+	}
+	return serials, nil
+}
+
+// serialHex 证书序列号的十六进制小写表示
+func serialHex(n *big.Int) string {
+	return n.Text(16)
+}
+
+// normalizeSerial 规范化配置中的序列号：去掉冒号、空格与前导零，转为小写
+func normalizeSerial(s string) string {
+	s = strings.ToLower(strings.NewReplacer(":", "", " ", "").Replace(s))
+	s = strings.TrimLeft(s, "0")
+	if s == "" {
+		return "0"
+	}
+	return s
+}
@@ -0,0 +1,113 @@
+package probe
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"servicetelemetry/config"
+)
+
+// Client 探测节点访问中心服务的客户端，以本节点的证书进行双向 TLS 认证
+type Client struct {
+	base   string
+	client *http.Client
+}
+
+// NewClient 创建访问中心服务的客户端
+// cfg：中心服务配置，url、certFile、keyFile 必填
+func NewClient(cfg *config.CentralConfig) (*Client, error) {
+	if !strings.HasPrefix(cfg.URL, "https://") {
+		return nil, errors.New("中心服务地址必须为 https://")
+	}
+	if cfg.CertFile == "" || cfg.KeyFile == "" {
+		return nil, errors.New("未配置探测节点证书（probes.central.certFile / keyFile）")
+	}
+	cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("加载探测节点证书失败：%w", err)
+	}
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12, Certificates: []tls.Certificate{cert}}
+	if cfg.CAFile != "" {
+		data, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("读取中心服务 CA 失败：%w", err)
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("中心服务 CA 文件中没有证书：%s", cfg.CAFile)
+		}
+	}
+	return &Client{
+		base: strings.TrimRight(cfg.URL, "/"),
+		client: &http.Client{
+			Timeout:   cfg.Timeout,
+			Transport: &http.Transport{TLSClientConfig: tlsConfig},
+		},
+	}, nil
+}
+
+// WhoAmI 查询中心服务识别到的本节点身份，用于部署时确认证书配置正确
+func (c *Client) WhoAmI() (*Identity, error) {
+	var id Identity
+	if err := c.do(http.MethodGet, "/api/v1/probes/whoami", nil, &id); err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
+
+// Heartbeat 发送心跳，返回本节点所在渠道的版本清单
+func (c *Client) Heartbeat(hb *Heartbeat) (*HeartbeatResponse, error) {
+	body, err := json.Marshal(hb)
+	if err != nil {
+		return nil, err
+	}
+	var resp HeartbeatResponse
+	if err := c.do(http.MethodPost, "/api/v1/probes/heartbeat", bytes.NewReader(body), &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// do 发送请求并解析 JSON 响应，非 2xx 响应返回中心服务的错误信息
+func (c *Client) do(method, path string, body io.Reader, out interface{}) error {
+	req, err := http.NewRequest(method, c.base+path, body)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("请求中心服务失败：%w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("读取中心服务响应失败：%w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var e struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if json.Unmarshal(data, &e) == nil && e.Error.Message != "" {
+			return fmt.Errorf("中心服务返回 %d：%s", resp.StatusCode, e.Error.Message)
+		}
+		return fmt.Errorf("中心服务返回异常状态码：%d", resp.StatusCode)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(data, out)
+}
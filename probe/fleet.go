@@ -0,0 +1,182 @@
+package probe
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"servicetelemetry/config"
+)
+
+// Version 本程序的版本号，构建时通过 -ldflags "-X servicetelemetry/probe.Version=1.4.0" 注入
+var Version = "dev"
+
+// DefaultChannel 探测节点未指定更新渠道时使用的渠道
+const DefaultChannel = "stable"
+
+// versionPattern 版本号允许的字符，版本号会用作安装包文件名的一部分
+var versionPattern = regexp.MustCompile(`^[0-9A-Za-z][0-9A-Za-z._+-]*$`)
+
+// sha256Pattern SHA-256 摘要（十六进制）
+var sha256Pattern = regexp.MustCompile(`^[0-9a-fA-F]{64}$`)
+
+// Heartbeat 探测节点心跳内容
+type Heartbeat struct {
+	Version    string    `json:"version"`          // 探测节点版本
+	Channel    string    `json:"channel"`          // 更新渠道
+	Region     string    `json:"region,omitempty"` // 探测节点所在区域
+	CheckTypes []string  `json:"checkTypes"`       // 支持的检查协议
+	OS         string    `json:"os"`               // 操作系统
+	Arch       string    `json:"arch"`             // CPU 架构
+	StartedAt  time.Time `json:"startedAt"`        // 进程启动时间
+}
+
+// HeartbeatResponse 心跳响应：探测节点所在渠道的版本清单
+type HeartbeatResponse struct {
+	Manifest        *config.ReleaseManifest `json:"manifest,omitempty"` // 所在渠道的期望版本，渠道未配置时为空
+	UpdateAvailable bool                    `json:"updateAvailable"`    // 当前版本与期望版本不一致
+}
+
+// ProbeStatus 探测节点的最新状态
+type ProbeStatus struct {
+	Heartbeat // 最近一次心跳内容
+
+	Name           string    `json:"name"`                     // 探测节点名称（证书 CN）
+	Serial         string    `json:"serial"`                   // 证书序列号
+	CertNotAfter   time.Time `json:"certNotAfter"`             // 证书到期时间
+	Address        string    `json:"address"`                  // 最近一次心跳的来源地址
+	LastSeen       time.Time `json:"lastSeen"`                 // 最近一次心跳时间
+	Online         bool      `json:"online"`                   // 是否在线（offlineAfter 内收到过心跳）
+	DesiredVersion string    `json:"desiredVersion,omitempty"` // 所在渠道的期望版本
+	UpToDate       bool      `json:"upToDate"`                 // 是否为期望版本（渠道未配置时视为最新）
+}
+
+// FleetView 探测节点总览
+type FleetView struct {
+	Total    int                               `json:"total"`    // 探测节点数
+	Online   int                               `json:"online"`   // 在线数
+	Outdated int                               `json:"outdated"` // 版本落后数
+	Versions map[string]int                    `json:"versions"` // 各版本的探测节点数
+	Channels map[string]config.ReleaseManifest `json:"channels"` // 各渠道的期望版本
+	Probes   []ProbeStatus                     `json:"probes"`   // 各探测节点状态，按名称排序
+}
+
+// Fleet 中心服务记录的探测节点集合，保存在内存中，服务重启后由下一次心跳重新建立
+type Fleet struct {
+	cfg *config.ProbeConfig
+
+	mu     sync.Mutex
+	probes map[string]*ProbeStatus
+}
+
+// NewFleet 创建探测节点集合，校验各渠道的版本清单
+func NewFleet(cfg *config.ProbeConfig) (*Fleet, error) {
+	for name, m := range cfg.Channels {
+		if err := validateManifest(&m); err != nil {
+			return nil, fmt.Errorf("更新渠道[%s]：%w", name, err)
+		}
+	}
+	return &Fleet{cfg: cfg, probes: make(map[string]*ProbeStatus)}, nil
+}
+
+// validateManifest 校验版本清单：版本号只含安全字符，配置下载地址时摘要必填
+func validateManifest(m *config.ReleaseManifest) error {
+	if !versionPattern.MatchString(m.Version) {
+		return fmt.Errorf("无效的版本号：%q", m.Version)
+	}
+	if m.URL == "" {
+		return nil
+	}
+	if u, err := url.Parse(m.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("无效的安装包地址：%s", m.URL)
+	}
+	if !sha256Pattern.MatchString(m.SHA256) {
+		return fmt.Errorf("配置安装包地址时 sha256 必须为 64 位十六进制摘要")
+	}
+	return nil
+}
+
+// Manifest 返回渠道的版本清单，渠道未配置时返回 nil
+func (f *Fleet) Manifest(channel string) *config.ReleaseManifest {
+	if channel == "" {
+		channel = DefaultChannel
+	}
+	m, ok := f.cfg.Channels[channel]
+	if !ok {
+		return nil
+	}
+	return &m
+}
+
+// Record 记录一次心跳，返回探测节点所在渠道的版本清单
+// id：已认证的探测节点身份
+// hb：心跳内容
+// address：来源地址
+func (f *Fleet) Record(id *Identity, hb *Heartbeat, address string) *HeartbeatResponse {
+	if hb.Channel == "" {
+		hb.Channel = DefaultChannel
+	}
+	resp := &HeartbeatResponse{Manifest: f.Manifest(hb.Channel)}
+	resp.UpdateAvailable = resp.Manifest != nil && resp.Manifest.Version != hb.Version
+
+	f.mu.Lock()
+	prev, seen := f.probes[id.Name]
+	f.probes[id.Name] = &ProbeStatus{
+		Name:         id.Name,
+		Serial:       id.Serial,
+		CertNotAfter: id.NotAfter,
+		Address:      address,
+		Heartbeat:    *hb,
+		LastSeen:     time.Now(),
+	}
+	f.mu.Unlock()
+
+	switch {
+	case !seen:
+		log.Infof("探测节点[%s]上线：版本 %s，渠道 %s，地址 %s", id.Name, hb.Version, hb.Channel, address)
+	case prev.Version != hb.Version:
+		log.Infof("探测节点[%s]版本变更：%s -> %s", id.Name, prev.Version, hb.Version)
+	}
+	return resp
+}
+
+// View 返回探测节点总览
+func (f *Fleet) View() *FleetView {
+	view := &FleetView{
+		Versions: make(map[string]int),
+		Channels: make(map[string]config.ReleaseManifest, len(f.cfg.Channels)),
+		Probes:   []ProbeStatus{},
+	}
+	for name, m := range f.cfg.Channels {
+		view.Channels[name] = m
+	}
+
+	f.mu.Lock()
+	for _, p := range f.probes {
+		status := *p
+		status.Online = time.Since(p.LastSeen) <= f.cfg.OfflineAfter
+		status.UpToDate = true
+		if m := f.Manifest(p.Channel); m != nil {
+			status.DesiredVersion = m.Version
+			status.UpToDate = m.Version == p.Version
+		}
+		view.Probes = append(view.Probes, status)
+	}
+	f.mu.Unlock()
+
+	sort.Slice(view.Probes, func(i, j int) bool { return view.Probes[i].Name < view.Probes[j].Name })
+	for _, p := range view.Probes {
+		view.Total++
+		view.Versions[p.Version]++
+		if p.Online {
+			view.Online++
+		}
+		if !p.UpToDate {
+			view.Outdated++
+		}
+	}
+	return view
+}
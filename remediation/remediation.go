@@ -0,0 +1,349 @@
+package remediation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"servicetelemetry/alert"
+	"servicetelemetry/config"
+	"servicetelemetry/core"
+	"servicetelemetry/logger"
+	"servicetelemetry/metrics"
+	"servicetelemetry/storage"
+)
+
+var log = logger.New("remediation")
+
+// 动作类型
+const (
+	TypeWebhook = "webhook" // POST JSON 到指定地址
+	TypeJenkins = "jenkins" // 触发 Jenkins 参数化任务（buildWithParameters）
+	TypeScript  = "script"  // 在本机执行配置中定义的脚本
+)
+
+// maxDetail 执行详情（响应内容、脚本输出）记录的最大字节数
+const maxDetail = 2000
+
+// Payload 发送给 Webhook 的处置请求内容
+type Payload struct {
+	Action              string    `json:"action"`              // 动作名称
+	TargetURL           string    `json:"targetUrl"`           // 触发处置的目标
+	Tags                []string  `json:"tags,omitempty"`      // 目标标签
+	ErrorType           string    `json:"errorType"`           // 检查结果的错误类型
+	ErrorMsg            string    `json:"errorMsg"`            // 检查结果的错误信息
+	ConsecutiveFailures int       `json:"consecutiveFailures"` // 触发时的连续失败次数
+	CheckedAt           time.Time `json:"checkedAt"`           // 触发处置的检查时间
+}
+
+// Manager 自动处置管理器：跟踪各目标的连续失败次数，目标进入失败状态（或连续失败达到动作要求的次数）时
+// 在后台执行目标引用的处置动作；同一目标同一动作受冷却时间限制，每次触发（含跳过）都写入审计记录
+type Manager struct {
+	cfg      *config.RemediationConfig
+	storage  *storage.MySQLStorage
+	silences *alert.SilenceManager
+	client   *http.Client
+
+	mu       sync.Mutex
+	failures map[string]int       // 目标地址 -> 连续失败次数
+	lastRun  map[string]time.Time // 动作名称|目标地址 -> 上次执行时间
+}
+
+// NewManager 创建自动处置管理器，未开启时返回 nil（nil 管理器的 HandleResult 为空操作）
+// cfg：自动处置配置
+// storage：数据库存储客户端，保存审计记录
+// silences：静默规则管理器，处于静默期（如维护窗口）的目标不执行处置
+func NewManager(cfg *config.RemediationConfig, storage *storage.MySQLStorage, silences *alert.SilenceManager) *Manager {
+	if !cfg.Enable {
+		return nil
+	}
+	return &Manager{
+		cfg:      cfg,
+		storage:  storage,
+		silences: silences,
+		client:   &http.Client{Timeout: cfg.Timeout},
+		failures: make(map[string]int),
+		lastRun:  make(map[string]time.Time),
+	}
+}
+
+// Validate 校验处置动作配置
+func Validate(cfg *config.RemediationConfig) error {
+	for name, a := range cfg.Actions {
+		if name == "" || strings.ContainsAny(name, " ,|") {
+			return fmt.Errorf("无效的处置动作名称：%q", name)
+		}
+		switch a.Type {
+		case TypeWebhook, TypeJenkins:
+			u, err := url.Parse(a.URL)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return fmt.Errorf("处置动作[%s]的地址无效：%s", name, a.URL)
+			}
+			if a.Type == TypeJenkins && (a.User == "" || a.Token == "") {
+				return fmt.Errorf("处置动作[%s]缺少 Jenkins 用户名或 API Token", name)
+			}
+		case TypeScript:
+			if len(a.Command) == 0 || !filepath.IsAbs(a.Command[0]) {
+				return fmt.Errorf("处置动作[%s]的脚本命令必须为绝对路径", name)
+			}
+		default:
+			return fmt.Errorf("处置动作[%s]的类型无效：%s，可选 webhook / jenkins / script", name, a.Type)
+		}
+		if a.AfterFailures < 0 || a.Cooldown < 0 {
+			return fmt.Errorf("处置动作[%s]的 afterFailures 与 cooldown 不能为负数", name)
+		}
+	}
+	return nil
+}
+
+// HandleResult 处理一条检查结果：更新目标的连续失败次数，达到动作要求的次数时在后台执行处置
+// 只处理引用了处置动作的目标；恢复后计数清零，再次失败时重新触发
+func (m *Manager) HandleResult(target *core.MonitorTarget, result *core.MonitorResult) {
+	// 接口或消息队列提交的目标不执行处置（含此前已入库的目标）
+	if m == nil || len(target.Remediation) == 0 || target.External() || result == nil {
+		return
+	}
+
+	m.mu.Lock()
+	if result.Status != "failed" {
+		delete(m.failures, target.URL)
+		m.mu.Unlock()
+		return
+	}
+	m.failures[target.URL]++
+	count := m.failures[target.URL]
+	m.mu.Unlock()
+
+	for _, name := range target.Remediation {
+		after := 1
+		if a, ok := m.cfg.Actions[name]; ok && a.AfterFailures > 1 {
+			after = a.AfterFailures
+		}
+		if count == after {
+			go m.Run(name, target, result, count)
+		}
+	}
+}
+
+// Run 执行一个处置动作并写入审计记录；动作未定义、目标处于静默期或处于冷却期时跳过
+// count：触发时目标的连续失败次数
+func (m *Manager) Run(name string, target *core.MonitorTarget, result *core.MonitorResult, count int) *storage.RemediationRun {
+	start := time.Now()
+	run := &storage.RemediationRun{
+		Action:    name,
+		TargetURL: target.URL,
+		ErrorType: result.ErrorType,
+		ErrorMsg:  result.ErrorMsg,
+		StartedAt: start,
+	}
+	defer m.record(run)
+
+	action, ok := m.cfg.Actions[name]
+	if !ok {
+		run.Outcome, run.Detail = storage.RemediationSkipped, "处置动作未定义"
+		return run
+	}
+	run.ActionType = action.Type
+	if m.silences != nil && m.silences.IsSilenced(target.URL) {
+		run.Outcome, run.Detail = storage.RemediationSkipped, "目标处于静默期"
+		return run
+	}
+
+	cooldown := action.Cooldown
+	if cooldown == 0 {
+		cooldown = m.cfg.Cooldown
+	}
+	key := name + "|" + target.URL
+	m.mu.Lock()
+	if last, ok := m.lastRun[key]; ok && start.Sub(last) < cooldown {
+		m.mu.Unlock()
+		run.Outcome = storage.RemediationSkipped
+		run.Detail = fmt.Sprintf("冷却期内（上次执行于 %s，冷却时间 %s）", last.Format("2006-01-02 15:04:05"), cooldown)
+		return run
+	}
+	m.lastRun[key] = start
+	m.mu.Unlock()
+
+	payload := &Payload{
+		Action:              name,
+		TargetURL:           target.URL,
+		Tags:                target.Tags,
+		ErrorType:           result.ErrorType,
+		ErrorMsg:            result.ErrorMsg,
+		ConsecutiveFailures: count,
+		CheckedAt:           result.CheckedAt,
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), m.cfg.Timeout)
+	defer cancel()
+	var detail string
+	var err error
+	switch action.Type {
+	case TypeWebhook:
+		detail, err = m.callWebhook(ctx, action, payload)
+	case TypeJenkins:
+		detail, err = m.triggerJenkins(ctx, action, payload)
+	case TypeScript:
+		detail, err = runScript(ctx, action, payload)
+	default:
+		err = fmt.Errorf("不支持的动作类型：%s", action.Type)
+	}
+	metrics.ObserveDelivery(metrics.KindRemediation, action.Type, err, start)
+	run.DurationMs = time.Since(start).Milliseconds()
+	run.Detail = truncate(detail)
+	run.Outcome = storage.RemediationSucceeded
+	if err != nil {
+		run.Outcome = storage.RemediationFailed
+		run.Detail = truncate(strings.TrimSpace(err.Error() + "\n" + detail))
+	}
+	return run
+}
+
+// record 写入审计记录并输出日志
+func (m *Manager) record(run *storage.RemediationRun) {
+	switch run.Outcome {
+	case storage.RemediationSucceeded:
+		log.Infof("目标[%s]执行处置动作[%s]成功，耗时 %dms", run.TargetURL, run.Action, run.DurationMs)
+	case storage.RemediationFailed:
+		log.Errorf("目标[%s]执行处置动作[%s]失败：%s", run.TargetURL, run.Action, run.Detail)
+	default:
+		log.Infof("目标[%s]跳过处置动作[%s]：%s", run.TargetURL, run.Action, run.Detail)
+	}
+	if err := m.storage.SaveRemediationRun(run); err != nil {
+		log.Errorf("保存处置执行记录失败：%v", err)
+	}
+}
+
+// callWebhook 将处置请求以 JSON POST 到 Webhook 地址，2xx 视为成功
+func (m *Manager) callWebhook(ctx context.Context, action config.RemediationAction, payload *Payload) (string, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, action.URL, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, ref := range action.Headers {
+		v, err := config.ResolveSecret(ref)
+		if err != nil {
+			return "", fmt.Errorf("解析请求头 %s 失败：%w", k, err)
+		}
+		req.Header.Set(k, v)
+	}
+	return m.do(req)
+}
+
+// triggerJenkins 触发 Jenkins 参数化任务，处置信息以 TARGET_URL、ERROR_TYPE、ERROR_MSG 等参数传入
+// 使用 API Token 认证时 Jenkins 不要求 CSRF crumb；任务进入队列（201）即视为成功
+func (m *Manager) triggerJenkins(ctx context.Context, action config.RemediationAction, payload *Payload) (string, error) {
+	token, err := config.ResolveSecret(action.Token)
+	if err != nil {
+		return "", fmt.Errorf("解析 Jenkins API Token 失败：%w", err)
+	}
+	params := url.Values{}
+	for k, v := range scriptEnv(payload) {
+		params.Set(k, v)
+	}
+	endpoint := strings.TrimRight(action.URL, "/") + "/buildWithParameters?" + params.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, nil)
+	if err != nil {
+		return "", err
+	}
+	req.SetBasicAuth(action.User, token)
+	detail, err := m.do(req)
+	if err == nil && detail == "" {
+		detail = "任务已进入队列"
+	}
+	return detail, err
+}
+
+// do 发送请求，返回响应状态与响应体摘要，非 2xx 响应视为失败
+func (m *Manager) do(req *http.Request) (string, error) {
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxDetail))
+	detail := strings.TrimSpace(resp.Status + " " + strings.TrimSpace(string(body)))
+	if loc := resp.Header.Get("Location"); loc != "" {
+		detail += "\nLocation: " + loc
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return detail, fmt.Errorf("HTTP状态码异常：%d", resp.StatusCode)
+	}
+	return detail, nil
+}
+
+// runScript 执行脚本，处置信息通过环境变量传入（不拼接到命令参数中），退出码非 0 视为失败
+func runScript(ctx context.Context, action config.RemediationAction, payload *Payload) (string, error) {
+	cmd := exec.CommandContext(ctx, action.Command[0], action.Command[1:]...)
+	cmd.Env = os.Environ()
+	for k, v := range scriptEnv(payload) {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+	var out cappedBuffer
+	cmd.Stdout, cmd.Stderr = &out, &out
+	err := cmd.Run()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return out.String(), errors.New("脚本执行超时")
+	}
+	return out.String(), err
+}
+
+// scriptEnv 传给脚本的环境变量与 Jenkins 任务参数
+func scriptEnv(payload *Payload) map[string]string {
+	return map[string]string{
+		"REMEDIATION_ACTION":   payload.Action,
+		"TARGET_URL":           payload.TargetURL,
+		"TARGET_TAGS":          strings.Join(payload.Tags, ","),
+		"ERROR_TYPE":           payload.ErrorType,
+		"ERROR_MSG":            payload.ErrorMsg,
+		"CONSECUTIVE_FAILURES": fmt.Sprintf("%d", payload.ConsecutiveFailures),
+	}
+}
+
+// cappedBuffer 只保留前 maxDetail 字节的输出，避免脚本输出过多占用内存
+type cappedBuffer struct {
+	buf bytes.Buffer
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	if room := maxDetail - b.buf.Len(); room > 0 {
+		if len(p) > room {
+			b.buf.Write(p[:room])
+		} else {
+			b.buf.Write(p)
+		}
+	}
+	return len(p), nil
+}
+
+func (b *cappedBuffer) String() string {
+	return strings.TrimSpace(b.buf.String())
+}
+
+// truncate 截断执行详情，保证不超过审计记录的字段长度且不截断多字节字符
+func truncate(s string) string {
+	if len(s) <= maxDetail {
+		return s
+	}
+	s = s[:maxDetail]
+	for !utf8.ValidString(s) {
+		s = s[:len(s)-1]
+	}
+	return s + "..."
+}
@@ -0,0 +1,124 @@
+package scheduler
+
+import (
+	"strings"
+	"time"
+
+	"servicetelemetry/alert"
+)
+
+// 目标不在预览中安排检查的原因
+const (
+	SkipSchedulerDisabled = "schedulerDisabled" // 未开启定时调度（monitor.scheduler）
+	SkipRegion            = "region"            // 限定在其他区域检查
+)
+
+// PlannedCheck 一次计划中的检查
+type PlannedCheck struct {
+	At        time.Time  `json:"at"`                  // 计划检查时间（所在调度周期的触发时间）
+	Silenced  bool       `json:"silenced,omitempty"`  // 处于静默期（维护窗口）：照常检查，但不发送告警、不执行处置
+	SilenceID uint64     `json:"silenceId,omitempty"` // 生效的静默规则
+	SilenceTo *time.Time `json:"silenceTo,omitempty"` // 静默结束时间
+}
+
+// TargetSchedule 单个目标的检查计划
+type TargetSchedule struct {
+	URL           string         `json:"url"`
+	Skipped       string         `json:"skipped,omitempty"`       // 不安排检查的原因：schedulerDisabled / region
+	LastCheckedAt *time.Time     `json:"lastCheckedAt,omitempty"` // 最近一次检查时间（内存中的最近结果）
+	Checks        []PlannedCheck `json:"checks"`                  // 接下来的 N 次计划检查
+	CachedCycles  int            `json:"cachedCycles"`            // 计划范围内因结果缓存未过期而不检查的周期数
+}
+
+// SchedulePreview 调度预览：各目标接下来的计划检查时间
+type SchedulePreview struct {
+	Enabled       bool              `json:"enabled"`             // 是否开启了定时调度
+	Region        string            `json:"region,omitempty"`    // 本实例所在区域
+	CheckInterval string            `json:"checkInterval"`       // 调度周期
+	CacheTTL      string            `json:"cacheTtl"`            // 结果缓存有效期，距上次检查不足该时长的周期直接使用缓存结果
+	RetryBackoff  []string          `json:"retryBackoff"`        // 检查失败后各次重试前的等待时间，失败的检查会相应延后完成
+	NextCycle     *time.Time        `json:"nextCycle,omitempty"` // 下一个调度周期的触发时间
+	GeneratedAt   time.Time         `json:"generatedAt"`
+	Targets       []*TargetSchedule `json:"targets"`
+}
+
+// maxPreviewCycles 为凑满 N 次检查最多向后推算的周期数（缓存有效期远大于检查间隔时避免无限推算）
+const maxPreviewCycles = 10000
+
+// Preview 推算各有效目标接下来的 n 次计划检查时间：调度周期按启动时间加整数倍检查间隔触发，
+// 距上次检查不足缓存有效期的周期使用缓存结果、不实际检查；落在静默规则有效期内的检查标记为静默
+// match：目标地址过滤（子串匹配，不区分大小写），为空表示全部目标
+// silences：静默规则管理器，可为 nil
+func (s *Scheduler) Preview(n int, match string, silences *alert.SilenceManager) (*SchedulePreview, error) {
+	s.reportMu.RLock()
+	startedAt := s.startedAt
+	s.reportMu.RUnlock()
+
+	now := time.Now()
+	interval := s.cfg.CheckInterval
+	ttl := s.checker.CacheTTL()
+	preview := &SchedulePreview{
+		Enabled:       !startedAt.IsZero(),
+		Region:        s.cfg.Region,
+		CheckInterval: interval.String(),
+		CacheTTL:      ttl.String(),
+		RetryBackoff:  []string{},
+		GeneratedAt:   now,
+		Targets:       []*TargetSchedule{},
+	}
+	if backoff := s.checker.RetryBackoff(); len(backoff) > 1 {
+		for _, d := range backoff[:len(backoff)-1] {
+			preview.RetryBackoff = append(preview.RetryBackoff, d.String())
+		}
+	}
+
+	var next time.Time
+	if preview.Enabled {
+		next = startedAt.Add((now.Sub(startedAt)/interval + 1) * interval)
+		preview.NextCycle = &next
+	}
+
+	targets, err := s.storage.ListTargets(true)
+	if err != nil {
+		return nil, err
+	}
+	match = strings.ToLower(match)
+	for _, t := range targets {
+		if match != "" && !strings.Contains(strings.ToLower(t.URL), match) {
+			continue
+		}
+		schedule := &TargetSchedule{URL: t.URL, Checks: []PlannedCheck{}}
+		preview.Targets = append(preview.Targets, schedule)
+
+		var last time.Time
+		if r, ok := s.checker.LastKnown(t.URL); ok {
+			last = r.CheckedAt
+			checkedAt := r.CheckedAt
+			schedule.LastCheckedAt = &checkedAt
+		}
+		switch {
+		case !preview.Enabled:
+			schedule.Skipped = SkipSchedulerDisabled
+			continue
+		case !t.AllowedIn(s.cfg.Region):
+			schedule.Skipped = SkipRegion
+			continue
+		}
+
+		for at, i := next, 0; len(schedule.Checks) < n && i < maxPreviewCycles; at, i = at.Add(interval), i+1 {
+			// 与 CheckTarget 一致：缓存结果未过期时不检查
+			if !last.IsZero() && at.Sub(last) <= ttl {
+				schedule.CachedCycles++
+				continue
+			}
+			last = at
+			check := PlannedCheck{At: at}
+			if silence := silences.MatchAt(t.URL, at); silence != nil {
+				ends := silence.EndsAt
+				check.Silenced, check.SilenceID, check.SilenceTo = true, silence.ID, &ends
+			}
+			schedule.Checks = append(schedule.Checks, check)
+		}
+	}
+	return preview, nil
+}
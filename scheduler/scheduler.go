@@ -0,0 +1,270 @@
+package scheduler
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"servicetelemetry/alert"
+	"servicetelemetry/config"
+	"servicetelemetry/core"
+	"servicetelemetry/eventbus"
+	"servicetelemetry/logger"
+	"servicetelemetry/remediation"
+	"servicetelemetry/storage"
+)
+
+var log = logger.New("scheduler")
+
+// CycleReport 单次调度周期的执行报告
+type CycleReport struct {
+	StartedAt  time.Time             `json:"startedAt"`  // 周期开始时间
+	FinishedAt time.Time             `json:"finishedAt"` // 周期结束时间
+	DryRun     bool                  `json:"dryRun"`     // 是否为演练模式（未入库、未告警）
+	Targets    int                   `json:"targets"`    // 本周期检查的目标数
+	Skipped    int                   `json:"skipped"`    // 限定在其他区域检查而跳过的目标数
+	Results    []*core.MonitorResult `json:"results"`    // 检查结果（演练模式下为「将会入库」的结果）
+	Alerts     []*alert.Alert        `json:"alerts"`     // 演练模式下「将会发送」的告警
+	Errors     []string              `json:"errors"`     // 执行过程中的错误
+}
+
+// Scheduler 定时调度器，按检查间隔周期性检查全部有效监控目标
+type Scheduler struct {
+	cfg     *config.MonitorConfig
+	checker *core.ServiceChecker
+	storage *storage.MySQLStorage
+	alerts  *alert.Manager
+	bus     *eventbus.Bus
+	remedy  *remediation.Manager // 自动处置管理器，未开启时为 nil
+
+	cycleMu    sync.Mutex // 保证同一时间只有一个周期在执行
+	reportMu   sync.RWMutex
+	lastReport *CycleReport
+	startedAt  time.Time // 定时调度启动时间，各周期按此时间加整数倍检查间隔触发；未启动时为零值
+}
+
+// NewScheduler 创建一个新的定时调度器
+// cfg：服务监控配置，提供检查间隔与演练开关
+// checker：服务检查器
+// storage：数据库存储客户端，提供目标列表并保存结果
+// alerts：告警管理器
+// bus：事件总线，可为 nil
+func NewScheduler(cfg *config.MonitorConfig, checker *core.ServiceChecker, storage *storage.MySQLStorage, alerts *alert.Manager, bus *eventbus.Bus) *Scheduler {
+	return &Scheduler{
+		cfg:     cfg,
+		checker: checker,
+		storage: storage,
+		alerts:  alerts,
+		bus:     bus,
+	}
+}
+
+// SetRemediation 设置自动处置管理器，目标进入失败状态时执行其引用的处置动作
+func (s *Scheduler) SetRemediation(m *remediation.Manager) {
+	s.remedy = m
+}
+
+// Start 启动定时调度（后台运行）
+func (s *Scheduler) Start() {
+	s.reportMu.Lock()
+	s.startedAt = time.Now()
+	s.reportMu.Unlock()
+	go func() {
+		ticker := time.NewTicker(s.cfg.CheckInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			report := s.RunCycle(s.cfg.DryRun)
+			for _, e := range report.Errors {
+				log.Errorf("调度周期执行异常：%s", e)
+			}
+		}
+	}()
+}
+
+// RunCycle 执行一次调度周期：检查全部有效目标（限定了区域的目标只在对应区域的实例上检查），保存结果并处理告警
+// dryRun：演练模式，只执行检查，返回将会入库的结果和将会发送的告警
+func (s *Scheduler) RunCycle(dryRun bool) *CycleReport {
+	s.cycleMu.Lock()
+	defer s.cycleMu.Unlock()
+
+	report := &CycleReport{
+		StartedAt: time.Now(),
+		DryRun:    dryRun,
+	}
+	defer func() {
+		report.FinishedAt = time.Now()
+		s.reportMu.Lock()
+		s.lastReport = report
+		s.reportMu.Unlock()
+	}()
+
+	all, err := s.storage.ListTargets(true)
+	if err != nil {
+		report.Errors = append(report.Errors, "加载监控目标失败："+err.Error())
+		return report
+	}
+	// 多区域部署时各实例共享目标表，只检查允许在本区域检查的目标
+	targets := all[:0]
+	for _, t := range all {
+		if t.AllowedIn(s.cfg.Region) {
+			targets = append(targets, t)
+		}
+	}
+	report.Targets = len(targets)
+	report.Skipped = len(all) - len(targets)
+
+	limiter := core.NewConcurrencyLimiter(s.cfg.Concurrency)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+
+	wg.Add(len(targets))
+	for _, t := range targets {
+		limiter.Acquire()
+		go func(target *core.MonitorTarget) {
+			defer limiter.Release()
+			defer wg.Done()
+
+			if dryRun {
+				result := s.checker.Probe(target)
+				preview := s.alerts.Preview(result)
+				mu.Lock()
+				report.Results = append(report.Results, result)
+				if preview != nil {
+					report.Alerts = append(report.Alerts, preview)
+				}
+				mu.Unlock()
+				return
+			}
+
+			result := s.checker.CheckTarget(target)
+			// 命中缓存的结果已在上次检查时入库，不重复保存
+			if result.CheckedAt.Before(report.StartedAt) {
+				return
+			}
+
+			s.alerts.Process(result)
+			s.remedy.HandleResult(target, result)
+			if err := s.storage.SaveResult(result); err != nil {
+				mu.Lock()
+				report.Errors = append(report.Errors, fmt.Sprintf("保存结果[%s]失败：%v", target.URL, err))
+				mu.Unlock()
+				return
+			}
+			s.checker.TraceOnFailure(target, result, s.storage.UpdateResultDetails)
+			s.bus.Emit(eventbus.TypeResult, result.TargetURL, result)
+			mu.Lock()
+			report.Results = append(report.Results, result)
+			mu.Unlock()
+		}(t)
+	}
+	wg.Wait()
+
+	return report
+}
+
+// LastReport 返回最近一次调度周期的执行报告，尚未执行过时返回 nil
+func (s *Scheduler) LastReport() *CycleReport {
+	s.reportMu.RLock()
+	defer s.reportMu.RUnlock()
+	return s.lastReport
+}
+
+// WarmUp 启动预热：从数据库加载各目标最近一次结果，填充结果缓存、最新状态与告警状态
+// window：加载该时间范围内的结果
+func (s *Scheduler) WarmUp(window time.Duration) (int, error) {
+	results, err := s.storage.LatestResults(time.Now().Add(-window))
+	if err != nil {
+		return 0, fmt.Errorf("加载历史结果失败：%w", err)
+	}
+	s.alerts.Seed(results)
+	return s.checker.Preload(results), nil
+}
+
+// HandleRegistration 处理消息队列中的目标注册 / 注销消息，无效消息记录日志后丢弃
+func (s *Scheduler) HandleRegistration(payload []byte) {
+	msg, err := eventbus.ParseRegistration(payload)
+	if err != nil {
+		log.Warnf("丢弃无效的目标注册消息：%v", err)
+		return
+	}
+
+	switch msg.Action {
+	case eventbus.ActionRegister:
+		// 与接口提交的目标一样，不允许读取本机环境变量或文件的凭据引用
+		if refs := msg.LocalSecretRefs(); len(refs) > 0 {
+			log.Warnf("丢弃目标注册消息[%s]：不能使用 env: / file: 凭据引用（%s）", msg.URL, strings.Join(refs, "、"))
+			return
+		}
+		if msg.Proxy != "" {
+			log.Warnf("丢弃目标注册消息[%s]：不能配置 proxy，检查时统一使用全局代理 monitor.proxy", msg.URL)
+			return
+		}
+		if len(msg.Remediation) > 0 {
+			log.Warnf("丢弃目标注册消息[%s]：不能配置 remediation，处置动作只能在声明式目标定义文件中引用", msg.URL)
+			return
+		}
+		normalized, err := s.checker.ValidateURL(msg.URL)
+		if err != nil {
+			log.Warnf("丢弃目标注册消息：%v", err)
+			return
+		}
+		msg.URL = normalized
+		if msg.Compare != nil {
+			if msg.Compare.URL, err = s.checker.ValidateURL(msg.Compare.URL); err != nil {
+				log.Warnf("丢弃目标注册消息[%s]：比对地址%v", msg.URL, err)
+				return
+			}
+		}
+		if msg.Checksum != nil && msg.Checksum.URL != "" {
+			if msg.Checksum.URL, err = s.checker.ValidateURL(msg.Checksum.URL); err != nil {
+				log.Warnf("丢弃目标注册消息[%s]：摘要文件地址%v", msg.URL, err)
+				return
+			}
+		}
+		target := core.TargetFromDefinition(msg.Definition())
+		target.Source = core.TargetSourceMQ
+		if errs := core.ValidateTarget(target); len(errs) > 0 {
+			log.Warnf("丢弃目标注册消息[%s]：%v", msg.URL, errs[0])
+			return
+		}
+		if err := s.storage.SaveTarget(target); err != nil {
+			log.Errorf("注册目标[%s]失败：%v", msg.URL, err)
+			return
+		}
+		log.Infof("已通过消息队列注册目标：%s", msg.URL)
+	case eventbus.ActionDeregister:
+		if normalized, err := core.NormalizeTargetURL(msg.URL); err == nil {
+			msg.URL = normalized
+		}
+		found, err := s.storage.DeactivateTarget(msg.URL)
+		if err != nil {
+			log.Errorf("注销目标[%s]失败：%v", msg.URL, err)
+			return
+		}
+		if found {
+			log.Infof("已通过消息队列注销目标：%s", msg.URL)
+		}
+	}
+}
+
+// SyncTargetSpec 将声明式目标定义同步到数据库，供调度器检查
+// filePath：声明式目标定义文件路径
+func (s *Scheduler) SyncTargetSpec(filePath string) (int, error) {
+	spec, err := config.LoadTargetSpec(filePath)
+	if err != nil {
+		return 0, err
+	}
+
+	for i, def := range spec.Targets {
+		target := core.TargetFromDefinition(def)
+		target.Source = core.TargetSourceSpec
+		if errs := core.ValidateTarget(target); len(errs) > 0 {
+			return i, fmt.Errorf("目标[%s]定义无效：%v", def.URL, errs[0])
+		}
+		if err := s.storage.SaveTarget(target); err != nil {
+			return i, fmt.Errorf("保存目标[%s]失败：%w", def.URL, err)
+		}
+	}
+	return len(spec.Targets), nil
+}
@@ -0,0 +1,145 @@
+package snapshot
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Change 两个快照版本之间的单个字段差异
+type Change struct {
+	Path string      `json:"path"`          // 字段路径，如 monitor.checkInterval、targets[2].url
+	Op   string      `json:"op"`            // added / removed / changed
+	Old  interface{} `json:"old,omitempty"` // 旧值（敏感字段为 ******）
+	New  interface{} `json:"new,omitempty"` // 新值（敏感字段为 ******）
+}
+
+// redacted 敏感字段的展示值
+const redacted = "******"
+
+// sensitiveKeys 字段名包含这些关键词（不区分大小写）时视为敏感字段，差异与内容展示时脱敏
+var sensitiveKeys = []string{"password", "apikey", "secret", "token", "credentials"}
+
+// Diff 对比两份 JSON 内容，返回按字段路径排序的差异
+func Diff(from, to []byte) ([]Change, error) {
+	var a, b interface{}
+	if err := json.Unmarshal(from, &a); err != nil {
+		return nil, fmt.Errorf("解析旧版本失败：%w", err)
+	}
+	if err := json.Unmarshal(to, &b); err != nil {
+		return nil, fmt.Errorf("解析新版本失败：%w", err)
+	}
+
+	oldFields, newFields := map[string]interface{}{}, map[string]interface{}{}
+	secret := map[string]bool{}
+	flatten("", a, false, oldFields, secret)
+	flatten("", b, false, newFields, secret)
+
+	changes := []Change{}
+	for path, ov := range oldFields {
+		nv, ok := newFields[path]
+		switch {
+		case !ok:
+			changes = append(changes, Change{Path: path, Op: "removed", Old: mask(path, ov, secret)})
+		case !equal(ov, nv):
+			changes = append(changes, Change{Path: path, Op: "changed", Old: mask(path, ov, secret), New: mask(path, nv, secret)})
+		}
+	}
+	for path, nv := range newFields {
+		if _, ok := oldFields[path]; !ok {
+			changes = append(changes, Change{Path: path, Op: "added", New: mask(path, nv, secret)})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes, nil
+}
+
+// Redact 返回敏感字段脱敏后的 JSON 内容，解析失败时原样返回
+func Redact(content string) string {
+	var v interface{}
+	if err := json.Unmarshal([]byte(content), &v); err != nil {
+		return content
+	}
+	data, err := json.MarshalIndent(redact(v, false), "", "  ")
+	if err != nil {
+		return content
+	}
+	return string(data)
+}
+
+// flatten 将 JSON 展开为「字段路径 -> 叶子值」，敏感字段的路径记录到 secret 中
+func flatten(prefix string, v interface{}, sensitive bool, out map[string]interface{}, secret map[string]bool) {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		if len(val) == 0 && prefix != "" {
+			out[prefix] = map[string]interface{}{}
+		}
+		for k, child := range val {
+			path := k
+			if prefix != "" {
+				path = prefix + "." + k
+			}
+			flatten(path, child, sensitive || isSensitive(k), out, secret)
+		}
+	case []interface{}:
+		if len(val) == 0 {
+			out[prefix] = []interface{}{}
+		}
+		for i, child := range val {
+			flatten(fmt.Sprintf("%s[%d]", prefix, i), child, sensitive, out, secret)
+		}
+	default:
+		if sensitive {
+			secret[prefix] = true
+		}
+		out[prefix] = val
+	}
+}
+
+// mask 敏感字段的非空值替换为脱敏值
+func mask(path string, v interface{}, secret map[string]bool) interface{} {
+	if secret[path] && v != nil && v != "" {
+		return redacted
+	}
+	return v
+}
+
+// redact 递归替换敏感字段的值
+func redact(v interface{}, sensitive bool) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, child := range val {
+			val[k] = redact(child, sensitive || isSensitive(k))
+		}
+		return val
+	case []interface{}:
+		for i, child := range val {
+			val[i] = redact(child, sensitive)
+		}
+		return val
+	default:
+		if sensitive && val != nil && val != "" {
+			return redacted
+		}
+		return val
+	}
+}
+
+// isSensitive 判断字段名是否为敏感字段
+func isSensitive(key string) bool {
+	lower := strings.ToLower(key)
+	for _, s := range sensitiveKeys {
+		if strings.Contains(lower, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// equal 比较两个叶子值是否相同
+func equal(a, b interface{}) bool {
+	da, _ := json.Marshal(a)
+	db, _ := json.Marshal(b)
+	return string(da) == string(db)
+}
@@ -0,0 +1,267 @@
+package snapshot
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"servicetelemetry/config"
+	"servicetelemetry/logger"
+	"servicetelemetry/storage"
+)
+
+var log = logger.New("snapshot")
+
+// 快照类型
+const (
+	KindConfig  = "config"  // 全局配置文件
+	KindTargets = "targets" // 声明式目标定义文件
+)
+
+// 快照产生原因
+const (
+	SourceInitial  = "initial"  // 首次记录
+	SourceChange   = "change"   // 检测到内容变化（如热加载）
+	SourceRollback = "rollback" // 回滚到历史版本
+)
+
+// Manager 配置快照管理器，定期检查生效配置与声明式目标定义，内容变化时保存新版本，并支持对比与回滚
+type Manager struct {
+	cfg     *config.SnapshotConfig
+	monitor *config.MonitorConfig
+	storage *storage.MySQLStorage
+
+	mu       sync.Mutex        // 串行化记录与回滚，避免回滚写文件时被定时检查误记为普通变化
+	lastHash map[string]string // 快照类型 -> 最新版本内容哈希
+}
+
+// NewManager 创建配置快照管理器
+// cfg：配置快照配置
+// monitor：服务监控配置，提供声明式目标定义文件路径
+// storage：数据库存储客户端，保存快照
+func NewManager(cfg *config.SnapshotConfig, monitor *config.MonitorConfig, storage *storage.MySQLStorage) *Manager {
+	return &Manager{
+		cfg:      cfg,
+		monitor:  monitor,
+		storage:  storage,
+		lastHash: make(map[string]string),
+	}
+}
+
+// Start 立即记录一次当前配置，之后按间隔定期检查（后台运行）
+func (m *Manager) Start() {
+	m.Capture()
+	go func() {
+		ticker := time.NewTicker(m.cfg.Interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			m.Capture()
+		}
+	}()
+}
+
+// Capture 检查全部快照类型，内容变化时保存新版本，返回本次保存的快照
+func (m *Manager) Capture() []*storage.ConfigSnapshot {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var saved []*storage.ConfigSnapshot
+	for _, kind := range []string{KindConfig, KindTargets} {
+		content, err := m.current(kind)
+		if err != nil {
+			log.Warnf("读取%s快照内容失败：%v", kind, err)
+			continue
+		}
+		if content == nil {
+			continue
+		}
+		s, err := m.recordLocked(kind, content, SourceChange)
+		if err != nil {
+			log.Errorf("保存%s快照失败：%v", kind, err)
+			continue
+		}
+		if s != nil {
+			saved = append(saved, s)
+		}
+	}
+	return saved
+}
+
+// current 读取快照类型对应的当前内容，内容无法解析（如编辑中的半成品文件）时返回错误，不记录
+// 配置文件不存在时记录默认配置；未配置声明式目标定义文件时返回 nil
+func (m *Manager) current(kind string) ([]byte, error) {
+	switch kind {
+	case KindConfig:
+		data, err := os.ReadFile(config.CurrentConfigFile())
+		if errors.Is(err, os.ErrNotExist) {
+			return json.MarshalIndent(config.GetCurrentConfig(), "", "  ")
+		}
+		if err != nil {
+			return nil, err
+		}
+		var cfg config.GlobalConfig
+		if err := json.Unmarshal(data, &cfg); err != nil {
+			return nil, fmt.Errorf("配置文件格式错误：%w", err)
+		}
+		return data, nil
+	case KindTargets:
+		if m.monitor.TargetsFile == "" {
+			return nil, nil
+		}
+		data, err := os.ReadFile(m.monitor.TargetsFile)
+		if err != nil {
+			return nil, err
+		}
+		var spec config.TargetSpec
+		if err := json.Unmarshal(data, &spec); err != nil {
+			return nil, fmt.Errorf("目标定义文件格式错误：%w", err)
+		}
+		return data, nil
+	}
+	return nil, fmt.Errorf("未知的快照类型：%s", kind)
+}
+
+// recordLocked 内容与最新版本不同时保存新版本，未变化时返回 nil，调用方需持有锁
+func (m *Manager) recordLocked(kind string, content []byte, source string) (*storage.ConfigSnapshot, error) {
+	sum := sha256.Sum256(content)
+	hash := hex.EncodeToString(sum[:])
+
+	last, ok := m.lastHash[kind]
+	if !ok {
+		// 进程启动后首次记录，与数据库中的最新版本比较，避免每次重启都产生新版本
+		latest, err := m.storage.LatestSnapshot(kind)
+		if err != nil {
+			return nil, err
+		}
+		if latest == nil {
+			source = SourceInitial
+		} else {
+			last = latest.Hash
+		}
+	}
+	if last == hash {
+		m.lastHash[kind] = hash
+		return nil, nil
+	}
+
+	s := &storage.ConfigSnapshot{
+		Kind:      kind,
+		Hash:      hash,
+		Source:    source,
+		Content:   string(content),
+		CreatedAt: time.Now(),
+	}
+	if err := m.storage.SaveSnapshot(s, m.cfg.MaxVersions); err != nil {
+		return nil, err
+	}
+	m.lastHash[kind] = hash
+	return s, nil
+}
+
+// List 查询快照版本列表（不含内容）
+// kind：快照类型，为空时返回全部类型
+func (m *Manager) List(kind string) ([]*storage.ConfigSnapshot, error) {
+	return m.storage.ListSnapshots(kind)
+}
+
+// Get 查询指定版本的快照，不存在时返回 nil
+func (m *Manager) Get(id int64) (*storage.ConfigSnapshot, error) {
+	return m.storage.GetSnapshot(id)
+}
+
+// Diff 对比两个版本的快照，返回逐字段的差异（敏感字段已脱敏）
+// fromID、toID：对比的起止版本号，需为同一类型
+func (m *Manager) Diff(fromID, toID int64) ([]Change, error) {
+	from, err := m.mustGet(fromID)
+	if err != nil {
+		return nil, err
+	}
+	to, err := m.mustGet(toID)
+	if err != nil {
+		return nil, err
+	}
+	if from.Kind != to.Kind {
+		return nil, fmt.Errorf("版本 %d（%s）与版本 %d（%s）类型不同，无法对比", fromID, from.Kind, toID, to.Kind)
+	}
+	return Diff([]byte(from.Content), []byte(to.Content))
+}
+
+// Rollback 将指定版本的内容写回对应文件并重新加载，同时记录一个新版本（source=rollback）
+// 全局配置回滚后立即热加载；声明式目标定义回滚后需由调用方重新同步到数据库
+func (m *Manager) Rollback(id int64) (*storage.ConfigSnapshot, error) {
+	target, err := m.mustGet(id)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	switch target.Kind {
+	case KindConfig:
+		path := config.CurrentConfigFile()
+		if err := writeFile(path, []byte(target.Content)); err != nil {
+			return nil, err
+		}
+		if _, err := config.LoadConfigFromFile(path); err != nil {
+			return nil, fmt.Errorf("重新加载配置失败：%w", err)
+		}
+	case KindTargets:
+		if m.monitor.TargetsFile == "" {
+			return nil, fmt.Errorf("未配置声明式目标定义文件，无法回滚")
+		}
+		if err := writeFile(m.monitor.TargetsFile, []byte(target.Content)); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("未知的快照类型：%s", target.Kind)
+	}
+
+	s, err := m.recordLocked(target.Kind, []byte(target.Content), SourceRollback)
+	if err != nil {
+		return nil, err
+	}
+	if s == nil {
+		// 内容与最新版本相同（回滚到当前版本），无需新增版本
+		return target, nil
+	}
+	return s, nil
+}
+
+// mustGet 查询快照，不存在时返回错误
+func (m *Manager) mustGet(id int64) (*storage.ConfigSnapshot, error) {
+	s, err := m.storage.GetSnapshot(id)
+	if err != nil {
+		return nil, err
+	}
+	if s == nil {
+		return nil, &NotFoundError{ID: id}
+	}
+	return s, nil
+}
+
+// NotFoundError 快照版本不存在
+type NotFoundError struct {
+	ID int64
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("配置快照版本 %d 不存在", e.ID)
+}
+
+// writeFile 先写临时文件再重命名，避免热加载读到写了一半的文件
+func writeFile(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("写入文件 %s 失败：%w", path, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("写入文件 %s 失败：%w", path, err)
+	}
+	return nil
+}
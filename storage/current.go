@@ -0,0 +1,109 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// TargetStatus 目标当前状态（target_current_status 表），随每次结果写入在同一事务中更新
+type TargetStatus struct {
+	TargetURL           string    `json:"targetUrl"`           // 目标地址
+	Status              string    `json:"status"`              // 最近一次检查状态：success / failed / degraded
+	StatusCode          int       `json:"statusCode"`          // 最近一次检查的状态码
+	ResponseTime        float64   `json:"responseTime"`        // 最近一次检查的响应耗时（毫秒）
+	ErrorMsg            string    `json:"errorMsg"`            // 最近一次检查的错误信息
+	ErrorType           string    `json:"errorType"`           // 最近一次检查的错误类型
+	ConsecutiveFailures int       `json:"consecutiveFailures"` // 连续失败次数，成功后清零
+	LastCheckedAt       time.Time `json:"lastCheckedAt"`       // 最近一次检查时间
+	LastChangeAt        time.Time `json:"lastChangeAt"`        // 状态最近一次变化的时间（进入当前状态的时间）
+}
+
+// currentStatusTableSQL 目标当前状态表，每个目标一行，避免状态类接口对 monitor_results 做窗口查询
+const currentStatusTableSQL = `
+	CREATE TABLE IF NOT EXISTS target_current_status (
+		target_url VARCHAR(255) NOT NULL PRIMARY KEY,
+		status VARCHAR(20) NOT NULL,
+		status_code INT DEFAULT 0,
+		response_time FLOAT DEFAULT 0,
+		error_msg VARCHAR(512) DEFAULT '',
+		error_type VARCHAR(20) DEFAULT '',
+		consecutive_failures INT NOT NULL DEFAULT 0,
+		last_checked_at DATETIME NOT NULL,
+		last_change_at DATETIME NOT NULL
+	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+	`
+
+// upsertCurrentStatusSQL 写入结果时更新目标当前状态
+// ON DUPLICATE KEY UPDATE 按书写顺序赋值，last_change_at 与 consecutive_failures 必须在 status 之前，才能与更新前的状态比较
+// 晚于当前记录的结果才会更新（重试或补写的旧结果不会覆盖最新状态）
+const upsertCurrentStatusSQL = `
+    INSERT INTO target_current_status (
+        target_url, status, status_code, response_time, error_msg, error_type,
+        consecutive_failures, last_checked_at, last_change_at
+    ) VALUES (?, ?, ?, ?, ?, ?, IF(? = 'failed', 1, 0), ?, ?)
+    ON DUPLICATE KEY UPDATE
+        last_change_at = IF(VALUES(last_checked_at) < last_checked_at OR status = VALUES(status), last_change_at, VALUES(last_checked_at)),
+        consecutive_failures = IF(VALUES(last_checked_at) < last_checked_at, consecutive_failures,
+            IF(VALUES(status) = 'failed', consecutive_failures + 1, 0)),
+        status = IF(VALUES(last_checked_at) < last_checked_at, status, VALUES(status)),
+        status_code = IF(VALUES(last_checked_at) < last_checked_at, status_code, VALUES(status_code)),
+        response_time = IF(VALUES(last_checked_at) < last_checked_at, response_time, VALUES(response_time)),
+        error_msg = IF(VALUES(last_checked_at) < last_checked_at, error_msg, VALUES(error_msg)),
+        error_type = IF(VALUES(last_checked_at) < last_checked_at, error_type, VALUES(error_type)),
+        last_checked_at = GREATEST(last_checked_at, VALUES(last_checked_at))
+    `
+
+// backfillCurrentStatus 当前状态表为空时，用各目标最近一次结果回填（升级到带当前状态表的版本后首次启动）
+// 历史结果中无法准确还原连续失败次数与状态变化时间，回填时按最近一次结果近似：失败记 1 次，变化时间取检查时间
+func backfillCurrentStatus(db *sql.DB) error {
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM target_current_status").Scan(&count); err != nil {
+		return fmt.Errorf("检查当前状态表失败：%w", err)
+	}
+	if count > 0 {
+		return nil
+	}
+	_, err := db.Exec(`
+    INSERT IGNORE INTO target_current_status (
+        target_url, status, status_code, response_time, error_msg, error_type,
+        consecutive_failures, last_checked_at, last_change_at
+    )
+    SELECT r.target_url, r.status, r.status_code, r.response_time, r.error_msg, r.error_type,
+        IF(r.status = 'failed', 1, 0), r.checked_at, r.checked_at
+    FROM monitor_results r
+    JOIN (SELECT target_url, MAX(id) AS max_id FROM monitor_results GROUP BY target_url) latest ON r.id = latest.max_id
+    `)
+	if err != nil {
+		return fmt.Errorf("回填当前状态表失败：%w", err)
+	}
+	return nil
+}
+
+// CurrentStatuses 查询全部目标的当前状态，异常目标排在前面
+func (ms *MySQLStorage) CurrentStatuses() ([]*TargetStatus, error) {
+	sql := `
+    SELECT target_url, status, status_code, response_time, error_msg, error_type,
+        consecutive_failures, last_checked_at, last_change_at
+    FROM target_current_status
+    ORDER BY status = 'failed' DESC, status = 'degraded' DESC, target_url
+    `
+	defer ms.queries.observe("CurrentStatuses", sql, nil, time.Now())
+
+	rows, err := ms.db.Query(sql)
+	if err != nil {
+		return nil, fmt.Errorf("执行CurrentStatuses SQL失败：%w", err)
+	}
+	defer rows.Close()
+
+	list := []*TargetStatus{}
+	for rows.Next() {
+		var s TargetStatus
+		if err := rows.Scan(&s.TargetURL, &s.Status, &s.StatusCode, &s.ResponseTime, &s.ErrorMsg, &s.ErrorType,
+			&s.ConsecutiveFailures, &s.LastCheckedAt, &s.LastChangeAt); err != nil {
+			return nil, fmt.Errorf("扫描当前状态失败：%w", err)
+		}
+		list = append(list, &s)
+	}
+	return list, rows.Err()
+}
@@ -0,0 +1,362 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"servicetelemetry/core"
+)
+
+// 重复目标的判定依据
+const (
+	DuplicateNormalizedURL = "normalizedUrl" // 规范化地址相同（如大小写、默认端口、根路径 / 的差异）
+	DuplicateHostPath      = "hostPath"      // 主机名与路径相同，协议、端口或查询参数不同（如 http 与 https）
+)
+
+// 合并目标错误
+var (
+	ErrMergeTargetNotFound = errors.New("目标不存在")
+	ErrMergeIntoSelf       = errors.New("不能将目标合并到自身")
+)
+
+// DuplicateTarget 重复目标组中的一个目标及其历史概况
+type DuplicateTarget struct {
+	ID            int64      `json:"id"`
+	URL           string     `json:"url"`
+	IsCurrent     bool       `json:"isCurrent"`
+	Tags          []string   `json:"tags,omitempty"`
+	ResultCount   int64      `json:"resultCount"`             // 历史结果条数
+	LastCheckedAt *time.Time `json:"lastCheckedAt,omitempty"` // 最近一次检查时间，没有结果时为空
+}
+
+// DuplicateGroup 一组疑似重复的目标，Suggested 为建议保留的目标（当前有效、结果最多者优先）
+type DuplicateGroup struct {
+	Reason    string             `json:"reason"` // 判定依据：normalizedUrl / hostPath
+	Key       string             `json:"key"`    // 组内共同的规范化地址或主机名+路径
+	Suggested string             `json:"suggested"`
+	Targets   []*DuplicateTarget `json:"targets"`
+}
+
+// MergeReport 合并结果，DryRun 时只统计将要迁移的记录数，不修改数据
+type MergeReport struct {
+	Into        string   `json:"into"`
+	Merged      []string `json:"merged"`
+	Results     int64    `json:"results"`         // 迁移的检查结果条数
+	Transitions int64    `json:"transitions"`     // 迁移的状态变化记录条数
+	Remediation int64    `json:"remediationRuns"` // 迁移的处置执行记录条数
+	Heartbeats  int64    `json:"heartbeatPings"`  // 合并的心跳记录条数（被合并目标的心跳次数与运行记录累加到 into）
+	Tags        []string `json:"tags,omitempty"`  // 合并后的标签
+	DryRun      bool     `json:"dryRun"`
+}
+
+// hostPathKey 计算 HTTP(S) 目标的主机名+路径（忽略协议、端口、查询参数与末尾的 /），其他协议返回空字符串
+func hostPathKey(targetURL string) string {
+	u, err := url.Parse(targetURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return ""
+	}
+	return strings.ToLower(u.Hostname()) + strings.TrimRight(u.Path, "/")
+}
+
+// FindDuplicateTargets 查找疑似重复的目标：规范化地址相同的目标为一组；
+// HTTP(S) 目标主机名与路径相同但规范化地址不同（协议、端口或查询参数不同）的另成一组，需人工确认后再合并
+func (ms *MySQLStorage) FindDuplicateTargets() ([]*DuplicateGroup, error) {
+	targets, err := ms.ListTargets(false)
+	if err != nil {
+		return nil, err
+	}
+
+	byNormalized := make(map[string][]*core.MonitorTarget)
+	byHostPath := make(map[string][]*core.MonitorTarget)
+	for _, t := range targets {
+		normalized := core.CanonicalTargetURL(t.URL)
+		byNormalized[normalized] = append(byNormalized[normalized], t)
+		if key := hostPathKey(normalized); key != "" {
+			byHostPath[key] = append(byHostPath[key], t)
+		}
+	}
+
+	var groups []*DuplicateGroup
+	for key, list := range byNormalized {
+		if len(list) > 1 {
+			groups = append(groups, &DuplicateGroup{Reason: DuplicateNormalizedURL, Key: key, Targets: duplicateTargets(list)})
+		}
+	}
+	for key, list := range byHostPath {
+		distinct := make(map[string]bool)
+		for _, t := range list {
+			distinct[core.CanonicalTargetURL(t.URL)] = true
+		}
+		if len(distinct) > 1 {
+			groups = append(groups, &DuplicateGroup{Reason: DuplicateHostPath, Key: key, Targets: duplicateTargets(list)})
+		}
+	}
+	if len(groups) == 0 {
+		return []*DuplicateGroup{}, nil
+	}
+
+	var urls []string
+	for _, g := range groups {
+		for _, t := range g.Targets {
+			urls = append(urls, t.URL)
+		}
+	}
+	if err := ms.fillDuplicateHistory(groups, urls); err != nil {
+		return nil, err
+	}
+	for _, g := range groups {
+		g.Suggested = suggestMergeTarget(g.Targets)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Reason != groups[j].Reason {
+			return groups[i].Reason == DuplicateNormalizedURL
+		}
+		return groups[i].Key < groups[j].Key
+	})
+	return groups, nil
+}
+
+// duplicateTargets 转换为重复目标列表，按 ID 排序
+func duplicateTargets(list []*core.MonitorTarget) []*DuplicateTarget {
+	out := make([]*DuplicateTarget, 0, len(list))
+	for _, t := range list {
+		out = append(out, &DuplicateTarget{ID: t.ID, URL: t.URL, IsCurrent: t.IsCurrent, Tags: t.Tags})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+// fillDuplicateHistory 查询各目标的历史结果条数与最近检查时间
+func (ms *MySQLStorage) fillDuplicateHistory(groups []*DuplicateGroup, urls []string) error {
+	query := "SELECT target_url, COUNT(*), MAX(checked_at) FROM monitor_results WHERE target_url IN (" +
+		placeholders(len(urls)) + ") GROUP BY target_url"
+	args := make([]interface{}, len(urls))
+	for i, u := range urls {
+		args[i] = u
+	}
+	defer ms.queries.observe("FindDuplicateTargets", query, args, time.Now())
+
+	rows, err := ms.db.Query(query, args...)
+	if err != nil {
+		return fmt.Errorf("查询重复目标历史失败：%w", err)
+	}
+	defer rows.Close()
+
+	type history struct {
+		count int64
+		last  time.Time
+	}
+	histories := make(map[string]history)
+	for rows.Next() {
+		var targetURL string
+		var h history
+		if err := rows.Scan(&targetURL, &h.count, &h.last); err != nil {
+			return fmt.Errorf("扫描重复目标历史失败：%w", err)
+		}
+		histories[targetURL] = h
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for _, g := range groups {
+		for _, t := range g.Targets {
+			if h, ok := histories[t.URL]; ok {
+				last := h.last
+				t.ResultCount, t.LastCheckedAt = h.count, &last
+			}
+		}
+	}
+	return nil
+}
+
+// suggestMergeTarget 建议保留的目标：当前有效优先，其次历史结果最多，再次 ID 最小（最早创建）
+func suggestMergeTarget(targets []*DuplicateTarget) string {
+	best := targets[0]
+	for _, t := range targets[1:] {
+		switch {
+		case t.IsCurrent != best.IsCurrent:
+			if t.IsCurrent {
+				best = t
+			}
+		case t.ResultCount > best.ResultCount:
+			best = t
+		}
+	}
+	return best.URL
+}
+
+// MergeTargets 将 from 中的目标合并到 into：检查结果、状态变化记录与处置执行记录改为归属 into，
+// 当前状态保留检查时间最新的一条，标签取并集，任一目标有效则 into 有效，最后删除被合并的目标
+// 在同一事务中执行；dryRun 为 true 时统计后回滚，不修改数据
+func (ms *MySQLStorage) MergeTargets(into string, from []string, dryRun bool) (*MergeReport, error) {
+	report := &MergeReport{Into: into, Merged: []string{}, DryRun: dryRun}
+	seen := map[string]bool{into: true}
+	for _, u := range from {
+		if u == into {
+			return nil, ErrMergeIntoSelf
+		}
+		if !seen[u] {
+			seen[u] = true
+			report.Merged = append(report.Merged, u)
+		}
+	}
+	if len(report.Merged) == 0 {
+		return nil, fmt.Errorf("未指定要合并的目标")
+	}
+	defer ms.queries.observe("MergeTargets", "MERGE monitor_targets", []interface{}{into, strings.Join(report.Merged, ",")}, time.Now())
+
+	tx, err := ms.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("开启合并事务失败：%w", err)
+	}
+	defer tx.Rollback()
+
+	all := append([]string{into}, report.Merged...)
+	args := make([]interface{}, len(all))
+	for i, u := range all {
+		args[i] = u
+	}
+	rows, err := tx.Query("SELECT target_url, is_current, tags FROM monitor_targets WHERE target_url IN ("+placeholders(len(all))+") FOR UPDATE", args...)
+	if err != nil {
+		return nil, fmt.Errorf("查询待合并目标失败：%w", err)
+	}
+	found := make(map[string]bool)
+	current := false
+	tagSet := make(map[string]bool)
+	for rows.Next() {
+		var targetURL, tags string
+		var isCurrent bool
+		if err := rows.Scan(&targetURL, &isCurrent, &tags); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("扫描待合并目标失败：%w", err)
+		}
+		found[targetURL] = true
+		current = current || isCurrent
+		for _, tag := range strings.Split(tags, ",") {
+			if tag != "" && !tagSet[tag] {
+				tagSet[tag] = true
+				report.Tags = append(report.Tags, tag)
+			}
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	for _, u := range all {
+		if !found[u] {
+			return nil, fmt.Errorf("%w：%s", ErrMergeTargetNotFound, u)
+		}
+	}
+
+	in := placeholders(len(report.Merged))
+	moveArgs := append([]interface{}{into}, args[1:]...)
+	for _, m := range []struct {
+		table string
+		count *int64
+	}{
+		{"monitor_results", &report.Results},
+		{"target_transitions", &report.Transitions},
+		{"remediation_runs", &report.Remediation},
+	} {
+		res, err := tx.Exec("UPDATE "+m.table+" SET target_url = ? WHERE target_url IN ("+in+")", moveArgs...)
+		if err != nil {
+			return nil, fmt.Errorf("迁移 %s 失败：%w", m.table, err)
+		}
+		*m.count, _ = res.RowsAffected()
+	}
+
+	if err := mergeCurrentStatus(tx, into, all, args); err != nil {
+		return nil, err
+	}
+	if report.Heartbeats, err = mergeHeartbeatPings(tx, into, all, args); err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec("DELETE FROM monitor_targets WHERE target_url IN ("+in+")", args[1:]...); err != nil {
+		return nil, fmt.Errorf("删除被合并的目标失败：%w", err)
+	}
+	if _, err := tx.Exec("UPDATE monitor_targets SET is_current = ?, tags = ? WHERE target_url = ?", current, strings.Join(report.Tags, ","), into); err != nil {
+		return nil, fmt.Errorf("更新合并后的目标失败：%w", err)
+	}
+
+	if dryRun {
+		return report, nil
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("提交合并事务失败：%w", err)
+	}
+	// 重复目标合并完成后补建规范化地址唯一索引（仍有其他重复组时跳过）
+	if err := ensureNormalizedIndex(ms.db); err != nil {
+		log.Warnf("合并目标后建立规范化地址唯一索引失败：%v", err)
+	}
+	return report, nil
+}
+
+// mergeCurrentStatus 保留各目标中检查时间最新的当前状态并改为归属 into，删除其余目标的当前状态
+func mergeCurrentStatus(tx *sql.Tx, into string, all []string, args []interface{}) error {
+	var latest string
+	err := tx.QueryRow("SELECT target_url FROM target_current_status WHERE target_url IN ("+placeholders(len(all))+
+		") ORDER BY last_checked_at DESC LIMIT 1", args...).Scan(&latest)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("查询待合并目标的当前状态失败：%w", err)
+	}
+	deleteArgs := append([]interface{}{latest}, args...)
+	if _, err := tx.Exec("DELETE FROM target_current_status WHERE target_url <> ? AND target_url IN ("+placeholders(len(all))+")", deleteArgs...); err != nil {
+		return fmt.Errorf("清理被合并目标的当前状态失败：%w", err)
+	}
+	if latest == into {
+		return nil
+	}
+	if _, err := tx.Exec("UPDATE target_current_status SET target_url = ? WHERE target_url = ?", into, latest); err != nil {
+		return fmt.Errorf("迁移当前状态失败：%w", err)
+	}
+	return nil
+}
+
+// mergeHeartbeatPings 合并各目标的心跳记录：保留最近收到心跳的一条（含进行中的运行状态与最近一次运行）并改为归属 into，
+// 心跳次数、重叠次数与放弃的运行次数累加，开始等待时间取最早，删除其余目标的心跳记录；返回被合并目标的心跳记录条数
+func mergeHeartbeatPings(tx *sql.Tx, into string, all []string, args []interface{}) (int64, error) {
+	in := placeholders(len(all))
+	var latest string
+	err := tx.QueryRow("SELECT target_url FROM heartbeat_pings WHERE target_url IN ("+in+
+		") ORDER BY last_ping_at IS NULL, last_ping_at DESC LIMIT 1", args...).Scan(&latest)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("查询待合并目标的心跳记录失败：%w", err)
+	}
+
+	var since time.Time
+	var pings, overlaps, abandoned, merged int64
+	sumArgs := append([]interface{}{into}, args...)
+	err = tx.QueryRow("SELECT MIN(since), SUM(pings), SUM(overlaps), SUM(abandoned_runs), SUM(target_url <> ?) FROM heartbeat_pings WHERE target_url IN ("+in+")",
+		sumArgs...).Scan(&since, &pings, &overlaps, &abandoned, &merged)
+	if err != nil {
+		return 0, fmt.Errorf("统计待合并目标的心跳记录失败：%w", err)
+	}
+
+	deleteArgs := append([]interface{}{latest}, args...)
+	if _, err := tx.Exec("DELETE FROM heartbeat_pings WHERE target_url <> ? AND target_url IN ("+in+")", deleteArgs...); err != nil {
+		return 0, fmt.Errorf("清理被合并目标的心跳记录失败：%w", err)
+	}
+	_, err = tx.Exec("UPDATE heartbeat_pings SET target_url = ?, since = ?, pings = ?, overlaps = ?, abandoned_runs = ? WHERE target_url = ?",
+		into, since, pings, overlaps, abandoned, latest)
+	if err != nil {
+		return 0, fmt.Errorf("迁移心跳记录失败：%w", err)
+	}
+	return merged, nil
+}
+
+// placeholders 生成 IN 子句的占位符，如 ?, ?, ?
+func placeholders(n int) string {
+	return "?" + strings.Repeat(", ?", n-1)
+}
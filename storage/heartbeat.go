@@ -0,0 +1,205 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"servicetelemetry/core"
+)
+
+// heartbeatTableSQL 心跳记录表，每个心跳目标一行
+const heartbeatTableSQL = `
+	CREATE TABLE IF NOT EXISTS heartbeat_pings (
+		target_url VARCHAR(255) NOT NULL PRIMARY KEY,
+		since DATETIME NOT NULL,
+		last_ping_at DATETIME NULL,
+		pings BIGINT NOT NULL DEFAULT 0,
+		last_source VARCHAR(64) DEFAULT '',
+		running INT NOT NULL DEFAULT 0,
+		run_started_at DATETIME(3) NULL,
+		last_start_at DATETIME(3) NULL,
+		last_run_ms BIGINT NULL,
+		last_run_end_at DATETIME(3) NULL,
+		overlaps BIGINT NOT NULL DEFAULT 0,
+		last_overlap_at DATETIME(3) NULL,
+		abandoned_runs BIGINT NOT NULL DEFAULT 0
+	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+	`
+
+// ensureHeartbeatRunColumns 为历史版本创建的心跳记录表补充运行信息字段
+func ensureHeartbeatRunColumns(db *sql.DB) error {
+	columns := []struct{ name, definition string }{
+		{"running", "INT NOT NULL DEFAULT 0"},
+		{"run_started_at", "DATETIME(3) NULL"},
+		{"last_start_at", "DATETIME(3) NULL"},
+		{"last_run_ms", "BIGINT NULL"},
+		{"last_run_end_at", "DATETIME(3) NULL"},
+		{"overlaps", "BIGINT NOT NULL DEFAULT 0"},
+		{"last_overlap_at", "DATETIME(3) NULL"},
+		{"abandoned_runs", "BIGINT NOT NULL DEFAULT 0"},
+	}
+	for _, col := range columns {
+		if err := ensureColumn(db, "heartbeat_pings", col.name, col.definition); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// heartbeatRun 心跳目标进行中的运行状态
+type heartbeatRun struct {
+	running      int
+	runStartedAt sql.NullTime
+	lastStartAt  sql.NullTime
+}
+
+// lockHeartbeatRun 在事务中锁定目标的心跳记录并读取运行状态，目标还没有记录时以 at 开始等待心跳
+func lockHeartbeatRun(tx *sql.Tx, targetURL string, at time.Time) (*heartbeatRun, error) {
+	if _, err := tx.Exec("INSERT IGNORE INTO heartbeat_pings (target_url, since) VALUES (?, ?)", targetURL, at); err != nil {
+		return nil, fmt.Errorf("初始化心跳记录失败：%w", err)
+	}
+	var run heartbeatRun
+	err := tx.QueryRow("SELECT running, run_started_at, last_start_at FROM heartbeat_pings WHERE target_url = ? FOR UPDATE", targetURL).
+		Scan(&run.running, &run.runStartedAt, &run.lastStartAt)
+	if err != nil {
+		return nil, fmt.Errorf("查询心跳记录失败：%w", err)
+	}
+	return &run, nil
+}
+
+// RecordHeartbeat 记录一次心跳（任务完成）；收到过开始信号时结束进行中最早的一次运行并记录其耗时
+// 开始与完成信号不携带运行标识，运行重叠时按先开始先完成近似计算耗时
+// targetURL：心跳目标地址
+// source：心跳来源地址
+// at：收到心跳的时间
+func (ms *MySQLStorage) RecordHeartbeat(targetURL, source string, at time.Time) error {
+	query := `UPDATE heartbeat_pings SET last_ping_at = ?, pings = pings + 1, last_source = ?,
+	running = ?, run_started_at = ?, last_run_ms = ?, last_run_end_at = ? WHERE target_url = ?`
+	defer ms.queries.observe("RecordHeartbeat", query, []interface{}{targetURL, source, at}, time.Now())
+
+	tx, err := ms.db.Begin()
+	if err != nil {
+		return fmt.Errorf("开启心跳事务失败：%w", err)
+	}
+	defer tx.Rollback()
+
+	run, err := lockHeartbeatRun(tx, targetURL, at)
+	if err != nil {
+		return err
+	}
+	var lastRunMs sql.NullInt64
+	var lastRunEnd sql.NullTime
+	if run.running > 0 && run.runStartedAt.Valid {
+		lastRunMs = sql.NullInt64{Int64: at.Sub(run.runStartedAt.Time).Milliseconds(), Valid: true}
+		lastRunEnd = sql.NullTime{Time: at, Valid: true}
+		run.running--
+		// 仍有进行中的运行（重叠）时，以最近一次开始信号作为下一次完成信号对应的开始时间
+		run.runStartedAt = run.lastStartAt
+		if run.running == 0 {
+			run.runStartedAt = sql.NullTime{}
+		}
+	}
+	args := []interface{}{at, source, run.running, run.runStartedAt, lastRunMs, lastRunEnd, targetURL}
+	if !lastRunMs.Valid {
+		// 未收到开始信号的心跳不覆盖最近一次运行的耗时
+		query = `UPDATE heartbeat_pings SET last_ping_at = ?, pings = pings + 1, last_source = ? WHERE target_url = ?`
+		args = []interface{}{at, source, targetURL}
+	}
+	if _, err := tx.Exec(query, args...); err != nil {
+		return fmt.Errorf("记录心跳失败：%w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("提交心跳事务失败：%w", err)
+	}
+	return nil
+}
+
+// RecordHeartbeatStart 记录一次运行开始；上一次运行尚未结束时记为重叠，开始时间早于 runLimit 仍未结束的运行视为已放弃
+// targetURL：心跳目标地址
+// source：开始信号的来源地址
+// at：收到开始信号的时间
+// runLimit：运行时限，见 MonitorTarget.HeartbeatRunLimit
+func (ms *MySQLStorage) RecordHeartbeatStart(targetURL, source string, at time.Time, runLimit time.Duration) error {
+	query := `UPDATE heartbeat_pings SET running = ?, run_started_at = ?, last_start_at = ?, last_source = ?,
+	overlaps = overlaps + ?, last_overlap_at = COALESCE(?, last_overlap_at), abandoned_runs = abandoned_runs + ? WHERE target_url = ?`
+	defer ms.queries.observe("RecordHeartbeatStart", query, []interface{}{targetURL, source, at}, time.Now())
+
+	tx, err := ms.db.Begin()
+	if err != nil {
+		return fmt.Errorf("开启心跳事务失败：%w", err)
+	}
+	defer tx.Rollback()
+
+	run, err := lockHeartbeatRun(tx, targetURL, at)
+	if err != nil {
+		return err
+	}
+	stale := at.Add(-runLimit)
+	abandoned := 0
+	switch {
+	case run.running > 0 && run.lastStartAt.Valid && run.lastStartAt.Time.Before(stale):
+		abandoned, run.running = run.running, 0
+	case run.running > 1 && run.runStartedAt.Valid && run.runStartedAt.Time.Before(stale):
+		// 只有较早的运行超时未结束：放弃它们，保留最近一次开始的运行
+		abandoned, run.running = run.running-1, 1
+		run.runStartedAt = run.lastStartAt
+	}
+
+	overlaps := 0
+	var overlapAt sql.NullTime
+	if run.running > 0 {
+		overlaps = 1
+		overlapAt = sql.NullTime{Time: at, Valid: true}
+	} else {
+		run.runStartedAt = sql.NullTime{Time: at, Valid: true}
+	}
+	run.running++
+
+	args := []interface{}{run.running, run.runStartedAt, at, source, overlaps, overlapAt, abandoned, targetURL}
+	if _, err := tx.Exec(query, args...); err != nil {
+		return fmt.Errorf("记录运行开始失败：%w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("提交心跳事务失败：%w", err)
+	}
+	return nil
+}
+
+// HeartbeatFor 返回目标的心跳记录；目标还没有记录时以当前时间开始等待心跳，新目标不会在注册后立即判定为超时
+func (ms *MySQLStorage) HeartbeatFor(targetURL string) (*core.HeartbeatPing, error) {
+	insert := "INSERT IGNORE INTO heartbeat_pings (target_url, since) VALUES (?, ?)"
+	if _, err := ms.db.Exec(insert, targetURL, time.Now()); err != nil {
+		return nil, fmt.Errorf("初始化心跳记录失败：%w", err)
+	}
+
+	query := `SELECT since, last_ping_at, pings, last_source, running, run_started_at, last_start_at,
+	last_run_ms, last_run_end_at, overlaps, last_overlap_at, abandoned_runs FROM heartbeat_pings WHERE target_url = ?`
+	defer ms.queries.observe("HeartbeatFor", query, []interface{}{targetURL}, time.Now())
+
+	var ping core.HeartbeatPing
+	var last, runStarted, lastStart, lastRunEnd, lastOverlap sql.NullTime
+	var lastRunMs sql.NullInt64
+	err := ms.db.QueryRow(query, targetURL).Scan(&ping.Since, &last, &ping.Pings, &ping.Source, &ping.Running, &runStarted,
+		&lastStart, &lastRunMs, &lastRunEnd, &ping.Overlaps, &lastOverlap, &ping.AbandonedRuns)
+	if err != nil {
+		return nil, fmt.Errorf("查询心跳记录失败：%w", err)
+	}
+	ping.LastPingAt = nullTime(last)
+	ping.RunStartedAt = nullTime(runStarted)
+	ping.LastStartAt = nullTime(lastStart)
+	ping.LastRunEndAt = nullTime(lastRunEnd)
+	ping.LastOverlapAt = nullTime(lastOverlap)
+	if lastRunMs.Valid {
+		ping.LastRunMs = &lastRunMs.Int64
+	}
+	return &ping, nil
+}
+
+// nullTime 将可为空的时间字段转换为指针，为空时返回 nil
+func nullTime(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
@@ -0,0 +1,119 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// IncidentLogRecord 事件沟通记录中的一条，与告警模块的沟通记录一一对应
+type IncidentLogRecord struct {
+	IncidentID uint64    // 事件ID
+	Time       time.Time // 记录时间
+	Kind       string    // 记录类型
+	Actor      string    // 操作人
+	Channel    string    // 通知渠道
+	Recipient  string    // 接收人
+	Message    string    // 记录内容
+	Error      string    // 发送失败的原因
+}
+
+// incidentTableSQL 事件表，保存每个事件最新的快照（JSON），服务重启后仍可导出沟通记录
+const incidentTableSQL = `
+	CREATE TABLE IF NOT EXISTS incidents (
+		id BIGINT UNSIGNED NOT NULL PRIMARY KEY,
+		snapshot TEXT NOT NULL,
+		updated_at DATETIME(3) NOT NULL
+	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+	`
+
+// incidentLogTableSQL 事件沟通记录表
+const incidentLogTableSQL = `
+	CREATE TABLE IF NOT EXISTS incident_log (
+		id BIGINT AUTO_INCREMENT PRIMARY KEY,
+		incident_id BIGINT UNSIGNED NOT NULL,
+		logged_at DATETIME(3) NOT NULL,
+		kind VARCHAR(20) NOT NULL,
+		actor VARCHAR(128) DEFAULT '',
+		channel VARCHAR(64) DEFAULT '',
+		recipient VARCHAR(255) DEFAULT '',
+		message TEXT,
+		error TEXT,
+		INDEX idx_incident_logged (incident_id, logged_at)
+	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+	`
+
+// AppendIncidentLog 追加一条沟通记录，并在同一事务中更新事件快照
+// snapshot：记录时事件的快照（JSON）
+func (ms *MySQLStorage) AppendIncidentLog(record *IncidentLogRecord, snapshot string) error {
+	query := `INSERT INTO incident_log (incident_id, logged_at, kind, actor, channel, recipient, message, error)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+	args := []interface{}{record.IncidentID, record.Time, record.Kind, record.Actor, record.Channel,
+		record.Recipient, record.Message, record.Error}
+	defer ms.queries.observe("AppendIncidentLog", query, args, time.Now())
+
+	tx, err := ms.db.Begin()
+	if err != nil {
+		return fmt.Errorf("开启AppendIncidentLog事务失败：%w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(query, args...); err != nil {
+		return fmt.Errorf("保存事件沟通记录失败：%w", err)
+	}
+	_, err = tx.Exec(`INSERT INTO incidents (id, snapshot, updated_at) VALUES (?, ?, ?)
+	ON DUPLICATE KEY UPDATE snapshot = VALUES(snapshot), updated_at = VALUES(updated_at)`,
+		record.IncidentID, snapshot, record.Time)
+	if err != nil {
+		return fmt.Errorf("保存事件快照失败：%w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("提交AppendIncidentLog事务失败：%w", err)
+	}
+	return nil
+}
+
+// IncidentLog 查询事件快照与全部沟通记录（按时间升序），事件不存在时快照为空
+func (ms *MySQLStorage) IncidentLog(incidentID uint64) (string, []*IncidentLogRecord, error) {
+	var snapshot string
+	err := ms.db.QueryRow("SELECT snapshot FROM incidents WHERE id = ?", incidentID).Scan(&snapshot)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil, nil
+	}
+	if err != nil {
+		return "", nil, fmt.Errorf("查询事件快照失败：%w", err)
+	}
+
+	query := `SELECT incident_id, logged_at, kind, actor, channel, recipient, message, error
+	FROM incident_log WHERE incident_id = ? ORDER BY logged_at, id`
+	args := []interface{}{incidentID}
+	defer ms.queries.observe("IncidentLog", query, args, time.Now())
+
+	rows, err := ms.db.Query(query, args...)
+	if err != nil {
+		return "", nil, fmt.Errorf("执行IncidentLog SQL失败：%w", err)
+	}
+	defer rows.Close()
+
+	var records []*IncidentLogRecord
+	for rows.Next() {
+		r := &IncidentLogRecord{}
+		var actor, channel, recipient, message, errMsg sql.NullString
+		if err := rows.Scan(&r.IncidentID, &r.Time, &r.Kind, &actor, &channel, &recipient, &message, &errMsg); err != nil {
+			return "", nil, fmt.Errorf("解析事件沟通记录失败：%w", err)
+		}
+		r.Actor, r.Channel, r.Recipient, r.Message, r.Error = actor.String, channel.String, recipient.String, message.String, errMsg.String
+		records = append(records, r)
+	}
+	return snapshot, records, rows.Err()
+}
+
+// MaxIncidentID 返回已保存的最大事件ID，没有记录时为 0；启动时据此继续分配事件ID，避免与重启前的事件重复
+func (ms *MySQLStorage) MaxIncidentID() (uint64, error) {
+	var id sql.NullInt64
+	if err := ms.db.QueryRow("SELECT MAX(id) FROM incidents").Scan(&id); err != nil {
+		return 0, fmt.Errorf("查询最大事件ID失败：%w", err)
+	}
+	return uint64(id.Int64), nil
+}
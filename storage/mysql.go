@@ -2,18 +2,25 @@ package storage
 
 import (
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"servicetelemetry/config"
 	"servicetelemetry/core"
+	"servicetelemetry/logger"
 
 	_ "github.com/go-sql-driver/mysql"
 )
 
+var log = logger.New("storage")
+
 // MySQLStorage MySQL存储客户端，负责监控数据的持久化和查询
 type MySQLStorage struct {
-	db *sql.DB // 数据库连接对象，用于执行SQL操作
+	db      *sql.DB        // 数据库连接对象，用于执行SQL操作
+	queries *queryRecorder // 存储操作耗时统计与慢查询记录
 }
 
 // NewMySQLStorage 创建一个新的MySQL存储客户端，自动创建数据库和数据表
@@ -62,7 +69,7 @@ func NewMySQLStorage(cfg *config.DBConfig) (*MySQLStorage, error) {
 		return nil, fmt.Errorf("初始化表失败：%w", err)
 	}
 
-	return &MySQLStorage{db: db}, nil
+	return &MySQLStorage{db: db, queries: newQueryRecorder(cfg.SlowQueryThreshold)}, nil
 }
 
 // initTables 初始化数据表，创建监控结果表和监控目标表
@@ -77,8 +84,14 @@ func initTables(db *sql.DB) error {
 		status_code INT DEFAULT 0,
 		response_time FLOAT DEFAULT 0,
 		ssl_cert_expiry VARCHAR(50) DEFAULT '',
+		ssl_days_left INT NULL,
+		ssl_not_after DATETIME NULL,
 		keyword_matched TINYINT(1) DEFAULT 0,
 		error_msg VARCHAR(512) DEFAULT '',
+		error_type VARCHAR(20) DEFAULT '',
+		details TEXT,
+		timings VARCHAR(255) NULL,
+		response_snapshot MEDIUMTEXT NULL,
 		checked_at DATETIME NOT NULL,
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
 	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -91,8 +104,43 @@ func initTables(db *sql.DB) error {
 		target_url VARCHAR(255) NOT NULL UNIQUE,
 		keyword VARCHAR(100) DEFAULT '',
 		is_current TINYINT(1) DEFAULT 1,
+		tags VARCHAR(255) DEFAULT '',
+		assertions VARCHAR(1024) DEFAULT '',
+		options TEXT,
+		normalized_url VARCHAR(255) NULL,
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+		UNIQUE KEY uk_normalized_url (normalized_url)
+	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+	`
+
+	// 创建配置快照表
+	snapshotTableSQL := `
+	CREATE TABLE IF NOT EXISTS config_snapshots (
+		id BIGINT AUTO_INCREMENT PRIMARY KEY,
+		kind VARCHAR(20) NOT NULL,
+		hash CHAR(64) NOT NULL,
+		source VARCHAR(64) DEFAULT '',
+		content MEDIUMTEXT NOT NULL,
+		created_at DATETIME NOT NULL,
+		INDEX idx_kind_id (kind, id)
+	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+	`
+
+	// 创建状态更新订阅表
+	subscriptionTableSQL := `
+	CREATE TABLE IF NOT EXISTS status_subscriptions (
+		id BIGINT AUTO_INCREMENT PRIMARY KEY,
+		channel VARCHAR(20) NOT NULL,
+		address VARCHAR(512) NOT NULL,
+		tags VARCHAR(1024) DEFAULT '',
+		confirmed BOOLEAN NOT NULL DEFAULT FALSE,
+		confirm_token CHAR(32) NOT NULL,
+		unsubscribe_token CHAR(32) NOT NULL,
+		created_at DATETIME NOT NULL,
+		confirmed_at DATETIME NULL,
+		UNIQUE KEY uk_confirm_token (confirm_token),
+		UNIQUE KEY uk_unsubscribe_token (unsubscribe_token)
 	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
 	`
 
@@ -103,115 +151,602 @@ func initTables(db *sql.DB) error {
 	if _, err := db.Exec(targetTableSQL); err != nil {
 		return err
 	}
+	if _, err := db.Exec(snapshotTableSQL); err != nil {
+		return err
+	}
+	if _, err := db.Exec(subscriptionTableSQL); err != nil {
+		return err
+	}
+	if _, err := db.Exec(currentStatusTableSQL); err != nil {
+		return err
+	}
+	if _, err := db.Exec(transitionTableSQL); err != nil {
+		return err
+	}
+	if _, err := db.Exec(remediationTableSQL); err != nil {
+		return err
+	}
+	if _, err := db.Exec(heartbeatTableSQL); err != nil {
+		return err
+	}
+	if _, err := db.Exec(incidentTableSQL); err != nil {
+		return err
+	}
+	if _, err := db.Exec(incidentLogTableSQL); err != nil {
+		return err
+	}
+
+	// 为历史版本创建的数据表补充新增字段
+	if err := ensureColumn(db, "monitor_results", "details", "TEXT"); err != nil {
+		return err
+	}
+	if err := ensureColumn(db, "monitor_results", "error_type", "VARCHAR(20) DEFAULT ''"); err != nil {
+		return err
+	}
+	if err := ensureColumn(db, "monitor_results", "timings", "VARCHAR(255) NULL"); err != nil {
+		return err
+	}
+	if err := ensureColumn(db, "monitor_results", "response_snapshot", "MEDIUMTEXT NULL"); err != nil {
+		return err
+	}
+	if err := ensureHeartbeatRunColumns(db); err != nil {
+		return err
+	}
+	if err := ensureCertColumns(db); err != nil {
+		return err
+	}
+	if err := ensureColumn(db, "monitor_targets", "tags", "VARCHAR(255) DEFAULT ''"); err != nil {
+		return err
+	}
+	if err := ensureColumn(db, "monitor_targets", "assertions", "VARCHAR(1024) DEFAULT ''"); err != nil {
+		return err
+	}
+	if err := ensureColumn(db, "monitor_targets", "options", "TEXT"); err != nil {
+		return err
+	}
+	// 已有目标无法区分来源，按接口提交处理；声明式目标定义文件中的目标在下次同步时更新为 spec
+	if err := ensureColumn(db, "monitor_targets", "source", "VARCHAR(10) DEFAULT 'api'"); err != nil {
+		return err
+	}
+	if err := ensureNormalizedURLs(db); err != nil {
+		return err
+	}
+
+	return backfillCurrentStatus(db)
+}
+
+// ensureCertColumns 追加证书剩余天数与 notAfter 字段；首次追加时按展示文本回填历史结果的剩余天数，
+// 历史结果没有 notAfter，保持为空
+func ensureCertColumns(db *sql.DB) error {
+	exists, err := columnExists(db, "monitor_results", "ssl_days_left")
+	if err != nil {
+		return err
+	}
+	if err := ensureColumn(db, "monitor_results", "ssl_days_left", "INT NULL"); err != nil {
+		return err
+	}
+	if err := ensureColumn(db, "monitor_results", "ssl_not_after", "DATETIME NULL"); err != nil {
+		return err
+	}
+	if exists {
+		return nil
+	}
+	_, err = db.Exec(`
+    UPDATE monitor_results SET ssl_days_left = CASE
+        WHEN ssl_cert_expiry = '今日过期' THEN 0
+        WHEN ssl_cert_expiry LIKE '还有%天过期' THEN CAST(SUBSTRING(ssl_cert_expiry, 3, CHAR_LENGTH(ssl_cert_expiry) - 5) AS SIGNED)
+        WHEN ssl_cert_expiry LIKE '已过期%天' THEN -CAST(SUBSTRING(ssl_cert_expiry, 4, CHAR_LENGTH(ssl_cert_expiry) - 4) AS SIGNED)
+    END
+    WHERE ssl_cert_expiry <> ''
+    `)
+	if err != nil {
+		return fmt.Errorf("回填证书剩余天数失败：%w", err)
+	}
+	return nil
+}
 
+// ensureNormalizedURLs 追加目标规范化地址字段，回填历史目标并建立唯一索引，保证同一服务的不同写法只对应一个目标
+// 历史数据中已存在规范化后重复的目标时不建立索引，仅记录告警，待合并重复目标（MergeTargets）后再建立
+func ensureNormalizedURLs(db *sql.DB) error {
+	if err := ensureColumn(db, "monitor_targets", "normalized_url", "VARCHAR(255) NULL"); err != nil {
+		return err
+	}
+
+	rows, err := db.Query("SELECT id, target_url FROM monitor_targets WHERE normalized_url IS NULL")
+	if err != nil {
+		return fmt.Errorf("查询待回填规范化地址的目标失败：%w", err)
+	}
+	pending := make(map[int64]string)
+	for rows.Next() {
+		var id int64
+		var targetURL string
+		if err := rows.Scan(&id, &targetURL); err != nil {
+			rows.Close()
+			return fmt.Errorf("扫描目标失败：%w", err)
+		}
+		pending[id] = targetURL
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for id, targetURL := range pending {
+		if _, err := db.Exec("UPDATE monitor_targets SET normalized_url = ? WHERE id = ?", core.CanonicalTargetURL(targetURL), id); err != nil {
+			return fmt.Errorf("回填目标[%s]规范化地址失败：%w", targetURL, err)
+		}
+	}
+	return ensureNormalizedIndex(db)
+}
+
+// ensureNormalizedIndex 规范化地址没有重复时建立唯一索引，已建立时直接返回
+func ensureNormalizedIndex(db *sql.DB) error {
+	var indexed int
+	err := db.QueryRow(
+		"SELECT COUNT(*) FROM information_schema.STATISTICS WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = 'monitor_targets' AND INDEX_NAME = 'uk_normalized_url'",
+	).Scan(&indexed)
+	if err != nil {
+		return fmt.Errorf("检查规范化地址索引失败：%w", err)
+	}
+	if indexed > 0 {
+		return nil
+	}
+
+	var duplicates []string
+	rows, err := db.Query("SELECT normalized_url FROM monitor_targets GROUP BY normalized_url HAVING COUNT(*) > 1")
+	if err != nil {
+		return fmt.Errorf("查询规范化后重复的目标失败：%w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var normalized string
+		if err := rows.Scan(&normalized); err != nil {
+			return fmt.Errorf("扫描重复目标失败：%w", err)
+		}
+		duplicates = append(duplicates, normalized)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if len(duplicates) > 0 {
+		log.Warnf("存在 %d 组规范化后地址相同的目标（如 %s），暂不建立唯一索引，请通过 POST /api/v1/admin/targets/merge 合并重复目标", len(duplicates), duplicates[0])
+		return nil
+	}
+	if _, err := db.Exec("ALTER TABLE monitor_targets ADD UNIQUE KEY uk_normalized_url (normalized_url)"); err != nil {
+		return fmt.Errorf("建立规范化地址唯一索引失败：%w", err)
+	}
+	return nil
+}
+
+// columnExists 检查数据表中是否存在指定字段
+func columnExists(db *sql.DB, table, column string) (bool, error) {
+	var count int
+	err := db.QueryRow(
+		"SELECT COUNT(*) FROM information_schema.COLUMNS WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? AND COLUMN_NAME = ?",
+		table, column,
+	).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("检查字段 %s.%s 失败：%w", table, column, err)
+	}
+	return count > 0, nil
+}
+
+// ensureColumn 检查数据表中是否存在指定字段，不存在则追加，用于兼容历史版本创建的数据表
+// db：数据库连接对象
+// table：表名
+// column：字段名
+// definition：字段定义，如 VARCHAR(255) NOT NULL
+func ensureColumn(db *sql.DB, table, column, definition string) error {
+	exists, err := columnExists(db, table, column)
+	if err != nil || exists {
+		return err
+	}
+
+	if _, err := db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition)); err != nil {
+		return fmt.Errorf("追加字段 %s.%s 失败：%w", table, column, err)
+	}
 	return nil
 }
 
-// SaveResult 保存监控结果到数据库
+// SaveResult 保存监控结果到数据库，并在同一事务中更新目标当前状态（target_current_status），状态变化时写入变化记录
 // result：监控结果结构体指针
 func (ms *MySQLStorage) SaveResult(result *core.MonitorResult) error {
 	sql := `
     INSERT INTO monitor_results (
         target_url, status, status_code, response_time,
-        ssl_cert_expiry, keyword_matched, error_msg, checked_at
-    ) VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+        ssl_cert_expiry, ssl_days_left, ssl_not_after, keyword_matched, error_msg, error_type, details, timings, response_snapshot, checked_at
+    ) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
     `
 
-	// 执行SQL时，打印参数（便于调试）
-	_, err := ms.db.Exec(
-		sql,
+	var details interface{}
+	if result.Details != nil {
+		data, err := json.Marshal(result.Details)
+		if err != nil {
+			return fmt.Errorf("序列化诊断信息失败：%w", err)
+		}
+		details = string(data)
+	}
+	var timings interface{}
+	if result.Timings != nil {
+		data, err := json.Marshal(result.Timings)
+		if err != nil {
+			return fmt.Errorf("序列化阶段耗时失败：%w", err)
+		}
+		timings = string(data)
+	}
+	var snapshot interface{}
+	if result.Snapshot != nil {
+		data, err := json.Marshal(result.Snapshot)
+		if err != nil {
+			return fmt.Errorf("序列化响应快照失败：%w", err)
+		}
+		snapshot = string(data)
+	}
+
+	args := []interface{}{
 		result.TargetURL,
 		result.Status,
 		result.StatusCode,
 		result.ResponseTime,
 		result.SSLCertExpiry,
+		result.SSLDaysLeft,
+		result.SSLNotAfter,
 		result.KeywordMatched,
 		result.ErrorMsg,
+		result.ErrorType,
+		details,
+		timings,
+		snapshot,
 		result.CheckedAt,
-	)
+	}
+	defer ms.queries.observe("SaveResult", sql, args, time.Now())
+
+	tx, err := ms.db.Begin()
+	if err != nil {
+		return fmt.Errorf("开启SaveResult事务失败：%w", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(sql, args...)
 	if err != nil {
 		return fmt.Errorf("执行SaveResult SQL失败：%w", err)
 	}
+	resultID, _ := res.LastInsertId()
+	result.ID = uint64(resultID)
+	if err := recordTransition(tx, resultID, result); err != nil {
+		return err
+	}
+	_, err = tx.Exec(upsertCurrentStatusSQL,
+		result.TargetURL, result.Status, result.StatusCode, result.ResponseTime, result.ErrorMsg, result.ErrorType,
+		result.Status, result.CheckedAt, result.CheckedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("更新目标当前状态失败：%w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("提交SaveResult事务失败：%w", err)
+	}
 	return nil
 }
 
 // SaveTarget 保存监控目标到数据库（存在则更新，不存在则插入）
+// 规范化地址相同的目标视为同一目标：沿用已保存的地址并回写到 target.URL，使检查结果继续写入原有历史
 // target：监控目标结构体指针
 func (ms *MySQLStorage) SaveTarget(target *core.MonitorTarget) error {
+	normalized := core.CanonicalTargetURL(target.URL)
+	existing, err := ms.targetURLByNormalized(normalized, target.URL)
+	if err != nil {
+		return err
+	}
+	if existing != "" {
+		target.URL = existing
+	}
+
 	sql := `
-	INSERT INTO monitor_targets (target_url, keyword, is_current)
-	VALUES (?, ?, ?)
-	ON DUPLICATE KEY UPDATE keyword=?, is_current=?
+	INSERT INTO monitor_targets (target_url, keyword, is_current, tags, assertions, options, source, normalized_url)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	ON DUPLICATE KEY UPDATE keyword=?, is_current=?, tags=?, assertions=?, options=?, source=?
 	`
 
-	_, err := ms.db.Exec(
-		sql,
+	tags := strings.Join(target.Tags, ",")
+	assertions := ""
+	if len(target.Assertions) > 0 {
+		data, err := json.Marshal(target.Assertions)
+		if err != nil {
+			return fmt.Errorf("序列化断言失败：%w", err)
+		}
+		assertions = string(data)
+	}
+	options, err := json.Marshal(target.TargetOptions)
+	if err != nil {
+		return fmt.Errorf("序列化检查选项失败：%w", err)
+	}
+
+	args := []interface{}{
 		target.URL,
 		target.Keyword,
 		target.IsCurrent,
+		tags,
+		assertions,
+		string(options),
+		target.Source,
+		normalized,
 		target.Keyword,
 		target.IsCurrent,
-	)
+		tags,
+		assertions,
+		string(options),
+		target.Source,
+	}
+	// 慢查询日志会通过接口输出，记录的检查选项使用脱敏后的副本
+	logged := append([]interface{}(nil), args...)
+	if masked, err := json.Marshal(target.TargetOptions.Masked()); err == nil {
+		logged[5], logged[12] = string(masked), string(masked)
+	}
+	defer ms.queries.observe("SaveTarget", sql, logged, time.Now())
 
+	_, err = ms.db.Exec(sql, args...)
 	return err
 }
 
-// QueryResults 按条件查询监控结果，支持时间范围和目标地址过滤
-// targetURL：目标地址模糊查询关键词（可选）
-// startTime：查询开始时间
-// endTime：查询结束时间
-// limit：返回结果最大条数
-// QueryResults 按条件查询监控结果
-func (ms *MySQLStorage) QueryResults(targetURL string, startTime, endTime time.Time, limit int) ([]*core.MonitorResult, error) {
-	sql := `
-    SELECT id, target_url, status, status_code, response_time,
-           ssl_cert_expiry, keyword_matched, error_msg, checked_at
-    FROM monitor_results
-    WHERE checked_at BETWEEN ? AND ?
-    `
-	args := []interface{}{startTime, endTime}
+// targetURLByNormalized 按规范化地址查找已保存目标的原始地址，优先返回与 targetURL 完全一致的目标，不存在时返回空字符串
+func (ms *MySQLStorage) targetURLByNormalized(normalized, targetURL string) (string, error) {
+	query := "SELECT target_url FROM monitor_targets WHERE normalized_url = ? ORDER BY target_url = ? DESC, id LIMIT 1"
+	args := []interface{}{normalized, targetURL}
+	defer ms.queries.observe("TargetURLByNormalized", query, args, time.Now())
+
+	var existing string
+	err := ms.db.QueryRow(query, args...).Scan(&existing)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("按规范化地址查询目标失败：%w", err)
+	}
+	return existing, nil
+}
+
+// ExistingTargetURL 返回与 targetURL 规范化地址相同的已保存目标地址，没有时原样返回 targetURL
+// 检查前调用，使结果、告警与自动修复从一开始就使用已保存的地址
+func (ms *MySQLStorage) ExistingTargetURL(targetURL string) (string, error) {
+	existing, err := ms.targetURLByNormalized(core.CanonicalTargetURL(targetURL), targetURL)
+	if err != nil || existing == "" {
+		return targetURL, err
+	}
+	return existing, nil
+}
+
+// ListTargets 查询监控目标列表
+// onlyCurrent：是否仅返回当前有效的监控目标
+func (ms *MySQLStorage) ListTargets(onlyCurrent bool) ([]*core.MonitorTarget, error) {
+	sql := "SELECT " + targetColumns + " FROM monitor_targets"
+	if onlyCurrent {
+		sql += " WHERE is_current = 1"
+	}
+	sql += " ORDER BY id"
+	defer ms.queries.observe("ListTargets", sql, nil, time.Now())
 
-	// 打印查询条件（便于调试）
-	fmt.Printf("查询条件：targetURL=%s, startTime=%s, endTime=%s\n", targetURL, startTime, endTime)
+	rows, err := ms.db.Query(sql)
+	if err != nil {
+		return nil, fmt.Errorf("执行ListTargets SQL失败：%w", err)
+	}
+	defer rows.Close()
+
+	return scanTargets(rows)
+}
+
+// GetTarget 按ID查询监控目标，不存在时返回 nil
+func (ms *MySQLStorage) GetTarget(id int64) (*core.MonitorTarget, error) {
+	sql := "SELECT " + targetColumns + " FROM monitor_targets WHERE id = ?"
+	defer ms.queries.observe("GetTarget", sql, []interface{}{id}, time.Now())
+
+	rows, err := ms.db.Query(sql, id)
+	if err != nil {
+		return nil, fmt.Errorf("执行GetTarget SQL失败：%w", err)
+	}
+	defer rows.Close()
 
-	if targetURL != "" {
-		sql += " AND target_url LIKE ?"
-		args = append(args, "%"+targetURL+"%")
+	targets, err := scanTargets(rows)
+	if err != nil || len(targets) == 0 {
+		return nil, err
 	}
+	return targets[0], nil
+}
 
-	sql += " ORDER BY status DESC, checked_at DESC LIMIT ?"
-	args = append(args, limit)
+// ListTargetsAfter 按ID游标分页查询监控目标（ID 升序），用于大规模目标的流式导出
+// afterID：游标，仅返回 ID 大于该值的目标，从头开始时传 0
+// limit：本页最大条数
+// onlyCurrent：是否仅返回当前有效的监控目标
+func (ms *MySQLStorage) ListTargetsAfter(afterID int64, limit int, onlyCurrent bool) ([]*core.MonitorTarget, error) {
+	sql := "SELECT " + targetColumns + " FROM monitor_targets WHERE id > ?"
+	if onlyCurrent {
+		sql += " AND is_current = 1"
+	}
+	sql += " ORDER BY id LIMIT ?"
+	args := []interface{}{afterID, limit}
+	defer ms.queries.observe("ListTargetsAfter", sql, args, time.Now())
 
 	rows, err := ms.db.Query(sql, args...)
 	if err != nil {
-		return nil, fmt.Errorf("执行QueryResults SQL失败：%w", err)
+		return nil, fmt.Errorf("执行ListTargetsAfter SQL失败：%w", err)
 	}
 	defer rows.Close()
 
-	var results []*core.MonitorResult
+	return scanTargets(rows)
+}
+
+// targetColumns 查询监控目标时的字段列表，与 scanTargets 的扫描顺序一致
+const targetColumns = "id, target_url, keyword, is_current, tags, assertions, COALESCE(options, ''), COALESCE(source, '')"
+
+// scanTargets 扫描监控目标查询结果
+func scanTargets(rows *sql.Rows) ([]*core.MonitorTarget, error) {
+	var targets []*core.MonitorTarget
 	for rows.Next() {
-		var r core.MonitorResult
-		err := rows.Scan(
-			&r.ID,
-			&r.TargetURL,
-			&r.Status,
-			&r.StatusCode,
-			&r.ResponseTime,
-			&r.SSLCertExpiry,
-			&r.KeywordMatched,
-			&r.ErrorMsg,
-			&r.CheckedAt,
-		)
+		var t core.MonitorTarget
+		var tags, assertions, options string
+		if err := rows.Scan(&t.ID, &t.URL, &t.Keyword, &t.IsCurrent, &tags, &assertions, &options, &t.Source); err != nil {
+			return nil, fmt.Errorf("扫描目标失败：%w", err)
+		}
+		if tags != "" {
+			t.Tags = strings.Split(tags, ",")
+		}
+		if assertions != "" {
+			if err := json.Unmarshal([]byte(assertions), &t.Assertions); err != nil {
+				return nil, fmt.Errorf("解析目标[%s]断言失败：%w", t.URL, err)
+			}
+		}
+		if options != "" {
+			if err := json.Unmarshal([]byte(options), &t.TargetOptions); err != nil {
+				return nil, fmt.Errorf("解析目标[%s]检查选项失败：%w", t.URL, err)
+			}
+		}
+		targets = append(targets, &t)
+	}
+
+	return targets, rows.Err()
+}
+
+// DeactivateTarget 将监控目标标记为非当前目标（停止检查，历史结果保留），返回目标是否存在
+// 按原始地址或规范化地址匹配，不同写法的地址均可注销同一目标
+func (ms *MySQLStorage) DeactivateTarget(targetURL string) (bool, error) {
+	sql := `UPDATE monitor_targets SET is_current = 0 WHERE target_url = ? OR normalized_url = ?`
+	args := []interface{}{targetURL, core.CanonicalTargetURL(targetURL)}
+	defer ms.queries.observe("DeactivateTarget", sql, args, time.Now())
+
+	res, err := ms.db.Exec(sql, args...)
+	if err != nil {
+		return false, fmt.Errorf("注销目标失败：%w", err)
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// DeleteTarget 删除监控目标（历史结果需另行通过 PurgeResults 删除），返回目标是否存在
+func (ms *MySQLStorage) DeleteTarget(targetURL string) (bool, error) {
+	sql := `DELETE FROM monitor_targets WHERE target_url = ?`
+	defer ms.queries.observe("DeleteTarget", sql, []interface{}{targetURL}, time.Now())
+
+	res, err := ms.db.Exec(sql, targetURL)
+	if err != nil {
+		return false, fmt.Errorf("删除目标失败：%w", err)
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// PurgeResults 删除目标地址以指定前缀开头的全部监控结果（如压测写入的数据）及其当前状态与状态变化记录，返回删除的结果条数
+func (ms *MySQLStorage) PurgeResults(urlPrefix string) (int64, error) {
+	if urlPrefix == "" {
+		return 0, fmt.Errorf("地址前缀不能为空")
+	}
+	sql := "DELETE FROM monitor_results WHERE target_url LIKE ?"
+	args := []interface{}{strings.NewReplacer("%", "\\%", "_", "\\_").Replace(urlPrefix) + "%"}
+	defer ms.queries.observe("PurgeResults", sql, args, time.Now())
+
+	res, err := ms.db.Exec(sql, args...)
+	if err != nil {
+		return 0, fmt.Errorf("删除监控结果失败：%w", err)
+	}
+	if _, err := ms.db.Exec("DELETE FROM target_current_status WHERE target_url LIKE ?", args...); err != nil {
+		return 0, fmt.Errorf("删除目标当前状态失败：%w", err)
+	}
+	if _, err := ms.db.Exec("DELETE FROM target_transitions WHERE target_url LIKE ?", args...); err != nil {
+		return 0, fmt.Errorf("删除状态变化记录失败：%w", err)
+	}
+	return res.RowsAffected()
+}
+
+// UpdateResultDetails 更新已保存结果的诊断信息（后台路由跟踪结束后补充 traceroute）
+func (ms *MySQLStorage) UpdateResultDetails(resultID uint64, details *core.ResultDetails) error {
+	data, err := json.Marshal(details)
+	if err != nil {
+		return fmt.Errorf("序列化诊断信息失败：%w", err)
+	}
+	sql := "UPDATE monitor_results SET details = ? WHERE id = ?"
+	args := []interface{}{string(data), resultID}
+	defer ms.queries.observe("UpdateResultDetails", sql, args, time.Now())
+	if _, err := ms.db.Exec(sql, args...); err != nil {
+		return fmt.Errorf("更新结果诊断信息失败：%w", err)
+	}
+	return nil
+}
+
+// snapshotExpireBatch 每批清除的响应快照条数，避免单条语句长时间锁表
+const snapshotExpireBatch = 1000
+
+// ExpireResponseSnapshots 清除检查时间早于 before 的响应快照（结果本身保留），返回清除的条数
+func (ms *MySQLStorage) ExpireResponseSnapshots(before time.Time) (int64, error) {
+	sql := "UPDATE monitor_results SET response_snapshot = NULL WHERE response_snapshot IS NOT NULL AND checked_at < ? LIMIT ?"
+	args := []interface{}{before, snapshotExpireBatch}
+	var total int64
+	for {
+		start := time.Now()
+		res, err := ms.db.Exec(sql, args...)
+		ms.queries.observe("ExpireResponseSnapshots", sql, args, start)
 		if err != nil {
-			return nil, fmt.Errorf("扫描结果失败：%w", err)
+			return total, fmt.Errorf("清除过期响应快照失败：%w", err)
+		}
+		n, _ := res.RowsAffected()
+		total += n
+		if n < snapshotExpireBatch {
+			return total, nil
 		}
-		results = append(results, &r)
 	}
+}
+
+// QueryResults 按条件查询监控结果，支持时间范围和目标地址过滤
+// targetURL：目标地址模糊查询关键词（可选）
+// startTime：查询开始时间
+// endTime：查询结束时间
+// limit：返回结果最大条数
+func (ms *MySQLStorage) QueryResults(targetURL string, startTime, endTime time.Time, limit int) ([]*core.MonitorResult, error) {
+	return ms.QueryResultFields(targetURL, startTime, endTime, limit, AllResultFields)
+}
 
-	return results, nil
+// QueryResultFields 按条件查询历史监控结果，仅查询指定字段（字段名见 ParseResultFields）
+// fields：需要查询的字段，未查询的字段保持零值
+func (ms *MySQLStorage) QueryResultFields(targetURL string, startTime, endTime time.Time, limit int, fields []string) ([]*core.MonitorResult, error) {
+	return ms.SearchResults(&ResultQuery{
+		Target: targetURL,
+		Since:  &startTime,
+		Until:  &endTime,
+		Fields: fields,
+		Limit:  limit,
+	})
 }
 
 // Close 关闭数据库连接，释放资源
 func (ms *MySQLStorage) Close() error {
 	return ms.db.Close()
 }
+
+// LatestResults 查询每个目标在指定时间之后的最近一次检查结果
+// since：仅统计该时间之后的结果
+func (ms *MySQLStorage) LatestResults(since time.Time) ([]*core.MonitorResult, error) {
+	return ms.LatestResultFields(since, AllResultFields)
+}
+
+// LatestResultFields 查询每个目标在指定时间之后的最近一次检查结果，仅查询指定字段
+// since：仅统计该时间之后的结果
+// fields：需要查询的字段，未查询的字段保持零值
+func (ms *MySQLStorage) LatestResultFields(since time.Time, fields []string) ([]*core.MonitorResult, error) {
+	sql := `
+    SELECT ` + selectResultColumns(fields, "r") + `
+    FROM monitor_results r
+    JOIN (
+        SELECT target_url, MAX(id) AS max_id
+        FROM monitor_results
+        WHERE checked_at >= ?
+        GROUP BY target_url
+    ) latest ON r.id = latest.max_id
+    `
+
+	defer ms.queries.observe("LatestResults", sql, []interface{}{since}, time.Now())
+
+	rows, err := ms.db.Query(sql, since)
+	if err != nil {
+		return nil, fmt.Errorf("执行LatestResults SQL失败：%w", err)
+	}
+	defer rows.Close()
+
+	return scanResults(rows, fields)
+}
@@ -0,0 +1,283 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"servicetelemetry/core"
+)
+
+// resultColumns 监控结果 JSON 字段名与数据表字段的对应关系（仅包含入库字段）
+var resultColumns = map[string]string{
+	"id":             "id",
+	"targetUrl":      "target_url",
+	"status":         "status",
+	"statusCode":     "status_code",
+	"responseTime":   "response_time",
+	"sslCertExpiry":  "ssl_cert_expiry",
+	"sslDaysLeft":    "ssl_days_left",
+	"sslNotAfter":    "ssl_not_after",
+	"keywordMatched": "keyword_matched",
+	"errorMsg":       "error_msg",
+	"errorType":      "error_type",
+	"details":        "details",
+	"timings":        "timings",
+	"snapshot":       "response_snapshot",
+	"checkedAt":      "checked_at",
+}
+
+// AllResultFields 默认返回的全部结果字段，顺序与原查询保持一致
+var AllResultFields = []string{
+	"id", "targetUrl", "status", "statusCode", "responseTime",
+	"sslCertExpiry", "sslDaysLeft", "sslNotAfter", "keywordMatched", "errorMsg", "errorType", "details", "timings", "snapshot", "checkedAt",
+}
+
+// ParseResultFields 解析逗号分隔的字段列表（如 status,responseTime），为空时返回全部字段
+// 字段名使用接口返回的 JSON 名称，重复字段自动去重，未知字段返回错误
+func ParseResultFields(s string) ([]string, error) {
+	if strings.TrimSpace(s) == "" {
+		return AllResultFields, nil
+	}
+
+	seen := make(map[string]bool)
+	var fields []string
+	for _, f := range strings.Split(s, ",") {
+		f = strings.TrimSpace(f)
+		if f == "" || seen[f] {
+			continue
+		}
+		if _, ok := resultColumns[f]; !ok {
+			return nil, fmt.Errorf("不支持的字段：%s，可选字段：%s", f, strings.Join(AllResultFields, ","))
+		}
+		seen[f] = true
+		fields = append(fields, f)
+	}
+	if len(fields) == 0 {
+		return AllResultFields, nil
+	}
+	return fields, nil
+}
+
+// WithResultFields 在字段列表中补充必需字段（不重复），用于聚合统计等依赖特定字段的场景
+func WithResultFields(fields []string, required ...string) []string {
+	merged := append([]string(nil), fields...)
+	for _, r := range required {
+		found := false
+		for _, f := range fields {
+			if f == r {
+				found = true
+				break
+			}
+		}
+		if !found {
+			merged = append(merged, r)
+		}
+	}
+	return merged
+}
+
+// ProjectResults 将监控结果裁剪为仅包含指定字段的对象列表，用于接口返回
+func ProjectResults(results []*core.MonitorResult, fields []string) []map[string]interface{} {
+	list := make([]map[string]interface{}, 0, len(results))
+	for _, r := range results {
+		item := make(map[string]interface{}, len(fields))
+		for _, f := range fields {
+			item[f] = resultFieldValue(r, f)
+		}
+		list = append(list, item)
+	}
+	return list
+}
+
+// selectResultColumns 生成 SELECT 字段列表，alias 为表别名（可为空）
+func selectResultColumns(fields []string, alias string) string {
+	prefix := ""
+	if alias != "" {
+		prefix = alias + "."
+	}
+	cols := make([]string, 0, len(fields))
+	for _, f := range fields {
+		cols = append(cols, prefix+resultColumns[f])
+	}
+	return strings.Join(cols, ", ")
+}
+
+// scanResults 按字段列表扫描查询结果，未查询的字段保持零值
+func scanResults(rows *sql.Rows, fields []string) ([]*core.MonitorResult, error) {
+	var results []*core.MonitorResult
+	for rows.Next() {
+		var r core.MonitorResult
+		dest := make([]interface{}, 0, len(fields))
+		for _, f := range fields {
+			dest = append(dest, resultFieldPointer(&r, f))
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("扫描结果失败：%w", err)
+		}
+		results = append(results, &r)
+	}
+	return results, rows.Err()
+}
+
+// resultFieldPointer 返回字段对应的结构体成员指针，供 rows.Scan 使用
+func resultFieldPointer(r *core.MonitorResult, field string) interface{} {
+	switch field {
+	case "id":
+		return &r.ID
+	case "targetUrl":
+		return &r.TargetURL
+	case "status":
+		return &r.Status
+	case "statusCode":
+		return &r.StatusCode
+	case "responseTime":
+		return &r.ResponseTime
+	case "sslCertExpiry":
+		return &r.SSLCertExpiry
+	case "sslDaysLeft":
+		return &r.SSLDaysLeft
+	case "sslNotAfter":
+		return &r.SSLNotAfter
+	case "keywordMatched":
+		return &r.KeywordMatched
+	case "errorMsg":
+		return &r.ErrorMsg
+	case "errorType":
+		return &r.ErrorType
+	case "details":
+		return &detailsScanner{result: r}
+	case "timings":
+		return &timingsScanner{result: r}
+	case "snapshot":
+		return &snapshotScanner{result: r}
+	case "checkedAt":
+		return &r.CheckedAt
+	}
+	return nil
+}
+
+// detailsScanner 将 JSON 格式的诊断信息字段解析到监控结果中
+type detailsScanner struct {
+	result *core.MonitorResult
+}
+
+// Scan 实现 sql.Scanner 接口，空值时保持 nil
+func (s *detailsScanner) Scan(src interface{}) error {
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("诊断信息字段类型不支持：%T", src)
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	var details core.ResultDetails
+	if err := json.Unmarshal(data, &details); err != nil {
+		return fmt.Errorf("解析诊断信息失败：%w", err)
+	}
+	s.result.Details = &details
+	return nil
+}
+
+// timingsScanner 将 JSON 格式的阶段耗时字段解析到监控结果中
+type timingsScanner struct {
+	result *core.MonitorResult
+}
+
+// Scan 实现 sql.Scanner 接口，空值时保持 nil
+func (s *timingsScanner) Scan(src interface{}) error {
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("阶段耗时字段类型不支持：%T", src)
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	var timings core.PhaseTimings
+	if err := json.Unmarshal(data, &timings); err != nil {
+		return fmt.Errorf("解析阶段耗时失败：%w", err)
+	}
+	s.result.Timings = &timings
+	return nil
+}
+
+// snapshotScanner 将 JSON 格式的响应快照字段解析到监控结果中
+type snapshotScanner struct {
+	result *core.MonitorResult
+}
+
+// Scan 实现 sql.Scanner 接口，空值（未保存或已超过保留时长被清除）时保持 nil
+func (s *snapshotScanner) Scan(src interface{}) error {
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("响应快照字段类型不支持：%T", src)
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	var snapshot core.ResponseSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return fmt.Errorf("解析响应快照失败：%w", err)
+	}
+	s.result.Snapshot = &snapshot
+	return nil
+}
+
+// resultFieldValue 返回字段对应的值
+func resultFieldValue(r *core.MonitorResult, field string) interface{} {
+	switch field {
+	case "id":
+		return r.ID
+	case "targetUrl":
+		return r.TargetURL
+	case "status":
+		return r.Status
+	case "statusCode":
+		return r.StatusCode
+	case "responseTime":
+		return r.ResponseTime
+	case "sslCertExpiry":
+		return r.SSLCertExpiry
+	case "sslDaysLeft":
+		return r.SSLDaysLeft
+	case "sslNotAfter":
+		return r.SSLNotAfter
+	case "keywordMatched":
+		return r.KeywordMatched
+	case "errorMsg":
+		return r.ErrorMsg
+	case "errorType":
+		return r.ErrorType
+	case "details":
+		return r.Details
+	case "timings":
+		return r.Timings
+	case "snapshot":
+		return r.Snapshot
+	case "checkedAt":
+		return r.CheckedAt
+	}
+	return nil
+}
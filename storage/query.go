@@ -0,0 +1,310 @@
+package storage
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"servicetelemetry/core"
+)
+
+// 查询条数限制
+const (
+	DefaultQueryLimit = 100  // 未指定 limit 时的返回条数
+	MaxQueryLimit     = 1000 // limit 上限
+)
+
+// 聚合维度
+const (
+	AggregateTarget    = "target"    // 按目标地址
+	AggregateStatus    = "status"    // 按检查状态
+	AggregateErrorType = "errorType" // 按错误类型
+	AggregateHour      = "hour"      // 按小时
+	AggregateDay       = "day"       // 按天
+)
+
+// aggregateKeys 聚合维度对应的分组表达式
+var aggregateKeys = map[string]string{
+	AggregateTarget:    "target_url",
+	AggregateStatus:    "status",
+	AggregateErrorType: "error_type",
+	AggregateHour:      "DATE_FORMAT(checked_at, '%Y-%m-%d %H:00')",
+	AggregateDay:       "DATE_FORMAT(checked_at, '%Y-%m-%d')",
+}
+
+// ResultQuery 监控结果查询条件（查询 DSL），由 POST /api/v1/query、历史数据查询与小助手检索共用
+// 各条件之间为「且」的关系，同一条件的多个取值之间为「或」的关系
+type ResultQuery struct {
+	Target       string     `json:"target"`       // 目标地址关键词（模糊匹配）
+	Schemes      []string   `json:"schemes"`      // 目标协议，如 https、tcp
+	Status       string     `json:"status"`       // 检查状态：success / failed / degraded
+	ErrorTypes   []string   `json:"errorTypes"`   // 错误类型，如 timeout、ssl
+	Tags         []string   `json:"tags"`         // 目标标签（按目标当前的标签匹配）
+	HasCert      bool       `json:"hasCert"`      // 仅返回带证书信息的结果
+	MaxCertDays  *int       `json:"maxCertDays"`  // 证书剩余天数上限（含），如 7 表示 7 天内过期或已过期，隐含 hasCert
+	Since        *time.Time `json:"since"`        // 开始时间（RFC 3339），与 hours 二选一
+	Until        *time.Time `json:"until"`        // 结束时间（RFC 3339），默认当前时间
+	Hours        int        `json:"hours"`        // 最近 N 小时，since 与 hours 均未指定时为 24
+	MinLatencyMs float64    `json:"minLatencyMs"` // 响应耗时下限（毫秒）
+	MaxLatencyMs float64    `json:"maxLatencyMs"` // 响应耗时上限（毫秒）
+	Fields       []string   `json:"fields"`       // 返回字段（字段名见 ParseResultFields），为空返回全部字段
+	Aggregate    string     `json:"aggregate"`    // 聚合维度：target / status / errorType / hour / day，为空返回明细
+	Limit        int        `json:"limit"`        // 返回条数（明细条数或聚合分组数），默认 100，最大 1000
+	Sort         string     `json:"sort"`         // 明细排序：为空时失败结果在前、按检查时间倒序；sslDaysLeft 按证书剩余天数升序（无证书信息的排在最后）
+}
+
+// 明细排序方式
+const (
+	SortDefault     = ""
+	SortSSLDaysLeft = "sslDaysLeft"
+)
+
+// ResultBucket 聚合查询的一个分组
+type ResultBucket struct {
+	Key       string  `json:"key"`       // 分组值
+	Total     int     `json:"total"`     // 检查次数
+	Failed    int     `json:"failed"`    // 失败次数
+	Degraded  int     `json:"degraded"`  // 降级次数（响应耗时超过阈值，计入成功率）
+	Uptime    float64 `json:"uptime"`    // 成功率（百分比）
+	AvgMs     float64 `json:"avgMs"`     // 平均响应耗时（毫秒）
+	MaxMs     float64 `json:"maxMs"`     // 最大响应耗时（毫秒）
+	FirstSeen string  `json:"firstSeen"` // 分组内最早的检查时间
+	LastSeen  string  `json:"lastSeen"`  // 分组内最近的检查时间
+}
+
+// Normalize 校验查询条件并补全默认值（时间范围、返回条数、返回字段）
+func (q *ResultQuery) Normalize() error {
+	switch q.Status {
+	case "", core.StatusSuccess, core.StatusFailed, core.StatusDegraded:
+	default:
+		return fmt.Errorf("无效的 status：%s，仅支持 success / failed / degraded", q.Status)
+	}
+	if q.Since != nil && q.Hours > 0 {
+		return fmt.Errorf("since 与 hours 只能指定其中一个")
+	}
+	if q.Hours < 0 {
+		return fmt.Errorf("hours 不能为负数")
+	}
+	if q.MinLatencyMs < 0 || q.MaxLatencyMs < 0 || (q.MaxLatencyMs > 0 && q.MinLatencyMs > q.MaxLatencyMs) {
+		return fmt.Errorf("无效的响应耗时范围：%v ~ %v", q.MinLatencyMs, q.MaxLatencyMs)
+	}
+	if q.Aggregate != "" {
+		if _, ok := aggregateKeys[q.Aggregate]; !ok {
+			return fmt.Errorf("不支持的聚合维度：%s，可选：target / status / errorType / hour / day", q.Aggregate)
+		}
+	}
+	for i, s := range q.Schemes {
+		q.Schemes[i] = strings.ToLower(strings.TrimSuffix(s, "://"))
+	}
+	switch q.Sort {
+	case SortDefault, SortSSLDaysLeft:
+	default:
+		return fmt.Errorf("无效的 sort：%s，可选 sslDaysLeft", q.Sort)
+	}
+
+	switch {
+	case q.Limit < 0:
+		return fmt.Errorf("limit 不能为负数")
+	case q.Limit == 0:
+		q.Limit = DefaultQueryLimit
+	case q.Limit > MaxQueryLimit:
+		q.Limit = MaxQueryLimit
+	}
+
+	if q.Until == nil {
+		now := time.Now()
+		q.Until = &now
+	}
+	if q.Since == nil {
+		hours := q.Hours
+		if hours == 0 {
+			hours = 24
+		}
+		since := q.Until.Add(-time.Duration(hours) * time.Hour)
+		q.Since = &since
+	}
+	if q.Since.After(*q.Until) {
+		return fmt.Errorf("开始时间不能晚于结束时间")
+	}
+
+	fields, err := ParseResultFields(strings.Join(q.Fields, ","))
+	if err != nil {
+		return err
+	}
+	q.Fields = fields
+	return nil
+}
+
+// where 生成查询条件与参数（调用前需先 Normalize）
+func (q *ResultQuery) where() (string, []interface{}) {
+	conds := []string{"checked_at BETWEEN ? AND ?"}
+	args := []interface{}{*q.Since, *q.Until}
+
+	if q.Target != "" {
+		conds = append(conds, "target_url LIKE ?")
+		args = append(args, "%"+q.Target+"%")
+	}
+	if len(q.Schemes) > 0 {
+		var or []string
+		for _, s := range q.Schemes {
+			or = append(or, "target_url LIKE ?")
+			args = append(args, s+"://%")
+		}
+		conds = append(conds, "("+strings.Join(or, " OR ")+")")
+	}
+	if q.Status != "" {
+		conds = append(conds, "status = ?")
+		args = append(args, q.Status)
+	}
+	if len(q.ErrorTypes) > 0 {
+		conds = append(conds, "error_type IN (?"+strings.Repeat(", ?", len(q.ErrorTypes)-1)+")")
+		for _, t := range q.ErrorTypes {
+			args = append(args, t)
+		}
+	}
+	if len(q.Tags) > 0 {
+		var or []string
+		for _, t := range q.Tags {
+			or = append(or, "FIND_IN_SET(?, tags)")
+			args = append(args, t)
+		}
+		conds = append(conds, "target_url IN (SELECT target_url FROM monitor_targets WHERE "+strings.Join(or, " OR ")+")")
+	}
+	if q.HasCert {
+		conds = append(conds, "(ssl_days_left IS NOT NULL OR ssl_cert_expiry <> '')")
+	}
+	if q.MaxCertDays != nil {
+		conds = append(conds, "ssl_days_left <= ?")
+		args = append(args, *q.MaxCertDays)
+	}
+	if q.MinLatencyMs > 0 {
+		conds = append(conds, "response_time >= ?")
+		args = append(args, q.MinLatencyMs)
+	}
+	if q.MaxLatencyMs > 0 {
+		conds = append(conds, "response_time <= ?")
+		args = append(args, q.MaxLatencyMs)
+	}
+	return " WHERE " + strings.Join(conds, " AND "), args
+}
+
+// Match 判断内存中的结果是否满足查询条件（调用前需先 Normalize），条件与 where 生成的 SQL 一致，
+// 地址与协议按不区分大小写匹配；标签按结果携带的目标标签匹配，未携带标签的结果不满足标签条件
+func (q *ResultQuery) Match(r *core.MonitorResult) bool {
+	if r.CheckedAt.Before(*q.Since) || r.CheckedAt.After(*q.Until) {
+		return false
+	}
+	url := strings.ToLower(r.TargetURL)
+	if q.Target != "" && !strings.Contains(url, strings.ToLower(q.Target)) {
+		return false
+	}
+	if len(q.Schemes) > 0 {
+		matched := false
+		for _, s := range q.Schemes {
+			if strings.HasPrefix(url, s+"://") {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if q.Status != "" && r.Status != q.Status {
+		return false
+	}
+	if len(q.ErrorTypes) > 0 && !containsString(q.ErrorTypes, r.ErrorType) {
+		return false
+	}
+	if len(q.Tags) > 0 {
+		matched := false
+		for _, t := range q.Tags {
+			if containsString(r.Tags, t) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if q.HasCert && r.SSLDaysLeft == nil && r.SSLCertExpiry == "" {
+		return false
+	}
+	if q.MaxCertDays != nil && (r.SSLDaysLeft == nil || *r.SSLDaysLeft > *q.MaxCertDays) {
+		return false
+	}
+	if q.MinLatencyMs > 0 && r.ResponseTime < q.MinLatencyMs {
+		return false
+	}
+	if q.MaxLatencyMs > 0 && r.ResponseTime > q.MaxLatencyMs {
+		return false
+	}
+	return true
+}
+
+// SearchResults 按查询条件返回结果明细（调用前需先 Normalize），仅查询 q.Fields 中的字段
+func (ms *MySQLStorage) SearchResults(q *ResultQuery) ([]*core.MonitorResult, error) {
+	where, args := q.where()
+	order := " ORDER BY status DESC, checked_at DESC"
+	if q.Sort == SortSSLDaysLeft {
+		order = " ORDER BY ssl_days_left IS NULL, ssl_days_left, checked_at DESC"
+	}
+	sql := "SELECT " + selectResultColumns(q.Fields, "") + " FROM monitor_results" + where + order + " LIMIT ?"
+	args = append(args, q.Limit)
+	defer ms.queries.observe("SearchResults", sql, args, time.Now())
+
+	rows, err := ms.db.Query(sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("执行SearchResults SQL失败：%w", err)
+	}
+	defer rows.Close()
+
+	return scanResults(rows, q.Fields)
+}
+
+// AggregateResults 按查询条件分组统计（调用前需先 Normalize），q.Aggregate 为分组维度
+func (ms *MySQLStorage) AggregateResults(q *ResultQuery) ([]*ResultBucket, error) {
+	key := aggregateKeys[q.Aggregate]
+	if key == "" {
+		return nil, fmt.Errorf("不支持的聚合维度：%s", q.Aggregate)
+	}
+	where, args := q.where()
+	sql := "SELECT " + key + ", COUNT(*), SUM(status = 'failed'), SUM(status = 'degraded'), AVG(response_time), MAX(response_time), MIN(checked_at), MAX(checked_at)" +
+		" FROM monitor_results" + where + " GROUP BY " + key + " ORDER BY " + key + " LIMIT ?"
+	args = append(args, q.Limit)
+	defer ms.queries.observe("AggregateResults", sql, args, time.Now())
+
+	rows, err := ms.db.Query(sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("执行AggregateResults SQL失败：%w", err)
+	}
+	defer rows.Close()
+
+	buckets := []*ResultBucket{}
+	for rows.Next() {
+		var b ResultBucket
+		var first, last time.Time
+		if err := rows.Scan(&b.Key, &b.Total, &b.Failed, &b.Degraded, &b.AvgMs, &b.MaxMs, &first, &last); err != nil {
+			return nil, fmt.Errorf("扫描聚合结果失败：%w", err)
+		}
+		if b.Total > 0 {
+			b.Uptime = math.Round(float64(b.Total-b.Failed)/float64(b.Total)*10000) / 100
+		}
+		b.FirstSeen = first.Format("2006-01-02 15:04:05")
+		b.LastSeen = last.Format("2006-01-02 15:04:05")
+		buckets = append(buckets, &b)
+	}
+	return buckets, rows.Err()
+}
+
+// containsString 判断切片中是否包含指定字符串
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+)
+
+// 处置执行结果
+const (
+	RemediationSucceeded = "succeeded" // 执行成功
+	RemediationFailed    = "failed"    // 执行失败（请求失败、非 2xx 响应、脚本非 0 退出等）
+	RemediationSkipped   = "skipped"   // 未执行（冷却期内、目标处于静默、动作未定义）
+)
+
+// RemediationRun 处置动作执行记录（审计日志），每次触发都会记录，包括被跳过的触发
+type RemediationRun struct {
+	ID         int64     `json:"id"`         // 记录唯一标识
+	Action     string    `json:"action"`     // 动作名称
+	ActionType string    `json:"actionType"` // 动作类型：webhook / jenkins / script
+	TargetURL  string    `json:"targetUrl"`  // 触发处置的目标
+	ErrorType  string    `json:"errorType"`  // 触发处置的检查结果的错误类型
+	ErrorMsg   string    `json:"errorMsg"`   // 触发处置的检查结果的错误信息
+	Outcome    string    `json:"outcome"`    // 执行结果：succeeded / failed / skipped
+	Detail     string    `json:"detail"`     // 执行详情：响应状态、脚本输出（截断）、跳过原因或错误信息
+	StartedAt  time.Time `json:"startedAt"`  // 开始执行时间
+	DurationMs int64     `json:"durationMs"` // 执行耗时（毫秒）
+}
+
+// remediationTableSQL 处置执行记录表
+const remediationTableSQL = `
+	CREATE TABLE IF NOT EXISTS remediation_runs (
+		id BIGINT AUTO_INCREMENT PRIMARY KEY,
+		action VARCHAR(64) NOT NULL,
+		action_type VARCHAR(20) NOT NULL,
+		target_url VARCHAR(255) NOT NULL,
+		error_type VARCHAR(20) DEFAULT '',
+		error_msg VARCHAR(512) DEFAULT '',
+		outcome VARCHAR(20) NOT NULL,
+		detail VARCHAR(2048) DEFAULT '',
+		started_at DATETIME NOT NULL,
+		duration_ms BIGINT DEFAULT 0,
+		INDEX idx_target_started (target_url, started_at),
+		INDEX idx_started (started_at)
+	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+	`
+
+// SaveRemediationRun 保存一条处置执行记录，保存后回填 ID
+func (ms *MySQLStorage) SaveRemediationRun(run *RemediationRun) error {
+	query := `INSERT INTO remediation_runs (action, action_type, target_url, error_type, error_msg, outcome, detail, started_at, duration_ms)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	args := []interface{}{run.Action, run.ActionType, run.TargetURL, run.ErrorType, run.ErrorMsg,
+		run.Outcome, run.Detail, run.StartedAt, run.DurationMs}
+	defer ms.queries.observe("SaveRemediationRun", query, args, time.Now())
+
+	res, err := ms.db.Exec(query, args...)
+	if err != nil {
+		return fmt.Errorf("保存处置执行记录失败：%w", err)
+	}
+	run.ID, _ = res.LastInsertId()
+	return nil
+}
+
+// ListRemediationRuns 查询处置执行记录，按时间倒序
+// targetURL：目标地址，为空表示全部目标
+// limit：最多返回的条数
+func (ms *MySQLStorage) ListRemediationRuns(targetURL string, limit int) ([]*RemediationRun, error) {
+	query := `SELECT id, action, action_type, target_url, error_type, error_msg, outcome, detail, started_at, duration_ms
+	FROM remediation_runs`
+	var args []interface{}
+	if targetURL != "" {
+		query += " WHERE target_url = ?"
+		args = append(args, targetURL)
+	}
+	query += " ORDER BY id DESC LIMIT ?"
+	args = append(args, limit)
+	defer ms.queries.observe("ListRemediationRuns", query, args, time.Now())
+
+	rows, err := ms.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("执行ListRemediationRuns SQL失败：%w", err)
+	}
+	defer rows.Close()
+
+	list := []*RemediationRun{}
+	for rows.Next() {
+		var r RemediationRun
+		if err := rows.Scan(&r.ID, &r.Action, &r.ActionType, &r.TargetURL, &r.ErrorType, &r.ErrorMsg,
+			&r.Outcome, &r.Detail, &r.StartedAt, &r.DurationMs); err != nil {
+			return nil, fmt.Errorf("扫描处置执行记录失败：%w", err)
+		}
+		list = append(list, &r)
+	}
+	return list, rows.Err()
+}
@@ -0,0 +1,178 @@
+package storage
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxSlowQueries 内存中保留的最近慢查询条数
+const maxSlowQueries = 50
+
+// maxArgLength 慢查询日志中单个参数的最大展示长度
+const maxArgLength = 200
+
+// sensitiveJSONField 匹配 JSON 参数中的敏感字段值（如检查选项中的密码、令牌），记录日志前脱敏
+var sensitiveJSONField = regexp.MustCompile(`(?i)("[^"]*(password|passwd|secret|token|apikey|api_key|authorization|credentials)[^"]*"\s*:\s*)"(?:[^"\\]|\\.)*"`)
+
+// OperationStats 单个存储操作的耗时统计
+type OperationStats struct {
+	Operation string     `json:"operation"`          // 操作名（存储方法名）
+	Count     int64      `json:"count"`              // 执行次数
+	SlowCount int64      `json:"slowCount"`          // 慢查询次数
+	TotalMs   float64    `json:"totalMs"`            // 累计耗时（毫秒）
+	AvgMs     float64    `json:"avgMs"`              // 平均耗时（毫秒）
+	MaxMs     float64    `json:"maxMs"`              // 最大耗时（毫秒）
+	LastSlow  *time.Time `json:"lastSlow,omitempty"` // 最近一次慢查询时间
+}
+
+// SlowQuery 一条慢查询记录
+type SlowQuery struct {
+	Operation  string    `json:"operation"`  // 操作名
+	SQL        string    `json:"sql"`        // SQL（已压缩空白）
+	Args       []string  `json:"args"`       // 参数（敏感字段已脱敏，过长的已截断）
+	DurationMs float64   `json:"durationMs"` // 耗时（毫秒）
+	At         time.Time `json:"at"`         // 执行时间
+}
+
+// QueryStats 存储性能诊断信息
+type QueryStats struct {
+	ThresholdMs float64           `json:"thresholdMs"` // 慢查询阈值（毫秒），0 表示不记录慢查询
+	Since       time.Time         `json:"since"`       // 统计开始时间
+	Operations  []*OperationStats `json:"operations"`  // 各操作统计，按累计耗时倒序
+	Recent      []*SlowQuery      `json:"recent"`      // 最近的慢查询，最新的排在前面
+}
+
+// queryRecorder 记录存储操作耗时，超过阈值的操作输出慢查询日志并保留最近记录
+type queryRecorder struct {
+	threshold time.Duration
+
+	mu     sync.Mutex
+	since  time.Time
+	ops    map[string]*OperationStats
+	recent []*SlowQuery
+}
+
+// newQueryRecorder 创建存储操作耗时记录器
+// threshold：慢查询阈值，小于等于 0 时只统计不记录慢查询
+func newQueryRecorder(threshold time.Duration) *queryRecorder {
+	return &queryRecorder{
+		threshold: threshold,
+		since:     time.Now(),
+		ops:       make(map[string]*OperationStats),
+	}
+}
+
+// observe 记录一次存储操作，在构建好 SQL 与参数后以 defer 方式调用，耗时包含结果扫描
+// op：操作名
+// query：执行的 SQL
+// args：SQL 参数
+// start：开始时间
+func (r *queryRecorder) observe(op, query string, args []interface{}, start time.Time) {
+	elapsed := time.Since(start)
+	ms := float64(elapsed.Microseconds()) / 1000
+	slow := r.threshold > 0 && elapsed >= r.threshold
+
+	r.mu.Lock()
+	stats, ok := r.ops[op]
+	if !ok {
+		stats = &OperationStats{Operation: op}
+		r.ops[op] = stats
+	}
+	stats.Count++
+	stats.TotalMs += ms
+	if ms > stats.MaxMs {
+		stats.MaxMs = ms
+	}
+	var record *SlowQuery
+	if slow {
+		now := time.Now()
+		stats.SlowCount++
+		stats.LastSlow = &now
+		record = &SlowQuery{
+			Operation:  op,
+			SQL:        compactSQL(query),
+			Args:       maskArgs(args),
+			DurationMs: ms,
+			At:         start,
+		}
+		r.recent = append([]*SlowQuery{record}, r.recent...)
+		if len(r.recent) > maxSlowQueries {
+			r.recent = r.recent[:maxSlowQueries]
+		}
+	}
+	r.mu.Unlock()
+
+	if record != nil {
+		log.Warnf("慢查询[%s]耗时 %.1fms（阈值 %s）：%s 参数：[%s]", op, ms, r.threshold, record.SQL, strings.Join(record.Args, ", "))
+	}
+}
+
+// snapshot 返回当前统计，reset 为 true 时同时清空统计
+func (r *queryRecorder) snapshot(reset bool) *QueryStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stats := &QueryStats{
+		ThresholdMs: float64(r.threshold.Microseconds()) / 1000,
+		Since:       r.since,
+		Operations:  make([]*OperationStats, 0, len(r.ops)),
+		Recent:      append([]*SlowQuery{}, r.recent...),
+	}
+	for _, op := range r.ops {
+		s := *op
+		if s.Count > 0 {
+			s.AvgMs = s.TotalMs / float64(s.Count)
+		}
+		stats.Operations = append(stats.Operations, &s)
+	}
+	sort.Slice(stats.Operations, func(i, j int) bool {
+		return stats.Operations[i].TotalMs > stats.Operations[j].TotalMs
+	})
+
+	if reset {
+		r.since = time.Now()
+		r.ops = make(map[string]*OperationStats)
+		r.recent = nil
+	}
+	return stats
+}
+
+// compactSQL 压缩 SQL 中的换行与连续空白，便于单行输出
+func compactSQL(query string) string {
+	return strings.Join(strings.Fields(query), " ")
+}
+
+// maskArgs 格式化 SQL 参数：JSON 中的敏感字段脱敏，过长的参数截断
+func maskArgs(args []interface{}) []string {
+	out := make([]string, len(args))
+	for i, a := range args {
+		var s string
+		switch v := a.(type) {
+		case nil:
+			s = "NULL"
+		case string:
+			s = sensitiveJSONField.ReplaceAllString(v, `$1"******"`)
+		case []byte:
+			s = sensitiveJSONField.ReplaceAllString(string(v), `$1"******"`)
+		case time.Time:
+			s = v.Format("2006-01-02 15:04:05")
+		default:
+			s = fmt.Sprint(v)
+		}
+		if r := []rune(s); len(r) > maxArgLength {
+			s = fmt.Sprintf("%s...（共%d字符）", string(r[:maxArgLength]), len(r))
+		}
+		out[i] = s
+	}
+	return out
+}
+
+// QueryStats 返回存储操作耗时统计与最近的慢查询
+// reset：是否在返回后清空统计
+func (ms *MySQLStorage) QueryStats(reset bool) *QueryStats {
+	return ms.queries.snapshot(reset)
+}
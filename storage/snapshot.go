@@ -0,0 +1,109 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ConfigSnapshot 配置快照，记录某一时刻生效的配置文件或声明式目标定义的完整内容
+type ConfigSnapshot struct {
+	ID        int64     `json:"id"`                // 版本号（自增）
+	Kind      string    `json:"kind"`              // 快照类型：config（全局配置）/ targets（声明式目标定义）
+	Hash      string    `json:"hash"`              // 内容的 SHA-256，用于判断是否变化
+	Source    string    `json:"source"`            // 产生原因：initial / change / rollback
+	Content   string    `json:"content,omitempty"` // 完整内容（列表查询时不返回）
+	CreatedAt time.Time `json:"createdAt"`         // 保存时间
+}
+
+// SaveSnapshot 保存配置快照，并只保留该类型最新的 keep 个版本（keep <= 0 表示不清理）
+// snapshot：配置快照，保存后回填 ID
+func (ms *MySQLStorage) SaveSnapshot(snapshot *ConfigSnapshot, keep int) error {
+	query := `INSERT INTO config_snapshots (kind, hash, source, content, created_at) VALUES (?, ?, ?, ?, ?)`
+	args := []interface{}{snapshot.Kind, snapshot.Hash, snapshot.Source, snapshot.Content, snapshot.CreatedAt}
+	defer ms.queries.observe("SaveSnapshot", query, args, time.Now())
+
+	res, err := ms.db.Exec(query, args...)
+	if err != nil {
+		return fmt.Errorf("保存配置快照失败：%w", err)
+	}
+	snapshot.ID, _ = res.LastInsertId()
+
+	if keep > 0 {
+		// MySQL 不支持在子查询中直接 LIMIT 同表，借助派生表计算保留下限
+		_, err = ms.db.Exec(`
+		DELETE FROM config_snapshots WHERE kind = ? AND id < (
+			SELECT min_id FROM (
+				SELECT MIN(id) AS min_id FROM (
+					SELECT id FROM config_snapshots WHERE kind = ? ORDER BY id DESC LIMIT ?
+				) AS latest
+			) AS bound
+		)`, snapshot.Kind, snapshot.Kind, keep)
+		if err != nil {
+			return fmt.Errorf("清理旧配置快照失败：%w", err)
+		}
+	}
+	return nil
+}
+
+// ListSnapshots 查询配置快照列表（不含内容），按版本号倒序
+// kind：快照类型，为空时返回全部类型
+func (ms *MySQLStorage) ListSnapshots(kind string) ([]*ConfigSnapshot, error) {
+	query := "SELECT id, kind, hash, source, created_at FROM config_snapshots"
+	var args []interface{}
+	if kind != "" {
+		query += " WHERE kind = ?"
+		args = append(args, kind)
+	}
+	query += " ORDER BY id DESC"
+	defer ms.queries.observe("ListSnapshots", query, args, time.Now())
+
+	rows, err := ms.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("查询配置快照失败：%w", err)
+	}
+	defer rows.Close()
+
+	var list []*ConfigSnapshot
+	for rows.Next() {
+		var s ConfigSnapshot
+		if err := rows.Scan(&s.ID, &s.Kind, &s.Hash, &s.Source, &s.CreatedAt); err != nil {
+			return nil, fmt.Errorf("扫描配置快照失败：%w", err)
+		}
+		list = append(list, &s)
+	}
+	return list, rows.Err()
+}
+
+// GetSnapshot 查询指定版本的配置快照（含内容），不存在时返回 nil
+func (ms *MySQLStorage) GetSnapshot(id int64) (*ConfigSnapshot, error) {
+	query := "SELECT id, kind, hash, source, content, created_at FROM config_snapshots WHERE id = ?"
+	defer ms.queries.observe("GetSnapshot", query, []interface{}{id}, time.Now())
+
+	var s ConfigSnapshot
+	err := ms.db.QueryRow(query, id).Scan(&s.ID, &s.Kind, &s.Hash, &s.Source, &s.Content, &s.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询配置快照失败：%w", err)
+	}
+	return &s, nil
+}
+
+// LatestSnapshot 查询指定类型的最新配置快照（含内容），不存在时返回 nil
+func (ms *MySQLStorage) LatestSnapshot(kind string) (*ConfigSnapshot, error) {
+	query := "SELECT id FROM config_snapshots WHERE kind = ? ORDER BY id DESC LIMIT 1"
+	defer ms.queries.observe("LatestSnapshot", query, []interface{}{kind}, time.Now())
+
+	var id int64
+	err := ms.db.QueryRow(query, kind).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询配置快照失败：%w", err)
+	}
+	return ms.GetSnapshot(id)
+}
@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"servicetelemetry/core"
+)
+
+// TargetStats 按目标统计时间范围内的检查结果：可用率、耗时分位数、故障次数与最近状态
+// 按目标与时间顺序流式读取，内存中只保留当前目标的耗时样本，不会一次加载全部结果
+// since、until：统计的时间范围
+func (ms *MySQLStorage) TargetStats(since, until time.Time) (map[string]*core.TargetStats, error) {
+	return ms.TargetStatsWith(since, until, nil)
+}
+
+// TargetStatsWith 同 TargetStats，并按 inBusinessHours 判断每次检查是否处于目标的工作时间，统计工作时间内的可用率
+// inBusinessHours：判断函数，返回 false 表示该次检查不在工作时间内（或目标未关联日历），为 nil 时不统计
+func (ms *MySQLStorage) TargetStatsWith(since, until time.Time, inBusinessHours func(targetURL string, checkedAt time.Time) bool) (map[string]*core.TargetStats, error) {
+	sql := `
+    SELECT target_url, status, response_time, ssl_cert_expiry, ssl_days_left, checked_at
+    FROM monitor_results
+    WHERE checked_at >= ? AND checked_at < ?
+    ORDER BY target_url, checked_at, id
+    `
+	args := []interface{}{since, until}
+	defer ms.queries.observe("TargetStats", sql, args, time.Now())
+
+	rows, err := ms.db.Query(sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("执行TargetStats SQL失败：%w", err)
+	}
+	defer rows.Close()
+
+	stats := make(map[string]*core.TargetStats)
+	var cur *core.TargetStats
+	var latencies []float64
+	var sumMs float64
+	finish := func() {
+		if cur == nil {
+			return
+		}
+		cur.Uptime = float64(cur.Total-cur.Failed) / float64(cur.Total) * 100
+		if cur.BusinessTotal > 0 {
+			cur.BusinessUptime = float64(cur.BusinessTotal-cur.BusinessFailed) / float64(cur.BusinessTotal) * 100
+		}
+		if len(latencies) > 0 {
+			sort.Float64s(latencies)
+			cur.AvgMs = sumMs / float64(len(latencies))
+			cur.P95Ms = core.Percentile(latencies, 0.95)
+		}
+	}
+
+	for rows.Next() {
+		var url, status, expiry string
+		var responseTime float64
+		var daysLeft *int
+		var checkedAt time.Time
+		if err := rows.Scan(&url, &status, &responseTime, &expiry, &daysLeft, &checkedAt); err != nil {
+			return nil, fmt.Errorf("扫描统计结果失败：%w", err)
+		}
+		if cur == nil || cur.TargetURL != url {
+			finish()
+			cur = &core.TargetStats{TargetURL: url}
+			stats[url] = cur
+			latencies, sumMs = latencies[:0], 0
+		}
+
+		cur.Total++
+		if status == "failed" {
+			cur.Failed++
+			if cur.LastStatus != "failed" {
+				cur.Incidents++
+			}
+		} else {
+			if status == core.StatusDegraded {
+				cur.Degraded++
+			}
+			latencies = append(latencies, responseTime)
+			sumMs += responseTime
+		}
+		if inBusinessHours != nil && inBusinessHours(url, checkedAt) {
+			cur.BusinessTotal++
+			if status == "failed" {
+				cur.BusinessFailed++
+			}
+		}
+		cur.LastStatus = status
+		cur.SSLCertExpiry, cur.SSLDaysLeft = expiry, daysLeft
+	}
+	finish()
+	return stats, rows.Err()
+}
@@ -0,0 +1,142 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Subscription 状态更新订阅，订阅人确认后在相关事件开启 / 更新 / 恢复时收到通知
+type Subscription struct {
+	ID               int64      `json:"id"`          // 订阅唯一标识
+	Channel          string     `json:"channel"`     // 通知方式：email / webhook
+	Address          string     `json:"address"`     // 邮箱地址或 Webhook 地址
+	Tags             []string   `json:"tags"`        // 订阅的组件（目标标签），为空表示订阅全部
+	Confirmed        bool       `json:"confirmed"`   // 是否已确认
+	ConfirmToken     string     `json:"-"`           // 确认令牌
+	UnsubscribeToken string     `json:"-"`           // 退订令牌
+	CreatedAt        time.Time  `json:"createdAt"`   // 订阅时间
+	ConfirmedAt      *time.Time `json:"confirmedAt"` // 确认时间，未确认为空
+}
+
+// subscriptionColumns 订阅查询的字段列表，与 scanSubscription 的扫描顺序一致
+const subscriptionColumns = "id, channel, address, tags, confirmed, confirm_token, unsubscribe_token, created_at, confirmed_at"
+
+// SaveSubscription 新增订阅，保存后回填 ID
+func (ms *MySQLStorage) SaveSubscription(s *Subscription) error {
+	tags, err := json.Marshal(s.Tags)
+	if err != nil {
+		return fmt.Errorf("序列化订阅组件失败：%w", err)
+	}
+	query := `INSERT INTO status_subscriptions (channel, address, tags, confirmed, confirm_token, unsubscribe_token, created_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?)`
+	args := []interface{}{s.Channel, s.Address, string(tags), s.Confirmed, s.ConfirmToken, s.UnsubscribeToken, s.CreatedAt}
+	defer ms.queries.observe("SaveSubscription", query, args, time.Now())
+
+	res, err := ms.db.Exec(query, args...)
+	if err != nil {
+		return fmt.Errorf("保存订阅失败：%w", err)
+	}
+	s.ID, _ = res.LastInsertId()
+	return nil
+}
+
+// ConfirmSubscription 按确认令牌确认订阅，令牌无效时返回 nil；重复确认直接返回订阅
+func (ms *MySQLStorage) ConfirmSubscription(token string) (*Subscription, error) {
+	s, err := ms.subscriptionBy("confirm_token", token)
+	if err != nil || s == nil || s.Confirmed {
+		return s, err
+	}
+
+	now := time.Now()
+	query := "UPDATE status_subscriptions SET confirmed = TRUE, confirmed_at = ? WHERE id = ?"
+	args := []interface{}{now, s.ID}
+	defer ms.queries.observe("ConfirmSubscription", query, args, time.Now())
+	if _, err := ms.db.Exec(query, args...); err != nil {
+		return nil, fmt.Errorf("确认订阅失败：%w", err)
+	}
+	s.Confirmed = true
+	s.ConfirmedAt = &now
+	return s, nil
+}
+
+// DeleteSubscription 按退订令牌删除订阅，返回被删除的订阅，令牌无效时返回 nil
+func (ms *MySQLStorage) DeleteSubscription(token string) (*Subscription, error) {
+	s, err := ms.subscriptionBy("unsubscribe_token", token)
+	if err != nil || s == nil {
+		return s, err
+	}
+
+	query := "DELETE FROM status_subscriptions WHERE id = ?"
+	defer ms.queries.observe("DeleteSubscription", query, []interface{}{s.ID}, time.Now())
+	if _, err := ms.db.Exec(query, s.ID); err != nil {
+		return nil, fmt.Errorf("删除订阅失败：%w", err)
+	}
+	return s, nil
+}
+
+// ListSubscriptions 查询订阅列表，按订阅时间倒序
+// onlyConfirmed：是否只返回已确认的订阅
+func (ms *MySQLStorage) ListSubscriptions(onlyConfirmed bool) ([]*Subscription, error) {
+	query := "SELECT " + subscriptionColumns + " FROM status_subscriptions"
+	if onlyConfirmed {
+		query += " WHERE confirmed = TRUE"
+	}
+	query += " ORDER BY id DESC"
+	defer ms.queries.observe("ListSubscriptions", query, nil, time.Now())
+
+	rows, err := ms.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("查询订阅失败：%w", err)
+	}
+	defer rows.Close()
+
+	var list []*Subscription
+	for rows.Next() {
+		s, err := scanSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, s)
+	}
+	return list, rows.Err()
+}
+
+// subscriptionBy 按令牌字段查询单条订阅，不存在时返回 nil
+func (ms *MySQLStorage) subscriptionBy(column, token string) (*Subscription, error) {
+	if token == "" {
+		return nil, nil
+	}
+	query := "SELECT " + subscriptionColumns + " FROM status_subscriptions WHERE " + column + " = ?"
+	defer ms.queries.observe("GetSubscription", query, []interface{}{token}, time.Now())
+
+	s, err := scanSubscription(ms.db.QueryRow(query, token))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return s, err
+}
+
+// scanSubscription 扫描一行订阅数据
+func scanSubscription(row interface{ Scan(...interface{}) error }) (*Subscription, error) {
+	var s Subscription
+	var tags string
+	var confirmedAt sql.NullTime
+	if err := row.Scan(&s.ID, &s.Channel, &s.Address, &tags, &s.Confirmed, &s.ConfirmToken, &s.UnsubscribeToken, &s.CreatedAt, &confirmedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("扫描订阅失败：%w", err)
+	}
+	if tags != "" {
+		if err := json.Unmarshal([]byte(tags), &s.Tags); err != nil {
+			return nil, fmt.Errorf("解析订阅[%d]组件失败：%w", s.ID, err)
+		}
+	}
+	if confirmedAt.Valid {
+		s.ConfirmedAt = &confirmedAt.Time
+	}
+	return &s, nil
+}
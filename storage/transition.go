@@ -0,0 +1,216 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"servicetelemetry/core"
+)
+
+// Transition 目标状态变化记录（up / down / degraded 之间的每次变化），用于精确计算故障区间
+type Transition struct {
+	ID              int64      `json:"id"`              // 记录唯一标识
+	TargetURL       string     `json:"targetUrl"`       // 目标地址
+	From            string     `json:"from"`            // 变化前状态
+	To              string     `json:"to"`              // 变化后状态
+	At              time.Time  `json:"at"`              // 变化时间（触发变化的检查时间）
+	EndedAt         *time.Time `json:"endedAt"`         // 该状态结束的时间（下一次变化的时间），仍处于该状态时为空
+	ResultID        int64      `json:"resultId"`        // 触发变化的检查结果ID
+	ErrorType       string     `json:"errorType"`       // 触发变化的检查结果的错误类型
+	ErrorMsg        string     `json:"errorMsg"`        // 触发变化的检查结果的错误信息
+	DurationSeconds float64    `json:"durationSeconds"` // 该状态的持续时间（秒），仍处于该状态时计算到查询时刻
+}
+
+// transitionTableSQL 目标状态变化表
+const transitionTableSQL = `
+	CREATE TABLE IF NOT EXISTS target_transitions (
+		id BIGINT AUTO_INCREMENT PRIMARY KEY,
+		target_url VARCHAR(255) NOT NULL,
+		from_state VARCHAR(20) NOT NULL,
+		to_state VARCHAR(20) NOT NULL,
+		at DATETIME NOT NULL,
+		result_id BIGINT NOT NULL,
+		error_type VARCHAR(20) DEFAULT '',
+		error_msg VARCHAR(512) DEFAULT '',
+		INDEX idx_target_at (target_url, at)
+	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+	`
+
+// recordTransition 在保存结果的事务中比较目标的上一状态，状态变化时写入变化记录
+// 必须在更新 target_current_status 之前调用；目标的第一条结果与晚到的旧结果不产生变化记录
+func recordTransition(tx *sql.Tx, resultID int64, result *core.MonitorResult) error {
+	var prevStatus string
+	var lastChecked time.Time
+	err := tx.QueryRow("SELECT status, last_checked_at FROM target_current_status WHERE target_url = ? FOR UPDATE",
+		result.TargetURL).Scan(&prevStatus, &lastChecked)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("查询目标上一状态失败：%w", err)
+	}
+
+	from, to := core.ResultState(prevStatus), core.ResultState(result.Status)
+	if from == to || result.CheckedAt.Before(lastChecked) {
+		return nil
+	}
+	_, err = tx.Exec(`INSERT INTO target_transitions (target_url, from_state, to_state, at, result_id, error_type, error_msg)
+	VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		result.TargetURL, from, to, result.CheckedAt, resultID, result.ErrorType, result.ErrorMsg)
+	if err != nil {
+		return fmt.Errorf("写入状态变化记录失败：%w", err)
+	}
+	return nil
+}
+
+// ListTransitions 查询目标在时间范围内的状态变化记录（按时间升序），并计算每个状态的结束时间与持续时间
+// 范围内最后一条记录的结束时间取其后的第一条变化记录（可能在 until 之后），没有时视为仍处于该状态
+func (ms *MySQLStorage) ListTransitions(targetURL string, since, until time.Time, limit int) ([]*Transition, error) {
+	query := `
+    SELECT id, target_url, from_state, to_state, at, result_id, error_type, error_msg
+    FROM target_transitions
+    WHERE target_url = ? AND at BETWEEN ? AND ?
+    ORDER BY at, id
+    LIMIT ?
+    `
+	args := []interface{}{targetURL, since, until, limit}
+	defer ms.queries.observe("ListTransitions", query, args, time.Now())
+
+	rows, err := ms.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("执行ListTransitions SQL失败：%w", err)
+	}
+	defer rows.Close()
+
+	list := []*Transition{}
+	for rows.Next() {
+		var t Transition
+		if err := rows.Scan(&t.ID, &t.TargetURL, &t.From, &t.To, &t.At, &t.ResultID, &t.ErrorType, &t.ErrorMsg); err != nil {
+			return nil, fmt.Errorf("扫描状态变化记录失败：%w", err)
+		}
+		list = append(list, &t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(list) == 0 {
+		return list, nil
+	}
+
+	for i := 0; i+1 < len(list); i++ {
+		list[i].EndedAt = &list[i+1].At
+	}
+	last := list[len(list)-1]
+	var next time.Time
+	err = ms.db.QueryRow("SELECT at FROM target_transitions WHERE target_url = ? AND (at > ? OR (at = ? AND id > ?)) ORDER BY at, id LIMIT 1",
+		targetURL, last.At, last.At, last.ID).Scan(&next)
+	switch {
+	case err == nil:
+		last.EndedAt = &next
+	case err != sql.ErrNoRows:
+		return nil, fmt.Errorf("查询后续状态变化失败：%w", err)
+	}
+
+	now := time.Now()
+	for _, t := range list {
+		end := now
+		if t.EndedAt != nil {
+			end = *t.EndedAt
+		}
+		t.DurationSeconds = end.Sub(t.At).Seconds()
+	}
+	return list, nil
+}
+
+// StateHistories 查询各目标在 [since, until) 内的状态历史，用于计算故障时长、MTTR 与 MTBF
+// 窗口开始时的状态取窗口前的最后一次变化；窗口前没有变化记录时取窗口内第一次变化的变化前状态；
+// 从未发生过变化的目标取当前状态，并从首次检查时间（即当前状态的开始时间）起统计
+func (ms *MySQLStorage) StateHistories(since, until time.Time) (map[string]*core.StateHistory, error) {
+	histories := make(map[string]*core.StateHistory)
+
+	// 窗口开始前的最后一次变化
+	query := `
+    SELECT t.target_url, t.to_state, t.at
+    FROM target_transitions t
+    JOIN (
+        SELECT target_url, MAX(id) AS max_id
+        FROM target_transitions
+        WHERE at < ?
+        GROUP BY target_url
+    ) prev ON t.id = prev.max_id
+    `
+	if err := ms.scanStates("StateHistories", query, []interface{}{since}, func(url, state string, _ time.Time) {
+		histories[url] = &core.StateHistory{Initial: state, Start: since}
+	}); err != nil {
+		return nil, err
+	}
+
+	// 窗口内的变化
+	query = `
+    SELECT target_url, from_state, to_state, at
+    FROM target_transitions
+    WHERE at >= ? AND at < ?
+    ORDER BY target_url, at, id
+    `
+	args := []interface{}{since, until}
+	defer ms.queries.observe("StateHistories", query, args, time.Now())
+	rows, err := ms.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("执行StateHistories SQL失败：%w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var url, from, to string
+		var at time.Time
+		if err := rows.Scan(&url, &from, &to, &at); err != nil {
+			return nil, fmt.Errorf("扫描状态变化记录失败：%w", err)
+		}
+		h, ok := histories[url]
+		if !ok {
+			h = &core.StateHistory{Initial: from, Start: since}
+			histories[url] = h
+		}
+		h.Changes = append(h.Changes, core.StateChange{At: at, To: to})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// 没有任何变化记录的目标
+	query = "SELECT target_url, status, last_change_at FROM target_current_status WHERE last_checked_at >= ?"
+	err = ms.scanStates("StateHistories", query, []interface{}{since}, func(url, status string, changedAt time.Time) {
+		if _, ok := histories[url]; ok || !changedAt.Before(until) {
+			return
+		}
+		start := since
+		if changedAt.After(since) {
+			start = changedAt
+		}
+		histories[url] = &core.StateHistory{Initial: core.ResultState(status), Start: start}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return histories, nil
+}
+
+// scanStates 执行返回（目标地址、状态、时间）的查询并逐行回调
+func (ms *MySQLStorage) scanStates(op, query string, args []interface{}, fn func(url, state string, at time.Time)) error {
+	defer ms.queries.observe(op, query, args, time.Now())
+	rows, err := ms.db.Query(query, args...)
+	if err != nil {
+		return fmt.Errorf("执行%s SQL失败：%w", op, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var url, state string
+		var at time.Time
+		if err := rows.Scan(&url, &state, &at); err != nil {
+			return fmt.Errorf("扫描目标状态失败：%w", err)
+		}
+		fn(url, state, at)
+	}
+	return rows.Err()
+}
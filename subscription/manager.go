@@ -0,0 +1,259 @@
+package subscription
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"strings"
+	"time"
+
+	"servicetelemetry/alert"
+	"servicetelemetry/config"
+	"servicetelemetry/logger"
+	"servicetelemetry/metrics"
+	"servicetelemetry/storage"
+)
+
+var log = logger.New("subscription")
+
+// 通知方式
+const (
+	ChannelEmail   = "email"
+	ChannelWebhook = "webhook"
+)
+
+// InputError 订阅参数错误
+type InputError struct {
+	Field string // 出错的字段
+	Msg   string // 错误说明
+}
+
+func (e *InputError) Error() string {
+	return e.Msg
+}
+
+// Manager 状态订阅管理器：处理订阅、确认、退订，并在事件开启 / 更新 / 恢复时通知已确认的订阅人
+type Manager struct {
+	cfg     *config.SubscriptionConfig
+	storage *storage.MySQLStorage
+	client  *http.Client
+	record  func(id uint64, entry alert.LogEntry) // 记录事件沟通记录，可为 nil
+}
+
+// NewManager 创建一个新的状态订阅管理器
+// cfg：状态订阅配置
+// storage：数据库存储客户端，保存订阅
+// client：推送 Webhook 使用的 HTTP 客户端，地址由订阅人提交，需在连接时执行地址安全校验且不跟随重定向
+func NewManager(cfg *config.SubscriptionConfig, storage *storage.MySQLStorage, client *http.Client) *Manager {
+	return &Manager{
+		cfg:     cfg,
+		storage: storage,
+		client:  client,
+	}
+}
+
+// Subscribe 新增订阅并发送确认消息（邮件中的确认链接，或推送到 Webhook 的确认地址），确认前不会收到通知
+// channel：通知方式 email / webhook
+// address：邮箱地址或 Webhook 地址（Webhook 地址需由调用方完成安全校验）
+// tags：订阅的组件（目标标签），为空表示订阅全部
+func (m *Manager) Subscribe(channel, address string, tags []string) (*storage.Subscription, error) {
+	address = strings.TrimSpace(address)
+	switch channel {
+	case ChannelEmail:
+		if m.cfg.SMTP.Host == "" {
+			return nil, &InputError{Field: "channel", Msg: "未配置邮件服务器，暂不支持邮件订阅"}
+		}
+		parsed, err := mail.ParseAddress(address)
+		if err != nil {
+			return nil, &InputError{Field: "address", Msg: "无效的邮箱地址：" + address}
+		}
+		address = parsed.Address
+	case ChannelWebhook:
+		if address == "" {
+			return nil, &InputError{Field: "address", Msg: "Webhook 地址不能为空"}
+		}
+	default:
+		return nil, &InputError{Field: "channel", Msg: "不支持的通知方式：" + channel}
+	}
+
+	var cleaned []string
+	for _, t := range tags {
+		if t = strings.TrimSpace(t); t != "" {
+			cleaned = append(cleaned, t)
+		}
+	}
+
+	confirmToken, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+	unsubscribeToken, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+	sub := &storage.Subscription{
+		Channel:          channel,
+		Address:          address,
+		Tags:             cleaned,
+		ConfirmToken:     confirmToken,
+		UnsubscribeToken: unsubscribeToken,
+		CreatedAt:        time.Now(),
+	}
+	if err := m.storage.SaveSubscription(sub); err != nil {
+		return nil, err
+	}
+
+	if err := m.sendConfirmation(sub); err != nil {
+		// 确认消息发不出去的订阅永远无法确认，直接删除，由用户重新订阅
+		if _, delErr := m.storage.DeleteSubscription(sub.UnsubscribeToken); delErr != nil {
+			log.Errorf("删除未能发送确认消息的订阅[%d]失败：%v", sub.ID, delErr)
+		}
+		return nil, fmt.Errorf("发送确认消息失败：%w", err)
+	}
+	log.Infof("新增%s订阅[%d]，等待确认", channel, sub.ID)
+	return sub, nil
+}
+
+// Confirm 确认订阅，令牌无效时返回 nil
+func (m *Manager) Confirm(token string) (*storage.Subscription, error) {
+	return m.storage.ConfirmSubscription(token)
+}
+
+// Unsubscribe 退订，令牌无效时返回 nil
+func (m *Manager) Unsubscribe(token string) (*storage.Subscription, error) {
+	return m.storage.DeleteSubscription(token)
+}
+
+// List 返回全部订阅（含未确认）
+func (m *Manager) List() ([]*storage.Subscription, error) {
+	return m.storage.ListSubscriptions(false)
+}
+
+// SetCommunicationLog 设置事件沟通记录的写入函数，每次通知订阅人后按通知方式记录一条状态页更新
+func (m *Manager) SetCommunicationLog(record func(id uint64, entry alert.LogEntry)) {
+	m.record = record
+}
+
+// HandleIncident 事件监听器：事件开启 / 更新 / 恢复时通知订阅了相关组件的已确认订阅人
+func (m *Manager) HandleIncident(change string, incident *alert.Incident) {
+	subs, err := m.storage.ListSubscriptions(true)
+	if err != nil {
+		log.Errorf("查询订阅失败，事件[%d]未通知订阅人：%v", incident.ID, err)
+		return
+	}
+
+	msg := newMessage(change, incident)
+	sent, failed := make(map[string]int), make(map[string]int)
+	lastErr := make(map[string]error)
+	var channels []string
+	for _, sub := range subs {
+		if !matches(sub.Tags, incident.Tags) {
+			continue
+		}
+		if _, ok := sent[sub.Channel]; !ok {
+			channels = append(channels, sub.Channel)
+		}
+		sent[sub.Channel]++
+		if err := m.send(sub, msg); err != nil {
+			failed[sub.Channel]++
+			lastErr[sub.Channel] = err
+			log.Warnf("通知订阅[%d]事件[%d]失败：%v", sub.ID, incident.ID, err)
+		}
+	}
+
+	if m.record == nil {
+		return
+	}
+	for _, channel := range channels {
+		entry := alert.LogEntry{
+			Time:      time.Now(),
+			Kind:      alert.LogStatusPage,
+			Channel:   channel,
+			Recipient: fmt.Sprintf("%d 位订阅人", sent[channel]),
+			Message:   msg.Subject,
+		}
+		if failed[channel] > 0 {
+			entry.Error = fmt.Sprintf("%d 位发送失败，最近一次：%v", failed[channel], lastErr[channel])
+		}
+		m.record(incident.ID, entry)
+	}
+}
+
+// send 按订阅的通知方式发送事件通知
+func (m *Manager) send(sub *storage.Subscription, msg *message) error {
+	unsubscribe := m.link("unsubscribe", sub.UnsubscribeToken)
+	if sub.Channel == ChannelEmail {
+		return m.deliver(sub, func() error {
+			return m.sendMail(sub.Address, msg.Subject, msg.Text+"\n\n退订："+unsubscribe)
+		})
+	}
+	return m.deliver(sub, func() error {
+		return m.postWebhook(sub.Address, map[string]interface{}{
+			"type":           "incident." + msg.Change,
+			"subject":        msg.Subject,
+			"text":           msg.Text,
+			"incident":       msg.Incident,
+			"unsubscribeUrl": unsubscribe,
+		})
+	})
+}
+
+// sendConfirmation 发送订阅确认消息
+func (m *Manager) sendConfirmation(sub *storage.Subscription) error {
+	confirm := m.link("confirm", sub.ConfirmToken)
+	unsubscribe := m.link("unsubscribe", sub.UnsubscribeToken)
+	if sub.Channel == ChannelEmail {
+		body := fmt.Sprintf("您订阅了%s的服务状态更新。\n\n请点击以下链接确认订阅：\n%s\n\n如非本人操作，请忽略本邮件或点击退订：\n%s",
+			describeComponents(sub.Tags), confirm, unsubscribe)
+		return m.deliver(sub, func() error {
+			return m.sendMail(sub.Address, "请确认服务状态订阅", body)
+		})
+	}
+	return m.deliver(sub, func() error {
+		return m.postWebhook(sub.Address, map[string]interface{}{
+			"type":           "subscription.confirm",
+			"components":     sub.Tags,
+			"confirmUrl":     confirm,
+			"unsubscribeUrl": unsubscribe,
+		})
+	})
+}
+
+// deliver 执行一次投递并按订阅的通知方式记录投递指标
+func (m *Manager) deliver(sub *storage.Subscription, send func() error) error {
+	start := time.Now()
+	err := send()
+	metrics.ObserveDelivery(metrics.KindSubscription, sub.Channel, err, start)
+	return err
+}
+
+// link 生成确认 / 退订链接
+func (m *Manager) link(action, token string) string {
+	return strings.TrimRight(m.cfg.PublicURL, "/") + "/api/v1/status/subscriptions/" + action + "?token=" + token
+}
+
+// matches 判断订阅的组件与事件涉及的组件是否有交集，订阅为空表示订阅全部
+func matches(subscribed, incidentTags []string) bool {
+	if len(subscribed) == 0 {
+		return true
+	}
+	for _, s := range subscribed {
+		for _, t := range incidentTags {
+			if s == t {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// newToken 生成 32 位十六进制随机令牌
+func newToken() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("生成订阅令牌失败：%w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
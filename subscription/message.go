@@ -0,0 +1,81 @@
+package subscription
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"servicetelemetry/alert"
+)
+
+// PublicIncident 对外公开的事件信息，只包含组件名称，不暴露内部目标地址
+type PublicIncident struct {
+	ID         uint64     `json:"id"`         // 事件ID
+	Status     string     `json:"status"`     // 事件状态：firing / resolved
+	Components []string   `json:"components"` // 受影响的组件（目标标签）
+	Remaining  int        `json:"remaining"`  // 仍处于异常状态的目标数
+	OpenedAt   time.Time  `json:"openedAt"`   // 事件开始时间
+	ResolvedAt *time.Time `json:"resolvedAt"` // 事件恢复时间
+}
+
+// message 事件通知内容
+type message struct {
+	Change   string
+	Subject  string
+	Text     string
+	Incident *PublicIncident
+}
+
+// NewPublicIncident 将内部事件转换为对外公开的事件信息
+func NewPublicIncident(incident *alert.Incident) *PublicIncident {
+	return &PublicIncident{
+		ID:         incident.ID,
+		Status:     incident.Status,
+		Components: incident.Tags,
+		Remaining:  incident.Remaining,
+		OpenedAt:   incident.OpenedAt,
+		ResolvedAt: incident.ResolvedAt,
+	}
+}
+
+// newMessage 根据事件变化生成通知内容
+func newMessage(change string, incident *alert.Incident) *message {
+	components := describeComponents(incident.Tags)
+	lines := []string{
+		fmt.Sprintf("事件编号：#%d", incident.ID),
+		"受影响组件：" + components,
+		"开始时间：" + incident.OpenedAt.Format("2006-01-02 15:04:05"),
+	}
+
+	var subject string
+	switch change {
+	case alert.IncidentOpened:
+		subject = fmt.Sprintf("【服务异常】%s出现故障", components)
+		lines = append(lines, "我们正在处理中，恢复后将再次通知您。")
+	case alert.IncidentUpdated:
+		subject = fmt.Sprintf("【处理进展】%s部分服务已恢复", components)
+		lines = append(lines, fmt.Sprintf("仍有 %d 个服务异常，我们正在继续处理。", incident.Remaining))
+	default:
+		subject = fmt.Sprintf("【已恢复】%s已恢复正常", components)
+		if incident.ResolvedAt != nil {
+			lines = append(lines,
+				"恢复时间："+incident.ResolvedAt.Format("2006-01-02 15:04:05"),
+				"持续时长："+incident.ResolvedAt.Sub(incident.OpenedAt).Round(time.Second).String())
+		}
+	}
+
+	return &message{
+		Change:   change,
+		Subject:  subject,
+		Text:     strings.Join(lines, "\n"),
+		Incident: NewPublicIncident(incident),
+	}
+}
+
+// describeComponents 组件列表的可读描述
+func describeComponents(tags []string) string {
+	if len(tags) == 0 {
+		return "全部服务"
+	}
+	return "「" + strings.Join(tags, "、") + "」"
+}
@@ -0,0 +1,88 @@
+package subscription
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sendMail 通过 SMTP 发送纯文本邮件，服务器支持时使用 STARTTLS
+func (m *Manager) sendMail(to, subject, body string) error {
+	sc := m.cfg.SMTP
+	addr := net.JoinHostPort(sc.Host, strconv.Itoa(sc.Port))
+	conn, err := net.DialTimeout("tcp", addr, m.cfg.SendTimeout)
+	if err != nil {
+		return fmt.Errorf("连接邮件服务器失败：%w", err)
+	}
+	conn.SetDeadline(time.Now().Add(m.cfg.SendTimeout))
+
+	client, err := smtp.NewClient(conn, sc.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("连接邮件服务器失败：%w", err)
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: sc.Host}); err != nil {
+			return fmt.Errorf("STARTTLS 失败：%w", err)
+		}
+	}
+	if sc.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", sc.Username, sc.Password, sc.Host)); err != nil {
+			return fmt.Errorf("邮件服务器认证失败：%w", err)
+		}
+	}
+	if err := client.Mail(sc.From); err != nil {
+		return fmt.Errorf("设置发件人失败：%w", err)
+	}
+	if err := client.Rcpt(to); err != nil {
+		return fmt.Errorf("设置收件人失败：%w", err)
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("发送邮件失败：%w", err)
+	}
+	headers := []string{
+		"From: " + sc.From,
+		"To: " + to,
+		"Subject: " + mime.BEncoding.Encode("UTF-8", subject),
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=UTF-8",
+		"Content-Transfer-Encoding: 8bit",
+		"Date: " + time.Now().Format(time.RFC1123Z),
+	}
+	msg := strings.Join(headers, "\r\n") + "\r\n\r\n" + strings.ReplaceAll(body, "\n", "\r\n")
+	if _, err := w.Write([]byte(msg)); err != nil {
+		return fmt.Errorf("发送邮件失败：%w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("发送邮件失败：%w", err)
+	}
+	return client.Quit()
+}
+
+// postWebhook 以 JSON 格式推送到订阅的 Webhook 地址
+func (m *Manager) postWebhook(url string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("序列化通知失败：%w", err)
+	}
+	resp, err := m.client.Post(url, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("推送Webhook失败：%w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("Webhook返回异常状态码：%d", resp.StatusCode)
+	}
+	return nil
+}